@@ -3,9 +3,13 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,16 +20,121 @@ type Config struct {
 	Port     string
 	TempDir  string
 	CacheDir string
+	// TTSCacheDir holds synthesized chunks keyed by provider, voice, speed and text ("off" disables)
+	TTSCacheDir string
+	// TTSAuditDir keeps a JSON-lines record of every TTS provider call per job ("off" disables)
+	TTSAuditDir string
 
 	// Output directory for saved videos
 	OutputDir string
 
 	// API Keys Pool
-	TTSAPIKeys       []string
-	ElevenLabsAPIKey string
-	VideoAPIKeys     []string
-	GeminiAPIKeys    []string
-	LocalHubURL      string
+	TTSAPIKeys        []string
+	ElevenLabsAPIKeys []string
+	ElevenLabsModel   string
+	AzureSpeechKeys   []string
+	AzureSpeechRegion string
+	VideoAPIKeys      []string
+	GeminiAPIKeys     []string
+	LocalHubURL       string
+
+	// Pika Labs text-to-video for video_source "ai" (keys in VIDEO_API_KEYS); VideoRateLimit
+	// caps generation requests per second per key
+	PikaAPIURL     string
+	VideoRateLimit float64 // also applies to the Runway and Kling keys
+
+	// Runway ML text-to-video, video_provider "runway"
+	RunwayAPIKeys []string
+	RunwayModel   string
+	RunwayAPIURL  string
+
+	// Kling AI text-to-video, video_provider "kling". Keys are accessKey:secretKey pairs and
+	// belong to one region: "global" (Singapore) or "cn"; KlingAPIURL overrides the endpoint.
+	KlingAPIKeys []string
+	KlingModel   string
+	KlingRegion  string
+	KlingAPIURL  string
+
+	// Still images for video_source "images": DALL·E with the OpenAI keys, Stability AI, or a
+	// local Stable Diffusion WebUI (SDXL) at SDWebUIURL
+	OpenAIImageModel string
+	StabilityAPIKeys []string
+	StabilityEngine  string
+	SDWebUIURL       string
+
+	// Visual prompts for AI clips whose segment has no visual_description: VisualPromptLLM is
+	// "openai" (OPENAI_API_KEYS), "claude" (AnthropicAPIKeys), "gemini" (GEMINI_API_KEYS) or
+	// "ollama" (OllamaURL); empty builds prompts from a template. VisualPromptModel overrides the
+	// provider's default model.
+	VisualPromptLLM   string
+	VisualPromptModel string
+	AnthropicAPIKeys  []string
+	OllamaURL         string
+
+	// Stock and local footage is ranked by embedding similarity to the segment when
+	// EmbeddingsProvider is "openai" (OPENAI_API_KEYS) or "ollama" (OllamaURL); empty keeps the
+	// keyword ranking. EmbeddingsModel overrides the provider's default model.
+	EmbeddingsProvider string
+	EmbeddingsModel    string
+
+	// PromptTemplate replaces the built-in visual prompt template (placeholders {text}, {style},
+	// {themes}, {branding}) and PromptBranding fills {branding}; tenants may set their own
+	// through PUT /api/prompt-template, persisted in PromptTemplateFile (empty keeps them in memory)
+	PromptTemplate     string
+	PromptBranding     string
+	PromptTemplateFile string
+
+	// OpenAI speech (or an OpenAI-compatible server at OpenAIBaseURL)
+	OpenAIAPIKeys  []string
+	OpenAITTSModel string
+	OpenAIBaseURL  string
+
+	// AWS credentials (Amazon Polly); the standard AWS_* variables
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	AWSRegion          string
+	PollyEngine        string // "neural" or "standard"
+
+	// Local Piper TTS: either a piper HTTP server or the piper binary with a voice model
+	PiperURL    string
+	PiperBinary string
+	PiperModel  string // path to a .onnx voice
+
+	// FPT.AI resilience: the circuit opens when more than FPTCircuitFailureRatio of the calls in
+	// FPTCircuitWindow failed (min FPTCircuitMinCalls) and stays open for FPTCircuitCooldown.
+	// Retries across all chunks are capped at FPTRetryBudgetRatio per call (reserve FPTRetryBudget).
+	FPTCircuitFailureRatio float64
+	FPTCircuitMinCalls     int
+	FPTCircuitWindow       time.Duration
+	FPTCircuitCooldown     time.Duration
+	FPTRetryBudgetRatio    float64
+	FPTRetryBudget         int
+
+	// FPTCallbackSecret enables FPT.AI completion callbacks to PUBLIC_BASE_URL/api/internal/tts-callback,
+	// authenticated by this token; without it every request is polled
+	FPTCallbackSecret string
+
+	// TTSRateLimits caps requests per second per API key for the key-pooled TTS providers
+	// (fpt, elevenlabs, azure, openai). Providers without an entry are not limited.
+	TTSRateLimits map[string]float64
+
+	// TTSFallbackProvider synthesizes chunks while the requested provider's circuit is open
+	TTSFallbackProvider string
+
+	// Self-hosted Coqui XTTS server (xtts-api-server)
+	XTTSURL            string
+	XTTSLanguage       string
+	XTTSDefaultSpeaker string // reference used when the request names an FPT voice
+
+	// Audio QA: a local Whisper (whisper.cpp server or CLI) transcribes each TTS chunk and
+	// chunks less similar to their text than AudioQAThreshold are regenerated. It also times
+	// the words of karaoke subtitles.
+	WhisperURL       string
+	WhisperBinary    string
+	WhisperModel     string // path to a ggml model
+	WhisperLanguage  string
+	AudioQAThreshold float64
 
 	// Processing Settings
 	MaxTextLength        int
@@ -39,18 +148,150 @@ type Config struct {
 	VideoResolution string
 	VideoFPS        int
 
+	// FFmpegHWAccel moves libx264 encodes to a hardware encoder: "auto" (default) picks the first
+	// one that works here, "none" keeps libx264, or "nvenc", "vaapi" or "videotoolbox"
+	FFmpegHWAccel string
+	VAAPIDevice   string        // render node for vaapi
+	FFmpegTimeout time.Duration // kills one ffmpeg run after this long; 0 = no limit
+	// FFmpegAccurateTrim trims clips to the exact frame, re-encoding the GOP of each cut,
+	// instead of stream-copying to the nearest keyframe
+	FFmpegAccurateTrim bool
+	// FFmpegEncodeChunks is how many parts of a long video the burned-in subtitle and output
+	// format encodes run in parallel; one per CPU core by default, 1 encodes in one run
+	FFmpegEncodeChunks int
+	// FFmpegDryRun logs each ffmpeg command line of a job instead of running it
+	FFmpegDryRun bool
+
+	// Narration loudness (two-pass EBU R128 loudnorm); YouTube normalizes to -14 LUFS
+	AudioLoudnessTarget float64 // integrated LUFS
+	AudioTruePeak       float64 // dBTP ceiling
+	AudioLoudnessRange  float64 // LU
+	// AudioNormalizeFinalMix normalizes the finished video's soundtrack to the same target, so
+	// music and intro/outro play at the narration's level
+	AudioNormalizeFinalMix bool
+
+	// MusicVolume scales background music (GenerateRequest.music_track) under the narration
+	MusicVolume float64
+
 	// Transition Settings
 	AudioCrossfadeDuration  float64
 	VideoTransitionType     string
 	VideoTransitionDuration float64
+	TitleCardDuration       float64 // seconds of each section's title card (GenerateRequest.title_cards)
+	EndScreenDuration       float64 // seconds of a generated end screen (GenerateRequest.end_screen)
+
+	// Channel look of subtitles (GenerateRequest.subtitle_style overrides each field); empty or
+	// zero keeps the built-in style. Colors are "#RRGGBB", sizes pixels of a 1080p frame,
+	// SubtitlePosition "bottom", "middle" or "top", SubtitleMaxLines 0 (no limit) to 4,
+	// SubtitleMaxLength the characters per cue of split scripts (0 = 100).
+	// SubtitleKaraoke highlights words as they are spoken, in SubtitleHighlightColor.
+	SubtitleFont           string
+	SubtitleFontSize       int
+	SubtitlePrimaryColor   string
+	SubtitleOutlineColor   string
+	SubtitleMarginV        int
+	SubtitlePosition       string
+	SubtitleBox            bool
+	SubtitleBoxColor       string
+	SubtitleMaxLines       int
+	SubtitleMaxLength      int
+	SubtitleKaraoke        bool
+	SubtitleHighlightColor string
+
+	// SubtitleTranslator translates subtitles into GenerateRequest.subtitle_languages: "deepl"
+	// (DeepLAPIKeys), "google" (GoogleTranslateAPIKeys) or "llm" (the VISUAL_PROMPT_LLM model);
+	// empty disables translation
+	SubtitleTranslator     string
+	DeepLAPIKeys           []string
+	GoogleTranslateAPIKeys []string
 
 	PexelsAPIKey      string
 	HuggingFaceTokens []string
 
+	// Stock clip library: "pexels" (default), "pixabay", "coverr", or "aggregate" to search
+	// every library with a key and merge the results
+	StockProvider string
+	PixabayAPIKey string
+	CoverrAPIKey  string
+
+	// Stock safety screening: clips and photos whose tags or titles contain a STOCK_BLOCKLIST
+	// term are skipped, and with SAFETY_CLASSIFIER_URL every download is scored by a local
+	// NSFW classifier and dropped at SAFETY_THRESHOLD or above. Both are off when empty.
+	StockBlocklist      []string
+	SafetyClassifierURL string
+	SafetyThreshold     float64
+
+	// Cleanup of downloaded stock clips, for soft low-bitrate SD fallbacks: STOCK_DENOISE is the
+	// hqdn3d strength (4 is ffmpeg's default), STOCK_SHARPEN the unsharp amount (0.5-1 suits
+	// upscaled SD). 0 turns each off.
+	StockDenoise float64
+	StockSharpen float64
+
+	// BrollDir is the folder of the user's own clips for video_source "local"; empty disables it
+	BrollDir string
+	// BrollUploadDir stores clips uploaded via POST /api/assets/broll; empty disables uploads.
+	// Render workers must see the same folder.
+	BrollUploadDir string
+	// IntroOutroDir stores intros and outros uploaded via POST /api/assets/intros and outros;
+	// empty disables uploads. Render workers must see the same folder.
+	IntroOutroDir string
+
 	// Rate Limiting
 	MaxConcurrentTTSRequests   int
 	MaxConcurrentVideoRequests int
 	RetryDelaySeconds          int
+
+	// Job retention: finished jobs older than JobRetention are expired and their files removed (0 disables).
+	// Downloads never shorten it; DELETE /api/jobs/:job_id and STORAGE_DELETE_LOCAL remove files sooner.
+	JobRetention     time.Duration
+	JobSweepInterval time.Duration
+
+	// Output storage: StorageBackend "s3" (S3 or an S3-compatible server such as MinIO at
+	// S3Endpoint) or "gcs" (Google Cloud Storage) uploads each finished video and its artifacts
+	// and links them from the job status; empty serves them from TEMP_DIR only. Uploaded objects outlive JOB_RETENTION: expire them
+	// with a bucket lifecycle rule.
+	StorageBackend     string
+	StoragePrefix      string // object key prefix, e.g. "renders/"
+	StorageDeleteLocal bool   // remove a job's temp files once uploaded; it can then not be re-rendered
+	// StorageURLTTL is how long the presigned URLs of uploaded files in job statuses stay valid
+	// (at most 7 days); 0 links the plain object URLs, for public buckets and CDNs
+	StorageURLTTL      time.Duration
+	S3Bucket           string
+	S3Region           string
+	S3Endpoint         string // e.g. http://minio:9000, addressed path-style; empty for AWS
+	S3PublicURL        string // base URL the objects are served from (CDN, public bucket); default the bucket's
+	S3AccessKeyID      string // default AWS_ACCESS_KEY_ID
+	S3SecretAccessKey  string
+	GCSBucket          string
+	GCSCredentialsFile string // service account JSON key; default GOOGLE_APPLICATION_CREDENTIALS
+	GCSPublicURL       string // default https://storage.googleapis.com/<bucket>
+
+	// Idempotency-Key headers on /api/generate are remembered for this long
+	IdempotencyKeyTTL time.Duration
+
+	// FeedPollInterval is how often RSS/Atom subscriptions are checked for new items (0 disables)
+	FeedPollInterval time.Duration
+
+	// LexiconFile persists the per-tenant pronunciation dictionaries (empty keeps them in memory)
+	LexiconFile string
+	// VoicesFile persists the per-tenant cloned voices (empty keeps them in memory)
+	VoicesFile string
+
+	// AdminToken guards /api/admin/* routes (empty disables them)
+	AdminToken string
+
+	// Distributed rendering: MODE is "all" (single process, default), "api" (HTTP front that
+	// dispatches over NATS) or "worker" (renders dispatched jobs). API and workers must share
+	// TEMP_DIR and OUTPUT_DIR (e.g. an NFS or EFS mount).
+	Mode               string
+	NATSURL            string
+	WorkerConcurrency  int
+	WorkerDispatchWait time.Duration
+
+	// Webhooks
+	PublicBaseURL     string
+	WebhookSecret     string
+	WebhookMaxRetries int
 }
 
 // LoadConfig loads configuration from environment variables
@@ -65,11 +306,79 @@ func LoadConfig() (*Config, error) {
 		CacheDir:  getEnv("CACHE_DIR", "./cache"),
 
 		// Parse API keys
-		TTSAPIKeys:       parseAPIKeys(getEnv("TTS_API_KEYS", "")),
-		ElevenLabsAPIKey: getEnv("ELEVENLABS_API_KEY", ""),
-		VideoAPIKeys:     parseAPIKeys(getEnv("VIDEO_API_KEYS", "")),
-		GeminiAPIKeys:    parseAPIKeys(getEnv("GEMINI_API_KEYS", "")),
-		LocalHubURL:      getEnv("LOCAL_HUB_URL", "http://localhost:5000"),
+		TTSAPIKeys: parseAPIKeys(getEnv("TTS_API_KEYS", "")),
+		// ELEVENLABS_API_KEYS takes a comma-separated pool; ELEVENLABS_API_KEY is the single-key form
+		ElevenLabsAPIKeys: parseAPIKeys(getEnv("ELEVENLABS_API_KEYS", getEnv("ELEVENLABS_API_KEY", ""))),
+		ElevenLabsModel:   getEnv("ELEVENLABS_MODEL_ID", "eleven_multilingual_v2"),
+		AzureSpeechKeys:   parseAPIKeys(getEnv("AZURE_SPEECH_KEYS", "")),
+		AzureSpeechRegion: getEnv("AZURE_SPEECH_REGION", "southeastasia"),
+		VideoAPIKeys:      parseAPIKeys(getEnv("VIDEO_API_KEYS", "")),
+		GeminiAPIKeys:     parseAPIKeys(getEnv("GEMINI_API_KEYS", "")),
+		LocalHubURL:       getEnv("LOCAL_HUB_URL", "http://localhost:5000"),
+
+		PikaAPIURL:     getEnv("PIKA_API_URL", ""),
+		VideoRateLimit: getEnvAsFloat("VIDEO_RATE_LIMIT", 0.1),
+
+		RunwayAPIKeys: parseAPIKeys(getEnv("RUNWAY_API_KEYS", "")),
+		RunwayModel:   getEnv("RUNWAY_MODEL", "gen3a_turbo"),
+		RunwayAPIURL:  getEnv("RUNWAY_API_URL", ""),
+
+		KlingAPIKeys: parseAPIKeys(getEnv("KLING_API_KEYS", "")),
+		KlingModel:   getEnv("KLING_MODEL", "kling-v1"),
+		KlingRegion:  strings.ToLower(getEnv("KLING_REGION", "global")),
+		KlingAPIURL:  getEnv("KLING_API_URL", ""),
+
+		OpenAIImageModel: getEnv("OPENAI_IMAGE_MODEL", "dall-e-3"),
+		StabilityAPIKeys: parseAPIKeys(getEnv("STABILITY_API_KEYS", getEnv("STABILITY_API_KEY", ""))),
+		StabilityEngine:  getEnv("STABILITY_ENGINE", "stable-diffusion-xl-1024-v1-0"),
+		SDWebUIURL:       getEnv("SD_WEBUI_URL", ""),
+
+		VisualPromptLLM:   strings.ToLower(getEnv("VISUAL_PROMPT_LLM", "")),
+		VisualPromptModel: getEnv("VISUAL_PROMPT_MODEL", ""),
+		AnthropicAPIKeys:  parseAPIKeys(getEnv("ANTHROPIC_API_KEYS", getEnv("ANTHROPIC_API_KEY", ""))),
+		OllamaURL:         getEnv("OLLAMA_URL", "http://localhost:11434"),
+
+		EmbeddingsProvider: strings.ToLower(getEnv("EMBEDDINGS_PROVIDER", "")),
+		EmbeddingsModel:    getEnv("EMBEDDINGS_MODEL", ""),
+
+		PromptTemplate:     getEnv("PROMPT_TEMPLATE", ""),
+		PromptBranding:     getEnv("PROMPT_BRANDING", ""),
+		PromptTemplateFile: getEnv("PROMPT_TEMPLATE_FILE", ""),
+
+		OpenAIAPIKeys:  parseAPIKeys(getEnv("OPENAI_API_KEYS", getEnv("OPENAI_API_KEY", ""))),
+		OpenAITTSModel: getEnv("OPENAI_TTS_MODEL", "tts-1"),
+		OpenAIBaseURL:  getEnv("OPENAI_BASE_URL", ""),
+
+		AWSAccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+		AWSSessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+		AWSRegion:          getEnv("AWS_REGION", "us-east-1"),
+		PollyEngine:        strings.ToLower(getEnv("POLLY_ENGINE", "neural")),
+
+		PiperURL:    getEnv("PIPER_URL", ""),
+		PiperBinary: getEnv("PIPER_BINARY", "piper"),
+		PiperModel:  getEnv("PIPER_MODEL", ""),
+
+		FPTCircuitFailureRatio: getEnvAsFloat("FPT_CIRCUIT_FAILURE_RATIO", 0.5),
+		FPTCircuitMinCalls:     getEnvAsInt("FPT_CIRCUIT_MIN_CALLS", 10),
+		FPTCircuitWindow:       getEnvAsDuration("FPT_CIRCUIT_WINDOW", 2*time.Minute),
+		FPTCircuitCooldown:     getEnvAsDuration("FPT_CIRCUIT_COOLDOWN", time.Minute),
+		FPTRetryBudgetRatio:    getEnvAsFloat("FPT_RETRY_BUDGET_RATIO", 0.2),
+		FPTRetryBudget:         getEnvAsInt("FPT_RETRY_BUDGET", 50),
+
+		FPTCallbackSecret: getEnv("FPT_CALLBACK_SECRET", ""),
+
+		TTSFallbackProvider: strings.ToLower(getEnv("TTS_FALLBACK_PROVIDER", "")),
+
+		XTTSURL:            getEnv("XTTS_URL", ""),
+		XTTSLanguage:       getEnv("XTTS_LANGUAGE", "en"),
+		XTTSDefaultSpeaker: getEnv("XTTS_DEFAULT_SPEAKER", "female"),
+
+		WhisperURL:       getEnv("WHISPER_URL", ""),
+		WhisperBinary:    getEnv("WHISPER_BINARY", "whisper-cli"),
+		WhisperModel:     getEnv("WHISPER_MODEL", ""),
+		WhisperLanguage:  getEnv("WHISPER_LANGUAGE", "auto"),
+		AudioQAThreshold: getEnvAsFloat("AUDIO_QA_THRESHOLD", 0.6),
 
 		// Processing settings
 		MaxTextLength:        getEnvAsInt("MAX_TEXT_LENGTH", 50000),
@@ -82,19 +391,123 @@ func LoadConfig() (*Config, error) {
 		VideoBitrate:    getEnv("VIDEO_BITRATE", "8M"),
 		VideoResolution: getEnv("VIDEO_RESOLUTION", "1920x1080"),
 		VideoFPS:        getEnvAsInt("VIDEO_FPS", 30),
+		MusicVolume:     getEnvAsFloat("MUSIC_VOLUME", 0.12),
+
+		FFmpegHWAccel: getEnv("FFMPEG_HWACCEL", "auto"),
+		VAAPIDevice:   getEnv("FFMPEG_VAAPI_DEVICE", "/dev/dri/renderD128"),
+		FFmpegTimeout: getEnvAsDuration("FFMPEG_TIMEOUT", 2*time.Hour),
+
+		FFmpegAccurateTrim: getEnv("FFMPEG_ACCURATE_TRIM", "false") == "true",
+		FFmpegEncodeChunks: getEnvAsInt("FFMPEG_ENCODE_CHUNKS", runtime.NumCPU()),
+		FFmpegDryRun:       getEnv("FFMPEG_DRY_RUN", "false") == "true",
+
+		AudioLoudnessTarget: getEnvAsFloat("AUDIO_LOUDNESS_TARGET", -14),
+		AudioTruePeak:       getEnvAsFloat("AUDIO_TRUE_PEAK", -1.5),
+		AudioLoudnessRange:  getEnvAsFloat("AUDIO_LOUDNESS_RANGE", 11),
+
+		AudioNormalizeFinalMix: getEnv("AUDIO_NORMALIZE_FINAL_MIX", "true") == "true",
 
 		// Transition settings
 		AudioCrossfadeDuration:  getEnvAsFloat("AUDIO_CROSSFADE_DURATION", 0.0),
 		VideoTransitionType:     getEnv("VIDEO_TRANSITION_TYPE", "fade"),
 		VideoTransitionDuration: getEnvAsFloat("VIDEO_TRANSITION_DURATION", 0.5),
+		TitleCardDuration:       getEnvAsFloat("TITLE_CARD_DURATION", 3.0),
+		EndScreenDuration:       getEnvAsFloat("END_SCREEN_DURATION", 20.0),
+
+		SubtitleFont:           getEnv("SUBTITLE_FONT", ""),
+		SubtitleFontSize:       getEnvAsInt("SUBTITLE_FONT_SIZE", 0),
+		SubtitlePrimaryColor:   getEnv("SUBTITLE_PRIMARY_COLOR", ""),
+		SubtitleOutlineColor:   getEnv("SUBTITLE_OUTLINE_COLOR", ""),
+		SubtitleMarginV:        getEnvAsInt("SUBTITLE_MARGIN_V", 0),
+		SubtitlePosition:       strings.ToLower(getEnv("SUBTITLE_POSITION", "")),
+		SubtitleBox:            getEnv("SUBTITLE_BOX", "false") == "true",
+		SubtitleBoxColor:       getEnv("SUBTITLE_BOX_COLOR", ""),
+		SubtitleMaxLines:       getEnvAsInt("SUBTITLE_MAX_LINES", 0),
+		SubtitleMaxLength:      getEnvAsInt("SUBTITLE_MAX_LENGTH", 0),
+		SubtitleKaraoke:        getEnv("SUBTITLE_KARAOKE", "false") == "true",
+		SubtitleHighlightColor: getEnv("SUBTITLE_HIGHLIGHT_COLOR", ""),
+
+		SubtitleTranslator:     strings.ToLower(getEnv("SUBTITLE_TRANSLATOR", "")),
+		DeepLAPIKeys:           parseAPIKeys(getEnv("DEEPL_API_KEYS", getEnv("DEEPL_API_KEY", ""))),
+		GoogleTranslateAPIKeys: parseAPIKeys(getEnv("GOOGLE_TRANSLATE_API_KEYS", getEnv("GOOGLE_TRANSLATE_API_KEY", ""))),
 
 		PexelsAPIKey:      getEnv("PEXELS_API_KEY", ""),
 		HuggingFaceTokens: parseAPIKeys(getEnv("HF_TOKEN", "")),
 
+		StockProvider: strings.ToLower(getEnv("STOCK_PROVIDER", "pexels")),
+		PixabayAPIKey: getEnv("PIXABAY_API_KEY", ""),
+		CoverrAPIKey:  getEnv("COVERR_API_KEY", ""),
+
+		StockBlocklist:      parseAPIKeys(getEnv("STOCK_BLOCKLIST", "")),
+		SafetyClassifierURL: getEnv("SAFETY_CLASSIFIER_URL", ""),
+		SafetyThreshold:     getEnvAsFloat("SAFETY_THRESHOLD", 0.7),
+
+		StockDenoise: getEnvAsFloat("STOCK_DENOISE", 0),
+		StockSharpen: getEnvAsFloat("STOCK_SHARPEN", 0),
+
+		BrollDir:       getEnv("BROLL_DIR", ""),
+		BrollUploadDir: getEnv("BROLL_UPLOAD_DIR", ""),
+		IntroOutroDir:  getEnv("INTRO_OUTRO_DIR", ""),
+
 		// Rate limiting
 		MaxConcurrentTTSRequests:   getEnvAsInt("MAX_CONCURRENT_TTS_REQUESTS", 1),
 		MaxConcurrentVideoRequests: getEnvAsInt("MAX_CONCURRENT_VIDEO_REQUESTS", 5),
 		RetryDelaySeconds:          getEnvAsInt("RETRY_DELAY_SECONDS", 60),
+
+		// Job retention
+		JobRetention:     getEnvAsDuration("JOB_RETENTION", 24*time.Hour),
+		JobSweepInterval: getEnvAsDuration("JOB_SWEEP_INTERVAL", 10*time.Minute),
+
+		// Output storage
+		StorageBackend:     strings.ToLower(getEnv("STORAGE_BACKEND", "")),
+		StoragePrefix:      getEnv("STORAGE_PREFIX", ""),
+		StorageDeleteLocal: getEnv("STORAGE_DELETE_LOCAL", "false") == "true",
+		StorageURLTTL:      getEnvAsDuration("STORAGE_URL_TTL", time.Hour),
+		S3Bucket:           getEnv("S3_BUCKET", ""),
+		S3Region:           getEnv("S3_REGION", getEnv("AWS_REGION", "us-east-1")),
+		S3Endpoint:         getEnv("S3_ENDPOINT", ""),
+		S3PublicURL:        getEnv("S3_PUBLIC_URL", ""),
+		S3AccessKeyID:      getEnv("S3_ACCESS_KEY_ID", getEnv("AWS_ACCESS_KEY_ID", "")),
+		S3SecretAccessKey:  getEnv("S3_SECRET_ACCESS_KEY", getEnv("AWS_SECRET_ACCESS_KEY", "")),
+		GCSBucket:          getEnv("GCS_BUCKET", ""),
+		GCSCredentialsFile: getEnv("GCS_CREDENTIALS_FILE", getEnv("GOOGLE_APPLICATION_CREDENTIALS", "")),
+		GCSPublicURL:       getEnv("GCS_PUBLIC_URL", ""),
+
+		IdempotencyKeyTTL: getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		FeedPollInterval: getEnvAsDuration("FEED_POLL_INTERVAL", 15*time.Minute),
+
+		LexiconFile: getEnv("LEXICON_FILE", ""),
+		VoicesFile:  getEnv("VOICES_FILE", ""),
+
+		AdminToken: getEnv("ADMIN_TOKEN", ""),
+
+		// Distributed rendering
+		Mode:               strings.ToLower(getEnv("MODE", "all")),
+		NATSURL:            getEnv("NATS_URL", ""),
+		WorkerConcurrency:  getEnvAsInt("WORKER_CONCURRENCY", 1),
+		WorkerDispatchWait: getEnvAsDuration("WORKER_DISPATCH_WAIT", 10*time.Minute),
+
+		// Webhooks
+		PublicBaseURL:     getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+		WebhookSecret:     getEnv("WEBHOOK_SECRET", ""),
+		WebhookMaxRetries: getEnvAsInt("WEBHOOK_MAX_RETRIES", 5),
+	}
+
+	rates, err := parseRateLimits(getEnv("TTS_RATE_LIMITS", "fpt=0.2"))
+	if err != nil {
+		return nil, fmt.Errorf("TTS_RATE_LIMITS: %w", err)
+	}
+	cfg.TTSRateLimits = rates
+
+	// The TTS cache lives under CACHE_DIR unless moved (e.g. to a volume shared by render workers)
+	cfg.TTSCacheDir = getEnv("TTS_CACHE_DIR", filepath.Join(cfg.CacheDir, "tts"))
+	if strings.EqualFold(cfg.TTSCacheDir, "off") {
+		cfg.TTSCacheDir = ""
+	}
+	cfg.TTSAuditDir = getEnv("TTS_AUDIT_DIR", filepath.Join(cfg.CacheDir, "tts_audit"))
+	if strings.EqualFold(cfg.TTSAuditDir, "off") {
+		cfg.TTSAuditDir = ""
 	}
 
 	// Validate configuration
@@ -107,8 +520,125 @@ func LoadConfig() (*Config, error) {
 
 // Validate checks if configuration is valid
 func (c *Config) Validate() error {
-	if len(c.TTSAPIKeys) == 0 {
-		return errors.New("TTS_API_KEYS is required")
+	switch c.Mode {
+	case "all", "api", "worker":
+	default:
+		return fmt.Errorf("MODE must be one of all, api, worker (got %q)", c.Mode)
+	}
+	if c.Mode != "all" && c.NATSURL == "" {
+		return errors.New("NATS_URL is required when MODE is api or worker")
+	}
+	// The API front never calls TTS itself
+	if c.Mode != "api" && len(c.TTSAPIKeys) == 0 && len(c.ElevenLabsAPIKeys) == 0 && len(c.AzureSpeechKeys) == 0 && len(c.OpenAIAPIKeys) == 0 && !c.HasAWSCredentials() && !c.HasPiper() && c.XTTSURL == "" {
+		return errors.New("TTS_API_KEYS (or ELEVENLABS_API_KEYS / AZURE_SPEECH_KEYS / OPENAI_API_KEYS / AWS credentials / PIPER_URL / PIPER_MODEL / XTTS_URL) is required")
+	}
+	if c.HasAWSCredentials() && c.PollyEngine != "neural" && c.PollyEngine != "standard" {
+		return fmt.Errorf("POLLY_ENGINE must be neural or standard (got %q)", c.PollyEngine)
+	}
+	// loudnorm's accepted ranges
+	if c.AudioLoudnessTarget < -70 || c.AudioLoudnessTarget > -5 {
+		return fmt.Errorf("AUDIO_LOUDNESS_TARGET must be between -70 and -5 LUFS (got %g)", c.AudioLoudnessTarget)
+	}
+	if c.AudioTruePeak < -9 || c.AudioTruePeak > 0 {
+		return fmt.Errorf("AUDIO_TRUE_PEAK must be between -9 and 0 dBTP (got %g)", c.AudioTruePeak)
+	}
+	if c.AudioLoudnessRange < 1 || c.AudioLoudnessRange > 20 {
+		return fmt.Errorf("AUDIO_LOUDNESS_RANGE must be between 1 and 20 LU (got %g)", c.AudioLoudnessRange)
+	}
+	if c.FPTCircuitFailureRatio <= 0 || c.FPTCircuitFailureRatio > 1 {
+		return fmt.Errorf("FPT_CIRCUIT_FAILURE_RATIO must be in (0, 1] (got %g)", c.FPTCircuitFailureRatio)
+	}
+	if c.FPTRetryBudgetRatio < 0 || c.FPTRetryBudget < 0 {
+		return errors.New("FPT_RETRY_BUDGET_RATIO and FPT_RETRY_BUDGET must not be negative")
+	}
+	for provider, rate := range c.TTSRateLimits {
+		switch provider {
+		case "fpt", "elevenlabs", "azure", "openai":
+		default:
+			return fmt.Errorf("TTS_RATE_LIMITS: %q has no API key pool to rate limit", provider)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("TTS_RATE_LIMITS: rate for %s must be positive (got %g)", provider, rate)
+		}
+	}
+	switch c.StockProvider {
+	case "pexels", "aggregate":
+	case "pixabay":
+		if c.PixabayAPIKey == "" {
+			return errors.New("STOCK_PROVIDER pixabay requires PIXABAY_API_KEY")
+		}
+	case "coverr":
+		if c.CoverrAPIKey == "" {
+			return errors.New("STOCK_PROVIDER coverr requires COVERR_API_KEY")
+		}
+	default:
+		return fmt.Errorf("STOCK_PROVIDER must be pexels, pixabay, coverr or aggregate (got %q)", c.StockProvider)
+	}
+	if c.StockDenoise < 0 || c.StockDenoise > 20 {
+		return fmt.Errorf("STOCK_DENOISE must be between 0 and 20 (got %g)", c.StockDenoise)
+	}
+	if c.StockSharpen < 0 || c.StockSharpen > 2 {
+		return fmt.Errorf("STOCK_SHARPEN must be between 0 and 2 (got %g)", c.StockSharpen)
+	}
+	if c.SafetyClassifierURL != "" && (c.SafetyThreshold <= 0 || c.SafetyThreshold > 1) {
+		return fmt.Errorf("SAFETY_THRESHOLD must be in (0, 1] (got %g)", c.SafetyThreshold)
+	}
+	if len(c.KlingAPIKeys) > 0 && c.KlingAPIURL == "" && c.KlingRegion != "global" && c.KlingRegion != "cn" {
+		return fmt.Errorf("KLING_REGION must be global or cn (got %q)", c.KlingRegion)
+	}
+	for _, key := range c.KlingAPIKeys {
+		if !strings.Contains(key, ":") {
+			return errors.New("KLING_API_KEYS must be accessKey:secretKey pairs")
+		}
+	}
+	switch c.VisualPromptLLM {
+	case "", "ollama":
+	case "openai":
+		if len(c.OpenAIAPIKeys) == 0 {
+			return errors.New("VISUAL_PROMPT_LLM openai requires OPENAI_API_KEYS")
+		}
+	case "claude":
+		if len(c.AnthropicAPIKeys) == 0 {
+			return errors.New("VISUAL_PROMPT_LLM claude requires ANTHROPIC_API_KEYS")
+		}
+	case "gemini":
+		if len(c.GeminiAPIKeys) == 0 {
+			return errors.New("VISUAL_PROMPT_LLM gemini requires GEMINI_API_KEYS")
+		}
+	default:
+		return fmt.Errorf("VISUAL_PROMPT_LLM must be openai, claude, gemini or ollama (got %q)", c.VisualPromptLLM)
+	}
+	switch c.SubtitleTranslator {
+	case "":
+	case "deepl":
+		if len(c.DeepLAPIKeys) == 0 {
+			return errors.New("SUBTITLE_TRANSLATOR deepl requires DEEPL_API_KEYS")
+		}
+	case "google":
+		if len(c.GoogleTranslateAPIKeys) == 0 {
+			return errors.New("SUBTITLE_TRANSLATOR google requires GOOGLE_TRANSLATE_API_KEYS")
+		}
+	case "llm":
+		if c.VisualPromptLLM == "" {
+			return errors.New("SUBTITLE_TRANSLATOR llm requires VISUAL_PROMPT_LLM")
+		}
+	default:
+		return fmt.Errorf("SUBTITLE_TRANSLATOR must be deepl, google or llm (got %q)", c.SubtitleTranslator)
+	}
+	switch c.EmbeddingsProvider {
+	case "", "ollama":
+	case "openai":
+		if len(c.OpenAIAPIKeys) == 0 {
+			return errors.New("EMBEDDINGS_PROVIDER openai requires OPENAI_API_KEYS")
+		}
+	default:
+		return fmt.Errorf("EMBEDDINGS_PROVIDER must be openai or ollama (got %q)", c.EmbeddingsProvider)
+	}
+	if c.VideoRateLimit < 0 {
+		return fmt.Errorf("VIDEO_RATE_LIMIT must not be negative (got %g)", c.VideoRateLimit)
+	}
+	if c.AudioQAThreshold < 0 || c.AudioQAThreshold > 1 {
+		return fmt.Errorf("AUDIO_QA_THRESHOLD must be between 0 and 1 (got %g)", c.AudioQAThreshold)
 	}
 	if c.AudioChunkSize <= 0 {
 		return errors.New("AUDIO_CHUNK_SIZE must be positive")
@@ -116,9 +646,54 @@ func (c *Config) Validate() error {
 	if c.VideoSegmentDuration <= 0 {
 		return errors.New("VIDEO_SEGMENT_DURATION must be positive")
 	}
+	switch c.StorageBackend {
+	case "":
+	case "s3":
+		if c.S3Bucket == "" {
+			return errors.New("STORAGE_BACKEND s3 requires S3_BUCKET")
+		}
+		if c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			return errors.New("STORAGE_BACKEND s3 requires S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY (or AWS credentials)")
+		}
+	case "gcs":
+		if c.GCSBucket == "" || c.GCSCredentialsFile == "" {
+			return errors.New("STORAGE_BACKEND gcs requires GCS_BUCKET and GCS_CREDENTIALS_FILE (or GOOGLE_APPLICATION_CREDENTIALS)")
+		}
+	default:
+		return fmt.Errorf("STORAGE_BACKEND must be s3 or gcs (got %q)", c.StorageBackend)
+	}
+	if c.StorageURLTTL < 0 || c.StorageURLTTL > 7*24*time.Hour {
+		return fmt.Errorf("STORAGE_URL_TTL must be between 0 and 168h (got %s)", c.StorageURLTTL)
+	}
+	if c.JobRetention > 0 && c.JobSweepInterval <= 0 {
+		return errors.New("JOB_SWEEP_INTERVAL must be positive when JOB_RETENTION is set")
+	}
 	return nil
 }
 
+// FPTCallbackURL is the callback_url sent with FPT.AI requests, or "" when callbacks are disabled
+func (c *Config) FPTCallbackURL() string {
+	if c.FPTCallbackSecret == "" {
+		return ""
+	}
+	return strings.TrimRight(c.PublicBaseURL, "/") + "/api/internal/tts-callback?token=" + url.QueryEscape(c.FPTCallbackSecret)
+}
+
+// HasAWSCredentials reports whether an AWS access key pair is configured
+func (c *Config) HasAWSCredentials() bool {
+	return c.AWSAccessKeyID != "" && c.AWSSecretAccessKey != ""
+}
+
+// HasWhisper reports whether a local Whisper server or model is configured for audio QA
+func (c *Config) HasWhisper() bool {
+	return c.WhisperURL != "" || c.WhisperModel != ""
+}
+
+// HasPiper reports whether a local Piper server or voice model is configured
+func (c *Config) HasPiper() bool {
+	return c.PiperURL != "" || c.PiperModel != ""
+}
+
 // Helper functions
 
 func getEnv(key, defaultValue string) string {
@@ -153,6 +728,18 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 	return value
 }
 
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func parseAPIKeys(keysStr string) []string {
 	if keysStr == "" {
 		return []string{}
@@ -168,7 +755,24 @@ func parseAPIKeys(keysStr string) []string {
 	return result
 }
 
+// parseRateLimits parses "provider=rps" pairs such as "fpt=0.2,elevenlabs=2"
+func parseRateLimits(s string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, pair := range parseAPIKeys(s) {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected provider=rps, got %q", pair)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate for %s: %w", name, err)
+		}
+		rates[strings.ToLower(strings.TrimSpace(name))] = rate
+	}
+	return rates, nil
+}
+
 func (c *Config) String() string {
-	return fmt.Sprintf("Config{Port: %s, TTS Keys: %d, Gemini Keys: %d, ChunkSize: %d, OutputDir: %s}",
-		c.Port, len(c.TTSAPIKeys), len(c.GeminiAPIKeys), c.AudioChunkSize, c.OutputDir)
+	return fmt.Sprintf("Config{Mode: %s, Port: %s, TTS Keys: %d, Gemini Keys: %d, ChunkSize: %d, OutputDir: %s}",
+		c.Mode, c.Port, len(c.TTSAPIKeys), len(c.GeminiAPIKeys), c.AudioChunkSize, c.OutputDir)
 }
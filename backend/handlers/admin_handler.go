@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"aituber/services"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler exposes maintenance operations
+type AdminHandler struct {
+	cfg        *config.Config
+	jobManager services.IJobManager
+	ttsAudit   *services.TTSAuditLog // nil when TTS_AUDIT_DIR is off
+}
+
+// NewAdminHandler creates an AdminHandler sharing the application's job manager and TTS audit log
+func NewAdminHandler(cfg *config.Config, jobManager services.IJobManager, ttsAudit *services.TTSAuditLog) *AdminHandler {
+	return &AdminHandler{
+		cfg:        cfg,
+		jobManager: jobManager,
+		ttsAudit:   ttsAudit,
+	}
+}
+
+// RequireAdminToken rejects requests without "Authorization: Bearer <ADMIN_TOKEN>".
+// When no token is configured the admin routes are disabled entirely.
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled: set ADMIN_TOKEN to enable them"})
+			return
+		}
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Cleanup handles POST /api/admin/cleanup
+// Removes temp dirs with no job record, plus those untouched for ?max_age_hours=N (default 24).
+func (h *AdminHandler) Cleanup(c *gin.Context) {
+	maxAgeHours := 24.0
+	if raw := c.Query("max_age_hours"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_age_hours must be a non-negative number"})
+			return
+		}
+		maxAgeHours = parsed
+	}
+
+	maxAge := time.Duration(maxAgeHours * float64(time.Hour))
+	report, err := services.PurgeStaleTempDirs(h.jobManager, h.cfg.TempDir, maxAge)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"removed":       report.Removed,
+		"removed_count": len(report.Removed),
+		"freed_bytes":   report.FreedBytes,
+	})
+}
+
+// TTSAudit handles GET /api/admin/jobs/:job_id/tts-audit
+// Lists the job's TTS provider calls, optionally filtered by ?provider=, ?chunk= and ?failed=true.
+func (h *AdminHandler) TTSAudit(c *gin.Context) {
+	if h.ttsAudit == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "TTS audit log is disabled: set TTS_AUDIT_DIR to enable it"})
+		return
+	}
+	jobID := c.Param("job_id")
+
+	chunk := -1
+	if raw := c.Query("chunk"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "chunk must be a non-negative integer"})
+			return
+		}
+		chunk = parsed
+	}
+	provider := strings.ToLower(c.Query("provider"))
+	failedOnly := c.Query("failed") == "true"
+
+	records, err := h.ttsAudit.Records(jobID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filtered := make([]models.TTSAuditRecord, 0, len(records))
+	failures := 0
+	for _, rec := range records {
+		if rec.Error != "" {
+			failures++
+		}
+		if (provider != "" && rec.Provider != provider) || (chunk >= 0 && rec.Chunk != chunk) || (failedOnly && rec.Error == "") {
+			continue
+		}
+		filtered = append(filtered, rec)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_id":      jobID,
+		"records":     filtered,
+		"count":       len(filtered),
+		"total_calls": len(records),
+		"failures":    failures,
+	})
+}
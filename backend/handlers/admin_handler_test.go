@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"aituber/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminHandler_TTSAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	audit := services.NewTTSAuditLog(t.TempDir())
+	audit.Append(models.TTSAuditRecord{JobID: "job1", Provider: "fpt", Chunk: 0, Attempt: 1, StatusCode: 500, Error: "boom"})
+	audit.Append(models.TTSAuditRecord{JobID: "job1", Provider: "fpt", Chunk: 0, Attempt: 2, StatusCode: 200})
+	audit.Append(models.TTSAuditRecord{JobID: "job1", Provider: "fpt", Chunk: 1, Attempt: 1, StatusCode: 200})
+
+	h := NewAdminHandler(&config.Config{TempDir: t.TempDir()}, services.NewJobManager(), audit)
+	router := gin.New()
+	router.GET("/api/admin/jobs/:job_id/tts-audit", h.TTSAudit)
+
+	tests := []struct {
+		name      string
+		url       string
+		wantCode  int
+		wantCount int
+	}{
+		{"All calls", "/api/admin/jobs/job1/tts-audit", http.StatusOK, 3},
+		{"Failures only", "/api/admin/jobs/job1/tts-audit?failed=true", http.StatusOK, 1},
+		{"One chunk", "/api/admin/jobs/job1/tts-audit?chunk=1", http.StatusOK, 1},
+		{"Other provider", "/api/admin/jobs/job1/tts-audit?provider=azure", http.StatusOK, 0},
+		{"Bad chunk", "/api/admin/jobs/job1/tts-audit?chunk=x", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Count    int `json:"count"`
+				Failures int `json:"failures"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+			if resp.Count != tt.wantCount || resp.Failures != 1 {
+				t.Errorf("Expected %d records and 1 failure, got %+v", tt.wantCount, resp)
+			}
+		})
+	}
+
+	disabled := NewAdminHandler(&config.Config{}, services.NewJobManager(), nil)
+	router = gin.New()
+	router.GET("/api/admin/jobs/:job_id/tts-audit", disabled.TTSAudit)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/jobs/job1/tts-audit", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with auditing disabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"aituber/services"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBrollUploadSize bounds one clip of POST /api/assets/broll
+const maxBrollUploadSize = 500 << 20
+
+// maxIntroOutroUploadSize bounds one clip of POST /api/assets/intros and /api/assets/outros
+const maxIntroOutroUploadSize = 200 << 20
+
+// AssetHandler stores each tenant's own b-roll clips, intros and outros for use in generated
+// videos
+type AssetHandler struct {
+	assets *services.BrollAssetStore // nil when BROLL_UPLOAD_DIR is unset
+	intros *services.IntroOutroStore // nil when INTRO_OUTRO_DIR is unset
+}
+
+// NewAssetHandler creates an AssetHandler over the b-roll and intro/outro stores
+func NewAssetHandler(assets *services.BrollAssetStore, intros *services.IntroOutroStore) *AssetHandler {
+	return &AssetHandler{assets: assets, intros: intros}
+}
+
+// ListBroll handles GET /api/assets/broll
+func (ah *AssetHandler) ListBroll(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if ah.assets == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "b-roll uploads are not configured (set BROLL_UPLOAD_DIR)"})
+		return
+	}
+	assets, err := ah.assets.List(tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "assets": assets, "count": len(assets)})
+}
+
+// UploadBroll handles POST /api/assets/broll (multipart: file, optional comma-separated keywords)
+func (ah *AssetHandler) UploadBroll(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if ah.assets == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "b-roll uploads are not configured (set BROLL_UPLOAD_DIR)"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBrollUploadSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+	if header.Size > maxBrollUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("clip may be at most %d MB", maxBrollUploadSize>>20)})
+		return
+	}
+
+	var keywords []string
+	for _, kw := range strings.Split(c.PostForm("keywords"), ",") {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			keywords = append(keywords, kw)
+		}
+	}
+	asset, err := ah.assets.Save(tenant, header.Filename, file, keywords)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, asset)
+}
+
+// clipRequest reads the tenant and the :kind ("intros" or "outros") of an intro/outro route,
+// writing the error response and returning ok == false when either is invalid
+func (ah *AssetHandler) clipRequest(c *gin.Context) (tenant, kind string, ok bool) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", "", false
+	}
+	kind = strings.TrimSuffix(c.Param("kind"), "s")
+	if !services.ValidClipKind(kind) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "asset type must be intros or outros"})
+		return "", "", false
+	}
+	if ah.intros == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "intro/outro uploads are not configured (set INTRO_OUTRO_DIR)"})
+		return "", "", false
+	}
+	return tenant, kind, true
+}
+
+// ListClips handles GET /api/assets/:kind for intros and outros
+func (ah *AssetHandler) ListClips(c *gin.Context) {
+	tenant, kind, ok := ah.clipRequest(c)
+	if !ok {
+		return
+	}
+	clips, err := ah.intros.List(tenant, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "clips": clips, "count": len(clips), "default": ah.intros.Default(tenant, kind)})
+}
+
+// UploadClip handles POST /api/assets/:kind (multipart: file, optional default=true to use the
+// clip for requests that name no intro or outro)
+func (ah *AssetHandler) UploadClip(c *gin.Context) {
+	tenant, kind, ok := ah.clipRequest(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxIntroOutroUploadSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+	if header.Size > maxIntroOutroUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("clip may be at most %d MB", maxIntroOutroUploadSize>>20)})
+		return
+	}
+
+	clip, err := ah.intros.Save(tenant, kind, header.Filename, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.PostForm("default") == "true" {
+		if err := ah.intros.SetDefault(tenant, kind, clip.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		clip.Default = true
+	}
+	c.JSON(http.StatusCreated, clip)
+}
+
+// SetDefaultClip handles PUT /api/assets/:kind/default with {"id": ...}: one of the tenant's
+// clips, "none" for no intro/outro, or "" for the bundled static/ clip
+func (ah *AssetHandler) SetDefaultClip(c *gin.Context) {
+	tenant, kind, ok := ah.clipRequest(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := ah.intros.SetDefault(tenant, kind, body.ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "default": body.ID})
+}
+
+// DeleteClip handles DELETE /api/assets/:kind/:id
+func (ah *AssetHandler) DeleteClip(c *gin.Context) {
+	tenant, kind, ok := ah.clipRequest(c)
+	if !ok {
+		return
+	}
+	if err := ah.intros.Delete(tenant, kind, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"aituber/services"
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func uploadRequest(t *testing.T, filename string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	w.WriteField("keywords", "office, team")
+	if filename != "" {
+		part, _ := w.CreateFormFile("file", filename)
+		part.Write([]byte("video"))
+	}
+	w.Close()
+	req := httptest.NewRequest("POST", "/api/assets/broll", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set(tenantHeader, "acme")
+	return req
+}
+
+func TestAssetHandler_UploadBroll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		store    *services.BrollAssetStore
+		filename string
+		want     int
+	}{
+		{"Uploads disabled", nil, "clip.mp4", http.StatusServiceUnavailable},
+		{"Missing file", services.NewBrollAssetStore(t.TempDir()), "", http.StatusBadRequest},
+		{"Not a video", services.NewBrollAssetStore(t.TempDir()), "notes.txt", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/api/assets/broll", NewAssetHandler(tt.store, nil).UploadBroll)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, uploadRequest(t, tt.filename))
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestVideoHandler_ValidateBrollAssets(t *testing.T) {
+	dir := t.TempDir()
+	id := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(`{"id": "`+id+`", "tenant": "acme", "file_name": "team.mp4", "duration": 4}`), 0644); err != nil {
+		t.Fatalf("Failed to write sidecar: %v", err)
+	}
+	h := NewVideoHandler(&config.Config{BrollUploadDir: dir}, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name    string
+		tenant  string
+		req     models.GenerateRequest
+		wantErr bool
+	}{
+		{"None", "acme", models.GenerateRequest{}, false},
+		{"Own asset", "acme", models.GenerateRequest{BrollAssets: []string{id}}, false},
+		{"Other tenant's asset", "globex", models.GenerateRequest{BrollAssets: []string{id}}, true},
+		{"Unknown asset", "acme", models.GenerateRequest{BrollAssets: []string{"16fd2706-8baf-433b-82eb-8c7fada847da"}}, true},
+		{"Not stock footage", "acme", models.GenerateRequest{VideoSource: "images", BrollAssets: []string{id}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.validateBrollAssets(tt.tenant, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBrollAssets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssetHandler_Clips(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	id := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(`{"id": "`+id+`", "tenant": "acme", "kind": "intro", "file_name": "tet.mp4", "duration": 5}`), 0644); err != nil {
+		t.Fatalf("Failed to write sidecar: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		store  *services.IntroOutroStore
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"Uploads disabled", nil, "GET", "/api/assets/intros", "", http.StatusServiceUnavailable},
+		{"Unknown kind", services.NewIntroOutroStore(dir), "GET", "/api/assets/banners", "", http.StatusNotFound},
+		{"List", services.NewIntroOutroStore(dir), "GET", "/api/assets/intros", "", http.StatusOK},
+		{"Default of the wrong kind", services.NewIntroOutroStore(dir), "PUT", "/api/assets/outros/default", `{"id": "` + id + `"}`, http.StatusNotFound},
+		{"Default", services.NewIntroOutroStore(dir), "PUT", "/api/assets/intros/default", `{"id": "` + id + `"}`, http.StatusOK},
+		{"Delete unknown", services.NewIntroOutroStore(dir), "DELETE", "/api/assets/intros/16fd2706-8baf-433b-82eb-8c7fada847da", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ah := NewAssetHandler(nil, tt.store)
+			router := gin.New()
+			router.GET("/api/assets/:kind", ah.ListClips)
+			router.PUT("/api/assets/:kind/default", ah.SetDefaultClip)
+			router.DELETE("/api/assets/:kind/:id", ah.DeleteClip)
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(tenantHeader, "acme")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestVideoHandler_ResolveIntroOutro(t *testing.T) {
+	dir := t.TempDir()
+	intro := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	if err := os.WriteFile(filepath.Join(dir, intro+".json"), []byte(`{"id": "`+intro+`", "tenant": "acme", "kind": "intro", "file_name": "tet.mp4", "duration": 5}`), 0644); err != nil {
+		t.Fatalf("Failed to write sidecar: %v", err)
+	}
+	h := NewVideoHandler(&config.Config{IntroOutroDir: dir}, nil, nil, nil, nil, nil, nil)
+	if err := h.introOutro.SetDefault("acme", services.ClipOutro, "none"); err != nil {
+		t.Fatalf("SetDefault() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		tenant    string
+		req       models.GenerateRequest
+		wantIntro string
+		wantOutro string
+		wantErr   bool
+	}{
+		{"Tenant defaults", "acme", models.GenerateRequest{}, "", "none", false},
+		{"Request's choice wins", "acme", models.GenerateRequest{IntroVideo: intro, OutroVideo: "bye.mp4"}, intro, "bye.mp4", false},
+		{"No defaults", "globex", models.GenerateRequest{}, "", "", false},
+		{"Other tenant's clip", "globex", models.GenerateRequest{IntroVideo: intro}, "", "", true},
+		{"Intro as outro", "acme", models.GenerateRequest{OutroVideo: intro}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := h.resolveIntroOutro(tt.tenant, &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveIntroOutro() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (req.IntroVideo != tt.wantIntro || req.OutroVideo != tt.wantOutro) {
+				t.Errorf("Got intro %q, outro %q; want %q, %q", req.IntroVideo, req.OutroVideo, tt.wantIntro, tt.wantOutro)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxFeedItemsPerPoll bounds how many jobs one poll of a feed may create
+const maxFeedItemsPerPoll = 20
+
+// FeedHandler manages RSS/Atom feed subscriptions that turn new articles into videos
+type FeedHandler struct {
+	feeds *services.FeedService
+}
+
+// NewFeedHandler creates a FeedHandler
+func NewFeedHandler(feeds *services.FeedService) *FeedHandler {
+	return &FeedHandler{feeds: feeds}
+}
+
+// CreateFeed handles POST /api/feeds
+func (fh *FeedHandler) CreateFeed(c *gin.Context) {
+	var req models.FeedSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if !isAbsoluteHTTPURL(req.URL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an absolute http(s) URL"})
+		return
+	}
+	if req.Platform != "youtube" && req.Platform != "tiktok" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "platform must be 'youtube' or 'tiktok'"})
+		return
+	}
+	if req.SpeakingSpeed != 0 && (req.SpeakingSpeed < 0.5 || req.SpeakingSpeed > 2.0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Speaking speed must be between 0.5 and 2.0"})
+		return
+	}
+	if req.MaxItemsPerPoll < 0 || req.MaxItemsPerPoll > maxFeedItemsPerPoll {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_items_per_poll must be between 1 and 20, or 0 for the default"})
+		return
+	}
+
+	sub, err := fh.feeds.Add(models.FeedSubscription{
+		ID:              uuid.New().String(),
+		URL:             req.URL,
+		Platform:        req.Platform,
+		Voice:           req.Voice,
+		SpeakingSpeed:   req.SpeakingSpeed,
+		VideoStyle:      req.VideoStyle,
+		TTSProvider:     req.TTSProvider,
+		MaxItemsPerPoll: req.MaxItemsPerPoll,
+		CreatedAt:       time.Now(),
+	}, req.IncludeExisting)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read feed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListFeeds handles GET /api/feeds
+func (fh *FeedHandler) ListFeeds(c *gin.Context) {
+	feeds := fh.feeds.List()
+	c.JSON(http.StatusOK, gin.H{"feeds": feeds, "count": len(feeds)})
+}
+
+// DeleteFeed handles DELETE /api/feeds/:feed_id
+func (fh *FeedHandler) DeleteFeed(c *gin.Context) {
+	if !fh.feeds.Remove(c.Param("feed_id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
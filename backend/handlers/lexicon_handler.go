@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tenantHeader selects whose lexicon a request reads or writes; absent means services.DefaultTenant
+const tenantHeader = "X-Tenant-ID"
+
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// LexiconHandler manages the per-tenant pronunciation dictionaries applied before TTS
+type LexiconHandler struct {
+	lexicon *services.LexiconStore
+}
+
+// NewLexiconHandler creates a LexiconHandler
+func NewLexiconHandler(lexicon *services.LexiconStore) *LexiconHandler {
+	return &LexiconHandler{lexicon: lexicon}
+}
+
+// GetLexicon handles GET /api/lexicon
+func (lh *LexiconHandler) GetLexicon(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entries := lh.lexicon.Get(tenant)
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "entries": entries, "count": len(entries)})
+}
+
+// PutLexicon handles PUT /api/lexicon
+func (lh *LexiconHandler) PutLexicon(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req models.LexiconRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := services.ValidateLexicon(req.Entries); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := lh.lexicon.Set(tenant, req.Entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries := lh.lexicon.Get(tenant)
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "entries": entries, "count": len(entries)})
+}
+
+// tenantFromRequest reads and validates the X-Tenant-ID header
+func tenantFromRequest(c *gin.Context) (string, error) {
+	tenant := strings.TrimSpace(c.GetHeader(tenantHeader))
+	if tenant == "" {
+		return services.DefaultTenant, nil
+	}
+	if !tenantPattern.MatchString(tenant) {
+		return "", fmt.Errorf("%s must be 1-64 letters, digits, '.', '_' or '-'", tenantHeader)
+	}
+	return tenant, nil
+}
+
+// mergeLexicon adds the tenant's dictionary to the request's own pronunciations (request entries win)
+func mergeLexicon(lexicon *services.LexiconStore, tenant string, req *models.GenerateRequest) {
+	if lexicon == nil {
+		return
+	}
+	entries := lexicon.Get(tenant)
+	if len(entries) == 0 {
+		return
+	}
+	for word, replacement := range req.Pronunciations {
+		// Words match case-insensitively, so "aituber" in the request overrides "AITuber" in the lexicon
+		for existing := range entries {
+			if strings.EqualFold(existing, word) {
+				delete(entries, existing)
+			}
+		}
+		entries[word] = replacement
+	}
+	req.Pronunciations = entries
+}
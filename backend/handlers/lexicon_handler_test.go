@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLexiconHandler_PutPerTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := services.NewLexiconStore("")
+	h := NewLexiconHandler(store)
+	router := gin.New()
+	router.GET("/api/lexicon", h.GetLexicon)
+	router.PUT("/api/lexicon", h.PutLexicon)
+
+	tests := []struct {
+		name   string
+		tenant string
+		body   string
+		want   int
+	}{
+		{"Valid lexicon", "acme", `{"entries": {"AITuber": "ây ai tu bơ"}}`, http.StatusOK},
+		{"Empty replacement", "acme", `{"entries": {"AITuber": ""}}`, http.StatusBadRequest},
+		{"Invalid tenant", "a/b", `{"entries": {}}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/api/lexicon", strings.NewReader(tt.body))
+			req.Header.Set(tenantHeader, tt.tenant)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/lexicon", nil))
+	var resp struct {
+		Tenant string `json:"tenant"`
+		Count  int    `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Tenant != services.DefaultTenant || resp.Count != 0 {
+		t.Errorf("Default tenant should have an empty lexicon, got %s", w.Body.String())
+	}
+
+	// Request pronunciations override the tenant's entries case-insensitively
+	req := models.GenerateRequest{Pronunciations: map[string]string{"aituber": "ai tu bơ"}}
+	mergeLexicon(store, "acme", &req)
+	if len(req.Pronunciations) != 1 || req.Pronunciations["aituber"] != "ai tu bơ" {
+		t.Errorf("Unexpected merged pronunciations %v", req.Pronunciations)
+	}
+}
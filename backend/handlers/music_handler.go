@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MusicHandler lists the bundled background music tracks
+type MusicHandler struct {
+	dir string
+}
+
+// NewMusicHandler creates a MusicHandler serving the tracks in dir
+func NewMusicHandler(dir string) *MusicHandler {
+	return &MusicHandler{dir: dir}
+}
+
+// ListMusic handles GET /api/music (optional ?mood=calm)
+func (mh *MusicHandler) ListMusic(c *gin.Context) {
+	tracks, err := services.ListMusicTracks(mh.dir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if mood := c.Query("mood"); mood != "" {
+		filtered := []models.MusicTrack{}
+		for _, track := range tracks {
+			if strings.EqualFold(track.Mood, mood) {
+				filtered = append(filtered, track)
+			}
+		}
+		tracks = filtered
+	}
+	if tracks == nil {
+		tracks = []models.MusicTrack{}
+	}
+	c.JSON(http.StatusOK, gin.H{"tracks": tracks, "count": len(tracks)})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateHandler manages the per-tenant visual prompt templates of AI clips
+type PromptTemplateHandler struct {
+	templates *services.PromptTemplateStore
+	defaults  models.PromptTemplate // PROMPT_TEMPLATE and PROMPT_BRANDING
+}
+
+// NewPromptTemplateHandler creates a PromptTemplateHandler; defaults is what tenants without
+// a template of their own get
+func NewPromptTemplateHandler(templates *services.PromptTemplateStore, defaults models.PromptTemplate) *PromptTemplateHandler {
+	if defaults.Template == "" {
+		defaults.Template = services.DefaultPromptTemplate
+	}
+	return &PromptTemplateHandler{templates: templates, defaults: defaults}
+}
+
+// GetPromptTemplate handles GET /api/prompt-template
+func (ph *PromptTemplateHandler) GetPromptTemplate(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ph.respond(c, tenant)
+}
+
+// PutPromptTemplate handles PUT /api/prompt-template; an empty template restores the default
+func (ph *PromptTemplateHandler) PutPromptTemplate(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req models.PromptTemplate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := validatePromptTemplate(req.Template, req.Branding); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ph.templates.Set(tenant, req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ph.respond(c, tenant)
+}
+
+// respond writes the tenant's effective template and whether it is the default
+func (ph *PromptTemplateHandler) respond(c *gin.Context, tenant string) {
+	tmpl, ok := ph.templates.Get(tenant)
+	if !ok {
+		tmpl = ph.defaults
+	}
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "template": tmpl.Template, "branding": tmpl.Branding, "default": !ok})
+}
+
+// validatePromptTemplate checks a request's or tenant's template (when set) and branding
+func validatePromptTemplate(template, branding string) error {
+	if strings.TrimSpace(template) != "" {
+		if err := services.ValidatePromptTemplate(template); err != nil {
+			return err
+		}
+	}
+	return services.ValidatePromptBranding(branding)
+}
+
+// mergePromptTemplate fills the request's template and branding from the tenant's where the
+// request leaves them empty; the workflow falls back to PROMPT_TEMPLATE after that
+func mergePromptTemplate(templates *services.PromptTemplateStore, tenant string, req *models.GenerateRequest) {
+	if templates == nil {
+		return
+	}
+	tmpl, ok := templates.Get(tenant)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(req.PromptTemplate) == "" {
+		req.PromptTemplate = tmpl.Template
+	}
+	if strings.TrimSpace(req.Branding) == "" {
+		req.Branding = tmpl.Branding
+	}
+}
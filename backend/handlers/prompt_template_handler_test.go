@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPromptTemplateHandler_PutPerTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := services.NewPromptTemplateStore("")
+	h := NewPromptTemplateHandler(store, models.PromptTemplate{})
+	router := gin.New()
+	router.GET("/api/prompt-template", h.GetPromptTemplate)
+	router.PUT("/api/prompt-template", h.PutPromptTemplate)
+
+	tests := []struct {
+		name   string
+		tenant string
+		body   string
+		want   int
+	}{
+		{"Valid template", "acme", `{"template": "{style} shot of {text}, {branding}", "branding": "orange robot mascot"}`, http.StatusOK},
+		{"Unknown placeholder", "acme", `{"template": "{text} by {author}"}`, http.StatusBadRequest},
+		{"No per-segment placeholder", "acme", `{"template": "{style} video"}`, http.StatusBadRequest},
+		{"Invalid tenant", "a/b", `{"template": "{text}"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/api/prompt-template", strings.NewReader(tt.body))
+			req.Header.Set(tenantHeader, tt.tenant)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/prompt-template", nil))
+	var resp struct {
+		Tenant   string `json:"tenant"`
+		Template string `json:"template"`
+		Default  bool   `json:"default"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Tenant != services.DefaultTenant || !resp.Default || resp.Template != services.DefaultPromptTemplate {
+		t.Errorf("Default tenant should get the built-in template, got %s", w.Body.String())
+	}
+
+	// The request's own template wins; the tenant's branding fills the gap
+	req := models.GenerateRequest{PromptTemplate: "{text}"}
+	mergePromptTemplate(store, "acme", &req)
+	if req.PromptTemplate != "{text}" || req.Branding != "orange robot mascot" {
+		t.Errorf("Unexpected merged template %q / %q", req.PromptTemplate, req.Branding)
+	}
+}
@@ -362,7 +362,7 @@ func (sh *SeriesHandler) runPartGeneration(seriesID string, idx int) {
 				}
 				sh.seriesMu.Unlock()
 
-				if vj.Status == "completed" || vj.Status == "failed" {
+				if services.JobFinished(vj.Status) {
 					return
 				}
 			}
@@ -377,7 +377,7 @@ func (sh *SeriesHandler) runPartGeneration(seriesID string, idx int) {
 	// Wait for completion in this goroutine so wg.Done() works correctly
 	for {
 		vj, _ := sh.jobManager.GetJob(jobID)
-		if vj.Status == "completed" || vj.Status == "failed" {
+		if services.JobFinished(vj.Status) {
 			break
 		}
 		time.Sleep(2 * time.Second)
@@ -391,7 +391,7 @@ func (sh *SeriesHandler) runPartGeneration(seriesID string, idx int) {
 	if s, ok := sh.series[seriesID]; ok && idx < len(s.Parts) {
 		p := s.Parts[idx]
 		if vj.Status == "completed" {
-			videoURL := fmt.Sprintf("/api/download/%s", jobID)
+			videoURL := downloadURL("/api/download", vj)
 			savedPath := vj.SavedPath
 			p.Status = "completed"
 			p.Progress = 100
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"aituber/models"
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TTSCallbackHandler receives the completion callbacks of asynchronous TTS providers
+type TTSCallbackHandler struct {
+	secret  string
+	deliver func(models.FPTCallback) error
+}
+
+// NewTTSCallbackHandler creates a handler that passes authenticated FPT.AI callbacks to deliver,
+// which wakes the waiting request in-process or relays the callback to the render workers
+func NewTTSCallbackHandler(secret string, deliver func(models.FPTCallback) error) *TTSCallbackHandler {
+	return &TTSCallbackHandler{secret: secret, deliver: deliver}
+}
+
+// FPTCallback handles POST /api/internal/tts-callback?token=<FPT_CALLBACK_SECRET>
+func (h *TTSCallbackHandler) FPTCallback(c *gin.Context) {
+	if h.secret == "" || subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.secret)) != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid callback token"})
+		return
+	}
+	var cb models.FPTCallback
+	if err := c.ShouldBindJSON(&cb); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if cb.RequestID == "" && cb.RequestIDAlt == "" && cb.Link == "" && cb.Async == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Callback identifies no request"})
+		return
+	}
+	if err := h.deliver(cb); err != nil {
+		log.Printf("[FPT] Could not deliver callback: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Could not deliver callback"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
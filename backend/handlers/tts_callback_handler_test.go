@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"aituber/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTTSCallbackHandler_FPTCallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var delivered []models.FPTCallback
+	h := NewTTSCallbackHandler("s3cret", func(cb models.FPTCallback) error {
+		delivered = append(delivered, cb)
+		return nil
+	})
+	router := gin.New()
+	router.POST("/api/internal/tts-callback", h.FPTCallback)
+
+	tests := []struct {
+		name string
+		url  string
+		body string
+		want int
+	}{
+		{"Missing token", "/api/internal/tts-callback", `{"requestid": "r1", "success": true}`, http.StatusForbidden},
+		{"Wrong token", "/api/internal/tts-callback?token=nope", `{"requestid": "r1", "success": true}`, http.StatusForbidden},
+		{"No request ID", "/api/internal/tts-callback?token=s3cret", `{"success": true}`, http.StatusBadRequest},
+		{"Valid", "/api/internal/tts-callback?token=s3cret", `{"requestid": "r1", "success": true}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+	if len(delivered) != 1 || delivered[0].RequestIDAlt != "r1" || !delivered[0].Success {
+		t.Errorf("Expected exactly the valid callback to be delivered, got %+v", delivered)
+	}
+}
@@ -5,13 +5,21 @@ import (
 	"aituber/models"
 	"aituber/services"
 	"aituber/utils"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -19,103 +27,996 @@ import (
 
 // VideoHandler handles video generation requests
 type VideoHandler struct {
-	cfg               *config.Config
-	jobManager        services.IJobManager
-	workflow          services.IVideoWorkflow
-	geminiSVC         services.IScriptGenerator
-	textProcessor     *services.TextProcessor
-	audioService      *services.AudioService
-	videoService      *services.VideoService
-	geminiService     *services.GeminiService
-	hfService         *services.HuggingFaceService
-	stockVideoService *services.StockVideoService
-	composerService   *services.ComposerService
-}
-
-// NewVideoHandler creates a new video handler
-func NewVideoHandler(cfg *config.Config) *VideoHandler {
-	// Create API key pools
-	ttsPool := utils.NewAPIKeyPool(cfg.TTSAPIKeys)
-
-	var videoPool *utils.APIKeyPool
-	if len(cfg.VideoAPIKeys) > 0 {
-		videoPool = utils.NewAPIKeyPool(cfg.VideoAPIKeys)
+	cfg        *config.Config
+	jobManager services.IJobManager
+	workflow   services.IVideoWorkflow
+	geminiSVC  services.IScriptGenerator
+	scheduler  *services.JobScheduler
+	lexicon    *services.LexiconStore
+	voices     *services.VoiceStore
+
+	promptTemplates *services.PromptTemplateStore // nil until SetPromptTemplates
+	storage         services.OutputStorage        // nil until SetOutputStorage
+	remoteCancel    func(jobID string) error      // nil until SetRemoteCancel
+
+	idempotency *services.IdempotencyStore
+	brollAssets *services.BrollAssetStore // nil when BROLL_UPLOAD_DIR is unset
+	introOutro  *services.IntroOutroStore // nil when INTRO_OUTRO_DIR is unset
+}
+
+// idempotencyKeyHeader lets clients safely retry POST /api/generate
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLen bounds client-supplied keys
+const maxIdempotencyKeyLen = 255
+
+// NewVideoHandler creates a new video handler sharing the application's services
+func NewVideoHandler(
+	cfg *config.Config,
+	jobManager services.IJobManager,
+	workflow services.IVideoWorkflow,
+	gemini services.IScriptGenerator,
+	scheduler *services.JobScheduler,
+	lexicon *services.LexiconStore,
+	voices *services.VoiceStore,
+) *VideoHandler {
+	return &VideoHandler{
+		cfg:        cfg,
+		jobManager: jobManager,
+		workflow:   workflow,
+		geminiSVC:  gemini,
+		scheduler:  scheduler,
+		lexicon:    lexicon,
+		voices:     voices,
+
+		idempotency: services.NewIdempotencyStore(cfg.IdempotencyKeyTTL),
+		brollAssets: services.NewBrollAssetStore(cfg.BrollUploadDir),
+		introOutro:  services.NewIntroOutroStore(cfg.IntroOutroDir),
+	}
+}
+
+// SetOutputStorage links uploaded files with URLs storage signs for STORAGE_URL_TTL, and
+// redirects their downloads there instead of streaming them through the API
+func (h *VideoHandler) SetOutputStorage(storage services.OutputStorage) {
+	h.storage = storage
+}
+
+// SetRemoteCancel relays cancellations to the render workers (MODE api) through cancel, since
+// the job's encodes run there rather than in this process
+func (h *VideoHandler) SetRemoteCancel(cancel func(jobID string) error) {
+	h.remoteCancel = cancel
+}
+
+// SetPromptTemplates makes jobs use their tenant's visual prompt template (PUT /api/prompt-template)
+func (h *VideoHandler) SetPromptTemplates(templates *services.PromptTemplateStore) {
+	h.promptTemplates = templates
+}
+
+// Generate handles POST /api/generate
+func (h *VideoHandler) Generate(c *gin.Context) {
+	var req models.GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	idempotencyKey := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if len(idempotencyKey) > maxIdempotencyKeyLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLen)})
+		return
+	}
+	// Fingerprint the payload as sent, before defaults are applied below
+	fingerprint := requestFingerprint(req)
+
+	// Cloned voices and pronunciations are per tenant
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.validateGenerateRequest(tenant, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate schedule
+	var runAt time.Time
+	if req.RunAt != "" {
+		parsed, err := time.Parse(time.RFC3339, req.RunAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "run_at must be an RFC3339 timestamp (e.g. 2026-01-02T01:00:00+07:00)"})
+			return
+		}
+		runAt = parsed
+	}
+
+	// Auto-generate ContentName from topic if not provided
+	req.ContentName = buildContentName(req)
+
+	// Generate job ID and register job
+	jobID := uuid.New().String()
+
+	// A retried request with the same key returns the original job instead of starting a new render
+	if idempotencyKey != "" {
+		existingID, err := h.idempotency.Claim(idempotencyKey, fingerprint, jobID)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if existingID != jobID {
+			resp := models.GenerateResponse{JobID: existingID, Status: "processing"}
+			if job, exists := h.jobManager.GetJob(existingID); exists {
+				resp.Status = job.Status
+				resp.DownloadToken = job.DownloadToken
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.JSON(http.StatusOK, resp)
+			return
+		}
+	}
+
+	// Return job ID immediately
+	c.JSON(http.StatusOK, h.startJob(jobID, req, runAt))
+}
+
+// Enqueue registers and starts a job for requests coming from inside the server (e.g. feed items).
+// It applies the same defaults as POST /api/generate and returns the new job ID.
+func (h *VideoHandler) Enqueue(req models.GenerateRequest) (string, error) {
+	if err := h.validateGenerateRequest(services.DefaultTenant, &req); err != nil {
+		return "", err
+	}
+	req.ContentName = buildContentName(req)
+
+	jobID := uuid.New().String()
+	h.startJob(jobID, req, time.Time{})
+	return jobID, nil
+}
+
+// validateGenerateRequest checks req as POST /api/generate does, for tenant, and applies its
+// defaults: speaking speed, intro/outro, cloned voice, pronunciations and prompt template
+func (h *VideoHandler) validateGenerateRequest(tenant string, req *models.GenerateRequest) error {
+	if req.Platform != "youtube" && req.Platform != "tiktok" {
+		return fmt.Errorf("platform must be 'youtube' or 'tiktok'")
+	}
+	if req.Topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+	// If no pre-written script, we need Gemini to generate one
+	if req.Script == "" && !h.geminiSVC.HasKeys() {
+		return fmt.Errorf("No GEMINI_API_KEYS configured — cannot auto-generate script. Please provide a pre-written script or add GEMINI_API_KEYS to .env")
+	}
+
+	// Set default speaking speed if not provided
+	applySpeedDefault(req)
+	if req.SpeakingSpeed < 0.5 || req.SpeakingSpeed > 2.0 {
+		return fmt.Errorf("Speaking speed must be between 0.5 and 2.0")
+	}
+	if err := validateMetadata(req.Title, req.Metadata); err != nil {
+		return err
+	}
+	if req.CallbackURL != "" && !isAbsoluteHTTPURL(req.CallbackURL) {
+		return fmt.Errorf("callback_url must be an absolute http(s) URL")
+	}
+
+	// Final assembly and footage options
+	if err := validateAssemblyOptions(*req); err != nil {
+		return err
+	}
+	if err := validateVideoSource(*req); err != nil {
+		return err
+	}
+	if err := h.validateAIVideo(*req); err != nil {
+		return err
+	}
+	if err := h.validateImageSource(*req); err != nil {
+		return err
+	}
+	if err := h.validateLocalSource(*req); err != nil {
+		return err
+	}
+	if err := validateAudioOverrides(*req); err != nil {
+		return err
+	}
+	if err := validateVideoOverrides(*req); err != nil {
+		return err
+	}
+
+	if err := resolveClonedVoice(h.voices, tenant, req); err != nil {
+		return err
+	}
+	if err := h.validateBrollAssets(tenant, *req); err != nil {
+		return err
+	}
+	if err := h.resolveIntroOutro(tenant, req); err != nil {
+		return err
+	}
+	if err := h.validateTTSProvider(req.TTSProvider); err != nil {
+		return err
+	}
+	if services.IsSSML(req.Script) {
+		if err := services.ValidateSSML(req.Script); err != nil {
+			return err
+		}
+	}
+
+	// Pronunciations: request entries on top of the tenant's lexicon
+	if err := services.ValidateLexicon(req.Pronunciations); err != nil {
+		return fmt.Errorf("pronunciations: %w", err)
+	}
+	mergeLexicon(h.lexicon, tenant, req)
+
+	// Visual prompt template: the request's, else the tenant's
+	if err := validatePromptTemplate(req.PromptTemplate, req.Branding); err != nil {
+		return err
+	}
+	mergePromptTemplate(h.promptTemplates, tenant, req)
+	return nil
+}
+
+// startJob registers the job and either starts it now or hands it to the scheduler
+func (h *VideoHandler) startJob(jobID string, req models.GenerateRequest, runAt time.Time) models.GenerateResponse {
+	job := h.jobManager.CreateJob(jobID, req.Platform, req.ContentName)
+	h.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.CallbackURL = req.CallbackURL
+		j.Title = req.Title
+		j.Metadata = req.Metadata
+		j.Request = req
+	})
+
+	status := "processing"
+	if runAt.After(time.Now()) {
+		// Held until run_at, e.g. overnight when API quotas reset
+		h.scheduler.Schedule(jobID, req, runAt)
+		status = "scheduled"
+	} else {
+		// Start background processing via Orchestrator
+		go h.workflow.StartGeneration(jobID, req)
+	}
+
+	return models.GenerateResponse{
+		JobID:         jobID,
+		Status:        status,
+		DownloadToken: job.DownloadToken,
+	}
+}
+
+// Rerender handles POST /api/jobs/:job_id/rerender
+// It starts a new job that reuses the source job's audio and stock clips with changed assembly
+// options; the source's download token is required, since the response carries the new job's.
+func (h *VideoHandler) Rerender(c *gin.Context) {
+	sourceID := c.Param("job_id")
+
+	source, ok := h.jobWithToken(c)
+	if !ok {
+		return
+	}
+	if source.Status == "expired" {
+		c.JSON(http.StatusGone, gin.H{"error": "Job has expired and its files were removed"})
+		return
+	}
+	if source.Assets == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job has no reusable audio and video yet"})
+		return
+	}
+	for _, path := range append(append([]string{}, source.Assets.AudioPaths...), source.Assets.SegmentVideoPaths...) {
+		if !utils.FileExists(path) {
+			c.JSON(http.StatusGone, gin.H{"error": "Source job's temporary files were already cleaned up"})
+			return
+		}
+	}
+
+	var rr models.RerenderRequest
+	if err := c.ShouldBindJSON(&rr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	req := source.Request
+	if rr.VideoTransition != nil {
+		req.VideoTransition = *rr.VideoTransition
+	}
+	if rr.BurnSubtitles != nil {
+		req.BurnSubtitles = *rr.BurnSubtitles
+	}
+	if rr.SoftSubtitles != nil {
+		req.SoftSubtitles = *rr.SoftSubtitles
+	}
+	if rr.SubtitleStyle != nil {
+		req.SubtitleStyle = *rr.SubtitleStyle
+	}
+	if rr.IntroVideo != nil {
+		req.IntroVideo = *rr.IntroVideo
+	}
+	if rr.OutroVideo != nil {
+		req.OutroVideo = *rr.OutroVideo
+	}
+	if rr.EndScreen != nil {
+		req.EndScreen = *rr.EndScreen
+	}
+	if rr.MusicTrack != nil {
+		req.MusicTrack = *rr.MusicTrack
+	}
+	if rr.ColorGrade != nil {
+		req.ColorGrade = *rr.ColorGrade
+	}
+	if rr.Presenter != nil {
+		req.Presenter = *rr.Presenter
+	}
+	if rr.LowerThirds != nil {
+		req.LowerThirds = *rr.LowerThirds
+	}
+	if rr.LowerThirdStyle != nil {
+		req.LowerThirdStyle = *rr.LowerThirdStyle
+	}
+	if rr.Preview != nil {
+		req.Preview = *rr.Preview
+	}
+	if rr.OutputFormat != nil {
+		req.OutputFormat = *rr.OutputFormat
+	}
+	if rr.VideoCodec != nil {
+		req.VideoCodec = *rr.VideoCodec
+	}
+	if rr.Quality != nil {
+		req.Quality = *rr.Quality
+	}
+	if rr.CallbackURL != nil {
+		req.CallbackURL = *rr.CallbackURL
+	}
+
+	if err := validateAssemblyOptions(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateVideoOverrides(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.resolveIntroOutro(tenant, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.CallbackURL != "" && !isAbsoluteHTTPURL(req.CallbackURL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "callback_url must be an absolute http(s) URL"})
+		return
+	}
+
+	req.RunAt = ""
+	req.ContentName = fmt.Sprintf("%s-rerender-%s", source.ContentName, time.Now().Format("0102-1504"))
+
+	jobID := uuid.New().String()
+	job := h.jobManager.CreateJob(jobID, req.Platform, req.ContentName)
+	h.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.CallbackURL = req.CallbackURL
+		j.Title = source.Title
+		j.Metadata = source.Metadata
+		j.Request = req
+		j.RerenderOf = sourceID
+	})
+
+	go h.workflow.StartRerender(jobID, *source, req)
+
+	c.JSON(http.StatusOK, models.GenerateResponse{
+		JobID:         jobID,
+		Status:        "processing",
+		DownloadToken: job.DownloadToken,
+	})
+}
+
+// CancelJob handles POST /api/jobs/:job_id/cancel?token=: a scheduled, running or waiting job
+// ends as "cancelled" and the encodes running in its temp folder are killed, by its render
+// worker when it was dispatched to one (MODE api).
+func (h *VideoHandler) CancelJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	job, ok := h.jobWithToken(c)
+	if !ok {
+		return
+	}
+	cancelled, err := h.jobManager.MarkCancelled(job.JobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if !cancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job has already finished"})
+		return
+	}
+	if h.remoteCancel != nil {
+		if err := h.remoteCancel(jobID); err != nil {
+			h.jobManager.LogEvent(jobID, fmt.Sprintf("Cancelled, but the render workers could not be told to stop: %v", err))
+		} else {
+			h.jobManager.LogEvent(jobID, "Cancelled, render workers told to stop its encodes")
+		}
 	} else {
-		videoPool = utils.NewAPIKeyPool([]string{"placeholder"})
-	}
-
-	// Initialize services
-	textProcessor := services.NewTextProcessor(cfg.AudioChunkSize, cfg.VideoSegmentDuration)
-
-	audioService := services.NewAudioService(
-		ttsPool,
-		cfg.ElevenLabsAPIKey,
-		cfg.TempDir,
-		cfg.AudioBitrate,
-		cfg.AudioSampleRate,
-		cfg.AudioCrossfadeDuration,
-	)
-
-	videoService := services.NewVideoService(
-		videoPool,
-		cfg.TempDir,
-		cfg.VideoBitrate,
-		cfg.VideoResolution,
-		cfg.VideoFPS,
-		cfg.VideoTransitionDuration,
-	)
-
-	geminiService := services.NewGeminiService(cfg.GeminiAPIKeys)
-	hfService := services.NewHuggingFaceService(cfg.HuggingFaceTokens)
-	stockVideoService := services.NewStockVideoService(cfg.PexelsAPIKey, cfg.TempDir, cfg.CacheDir, geminiService, hfService, cfg.LocalHubURL)
-	composerService := services.NewComposerService(cfg.VideoBitrate)
-
-	// Create job manager and workflow
-	jobManager := services.NewJobManager()
-	workflow := services.NewVideoWorkflowService(cfg, jobManager, textProcessor, audioService, videoService, stockVideoService, composerService, geminiService)
+		killed := utils.CancelFFmpeg(filepath.Join(h.cfg.TempDir, jobID))
+		h.jobManager.LogEvent(jobID, fmt.Sprintf("Cancelled, %d running encodes stopped", killed))
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "status": "cancelled"})
+}
+
+// DeleteJob handles DELETE /api/jobs/:job_id: removes a finished job's files now rather than
+// after JOB_RETENTION, once the caller has what it needs. The job stays listed as "expired";
+// uploaded copies are left to the bucket's lifecycle rules.
+func (h *VideoHandler) DeleteJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	job, exists := h.jobManager.GetJob(jobID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if !hasDownloadToken(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid download token"})
+		return
+	}
+	expired, err := h.jobManager.MarkExpired(jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if !expired {
+		if job.Status == "expired" {
+			c.JSON(http.StatusGone, gin.H{"error": "Job has expired and its files were removed"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Job has not finished (cancel it first)", "status": job.Status})
+		return
+	}
+	if err := utils.CleanupJobFiles(h.cfg.TempDir, jobID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove job files: " + err.Error()})
+		return
+	}
+	h.jobManager.LogEvent(jobID, "Files deleted on request")
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "status": "expired"})
+}
+
+// GetStoryboard handles GET /api/jobs/:job_id/storyboard
+func (h *VideoHandler) GetStoryboard(c *gin.Context) {
+	jobID := c.Param("job_id")
+	job, ok := h.jobWithToken(c)
+	if !ok {
+		return
+	}
+	if job.Storyboard == nil {
+		if job.Request.Storyboard && job.Status == "processing" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Storyboard is still being planned", "status": job.Status})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job has no storyboard (start it with storyboard: true)"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "status": job.Status, "storyboard": job.Storyboard})
+}
+
+// PutStoryboard handles PUT /api/jobs/:job_id/storyboard, replacing the scenes of a storyboard
+// that is waiting for approval
+func (h *VideoHandler) PutStoryboard(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if _, ok := h.jobWithToken(c); !ok {
+		return
+	}
+	var storyboard models.Storyboard
+	if err := c.ShouldBindJSON(&storyboard); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := services.ValidateStoryboard(storyboard); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	storyboard.ApprovedAt = nil
+
+	if status, msg := h.updateStoryboard(jobID, func(j *models.JobStatus) {
+		j.Storyboard = &storyboard
+	}); status != http.StatusOK {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "status": "awaiting_approval", "storyboard": storyboard})
+}
+
+// ApproveStoryboard handles POST /api/jobs/:job_id/storyboard/approve: the job renders its
+// scenes as segments, with a library track of the scenes' music mood unless it chose one
+func (h *VideoHandler) ApproveStoryboard(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if _, ok := h.jobWithToken(c); !ok {
+		return
+	}
+	job, err := h.jobManager.ResumeJob(jobID, "awaiting_approval", func(j *models.JobStatus) bool {
+		if j.Storyboard == nil {
+			return false
+		}
+		now := time.Now()
+		approved := *j.Storyboard
+		approved.ApprovedAt = &now
+
+		req := j.Request
+		req.Storyboard = false
+		req.Segments = services.StoryboardSegments(approved)
+		if req.MusicTrack == "" {
+			if mood := services.StoryboardMusicMood(approved); mood != "" {
+				req.MusicTrack, _ = services.MusicTrackForMood(services.MusicLibraryDir, mood)
+			}
+		}
+
+		j.Storyboard = &approved
+		j.Request = req
+		return true
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job is not waiting for storyboard approval"})
+		return
+	}
+	h.jobManager.LogEvent(jobID, "Storyboard approved, rendering")
+	go h.workflow.StartGeneration(jobID, job.Request)
+
+	c.JSON(http.StatusOK, models.GenerateResponse{JobID: jobID, Status: "processing"})
+}
+
+// updateStoryboard applies fn to a job waiting for storyboard approval, checked under the job
+// manager's lock so concurrent edits and approvals cannot both win. It returns the HTTP status,
+// with an error message unless it is 200.
+func (h *VideoHandler) updateStoryboard(jobID string, fn func(*models.JobStatus)) (int, string) {
+	waiting := false
+	err := h.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		if j.Status == "awaiting_approval" && j.Storyboard != nil {
+			waiting = true
+			fn(j)
+		}
+	})
+	if err != nil {
+		return http.StatusNotFound, "Job not found"
+	}
+	if !waiting {
+		return http.StatusConflict, "Job is not waiting for storyboard approval"
+	}
+	return http.StatusOK, ""
+}
+
+// GetSubtitles handles GET /api/jobs/:job_id/subtitles, the cues of a subtitle_review job
+func (h *VideoHandler) GetSubtitles(c *gin.Context) {
+	jobID := c.Param("job_id")
+	job, ok := h.jobWithToken(c)
+	if !ok {
+		return
+	}
+	if job.Subtitles == nil {
+		if job.Request.SubtitleReview && (job.Status == "processing" || job.Status == "scheduled") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Subtitles are not ready for review yet", "status": job.Status})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job has no subtitles to review (start it with subtitle_review: true)"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "status": job.Status, "subtitles": job.Subtitles})
+}
+
+// PutSubtitles handles PUT /api/jobs/:job_id/subtitles, replacing the cues of a job that is
+// waiting for subtitle review
+func (h *VideoHandler) PutSubtitles(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if _, ok := h.jobWithToken(c); !ok {
+		return
+	}
+	var body struct {
+		Subtitles []models.SubtitleCue `json:"subtitles"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := services.ValidateSubtitleCues(body.Subtitles); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if status, msg := h.updateReview(jobID, func(j *models.JobStatus) {
+		j.Subtitles = body.Subtitles
+	}); status != http.StatusOK {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "status": "awaiting_review", "subtitles": body.Subtitles})
+}
+
+// ContinueJob handles POST /api/jobs/:job_id/continue: a job waiting for subtitle review is
+// composed with its current cues
+func (h *VideoHandler) ContinueJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if _, ok := h.jobWithToken(c); !ok {
+		return
+	}
+	job, err := h.jobManager.ResumeJob(jobID, "awaiting_review", func(j *models.JobStatus) bool {
+		return j.Subtitles != nil
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job is not waiting for subtitle review"})
+		return
+	}
+	h.jobManager.LogEvent(jobID, "Subtitles reviewed, composing video")
+	go h.workflow.ContinueAfterReview(jobID, *job)
+
+	c.JSON(http.StatusOK, models.GenerateResponse{JobID: jobID, Status: "processing"})
+}
+
+// updateReview applies fn to a job waiting for subtitle review under the job manager's lock,
+// like updateStoryboard
+func (h *VideoHandler) updateReview(jobID string, fn func(*models.JobStatus)) (int, string) {
+	waiting := false
+	err := h.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		if j.Status == "awaiting_review" && j.Subtitles != nil {
+			waiting = true
+			fn(j)
+		}
+	})
+	if err != nil {
+		return http.StatusNotFound, "Job not found"
+	}
+	if !waiting {
+		return http.StatusConflict, "Job is not waiting for subtitle review"
+	}
+	return http.StatusOK, ""
+}
+
+// validateAssemblyOptions checks the transition name and that chosen intro/outro clips exist in
+// static/; uploaded ones, named by ID, are checked by resolveIntroOutro
+func validateAssemblyOptions(req models.GenerateRequest) error {
+	if req.VideoTransition != "" && !utils.IsValidTransition(req.VideoTransition) {
+		return fmt.Errorf("unsupported video_transition %q", req.VideoTransition)
+	}
+	for field, name := range map[string]string{"intro_video": req.IntroVideo, "outro_video": req.OutroVideo} {
+		if name == "" || name == "none" || isUUID(name) {
+			continue
+		}
+		if !utils.FileExists(services.ResolveStaticVideo(name, "")) {
+			return fmt.Errorf("%s %q not found in static/", field, name)
+		}
+	}
+	if req.MusicTrack != "" {
+		if _, err := services.ResolveMusicTrack(req.MusicTrack); err != nil {
+			return err
+		}
+	}
+	if _, err := utils.ResolveOutputFormat(req.OutputFormat, req.VideoCodec); err != nil {
+		return err
+	}
+	if err := services.ValidateColorGrade(req.ColorGrade); err != nil {
+		return err
+	}
+	if err := services.ValidatePresenter(req.Presenter); err != nil {
+		return err
+	}
+	if err := services.ValidateLowerThirds(req.LowerThirds, req.LowerThirdStyle); err != nil {
+		return err
+	}
+	if err := validateEndScreen(req.EndScreen); err != nil {
+		return err
+	}
+	if !services.IsValidPreview(req.Preview) {
+		return fmt.Errorf("unsupported preview %q (expected mp4, gif or both)", req.Preview)
+	}
+	if err := services.ValidateSubtitleStyle(req.SubtitleStyle); err != nil {
+		return err
+	}
+	if len(req.SubtitleLanguages) > services.MaxSubtitleLanguages {
+		return fmt.Errorf("subtitle_languages may list at most %d languages", services.MaxSubtitleLanguages)
+	}
+	for _, lang := range req.SubtitleLanguages {
+		if !services.ValidSubtitleLanguage(lang) {
+			return fmt.Errorf("subtitle_languages: %q is not a language code such as \"en\" or \"pt-BR\"", lang)
+		}
+	}
+	return nil
+}
+
+// Safe ranges of the per-request audio overrides
+var (
+	audioBitratePattern = regexp.MustCompile(`^(\d{2,3})k$`)
+	audioSampleRates    = map[int]bool{22050: true, 24000: true, 32000: true, 44100: true, 48000: true}
+)
+
+const maxAudioCrossfade = 2.0
+
+// Per-request video overrides
+var videoFrameRates = map[int]bool{24: true, 30: true, 60: true}
+
+// validateVideoOverrides checks resolution, fps and quality
+func validateVideoOverrides(req models.GenerateRequest) error {
+	if req.Resolution != "" && !services.IsValidResolution(req.Resolution) {
+		return fmt.Errorf("unsupported resolution %q (expected 720p, 1080p or 4k)", req.Resolution)
+	}
+	if req.FPS != 0 && !videoFrameRates[req.FPS] {
+		return fmt.Errorf("fps must be 24, 30 or 60 (got %d)", req.FPS)
+	}
+	if req.Quality != "" && !utils.IsValidQuality(req.Quality) {
+		return fmt.Errorf("unsupported quality %q (expected draft, standard or high)", req.Quality)
+	}
+	return nil
+}
+
+// validateAudioOverrides checks audio_bitrate, audio_sample_rate and audio_crossfade
+func validateAudioOverrides(req models.GenerateRequest) error {
+	if req.AudioBitrate != "" {
+		m := audioBitratePattern.FindStringSubmatch(req.AudioBitrate)
+		if m == nil {
+			return fmt.Errorf("audio_bitrate must look like \"192k\" (got %q)", req.AudioBitrate)
+		}
+		if kbps, _ := strconv.Atoi(m[1]); kbps < 64 || kbps > 320 {
+			return fmt.Errorf("audio_bitrate must be between 64k and 320k (got %s)", req.AudioBitrate)
+		}
+	}
+	if req.AudioSampleRate != 0 && !audioSampleRates[req.AudioSampleRate] {
+		return fmt.Errorf("audio_sample_rate must be 22050, 24000, 32000, 44100 or 48000 (got %d)", req.AudioSampleRate)
+	}
+	if req.AudioCrossfade != nil && (*req.AudioCrossfade < 0 || *req.AudioCrossfade > maxAudioCrossfade) {
+		return fmt.Errorf("audio_crossfade must be between 0 and %g seconds (got %g)", maxAudioCrossfade, *req.AudioCrossfade)
+	}
+	return nil
+}
+
+// backgroundColorPattern accepts "#RRGGBB", "0xRRGGBB" or an ffmpeg color name
+var backgroundColorPattern = regexp.MustCompile(`^((#|0x)[0-9A-Fa-f]{6}|[A-Za-z]{3,20})$`)
+
+// validateVideoSource checks orientation, ken_burns and the options of video_source "waveform", "static"
+// and "slides"; other sources use footage, and a background only for title_cards and end_screen
+func validateVideoSource(req models.GenerateRequest) error {
+	switch req.Orientation {
+	case "", "landscape", "portrait", "square":
+	default:
+		return fmt.Errorf("unsupported orientation %q (expected landscape, portrait or square)", req.Orientation)
+	}
+	switch req.AspectRatio {
+	case "", "16:9", "9:16", "1:1":
+	default:
+		return fmt.Errorf("unsupported aspect_ratio %q (expected 16:9, 9:16 or 1:1)", req.AspectRatio)
+	}
+	if req.KenBurns < 0 || req.KenBurns > 1 {
+		return fmt.Errorf("ken_burns must be between 0 and 1 (got %g)", req.KenBurns)
+	}
+	if req.KenBurns > 0 && req.VideoSource != "" && req.VideoSource != services.VideoSourceLocal {
+		return fmt.Errorf("ken_burns applies to stock and local footage, not video_source %q", req.VideoSource)
+	}
+	if req.Storyboard && !services.StoryboardSource(req.VideoSource) {
+		return fmt.Errorf("storyboard needs a video_source with a clip per scene (\"\", ai, images or local), not %q", req.VideoSource)
+	}
+	switch req.VideoSource {
+	case services.VideoSourceWaveform:
+		switch req.WaveformStyle {
+		case "", services.WaveformStyleWaves, services.WaveformStyleSpectrum:
+		default:
+			return fmt.Errorf("unsupported waveform_style %q (expected waves or spectrum)", req.WaveformStyle)
+		}
+	case services.VideoSourceStatic, services.VideoSourceSlides:
+		if req.VideoSource == services.VideoSourceSlides && strings.TrimSpace(req.Script) == "" && len(req.Segments) == 0 {
+			return fmt.Errorf("video_source %q requires a Markdown script", services.VideoSourceSlides)
+		}
+		if req.AvatarImage != "" && !utils.FileExists(services.ResolveStaticVideo(req.AvatarImage, "")) {
+			return fmt.Errorf("avatar_image %q not found in static/", req.AvatarImage)
+		}
+	default:
+		if !req.TitleCards && !req.EndScreen.Enabled {
+			return nil
+		}
+	}
+	// Also the background of title cards and of the end screen
+	if req.BackgroundColor != "" && !backgroundColorPattern.MatchString(req.BackgroundColor) {
+		return fmt.Errorf("background_color %q must be #RRGGBB or a color name", req.BackgroundColor)
+	}
+	if req.BackgroundImage != "" && !utils.FileExists(services.ResolveStaticVideo(req.BackgroundImage, "")) {
+		return fmt.Errorf("background_image %q not found in static/", req.BackgroundImage)
+	}
+	return nil
+}
 
-	return &VideoHandler{
-		cfg:               cfg,
-		jobManager:        jobManager,
-		workflow:          workflow,
-		geminiSVC:         geminiService,
-		textProcessor:     textProcessor,
-		audioService:      audioService,
-		videoService:      videoService,
-		geminiService:     geminiService,
-		hfService:         hfService,
-		stockVideoService: stockVideoService,
-		composerService:   composerService,
+// validateImageSource checks image_provider and that the chosen image API is configured
+func (h *VideoHandler) validateImageSource(req models.GenerateRequest) error {
+	if req.VideoSource != services.VideoSourceImages {
+		if req.ImageProvider != "" {
+			return fmt.Errorf("image_provider requires video_source %q", services.VideoSourceImages)
+		}
+		return nil
+	}
+	switch req.ImageProvider {
+	case "", services.ImageProviderOpenAI:
+		if len(h.cfg.OpenAIAPIKeys) == 0 {
+			return fmt.Errorf("image_provider %q is not configured (set OPENAI_API_KEYS)", services.ImageProviderOpenAI)
+		}
+	case services.ImageProviderStability:
+		if len(h.cfg.StabilityAPIKeys) == 0 {
+			return fmt.Errorf("image_provider %q is not configured (set STABILITY_API_KEYS)", req.ImageProvider)
+		}
+	case services.ImageProviderLocal:
+		if h.cfg.SDWebUIURL == "" {
+			return fmt.Errorf("image_provider %q is not configured (set SD_WEBUI_URL)", req.ImageProvider)
+		}
+	default:
+		return fmt.Errorf("unsupported image_provider %q (expected openai, stability or local)", req.ImageProvider)
 	}
+	return nil
 }
 
-// Generate handles POST /api/generate
-func (h *VideoHandler) Generate(c *gin.Context) {
-	var req models.GenerateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
-		return
+// validateLocalSource checks that video_source "local" has a b-roll folder to draw from
+func (h *VideoHandler) validateLocalSource(req models.GenerateRequest) error {
+	if req.VideoSource == services.VideoSourceLocal && h.cfg.BrollDir == "" {
+		return fmt.Errorf("video_source %q is not configured (set BROLL_DIR)", services.VideoSourceLocal)
 	}
+	return nil
+}
 
-	// Validate platform
-	if req.Platform != "youtube" && req.Platform != "tiktok" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "platform must be 'youtube' or 'tiktok'"})
-		return
+// validateEndScreen checks the duration, placeholders and background of end_screen
+func validateEndScreen(es models.EndScreen) error {
+	if es.Duration != 0 && (es.Duration < services.MinEndScreenSeconds || es.Duration > services.MaxEndScreenSeconds) {
+		return fmt.Errorf("end_screen duration must be between %g and %g seconds (got %g)", services.MinEndScreenSeconds, services.MaxEndScreenSeconds, es.Duration)
 	}
+	if es.Videos < 0 || es.Videos > services.MaxEndScreenVideos {
+		return fmt.Errorf("end_screen videos must be between 1 and %d, or 0 for the default (got %d)", services.MaxEndScreenVideos, es.Videos)
+	}
+	if utf8.RuneCountInString(es.Text) > maxEndScreenTextLen {
+		return fmt.Errorf("end_screen text may have at most %d characters", maxEndScreenTextLen)
+	}
+	if es.BackgroundColor != "" && !backgroundColorPattern.MatchString(es.BackgroundColor) {
+		return fmt.Errorf("end_screen background_color %q must be #RRGGBB or a color name", es.BackgroundColor)
+	}
+	if es.BackgroundImage != "" && !utils.FileExists(services.ResolveStaticVideo(es.BackgroundImage, "")) {
+		return fmt.Errorf("end_screen background_image %q not found in static/", es.BackgroundImage)
+	}
+	return nil
+}
 
-	// Validate topic
-	if req.Topic == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "topic is required"})
-		return
+// maxEndScreenTextLen keeps the end screen prompt to a line or two
+const maxEndScreenTextLen = 80
+
+// resolveIntroOutro gives req tenant's default intro and outro where it names none, and checks
+// that the uploaded clips it names by ID are tenant's clips of that kind
+func (h *VideoHandler) resolveIntroOutro(tenant string, req *models.GenerateRequest) error {
+	for _, choice := range []struct {
+		field, kind string
+		name        *string
+	}{
+		{"intro_video", services.ClipIntro, &req.IntroVideo},
+		{"outro_video", services.ClipOutro, &req.OutroVideo},
+	} {
+		if h.introOutro == nil {
+			if isUUID(*choice.name) {
+				return fmt.Errorf("%s: uploaded intros and outros are not configured (set INTRO_OUTRO_DIR)", choice.field)
+			}
+			continue
+		}
+		if *choice.name == "" {
+			*choice.name = h.introOutro.Default(tenant, choice.kind)
+		}
+		if isUUID(*choice.name) {
+			if _, err := h.introOutro.Find(tenant, choice.kind, *choice.name); err != nil {
+				return fmt.Errorf("%s: %w", choice.field, err)
+			}
+		}
 	}
+	return nil
+}
 
-	// If no pre-written script, we need Gemini to generate one
-	if req.Script == "" && !h.geminiSVC.HasKeys() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No GEMINI_API_KEYS configured — cannot auto-generate script. Please provide a pre-written script or add GEMINI_API_KEYS to .env"})
-		return
+// isUUID reports whether s is an ID such as those of uploaded assets
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
+
+// maxBrollAssets bounds the uploaded clips one request can reference
+const maxBrollAssets = 20
+
+// validateBrollAssets checks that broll_assets are stock-footage uploads of tenant
+func (h *VideoHandler) validateBrollAssets(tenant string, req models.GenerateRequest) error {
+	if len(req.BrollAssets) == 0 {
+		return nil
+	}
+	if req.VideoSource != "" {
+		return fmt.Errorf("broll_assets are interleaved with stock footage and require an empty video_source")
+	}
+	if h.brollAssets == nil {
+		return fmt.Errorf("broll_assets are not configured (set BROLL_UPLOAD_DIR)")
 	}
+	if len(req.BrollAssets) > maxBrollAssets {
+		return fmt.Errorf("at most %d broll_assets per request", maxBrollAssets)
+	}
+	for _, id := range req.BrollAssets {
+		asset, err := h.brollAssets.Get(id)
+		if err != nil || asset.Tenant != tenant {
+			return fmt.Errorf("b-roll asset %q not found", id)
+		}
+	}
+	return nil
+}
 
-	// Set default speaking speed if not provided
+// maxNegativePrompt bounds negative_prompt, in characters
+const maxNegativePrompt = 500
+
+// validateAIVideo checks video_provider and the clip style, and that the chosen text-to-video
+// API has keys
+func (h *VideoHandler) validateAIVideo(req models.GenerateRequest) error {
+	if req.VideoSource != services.VideoSourceAI {
+		if req.VideoProvider != "" || req.Seed != 0 || req.NegativePrompt != "" || req.StyleImage != "" {
+			return fmt.Errorf("video_provider, seed, negative_prompt and style_image require video_source %q", services.VideoSourceAI)
+		}
+		return nil
+	}
+	if req.Seed < 0 || req.Seed > math.MaxUint32 {
+		return fmt.Errorf("seed must be between 0 and %d (got %d)", uint32(math.MaxUint32), req.Seed)
+	}
+	if utf8.RuneCountInString(req.NegativePrompt) > maxNegativePrompt {
+		return fmt.Errorf("negative_prompt may be at most %d characters", maxNegativePrompt)
+	}
+	if req.StyleImage != "" && !utils.FileExists(services.ResolveStaticVideo(req.StyleImage, "")) {
+		return fmt.Errorf("style_image %q not found in static/", req.StyleImage)
+	}
+	switch req.VideoProvider {
+	case "", services.VideoProviderPika:
+		if len(h.cfg.VideoAPIKeys) == 0 {
+			return fmt.Errorf("video_provider %q is not configured (set VIDEO_API_KEYS)", services.VideoProviderPika)
+		}
+	case services.VideoProviderRunway:
+		if len(h.cfg.RunwayAPIKeys) == 0 {
+			return fmt.Errorf("video_provider %q is not configured (set RUNWAY_API_KEYS)", req.VideoProvider)
+		}
+	case services.VideoProviderKling:
+		if len(h.cfg.KlingAPIKeys) == 0 {
+			return fmt.Errorf("video_provider %q is not configured (set KLING_API_KEYS)", req.VideoProvider)
+		}
+	default:
+		return fmt.Errorf("unsupported video_provider %q (expected pika, runway or kling)", req.VideoProvider)
+	}
+	return nil
+}
+
+// validateTTSProvider rejects unknown providers and providers without configured keys
+func (h *VideoHandler) validateTTSProvider(provider string) error {
+	switch provider {
+	case "", services.TTSProviderFPT:
+		if len(h.cfg.TTSAPIKeys) == 0 {
+			return fmt.Errorf("tts_provider %q is not configured (set TTS_API_KEYS)", services.TTSProviderFPT)
+		}
+	case services.TTSProviderElevenLabs:
+		if len(h.cfg.ElevenLabsAPIKeys) == 0 {
+			return fmt.Errorf("tts_provider %q is not configured (set ELEVENLABS_API_KEYS)", provider)
+		}
+	case services.TTSProviderAzure:
+		if len(h.cfg.AzureSpeechKeys) == 0 {
+			return fmt.Errorf("tts_provider %q is not configured (set AZURE_SPEECH_KEYS)", provider)
+		}
+	case services.TTSProviderOpenAI:
+		if len(h.cfg.OpenAIAPIKeys) == 0 {
+			return fmt.Errorf("tts_provider %q is not configured (set OPENAI_API_KEYS)", provider)
+		}
+	case services.TTSProviderPolly:
+		if !h.cfg.HasAWSCredentials() {
+			return fmt.Errorf("tts_provider %q is not configured (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)", provider)
+		}
+	case services.TTSProviderPiper:
+		if !h.cfg.HasPiper() {
+			return fmt.Errorf("tts_provider %q is not configured (set PIPER_URL or PIPER_MODEL)", provider)
+		}
+	case services.TTSProviderXTTS:
+		if h.cfg.XTTSURL == "" {
+			return fmt.Errorf("tts_provider %q is not configured (set XTTS_URL)", provider)
+		}
+	default:
+		return fmt.Errorf("unsupported tts_provider %q (expected fpt, elevenlabs, azure, openai, polly, piper or xtts)", provider)
+	}
+	return nil
+}
+
+// applySpeedDefault sets the platform's default speaking speed when none was given
+func applySpeedDefault(req *models.GenerateRequest) {
 	if req.SpeakingSpeed == 0 {
 		if req.Platform == "tiktok" {
 			req.SpeakingSpeed = 1.2
@@ -123,35 +1024,20 @@ func (h *VideoHandler) Generate(c *gin.Context) {
 			req.SpeakingSpeed = 1.0
 		}
 	}
-	// Validate speaking speed range
-	if req.SpeakingSpeed < 0.5 || req.SpeakingSpeed > 2.0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Speaking speed must be between 0.5 and 2.0"})
-		return
-	}
+}
 
-	// Auto-generate ContentName from topic if not provided
-	if req.ContentName == "" {
-		req.ContentName = slugify(req.Topic)
-	} else {
-		req.ContentName = slugify(req.ContentName)
+// buildContentName slugifies the content name (or topic) and suffixes it with a timestamp
+func buildContentName(req models.GenerateRequest) string {
+	name := req.ContentName
+	if name == "" {
+		name = req.Topic
 	}
-	req.ContentName = fmt.Sprintf("%s-%s", req.ContentName, time.Now().Format("0102-1504"))
-
-	// Generate job ID and register job
-	jobID := uuid.New().String()
-	h.jobManager.CreateJob(jobID, req.Platform, req.ContentName)
-
-	// Start background processing via Orchestrator
-	go h.workflow.StartGeneration(jobID, req)
-
-	// Return job ID immediately
-	c.JSON(http.StatusOK, models.GenerateResponse{
-		JobID:  jobID,
-		Status: "processing",
-	})
+	return fmt.Sprintf("%s-%s", slugify(name), time.Now().Format("0102-1504"))
 }
 
 // GetStatus handles GET /api/status/:job_id
+// ?events=true adds the job's timestamped event timeline. Download links are only included with
+// ?token=<download_token>, so a leaked job ID alone reveals the progress but not the token.
 func (h *VideoHandler) GetStatus(c *gin.Context) {
 	jobID := c.Param("job_id")
 
@@ -161,16 +1047,65 @@ func (h *VideoHandler) GetStatus(c *gin.Context) {
 		return
 	}
 
-	// Build response
+	resp := h.buildStatusResponse(job, hasDownloadToken(c, job))
+	if includeEvents, _ := strconv.ParseBool(c.Query("events")); includeEvents {
+		resp.Events = h.jobManager.History(jobID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListJobs handles GET /api/jobs
+// Optional query filters: ?status=completed&metadata.channel=cooking
+func (h *VideoHandler) ListJobs(c *gin.Context) {
+	statusFilter := c.Query("status")
+	metaFilter := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if strings.HasPrefix(key, "metadata.") && len(values) > 0 {
+			metaFilter[strings.TrimPrefix(key, "metadata.")] = values[0]
+		}
+	}
+
+	jobs := make([]models.StatusResponse, 0)
+	for _, job := range h.jobManager.ListJobs() {
+		if statusFilter != "" && job.Status != statusFilter {
+			continue
+		}
+		if !matchesMetadata(job.Metadata, metaFilter) {
+			continue
+		}
+		// Listings never carry download tokens
+		jobs = append(jobs, h.buildStatusResponse(&job, false))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs, "count": len(jobs)})
+}
+
+// requestFingerprint hashes the request payload so an idempotency key can't be reused for a different job
+func requestFingerprint(req models.GenerateRequest) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// buildStatusResponse converts a job record into the public status payload.
+// Download links are only included when withLinks is set, since they embed the job's download token.
+func (h *VideoHandler) buildStatusResponse(job *models.JobStatus, withLinks bool) models.StatusResponse {
+	jobID := job.JobID
 	resp := models.StatusResponse{
+		JobID:       job.JobID,
+		Title:       job.Title,
+		Metadata:    job.Metadata,
 		Status:      job.Status,
 		Progress:    job.Progress,
 		CurrentStep: job.CurrentStep,
 	}
 
-	if job.Status == "completed" && job.VideoPath != "" {
-		videoURL := fmt.Sprintf("/api/download/%s", jobID)
+	if withLinks && job.Status == "completed" && job.VideoPath != "" {
+		videoURL := h.storedURL(job, filepath.Base(job.VideoPath), downloadURL("/api/download", job))
+		subtitleURL := h.storedURL(job, "subtitles.srt", downloadURL("/api/download-subtitle", job))
 		resp.VideoURL = &videoURL
+		resp.SubtitleURL = &subtitleURL
+		resp.Artifacts = h.artifacts(job)
 	}
 
 	if job.Status == "completed" && job.SavedPath != "" {
@@ -182,10 +1117,138 @@ func (h *VideoHandler) GetStatus(c *gin.Context) {
 		resp.Error = &errMsg
 	}
 
-	c.JSON(http.StatusOK, resp)
+	resp.RerenderOf = job.RerenderOf
+
+	if !job.RunAt.IsZero() {
+		runAt := job.RunAt
+		resp.RunAt = &runAt
+	}
+
+	if eta, ok := h.jobManager.EstimateRemaining(jobID); ok {
+		seconds := int(math.Ceil(eta.Seconds()))
+		resp.ETASeconds = &seconds
+	}
+
+	switch {
+	case job.Status == "expired":
+		expiredAt := job.UpdatedAt
+		resp.ExpiredAt = &expiredAt
+	case (job.Status == "completed" || job.Status == "failed" || job.Status == "cancelled") && h.cfg.JobRetention > 0:
+		expiresAt := job.UpdatedAt.Add(h.cfg.JobRetention)
+		resp.ExpiresAt = &expiresAt
+	}
+
+	return resp
+}
+
+// artifacts lists the files of a completed job that exist on disk or in output storage, with
+// their download links
+func (h *VideoHandler) artifacts(job *models.JobStatus) []models.Artifact {
+	videoFormat := strings.TrimPrefix(filepath.Ext(job.VideoPath), ".")
+	if videoFormat == "" {
+		videoFormat = "mp4"
+	}
+	list := []models.Artifact{{Type: "video", Format: videoFormat, URL: h.storedURL(job, filepath.Base(job.VideoPath), downloadURL("/api/download", job))}}
+	outputDir := filepath.Join(h.cfg.TempDir, job.JobID, "output")
+	exists := func(name string) bool {
+		if _, stored := job.StoredURLs[name]; stored {
+			return true
+		}
+		_, err := os.Stat(filepath.Join(outputDir, name))
+		return err == nil
+	}
+	for _, lang := range append([]string{""}, job.Request.SubtitleLanguages...) {
+		formats := []string{"srt", "vtt", "ass"}
+		name, query := "subtitles", ""
+		if lang != "" {
+			formats = formats[:2] // translations have no styled copy
+			name, query = "subtitles."+lang, "&lang="+lang
+		}
+		for _, format := range formats {
+			if exists(name + "." + format) {
+				url := h.storedURL(job, name+"."+format, downloadURL("/api/download-subtitle", job)+"&format="+format+query)
+				list = append(list, models.Artifact{Type: "subtitle", Format: format, Language: lang, URL: url})
+			}
+		}
+	}
+	if exists(services.PreviewFileName) {
+		list = append(list, models.Artifact{Type: "preview", Format: "mp4", URL: h.storedURL(job, services.PreviewFileName, downloadURL("/api/preview", job))})
+	}
+	if exists(services.PreviewGIFFileName) {
+		list = append(list, models.Artifact{Type: "preview", Format: "gif", URL: h.storedURL(job, services.PreviewGIFFileName, downloadURL("/api/preview", job)+"&format=gif")})
+	}
+	if exists(thumbnailName) {
+		list = append(list, models.Artifact{Type: "thumbnail", Format: "jpg", URL: h.storedURL(job, thumbnailName, downloadURL("/api/download-thumbnail", job))})
+	}
+	if exists(services.TranscriptFileName) {
+		list = append(list, models.Artifact{Type: "transcript", Format: "json", URL: h.storedURL(job, services.TranscriptFileName, downloadURL("/api/download-transcript", job))})
+	}
+	if exists(services.ChaptersFileName) {
+		list = append(list, models.Artifact{Type: "chapters", Format: "txt", URL: h.storedURL(job, services.ChaptersFileName, downloadURL("/api/download-chapters", job))})
+	}
+	return list
+}
+
+// StreamJobEvents handles GET /ws/jobs/:job_id
+// It upgrades to a WebSocket and pushes progress, log and status events until the job finishes.
+func (h *VideoHandler) StreamJobEvents(c *gin.Context) {
+	jobID := c.Param("job_id")
+
+	job, exists := h.jobManager.GetJob(jobID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	// Subscribe before the upgrade so no event between snapshot and stream is lost
+	events, unsubscribe := h.jobManager.Subscribe(jobID)
+	defer unsubscribe()
+
+	ws, err := utils.UpgradeWebSocket(c.Writer, c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "WebSocket upgrade failed: " + err.Error()})
+		return
+	}
+	defer ws.Close()
+
+	// Initial snapshot so late subscribers see the current state immediately
+	if err := ws.WriteJSON(models.JobEvent{
+		JobID:     jobID,
+		Type:      "status",
+		Step:      job.CurrentStep,
+		Progress:  job.Progress,
+		Status:    job.Status,
+		Timestamp: time.Now(),
+	}); err != nil {
+		return
+	}
+	if services.JobFinished(job.Status) {
+		return
+	}
+
+	clientGone := make(chan struct{})
+	go func() {
+		ws.ReadLoop()
+		close(clientGone)
+	}()
+
+	for {
+		select {
+		case <-clientGone:
+			return
+		case event := <-events:
+			if err := ws.WriteJSON(event); err != nil {
+				return
+			}
+			if event.Type == "status" && services.JobFinished(event.Status) {
+				return
+			}
+		}
+	}
 }
 
-// DownloadSubtitle handles GET /api/download-subtitle/:job_id
+// DownloadSubtitle handles GET /api/download-subtitle/:job_id. ?format= picks srt (default), vtt or
+// the styled ass; ?lang= a translation from subtitle_languages (SRT and VTT only).
 func (h *VideoHandler) DownloadSubtitle(c *gin.Context) {
 	jobID := c.Param("job_id")
 
@@ -195,23 +1258,166 @@ func (h *VideoHandler) DownloadSubtitle(c *gin.Context) {
 		return
 	}
 
+	if !hasDownloadToken(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid download token"})
+		return
+	}
+
+	if job.Status == "expired" {
+		c.JSON(http.StatusGone, gin.H{"error": "Job has expired and its files were removed"})
+		return
+	}
+
 	if job.Status != "completed" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Job not completed yet"})
 		return
 	}
 
-	srtPath := filepath.Join(h.cfg.TempDir, jobID, "output", "subtitles.srt")
-	if _, err := os.Stat(srtPath); os.IsNotExist(err) {
+	ext, contentType := "srt", "application/x-subrip"
+	switch c.DefaultQuery("format", "srt") {
+	case "srt":
+	case "vtt":
+		ext, contentType = "vtt", "text/vtt"
+	case "ass":
+		ext, contentType = "ass", "text/x-ssa"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be srt, vtt or ass"})
+		return
+	}
+	var langSuffix string
+	if lang := c.Query("lang"); lang != "" {
+		if !services.ValidSubtitleLanguage(lang) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lang"})
+			return
+		}
+		langSuffix = "." + lang
+	}
+
+	subtitlePath := filepath.Join(h.cfg.TempDir, jobID, "output", "subtitles"+langSuffix+"."+ext)
+	if h.redirectToStored(c, job, filepath.Base(subtitlePath)) {
+		return
+	}
+	if _, err := os.Stat(subtitlePath); os.IsNotExist(err) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Subtitle file not found"})
 		return
 	}
 
-	c.Header("Content-Type", "application/x-subrip")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=subtitles_%s.srt", jobID))
-	c.File(srtPath)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=subtitles_%s%s.%s", jobID, langSuffix, ext))
+	c.File(subtitlePath)
+}
+
+// thumbnailName is the still saved next to a job's subtitles
+const thumbnailName = "thumbnail.jpg"
+
+// DownloadThumbnail handles GET /api/download-thumbnail/:job_id
+func (h *VideoHandler) DownloadThumbnail(c *gin.Context) {
+	h.downloadOutputFile(c, thumbnailName, "image/jpeg", "Thumbnail")
+}
+
+// DownloadChapters handles GET /api/download-chapters/:job_id: the YouTube chapter list
+func (h *VideoHandler) DownloadChapters(c *gin.Context) {
+	h.downloadOutputFile(c, services.ChaptersFileName, "text/plain; charset=utf-8", "Chapters")
+}
+
+// DownloadTranscript handles GET /api/download-transcript/:job_id: the narration as JSON segments
+func (h *VideoHandler) DownloadTranscript(c *gin.Context) {
+	h.downloadOutputFile(c, services.TranscriptFileName, "application/json", "Transcript")
+}
+
+// Preview handles GET /api/preview/:job_id: the 480p preview MP4, or with ?format=gif the GIF,
+// shown inline
+func (h *VideoHandler) Preview(c *gin.Context) {
+	name, contentType := services.PreviewFileName, "video/mp4"
+	switch c.DefaultQuery("format", "mp4") {
+	case "mp4":
+	case "gif":
+		name, contentType = services.PreviewGIFFileName, "image/gif"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be mp4 or gif"})
+		return
+	}
+	path, ok := h.outputFile(c, name, "Preview")
+	if !ok {
+		return
+	}
+	if !serveSeekable(c, path, contentType, "inline") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview not found"})
+	}
+}
+
+// serveSeekable streams the file at path with Range, If-Range and conditional GET support, so
+// players can seek and interrupted downloads resume; false, with nothing written, when the
+// file does not exist. The ETag changes whenever the file is re-rendered.
+func serveSeekable(c *gin.Context, path, contentType, disposition string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", disposition)
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime(), file)
+	return true
+}
+
+// downloadOutputFile serves name from a completed job's output folder as an attachment
+// "<base>_<job_id><ext>"; what names the file in the not-found error
+func (h *VideoHandler) downloadOutputFile(c *gin.Context, name, contentType, what string) {
+	path, ok := h.outputFile(c, name, what)
+	if !ok {
+		return
+	}
+	ext := filepath.Ext(name)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s%s", strings.TrimSuffix(name, ext), c.Param("job_id"), ext))
+	c.File(path)
+}
+
+// outputFile is the path of name in the output folder of the completed job :job_id, after
+// checking its download token; otherwise it writes the response (an error, or a redirect to
+// the uploaded copy) and returns false
+func (h *VideoHandler) outputFile(c *gin.Context, name, what string) (string, bool) {
+	jobID := c.Param("job_id")
+
+	job, exists := h.jobManager.GetJob(jobID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return "", false
+	}
+
+	if !hasDownloadToken(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid download token"})
+		return "", false
+	}
+
+	if job.Status == "expired" {
+		c.JSON(http.StatusGone, gin.H{"error": "Job has expired and its files were removed"})
+		return "", false
+	}
+
+	if job.Status != "completed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Job not completed yet"})
+		return "", false
+	}
+
+	if h.redirectToStored(c, job, name) {
+		return "", false
+	}
+	path := filepath.Join(h.cfg.TempDir, jobID, "output", name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": what + " not found"})
+		return "", false
+	}
+	return path, true
 }
 
-// Download handles GET /api/download/:job_id
+// Download handles GET and HEAD /api/download/:job_id: the video, with Range support
 func (h *VideoHandler) Download(c *gin.Context) {
 	jobID := c.Param("job_id")
 
@@ -221,6 +1427,16 @@ func (h *VideoHandler) Download(c *gin.Context) {
 		return
 	}
 
+	if !hasDownloadToken(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid download token"})
+		return
+	}
+
+	if job.Status == "expired" {
+		c.JSON(http.StatusGone, gin.H{"error": "Job has expired and its files were removed"})
+		return
+	}
+
 	if job.Status != "completed" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Job not completed yet"})
 		return
@@ -231,13 +1447,150 @@ func (h *VideoHandler) Download(c *gin.Context) {
 		return
 	}
 
-	// Stream video file
-	c.Header("Content-Type", "video/mp4")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=video_%s.mp4", jobID))
-	c.File(job.VideoPath)
+	if h.redirectToStored(c, job, filepath.Base(job.VideoPath)) {
+		return
+	}
+
+	ext := filepath.Ext(job.VideoPath)
+	if ext == "" {
+		ext = ".mp4"
+	}
+	disposition := fmt.Sprintf("attachment; filename=video_%s%s", jobID, ext)
+	if !serveSeekable(c, job.VideoPath, utils.VideoContentType(job.VideoPath), disposition) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video file not found"})
+	}
+}
+
+// MediaInfo handles GET /api/jobs/:job_id/mediainfo: the ffprobe data of a completed job's
+// video, so clients can check it against their specs before publishing
+func (h *VideoHandler) MediaInfo(c *gin.Context) {
+	jobID := c.Param("job_id")
+	job, exists := h.jobManager.GetJob(jobID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if !hasDownloadToken(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid download token"})
+		return
+	}
+	if job.Status == "expired" {
+		c.JSON(http.StatusGone, gin.H{"error": "Job has expired and its files were removed"})
+		return
+	}
+	if job.Status != "completed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Job not completed yet"})
+		return
+	}
+	if _, err := os.Stat(job.VideoPath); job.VideoPath == "" || err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video file not found"})
+		return
+	}
+
+	info, err := utils.ProbeMediaInfo(job.VideoPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read media info: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "mediainfo": info})
+}
+
+// downloadURL builds a download link for a job, carrying its download token
+func downloadURL(route string, job *models.JobStatus) string {
+	return fmt.Sprintf("%s/%s?token=%s", route, job.JobID, job.DownloadToken)
+}
+
+// storedURL is the URL of the uploaded copy of job's output file name, presigned when
+// STORAGE_URL_TTL is set, or local when it was not uploaded
+func (h *VideoHandler) storedURL(job *models.JobStatus, name, local string) string {
+	u, ok := job.StoredURLs[name]
+	if !ok {
+		return local
+	}
+	if h.storage != nil && h.cfg.StorageURLTTL > 0 {
+		// A signing failure leaves the object URL, which serves public buckets
+		if signed, err := h.storage.SignedURL(services.StorageKey(h.cfg.StoragePrefix, job.JobID, name), h.cfg.StorageURLTTL); err == nil {
+			return signed
+		}
+	}
+	return u
+}
+
+// redirectToStored sends the client to the uploaded copy of job's output file name, so the API
+// does not stream it; false when it was not uploaded
+func (h *VideoHandler) redirectToStored(c *gin.Context, job *models.JobStatus, name string) bool {
+	if _, ok := job.StoredURLs[name]; !ok {
+		return false
+	}
+	c.Redirect(http.StatusFound, h.storedURL(job, name, ""))
+	return true
+}
+
+// jobWithToken is the job :job_id when the request carries its download token; otherwise it
+// writes the 404 or 403 and returns false
+func (h *VideoHandler) jobWithToken(c *gin.Context) (*models.JobStatus, bool) {
+	job, exists := h.jobManager.GetJob(c.Param("job_id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, false
+	}
+	if !hasDownloadToken(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid download token"})
+		return nil, false
+	}
+	return job, true
+}
+
+// hasDownloadToken checks the ?token= query parameter against the job's download token
+func hasDownloadToken(c *gin.Context, job *models.JobStatus) bool {
+	token := c.Query("token")
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(job.DownloadToken)) == 1
+}
+
+// Limits on caller-supplied job labels
+const (
+	maxTitleLength    = 200
+	maxMetadataKeys   = 32
+	maxMetadataKeyLen = 64
+	maxMetadataValLen = 256
+)
+
+// validateMetadata enforces size limits on job title and metadata labels
+func validateMetadata(title string, metadata map[string]string) error {
+	if len(title) > maxTitleLength {
+		return fmt.Errorf("title must be at most %d characters", maxTitleLength)
+	}
+	if len(metadata) > maxMetadataKeys {
+		return fmt.Errorf("metadata may contain at most %d keys", maxMetadataKeys)
+	}
+	for k, v := range metadata {
+		if k == "" || len(k) > maxMetadataKeyLen {
+			return fmt.Errorf("metadata keys must be 1-%d characters", maxMetadataKeyLen)
+		}
+		if len(v) > maxMetadataValLen {
+			return fmt.Errorf("metadata value for %q exceeds %d characters", k, maxMetadataValLen)
+		}
+	}
+	return nil
+}
+
+// matchesMetadata reports whether labels contains every key/value pair in filter
+func matchesMetadata(labels, filter map[string]string) bool {
+	for k, v := range filter {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
 
-	// Schedule cleanup after download (1 hour)
-	go utils.ScheduleCleanup(h.cfg.TempDir, jobID, 1*time.Hour)
+// isAbsoluteHTTPURL checks that a webhook or feed URL is an absolute http(s) URL
+func isAbsoluteHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
 
 // slugify converts a string to a URL-friendly slug
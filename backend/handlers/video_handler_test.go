@@ -1,10 +1,24 @@
 package handlers
 
 import (
+	"aituber/config"
+	"aituber/models"
+	"aituber/services"
 	"aituber/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 func TestVideoHandler_BuildFinalConcatList(t *testing.T) {
@@ -75,7 +89,946 @@ func TestVideoHandler_BuildFinalConcatList(t *testing.T) {
 	})
 }
 
+func TestValidateMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{"Empty", "", nil, false},
+		{"Valid labels", "Episode 1", map[string]string{"channel": "cooking", "campaign": "tet"}, false},
+		{"Title too long", strings.Repeat("x", maxTitleLength+1), nil, true},
+		{"Empty key", "", map[string]string{"": "v"}, true},
+		{"Value too long", "", map[string]string{"k": strings.Repeat("v", maxMetadataValLen+1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMetadata(tt.title, tt.metadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateVideoSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.GenerateRequest
+		wantErr bool
+	}{
+		{"Stock footage", models.GenerateRequest{}, false},
+		{"Portrait override", models.GenerateRequest{Platform: "youtube", Orientation: "portrait"}, false},
+		{"Unknown orientation", models.GenerateRequest{Orientation: "diagonal"}, true},
+		{"Square", models.GenerateRequest{Platform: "youtube", AspectRatio: "1:1"}, false},
+		{"Unknown aspect ratio", models.GenerateRequest{AspectRatio: "4:3"}, true},
+		{"Ken Burns on stock", models.GenerateRequest{KenBurns: 0.5}, false},
+		{"Ken Burns too strong", models.GenerateRequest{KenBurns: 1.5}, true},
+		{"Ken Burns on waveform", models.GenerateRequest{VideoSource: "waveform", KenBurns: 0.5}, true},
+		{"Waveform defaults", models.GenerateRequest{VideoSource: "waveform"}, false},
+		{"Spectrum", models.GenerateRequest{VideoSource: "waveform", WaveformStyle: "spectrum"}, false},
+		{"Unknown style", models.GenerateRequest{VideoSource: "waveform", WaveformStyle: "bars"}, true},
+		{"Missing background", models.GenerateRequest{VideoSource: "waveform", BackgroundImage: "nope.jpg"}, true},
+		{"Static color", models.GenerateRequest{VideoSource: "static", BackgroundColor: "#1A1A2E"}, false},
+		{"Static bad color", models.GenerateRequest{VideoSource: "static", BackgroundColor: "red;drawtext"}, true},
+		{"Static missing avatar", models.GenerateRequest{VideoSource: "static", AvatarImage: "nope.png"}, true},
+		{"Slides", models.GenerateRequest{VideoSource: "slides", Script: "# Intro\nHello"}, false},
+		{"Slides without script", models.GenerateRequest{VideoSource: "slides", Topic: "a topic"}, true},
+		{"Storyboard on stock", models.GenerateRequest{Storyboard: true}, false},
+		{"Title cards on a color", models.GenerateRequest{TitleCards: true, BackgroundColor: "navy"}, false},
+		{"Title cards bad color", models.GenerateRequest{TitleCards: true, BackgroundColor: "red:s=1"}, true},
+		{"Title cards missing background", models.GenerateRequest{TitleCards: true, BackgroundImage: "nope.jpg"}, true},
+		{"Storyboard on static", models.GenerateRequest{VideoSource: "static", Storyboard: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVideoSource(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateVideoSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAudioOverrides(t *testing.T) {
+	zero, tooLong := 0.0, 5.0
+	tests := []struct {
+		name    string
+		req     models.GenerateRequest
+		wantErr bool
+	}{
+		{"Defaults", models.GenerateRequest{}, false},
+		{"Valid overrides", models.GenerateRequest{AudioBitrate: "128k", AudioSampleRate: 48000, AudioCrossfade: &zero}, false},
+		{"Bitrate too high", models.GenerateRequest{AudioBitrate: "640k"}, true},
+		{"Bitrate without unit", models.GenerateRequest{AudioBitrate: "192"}, true},
+		{"Unsupported sample rate", models.GenerateRequest{AudioSampleRate: 8000}, true},
+		{"Crossfade too long", models.GenerateRequest{AudioCrossfade: &tooLong}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAudioOverrides(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAudioOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateVideoOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.GenerateRequest
+		wantErr bool
+	}{
+		{"Defaults", models.GenerateRequest{}, false},
+		{"4K at 60fps", models.GenerateRequest{Resolution: "4k", FPS: 60}, false},
+		{"720p at 24fps", models.GenerateRequest{Resolution: "720p", FPS: 24}, false},
+		{"Unsupported resolution", models.GenerateRequest{Resolution: "1440p"}, true},
+		{"Unsupported fps", models.GenerateRequest{FPS: 25}, true},
+		{"Draft quality", models.GenerateRequest{Quality: "draft"}, false},
+		{"Unknown quality", models.GenerateRequest{Quality: "ultra"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVideoOverrides(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateVideoOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchesMetadata(t *testing.T) {
+	labels := map[string]string{"channel": "cooking", "campaign": "tet"}
+
+	if !matchesMetadata(labels, map[string]string{"channel": "cooking"}) {
+		t.Error("Expected subset filter to match")
+	}
+	if matchesMetadata(labels, map[string]string{"channel": "travel"}) {
+		t.Error("Expected mismatched value not to match")
+	}
+	if !matchesMetadata(nil, nil) {
+		t.Error("Expected empty filter to match everything")
+	}
+}
+
 func TestVideoHandler_Dummy(t *testing.T) {
 	// Placeholder to keep the file if needed, or we could delete it if empty.
 	// For now, let's just remove the broken part.
 }
+
+func TestVideoHandler_DownloadRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	videoPath := filepath.Join(t.TempDir(), "final.mp4")
+	if err := os.WriteFile(videoPath, []byte("video"), 0644); err != nil {
+		t.Fatalf("Failed to write mock video: %v", err)
+	}
+
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	jm.MarkCompleted("job1", videoPath, "")
+
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/download/:job_id", h.Download)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"Missing token", "/api/download/job1", http.StatusForbidden},
+		{"Wrong token", "/api/download/job1?token=nope", http.StatusForbidden},
+		{"Valid token", "/api/download/job1?token=" + job.DownloadToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestVideoHandler_MediaInfoPreconditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jm := services.NewJobManager()
+	running := jm.CreateJob("running", "youtube", "test")
+	gone := jm.CreateJob("gone", "youtube", "test")
+	jm.MarkCompleted("gone", filepath.Join(t.TempDir(), "deleted.mp4"), "")
+
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/jobs/:job_id/mediainfo", h.MediaInfo)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"Unknown job", "/api/jobs/missing/mediainfo", http.StatusNotFound},
+		{"Missing token", "/api/jobs/gone/mediainfo", http.StatusForbidden},
+		{"Running job", "/api/jobs/running/mediainfo?token=" + running.DownloadToken, http.StatusBadRequest},
+		{"Deleted video", "/api/jobs/gone/mediainfo?token=" + gone.DownloadToken, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestVideoHandler_CancelJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jm := services.NewJobManager()
+	running := jm.CreateJob("running", "youtube", "test")
+	done := jm.CreateJob("done", "youtube", "test")
+	jm.MarkCompleted("done", "/tmp/x.mp4", "")
+
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.POST("/api/jobs/:job_id/cancel", h.CancelJob)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"Unknown job", "/api/jobs/missing/cancel?token=x", http.StatusNotFound},
+		{"Missing token", "/api/jobs/running/cancel", http.StatusForbidden},
+		{"Wrong token", "/api/jobs/running/cancel?token=" + done.DownloadToken, http.StatusForbidden},
+		{"Running job", "/api/jobs/running/cancel?token=" + running.DownloadToken, http.StatusOK},
+		{"Cancelled twice", "/api/jobs/running/cancel?token=" + running.DownloadToken, http.StatusConflict},
+		{"Finished job", "/api/jobs/done/cancel?token=" + done.DownloadToken, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", tt.url, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if job, _ := jm.GetJob("running"); job.Status != "cancelled" {
+		t.Errorf("Expected the job cancelled, got %q", job.Status)
+	}
+}
+
+func TestVideoHandler_CancelJobRelaysToWorkers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jm := services.NewJobManager()
+	running := jm.CreateJob("running", "youtube", "test")
+
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, nil, nil, nil, nil, nil)
+	var relayed []string
+	h.SetRemoteCancel(func(jobID string) error {
+		relayed = append(relayed, jobID)
+		return nil
+	})
+	router := gin.New()
+	router.POST("/api/jobs/:job_id/cancel", h.CancelJob)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/jobs/running/cancel", nil))
+	if w.Code != http.StatusForbidden || relayed != nil {
+		t.Errorf("Expected a cancel without the token not to reach the workers, got %d %v", w.Code, relayed)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/jobs/running/cancel?token="+running.DownloadToken, nil))
+	if w.Code != http.StatusOK || !reflect.DeepEqual(relayed, []string{"running"}) {
+		t.Errorf("Expected the cancel to be relayed, got %d %v", w.Code, relayed)
+	}
+}
+
+func TestVideoHandler_RerenderPreconditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jm := services.NewJobManager()
+	job := jm.CreateJob("no-assets", "youtube", "test")
+	jm.MarkCompleted("no-assets", "/tmp/x.mp4", "")
+	token := "?token=" + job.DownloadToken
+
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.POST("/api/jobs/:job_id/rerender", h.Rerender)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"Unknown job", "/api/jobs/missing/rerender", http.StatusNotFound},
+		{"Missing token", "/api/jobs/no-assets/rerender", http.StatusForbidden},
+		{"Job without reusable assets", "/api/jobs/no-assets/rerender" + token, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", tt.url, strings.NewReader(`{"burn_subtitles": true}`)))
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestVideoHandler_ValidateAIVideo(t *testing.T) {
+	h := NewVideoHandler(&config.Config{VideoAPIKeys: []string{"pika"}, KlingAPIKeys: []string{"ak:sk"}}, nil, nil, nil, nil, nil, nil)
+	tests := []struct {
+		name    string
+		req     models.GenerateRequest
+		wantErr bool
+	}{
+		{"Stock footage", models.GenerateRequest{}, false},
+		{"Pika by default", models.GenerateRequest{VideoSource: "ai"}, false},
+		{"Runway without keys", models.GenerateRequest{VideoSource: "ai", VideoProvider: "runway"}, true},
+		{"Kling with keys", models.GenerateRequest{VideoSource: "ai", VideoProvider: "kling"}, false},
+		{"Unknown provider", models.GenerateRequest{VideoSource: "ai", VideoProvider: "sora"}, true},
+		{"Provider without ai source", models.GenerateRequest{VideoProvider: "pika"}, true},
+		{"Consistent style", models.GenerateRequest{VideoSource: "ai", Seed: 42, NegativePrompt: "text, watermark"}, false},
+		{"Seed out of range", models.GenerateRequest{VideoSource: "ai", Seed: -1}, true},
+		{"Missing style image", models.GenerateRequest{VideoSource: "ai", StyleImage: "nope.png"}, true},
+		{"Seed without ai source", models.GenerateRequest{Seed: 42}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.validateAIVideo(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAIVideo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVideoHandler_ValidateImageSource(t *testing.T) {
+	h := NewVideoHandler(&config.Config{OpenAIAPIKeys: []string{"sk"}}, nil, nil, nil, nil, nil, nil)
+	tests := []struct {
+		name    string
+		req     models.GenerateRequest
+		wantErr bool
+	}{
+		{"Stock footage", models.GenerateRequest{}, false},
+		{"OpenAI by default", models.GenerateRequest{VideoSource: "images"}, false},
+		{"Stability without key", models.GenerateRequest{VideoSource: "images", ImageProvider: "stability"}, true},
+		{"Unknown provider", models.GenerateRequest{VideoSource: "images", ImageProvider: "midjourney"}, true},
+		{"Provider without images source", models.GenerateRequest{ImageProvider: "openai"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.validateImageSource(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateImageSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVideoHandler_ValidateLocalSource(t *testing.T) {
+	req := models.GenerateRequest{VideoSource: "local"}
+	if err := NewVideoHandler(&config.Config{}, nil, nil, nil, nil, nil, nil).validateLocalSource(req); err == nil {
+		t.Error("Expected an error without BROLL_DIR")
+	}
+	if err := NewVideoHandler(&config.Config{BrollDir: t.TempDir()}, nil, nil, nil, nil, nil, nil).validateLocalSource(req); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// startedWorkflow records the jobs started through it
+type startedWorkflow struct {
+	started   chan models.GenerateRequest
+	continued chan models.JobStatus
+}
+
+func (w *startedWorkflow) StartGeneration(jobID string, req models.GenerateRequest) {
+	w.started <- req
+}
+
+func (w *startedWorkflow) StartRerender(jobID string, source models.JobStatus, req models.GenerateRequest) {
+}
+
+func (w *startedWorkflow) ContinueAfterReview(jobID string, job models.JobStatus) {
+	w.continued <- job
+}
+
+func TestVideoHandler_StoryboardApproval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jm := services.NewJobManager()
+	created := jm.CreateJob("job1", "youtube", "test")
+	jm.UpdateJob("job1", func(j *models.JobStatus) {
+		j.Request = models.GenerateRequest{Topic: "pets", Storyboard: true}
+	})
+	plain := jm.CreateJob("plain", "youtube", "test")
+
+	workflow := &startedWorkflow{started: make(chan models.GenerateRequest, 1)}
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, workflow, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/jobs/:job_id/storyboard", h.GetStoryboard)
+	router.PUT("/api/jobs/:job_id/storyboard", h.PutStoryboard)
+	router.POST("/api/jobs/:job_id/storyboard/approve", h.ApproveStoryboard)
+
+	doAs := func(token, method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, url+"?token="+token, strings.NewReader(body)))
+		return w
+	}
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		return doAs(created.DownloadToken, method, url, body)
+	}
+
+	if w := do("GET", "/api/jobs/job1/storyboard", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while planning, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doAs(plain.DownloadToken, "GET", "/api/jobs/plain/storyboard", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a storyboard, got %d", w.Code)
+	}
+	if w := do("POST", "/api/jobs/job1/storyboard/approve", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 before planning finished, got %d", w.Code)
+	}
+
+	jm.MarkAwaitingApproval("job1", &models.Storyboard{Scenes: []models.StoryboardScene{{Text: "Mèo ngủ"}}})
+	for _, route := range []string{"GET /api/jobs/job1/storyboard", "PUT /api/jobs/job1/storyboard", "POST /api/jobs/job1/storyboard/approve"} {
+		method, url, _ := strings.Cut(route, " ")
+		if w := doAs(plain.DownloadToken, method, url, `{"scenes": [{"text": "x"}]}`); w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 with another job's token, got %d", route, w.Code)
+		}
+	}
+	if w := do("GET", "/api/jobs/job1/storyboard", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Mèo ngủ") {
+		t.Errorf("Expected the planned storyboard, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("PUT", "/api/jobs/job1/storyboard", `{"scenes": [{"text": ""}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an empty scene to be rejected, got %d", w.Code)
+	}
+	edited := `{"scenes": [{"text": "Mèo ngủ trưa", "on_screen_text": "Giờ ngủ", "broll_keywords": "cat nap"}, {"text": "Chó chạy"}]}`
+	if w := do("PUT", "/api/jobs/job1/storyboard", edited); w.Code != http.StatusOK {
+		t.Fatalf("Expected the edit to be saved, got %d: %s", w.Code, w.Body.String())
+	}
+
+	events, unsubscribe := jm.Subscribe("job1")
+	defer unsubscribe()
+	if w := do("POST", "/api/jobs/job1/storyboard/approve", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "download_token") {
+		t.Fatalf("Expected approval without handing out the token, got %d: %s", w.Code, w.Body.String())
+	}
+	if ev := <-events; ev.Type != "status" || ev.Status != "processing" {
+		t.Errorf("Expected subscribers to see the job resume, got %+v", ev)
+	}
+	req := <-workflow.started
+	if req.Storyboard || len(req.Segments) != 2 || req.Segments[0].OnScreenText != "Giờ ngủ" || req.Segments[0].VisualPrompt != "cat nap" {
+		t.Errorf("Expected the edited scenes as segments, got %+v", req)
+	}
+	job, _ := jm.GetJob("job1")
+	if job.Status != "processing" || job.Storyboard.ApprovedAt == nil {
+		t.Errorf("Expected an approved, processing job, got %q", job.Status)
+	}
+	if w := do("PUT", "/api/jobs/job1/storyboard", edited); w.Code != http.StatusConflict {
+		t.Errorf("Expected edits after approval to be rejected, got %d", w.Code)
+	}
+}
+
+func TestVideoHandler_SubtitleReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jm := services.NewJobManager()
+	created := jm.CreateJob("job1", "youtube", "test")
+	jm.UpdateJob("job1", func(j *models.JobStatus) {
+		j.Request = models.GenerateRequest{Topic: "pets", SubtitleReview: true}
+		j.Status = "processing"
+	})
+
+	workflow := &startedWorkflow{continued: make(chan models.JobStatus, 1)}
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, workflow, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/jobs/:job_id/subtitles", h.GetSubtitles)
+	router.PUT("/api/jobs/:job_id/subtitles", h.PutSubtitles)
+	router.POST("/api/jobs/:job_id/continue", h.ContinueJob)
+
+	doAs := func(token, method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, url+"?token="+token, strings.NewReader(body)))
+		return w
+	}
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		return doAs(created.DownloadToken, method, url, body)
+	}
+
+	if w := do("GET", "/api/jobs/job1/subtitles", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 before the review is ready, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/jobs/job1/continue", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 before the review is ready, got %d", w.Code)
+	}
+
+	jm.MarkAwaitingReview("job1", []models.SubtitleCue{{Start: 0, End: 2.5, Text: "Mèo ngủ"}, {Start: 2.5, End: 4, Text: "Chó chạy"}})
+	for _, method := range []string{"GET", "PUT", "POST"} {
+		url := "/api/jobs/job1/subtitles"
+		if method == "POST" {
+			url = "/api/jobs/job1/continue"
+		}
+		if w := doAs("wrong", method, url, `{"subtitles": [{"start": 0, "end": 1, "text": "x"}]}`); w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403 without the download token, got %d", method, url, w.Code)
+		}
+	}
+	if w := do("GET", "/api/jobs/job1/subtitles", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Mèo ngủ") {
+		t.Errorf("Expected the cues to review, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, bad := range []string{
+		`{"subtitles": []}`,
+		`{"subtitles": [{"start": 0, "end": 2, "text": " "}]}`,
+		`{"subtitles": [{"start": 2, "end": 1, "text": "a"}]}`,
+		`{"subtitles": [{"start": 2, "end": 3, "text": "a"}, {"start": 1, "end": 4, "text": "b"}]}`,
+	} {
+		if w := do("PUT", "/api/jobs/job1/subtitles", bad); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", bad, w.Code)
+		}
+	}
+	edited := `{"subtitles": [{"start": 0, "end": 2.5, "text": "Mèo ngủ trưa"}, {"start": 2.6, "end": 4, "text": "Chó chạy"}]}`
+	if w := do("PUT", "/api/jobs/job1/subtitles", edited); w.Code != http.StatusOK {
+		t.Fatalf("Expected the edit to be saved, got %d: %s", w.Code, w.Body.String())
+	}
+
+	events, unsubscribe := jm.Subscribe("job1")
+	defer unsubscribe()
+	if w := do("POST", "/api/jobs/job1/continue", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "download_token") {
+		t.Fatalf("Expected the job to continue without handing out the token, got %d: %s", w.Code, w.Body.String())
+	}
+	if ev := <-events; ev.Type != "status" || ev.Status != "processing" {
+		t.Errorf("Expected subscribers to see the job resume, got %+v", ev)
+	}
+	job := <-workflow.continued
+	if job.Status != "processing" || len(job.Subtitles) != 2 || job.Subtitles[0].Text != "Mèo ngủ trưa" || job.Subtitles[1].Start != 2.6 {
+		t.Errorf("Expected the edited cues to be composed, got %q %+v", job.Status, job.Subtitles)
+	}
+	if w := do("PUT", "/api/jobs/job1/subtitles", edited); w.Code != http.StatusConflict {
+		t.Errorf("Expected edits after continuing to be rejected, got %d", w.Code)
+	}
+}
+
+func TestVideoHandler_DownloadSubtitleFormats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "job1", "output")
+	os.MkdirAll(outputDir, 0755)
+	for _, name := range []string{"subtitles.srt", "subtitles.vtt", "subtitles.en.vtt"} {
+		os.WriteFile(filepath.Join(outputDir, name), []byte(name), 0644)
+	}
+
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	jm.MarkCompleted("job1", filepath.Join(outputDir, "final.mp4"), "")
+
+	h := NewVideoHandler(&config.Config{TempDir: tempDir}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/download-subtitle/:job_id", h.DownloadSubtitle)
+
+	tests := []struct {
+		query string
+		want  int
+		body  string
+	}{
+		{"", http.StatusOK, "subtitles.srt"},
+		{"&format=vtt", http.StatusOK, "subtitles.vtt"},
+		{"&format=vtt&lang=en", http.StatusOK, "subtitles.en.vtt"},
+		{"&lang=ja", http.StatusNotFound, ""},
+		{"&lang=../../etc", http.StatusBadRequest, ""},
+		{"&format=txt", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/download-subtitle/job1?token="+job.DownloadToken+tt.query, nil))
+		if w.Code != tt.want || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%q: got %d %q", tt.query, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestValidateAssemblyOptions_SubtitleLanguages(t *testing.T) {
+	if err := validateAssemblyOptions(models.GenerateRequest{SubtitleLanguages: []string{"en", "pt-BR"}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, langs := range [][]string{{"english"}, {"en/../x"}, make([]string, services.MaxSubtitleLanguages+1)} {
+		if err := validateAssemblyOptions(models.GenerateRequest{SubtitleLanguages: langs}); err == nil {
+			t.Errorf("Expected %q to be rejected", langs)
+		}
+	}
+}
+
+func TestVideoHandler_StatusArtifacts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "job1", "output")
+	os.MkdirAll(outputDir, 0755)
+	for _, name := range []string{"subtitles.srt", "subtitles.vtt", "subtitles.ja.srt", "preview.gif", "thumbnail.jpg", "transcript.json", "chapters.txt"} {
+		os.WriteFile(filepath.Join(outputDir, name), []byte(name), 0644)
+	}
+
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	jm.UpdateJob("job1", func(j *models.JobStatus) {
+		j.Request = models.GenerateRequest{SubtitleLanguages: []string{"ja", "en"}}
+	})
+	jm.MarkCompleted("job1", filepath.Join(outputDir, "final.mp4"), "")
+
+	h := NewVideoHandler(&config.Config{TempDir: tempDir}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/status/:job_id", h.GetStatus)
+	router.GET("/api/download-thumbnail/:job_id", h.DownloadThumbnail)
+	router.GET("/api/download-chapters/:job_id", h.DownloadChapters)
+	router.GET("/api/preview/:job_id", h.Preview)
+
+	token := "?token=" + job.DownloadToken
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/status/job1"+token, nil))
+	var resp models.StatusResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	want := []models.Artifact{
+		{Type: "video", Format: "mp4", URL: "/api/download/job1" + token},
+		{Type: "subtitle", Format: "srt", URL: "/api/download-subtitle/job1" + token + "&format=srt"},
+		{Type: "subtitle", Format: "vtt", URL: "/api/download-subtitle/job1" + token + "&format=vtt"},
+		{Type: "subtitle", Format: "srt", Language: "ja", URL: "/api/download-subtitle/job1" + token + "&format=srt&lang=ja"},
+		{Type: "preview", Format: "gif", URL: "/api/preview/job1" + token + "&format=gif"},
+		{Type: "thumbnail", Format: "jpg", URL: "/api/download-thumbnail/job1" + token},
+		{Type: "transcript", Format: "json", URL: "/api/download-transcript/job1" + token},
+		{Type: "chapters", Format: "txt", URL: "/api/download-chapters/job1" + token},
+	}
+	if !reflect.DeepEqual(resp.Artifacts, want) {
+		t.Errorf("artifacts:\n got %+v\nwant %+v", resp.Artifacts, want)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", want[5].URL, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "thumbnail.jpg" {
+		t.Errorf("thumbnail download: got %d %q", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", want[7].URL, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "chapters.txt" || w.Header().Get("Content-Disposition") != "attachment; filename=chapters_job1.txt" {
+		t.Errorf("chapters download: got %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Disposition"))
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/download-thumbnail/job1", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected a thumbnail without token to be refused, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", want[4].URL, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "preview.gif" || w.Header().Get("Content-Type") != "image/gif" || w.Header().Get("Content-Disposition") != "inline" {
+		t.Errorf("GIF preview: got %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+	for query, code := range map[string]int{"": http.StatusNotFound, "&format=webp": http.StatusBadRequest} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/preview/job1"+token+query, nil))
+		if w.Code != code {
+			t.Errorf("preview%s: expected %d, got %d", query, code, w.Code)
+		}
+	}
+}
+
+func TestVideoHandler_StoredOutputs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Uploaded, then removed from disk (STORAGE_DELETE_LOCAL)
+	tempDir := t.TempDir()
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	jm.UpdateJob("job1", func(j *models.JobStatus) {
+		j.StoredURLs = map[string]string{
+			"final.mp4":     "https://cdn.example.com/job1/final.mp4",
+			"subtitles.srt": "https://cdn.example.com/job1/subtitles.srt",
+			"thumbnail.jpg": "https://cdn.example.com/job1/thumbnail.jpg",
+		}
+	})
+	jm.MarkCompleted("job1", filepath.Join(tempDir, "job1", "output", "final.mp4"), "")
+
+	h := NewVideoHandler(&config.Config{TempDir: tempDir}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/status/:job_id", h.GetStatus)
+	router.GET("/api/download/:job_id", h.Download)
+	router.GET("/api/download-subtitle/:job_id", h.DownloadSubtitle)
+	router.GET("/api/download-thumbnail/:job_id", h.DownloadThumbnail)
+	router.GET("/api/download-chapters/:job_id", h.DownloadChapters)
+
+	token := "?token=" + job.DownloadToken
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/status/job1"+token, nil))
+	var resp models.StatusResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	want := []models.Artifact{
+		{Type: "video", Format: "mp4", URL: "https://cdn.example.com/job1/final.mp4"},
+		{Type: "subtitle", Format: "srt", URL: "https://cdn.example.com/job1/subtitles.srt"},
+		{Type: "thumbnail", Format: "jpg", URL: "https://cdn.example.com/job1/thumbnail.jpg"},
+	}
+	if resp.VideoURL == nil || *resp.VideoURL != want[0].URL || !reflect.DeepEqual(resp.Artifacts, want) {
+		t.Errorf("Expected the stored URLs, got %v %+v", resp.VideoURL, resp.Artifacts)
+	}
+
+	for route, location := range map[string]string{
+		"/api/download/job1" + token:                       want[0].URL,
+		"/api/download-subtitle/job1" + token:              want[1].URL,
+		"/api/download-thumbnail/job1" + token:             want[2].URL,
+		"/api/download-subtitle/job1" + token + "&lang=ja": "",
+		"/api/download-chapters/job1" + token:              "",
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", route, nil))
+		if location == "" {
+			if w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected 404 for a file neither on disk nor stored, got %d", route, w.Code)
+			}
+		} else if w.Code != http.StatusFound || w.Header().Get("Location") != location {
+			t.Errorf("%s: expected a redirect to %s, got %d %q", route, location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestValidateAssemblyOptions_Preview(t *testing.T) {
+	for _, preview := range []string{"", "mp4", "gif", "both"} {
+		if err := validateAssemblyOptions(models.GenerateRequest{Preview: preview}); err != nil {
+			t.Errorf("Unexpected error for %q: %v", preview, err)
+		}
+	}
+	if err := validateAssemblyOptions(models.GenerateRequest{Preview: "webp"}); err == nil {
+		t.Error("Expected an unknown preview to be rejected")
+	}
+}
+
+func TestValidateAssemblyOptions_EndScreen(t *testing.T) {
+	ok := models.EndScreen{Enabled: true, Duration: 15, Videos: 1, Text: "Đăng ký kênh nhé!", BackgroundColor: "#202040"}
+	if err := validateAssemblyOptions(models.GenerateRequest{EndScreen: ok}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for name, es := range map[string]models.EndScreen{
+		"too short":          {Enabled: true, Duration: 3},
+		"too long":           {Enabled: true, Duration: 25},
+		"too many videos":    {Enabled: true, Videos: 3},
+		"bad color":          {Enabled: true, BackgroundColor: "red,drawtext"},
+		"missing background": {Enabled: true, BackgroundImage: "nope.jpg"},
+	} {
+		if err := validateAssemblyOptions(models.GenerateRequest{EndScreen: es}); err == nil {
+			t.Errorf("Expected end_screen %s to be rejected", name)
+		}
+	}
+}
+
+func TestValidateAssemblyOptions_OutputFormat(t *testing.T) {
+	if err := validateAssemblyOptions(models.GenerateRequest{OutputFormat: "webm", VideoCodec: "av1"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, req := range []models.GenerateRequest{{OutputFormat: "avi"}, {OutputFormat: "webm", VideoCodec: "h265"}, {VideoCodec: "theora"}} {
+		if err := validateAssemblyOptions(req); err == nil {
+			t.Errorf("Expected %s/%s to be rejected", req.OutputFormat, req.VideoCodec)
+		}
+	}
+}
+
+// signingStorage signs URLs as the bucket with the key and ttl in the query
+type signingStorage struct{}
+
+func (signingStorage) Upload(ctx context.Context, key, localPath string) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (signingStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	return fmt.Sprintf("https://bucket.example.com/%s?expires=%d", key, int(ttl.Seconds())), nil
+}
+
+func TestVideoHandler_SignedStoredURLs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Uploaded and still on disk
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "job1", "output")
+	os.MkdirAll(outputDir, 0755)
+	os.WriteFile(filepath.Join(outputDir, "final.mp4"), []byte("video"), 0644)
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	jm.UpdateJob("job1", func(j *models.JobStatus) {
+		j.StoredURLs = map[string]string{"final.mp4": "https://cdn.example.com/renders/job1/final.mp4"}
+	})
+	jm.MarkCompleted("job1", filepath.Join(outputDir, "final.mp4"), "")
+
+	h := NewVideoHandler(&config.Config{TempDir: tempDir, StoragePrefix: "renders", StorageURLTTL: time.Hour}, jm, nil, nil, nil, nil, nil)
+	h.SetOutputStorage(signingStorage{})
+	router := gin.New()
+	router.GET("/api/status/:job_id", h.GetStatus)
+	router.GET("/api/download/:job_id", h.Download)
+
+	signed := "https://bucket.example.com/renders/job1/final.mp4?expires=3600"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/status/job1?token="+job.DownloadToken, nil))
+	var resp models.StatusResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.VideoURL == nil || *resp.VideoURL != signed {
+		t.Errorf("Expected a signed video URL, got %v", resp.VideoURL)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/download/job1?token="+job.DownloadToken, nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != signed {
+		t.Errorf("Expected the download to redirect to the signed URL, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestVideoHandler_DownloadRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "job1", "output")
+	os.MkdirAll(outputDir, 0755)
+	videoPath := filepath.Join(outputDir, "final.mp4")
+	os.WriteFile(videoPath, []byte("0123456789"), 0644)
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	jm.MarkCompleted("job1", videoPath, "")
+
+	h := NewVideoHandler(&config.Config{TempDir: tempDir}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/download/:job_id", h.Download)
+	router.HEAD("/api/download/:job_id", h.Download)
+	route := "/api/download/job1?token=" + job.DownloadToken
+	get := func(method string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, route, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("HEAD", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Length") != "10" || w.Header().Get("Accept-Ranges") != "bytes" || etag == "" || w.Body.Len() != 0 {
+		t.Fatalf("Unexpected HEAD response %d %v", w.Code, w.Header())
+	}
+
+	w = get("GET", map[string]string{"Range": "bytes=2-5", "If-Range": etag})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" || w.Header().Get("Content-Range") != "bytes 2-5/10" {
+		t.Errorf("Expected bytes 2-5, got %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Range"))
+	}
+	if w.Header().Get("Content-Type") != "video/mp4" || !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment; filename=video_job1.mp4") {
+		t.Errorf("Unexpected headers %v", w.Header())
+	}
+
+	// A resume against an earlier render gets the whole new file
+	w = get("GET", map[string]string{"Range": "bytes=2-5", "If-Range": `"stale"`})
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Errorf("Expected the full file for a stale If-Range, got %d %q", w.Code, w.Body.String())
+	}
+
+	os.Remove(videoPath)
+	w = get("GET", nil)
+	if w.Code != http.StatusNotFound || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected a JSON 404 for a removed video, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestVideoHandler_DeleteJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "job1", "output")
+	os.MkdirAll(outputDir, 0755)
+	os.WriteFile(filepath.Join(outputDir, "final.mp4"), []byte("video"), 0644)
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	running := jm.CreateJob("job2", "youtube", "test")
+
+	h := NewVideoHandler(&config.Config{TempDir: tempDir}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/download/:job_id", h.Download)
+	router.DELETE("/api/jobs/:job_id", h.DeleteJob)
+	do := func(method, route string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, route, nil))
+		return w.Code
+	}
+
+	if code := do("DELETE", "/api/jobs/job2?token="+running.DownloadToken); code != http.StatusConflict {
+		t.Errorf("Expected 409 for a running job, got %d", code)
+	}
+	jm.MarkCompleted("job1", filepath.Join(outputDir, "final.mp4"), "")
+	if code := do("DELETE", "/api/jobs/job1?token=wrong"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the download token, got %d", code)
+	}
+
+	token := "?token=" + job.DownloadToken
+	for i := 0; i < 2; i++ {
+		if code := do("GET", "/api/download/job1"+token); code != http.StatusOK {
+			t.Fatalf("Download %d: expected 200, got %d", i+1, code)
+		}
+	}
+	if code := do("DELETE", "/api/jobs/job1"+token); code != http.StatusOK {
+		t.Fatalf("Expected the delete to succeed, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "job1")); !os.IsNotExist(err) {
+		t.Error("Expected the job's files to be removed")
+	}
+	if code := do("GET", "/api/download/job1"+token); code != http.StatusGone {
+		t.Errorf("Expected 410 after the delete, got %d", code)
+	}
+	if code := do("DELETE", "/api/jobs/job1"+token); code != http.StatusGone {
+		t.Errorf("Expected 410 for a second delete, got %d", code)
+	}
+}
+
+func TestVideoHandler_StatusWithoutToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jm := services.NewJobManager()
+	job := jm.CreateJob("job1", "youtube", "test")
+	jm.MarkCompleted("job1", filepath.Join(t.TempDir(), "final.mp4"), "")
+
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, nil, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/api/status/:job_id", h.GetStatus)
+
+	for _, route := range []string{"/api/status/job1", "/api/status/job1?token=wrong"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", route, nil))
+		var resp models.StatusResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || resp.Status != "completed" {
+			t.Fatalf("%s: expected the job's status, got %d %s", route, w.Code, w.Body.String())
+		}
+		if resp.VideoURL != nil || resp.SubtitleURL != nil || resp.Artifacts != nil || strings.Contains(w.Body.String(), job.DownloadToken) {
+			t.Errorf("%s: expected no download links without the token, got %s", route, w.Body.String())
+		}
+	}
+}
+
+func TestVideoHandler_EnqueueValidates(t *testing.T) {
+	jm := services.NewJobManager()
+	h := NewVideoHandler(&config.Config{TempDir: t.TempDir()}, jm, nil, nil, nil, nil, nil)
+
+	base := models.GenerateRequest{Platform: "youtube", Topic: "test", Script: "Xin chào"}
+	for name, mutate := range map[string]func(*models.GenerateRequest){
+		"bad callback":   func(r *models.GenerateRequest) { r.CallbackURL = "ftp://example.com/hook" },
+		"bad transition": func(r *models.GenerateRequest) { r.VideoTransition = "bogus" },
+		"bad speed":      func(r *models.GenerateRequest) { r.SpeakingSpeed = 3 },
+	} {
+		req := base
+		mutate(&req)
+		if _, err := h.Enqueue(req); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+	if jobs := jm.ListJobs(); len(jobs) != 0 {
+		t.Errorf("Expected no jobs for rejected requests, got %d", len(jobs))
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxVoiceSampleSize bounds the reference recording of POST /api/voices/clone
+const maxVoiceSampleSize = 10 << 20
+
+// VoiceHandler clones voices from reference audio and lists each tenant's cloned voices
+type VoiceHandler struct {
+	voices  *services.VoiceStore
+	cloners map[string]services.VoiceCloner // by TTS provider name
+}
+
+// NewVoiceHandler creates a VoiceHandler for the providers in cloners
+func NewVoiceHandler(voices *services.VoiceStore, cloners map[string]services.VoiceCloner) *VoiceHandler {
+	return &VoiceHandler{voices: voices, cloners: cloners}
+}
+
+// ListVoices handles GET /api/voices
+func (vh *VoiceHandler) ListVoices(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	voices := vh.voices.List(tenant)
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "voices": voices, "count": len(voices)})
+}
+
+// CloneVoice handles POST /api/voices/clone (multipart: name, audio, optional provider)
+func (vh *VoiceHandler) CloneVoice(c *gin.Context) {
+	tenant, err := tenantFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVoiceSampleSize+1<<20)
+	name := strings.TrimSpace(c.PostForm("name"))
+	if err := services.ValidateVoiceName(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	provider, err := vh.cloneProvider(c.PostForm("provider"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audio file is required"})
+		return
+	}
+	defer file.Close()
+	if header.Size > maxVoiceSampleSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("audio may be at most %d MB", maxVoiceSampleSize>>20)})
+		return
+	}
+	sample, err := io.ReadAll(file)
+	if err != nil || len(sample) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read audio file"})
+		return
+	}
+
+	voiceID, err := vh.cloners[provider].CloneVoice(c.Request.Context(), name, sample)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("%s voice cloning failed: %v", provider, err)})
+		return
+	}
+	voice := models.ClonedVoice{Name: name, VoiceID: voiceID, Provider: provider, CreatedAt: time.Now()}
+	if err := vh.voices.Add(tenant, voice); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, voice)
+}
+
+// cloneProvider picks the requested cloning provider, or the only/preferred configured one
+func (vh *VoiceHandler) cloneProvider(provider string) (string, error) {
+	if provider != "" {
+		if _, ok := vh.cloners[provider]; !ok {
+			return "", fmt.Errorf("provider %q cannot clone voices (configured: %s)", provider, vh.cloneProviderNames())
+		}
+		return provider, nil
+	}
+	for _, name := range []string{services.TTSProviderElevenLabs, services.TTSProviderXTTS} {
+		if _, ok := vh.cloners[name]; ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no voice cloning provider is configured (set ELEVENLABS_API_KEYS or XTTS_URL)")
+}
+
+func (vh *VoiceHandler) cloneProviderNames() string {
+	names := make([]string, 0, len(vh.cloners))
+	for name := range vh.cloners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// resolveClonedVoice replaces a cloned voice's name in req.Voice by its provider ID and selects that
+// provider when the request names none
+func resolveClonedVoice(voices *services.VoiceStore, tenant string, req *models.GenerateRequest) error {
+	if voices == nil {
+		return nil
+	}
+	voice, ok := voices.Find(tenant, req.Voice)
+	if !ok {
+		return nil
+	}
+	if req.TTSProvider != "" && req.TTSProvider != voice.Provider {
+		return fmt.Errorf("voice %q was cloned with %s, not %s", voice.Name, voice.Provider, req.TTSProvider)
+	}
+	req.Voice = voice.VoiceID
+	req.TTSProvider = voice.Provider
+	return nil
+}
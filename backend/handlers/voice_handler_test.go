@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"aituber/models"
+	"aituber/services"
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeCloner struct{ samples map[string][]byte }
+
+func (f *fakeCloner) CloneVoice(ctx context.Context, name string, sample []byte) (string, error) {
+	f.samples[name] = sample
+	return "cloned-" + name, nil
+}
+
+func cloneRequest(t *testing.T, fields map[string]string, sample []byte) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for key, value := range fields {
+		w.WriteField(key, value)
+	}
+	if sample != nil {
+		part, _ := w.CreateFormFile("audio", "sample.wav")
+		part.Write(sample)
+	}
+	w.Close()
+	req := httptest.NewRequest("POST", "/api/voices/clone", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set(tenantHeader, "acme")
+	return req
+}
+
+func TestVoiceHandler_CloneVoice(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := services.NewVoiceStore("")
+	cloner := &fakeCloner{samples: make(map[string][]byte)}
+	h := NewVoiceHandler(store, map[string]services.VoiceCloner{services.TTSProviderXTTS: cloner})
+	router := gin.New()
+	router.POST("/api/voices/clone", h.CloneVoice)
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+		sample []byte
+		want   int
+	}{
+		{"Clone with the only provider", map[string]string{"name": "host"}, []byte("RIFF-sample"), http.StatusCreated},
+		{"Missing audio", map[string]string{"name": "host"}, nil, http.StatusBadRequest},
+		{"Invalid name", map[string]string{"name": "../host"}, []byte("RIFF"), http.StatusBadRequest},
+		{"Provider without cloning", map[string]string{"name": "host", "provider": "fpt"}, []byte("RIFF"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, cloneRequest(t, tt.fields, tt.sample))
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if string(cloner.samples["host"]) != "RIFF-sample" {
+		t.Errorf("Sample not forwarded to the provider: %q", cloner.samples["host"])
+	}
+	if voices := store.List("acme"); len(voices) != 1 || voices[0].VoiceID != "cloned-host" {
+		t.Errorf("Expected the cloned voice stored for the tenant, got %+v", voices)
+	}
+
+	// The name selects the voice and its provider; other tenants and explicit providers don't match
+	req := models.GenerateRequest{Voice: "Host"}
+	if err := resolveClonedVoice(store, "acme", &req); err != nil || req.Voice != "cloned-host" || req.TTSProvider != services.TTSProviderXTTS {
+		t.Errorf("Unexpected resolution %+v (err %v)", req, err)
+	}
+	req = models.GenerateRequest{Voice: "host"}
+	if resolveClonedVoice(store, services.DefaultTenant, &req); req.Voice != "host" {
+		t.Errorf("Voice of another tenant should not resolve, got %q", req.Voice)
+	}
+	req = models.GenerateRequest{Voice: "host", TTSProvider: services.TTSProviderElevenLabs}
+	if err := resolveClonedVoice(store, "acme", &req); err == nil {
+		t.Error("Expected an error for a voice cloned with another provider")
+	}
+}
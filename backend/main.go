@@ -3,11 +3,13 @@ package main
 import (
 	"aituber/config"
 	"aituber/handlers"
+	"aituber/models"
 	"aituber/services"
 	"aituber/utils"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -22,6 +24,12 @@ func main() {
 	}
 	log.Printf("Configuration loaded: %s", cfg)
 
+	// Render workers only consume the dispatch queue; they serve no HTTP
+	if cfg.Mode == "worker" {
+		runWorker(cfg)
+		return
+	}
+
 	// Create Gin router
 	router := gin.Default()
 
@@ -29,7 +37,7 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -44,8 +52,217 @@ func main() {
 	})
 
 	// --- SETUP DEPENDENCY INJECTION ---
-	// 1. API pools
-	ttsPool := utils.NewAPIKeyPool(cfg.TTSAPIKeys)
+	// 1. Job Manager
+	jobManager := services.NewJobManager()
+	webhookService := services.NewWebhookService(cfg.WebhookSecret, cfg.PublicBaseURL, cfg.WebhookMaxRetries)
+	jobManager.OnFinished(webhookService.NotifyJob)
+	go services.StartJobSweeper(jobManager, cfg.TempDir, cfg.JobRetention, cfg.JobSweepInterval, nil)
+
+	// Output storage, uploaded to by the pipeline and linked from job statuses
+	storage := outputStorage(cfg)
+
+	// 2. Orchestrator Workflow: in-process, or dispatched to render workers over NATS
+	geminiService := services.NewGeminiService(cfg.GeminiAPIKeys)
+	var workflowSvc services.IVideoWorkflow
+	var deliverFPTCallback func(models.FPTCallback) error
+	var remoteCancel func(jobID string) error
+	if cfg.Mode == "api" {
+		nc, err := utils.ConnectNATS(cfg.NATSURL, "aituber-api")
+		if err != nil {
+			log.Fatalf("Failed to connect to NATS: %v", err)
+		}
+		if err := services.StartWorkerEventConsumer(nc, jobManager); err != nil {
+			log.Fatalf("Failed to subscribe to worker events: %v", err)
+		}
+		go func() {
+			<-nc.Done()
+			log.Fatalf("Lost NATS connection; exiting so the supervisor restarts the API")
+		}()
+		workflowSvc = services.NewQueueWorkflow(nc, jobManager, cfg.WorkerDispatchWait)
+		deliverFPTCallback = func(cb models.FPTCallback) error { return services.PublishFPTCallback(nc, cb) }
+		remoteCancel = func(jobID string) error { return services.PublishCancel(nc, jobID) }
+		log.Printf("API mode: dispatching jobs to render workers via %s", cfg.NATSURL)
+	} else {
+		fptCallbacks := services.NewFPTCallbackHub()
+		workflowSvc = newPipeline(cfg, jobManager, geminiService, fptCallbacks, storage)
+		deliverFPTCallback = func(cb models.FPTCallback) error {
+			fptCallbacks.Notify(cb)
+			return nil
+		}
+	}
+
+	// Scheduled jobs (run_at in the future)
+	scheduler := services.NewJobScheduler(jobManager, workflowSvc)
+	go scheduler.Run(nil)
+
+	// 3. Initialize handlers
+	lexiconStore := services.NewLexiconStore(cfg.LexiconFile)
+	voiceStore := services.NewVoiceStore(cfg.VoicesFile)
+	videoHandler := handlers.NewVideoHandler(cfg, jobManager, workflowSvc, geminiService, scheduler, lexiconStore, voiceStore)
+	lexiconHandler := handlers.NewLexiconHandler(lexiconStore)
+	promptTemplateStore := services.NewPromptTemplateStore(cfg.PromptTemplateFile)
+	videoHandler.SetPromptTemplates(promptTemplateStore)
+	videoHandler.SetOutputStorage(storage)
+	videoHandler.SetRemoteCancel(remoteCancel)
+	promptTemplateHandler := handlers.NewPromptTemplateHandler(promptTemplateStore, models.PromptTemplate{Template: cfg.PromptTemplate, Branding: cfg.PromptBranding})
+	voiceHandler := handlers.NewVoiceHandler(voiceStore, voiceCloners(cfg))
+	assetHandler := handlers.NewAssetHandler(services.NewBrollAssetStore(cfg.BrollUploadDir), services.NewIntroOutroStore(cfg.IntroOutroDir))
+	musicHandler := handlers.NewMusicHandler(services.MusicLibraryDir)
+	seriesHandler := handlers.NewSeriesHandler(cfg, jobManager, workflowSvc, geminiService)
+	ttsCallbackHandler := handlers.NewTTSCallbackHandler(cfg.FPTCallbackSecret, deliverFPTCallback)
+	adminHandler := handlers.NewAdminHandler(cfg, jobManager, services.NewTTSAuditLog(cfg.TTSAuditDir))
+
+	// RSS/Atom subscriptions enqueue jobs through the same path as POST /api/generate
+	feedService := services.NewFeedService(videoHandler.Enqueue, cfg.MaxTextLength)
+	go feedService.StartPolling(cfg.FeedPollInterval, nil)
+	feedHandler := handlers.NewFeedHandler(feedService)
+
+	// Real-time job progress
+	router.GET("/ws/jobs/:job_id", videoHandler.StreamJobEvents)
+
+	// API routes
+	api := router.Group("/api")
+	{
+		api.POST("/generate", videoHandler.Generate)
+		api.GET("/jobs", videoHandler.ListJobs)
+		api.POST("/jobs/:job_id/rerender", videoHandler.Rerender)
+		api.POST("/jobs/:job_id/cancel", videoHandler.CancelJob)
+		api.DELETE("/jobs/:job_id", videoHandler.DeleteJob)
+		api.GET("/jobs/:job_id/storyboard", videoHandler.GetStoryboard)
+		api.PUT("/jobs/:job_id/storyboard", videoHandler.PutStoryboard)
+		api.POST("/jobs/:job_id/storyboard/approve", videoHandler.ApproveStoryboard)
+		api.GET("/jobs/:job_id/subtitles", videoHandler.GetSubtitles)
+		api.PUT("/jobs/:job_id/subtitles", videoHandler.PutSubtitles)
+		api.POST("/jobs/:job_id/continue", videoHandler.ContinueJob)
+		api.GET("/jobs/:job_id/mediainfo", videoHandler.MediaInfo)
+		api.GET("/status/:job_id", videoHandler.GetStatus)
+		api.GET("/download/:job_id", videoHandler.Download)
+		api.HEAD("/download/:job_id", videoHandler.Download)
+		api.GET("/download-subtitle/:job_id", videoHandler.DownloadSubtitle)
+		api.GET("/download-thumbnail/:job_id", videoHandler.DownloadThumbnail)
+		api.GET("/preview/:job_id", videoHandler.Preview)
+		api.GET("/download-chapters/:job_id", videoHandler.DownloadChapters)
+		api.GET("/download-transcript/:job_id", videoHandler.DownloadTranscript)
+
+		// Series routes
+		api.POST("/generate-series", seriesHandler.GenerateSeries)
+		api.GET("/series-status/:series_id", seriesHandler.GetSeriesStatus)
+		api.POST("/retry-series-part/:series_id/:part_index", seriesHandler.RetrySeriesPart)
+
+		// Feed subscription routes
+		api.POST("/feeds", feedHandler.CreateFeed)
+		api.GET("/feeds", feedHandler.ListFeeds)
+		api.DELETE("/feeds/:feed_id", feedHandler.DeleteFeed)
+
+		// Bundled background music
+		api.GET("/music", musicHandler.ListMusic)
+
+		// Pronunciation lexicon routes (per X-Tenant-ID)
+		api.GET("/lexicon", lexiconHandler.GetLexicon)
+		api.PUT("/lexicon", lexiconHandler.PutLexicon)
+
+		// Visual prompt template of AI clips (per X-Tenant-ID)
+		api.GET("/prompt-template", promptTemplateHandler.GetPromptTemplate)
+		api.PUT("/prompt-template", promptTemplateHandler.PutPromptTemplate)
+
+		// Voice cloning routes (per X-Tenant-ID)
+		api.GET("/voices", voiceHandler.ListVoices)
+		api.POST("/voices/clone", voiceHandler.CloneVoice)
+
+		// Uploaded b-roll (per X-Tenant-ID), referenced by GenerateRequest.broll_assets
+		api.GET("/assets/broll", assetHandler.ListBroll)
+		api.POST("/assets/broll", assetHandler.UploadBroll)
+
+		// Uploaded intros and outros (per X-Tenant-ID), :kind is "intros" or "outros"
+		api.GET("/assets/:kind", assetHandler.ListClips)
+		api.POST("/assets/:kind", assetHandler.UploadClip)
+		api.PUT("/assets/:kind/default", assetHandler.SetDefaultClip)
+		api.DELETE("/assets/:kind/:id", assetHandler.DeleteClip)
+
+		// Provider callbacks, authenticated by their own secret
+		api.POST("/internal/tts-callback", ttsCallbackHandler.FPTCallback)
+
+		// Admin routes
+		admin := api.Group("/admin", handlers.RequireAdminToken(cfg.AdminToken))
+		admin.POST("/cleanup", adminHandler.Cleanup)
+		admin.GET("/jobs/:job_id/tts-audit", adminHandler.TTSAudit)
+	}
+
+	// Start server
+	addr := fmt.Sprintf(":%s", cfg.Port)
+	log.Printf("Starting server on %s", addr)
+	if err := router.Run(addr); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// voiceCloners returns the configured TTS providers that can clone voices, by provider name.
+// They are built here rather than taken from the AudioService, which only exists in render processes.
+func voiceCloners(cfg *config.Config) map[string]services.VoiceCloner {
+	cloners := make(map[string]services.VoiceCloner)
+	if len(cfg.ElevenLabsAPIKeys) > 0 {
+		cloners[services.TTSProviderElevenLabs] = services.NewElevenLabsTTS(utils.NewAPIKeyPool(cfg.ElevenLabsAPIKeys), cfg.ElevenLabsModel).(services.VoiceCloner)
+	}
+	if cfg.XTTSURL != "" {
+		cloners[services.TTSProviderXTTS] = services.NewXTTSTTS(cfg.XTTSURL, cfg.XTTSLanguage, cfg.XTTSDefaultSpeaker).(services.VoiceCloner)
+	}
+	return cloners
+}
+
+// ttsKeyPool builds a provider's key pool with its configured per-key rate limit
+func ttsKeyPool(cfg *config.Config, provider string, keys []string) *utils.APIKeyPool {
+	pool := utils.NewAPIKeyPool(keys)
+	if rate := cfg.TTSRateLimits[provider]; rate > 0 {
+		pool.SetRateLimit(rate)
+		log.Printf("TTS rate limit for %s: %g requests/s per key (%d keys)", provider, rate, len(keys))
+	}
+	return pool
+}
+
+// registerTTSProviders registers every TTS engine that has credentials or an endpoint configured
+func registerTTSProviders(cfg *config.Config, audioService *services.AudioService, fptCallbacks *services.FPTCallbackHub) {
+	if len(cfg.TTSAPIKeys) > 0 {
+		breaker := utils.NewCircuitBreaker(cfg.FPTCircuitWindow, cfg.FPTCircuitFailureRatio, cfg.FPTCircuitMinCalls, cfg.FPTCircuitCooldown)
+		budget := utils.NewRetryBudget(cfg.FPTRetryBudgetRatio, float64(cfg.FPTRetryBudget))
+		audioService.RegisterTTSProvider(services.TTSProviderFPT, services.NewFPTTTS(ttsKeyPool(cfg, services.TTSProviderFPT, cfg.TTSAPIKeys), breaker, budget, fptCallbacks, cfg.FPTCallbackURL()))
+	}
+	if len(cfg.ElevenLabsAPIKeys) > 0 {
+		audioService.RegisterTTSProvider(services.TTSProviderElevenLabs, services.NewElevenLabsTTS(ttsKeyPool(cfg, services.TTSProviderElevenLabs, cfg.ElevenLabsAPIKeys), cfg.ElevenLabsModel))
+	}
+	if len(cfg.AzureSpeechKeys) > 0 {
+		audioService.RegisterTTSProvider(services.TTSProviderAzure, services.NewAzureTTS(ttsKeyPool(cfg, services.TTSProviderAzure, cfg.AzureSpeechKeys), cfg.AzureSpeechRegion))
+	}
+	if len(cfg.OpenAIAPIKeys) > 0 {
+		audioService.RegisterTTSProvider(services.TTSProviderOpenAI, services.NewOpenAITTS(ttsKeyPool(cfg, services.TTSProviderOpenAI, cfg.OpenAIAPIKeys), cfg.OpenAITTSModel, cfg.OpenAIBaseURL))
+	}
+	if cfg.HasAWSCredentials() {
+		creds := utils.AWSCredentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		}
+		audioService.RegisterTTSProvider(services.TTSProviderPolly, services.NewPollyTTS(creds, cfg.AWSRegion, cfg.PollyEngine))
+	}
+	if cfg.HasPiper() {
+		audioService.RegisterTTSProvider(services.TTSProviderPiper, services.NewPiperTTS(cfg.PiperURL, cfg.PiperBinary, cfg.PiperModel))
+	}
+	if cfg.XTTSURL != "" {
+		audioService.RegisterTTSProvider(services.TTSProviderXTTS, services.NewXTTSTTS(cfg.XTTSURL, cfg.XTTSLanguage, cfg.XTTSDefaultSpeaker))
+	}
+	log.Printf("TTS providers: %v", audioService.TTSProviders())
+	if cfg.TTSFallbackProvider != "" {
+		if slices.Contains(audioService.TTSProviders(), cfg.TTSFallbackProvider) {
+			audioService.SetTTSFallback(cfg.TTSFallbackProvider)
+		} else {
+			log.Printf("Warning: TTS_FALLBACK_PROVIDER %q is not configured, ignoring it", cfg.TTSFallbackProvider)
+		}
+	}
+}
+
+// newPipeline wires the rendering services into a workflow reporting to jobManager;
+// FPT.AI callbacks for its requests are delivered to fptCallbacks.
+func newPipeline(cfg *config.Config, jobManager services.IJobManager, geminiService *services.GeminiService, fptCallbacks *services.FPTCallbackHub, storage services.OutputStorage) *services.VideoWorkflowService {
+	// API pools
 	var videoPool *utils.APIKeyPool
 	if len(cfg.VideoAPIKeys) > 0 {
 		videoPool = utils.NewAPIKeyPool(cfg.VideoAPIKeys)
@@ -53,14 +270,9 @@ func main() {
 		videoPool = utils.NewAPIKeyPool([]string{"placeholder"})
 	}
 
-	// 2. Job Manager
-	jobManager := services.NewJobManager()
-
-	// 3. Core Services
+	// Core Services
 	textProcessor := services.NewTextProcessor(cfg.AudioChunkSize, cfg.VideoSegmentDuration)
 	audioService := services.NewAudioService(
-		ttsPool,
-		cfg.ElevenLabsAPIKey,
 		cfg.TempDir,
 		cfg.AudioBitrate,
 		cfg.AudioSampleRate,
@@ -74,13 +286,103 @@ func main() {
 		cfg.VideoFPS,
 		cfg.VideoTransitionDuration,
 	)
-	geminiService := services.NewGeminiService(cfg.GeminiAPIKeys)
+	videoService.SetPikaURL(cfg.PikaAPIURL)
+	videoPool.SetRateLimit(cfg.VideoRateLimit)
+	if len(cfg.RunwayAPIKeys) > 0 {
+		runwayPool := utils.NewAPIKeyPool(cfg.RunwayAPIKeys)
+		runwayPool.SetRateLimit(cfg.VideoRateLimit)
+		videoService.SetRunway(runwayPool, cfg.RunwayModel, cfg.RunwayAPIURL)
+	}
+	if len(cfg.KlingAPIKeys) > 0 {
+		klingPool := utils.NewAPIKeyPool(cfg.KlingAPIKeys)
+		klingPool.SetRateLimit(cfg.VideoRateLimit)
+		if err := videoService.SetKling(klingPool, cfg.KlingModel, cfg.KlingRegion, cfg.KlingAPIURL); err != nil {
+			log.Fatalf("Invalid Kling configuration: %v", err)
+		}
+	}
+	if cfg.PromptTemplate != "" {
+		if err := services.ValidatePromptTemplate(cfg.PromptTemplate); err != nil {
+			log.Fatalf("Invalid PROMPT_TEMPLATE: %v", err)
+		}
+	}
+	if err := services.ValidatePromptBranding(cfg.PromptBranding); err != nil {
+		log.Fatalf("Invalid PROMPT_BRANDING: %v", err)
+	}
+	if err := services.ValidateSubtitleStyle(services.ConfigSubtitleStyle(cfg)); err != nil {
+		log.Fatalf("Invalid SUBTITLE_* settings: %v", err)
+	}
+	var promptLLM services.PromptLLM
+	if cfg.VisualPromptLLM != "" {
+		llm, err := newPromptLLM(cfg)
+		if err != nil {
+			log.Fatalf("Invalid VISUAL_PROMPT_LLM configuration: %v", err)
+		}
+		videoService.SetPromptLLM(cfg.VisualPromptLLM, llm)
+		log.Printf("Visual prompts written by %s", cfg.VisualPromptLLM)
+		promptLLM = llm
+	}
 	hfService := services.NewHuggingFaceService(cfg.HuggingFaceTokens)
 	stockVideoService := services.NewStockVideoService(cfg.PexelsAPIKey, cfg.TempDir, cfg.CacheDir, geminiService, hfService, cfg.LocalHubURL)
+	if err := stockVideoService.SetStockSource(cfg.StockProvider, cfg.PixabayAPIKey, cfg.CoverrAPIKey); err != nil {
+		log.Fatalf("Invalid stock configuration: %v", err)
+	}
+	stockVideoService.SetSafetyFilter(services.NewStockSafetyFilter(cfg.StockBlocklist, cfg.SafetyClassifierURL, cfg.SafetyThreshold))
+	stockVideoService.SetFootageCleanup(utils.FootageCleanup{Denoise: cfg.StockDenoise, Sharpen: cfg.StockSharpen})
+	if cfg.BrollDir != "" {
+		stockVideoService.SetBrollLibrary(services.NewBrollLibrary(cfg.BrollDir))
+	}
+	if cfg.EmbeddingsProvider != "" {
+		embedder, err := newEmbedder(cfg)
+		if err != nil {
+			log.Fatalf("Invalid EMBEDDINGS_PROVIDER configuration: %v", err)
+		}
+		stockVideoService.SetEmbedder(cfg.EmbeddingsProvider, embedder)
+		log.Printf("Footage ranked by %s embeddings", cfg.EmbeddingsProvider)
+	}
 	composerService := services.NewComposerService(cfg.VideoBitrate)
+	composerService.SetEncodeChunks(cfg.FFmpegEncodeChunks)
+	utils.SetFFmpegTimeout(cfg.FFmpegTimeout)
+	utils.SetAccurateTrim(cfg.FFmpegAccurateTrim)
+	utils.SetFFmpegDryRun(cfg.FFmpegDryRun)
+	if cfg.FFmpegDryRun {
+		log.Printf("FFmpeg dry run: command lines are logged, not run")
+	}
+	if accel, err := utils.ConfigureHWAccel(cfg.FFmpegHWAccel, cfg.VAAPIDevice); err != nil {
+		log.Printf("Hardware encoding disabled: %v", err)
+	} else if accel != utils.HWAccelNone {
+		log.Printf("H.264 encoded with %s", accel)
+	}
 
-	// 4. Orchestrator Workflow
-	workflowSvc := services.NewVideoWorkflowService(
+	registerTTSProviders(cfg, audioService, fptCallbacks)
+	audioService.SetTTSCacheDir(cfg.TTSCacheDir)
+	audioService.SetTTSAudit(services.NewTTSAuditLog(cfg.TTSAuditDir))
+	audioService.SetLoudnessTarget(utils.LoudnessTarget{
+		Integrated: cfg.AudioLoudnessTarget,
+		TruePeak:   cfg.AudioTruePeak,
+		LRA:        cfg.AudioLoudnessRange,
+	})
+	if cfg.HasWhisper() {
+		audioService.SetAudioQA(services.NewWhisperTranscriber(cfg.WhisperURL, cfg.WhisperBinary, cfg.WhisperModel, cfg.WhisperLanguage), cfg.AudioQAThreshold)
+		log.Printf("Audio QA enabled (Whisper, similarity threshold %.2f)", cfg.AudioQAThreshold)
+	}
+
+	// Retries and search results land in each job's event timeline
+	audioService.SetEventLogger(jobManager.LogEvent)
+	stockVideoService.SetEventLogger(jobManager.LogEvent)
+
+	// Image providers get their own pools: image and speech quotas are separate
+	imageService := services.NewImageService()
+	if len(cfg.OpenAIAPIKeys) > 0 {
+		imageService.RegisterImageProvider(services.ImageProviderOpenAI, services.NewOpenAIImages(utils.NewAPIKeyPool(cfg.OpenAIAPIKeys), cfg.OpenAIImageModel, cfg.OpenAIBaseURL))
+	}
+	if len(cfg.StabilityAPIKeys) > 0 {
+		imageService.RegisterImageProvider(services.ImageProviderStability, services.NewStabilityImages(utils.NewAPIKeyPool(cfg.StabilityAPIKeys), cfg.StabilityEngine, ""))
+	}
+	if cfg.SDWebUIURL != "" {
+		imageService.RegisterImageProvider(services.ImageProviderLocal, services.NewLocalSDImages(cfg.SDWebUIURL))
+	}
+
+	workflow := services.NewVideoWorkflowService(
 		cfg,
 		jobManager,
 		textProcessor,
@@ -90,29 +392,110 @@ func main() {
 		composerService,
 		geminiService,
 	)
+	workflow.SetImageService(imageService)
+	workflow.SetBrollAssets(services.NewBrollAssetStore(cfg.BrollUploadDir))
+	workflow.SetIntroOutroClips(services.NewIntroOutroStore(cfg.IntroOutroDir))
+	if cfg.HasWhisper() {
+		workflow.SetWordAligner(services.NewWhisperAligner(cfg.WhisperURL, cfg.WhisperBinary, cfg.WhisperModel, cfg.WhisperLanguage))
+	}
+	if cfg.SubtitleTranslator != "" {
+		translator, err := newSubtitleTranslator(cfg, promptLLM)
+		if err != nil {
+			log.Fatalf("Invalid SUBTITLE_TRANSLATOR configuration: %v", err)
+		}
+		workflow.SetSubtitleTranslator(translator)
+		log.Printf("Subtitles translated with %s", cfg.SubtitleTranslator)
+	}
+	workflow.SetOutputStorage(storage)
+	return workflow
+}
 
-	// 5. Initialize handlers
-	videoHandler := handlers.NewVideoHandler(cfg)
-	seriesHandler := handlers.NewSeriesHandler(cfg, jobManager, workflowSvc, geminiService)
+// outputStorage creates the STORAGE_BACKEND client, nil when none is configured
+func outputStorage(cfg *config.Config) services.OutputStorage {
+	if cfg.StorageBackend == "" {
+		return nil
+	}
+	storage, err := newOutputStorage(cfg)
+	if err != nil {
+		log.Fatalf("Invalid STORAGE_BACKEND configuration: %v", err)
+	}
+	log.Printf("Finished videos uploaded to %s", cfg.StorageBackend)
+	return storage
+}
 
-	// API routes
-	api := router.Group("/api")
-	{
-		api.POST("/generate", videoHandler.Generate)
-		api.GET("/status/:job_id", videoHandler.GetStatus)
-		api.GET("/download/:job_id", videoHandler.Download)
-		api.GET("/download-subtitle/:job_id", videoHandler.DownloadSubtitle)
+// newOutputStorage creates the STORAGE_BACKEND client finished videos are uploaded to
+func newOutputStorage(cfg *config.Config) (services.OutputStorage, error) {
+	switch cfg.StorageBackend {
+	case services.StorageS3:
+		creds := utils.AWSCredentials{AccessKeyID: cfg.S3AccessKeyID, SecretAccessKey: cfg.S3SecretAccessKey}
+		if cfg.S3AccessKeyID == cfg.AWSAccessKeyID {
+			creds.SessionToken = cfg.AWSSessionToken
+		}
+		return services.NewS3Storage(creds, cfg.S3Region, cfg.S3Bucket, cfg.S3Endpoint, cfg.S3PublicURL)
+	case services.StorageGCS:
+		return services.NewGCSStorage(cfg.GCSCredentialsFile, cfg.GCSBucket, cfg.GCSPublicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
 
-		// Series routes
-		api.POST("/generate-series", seriesHandler.GenerateSeries)
-		api.GET("/series-status/:series_id", seriesHandler.GetSeriesStatus)
-		api.POST("/retry-series-part/:series_id/:part_index", seriesHandler.RetrySeriesPart)
+// newPromptLLM creates the VISUAL_PROMPT_LLM client with its own key pool, so prompt calls do
+// not eat into the speech or image quotas' rate limits
+func newPromptLLM(cfg *config.Config) (services.PromptLLM, error) {
+	switch cfg.VisualPromptLLM {
+	case services.PromptLLMOpenAI:
+		return services.NewPromptLLM(cfg.VisualPromptLLM, utils.NewAPIKeyPool(cfg.OpenAIAPIKeys), cfg.VisualPromptModel, cfg.OpenAIBaseURL)
+	case services.PromptLLMClaude:
+		return services.NewPromptLLM(cfg.VisualPromptLLM, utils.NewAPIKeyPool(cfg.AnthropicAPIKeys), cfg.VisualPromptModel, "")
+	case services.PromptLLMGemini:
+		return services.NewPromptLLM(cfg.VisualPromptLLM, utils.NewAPIKeyPool(cfg.GeminiAPIKeys), cfg.VisualPromptModel, "")
+	default:
+		return services.NewPromptLLM(cfg.VisualPromptLLM, nil, cfg.VisualPromptModel, cfg.OllamaURL)
 	}
+}
 
-	// Start server
-	addr := fmt.Sprintf(":%s", cfg.Port)
-	log.Printf("Starting server on %s", addr)
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+// newSubtitleTranslator creates the SUBTITLE_TRANSLATOR client; "llm" shares the visual prompt model
+func newSubtitleTranslator(cfg *config.Config, llm services.PromptLLM) (services.SubtitleTranslator, error) {
+	switch cfg.SubtitleTranslator {
+	case services.TranslatorDeepL:
+		return services.NewSubtitleTranslator(cfg.SubtitleTranslator, utils.NewAPIKeyPool(cfg.DeepLAPIKeys), nil, "")
+	case services.TranslatorGoogle:
+		return services.NewSubtitleTranslator(cfg.SubtitleTranslator, utils.NewAPIKeyPool(cfg.GoogleTranslateAPIKeys), nil, "")
+	default:
+		return services.NewSubtitleTranslator(cfg.SubtitleTranslator, nil, llm, "")
 	}
 }
+
+// newEmbedder creates the EMBEDDINGS_PROVIDER client, with its own key pool like newPromptLLM
+func newEmbedder(cfg *config.Config) (services.Embedder, error) {
+	if cfg.EmbeddingsProvider == services.EmbedderOpenAI {
+		return services.NewEmbedder(cfg.EmbeddingsProvider, utils.NewAPIKeyPool(cfg.OpenAIAPIKeys), cfg.EmbeddingsModel, cfg.OpenAIBaseURL)
+	}
+	return services.NewEmbedder(cfg.EmbeddingsProvider, nil, cfg.EmbeddingsModel, cfg.OllamaURL)
+}
+
+// runWorker renders jobs dispatched by API instances until the NATS connection drops.
+// There is no reconnect: the process exits and its supervisor (systemd, Kubernetes) restarts it.
+func runWorker(cfg *config.Config) {
+	workerID := services.DefaultWorkerID()
+	nc, err := utils.ConnectNATS(cfg.NATSURL, "aituber-worker-"+workerID)
+	if err != nil {
+		log.Fatalf("Failed to connect to NATS: %v", err)
+	}
+
+	jobManager := services.NewRemoteJobManager(nc, workerID)
+	fptCallbacks := services.NewFPTCallbackHub()
+	if cfg.FPTCallbackSecret != "" {
+		if err := services.SubscribeFPTCallbacks(nc, fptCallbacks); err != nil {
+			log.Fatalf("Failed to subscribe to FPT callbacks: %v", err)
+		}
+	}
+	workflowSvc := newPipeline(cfg, jobManager, services.NewGeminiService(cfg.GeminiAPIKeys), fptCallbacks, outputStorage(cfg))
+	worker := services.NewRenderWorker(nc, jobManager, workflowSvc, workerID, cfg.TempDir, cfg.WorkerConcurrency)
+	if err := worker.Start(); err != nil {
+		log.Fatalf("Failed to start render worker: %v", err)
+	}
+
+	<-nc.Done()
+	log.Fatalf("Lost NATS connection with %d jobs in progress; exiting", jobManager.Running())
+}
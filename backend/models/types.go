@@ -15,33 +15,251 @@ type GenerateRequest struct {
 	Voice         string  `json:"voice" binding:"required"`
 	SpeakingSpeed float64 `json:"speaking_speed"`
 
+	// Optional overrides of the configured audio quality (AUDIO_BITRATE, AUDIO_SAMPLE_RATE, AUDIO_CROSSFADE_DURATION)
+	AudioBitrate    string   `json:"audio_bitrate"`     // "64k" to "320k"
+	AudioSampleRate int      `json:"audio_sample_rate"` // 22050, 24000, 32000, 44100 or 48000
+	AudioCrossfade  *float64 `json:"audio_crossfade"`   // seconds between chunks, 0-2; 0 disables
+
+	// Optional overrides of the configured video quality (VIDEO_RESOLUTION, VIDEO_FPS)
+	Resolution string `json:"resolution"` // "720p", "1080p" or "4k", along the frame's short edge
+	FPS        int    `json:"fps"`        // 24, 30 or 60
+	// Encode preset: "draft" (ultrafast 720p, to iterate on a script), "standard" (default) or
+	// "high" (slow preset, lower CRF). Resolution still wins over draft's 720p.
+	Quality string `json:"quality"`
+
+	// Optional container and codec of the finished video, default H.264 MP4. mp4 takes h264, h265
+	// or av1, mov h264 or h265, and webm vp9 (its default) or av1.
+	OutputFormat string `json:"output_format"` // "mp4", "webm" or "mov"
+	VideoCodec   string `json:"video_codec"`   // "h264", "h265", "vp9" or "av1"
+
 	// Legacy / optional: pre-written script (bypasses Gemini gen if provided)
-	Script        string `json:"script"`
-	VideoStyle    string `json:"video_style"`
-	VideoSource   string `json:"video_source"`
-	StockKeywords string `json:"stock_keywords"`
-	TTSProvider   string `json:"tts_provider"` // "fpt" or "elevenlabs"
-	T2VModel      string `json:"t2v_model"`    // e.g. "genmo/mochi-1-preview"
-	T2VProvider   string `json:"t2v_provider"` // e.g. "fal-ai"
+	Script        string   `json:"script"`
+	VideoStyle    string   `json:"video_style"`
+	VideoSource   string   `json:"video_source"`   // "" (stock/AI footage), "ai" (text-to-video clips), "images" (AI stills), "local" (BROLL_DIR), "static", "slides" (Markdown script) or "waveform"
+	VideoProvider string   `json:"video_provider"` // video_source "ai" only: "pika" (default), "runway" or "kling"
+	ImageProvider string   `json:"image_provider"` // video_source "images" only: "openai" (default), "stability" or "local"
+	StockKeywords string   `json:"stock_keywords"`
+	BrollAssets   []string `json:"broll_assets"` // IDs from POST /api/assets/broll, spread over the stock timeline
+	Orientation   string   `json:"orientation"`  // "landscape", "portrait" or "square"; default by platform (portrait for tiktok)
+	AspectRatio   string   `json:"aspect_ratio"` // "16:9", "9:16" or "1:1"; same as orientation, and wins over it
+	TTSProvider   string   `json:"tts_provider"` // "fpt", "elevenlabs", "azure", "openai", "polly", "piper" or "xtts"
+	T2VModel      string   `json:"t2v_model"`    // e.g. "genmo/mochi-1-preview"
+	T2VProvider   string   `json:"t2v_provider"` // e.g. "fal-ai"
+
+	// Footage sources ("", "local" and broll_assets): slow zoom on each clip, 0 (off) to 1 (20% zoom)
+	KenBurns float64 `json:"ken_burns"`
+
+	// video_source "ai" only: one look for every clip of the video, where the provider supports it
+	Seed           int64  `json:"seed"`            // 0 lets the provider pick per clip
+	NegativePrompt string `json:"negative_prompt"` // what the clips must not show
+	StyleImage     string `json:"style_image"`     // reference image in static/ (Pika only)
+
+	// Visual prompts of segments without a visual_description (video_source "ai"). PromptTemplate
+	// takes {text}, {style}, {themes} and {branding}; both default to the tenant's
+	// (PUT /api/prompt-template), then PROMPT_TEMPLATE and PROMPT_BRANDING.
+	PromptTemplate string `json:"prompt_template"`
+	Branding       string `json:"branding"` // e.g. "teal and orange palette, friendly robot mascot"
 
 	// If Segments is provided, it bypasses both Script text and AI generation
 	Segments []VideoSegment `json:"segments"`
+
+	// Storyboard stops the job after the script with a storyboard (GET /api/jobs/:job_id/storyboard)
+	// that can be edited and must be approved before rendering. Footage sources only ("", "ai", "images", "local").
+	Storyboard bool `json:"storyboard"`
+
+	// SubtitleReview pauses the job in "awaiting_review" once narration, clips and subtitles are
+	// ready; the cues can be corrected (PUT /api/jobs/:job_id/subtitles) before
+	// POST /api/jobs/:job_id/continue composes the video with them
+	SubtitleReview bool `json:"subtitle_review"`
+
+	// Optional webhook: receives a signed JSON POST when the job completes or fails
+	CallbackURL string `json:"callback_url"`
+
+	// Optional labels for operators (e.g. {"channel": "cooking", "campaign": "tet-2026"})
+	Title    string            `json:"title"`
+	Metadata map[string]string `json:"metadata"`
+
+	// Final assembly options (can also be changed later via POST /api/jobs/:job_id/rerender)
+	VideoTransition string        `json:"video_transition"` // xfade transition between segments, e.g. "fade"; empty = hard cuts
+	BurnSubtitles   bool          `json:"burn_subtitles"`
+	SoftSubtitles   bool          `json:"soft_subtitles"` // embed the subtitles as a toggleable track (mov_text, WebVTT in webm)
+	SubtitleStyle   SubtitleStyle `json:"subtitle_style"` // subtitle look and layout; unset fields use SUBTITLE_* or the built-in style
+	// Machine-translated copies of the subtitles (SUBTITLE_TRANSLATOR), e.g. ["en", "ja"]; each is
+	// downloadable via /api/download-subtitle/:job_id?lang=en as SRT or VTT
+	SubtitleLanguages []string `json:"subtitle_languages"`
+	// YouTube only: an ID from POST /api/assets/intros, a file name under static/ or "none". Empty
+	// picks the tenant's default (PUT /api/assets/intros/default), else static/intro_video.mp4.
+	IntroVideo string `json:"intro_video"`
+	OutroVideo string `json:"outro_video"` // likewise, from /api/assets/outros or static/outro_video.mp4
+	MusicTrack string `json:"music_track"` // ID from GET /api/music, looped/trimmed under the narration
+	// YouTube only: a generated end screen, played instead of the outro video
+	EndScreen EndScreen `json:"end_screen"`
+	// One look over all the footage, so stock clips from different sources match
+	ColorGrade ColorGrade `json:"color_grade"`
+	// A presenter video or avatar loop in a corner over the footage, for the whole narration
+	Presenter Presenter `json:"presenter"`
+	// Timed titles drawn at the bottom left, e.g. speaker names; segments and storyboard scenes
+	// can also carry a lower_third, shown as they start
+	LowerThirds     []LowerThird    `json:"lower_thirds"`
+	LowerThirdStyle LowerThirdStyle `json:"lower_third_style"`
+	// A short title card (TITLE_CARD_DURATION) before each section of the script, on
+	// background_image or background_color; not for the "waveform" and "static" sources
+	TitleCards bool `json:"title_cards"`
+	// Small copy to check the video with before downloading it, via /api/preview/:job_id: "mp4"
+	// (480p), "gif" (the first 10 seconds) or "both"; empty renders none
+	Preview string `json:"preview"`
+
+	// video_source "waveform": audio visualization instead of footage
+	WaveformStyle   string `json:"waveform_style"`   // "waves" (default) or "spectrum"
+	BackgroundImage string `json:"background_image"` // file name under static/; default waveform_background.jpg, else a dark background
+
+	// video_source "static": one still frame for the whole narration, with Title drawn on it.
+	// Uses background_image (no default) or BackgroundColor, as does every slide of "slides"
+	// and every title card.
+	BackgroundColor string `json:"background_color"` // "#RRGGBB" or an ffmpeg color name; default dark blue-grey
+	AvatarImage     string `json:"avatar_image"`     // file name under static/, shown bottom-center
+
+	// Optional word -> phonetic replacement applied before TTS (e.g. {"AITuber": "ây ai tu bơ"}).
+	// Entries of the caller's tenant lexicon (PUT /api/lexicon) are merged in; request entries win.
+	Pronunciations map[string]string `json:"pronunciations"`
+
+	// Optional RFC3339 start time; future jobs wait in "scheduled" status (e.g. overnight when quotas reset)
+	RunAt string `json:"run_at"`
+}
+
+// SubtitleStyle brands subtitles. The look applies to burned-in and ASS subtitles, MaxLines also
+// to SRT cues. Sizes are pixels of a 1080p frame (1080x1920 in portrait); zero or empty fields
+// keep the default.
+type SubtitleStyle struct {
+	Font         string `json:"font"`          // font family installed on the render host
+	Size         int    `json:"size"`          // font size
+	PrimaryColor string `json:"primary_color"` // text, "#RRGGBB"
+	OutlineColor string `json:"outline_color"` // outline, "#RRGGBB"
+	MarginV      int    `json:"margin_v"`      // distance from the bottom (or, at the top, the top) edge
+	Position     string `json:"position"`      // "bottom" (default), "middle" or "top"
+	Box          *bool  `json:"box"`           // semi-transparent box behind the text instead of an outline
+	BoxColor     string `json:"box_color"`     // "#RRGGBB", default black
+	MaxLines     int    `json:"max_lines"`     // 1-4 lines per cue, longer cues are split in time; 0 = no limit
+	MaxLength    int    `json:"max_length"`    // characters per cue when a script is split into cues (default 100)
+	// Karaoke highlights each word as it is spoken (burned-in and .ass subtitles), in
+	// HighlightColor ("#RRGGBB"); words are timed by Whisper when it is configured
+	Karaoke        *bool  `json:"karaoke"`
+	HighlightColor string `json:"highlight_color"`
+}
+
+// ColorGrade is the grade of GenerateRequest.ColorGrade: a LUT, then the adjustments. Unset
+// fields leave the footage as it is.
+type ColorGrade struct {
+	LUT         string   `json:"lut"`         // .cube file under static/luts, e.g. "teal_orange.cube"
+	Contrast    *float64 `json:"contrast"`    // 0.5-2, 1 is unchanged
+	Saturation  *float64 `json:"saturation"`  // 0 (black and white) to 3, 1 is unchanged
+	Temperature float64  `json:"temperature"` // -1 (cooler) to 1 (warmer)
+}
+
+// Presenter is the picture-in-picture overlay of GenerateRequest.Presenter, looped when it is
+// shorter than the narration. An empty Video shows none; other unset fields keep the default.
+type Presenter struct {
+	Video        string   `json:"video"`         // file name under static/: mp4, mov, webm (transparency kept), mkv or gif
+	Corner       string   `json:"corner"`        // "bottom_right" (default), "bottom_left", "top_right" or "top_left"
+	Size         float64  `json:"size"`          // width as a fraction of the frame width, 0.1-0.5; default 0.25
+	Margin       *float64 `json:"margin"`        // gap to the frame edges as a fraction of its width, 0-0.2; default 0.03
+	CornerRadius float64  `json:"corner_radius"` // fraction of the overlay's shorter side, 0-0.5 (0.5 makes a square a circle)
+}
+
+// EndScreen is the end screen of GenerateRequest.EndScreen: a prompt over a background, with
+// placeholder zones where YouTube's end screen elements (subscribe, next videos) are placed
+type EndScreen struct {
+	Enabled         bool    `json:"enabled"`
+	Duration        float64 `json:"duration"`         // 5-20 seconds, as YouTube allows; default END_SCREEN_DURATION
+	Text            string  `json:"text"`             // default "Thanks for watching! Subscribe for more."
+	Videos          int     `json:"videos"`           // video placeholders, 1 or 2 (default)
+	BackgroundImage string  `json:"background_image"` // file name under static/; default the request's background
+	BackgroundColor string  `json:"background_color"` // "#RRGGBB" or an ffmpeg color name
+}
+
+// LowerThird is one timed title of GenerateRequest.LowerThirds. Times are in seconds of the
+// narration; the intro is not counted.
+type LowerThird struct {
+	Text     string  `json:"text"`
+	Subtitle string  `json:"subtitle"` // optional smaller second line, e.g. a speaker's role
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"` // default 5
+}
+
+// LowerThirdStyle is the look of the lower thirds; unset fields keep the built-in style
+type LowerThirdStyle struct {
+	Font         string `json:"font"`          // font family installed on the render host
+	Size         int    `json:"size"`          // title font size in pixels of a 1080p frame
+	PrimaryColor string `json:"primary_color"` // text, "#RRGGBB"
+	BoxColor     string `json:"box_color"`     // box behind the text, "#RRGGBB"
+	Animation    string `json:"animation"`     // "fade" (default), "slide" or "none"
+}
+
+// RerenderRequest – POST /api/jobs/:job_id/rerender
+// Only the supplied fields change; audio chunks and stock clips of the source job are reused.
+type RerenderRequest struct {
+	VideoTransition *string          `json:"video_transition"`
+	BurnSubtitles   *bool            `json:"burn_subtitles"`
+	SoftSubtitles   *bool            `json:"soft_subtitles"`
+	SubtitleStyle   *SubtitleStyle   `json:"subtitle_style"`
+	IntroVideo      *string          `json:"intro_video"`
+	OutroVideo      *string          `json:"outro_video"`
+	EndScreen       *EndScreen       `json:"end_screen"`
+	MusicTrack      *string          `json:"music_track"`
+	ColorGrade      *ColorGrade      `json:"color_grade"`
+	Presenter       *Presenter       `json:"presenter"`
+	LowerThirds     *[]LowerThird    `json:"lower_thirds"`
+	LowerThirdStyle *LowerThirdStyle `json:"lower_third_style"`
+	Preview         *string          `json:"preview"`
+	OutputFormat    *string          `json:"output_format"`
+	VideoCodec      *string          `json:"video_codec"`
+	Quality         *string          `json:"quality"`
+	CallbackURL     *string          `json:"callback_url"`
+}
+
+// RenderAssets are the expensive intermediate files of a job that a re-render can reuse
+type RenderAssets struct {
+	Segments          []VideoSegment
+	AudioPaths        []string
+	AudioTexts        []string
+	SegmentVideoPaths []string // in timeline order, failed segments omitted
+	Orientation       string
 }
 
 // GenerateResponse returns the job ID
 type GenerateResponse struct {
-	JobID  string `json:"job_id"`
-	Status string `json:"status"`
+	JobID         string `json:"job_id"`
+	Status        string `json:"status"`
+	DownloadToken string `json:"download_token,omitempty"` // required as ?token= on download URLs
 }
 
 // StatusResponse returns current progress
 type StatusResponse struct {
-	Status      string  `json:"status"` // "processing", "completed", "failed"
-	Progress    int     `json:"progress"`
-	CurrentStep string  `json:"current_step"`
-	VideoURL    *string `json:"video_url,omitempty"`
-	SavedPath   *string `json:"saved_path,omitempty"`
-	Error       *string `json:"error,omitempty"`
+	JobID       string            `json:"job_id"`
+	Title       string            `json:"title,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Status      string            `json:"status"` // "scheduled", "processing", "awaiting_approval", "awaiting_review", "completed", "failed", "cancelled", "expired"
+	Progress    int               `json:"progress"`
+	CurrentStep string            `json:"current_step"`
+	VideoURL    *string           `json:"video_url,omitempty"`
+	SubtitleURL *string           `json:"subtitle_url,omitempty"`
+	Artifacts   []Artifact        `json:"artifacts,omitempty"` // every downloadable file, once completed
+	SavedPath   *string           `json:"saved_path,omitempty"`
+	Error       *string           `json:"error,omitempty"`
+	ETASeconds  *int              `json:"eta_seconds,omitempty"` // only while processing and once an estimate exists
+	RunAt       *time.Time        `json:"run_at,omitempty"`
+	RerenderOf  string            `json:"rerender_of,omitempty"`
+	Events      []JobEvent        `json:"events,omitempty"` // only with ?events=true
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
+	ExpiredAt   *time.Time        `json:"expired_at,omitempty"`
+}
+
+// Artifact is one downloadable output of a completed job
+type Artifact struct {
+	Type     string `json:"type"`               // "video", "preview", "subtitle", "thumbnail", "transcript" or "chapters"
+	Format   string `json:"format"`             // e.g. "mp4", "gif", "srt", "vtt", "ass" or "jpg"
+	Language string `json:"language,omitempty"` // translated subtitles only
+	URL      string `json:"url"`
 }
 
 // VideoSegment represents a text segment with duration
@@ -50,6 +268,75 @@ type VideoSegment struct {
 	EstimatedDuration float64 `json:"estimated_duration,omitempty"`
 	VisualPrompt      string  `json:"pexels_search_query"`
 	VisualDescription string  `json:"visual_description"`
+
+	// video_source "slides": what the segment's slide shows (see services.ParseMarkdownSlides)
+	SlideTitle string `json:"slide_title,omitempty"`
+	SlideBody  string `json:"slide_body,omitempty"` // one line per paragraph or "• " list item
+
+	// Caption drawn at the top of the segment's clip (not on slides, which have their own title)
+	OnScreenText string `json:"on_screen_text,omitempty"`
+	// Lower third shown as the segment starts, e.g. "Jane Doe\nHost": the first line is the
+	// title, a second one the smaller subtitle
+	LowerThird string `json:"lower_third,omitempty"`
+
+	// The segment starts a section of this name: from a Markdown heading or a "[section: Name]"
+	// line of the script, or set by the caller
+	SectionTitle string `json:"section_title,omitempty"`
+	// Set on the segments title_cards inserts: a card showing it, over a pause
+	TitleCard string `json:"title_card,omitempty"`
+}
+
+// StoryboardScene is one segment of a storyboard, as planned and then edited by the user
+type StoryboardScene struct {
+	Text              string `json:"text"` // narration
+	VisualDescription string `json:"visual_description"`
+	OnScreenText      string `json:"on_screen_text"`
+	LowerThird        string `json:"lower_third"`    // e.g. a speaker's name, see VideoSegment.LowerThird
+	SectionTitle      string `json:"section_title"`  // the scene starts a section, see VideoSegment.SectionTitle
+	BrollKeywords     string `json:"broll_keywords"` // English stock footage search terms
+	MusicMood         string `json:"music_mood"`     // e.g. "calm", "upbeat", "epic"
+}
+
+// Storyboard – the scene plan of a job started with storyboard: true; PUT
+// /api/jobs/:job_id/storyboard replaces Scenes until the storyboard is approved
+type Storyboard struct {
+	Scenes     []StoryboardScene `json:"scenes"`
+	ApprovedAt *time.Time        `json:"approved_at,omitempty"`
+}
+
+// SubtitleCue is one subtitle of a job under review, timed in seconds on the narration (an
+// intro's length is added when the subtitle files are written)
+type SubtitleCue struct {
+	Start float64        `json:"start"`
+	End   float64        `json:"end"`
+	Text  string         `json:"text"`
+	Words []SubtitleWord `json:"words,omitempty"` // karaoke timing, re-estimated when it no longer matches the text
+}
+
+// SubtitleWord is when one word of a SubtitleCue is spoken
+type SubtitleWord struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Transcript is a job's narration as output/transcript.json, timed on the final video like its
+// subtitles, for reuse without parsing SRT
+type Transcript struct {
+	Duration float64             `json:"duration"` // end of the last segment, in seconds
+	Segments []TranscriptSegment `json:"segments"`
+}
+
+// TranscriptSegment is one narrated segment of a Transcript
+type TranscriptSegment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker"` // the TTS voice
+	// Share of the words the speech aligner heard when karaoke timing was aligned; otherwise 1,
+	// since the text is the script the narration was synthesized from
+	Confidence float64        `json:"confidence"`
+	Words      []SubtitleWord `json:"words,omitempty"`
 }
 
 // JobStatus tracks processing status in memory
@@ -63,8 +350,57 @@ type JobStatus struct {
 	VideoPath   string
 	SavedPath   string
 	Error       error
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	CallbackURL string
+	// DownloadToken must accompany download requests so a leaked job ID alone can't fetch the video
+	DownloadToken string
+	Title         string
+	Metadata      map[string]string
+	RunAt         time.Time // zero unless the job was scheduled for later
+	Request       GenerateRequest
+	Assets        *RenderAssets     // set once audio and stock clips are ready
+	Storyboard    *Storyboard       // set once a storyboard job's plan is ready
+	Subtitles     []SubtitleCue     // set once a subtitle_review job waits for review, then holds the edits
+	RerenderOf    string            // source job ID for re-renders
+	StoredURLs    map[string]string // object URLs of the files uploaded to output storage, by file name
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// Step timing, used to estimate time remaining
+	ScriptLength      int // characters of the final script; scales per-step history
+	StepKey           string
+	StepStartedAt     time.Time
+	StepStartProgress int
+	StepTimings       []StepTiming
+
+	// Events is the job's timeline (step changes, pipeline log lines, final status), oldest first
+	Events []JobEvent
+}
+
+// StepTiming records how long one pipeline step took for a job
+type StepTiming struct {
+	Step          string
+	StartProgress int
+	Duration      time.Duration
+}
+
+// JobEvent is a real-time update pushed to job subscribers (e.g. WebSocket clients)
+type JobEvent struct {
+	JobID     string    `json:"job_id"`
+	Type      string    `json:"type"` // "progress" | "log" | "status"
+	Step      string    `json:"step,omitempty"`
+	Progress  int       `json:"progress"`
+	Status    string    `json:"status,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WebhookPayload is POSTed to GenerateRequest.CallbackURL when a job finishes
+type WebhookPayload struct {
+	JobID       string    `json:"job_id"`
+	Status      string    `json:"status"` // "completed" | "failed" | "cancelled"
+	DownloadURL string    `json:"download_url,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
 }
 
 // ---------- Series Video Generation ----------
@@ -77,7 +413,7 @@ type SeriesGenerateRequest struct {
 	Voice         string  `json:"voice" binding:"required"`
 	SpeakingSpeed float64 `json:"speaking_speed"`
 	ContentName   string  `json:"content_name"` // optional slug
-	TTSProvider   string  `json:"tts_provider"` // "fpt" or "elevenlabs"
+	TTSProvider   string  `json:"tts_provider"` // "fpt", "elevenlabs", "azure", "openai", "polly", "piper" or "xtts"
 	T2VModel      string  `json:"t2v_model"`    // e.g. "genmo/mochi-1-preview"
 	T2VProvider   string  `json:"t2v_provider"` // e.g. "fal-ai"
 }
@@ -128,3 +464,177 @@ type SeriesPartOutline struct {
 	Summary    string   `json:"summary"`
 	KeyPoints  []string `json:"key_points"`
 }
+
+// ---------- Music Library ----------
+
+// MusicTrack – a bundled background track under static/music, listed by GET /api/music
+type MusicTrack struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Mood     string  `json:"mood,omitempty"` // e.g. "calm", "upbeat", "epic"
+	BPM      int     `json:"bpm,omitempty"`
+	Duration float64 `json:"duration"` // seconds
+	License  string  `json:"license,omitempty"`
+	File     string  `json:"file"`
+}
+
+// ---------- Pronunciation Lexicon ----------
+
+// LexiconRequest – PUT /api/lexicon replaces the tenant's dictionary (an empty map clears it)
+type LexiconRequest struct {
+	Entries map[string]string `json:"entries"` // word -> phonetic replacement
+}
+
+// PromptTemplate – the visual prompt template and channel branding of a tenant
+// (GET/PUT /api/prompt-template); an empty Template clears it
+type PromptTemplate struct {
+	Template string `json:"template"`
+	Branding string `json:"branding"`
+}
+
+// ---------- Cloned Voices ----------
+
+// ClonedVoice – a voice created by POST /api/voices/clone; GenerateRequest.Voice may use Name
+type ClonedVoice struct {
+	Name      string    `json:"name"`
+	VoiceID   string    `json:"voice_id"` // provider-side ID passed to TTS
+	Provider  string    `json:"provider"` // "elevenlabs" or "xtts"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ---------- B-roll Uploads ----------
+
+// BrollAsset is a clip uploaded via POST /api/assets/broll, referenced by ID in
+// GenerateRequest.BrollAssets
+type BrollAsset struct {
+	ID        string    `json:"id"`
+	Tenant    string    `json:"tenant"`
+	FileName  string    `json:"file_name"` // as uploaded
+	Keywords  []string  `json:"keywords,omitempty"`
+	Duration  float64   `json:"duration"` // seconds
+	Size      int64     `json:"size"`     // bytes
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ---------- Intro/Outro Uploads ----------
+
+// IntroOutroClip is an intro or outro uploaded via POST /api/assets/intros or /api/assets/outros,
+// selected by ID in GenerateRequest.IntroVideo or OutroVideo
+type IntroOutroClip struct {
+	ID        string    `json:"id"`
+	Tenant    string    `json:"tenant"`
+	Kind      string    `json:"kind"`      // "intro" or "outro"
+	FileName  string    `json:"file_name"` // as uploaded
+	Duration  float64   `json:"duration"`  // seconds
+	Size      int64     `json:"size"`      // bytes
+	CreatedAt time.Time `json:"created_at"`
+	Default   bool      `json:"default"` // the tenant's choice for requests that name none
+}
+
+// ---------- TTS Audit ----------
+
+// TTSAuditRecord – one TTS provider call, listed by GET /api/admin/jobs/:job_id/tts-audit
+type TTSAuditRecord struct {
+	Time           time.Time `json:"time"`
+	JobID          string    `json:"job_id"`
+	Provider       string    `json:"provider"`
+	Chunk          int       `json:"chunk"`
+	Attempt        int       `json:"attempt"`
+	KeyFingerprint string    `json:"key_fingerprint,omitempty"` // first 8 hex chars of the key's SHA-256
+	StatusCode     int       `json:"status_code,omitempty"`     // HTTP status, when the provider is HTTP based
+	AsyncURL       string    `json:"async_url,omitempty"`       // FPT.AI download URL
+	LatencyMs      int64     `json:"latency_ms"`
+	Bytes          int       `json:"bytes,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// FPTCallback – the body FPT.AI POSTs to the callback_url of an async TTS request once the
+// audio is rendered. The request ID is accepted under either spelling, and the audio URL as
+// either link or async.
+type FPTCallback struct {
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	RequestID    string `json:"request_id,omitempty"`
+	RequestIDAlt string `json:"requestid,omitempty"`
+	Link         string `json:"link,omitempty"`
+	Async        string `json:"async,omitempty"`
+}
+
+// ---------- RSS/Atom Feed Subscriptions ----------
+
+// FeedSubscriptionRequest – POST /api/feeds
+type FeedSubscriptionRequest struct {
+	URL           string  `json:"url" binding:"required"`      // RSS 2.0 or Atom feed
+	Platform      string  `json:"platform" binding:"required"` // "youtube" | "tiktok"
+	Voice         string  `json:"voice" binding:"required"`
+	SpeakingSpeed float64 `json:"speaking_speed"`
+	VideoStyle    string  `json:"video_style"`
+	TTSProvider   string  `json:"tts_provider"`
+	// IncludeExisting enqueues items already in the feed; by default only items published later are rendered
+	IncludeExisting bool `json:"include_existing"`
+	// MaxItemsPerPoll caps jobs created per poll (default 3)
+	MaxItemsPerPoll int `json:"max_items_per_poll"`
+}
+
+// FeedSubscription – a registered feed whose new items become generate jobs
+type FeedSubscription struct {
+	ID              string     `json:"id"`
+	URL             string     `json:"url"`
+	Platform        string     `json:"platform"`
+	Voice           string     `json:"voice"`
+	SpeakingSpeed   float64    `json:"speaking_speed"`
+	VideoStyle      string     `json:"video_style,omitempty"`
+	TTSProvider     string     `json:"tts_provider,omitempty"`
+	MaxItemsPerPoll int        `json:"max_items_per_poll"`
+	ItemsEnqueued   int        `json:"items_enqueued"`
+	RecentJobIDs    []string   `json:"recent_job_ids,omitempty"`
+	LastPolledAt    *time.Time `json:"last_polled_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ---------- Distributed Rendering ----------
+
+// WorkerDispatch is sent from the API to the render worker queue
+type WorkerDispatch struct {
+	Kind        string          `json:"kind"` // "generate" | "rerender" | "continue"
+	JobID       string          `json:"job_id"`
+	Platform    string          `json:"platform"`
+	ContentName string          `json:"content_name"`
+	Request     GenerateRequest `json:"request"`
+	// Re-renders only: the source job and its reusable assets (paths on shared storage); a
+	// reviewed job continues from its own assets
+	SourceJobID  string        `json:"source_job_id,omitempty"`
+	SourceAssets *RenderAssets `json:"source_assets,omitempty"`
+	// Continues only: the reviewed subtitles
+	Subtitles []SubtitleCue `json:"subtitles,omitempty"`
+}
+
+// WorkerDispatchReply is a worker's answer to a dispatch
+type WorkerDispatchReply struct {
+	Accepted bool   `json:"accepted"`
+	WorkerID string `json:"worker_id"`
+	Reason   string `json:"reason,omitempty"` // e.g. "busy"
+}
+
+// WorkerCancel is broadcast from the API to every render worker when a job is cancelled
+type WorkerCancel struct {
+	JobID string `json:"job_id"`
+}
+
+// WorkerEvent is a job state change published by a render worker and applied by the API
+type WorkerEvent struct {
+	JobID        string            `json:"job_id"`
+	WorkerID     string            `json:"worker_id"`
+	Type         string            `json:"type"` // "progress" | "log" | "sync" | "storyboard" | "review" | "failed" | "completed"
+	Step         string            `json:"step,omitempty"`
+	Progress     int               `json:"progress,omitempty"`
+	Message      string            `json:"message,omitempty"`
+	VideoPath    string            `json:"video_path,omitempty"`
+	SavedPath    string            `json:"saved_path,omitempty"`
+	ScriptLength int               `json:"script_length,omitempty"`
+	Assets       *RenderAssets     `json:"assets,omitempty"`
+	StoredURLs   map[string]string `json:"stored_urls,omitempty"`
+	Storyboard   *Storyboard       `json:"storyboard,omitempty"`
+	Subtitles    []SubtitleCue     `json:"subtitles,omitempty"`
+}
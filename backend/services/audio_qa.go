@@ -0,0 +1,179 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxQARegenerations bounds how often a chunk that fails QA is synthesized again
+const maxQARegenerations = 2
+
+// Transcriber turns speech back into text for audio QA
+type Transcriber interface {
+	Transcribe(ctx context.Context, audioPath string) (string, error)
+}
+
+// whisperTranscriber runs a local Whisper model, either through a whisper.cpp server or its CLI
+type whisperTranscriber struct {
+	url        string // whisper.cpp server; takes precedence over the binary
+	binary     string
+	model      string // path to a ggml model
+	language   string
+	httpClient *http.Client
+}
+
+// NewWhisperTranscriber creates the Whisper transcriber. With url set, audio is posted to a
+// whisper.cpp server's /inference endpoint; otherwise binary is run with the model at model.
+func NewWhisperTranscriber(url, binary, model, language string) Transcriber {
+	return newWhisper(url, binary, model, language)
+}
+
+// NewWhisperAligner creates a WordAligner over the same Whisper setup as NewWhisperTranscriber
+func NewWhisperAligner(url, binary, model, language string) WordAligner {
+	return newWhisper(url, binary, model, language)
+}
+
+func newWhisper(url, binary, model, language string) *whisperTranscriber {
+	if binary == "" {
+		binary = "whisper-cli"
+	}
+	if language == "" {
+		language = "auto"
+	}
+	return &whisperTranscriber{
+		url:        strings.TrimRight(url, "/"),
+		binary:     binary,
+		model:      model,
+		language:   language,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Transcribe returns the text spoken in audioPath
+func (w *whisperTranscriber) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	// whisper.cpp only reads 16 kHz mono WAV
+	wavPath := audioPath + ".asr.wav"
+	if err := utils.ConvertAudioForASR(audioPath, wavPath); err != nil {
+		return "", fmt.Errorf("failed to prepare audio for Whisper: %w", err)
+	}
+	defer os.Remove(wavPath)
+
+	if w.url != "" {
+		return w.callWhisperServer(ctx, wavPath)
+	}
+	return w.runWhisper(ctx, wavPath)
+}
+
+// callWhisperServer posts the WAV to a whisper.cpp server and reads the plain-text transcript
+func (w *whisperTranscriber) callWhisperServer(ctx context.Context, wavPath string) (string, error) {
+	text, err := w.postWhisper(ctx, wavPath, map[string]string{"response_format": "text"})
+	return strings.TrimSpace(string(text)), err
+}
+
+// postWhisper posts the WAV with fields (plus the language) to the server's /inference endpoint
+// and returns the response body
+func (w *whisperTranscriber) postWhisper(ctx context.Context, wavPath string, fields map[string]string) ([]byte, error) {
+	sample, err := os.ReadFile(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	fields["language"] = w.language
+	body, contentType, err := voiceSampleForm("file", "chunk.wav", sample, fields)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", w.url+"/inference", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Whisper request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Whisper server returned %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// runWhisper runs the whisper.cpp CLI without timestamps and reads the transcript from stdout
+func (w *whisperTranscriber) runWhisper(ctx context.Context, wavPath string) (string, error) {
+	if w.model == "" {
+		return "", fmt.Errorf("no Whisper model configured (set WHISPER_MODEL or WHISPER_URL)")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, w.binary, "-m", w.model, "-f", wavPath, "-l", w.language, "-nt", "-np")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", w.binary, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// passesQA transcribes a synthesized chunk and compares it with the text it was made from.
+// Transcription failures pass: QA must not fail jobs that would otherwise render.
+func (as *AudioService) passesQA(ctx context.Context, text, audioPath, jobID string, index int) bool {
+	if as.qa == nil {
+		return true
+	}
+	expected := StripScriptMarkers(StripSSML(text))
+	transcript, err := as.qa.Transcribe(ctx, audioPath)
+	if err != nil {
+		log.Printf("[Chunk %d] Audio QA skipped: %v", index, err)
+		return true
+	}
+	score := transcriptSimilarity(expected, transcript)
+	if score >= as.qaThreshold {
+		return true
+	}
+	log.Printf("[Chunk %d] Audio QA failed (similarity %.2f < %.2f): heard %q", index, score, as.qaThreshold, transcript)
+	as.events.Logf(jobID, "Chunk %d failed audio QA (similarity %.2f), regenerating", index, score)
+	return false
+}
+
+// transcriptSimilarity is 1 minus the word-level edit distance between expected and got,
+// relative to the longer of the two, ignoring case and punctuation
+func transcriptSimilarity(expected, got string) float64 {
+	a, b := qaWords(expected), qaWords(got)
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(b)])/float64(max(len(a), len(b)))
+}
+
+// qaWords lowercases s and splits it into words, dropping punctuation
+func qaWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestTranscriptSimilarity(t *testing.T) {
+	tests := []struct {
+		expected, got string
+		want          float64
+	}{
+		{"Xin chào, các bạn!", "xin chào các bạn", 1},
+		{"One two three four", "one two four", 0.75},
+		{"One two three four", "", 0},
+		{"", "", 1},
+	}
+	for _, tt := range tests {
+		if got := transcriptSimilarity(tt.expected, tt.got); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("transcriptSimilarity(%q, %q) = %.2f, want %.2f", tt.expected, tt.got, got, tt.want)
+		}
+	}
+}
+
+// scriptedTranscriber hears the transcripts in order, then the last one forever
+type scriptedTranscriber struct {
+	mu    sync.Mutex
+	heard []string
+	calls int
+}
+
+func (s *scriptedTranscriber) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return s.heard[min(s.calls, len(s.heard))-1], nil
+}
+
+func TestGenerateAudioChunks_QARegenerates(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	as.SetTTSCacheDir(t.TempDir())
+	tts := &scriptedTTS{audio: []byte("ID3")}
+	as.RegisterTTSProvider("fake", tts)
+	qa := &scriptedTranscriber{heard: []string{"hello wo", "hello world"}}
+	as.SetAudioQA(qa, 0.8)
+
+	if _, err := as.GenerateAudioChunks([]string{"Hello <break time=\"1s\"/> world."}, "fake", "v", []float64{1.0}, AudioFormat{}, "job1", 1, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tts.calls != 2 || qa.calls != 2 {
+		t.Errorf("Expected one regeneration, got %d synthesis and %d QA calls", tts.calls, qa.calls)
+	}
+
+	// A chunk that never passes is kept after the regeneration budget, and not cached
+	qa.heard, qa.calls, tts.calls = []string{"garbled"}, 0, 0
+	if _, err := as.GenerateAudioChunks([]string{"Goodbye."}, "fake", "v", []float64{1.0}, AudioFormat{}, "job2", 1, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tts.calls != maxQARegenerations+1 {
+		t.Errorf("Expected %d synthesis calls, got %d", maxQARegenerations+1, tts.calls)
+	}
+	if _, ok := as.cache.get(ttsCacheKey("fake", "v", 1.0, "Goodbye.")); ok {
+		t.Error("Audio that failed QA should not stay cached")
+	}
+}
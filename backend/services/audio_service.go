@@ -3,113 +3,261 @@ package services
 import (
 	"aituber/models"
 	"aituber/utils"
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
-// AudioService handles text-to-speech and audio processing
+// TTS providers selectable via GenerateRequest.TTSProvider
+const (
+	TTSProviderFPT        = "fpt"
+	TTSProviderElevenLabs = "elevenlabs"
+	TTSProviderAzure      = "azure" // Azure Cognitive Services Speech
+	TTSProviderPolly      = "polly" // Amazon Polly
+	TTSProviderPiper      = "piper" // local Piper, no API cost
+	TTSProviderXTTS       = "xtts"  // self-hosted Coqui XTTS with cloned voices
+	TTSProviderOpenAI     = "openai"
+)
+
+// AudioService handles text-to-speech and audio processing.
+// Engines are TTSProvider implementations registered under their provider name.
 type AudioService struct {
-	apiPool           *utils.APIKeyPool
-	elevenLabsAPIKey  string
-	httpClient        *http.Client
+	providers    map[string]TTSProvider
+	providersMux sync.RWMutex
+
 	tempDir           string
 	audioBitrate      string
 	sampleRate        int
 	crossfadeDuration float64
-	rateLimiter       <-chan time.Time
+	loudness          utils.LoudnessTarget
+	events            EventLogger
+	cache             *ttsCache   // nil disables the TTS cache
+	qa                Transcriber // nil disables audio QA
+	qaThreshold       float64
+	fallback          string       // provider used while the requested one's circuit is open
+	audit             *TTSAuditLog // nil disables the TTS audit log
 }
 
-// NewAudioService creates a new audio service
-func NewAudioService(apiPool *utils.APIKeyPool, elevenLabsKey string, tempDir string, audioBitrate string, sampleRate int, crossfadeDuration float64) *AudioService {
-	limiter := time.Tick(5000 * time.Millisecond)
+// AudioFormat is a job's override of the service's audio encoding; zero fields keep the defaults
+type AudioFormat struct {
+	Bitrate    string   // e.g. "192k"
+	SampleRate int      // Hz
+	Crossfade  *float64 // seconds between chunks; 0 disables
+}
 
+// resolve fills the unset fields of f with the service defaults
+func (as *AudioService) resolve(f AudioFormat) (bitrate string, sampleRate int, crossfade float64) {
+	bitrate, sampleRate, crossfade = as.audioBitrate, as.sampleRate, as.crossfadeDuration
+	if f.Bitrate != "" {
+		bitrate = f.Bitrate
+	}
+	if f.SampleRate > 0 {
+		sampleRate = f.SampleRate
+	}
+	if f.Crossfade != nil {
+		crossfade = *f.Crossfade
+	}
+	return bitrate, sampleRate, crossfade
+}
+
+// NewAudioService creates a new audio service with no providers; see RegisterTTSProvider
+func NewAudioService(tempDir string, audioBitrate string, sampleRate int, crossfadeDuration float64) *AudioService {
 	return &AudioService{
-		apiPool:          apiPool,
-		elevenLabsAPIKey: elevenLabsKey,
-		httpClient: &http.Client{
-			Timeout: 2 * time.Minute,
-		},
+		providers:         make(map[string]TTSProvider),
 		tempDir:           tempDir,
 		audioBitrate:      audioBitrate,
 		sampleRate:        sampleRate,
 		crossfadeDuration: crossfadeDuration,
-		rateLimiter:       limiter,
+		loudness:          utils.DefaultLoudnessTarget,
+	}
+}
+
+// SetLoudnessTarget sets the loudness the merged narration is normalized to
+func (as *AudioService) SetLoudnessTarget(target utils.LoudnessTarget) {
+	as.loudness = target
+}
+
+// SetEventLogger routes TTS retries into the job's event timeline
+func (as *AudioService) SetEventLogger(fn EventLogger) {
+	as.events = fn
+}
+
+// SetTTSCacheDir enables the content-addressed TTS cache in dir; empty disables it
+func (as *AudioService) SetTTSCacheDir(dir string) {
+	if dir == "" {
+		as.cache = nil
+		return
 	}
+	as.cache = &ttsCache{dir: dir}
 }
 
-// FPTTTSResponse represents FPT.AI TTS API response
-type FPTTTSResponse struct {
-	Async     string `json:"async,omitempty"`
-	Error     int    `json:"error,omitempty"`
-	Message   string `json:"message,omitempty"`
-	RequestID string `json:"request_id,omitempty"`
+// SetTTSFallback names the provider that takes over chunks while the requested provider's
+// circuit breaker is open; empty disables the fallback
+func (as *AudioService) SetTTSFallback(provider string) {
+	as.fallback = provider
 }
 
-// ElevenLabsTTSWithTimestampsResponse represents ElevenLabs TTS API response with timestamps
-type ElevenLabsTTSWithTimestampsResponse struct {
-	Audio     []byte `json:"audio"`
-	Alignment struct {
-		Chars            []string `json:"chars"`
-		CharStartTimesMs []int    `json:"char_start_times_ms"`
-		CharEndTimesMs   []int    `json:"char_end_times_ms"`
-	} `json:"alignment"`
+// SetTTSAudit records every provider call of every chunk in l; nil disables auditing
+func (as *AudioService) SetTTSAudit(l *TTSAuditLog) {
+	as.audit = l
 }
 
-// GenerateAudioChunks generates audio for each text chunk (FPT.AI flow)
-func (as *AudioService) GenerateAudioChunks(chunks []string, voice string, speed float64, jobID string, maxConcurrent int) ([]string, error) {
+// SetAudioQA transcribes every synthesized chunk with t and regenerates chunks whose transcript
+// is less similar than threshold (0-1) to their text; a nil t disables QA
+func (as *AudioService) SetAudioQA(t Transcriber, threshold float64) {
+	as.qa = t
+	as.qaThreshold = threshold
+}
+
+// AudioChunk is the outcome of synthesizing chunk Index
+type AudioChunk struct {
+	Index int
+	Path  string
+	Err   error
+}
+
+// GenerateAudioChunks generates audio for each text chunk with the named provider ("fpt" by default),
+// speaking chunk i at speeds[i]. onProgress, if non-nil, is called after each chunk finishes with the number done so far.
+func (as *AudioService) GenerateAudioChunks(chunks []string, provider, voice string, speeds []float64, format AudioFormat, jobID string, maxConcurrent int, onProgress ProgressFunc) ([]string, error) {
+	results, err := as.StreamAudioChunks(chunks, provider, voice, speeds, format, jobID, maxConcurrent)
+	if err != nil {
+		return nil, err
+	}
+
 	audioPaths := make([]string, len(chunks))
 	errors := make([]error, len(chunks))
+	tracker := newProgressTracker(len(chunks), onProgress)
+	for chunk := range results {
+		if chunk.Err != nil {
+			errors[chunk.Index] = chunk.Err
+			continue
+		}
+		audioPaths[chunk.Index] = chunk.Path
+		tracker.step()
+	}
+	for i, err := range errors {
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate audio chunk %d: %w", i, err)
+		}
+	}
+	return audioPaths, nil
+}
 
-	log.Printf("[AudioService] Starting chunked audio generation (FPT) for %d chunks", len(chunks))
+// StreamAudioChunks synthesizes the chunks like GenerateAudioChunks but delivers each one as soon as
+// it is ready, so callers can start on early chunks while later ones are still synthesizing.
+// Workers take chunks in order, so results arrive roughly front to back. The channel is closed
+// after the last chunk.
+func (as *AudioService) StreamAudioChunks(chunks []string, provider, voice string, speeds []float64, format AudioFormat, jobID string, maxConcurrent int) (<-chan AudioChunk, error) {
+	if provider == "" {
+		provider = TTSProviderFPT
+	}
+	if len(speeds) != len(chunks) {
+		return nil, fmt.Errorf("got %d speeds for %d chunks", len(speeds), len(chunks))
+	}
+	tts, ok := as.ttsProvider(provider)
+	if !ok {
+		return nil, fmt.Errorf("TTS provider %q is not configured", provider)
+	}
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
 
-	// Create semaphore
-	sem := make(chan struct{}, maxConcurrent)
-	var wg sync.WaitGroup
+	_, sampleRate, _ := as.resolve(format)
+	ctx := context.Background()
+	var cacheHits int32
 
-	for i, chunk := range chunks {
+	log.Printf("[AudioService] Starting chunked audio generation (%s) for %d chunks", provider, len(chunks))
+
+	indexes := make(chan int, len(chunks))
+	for i := range chunks {
+		indexes <- i
+	}
+	close(indexes)
+
+	results := make(chan AudioChunk, len(chunks))
+	var wg sync.WaitGroup
+	for w := 0; w < maxConcurrent && w < len(chunks); w++ {
 		wg.Add(1)
-		go func(index int, text string) {
+		go func() {
 			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			// Force FPT fallback logic by passing provider context if needed,
-			// but here we just call the old robust segment flow.
-			audioPath, err := as.generateSingleAudioFPT(text, voice, speed, jobID, index)
-			if err == nil {
-				audioPath, err = as.postProcessAudio(audioPath, jobID, index)
-			}
-			if err != nil {
-				errors[index] = err
-			} else {
-				audioPaths[index] = audioPath
+			for index := range indexes {
+				text, speed := chunks[index], speeds[index]
+				audioPath, err := as.cachedChunk(provider, text, voice, speed, jobID, index)
+				if err == nil && audioPath != "" {
+					atomic.AddInt32(&cacheHits, 1)
+					audioPath, err = as.postProcessAudio(audioPath, sampleRate, jobID, index)
+				} else {
+					audioPath, err = as.synthesizeCheckedChunk(ctx, provider, tts, text, voice, speed, sampleRate, jobID, index)
+				}
+				if err != nil && errors.Is(err, ErrTTSCircuitOpen) {
+					audioPath, err = as.synthesizeFallback(ctx, provider, text, voice, speed, sampleRate, jobID, index, err)
+				}
+				results <- AudioChunk{Index: index, Path: audioPath, Err: err}
 			}
-		}(i, chunk)
+		}()
 	}
 
-	wg.Wait()
-	for i, err := range errors {
+	go func() {
+		wg.Wait()
+		if cacheHits > 0 {
+			log.Printf("[AudioService] %d/%d chunks reused from TTS cache", cacheHits, len(chunks))
+			as.events.Logf(jobID, "%d of %d audio chunks reused from TTS cache", cacheHits, len(chunks))
+		}
+		close(results)
+	}()
+	return results, nil
+}
+
+// synthesizeFallback synthesizes chunk index with the fallback provider after provider failed fast
+// with err; without a usable fallback it returns err
+func (as *AudioService) synthesizeFallback(ctx context.Context, provider, text, voice string, speed float64, sampleRate int, jobID string, index int, err error) (string, error) {
+	if as.fallback == "" || as.fallback == provider {
+		return "", err
+	}
+	tts, ok := as.ttsProvider(as.fallback)
+	if !ok {
+		return "", err
+	}
+	log.Printf("[Chunk %d] %s unavailable (%v), using %s", index, provider, err, as.fallback)
+	as.events.Logf(jobID, "Chunk %d: %s is failing, synthesized with %s instead", index, provider, as.fallback)
+	return as.synthesizeCheckedChunk(ctx, as.fallback, tts, text, voice, speed, sampleRate, jobID, index)
+}
+
+// synthesizeCheckedChunk synthesizes and post-processes chunk index, regenerating it while it
+// fails audio QA. After maxQARegenerations the last take is kept.
+func (as *AudioService) synthesizeCheckedChunk(ctx context.Context, provider string, tts TTSProvider, text, voice string, speed float64, sampleRate int, jobID string, index int) (string, error) {
+	for regenerations := 0; ; regenerations++ {
+		audioPath, err := as.synthesizeChunk(ctx, provider, tts, text, voice, speed, jobID, index)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate audio chunk %d: %w", i, err)
+			return "", err
+		}
+		audioPath, err = as.postProcessAudio(audioPath, sampleRate, jobID, index)
+		if err != nil || as.passesQA(ctx, text, audioPath, jobID, index) {
+			return audioPath, err
+		}
+		// The rejected take must not be served from the cache on the next attempt or render
+		if as.cache != nil {
+			as.cache.remove(ttsCacheKey(provider, voice, speed, text))
+		}
+		if regenerations == maxQARegenerations {
+			log.Printf("[Chunk %d] Keeping audio that failed QA after %d regenerations", index, regenerations)
+			return audioPath, nil
 		}
 	}
-	return audioPaths, nil
 }
 
 // GenerateAudioFullScript generates TTS for the entire script at once (ElevenLabs flow)
 // It then splits the audio into segments based on word alignments.
 func (as *AudioService) GenerateAudioFullScript(segments []models.VideoSegment, voice string, jobID string) ([]string, error) {
-	if as.elevenLabsAPIKey == "" || as.elevenLabsAPIKey == "placeholder" {
+	provider, _ := as.ttsProvider(TTSProviderElevenLabs)
+	elevenLabs, ok := provider.(*elevenLabsTTS)
+	if !ok {
 		return nil, fmt.Errorf("ElevenLabs API Key is missing")
 	}
 
@@ -125,11 +273,11 @@ func (as *AudioService) GenerateAudioFullScript(segments []models.VideoSegment,
 	}
 
 	// 2. Map Voice ID
-	actualVoiceID := as.mapToElevenLabsVoice(voice)
+	actualVoiceID := mapToElevenLabsVoice(voice)
 
 	// 3. Call ElevenLabs with timestamps
 	log.Printf("[AudioService] Calling ElevenLabs with timestamps for voice: %s", actualVoiceID)
-	audioData, alignment, err := as.callElevenLabsTTSWithTimestamps(fullContent.String(), actualVoiceID)
+	audioData, alignment, err := elevenLabs.callElevenLabsTTSWithTimestamps(fullContent.String(), actualVoiceID)
 	if err != nil {
 		return nil, fmt.Errorf("ElevenLabs full script failed: %w", err)
 	}
@@ -172,7 +320,7 @@ func (as *AudioService) GenerateAudioFullScript(segments []models.VideoSegment,
 		}
 
 		// Post-process (silence removal)
-		pacedPath, _ := as.postProcessAudio(segmentPath, jobID, i)
+		pacedPath, _ := as.postProcessAudio(segmentPath, as.sampleRate, jobID, i)
 		audioPaths[i] = pacedPath
 
 		lastEnd = endSec
@@ -182,349 +330,39 @@ func (as *AudioService) GenerateAudioFullScript(segments []models.VideoSegment,
 	return audioPaths, nil
 }
 
-// mapToElevenLabsVoice maps FPT voices or takes long ID
-func (as *AudioService) mapToElevenLabsVoice(voiceID string) string {
-	const (
-		elevenMaleID   = "ipTvfDXAg1zowfF1rv9w"
-		elevenFemaleID = "Si3s1VCb7dLbeqH57kiC"
-	)
-	if len(voiceID) >= 10 {
-		return voiceID
-	}
-	isMale := false
-	maleVoices := []string{"minhquang", "giahuy", "vandoan", "manhduc"}
-	for _, mv := range maleVoices {
-		if voiceID == mv {
-			isMale = true
-			break
-		}
-	}
-	if isMale {
-		return elevenMaleID
-	}
-	return elevenFemaleID
-}
-
-// generateSingleAudioFPT calls FPT.AI TTS and polls for the result.
-// The TTS *API call* and the *poll* now have independent retry budgets:
-//   - API call: max 3 attempts (only if the API itself returns an error).
-//   - Poll: up to 30 attempts with exponential backoff (total ~5 min).
-//     If poll times out, we retry the API call to get a fresh URL.
-func (as *AudioService) generateSingleAudioFPT(text, voice string, speed float64, jobID string, index int) (string, error) {
-	audioPath := filepath.Join(as.tempDir, jobID, "audio", fmt.Sprintf("chunk_%03d.mp3", index))
-	maxAPIRetries := 36 // Tăng số lượng retries để có thể roll qua nhiều key hơn nếu FPT bị treo
-	var lastErr error
-	var asyncURLs []string // Mảng lưu các URL đã sinh ra trong các lần retry
-
-	for attempt := 0; attempt < maxAPIRetries; attempt++ {
-		if attempt > 0 {
-			log.Printf("[Chunk %d] Re-requesting FPT.AI TTS (Attempt %d/%d)", index, attempt+1, maxAPIRetries)
-		}
-
-		apiKey, err := as.apiPool.GetRandomKey()
-		if err != nil {
-			return "", fmt.Errorf("no available FPT API keys: %w", err)
-		}
-
-		asyncURL, apiErr := as.callFPTTTSAsync(text, voice, speed, apiKey)
-		if apiErr != nil {
-			log.Printf("[Chunk %d] FPT API call failed: %v", index, apiErr)
-			as.apiPool.MarkFailed(apiKey, 15*time.Second)
-			lastErr = apiErr
-			time.Sleep(3 * time.Second)
-			continue
-		}
-		as.apiPool.MarkSuccess(apiKey)
-
-		// Thêm url mới vào list
-		asyncURLs = append(asyncURLs, asyncURL)
-
-		// Poll TẤT CẢ các URL trong list độc lập
-		audioData, downloadErr := as.pollForAudioDownloadList(asyncURLs, index)
-		if downloadErr != nil {
-			// Poll exhausted.
-			log.Printf("[Chunk %d] Poll exhausted for %d URLs, will re-request TTS: %v", index, len(asyncURLs), downloadErr)
-			lastErr = downloadErr
-			continue // try getting a fresh URL
-		}
-
-		if err := as.saveAudioFile(audioData, audioPath); err != nil {
-			return "", err
-		}
-		return as.postProcessAudio(audioPath, jobID, index)
-	}
-	// Nếu thử hết 5 lần vẫn lỗi, trả về lỗi cuối cùng
-	return "", fmt.Errorf("FPT failed after %d API attempts, last error: %v", maxAPIRetries, lastErr)
-}
-
-// callElevenLabsTTSWithTimestamps calls ElevenLabs API and returns audio + alignment
-func (as *AudioService) callElevenLabsTTSWithTimestamps(text, voiceID string) ([]byte, ElevenLabsTTSWithTimestampsResponse_Alignment, error) {
-	// The endpoint for timestamps is slightly different and requires a streaming output format
-	url := fmt.Sprintf("https://api.elevenlabs.io/v1/text-to-speech/%s/stream/with-timestamps", voiceID)
-
-	payload := map[string]interface{}{
-		"text":     text,
-		"model_id": "eleven_multilingual_v2",
-		"voice_settings": map[string]interface{}{
-			"stability":        0.5,
-			"similarity_boost": 0.75,
-		},
-	}
-
-	jsonPayload, _ := json.Marshal(payload)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return nil, ElevenLabsTTSWithTimestampsResponse_Alignment{}, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("xi-api-key", as.elevenLabsAPIKey)
-
-	resp, err := as.httpClient.Do(req)
-	if err != nil {
-		return nil, ElevenLabsTTSWithTimestampsResponse_Alignment{}, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, ElevenLabsTTSWithTimestampsResponse_Alignment{}, fmt.Errorf("ElevenLabs API returned %d: %s", resp.StatusCode, string(body))
+// isFPTMaleVoice reports whether an FPT.AI voice name is male; other providers map by gender
+func isFPTMaleVoice(voice string) bool {
+	switch voice {
+	case "minhquang", "giahuy", "vandoan", "manhduc":
+		return true
 	}
-
-	// The "with-timestamps" response is a JSON stream where each line/chunk contains audio and alignment.
-	// Since we are calling the non-streaming REST wrapper as a block, we need to assemble it.
-	// Actually, for REST it returns a combined JSON object.
-	var fullAudio []byte
-	var finalAlignment ElevenLabsTTSWithTimestampsResponse_Alignment
-
-	decoder := json.NewDecoder(resp.Body)
-	for decoder.More() {
-		var chunk struct {
-			AudioBase64 string                                        `json:"audio_base64"`
-			Alignment   ElevenLabsTTSWithTimestampsResponse_Alignment `json:"alignment"`
-		}
-		if err := decoder.Decode(&chunk); err != nil {
-			break
-		}
-
-		if chunk.AudioBase64 != "" {
-			audio, _ := base64.StdEncoding.DecodeString(chunk.AudioBase64)
-			fullAudio = append(fullAudio, audio...)
-		}
-
-		if len(chunk.Alignment.Chars) > 0 {
-			finalAlignment.Chars = append(finalAlignment.Chars, chunk.Alignment.Chars...)
-			finalAlignment.CharStartTimesMs = append(finalAlignment.CharStartTimesMs, chunk.Alignment.CharStartTimesMs...)
-			finalAlignment.CharEndTimesMs = append(finalAlignment.CharEndTimesMs, chunk.Alignment.CharEndTimesMs...)
-		}
-	}
-
-	return fullAudio, finalAlignment, nil
-}
-
-// ElevenLabsTTSWithTimestampsResponse_Alignment helper struct
-type ElevenLabsTTSWithTimestampsResponse_Alignment struct {
-	Chars            []string `json:"chars"`
-	CharStartTimesMs []int    `json:"char_start_times_ms"`
-	CharEndTimesMs   []int    `json:"char_end_times_ms"`
+	return false
 }
 
-// callElevenLabsTTS calls ElevenLabs Text-to-Speech API (Legacy/Simple fallback)
-func (as *AudioService) callElevenLabsTTS(text, voiceID string) ([]byte, error) {
-	// Male: ipTvfDXAg1zowfF1rv9w
-	// Female: Si3s1VCb7dLbeqH57kiC
-	const (
-		elevenMaleID   = "ipTvfDXAg1zowfF1rv9w"
-		elevenFemaleID = "Si3s1VCb7dLbeqH57kiC"
-	)
-
-	actualVoiceID := voiceID
-	// If it's a long ID, it's already an ElevenLabs ID
-	if len(actualVoiceID) < 10 {
-		// It's an FPT voice ID, map it to ElevenLabs by gender
-		isMale := false
-		maleVoices := []string{"minhquang", "giahuy", "vandoan", "manhduc"}
-		for _, mv := range maleVoices {
-			if actualVoiceID == mv {
-				isMale = true
-				break
-			}
-		}
-
-		if isMale {
-			actualVoiceID = elevenMaleID
-		} else {
-			actualVoiceID = elevenFemaleID
-		}
-	}
-
-	url := fmt.Sprintf("https://api.elevenlabs.io/v1/text-to-speech/%s", actualVoiceID)
-
-	// ElevenLabs settings for v3
-	payload := map[string]interface{}{
-		"text":     text,
-		"model_id": "eleven_multilingual_v2", // Multilingual v2 is super stable for VN
-		"voice_settings": map[string]interface{}{
-			"stability":         0.5,
-			"similarity_boost":  0.75,
-			"style":             0.0,
-			"use_speaker_boost": true,
-		},
-	}
-
-	jsonPayload, _ := json.Marshal(payload)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return nil, err
+// isFPTVoice reports whether voice is one of the FPT.AI voice names
+func isFPTVoice(voice string) bool {
+	switch voice {
+	case "banmai", "leminh", "thuminh", "myan", "lannhi", "linhsan", "ngoclam":
+		return true
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("xi-api-key", as.elevenLabsAPIKey)
-
-	resp, err := as.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ElevenLabs API returned %d: %s", resp.StatusCode, string(body))
-	}
-
-	return io.ReadAll(resp.Body)
+	return isFPTMaleVoice(voice)
 }
 
-// postProcessAudio handles silence removal and path management
-func (as *AudioService) postProcessAudio(audioPath, jobID string, index int) (string, error) {
+// postProcessAudio removes silences and normalizes the chunk to the configured sample rate in stereo.
+// Providers (and fallbacks between them) disagree on both, and acrossfade rejects mismatched inputs.
+func (as *AudioService) postProcessAudio(audioPath string, sampleRate int, jobID string, index int) (string, error) {
 	pacedPath := filepath.Join(as.tempDir, jobID, "audio", fmt.Sprintf("chunk_paced_%03d.mp3", index))
-	if err := utils.RemoveAudioSilence(audioPath, pacedPath); err == nil {
+	if err := utils.NormalizeAudioChunk(audioPath, pacedPath, sampleRate, true); err == nil {
 		os.Remove(audioPath)
 		return pacedPath, nil
 	}
-	log.Printf("[Chunk %d] Silence removal failed (using original)", index)
-	return audioPath, nil
-}
-
-// callFPTTTSAsync calls FPT.AI TTS API and returns the async URL
-func (as *AudioService) callFPTTTSAsync(text, voice string, speed float64, apiKey string) (string, error) {
-	// Wait for rate limiter
-	<-as.rateLimiter
-
-	// FPT.AI TTS API endpoint
-	url := "https://api.fpt.ai/hmi/tts/v5"
-
-	// Create HTTP request with plain text body
-	req, err := http.NewRequest("POST", url, bytes.NewBufferString(text))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers (voice and speed must be in headers, not JSON body)
-	req.Header.Set("api-key", apiKey)
-	req.Header.Set("voice", voice)
-	req.Header.Set("speed", fmt.Sprintf("%.1f", speed))
-
-	// Send request
-	resp, err := as.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		// Try to parse error response
-		var errResp FPTTTSResponse
-		if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
-			return "", fmt.Errorf("API error: %s (code: %d)", errResp.Message, errResp.Error)
-		}
-		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	// Parse response to get async URL
-	var apiResp FPTTTSResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w. Body: %s", err, string(body))
-	}
-
-	if apiResp.Error != 0 {
-		return "", fmt.Errorf("API error: %s (code: %d)", apiResp.Message, apiResp.Error)
-	}
-
-	if apiResp.Async == "" {
-		return "", fmt.Errorf("no async URL in response. Body: %s", string(body))
-	}
-
-	log.Printf("[TTS API] Received async URL: %s (request_id: %s)", apiResp.Async, apiResp.RequestID)
-
-	// Đợi một khoảng ngắn để FPT tạo file. Thay vì 5s cứng ngắc, chờ 3s là đủ cho chunk nhỏ.
-	time.Sleep(3 * time.Second)
-
-	return apiResp.Async, nil
-}
-
-// pollForAudioDownloadList polls a list of FPT.AI generated audio URLs.
-// Quy định theo ý tưởng mới: Tổng thời gian chờ tối đa khoảng 60s.
-// Nó lặp qua tất cả URLs trong danh sách, nếu bất kỳ URL nào trả về data thành công thì thoát và lấy kết quả đó.
-func (as *AudioService) pollForAudioDownloadList(urls []string, chunkIndex int) ([]byte, error) {
-	maxAttempts := 15
-	pollInterval := 4 * time.Second // 15 attempts * 4s = ~60s tổng thời gian chờ timeout
-	var lastErr error
-
-	for i := 1; i <= maxAttempts; i++ {
-		var any404 bool
-
-		for _, url := range urls {
-			data, err := as.downloadAudio(url)
-			if err == nil {
-				log.Printf("[Chunk %d] Audio ready after %d poll attempt(s) from one of the URLs", chunkIndex, i)
-				return data, nil
-			}
-
-			lastErr = err
-			if strings.Contains(err.Error(), "404") {
-				any404 = true
-			}
-		}
-
-		if any404 {
-			log.Printf("[Chunk %d] Audio not ready (404) for %d URLs, waiting 4s (attempt %d/%d, max ~60s)", chunkIndex, len(urls), i, maxAttempts)
-		} else {
-			log.Printf("[Chunk %d] Download error: %v, waiting 4s (attempt %d/%d, max ~60s)", chunkIndex, lastErr, i, maxAttempts)
-		}
-
-		// Giữ nguyên 4s cho mỗi lần thử để rải đều trong 60s
-		time.Sleep(pollInterval)
-	}
-
-	return nil, fmt.Errorf("all %d URLs still 404 or err after ~60s wait (poll exhausted): %w", len(urls), lastErr)
-}
-
-// downloadAudio downloads audio from URL
-func (as *AudioService) downloadAudio(url string) ([]byte, error) {
-	resp, err := as.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download audio: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read audio data: %w", err)
+	log.Printf("[Chunk %d] Silence removal failed, normalizing format only", index)
+	if err := utils.NormalizeAudioChunk(audioPath, pacedPath, sampleRate, false); err != nil {
+		log.Printf("[Chunk %d] Normalizing to %d Hz stereo failed (using original): %v", index, sampleRate, err)
+		return audioPath, nil
 	}
-
-	return data, nil
+	os.Remove(audioPath)
+	return pacedPath, nil
 }
 
 // saveAudioFile saves audio data to file
@@ -550,18 +388,50 @@ func (as *AudioService) saveAudioFile(data []byte, path string) error {
 	return nil
 }
 
+// JoinWithPauses renders the audio of segment index from its synthesized chunks and the silences
+// requested by [pause ...] markers, in order
+func (as *AudioService) JoinWithPauses(pieces []AudioPiece, format AudioFormat, jobID string, index int) (string, error) {
+	bitrate, sampleRate, _ := as.resolve(format)
+	audioDir := filepath.Join(as.tempDir, jobID, "audio")
+	if err := os.MkdirAll(audioDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	paths := make([]string, 0, len(pieces))
+	for i, piece := range pieces {
+		if piece.Path != "" {
+			paths = append(paths, piece.Path)
+			continue
+		}
+		silencePath := filepath.Join(audioDir, fmt.Sprintf("pause_%03d_%02d.mp3", index, i))
+		if err := utils.GenerateSilence(silencePath, piece.Pause, sampleRate, bitrate); err != nil {
+			return "", fmt.Errorf("failed to generate %.2fs pause: %w", piece.Pause, err)
+		}
+		paths = append(paths, silencePath)
+	}
+
+	segmentPath := filepath.Join(audioDir, fmt.Sprintf("segment_%03d.mp3", index))
+	if err := utils.ConcatAudioFiles(paths, segmentPath, sampleRate, bitrate); err != nil {
+		return "", fmt.Errorf("failed to insert pauses: %w", err)
+	}
+	return segmentPath, nil
+}
+
 // MergeAudioFiles merges audio files with crossfade
-func (as *AudioService) MergeAudioFiles(audioPaths []string, outputPath string) error {
+func (as *AudioService) MergeAudioFiles(audioPaths []string, outputPath string, format AudioFormat) error {
 	if len(audioPaths) == 0 {
 		return fmt.Errorf("no audio files to merge")
 	}
 
 	// Use FFmpeg utility to merge with crossfade
+	bitrate, sampleRate, crossfade := as.resolve(format)
 	err := utils.MergeAudioWithCrossfade(
 		audioPaths,
 		outputPath,
-		as.crossfadeDuration,
-		as.audioBitrate,
+		crossfade,
+		bitrate,
+		sampleRate,
+		as.loudness,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to merge audio: %w", err)
@@ -1,12 +1,18 @@
 package services
 
 import (
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 )
 
 func TestMapToElevenLabsVoice(t *testing.T) {
-	as := &AudioService{}
-
 	tests := []struct {
 		voiceName string
 		expected  string // ElevenLabs ID
@@ -18,9 +24,94 @@ func TestMapToElevenLabsVoice(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := as.mapToElevenLabsVoice(tt.voiceName)
+		result := mapToElevenLabsVoice(tt.voiceName)
 		if result != tt.expected {
 			t.Errorf("mapToElevenLabsVoice(%s) = %s; want %s", tt.voiceName, result, tt.expected)
 		}
 	}
 }
+
+func TestMapToElevenLabsVoice_Presets(t *testing.T) {
+	if got := mapToElevenLabsVoice("Rachel"); got != "21m00Tcm4TlvDq8nQR9k" {
+		t.Errorf("mapToElevenLabsVoice(Rachel) = %s", got)
+	}
+}
+
+func TestElevenLabsSpeed(t *testing.T) {
+	tests := map[float64]float64{0: 1.0, 0.5: 0.7, 1.0: 1.0, 1.1: 1.1, 2.0: 1.2}
+	for in, want := range tests {
+		if got := elevenLabsSpeed(in); got != want {
+			t.Errorf("elevenLabsSpeed(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestGenerateSingleAudioElevenLabs(t *testing.T) {
+	var mu sync.Mutex
+	var keysSeen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("xi-api-key")
+		mu.Lock()
+		keysSeen = append(keysSeen, key)
+		mu.Unlock()
+
+		if !strings.HasPrefix(r.URL.Path, "/v1/text-to-speech/21m00Tcm4TlvDq8nQR9k/stream") {
+			http.Error(w, "unknown voice", http.StatusBadRequest)
+			return
+		}
+		if key == "bad-key" {
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model_id"] != "eleven_test" {
+			http.Error(w, "wrong model", http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("ID3-fake-mp3"))
+	}))
+	defer srv.Close()
+
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	as.RegisterTTSProvider(TTSProviderElevenLabs, &elevenLabsTTS{
+		pool:       utils.NewAPIKeyPool([]string{"bad-key", "good-key"}),
+		model:      "eleven_test",
+		baseURL:    srv.URL,
+		httpClient: srv.Client(),
+	})
+	provider, _ := as.ttsProvider(TTSProviderElevenLabs)
+
+	path, err := as.synthesizeChunk(context.Background(), TTSProviderElevenLabs, provider, "Hello there", "rachel", 1.0, "job1", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v (keys tried %v)", err, keysSeen)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "ID3-fake-mp3" {
+		t.Errorf("Unexpected audio content %q", data)
+	}
+
+	// Client errors other than auth/rate limits are not retried
+	mu.Lock()
+	keysSeen = nil
+	mu.Unlock()
+	if _, err := as.synthesizeChunk(context.Background(), TTSProviderElevenLabs, provider, "Hello", "long-unknown-voice-id", 1.0, "job1", 1); err == nil {
+		t.Error("Expected error for unknown voice")
+	}
+	if len(keysSeen) != 1 {
+		t.Errorf("Expected a single attempt for a 400, got %d", len(keysSeen))
+	}
+}
+
+func TestAudioService_ResolveFormat(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0.3)
+
+	if bitrate, rate, crossfade := as.resolve(AudioFormat{}); bitrate != "192k" || rate != 44100 || crossfade != 0.3 {
+		t.Errorf("Expected service defaults, got %s %d %g", bitrate, rate, crossfade)
+	}
+	zero := 0.0
+	if bitrate, rate, crossfade := as.resolve(AudioFormat{Bitrate: "128k", SampleRate: 48000, Crossfade: &zero}); bitrate != "128k" || rate != 48000 || crossfade != 0 {
+		t.Errorf("Expected overrides, got %s %d %g", bitrate, rate, crossfade)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Azure neural voices used when the request names an FPT.AI voice
+const (
+	azureDefaultMaleVoice   = "vi-VN-NamMinhNeural"
+	azureDefaultFemaleVoice = "vi-VN-HoaiMyNeural"
+	azureOutputFormat       = "audio-48khz-192kbitrate-mono-mp3"
+)
+
+// azureVoicePattern matches neural voice names such as "en-US-JennyNeural" or "zh-CN-XiaoxiaoMultilingualNeural"
+var azureVoicePattern = regexp.MustCompile(`^[a-z]{2,3}-[A-Za-z]{2,4}(-[A-Za-z]+)?-[A-Za-z]+Neural$`)
+
+// azureTTS is the Azure Cognitive Services Speech backend (neural voices via SSML)
+type azureTTS struct {
+	pool       *utils.APIKeyPool
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewAzureTTS creates the Azure Speech provider; region is e.g. "southeastasia"
+func NewAzureTTS(pool *utils.APIKeyPool, region string) TTSProvider {
+	return &azureTTS{
+		pool:       pool,
+		endpoint:   fmt.Sprintf("https://%s.tts.speech.microsoft.com/cognitiveservices/v1", region),
+		httpClient: newTTSHTTPClient(),
+	}
+}
+
+// SupportsSSML is true: inline SSML is placed inside the voice element as-is
+func (a *azureTTS) SupportsSSML() bool { return true }
+
+// Synthesize renders text through Azure's REST endpoint
+func (a *azureTTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	ssml := buildAzureSSML(text, mapToAzureVoice(voice), speed)
+	return withAPIKey(ctx, a.pool, "Azure Speech", func(apiKey string) ([]byte, int, error) {
+		return a.callAzureTTS(ctx, ssml, apiKey)
+	})
+}
+
+func (a *azureTTS) callAzureTTS(ctx context.Context, ssml, apiKey string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", a.endpoint, bytes.NewBufferString(ssml))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/ssml+xml")
+	req.Header.Set("X-Microsoft-OutputFormat", azureOutputFormat)
+	req.Header.Set("Ocp-Apim-Subscription-Key", apiKey)
+	req.Header.Set("User-Agent", "aituber")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("Azure Speech returned %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download audio stream: %w", err)
+	}
+	return data, resp.StatusCode, nil
+}
+
+// mapToAzureVoice passes Azure voice names (e.g. "en-US-JennyNeural") through and maps FPT voices by gender
+func mapToAzureVoice(voice string) string {
+	if azureVoicePattern.MatchString(voice) {
+		return voice
+	}
+	if isFPTMaleVoice(voice) {
+		return azureDefaultMaleVoice
+	}
+	return azureDefaultFemaleVoice
+}
+
+// buildAzureSSML wraps text (plain or inline SSML) in an SSML document for voice; the locale is taken from the voice name
+func buildAzureSSML(text, voice string, speed float64) string {
+	lang := "vi-VN"
+	if parts := strings.Split(voice, "-"); len(parts) >= 3 {
+		lang = parts[0] + "-" + parts[1]
+	}
+
+	body := unwrapSpeak(text)
+	if !IsSSML(text) {
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(text))
+		body = escaped.String()
+	}
+
+	// prosody rate is relative: speed 1.2 -> "+20%"
+	if speed <= 0 {
+		speed = 1.0
+	}
+	rate := fmt.Sprintf("%+d%%", int(math.Round((speed-1)*100)))
+
+	return fmt.Sprintf(
+		`<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="%s"><voice name="%s"><prosody rate="%s">%s</prosody></voice></speak>`,
+		lang, voice, rate, body,
+	)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMapToAzureVoice(t *testing.T) {
+	tests := map[string]string{
+		"en-US-JennyNeural":                "en-US-JennyNeural",
+		"minhquang":                        azureDefaultMaleVoice,
+		"banmai":                           azureDefaultFemaleVoice,
+		`en-US-Jenny"/><x a="Neural`:       azureDefaultFemaleVoice,
+		"zh-CN-XiaoxiaoMultilingualNeural": "zh-CN-XiaoxiaoMultilingualNeural",
+	}
+	for in, want := range tests {
+		if got := mapToAzureVoice(in); got != want {
+			t.Errorf("mapToAzureVoice(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildAzureSSML(t *testing.T) {
+	ssml := buildAzureSSML("Tom & Jerry <3", "en-US-JennyNeural", 1.2)
+	for _, want := range []string{`xml:lang="en-US"`, `<voice name="en-US-JennyNeural">`, `rate="+20%"`, "Tom &amp; Jerry &lt;3"} {
+		if !strings.Contains(ssml, want) {
+			t.Errorf("SSML missing %q: %s", want, ssml)
+		}
+	}
+	if ssml := buildAzureSSML("x", azureDefaultFemaleVoice, 0.8); !strings.Contains(ssml, `rate="-20%"`) || !strings.Contains(ssml, `xml:lang="vi-VN"`) {
+		t.Errorf("Unexpected SSML: %s", ssml)
+	}
+	ssml = buildAzureSSML(`<speak>Hi <break time="300ms"/> <emphasis level="strong">there</emphasis></speak>`, "en-US-JennyNeural", 1.0)
+	if !strings.Contains(ssml, `Hi <break time="300ms"/> <emphasis level="strong">there</emphasis>`) || strings.Count(ssml, "<speak") != 1 {
+		t.Errorf("Inline SSML not passed through: %s", ssml)
+	}
+}
+
+func TestAzureTTS_Synthesize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Ocp-Apim-Subscription-Key") != "azure-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/ssml+xml" || r.Header.Get("X-Microsoft-OutputFormat") == "" {
+			http.Error(w, "bad headers", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "vi-VN-NamMinhNeural") {
+			http.Error(w, "wrong voice", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ID3-azure"))
+	}))
+	defer srv.Close()
+
+	provider := NewAzureTTS(utils.NewAPIKeyPool([]string{"azure-key"}), "southeastasia").(*azureTTS)
+	if !strings.HasPrefix(provider.endpoint, "https://southeastasia.tts.speech.microsoft.com/") {
+		t.Errorf("Unexpected endpoint %s", provider.endpoint)
+	}
+	provider.endpoint = srv.URL
+	provider.httpClient = srv.Client()
+
+	data, err := provider.Synthesize(context.Background(), "Xin chào", "minhquang", 1.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "ID3-azure" {
+		t.Errorf("Unexpected audio content %q", data)
+	}
+}
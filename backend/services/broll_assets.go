@@ -0,0 +1,126 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BrollAssetStore keeps clips uploaded by tenants. Each asset is "<id><ext>" plus a "<id>.json"
+// sidecar written last, so a half-finished upload is never listed. The sidecar doubles as a
+// BrollLibrary sidecar, so the folder can also serve as BROLL_DIR.
+type BrollAssetStore struct {
+	dir string
+}
+
+// NewBrollAssetStore creates a store in dir, or returns nil (uploads disabled) for an empty dir
+func NewBrollAssetStore(dir string) *BrollAssetStore {
+	if dir == "" {
+		return nil
+	}
+	return &BrollAssetStore{dir: dir}
+}
+
+// Save stores the clip read from src for tenant. fileName (as uploaded) picks the extension;
+// files ffprobe cannot read are rejected.
+func (s *BrollAssetStore) Save(tenant, fileName string, src io.Reader, keywords []string) (models.BrollAsset, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if !brollExtensions[ext] {
+		return models.BrollAsset{}, fmt.Errorf("unsupported clip type %q (expected mp4, mov, m4v, webm or mkv)", ext)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return models.BrollAsset{}, fmt.Errorf("failed to create b-roll upload dir: %w", err)
+	}
+
+	asset := models.BrollAsset{
+		ID:        uuid.New().String(),
+		Tenant:    tenant,
+		FileName:  filepath.Base(fileName),
+		Keywords:  keywords,
+		CreatedAt: time.Now(),
+	}
+	clipPath := filepath.Join(s.dir, asset.ID+ext)
+	size, err := writeBrollFile(clipPath, src)
+	if err != nil {
+		os.Remove(clipPath)
+		return models.BrollAsset{}, fmt.Errorf("failed to store clip: %w", err)
+	}
+	asset.Size = size
+	if asset.Duration, err = utils.GetVideoDuration(clipPath); err != nil || asset.Duration <= 0 {
+		os.Remove(clipPath)
+		return models.BrollAsset{}, fmt.Errorf("file is not a readable video")
+	}
+
+	data, _ := json.MarshalIndent(asset, "", "  ")
+	if err := os.WriteFile(filepath.Join(s.dir, asset.ID+".json"), data, 0644); err != nil {
+		os.Remove(clipPath)
+		return models.BrollAsset{}, fmt.Errorf("failed to store clip metadata: %w", err)
+	}
+	return asset, nil
+}
+
+func writeBrollFile(path string, src io.Reader) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, src)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// Get returns asset id, whoever uploaded it
+func (s *BrollAssetStore) Get(id string) (models.BrollAsset, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return models.BrollAsset{}, fmt.Errorf("b-roll asset %q not found", id)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		return models.BrollAsset{}, fmt.Errorf("b-roll asset %q not found", id)
+	}
+	var asset models.BrollAsset
+	if err := json.Unmarshal(data, &asset); err != nil {
+		return models.BrollAsset{}, fmt.Errorf("b-roll asset %q is corrupt: %w", id, err)
+	}
+	return asset, nil
+}
+
+// List returns the tenant's assets, newest first
+func (s *BrollAssetStore) List(tenant string) ([]models.BrollAsset, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	assets := []models.BrollAsset{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if asset, err := s.Get(id); err == nil && asset.Tenant == tenant {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].CreatedAt.After(assets[j].CreatedAt) })
+	return assets, nil
+}
+
+// Clip returns the stored file of asset for segment assembly
+func (s *BrollAssetStore) Clip(asset models.BrollAsset) BrollClip {
+	return BrollClip{
+		Path:     filepath.Join(s.dir, asset.ID+strings.ToLower(filepath.Ext(asset.FileName))),
+		Duration: asset.Duration,
+		Keywords: asset.Keywords,
+	}
+}
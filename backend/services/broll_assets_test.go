@@ -0,0 +1,56 @@
+package services
+
+import (
+	"aituber/models"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeBrollAsset stores an asset as Save would, without probing a real video
+func writeBrollAsset(t *testing.T, dir string, asset models.BrollAsset) {
+	t.Helper()
+	data, _ := json.Marshal(asset)
+	if err := os.WriteFile(filepath.Join(dir, asset.ID+".json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write sidecar: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, asset.ID+filepath.Ext(asset.FileName)), []byte("video"), 0644); err != nil {
+		t.Fatalf("Failed to write clip: %v", err)
+	}
+}
+
+func TestBrollAssetStore(t *testing.T) {
+	if NewBrollAssetStore("") != nil {
+		t.Error("Expected uploads disabled without a dir")
+	}
+	dir := t.TempDir()
+	store := NewBrollAssetStore(dir)
+	old := models.BrollAsset{ID: "0b5a3c36-55d6-4b8e-9b52-8d1f0c1e3a01", Tenant: "acme", FileName: "Office.MOV", Duration: 6, CreatedAt: time.Now().Add(-time.Hour)}
+	recent := models.BrollAsset{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Tenant: "acme", FileName: "team.mp4", Duration: 4, CreatedAt: time.Now()}
+	other := models.BrollAsset{ID: "16fd2706-8baf-433b-82eb-8c7fada847da", Tenant: "globex", FileName: "lab.mp4", Duration: 9, CreatedAt: time.Now()}
+	for _, a := range []models.BrollAsset{old, recent, other} {
+		writeBrollAsset(t, dir, a)
+	}
+
+	assets, err := store.List("acme")
+	if err != nil || len(assets) != 2 || assets[0].ID != recent.ID || assets[1].ID != old.ID {
+		t.Errorf("Expected acme's assets newest first, got %+v (%v)", assets, err)
+	}
+	if _, err := store.Get("../../etc/passwd"); err == nil {
+		t.Error("Expected a non-UUID ID to be rejected")
+	}
+	got, err := store.Get(old.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if clip := store.Clip(got); clip.Path != filepath.Join(dir, old.ID+".mov") || clip.Duration != 6 {
+		t.Errorf("Unexpected clip %+v", clip)
+	}
+
+	if _, err := store.Save("acme", "notes.txt", strings.NewReader("text"), nil); err == nil {
+		t.Error("Expected a non-video file type to be rejected")
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var brollExtensions = map[string]bool{".mp4": true, ".mov": true, ".m4v": true, ".webm": true, ".mkv": true}
+
+// maxBrollPicks bounds how often a short library is cycled to cover one segment
+const maxBrollPicks = 20
+
+// BrollLibrary indexes a folder of the user's own footage for video_source "local". A clip's
+// keywords come from its file name ("city-night_traffic.mp4" -> city, night, traffic) plus an
+// optional sidecar "<name>.json"; durations are probed once per file version and cached.
+type BrollLibrary struct {
+	dir   string
+	mu    sync.Mutex
+	cache map[string]brollEntry // by file name
+}
+
+// BrollClip is one indexed file of the library
+type BrollClip struct {
+	Path     string
+	Duration float64 // seconds
+	Keywords []string
+}
+
+type brollEntry struct {
+	clip    BrollClip
+	size    int64
+	modTime time.Time
+}
+
+// brollSidecar is the optional "<name>.json" next to a clip
+type brollSidecar struct {
+	Keywords    []string `json:"keywords"`
+	Description string   `json:"description"`
+	Duration    float64  `json:"duration"` // seconds; skips probing when set
+}
+
+// NewBrollLibrary creates a library over dir; nothing is read until Clips is called
+func NewBrollLibrary(dir string) *BrollLibrary {
+	return &BrollLibrary{dir: dir, cache: make(map[string]brollEntry)}
+}
+
+// SetBrollLibrary enables video_source "local" with footage from lib
+func (sv *StockVideoService) SetBrollLibrary(lib *BrollLibrary) {
+	sv.broll = lib
+}
+
+// Clips indexes the library: new or changed files are probed, removed ones forgotten. Files
+// whose duration cannot be read are skipped. Clips are sorted by path.
+func (l *BrollLibrary) Clips() ([]BrollClip, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read b-roll library: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	index := make(map[string]brollEntry)
+	var clips []BrollClip
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !brollExtensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		cached, ok := l.cache[name]
+		if !ok || cached.size != info.Size() || !cached.modTime.Equal(info.ModTime()) {
+			clip, err := l.indexClip(name)
+			if err != nil {
+				log.Printf("[B-roll] Skipping %s: %v", name, err)
+				continue
+			}
+			cached = brollEntry{clip: clip, size: info.Size(), modTime: info.ModTime()}
+		}
+		index[name] = cached
+		clips = append(clips, cached.clip)
+	}
+	l.cache = index
+
+	sort.Slice(clips, func(i, j int) bool { return clips[i].Path < clips[j].Path })
+	return clips, nil
+}
+
+// indexClip reads the keywords and duration of one file
+func (l *BrollLibrary) indexClip(name string) (BrollClip, error) {
+	path := filepath.Join(l.dir, name)
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	clip := BrollClip{Path: path, Keywords: brollWords(base)}
+
+	if data, err := os.ReadFile(filepath.Join(l.dir, base+".json")); err == nil {
+		var sidecar brollSidecar
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			return clip, fmt.Errorf("invalid sidecar: %w", err)
+		}
+		for _, kw := range sidecar.Keywords {
+			clip.Keywords = append(clip.Keywords, brollWords(kw)...)
+		}
+		clip.Keywords = append(clip.Keywords, brollWords(sidecar.Description)...)
+		clip.Duration = sidecar.Duration
+	}
+	if clip.Duration <= 0 {
+		d, err := utils.GetVideoDuration(path)
+		if err != nil {
+			return clip, err
+		}
+		clip.Duration = d
+	}
+	return clip, nil
+}
+
+// brollWords splits s into lowercase words on anything but letters and digits
+func brollWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// pickBrollClips chooses clips covering minDuration seconds for keywords. Clips the job has not
+// used come first, then the highest similarity when scores (one per clip) are given, otherwise
+// those matching the most keywords; a library too short for the segment is cycled. Picked clips
+// are marked used.
+func pickBrollClips(clips []BrollClip, keywords string, scores []float64, minDuration float64, usedMedia *sync.Map) []BrollClip {
+	if len(clips) == 0 {
+		return nil
+	}
+	wanted := make(map[string]bool)
+	for _, w := range brollWords(keywords) {
+		wanted[w] = true
+	}
+	type rankedClip struct {
+		clip  BrollClip
+		used  bool
+		score float64
+	}
+	ranked := make([]rankedClip, len(clips))
+	for i, clip := range clips {
+		_, used := usedMedia.Load("local_" + clip.Path)
+		matched := make(map[string]bool)
+		for _, kw := range clip.Keywords {
+			if wanted[kw] {
+				matched[kw] = true
+			}
+		}
+		score := float64(len(matched))
+		if scores != nil {
+			score = scores[i]
+		}
+		ranked[i] = rankedClip{clip: clip, used: used, score: score}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].used != ranked[j].used {
+			return !ranked[i].used
+		}
+		return ranked[i].score > ranked[j].score
+	})
+
+	var picked []BrollClip
+	var total float64
+	for i := 0; total < minDuration && i < maxBrollPicks; i++ {
+		clip := ranked[i%len(ranked)].clip
+		usedMedia.Store("local_"+clip.Path, true)
+		picked = append(picked, clip)
+		total += clip.Duration
+	}
+	return picked
+}
+
+// PrepareLocalSegment builds the clip of segment segIndex from the b-roll library, trimmed and
+// scaled like stock footage; with an embedder the clips closest in meaning to the narration text win
+func (sv *StockVideoService) PrepareLocalSegment(keywords, text string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
+	if sv.broll == nil {
+		return "", fmt.Errorf("local b-roll library is not configured (set BROLL_DIR)")
+	}
+	clips, err := sv.broll.Clips()
+	if err != nil {
+		return "", err
+	}
+	if len(clips) == 0 {
+		return "", fmt.Errorf("b-roll library %s has no clips", sv.broll.dir)
+	}
+	sv.events.Logf(jobID, "Segment %d: using local clips for %q", segIndex+1, keywords)
+	return sv.assembleBrollSegment(clips, keywords, segmentMatchText(keywords, text, ""), audioDuration, jobID, segIndex, orientation, "local: "+keywords)
+}
+
+// PrepareUploadedSegment builds the clip of segment segIndex from one uploaded b-roll clip,
+// looped if it is shorter than the narration
+func (sv *StockVideoService) PrepareUploadedSegment(clip BrollClip, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
+	return sv.assembleBrollSegment([]BrollClip{clip}, "", "", audioDuration, jobID, segIndex, orientation, "upload: "+filepath.Base(clip.Path))
+}
+
+// assembleBrollSegment picks clips for keywords, or by similarity to query when an embedder is
+// set, and trims and scales them like stock footage
+func (sv *StockVideoService) assembleBrollSegment(clips []BrollClip, keywords, query string, audioDuration float64, jobID string, segIndex int, orientation, label string) (string, error) {
+	segDir := filepath.Join(sv.tempDir, jobID, "stock", fmt.Sprintf("seg_%03d", segIndex))
+	if err := os.MkdirAll(segDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create segment dir: %w", err)
+	}
+	trackIface, _ := sv.jobMediaTrack.LoadOrStore(jobID, &sync.Map{})
+	usedMedia := trackIface.(*sync.Map)
+
+	var scores []float64
+	if len(clips) > 1 {
+		descriptions := make([]string, len(clips))
+		for i, clip := range clips {
+			descriptions[i] = strings.Join(clip.Keywords, " ")
+		}
+		scores = sv.similarities(context.Background(), jobID, query, descriptions)
+	}
+	picked := pickBrollClips(clips, keywords, scores, audioDuration+0.5, usedMedia)
+	paths := make([]string, len(picked))
+	for i, clip := range picked {
+		paths[i] = clip.Path
+	}
+	return sv.processAndTrimStockVideo(paths, audioDuration, orientation, segDir, segIndex, label)
+}
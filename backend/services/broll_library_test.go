@@ -0,0 +1,73 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestBrollLibrary_Clips(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"city-night_traffic.mp4":  "video",
+		"city-night_traffic.json": `{"keywords": ["Rush Hour"], "description": "cars downtown", "duration": 12.5}`,
+		"beach.mov":               "video",
+		"beach.json":              `{"duration": 8}`,
+		"notes.txt":               "not footage",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	lib := NewBrollLibrary(dir)
+	clips, err := lib.Clips()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(clips) != 2 {
+		t.Fatalf("Expected 2 clips, got %+v", clips)
+	}
+	city := clips[1]
+	if city.Path != filepath.Join(dir, "city-night_traffic.mp4") || city.Duration != 12.5 {
+		t.Errorf("Unexpected clip %+v", city)
+	}
+	want := []string{"city", "night", "traffic", "rush", "hour", "cars", "downtown"}
+	if len(city.Keywords) != len(want) {
+		t.Fatalf("Expected keywords %v, got %v", want, city.Keywords)
+	}
+	for i, kw := range want {
+		if city.Keywords[i] != kw {
+			t.Errorf("Expected keywords %v, got %v", want, city.Keywords)
+			break
+		}
+	}
+
+	// Removed files drop out of the index
+	os.Remove(filepath.Join(dir, "beach.mov"))
+	if clips, _ := lib.Clips(); len(clips) != 1 {
+		t.Errorf("Expected 1 clip after removal, got %d", len(clips))
+	}
+}
+
+func TestPickBrollClips(t *testing.T) {
+	clips := []BrollClip{
+		{Path: "a.mp4", Duration: 4, Keywords: []string{"beach", "sunset"}},
+		{Path: "b.mp4", Duration: 4, Keywords: []string{"city", "night"}},
+		{Path: "c.mp4", Duration: 4, Keywords: []string{"city", "night", "traffic"}},
+	}
+	used := &sync.Map{}
+
+	picked := pickBrollClips(clips, "city traffic at night", nil, 6, used)
+	if len(picked) != 2 || picked[0].Path != "c.mp4" || picked[1].Path != "b.mp4" {
+		t.Errorf("Expected best matches first, got %+v", picked)
+	}
+
+	// The next segment prefers the clip not used yet, then cycles the library to cover 10s
+	picked = pickBrollClips(clips, "city", nil, 10, used)
+	if len(picked) != 3 || picked[0].Path != "a.mp4" {
+		t.Errorf("Expected unused clip first and 3 picks, got %+v", picked)
+	}
+}
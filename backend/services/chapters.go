@@ -0,0 +1,174 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// YouTube only turns a description's timestamps into chapters when the first is 00:00, there are
+// at least three and each lasts at least ten seconds
+const (
+	minChapters       = 3
+	minChapterSeconds = 10.0
+	autoChapters      = 6  // untitled scripts are divided into about this many chapters
+	maxChapterTitle   = 50 // columns; longer narration is cut at a word
+)
+
+// ChaptersFileName is the chapter list saved next to a job's subtitles
+const ChaptersFileName = "chapters.txt"
+
+// chapter is one line of the chapters file
+type chapter struct {
+	Start float64
+	Title string
+}
+
+// writeChapters saves output/chapters.txt, lines such as "01:23 Topic A" to paste into a YouTube
+// description, from the segments and the length of their narration. Segments with a slide title
+// or on-screen text start chapters of that name; otherwise the script is divided evenly and
+// chapters are named after their first words. Too short a video for chapters is only logged.
+func (s *VideoWorkflowService) writeChapters(jobID, tempDir string, req models.GenerateRequest, assets *models.RenderAssets) {
+	offset := s.introDuration(req)
+	starts, segments, end, err := s.narrationTimeline(assets, offset)
+	if err != nil {
+		log.Printf("[Job %s] Chapters skipped: %v", jobID, err)
+		return
+	}
+
+	chapters := buildChapters(s.textProcessor, segments, starts, end, offset)
+	if len(chapters) < minChapters {
+		s.jobManager.LogEvent(jobID, fmt.Sprintf("Chapters skipped: the video is too short for %d chapters of %.0fs", minChapters, minChapterSeconds))
+		return
+	}
+	var b strings.Builder
+	for _, ch := range chapters {
+		fmt.Fprintf(&b, "%s %s\n", chapterTimestamp(ch.Start), ch.Title)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "output", ChaptersFileName), []byte(b.String()), 0644); err != nil {
+		log.Printf("[Job %s] Failed to write chapters: %v", jobID, err)
+		return
+	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("%d chapters written", len(chapters)))
+}
+
+// narrationTimeline is when each narrated segment of assets starts, counting from offset, and
+// when the narration ends. Audio files are kept segments in order; a segment without narration
+// has none and is left out.
+func (s *VideoWorkflowService) narrationTimeline(assets *models.RenderAssets, offset float64) ([]float64, []models.VideoSegment, float64, error) {
+	var starts []float64
+	var segments []models.VideoSegment
+	at, k := offset, 0
+	for _, seg := range assets.Segments {
+		if k >= len(assets.AudioPaths) || k >= len(assets.AudioTexts) || seg.Text != assets.AudioTexts[k] {
+			continue
+		}
+		duration, err := utils.GetAudioDuration(assets.AudioPaths[k])
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if k > 0 {
+			at -= s.cfg.AudioCrossfadeDuration
+		}
+		starts, segments = append(starts, at), append(segments, seg)
+		at += duration
+		k++
+	}
+	return starts, segments, at, nil
+}
+
+// buildChapters groups segments, starting at starts and ending at end, into chapters of at least
+// minChapterSeconds. An intro longer than that is a chapter of its own; a shorter one belongs to
+// the first chapter, which always starts at 0. A heading followed too soon by the next gives way
+// to it.
+func buildChapters(tp *TextProcessor, segments []models.VideoSegment, starts []float64, end, intro float64) []chapter {
+	if len(segments) == 0 {
+		return nil
+	}
+	titled := false
+	for _, seg := range segments {
+		titled = titled || segmentHeading(seg) != ""
+	}
+
+	// Chapters may start where the heading changes, or at any segment of an untitled script
+	var candidates []chapter
+	for i, seg := range segments {
+		title := segmentHeading(seg)
+		if i > 0 && titled && (title == "" || title == candidates[len(candidates)-1].Title) {
+			continue
+		}
+		if title == "" {
+			title = narrationTitle(tp, seg.Text)
+		}
+		candidates = append(candidates, chapter{Start: starts[i], Title: title})
+	}
+
+	every := math.Max(minChapterSeconds, (end-intro)/autoChapters)
+	var chapters []chapter
+	if intro >= minChapterSeconds {
+		chapters = append(chapters, chapter{Start: 0, Title: "Intro"})
+	}
+	narration := len(chapters)
+	for i, c := range candidates {
+		if len(chapters) == 0 {
+			c.Start = 0
+			chapters = append(chapters, c)
+			continue
+		}
+		gap := c.Start - chapters[len(chapters)-1].Start
+		if gap < minChapterSeconds || (!titled && len(chapters) > narration && gap < every) {
+			continue
+		}
+		if titled && i+1 < len(candidates) && candidates[i+1].Start-c.Start < minChapterSeconds {
+			continue
+		}
+		chapters = append(chapters, c)
+	}
+	// The last chapter runs to the end of the narration, and must be long enough too
+	if n := len(chapters); n > 1 && end-chapters[n-1].Start < minChapterSeconds {
+		chapters = chapters[:n-1]
+	}
+	return chapters
+}
+
+// segmentHeading is the title a segment gives its chapter: its title card's, its section's,
+// its slide's or its on-screen text, on one line
+func segmentHeading(seg models.VideoSegment) string {
+	for _, title := range []string{seg.TitleCard, seg.SectionTitle, seg.SlideTitle, seg.OnScreenText} {
+		if strings.TrimSpace(title) != "" {
+			return strings.Join(strings.Fields(title), " ")
+		}
+	}
+	return ""
+}
+
+// narrationTitle names a chapter after the first sentence of its narration, cut at a word
+func narrationTitle(tp *TextProcessor, text string) string {
+	text = strings.Join(strings.Fields(StripScriptMarkers(StripSSML(text))), " ")
+	if sentences := tp.splitIntoSentences(text); len(sentences) > 0 {
+		text = sentences[0]
+	}
+	text = strings.TrimRight(text, ".!?。！？ ")
+	if utils.DisplayWidth(text) <= maxChapterTitle {
+		return text
+	}
+	cut := utils.LastBreak(text, utils.FitPrefix(text, maxChapterTitle-1, utils.RuneWidth))
+	if cut <= 0 {
+		cut = utils.FitPrefix(text, maxChapterTitle-1, utils.RuneWidth)
+	}
+	return strings.TrimRight(text[:cut], " ,;:，、") + "…"
+}
+
+// chapterTimestamp formats seconds as YouTube expects: "01:23", or "1:02:03" past an hour
+func chapterTimestamp(seconds float64) string {
+	d := int(seconds)
+	if h := d / 3600; h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, (d%3600)/60, d%60)
+	}
+	return fmt.Sprintf("%02d:%02d", d/60, d%60)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"aituber/models"
+	"reflect"
+	"testing"
+)
+
+func TestBuildChapters(t *testing.T) {
+	tp := NewTextProcessor(4500, 5.5)
+
+	titled := []models.VideoSegment{
+		{Text: "Xin chào.", SlideTitle: "Giới thiệu"},
+		{Text: "Phần một nói về lịch sử."},
+		{Text: "Phần hai.", SlideTitle: "Lịch sử"},
+		{Text: "Còn nữa.", SlideTitle: "Lịch sử"},
+		{Text: "Một cảnh ngắn.", OnScreenText: "Quá ngắn"},
+		{Text: "Kết thúc.", SlideTitle: "Tổng kết"},
+	}
+	// The intro is too short for a chapter and "Quá ngắn" gives way to the heading 5s after it
+	got := buildChapters(tp, titled, []float64{6, 14, 30, 40, 75, 80}, 120, 6)
+	want := []chapter{{0, "Giới thiệu"}, {30, "Lịch sử"}, {80, "Tổng kết"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("titled segments:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Untitled narration is divided evenly and named after its first sentence; a long intro is a chapter
+	var plain []models.VideoSegment
+	var starts []float64
+	for i := 0; i < 12; i++ {
+		plain = append(plain, models.VideoSegment{Text: "Câu số một. Câu thứ hai."})
+		starts = append(starts, 12+float64(i)*10)
+	}
+	got = buildChapters(tp, plain, starts, 132, 12)
+	if len(got) != 7 || got[0] != (chapter{0, "Intro"}) || got[1] != (chapter{12, "Câu số một"}) || got[2].Start != 32 {
+		t.Errorf("untitled segments: got %+v", got)
+	}
+
+	// The last chapter is dropped when it would last less than ten seconds
+	got = buildChapters(tp, titled[:3], []float64{0, 10, 20}, 25, 0)
+	if len(got) != 1 {
+		t.Errorf("expected a short last chapter merged, got %+v", got)
+	}
+}
+
+func TestNarrationTitle(t *testing.T) {
+	tp := NewTextProcessor(4500, 5.5)
+	long := "Trong phần này chúng ta sẽ tìm hiểu về lịch sử hình thành và phát triển của thành phố"
+	if got := narrationTitle(tp, "[pause 1s] "+long+". Sau đó."); got != "Trong phần này chúng ta sẽ tìm hiểu về lịch sử…" {
+		t.Errorf("got %q", got)
+	}
+	if got := chapterTimestamp(83.9); got != "01:23" {
+		t.Errorf("got %q", got)
+	}
+	if got := chapterTimestamp(3723); got != "1:02:03" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// LUTDir holds the .cube LUTs GenerateRequest.ColorGrade can name
+var LUTDir = filepath.Join(staticVideoDir, "luts")
+
+// lutNamePattern keeps LUT names to plain file names, which need no escaping in a filter graph
+var lutNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._ -]*\.cube$`)
+
+// Safe ranges of the color grade adjustments
+const (
+	minGradeContrast   = 0.5
+	maxGradeContrast   = 2.0
+	maxGradeSaturation = 3.0
+	maxGradeWarmth     = 1.0
+)
+
+// ResolveLUT returns the file of LUT name under static/luts
+func ResolveLUT(name string) (string, error) {
+	if !lutNamePattern.MatchString(name) {
+		return "", fmt.Errorf("color_grade lut %q must be a .cube file name", name)
+	}
+	path := filepath.Join(LUTDir, name)
+	if !utils.FileExists(path) {
+		return "", fmt.Errorf("color_grade lut %q not found in %s", name, LUTDir)
+	}
+	return path, nil
+}
+
+// ValidateColorGrade checks the LUT and adjustment ranges of grade
+func ValidateColorGrade(grade models.ColorGrade) error {
+	if grade.LUT != "" {
+		if _, err := ResolveLUT(grade.LUT); err != nil {
+			return err
+		}
+	}
+	if c := grade.Contrast; c != nil && (*c < minGradeContrast || *c > maxGradeContrast) {
+		return fmt.Errorf("color_grade contrast must be between %.1f and %.1f (got %g)", minGradeContrast, maxGradeContrast, *c)
+	}
+	if s := grade.Saturation; s != nil && (*s < 0 || *s > maxGradeSaturation) {
+		return fmt.Errorf("color_grade saturation must be between 0 and %.1f (got %g)", maxGradeSaturation, *s)
+	}
+	if t := grade.Temperature; t < -maxGradeWarmth || t > maxGradeWarmth {
+		return fmt.Errorf("color_grade temperature must be between -1 and 1 (got %g)", t)
+	}
+	return nil
+}
+
+// colorGrade is the utils.ColorGrade of grade; ok is false when it changes nothing
+func colorGrade(grade models.ColorGrade) (g utils.ColorGrade, ok bool, err error) {
+	g = utils.ColorGrade{Contrast: 1, Saturation: 1, Temperature: grade.Temperature}
+	if grade.LUT != "" {
+		if g.LUTPath, err = ResolveLUT(grade.LUT); err != nil {
+			return g, false, err
+		}
+	}
+	if grade.Contrast != nil {
+		g.Contrast = *grade.Contrast
+	}
+	if grade.Saturation != nil {
+		g.Saturation = *grade.Saturation
+	}
+	return g, len(g.Filter().Filters) > 0, nil
+}
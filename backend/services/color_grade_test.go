@@ -0,0 +1,45 @@
+package services
+
+import (
+	"aituber/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateColorGrade(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "teal_orange.cube"), []byte("LUT_3D_SIZE 2\n"), 0644)
+	defer func(old string) { LUTDir = old }(LUTDir)
+	LUTDir = dir
+
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name    string
+		grade   models.ColorGrade
+		wantErr bool
+	}{
+		{"None", models.ColorGrade{}, false},
+		{"LUT and adjustments", models.ColorGrade{LUT: "teal_orange.cube", Contrast: f(1.1), Saturation: f(0), Temperature: 0.3}, false},
+		{"Missing LUT", models.ColorGrade{LUT: "missing.cube"}, true},
+		{"Not a cube file", models.ColorGrade{LUT: "../secrets.txt"}, true},
+		{"Contrast too high", models.ColorGrade{Contrast: f(3)}, true},
+		{"Negative saturation", models.ColorGrade{Saturation: f(-0.5)}, true},
+		{"Temperature out of range", models.ColorGrade{Temperature: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateColorGrade(tt.grade); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateColorGrade() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, ok, _ := colorGrade(models.ColorGrade{Contrast: f(1), Saturation: f(1)}); ok {
+		t.Error("Expected a neutral grade to be skipped")
+	}
+	g, ok, err := colorGrade(models.ColorGrade{LUT: "teal_orange.cube"})
+	if !ok || err != nil || g.LUTPath != filepath.Join(dir, "teal_orange.cube") || g.Contrast != 1 || g.Saturation != 1 {
+		t.Errorf("colorGrade() = %+v, %v, %v", g, ok, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 // ComposerService combines audio and video into final output
 type ComposerService struct {
 	videoBitrate string
+	encodeChunks int
 }
 
 // NewComposerService creates a new composer service
@@ -17,17 +18,52 @@ func NewComposerService(videoBitrate string) *ComposerService {
 	}
 }
 
-// ComposeVideoWithAudio combines video and audio tracks
-func (cs *ComposerService) ComposeVideoWithAudio(videoPath, audioPath, outputPath string) error {
+// ComposeVideoWithAudio combines video and audio tracks, plus the SRT at subtitlePath as a soft
+// subtitle track unless it is empty
+func (cs *ComposerService) ComposeVideoWithAudio(videoPath, audioPath, subtitlePath, outputPath string) error {
 	if videoPath == "" || audioPath == "" {
 		return fmt.Errorf("video and audio paths are required")
 	}
 
 	// Use FFmpeg utility to combine
-	err := utils.CombineAudioVideo(videoPath, audioPath, outputPath)
+	err := utils.CombineAudioVideo(videoPath, audioPath, subtitlePath, outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to compose video: %w", err)
 	}
 
 	return nil
 }
+
+// ComposeWithPresenter is ComposeVideoWithAudio with presenter overlaid on the video
+func (cs *ComposerService) ComposeWithPresenter(videoPath, audioPath, subtitlePath, outputPath string, presenter utils.Presenter) error {
+	if videoPath == "" || audioPath == "" {
+		return fmt.Errorf("video and audio paths are required")
+	}
+	if err := utils.CombineAudioVideoWithPresenter(videoPath, audioPath, subtitlePath, outputPath, presenter); err != nil {
+		return fmt.Errorf("failed to compose video with presenter: %w", err)
+	}
+	return nil
+}
+
+// SetEncodeChunks splits the video of BurnSubtitles' and EncodeOutput's encodes into up to n
+// parts encoded in parallel (FFMPEG_ENCODE_CHUNKS); below 2 each video is encoded in one run
+func (cs *ComposerService) SetEncodeChunks(n int) {
+	cs.encodeChunks = n
+}
+
+// BurnSubtitles re-encodes the composed MP4 at videoPath with the ASS files at assPaths drawn
+// in, later ones over earlier ones
+func (cs *ComposerService) BurnSubtitles(videoPath, outputPath string, assPaths ...string) error {
+	if err := utils.BurnASSChunked(videoPath, outputPath, cs.encodeChunks, assPaths...); err != nil {
+		return fmt.Errorf("failed to burn subtitles: %w", err)
+	}
+	return nil
+}
+
+// EncodeOutput converts the composed H.264 MP4 at videoPath into format
+func (cs *ComposerService) EncodeOutput(videoPath, outputPath string, format utils.OutputFormat) error {
+	if err := utils.TranscodeVideoChunked(videoPath, outputPath, format, cs.encodeChunks); err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", format.Container, format.Codec, err)
+	}
+	return nil
+}
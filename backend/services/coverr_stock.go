@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+)
+
+// coverrStock is the Coverr video library: fewer clips than Pexels, but all of them curated
+type coverrStock struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// CoverrVideoResponse represents Coverr video search response
+type CoverrVideoResponse struct {
+	Hits []struct {
+		ID         string   `json:"id"`
+		Title      string   `json:"title"`
+		Tags       []string `json:"tags"`
+		Duration   float64  `json:"duration"`
+		IsVertical bool     `json:"is_vertical"`
+		URLs       struct {
+			MP4         string `json:"mp4"`
+			MP4Download string `json:"mp4_download"`
+		} `json:"urls"`
+	} `json:"hits"`
+}
+
+// SearchVideos implements StockProvider, keeping Coverr's relevance order
+func (c *coverrStock) SearchVideos(ctx context.Context, keywords string, page, perPage int, orientation string) ([]StockClip, error) {
+	params := url.Values{}
+	params.Add("query", keywords)
+	params.Add("page", fmt.Sprintf("%d", page-1)) // Coverr pages start at 0
+	params.Add("page_size", fmt.Sprintf("%d", perPage))
+	params.Add("urls", "true")
+
+	var result CoverrVideoResponse
+	if err := getStockJSON(ctx, c.httpClient, "https://api.coverr.co/videos?"+params.Encode(), "Bearer "+c.apiKey, &result); err != nil {
+		return nil, fmt.Errorf("coverr search failed: %w", err)
+	}
+
+	var clips []StockClip
+	for _, hit := range result.Hits {
+		if hit.IsVertical != (orientation == "portrait") {
+			continue
+		}
+		duration := int(math.Round(hit.Duration))
+		if duration < 3 || duration > 60 {
+			continue
+		}
+		link := hit.URLs.MP4Download
+		if link == "" {
+			link = hit.URLs.MP4
+		}
+		if link == "" {
+			continue
+		}
+		clips = append(clips, StockClip{ID: "coverr:" + hit.ID, Link: link, Duration: duration, Source: StockProviderCoverr, Tags: append([]string{hit.Title}, hit.Tags...)})
+	}
+	return clips, nil
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// NATS subjects shared by the API front and render workers
+const (
+	DispatchSubject    = "aituber.jobs.dispatch"
+	WorkerEventSubject = "aituber.jobs.events"
+	CancelSubject      = "aituber.jobs.cancel"
+	renderWorkerQueue  = "render-workers"
+)
+
+// Dispatch timing: each attempt waits dispatchReplyTimeout for a worker to answer;
+// busy or missing workers are retried every dispatchRetryDelay until the caller's wait expires.
+const (
+	dispatchReplyTimeout = 5 * time.Second
+	dispatchRetryDelay   = 3 * time.Second
+)
+
+// ---------- API side ----------
+
+// QueueWorkflow implements IVideoWorkflow by handing jobs to render workers over NATS.
+// Job state stays in the API's JobManager; workers report back via StartWorkerEventConsumer.
+type QueueWorkflow struct {
+	nc           *utils.NATSConn
+	jobManager   IJobManager
+	dispatchWait time.Duration
+}
+
+// NewQueueWorkflow creates a workflow that dispatches to workers, waiting up to dispatchWait for a free one
+func NewQueueWorkflow(nc *utils.NATSConn, jobManager IJobManager, dispatchWait time.Duration) *QueueWorkflow {
+	return &QueueWorkflow{nc: nc, jobManager: jobManager, dispatchWait: dispatchWait}
+}
+
+// StartGeneration dispatches a generate job to the worker pool
+func (q *QueueWorkflow) StartGeneration(jobID string, req models.GenerateRequest) {
+	q.dispatch(models.WorkerDispatch{
+		Kind:        "generate",
+		JobID:       jobID,
+		Platform:    req.Platform,
+		ContentName: req.ContentName,
+		Request:     req,
+	})
+}
+
+// StartRerender dispatches a re-render; the source assets must be on storage shared with the workers
+func (q *QueueWorkflow) StartRerender(jobID string, source models.JobStatus, req models.GenerateRequest) {
+	q.dispatch(models.WorkerDispatch{
+		Kind:         "rerender",
+		JobID:        jobID,
+		Platform:     req.Platform,
+		ContentName:  req.ContentName,
+		Request:      req,
+		SourceJobID:  source.JobID,
+		SourceAssets: source.Assets,
+	})
+}
+
+// ContinueAfterReview dispatches the composition of a reviewed job; its assets must be on
+// storage shared with the workers
+func (q *QueueWorkflow) ContinueAfterReview(jobID string, job models.JobStatus) {
+	q.dispatch(models.WorkerDispatch{
+		Kind:         "continue",
+		JobID:        jobID,
+		Platform:     job.Request.Platform,
+		ContentName:  job.Request.ContentName,
+		Request:      job.Request,
+		SourceAssets: job.Assets,
+		Subtitles:    job.Subtitles,
+	})
+}
+
+// dispatch retries until a worker accepts the job or dispatchWait runs out, then fails the job
+func (q *QueueWorkflow) dispatch(d models.WorkerDispatch) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		q.jobManager.MarkFailed(d.JobID, fmt.Errorf("failed to encode dispatch: %w", err))
+		return
+	}
+
+	q.jobManager.UpdateProgress(d.JobID, "Waiting for render worker", 1)
+	deadline := time.Now().Add(q.dispatchWait)
+	for {
+		reply, err := q.nc.Request(DispatchSubject, data, dispatchReplyTimeout)
+		if err == nil {
+			var r models.WorkerDispatchReply
+			if err = json.Unmarshal(reply, &r); err == nil {
+				if r.Accepted {
+					log.Printf("[Dispatch] Job %s accepted by worker %s", d.JobID, r.WorkerID)
+					q.jobManager.LogEvent(d.JobID, fmt.Sprintf("Assigned to render worker %s", r.WorkerID))
+					return
+				}
+				err = fmt.Errorf("worker %s declined: %s", r.WorkerID, r.Reason)
+			}
+		}
+
+		if !time.Now().Add(dispatchRetryDelay).Before(deadline) {
+			log.Printf("[Dispatch] Job %s: giving up: %v", d.JobID, err)
+			q.jobManager.MarkFailed(d.JobID, fmt.Errorf("no render workers available: %w", err))
+			return
+		}
+		time.Sleep(dispatchRetryDelay)
+	}
+}
+
+// PublishCancel tells the render workers to stop the encodes of a cancelled job. Every worker
+// receives it; only the one running the job acts on it.
+func PublishCancel(nc *utils.NATSConn, jobID string) error {
+	data, err := json.Marshal(models.WorkerCancel{JobID: jobID})
+	if err != nil {
+		return err
+	}
+	return nc.Publish(CancelSubject, data)
+}
+
+// StartWorkerEventConsumer applies worker events to the API's job manager, so status polling,
+// WebSocket streams, ETA history and webhooks behave exactly as in single-process mode.
+func StartWorkerEventConsumer(nc *utils.NATSConn, jm IJobManager) error {
+	_, err := nc.Subscribe(WorkerEventSubject, func(msg *utils.NATSMsg) {
+		var ev models.WorkerEvent
+		if err := json.Unmarshal(msg.Data, &ev); err != nil {
+			log.Printf("[Dispatch] Dropping malformed worker event: %v", err)
+			return
+		}
+		applyWorkerEvent(jm, ev)
+	})
+	return err
+}
+
+func applyWorkerEvent(jm IJobManager, ev models.WorkerEvent) {
+	switch ev.Type {
+	case "progress":
+		jm.UpdateProgress(ev.JobID, ev.Step, ev.Progress)
+	case "log":
+		jm.LogEvent(ev.JobID, ev.Message)
+	case "sync":
+		jm.UpdateJob(ev.JobID, func(j *models.JobStatus) {
+			if ev.ScriptLength > 0 {
+				j.ScriptLength = ev.ScriptLength
+			}
+			if ev.Assets != nil {
+				j.Assets = ev.Assets
+			}
+			if ev.StoredURLs != nil {
+				j.StoredURLs = ev.StoredURLs
+			}
+		})
+	case "storyboard":
+		jm.MarkAwaitingApproval(ev.JobID, ev.Storyboard)
+	case "review":
+		jm.MarkAwaitingReview(ev.JobID, ev.Subtitles)
+	case "failed":
+		jm.MarkFailed(ev.JobID, errors.New(ev.Message))
+	case "completed":
+		jm.MarkCompleted(ev.JobID, ev.VideoPath, ev.SavedPath)
+	default:
+		log.Printf("[Dispatch] Job %s: unknown worker event type %q", ev.JobID, ev.Type)
+	}
+}
+
+// ---------- Worker side ----------
+
+// RemoteJobManager is the worker's job manager: it keeps a local copy of each running job
+// (the pipeline reads nothing else) and publishes every change to the API.
+type RemoteJobManager struct {
+	*JobManager
+	nc       *utils.NATSConn
+	workerID string
+}
+
+// NewRemoteJobManager creates a job manager that mirrors updates to WorkerEventSubject
+func NewRemoteJobManager(nc *utils.NATSConn, workerID string) *RemoteJobManager {
+	return &RemoteJobManager{JobManager: NewJobManager(), nc: nc, workerID: workerID}
+}
+
+// UpdateProgress records progress locally and forwards it
+func (r *RemoteJobManager) UpdateProgress(jobID string, step string, progress int) error {
+	err := r.JobManager.UpdateProgress(jobID, step, progress)
+	r.emit(models.WorkerEvent{JobID: jobID, Type: "progress", Step: step, Progress: progress})
+	return err
+}
+
+// LogEvent forwards a pipeline log line
+func (r *RemoteJobManager) LogEvent(jobID, message string) {
+	r.JobManager.LogEvent(jobID, message)
+	r.emit(models.WorkerEvent{JobID: jobID, Type: "log", Message: message})
+}
+
+// UpdateJob applies fn locally and forwards the fields the pipeline sets (script length, render
+// assets, stored file URLs)
+func (r *RemoteJobManager) UpdateJob(jobID string, fn func(*models.JobStatus)) error {
+	var ev models.WorkerEvent
+	err := r.JobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		fn(j)
+		ev = models.WorkerEvent{JobID: jobID, Type: "sync", ScriptLength: j.ScriptLength, Assets: j.Assets, StoredURLs: j.StoredURLs}
+	})
+	if err == nil {
+		r.emit(ev)
+	}
+	return err
+}
+
+// MarkFailed forwards the failure and drops the local copy
+func (r *RemoteJobManager) MarkFailed(jobID string, err error) error {
+	markErr := r.JobManager.MarkFailed(jobID, err)
+	r.forget(jobID)
+	r.emit(models.WorkerEvent{JobID: jobID, Type: "failed", Message: err.Error()})
+	return markErr
+}
+
+// MarkCompleted forwards the result paths and drops the local copy
+func (r *RemoteJobManager) MarkCompleted(jobID, videoPath, savedPath string) error {
+	err := r.JobManager.MarkCompleted(jobID, videoPath, savedPath)
+	r.forget(jobID)
+	r.emit(models.WorkerEvent{JobID: jobID, Type: "completed", VideoPath: videoPath, SavedPath: savedPath})
+	return err
+}
+
+// MarkAwaitingApproval forwards the planned storyboard and drops the local copy: approval
+// dispatches the job again
+func (r *RemoteJobManager) MarkAwaitingApproval(jobID string, storyboard *models.Storyboard) error {
+	err := r.JobManager.MarkAwaitingApproval(jobID, storyboard)
+	r.forget(jobID)
+	r.emit(models.WorkerEvent{JobID: jobID, Type: "storyboard", Storyboard: storyboard})
+	return err
+}
+
+// MarkAwaitingReview forwards the subtitles to review and drops the local copy: continuing
+// dispatches the job again
+func (r *RemoteJobManager) MarkAwaitingReview(jobID string, subtitles []models.SubtitleCue) error {
+	err := r.JobManager.MarkAwaitingReview(jobID, subtitles)
+	r.forget(jobID)
+	r.emit(models.WorkerEvent{JobID: jobID, Type: "review", Subtitles: subtitles})
+	return err
+}
+
+// Running returns the number of jobs currently rendering on this worker
+func (r *RemoteJobManager) Running() int {
+	r.jobsMux.RLock()
+	defer r.jobsMux.RUnlock()
+	return len(r.jobs)
+}
+
+func (r *RemoteJobManager) forget(jobID string) {
+	r.jobsMux.Lock()
+	delete(r.jobs, jobID)
+	r.jobsMux.Unlock()
+}
+
+func (r *RemoteJobManager) emit(ev models.WorkerEvent) {
+	ev.WorkerID = r.workerID
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("[Worker] Job %s: failed to encode %s event: %v", ev.JobID, ev.Type, err)
+		return
+	}
+	if err := r.nc.Publish(WorkerEventSubject, data); err != nil {
+		log.Printf("[Worker] Job %s: failed to publish %s event: %v", ev.JobID, ev.Type, err)
+	}
+}
+
+// RenderWorker takes jobs off the dispatch queue and runs them through the local pipeline
+type RenderWorker struct {
+	nc         *utils.NATSConn
+	jobManager *RemoteJobManager
+	workflow   IVideoWorkflow
+	id         string
+	tempDir    string // where the pipeline writes each job's folder
+	slots      chan struct{}
+}
+
+// NewRenderWorker creates a worker running at most concurrency jobs at once, with their temp
+// folders under tempDir
+func NewRenderWorker(nc *utils.NATSConn, jobManager *RemoteJobManager, workflow IVideoWorkflow, workerID, tempDir string, concurrency int) *RenderWorker {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &RenderWorker{
+		nc:         nc,
+		jobManager: jobManager,
+		workflow:   workflow,
+		id:         workerID,
+		tempDir:    tempDir,
+		slots:      make(chan struct{}, concurrency),
+	}
+}
+
+// DefaultWorkerID identifies this process in dispatch replies and events
+func DefaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Start joins the render worker queue group and listens for cancellations
+func (w *RenderWorker) Start() error {
+	_, err := w.nc.QueueSubscribe(DispatchSubject, renderWorkerQueue, w.handleDispatch)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", DispatchSubject, err)
+	}
+	if _, err := w.nc.Subscribe(CancelSubject, w.handleCancel); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", CancelSubject, err)
+	}
+	log.Printf("[Worker] %s listening on %s (max %d concurrent jobs)", w.id, DispatchSubject, cap(w.slots))
+	return nil
+}
+
+// handleDispatch replies immediately; a full worker declines so the API retries another member of the group
+func (w *RenderWorker) handleDispatch(msg *utils.NATSMsg) {
+	var d models.WorkerDispatch
+	if err := json.Unmarshal(msg.Data, &d); err != nil {
+		log.Printf("[Worker] Dropping malformed dispatch: %v", err)
+		w.reply(msg, models.WorkerDispatchReply{Reason: "malformed dispatch"})
+		return
+	}
+
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		w.reply(msg, models.WorkerDispatchReply{Reason: "busy"})
+		return
+	}
+
+	w.jobManager.CreateJob(d.JobID, d.Platform, d.ContentName)
+	w.jobManager.JobManager.UpdateJob(d.JobID, func(j *models.JobStatus) {
+		j.Request = d.Request
+	})
+	w.reply(msg, models.WorkerDispatchReply{Accepted: true})
+	log.Printf("[Worker] %s: starting %s job %s", w.id, d.Kind, d.JobID)
+
+	go func() {
+		defer func() { <-w.slots }()
+		// Nothing encodes in the job's folder any more once its pipeline returns
+		defer utils.ReleaseFFmpegCancel(filepath.Join(w.tempDir, d.JobID))
+		switch d.Kind {
+		case "rerender":
+			w.workflow.StartRerender(d.JobID, models.JobStatus{JobID: d.SourceJobID, Assets: d.SourceAssets}, d.Request)
+			return
+		case "continue":
+			w.workflow.ContinueAfterReview(d.JobID, models.JobStatus{JobID: d.JobID, Request: d.Request, Assets: d.SourceAssets, Subtitles: d.Subtitles})
+			return
+		}
+		w.workflow.StartGeneration(d.JobID, d.Request)
+	}()
+}
+
+// handleCancel kills the encodes of a cancelled job this worker is running; the pipeline then
+// fails, which the API ignores for a cancelled job
+func (w *RenderWorker) handleCancel(msg *utils.NATSMsg) {
+	var c models.WorkerCancel
+	if err := json.Unmarshal(msg.Data, &c); err != nil {
+		log.Printf("[Worker] Dropping malformed cancel: %v", err)
+		return
+	}
+	if _, running := w.jobManager.GetJob(c.JobID); !running {
+		return
+	}
+	killed := utils.CancelFFmpeg(filepath.Join(w.tempDir, c.JobID))
+	log.Printf("[Worker] %s: job %s cancelled, %d running encodes stopped", w.id, c.JobID, killed)
+}
+
+func (w *RenderWorker) reply(msg *utils.NATSMsg, r models.WorkerDispatchReply) {
+	if msg.Reply == "" {
+		return
+	}
+	r.WorkerID = w.id
+	data, _ := json.Marshal(r)
+	if err := w.nc.Publish(msg.Reply, data); err != nil {
+		log.Printf("[Worker] Failed to reply to dispatch: %v", err)
+	}
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// --- Minimal in-process NATS server (SUB/UNSUB/PUB, queue groups, wildcards) ---
+
+type fakeNATSSub struct {
+	client  *fakeNATSClient
+	subject string
+	queue   string
+	sid     string
+}
+
+type fakeNATSClient struct {
+	conn net.Conn
+	wmu  sync.Mutex
+}
+
+func (c *fakeNATSClient) send(s string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	io.WriteString(c.conn, s)
+}
+
+type fakeNATSServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	subs []*fakeNATSSub
+	next int
+}
+
+func startFakeNATS(t *testing.T) *fakeNATSServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNATSServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeNATSServer) URL() string {
+	return "nats://" + s.ln.Addr().String()
+}
+
+func (s *fakeNATSServer) serve(conn net.Conn) {
+	defer conn.Close()
+	c := &fakeNATSClient{conn: conn}
+	c.send("INFO {\"server_id\":\"fake\"}\r\n")
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		f := strings.Fields(strings.TrimSpace(line))
+		if len(f) == 0 {
+			continue
+		}
+		switch f[0] {
+		case "PING":
+			c.send("PONG\r\n")
+		case "SUB":
+			sub := &fakeNATSSub{client: c, subject: f[1], sid: f[len(f)-1]}
+			if len(f) == 4 {
+				sub.queue = f[2]
+			}
+			s.mu.Lock()
+			s.subs = append(s.subs, sub)
+			s.mu.Unlock()
+		case "UNSUB":
+			s.mu.Lock()
+			for i, sub := range s.subs {
+				if sub.client == c && sub.sid == f[1] {
+					s.subs = append(s.subs[:i], s.subs[i+1:]...)
+					break
+				}
+			}
+			s.mu.Unlock()
+		case "PUB":
+			n, _ := strconv.Atoi(f[len(f)-1])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			reply := ""
+			if len(f) == 4 {
+				reply = f[2]
+			}
+			s.route(f[1], reply, payload[:n])
+		}
+	}
+}
+
+func (s *fakeNATSServer) route(subject, reply string, data []byte) {
+	s.mu.Lock()
+	var targets []*fakeNATSSub
+	groups := make(map[string][]*fakeNATSSub)
+	for _, sub := range s.subs {
+		if !fakeSubjectMatch(sub.subject, subject) {
+			continue
+		}
+		if sub.queue == "" {
+			targets = append(targets, sub)
+		} else {
+			groups[sub.queue] = append(groups[sub.queue], sub)
+		}
+	}
+	for _, members := range groups {
+		s.next++
+		targets = append(targets, members[s.next%len(members)])
+	}
+	s.mu.Unlock()
+
+	for _, sub := range targets {
+		head := fmt.Sprintf("MSG %s %s %d\r\n", subject, sub.sid, len(data))
+		if reply != "" {
+			head = fmt.Sprintf("MSG %s %s %s %d\r\n", subject, sub.sid, reply, len(data))
+		}
+		sub.client.send(head + string(data) + "\r\n")
+	}
+}
+
+func fakeSubjectMatch(pattern, subject string) bool {
+	p, s := strings.Split(pattern, "."), strings.Split(subject, ".")
+	for i, tok := range p {
+		if tok == ">" {
+			return len(s) > i
+		}
+		if i >= len(s) || (tok != "*" && tok != s[i]) {
+			return false
+		}
+	}
+	return len(p) == len(s)
+}
+
+// --- Fake pipeline run by the worker ---
+
+type stubPipeline struct {
+	jm      IJobManager
+	release chan struct{} // if set, jobs block until closed
+}
+
+func (p *stubPipeline) StartGeneration(jobID string, req models.GenerateRequest) {
+	if p.release != nil {
+		<-p.release
+	}
+	p.jm.UpdateProgress(jobID, "Generating audio 1/2", 30)
+	p.jm.LogEvent(jobID, "1 audio chunks generated")
+	p.jm.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.ScriptLength = len(req.Script)
+		j.Assets = &models.RenderAssets{AudioPaths: []string{"/shared/a.mp3"}}
+	})
+	if req.SubtitleReview {
+		p.jm.MarkAwaitingReview(jobID, []models.SubtitleCue{{Start: 0, End: 2, Text: req.Script}})
+		return
+	}
+	p.jm.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.StoredURLs = map[string]string{jobID + ".mp4": "https://cdn.example.com/" + jobID + ".mp4"}
+	})
+	p.jm.MarkCompleted(jobID, "/shared/"+jobID+".mp4", "/out/"+jobID+".mp4")
+}
+
+// ContinueAfterReview "renders" the reviewed text into the video path
+func (p *stubPipeline) ContinueAfterReview(jobID string, job models.JobStatus) {
+	if job.Assets == nil || len(job.Subtitles) == 0 {
+		p.jm.MarkFailed(jobID, fmt.Errorf("job %s has no assets to continue from", jobID))
+		return
+	}
+	p.jm.MarkCompleted(jobID, "/shared/"+job.Subtitles[0].Text+".mp4", "")
+}
+
+func (p *stubPipeline) StartRerender(jobID string, source models.JobStatus, req models.GenerateRequest) {
+	if source.Assets == nil {
+		p.jm.MarkFailed(jobID, fmt.Errorf("source job %s has no reusable assets", source.JobID))
+		return
+	}
+	p.jm.MarkCompleted(jobID, source.Assets.AudioPaths[0], "")
+}
+
+// waitForStatus polls snapshots (GetJob returns the live pointer, which the consumer mutates)
+func waitForStatus(t *testing.T, jm *JobManager, jobID string, statuses ...string) models.JobStatus {
+	t.Helper()
+	var last models.JobStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, job := range jm.ListJobs() {
+			if job.JobID != jobID {
+				continue
+			}
+			last = job
+			for _, s := range statuses {
+				if job.Status == s {
+					return job
+				}
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s never reached %v (last status %q)", jobID, statuses, last.Status)
+	return last
+}
+
+func connectFake(t *testing.T, srv *fakeNATSServer, name string) *utils.NATSConn {
+	t.Helper()
+	nc, err := utils.ConnectNATS(srv.URL(), name)
+	if err != nil {
+		t.Fatalf("ConnectNATS: %v", err)
+	}
+	t.Cleanup(func() { nc.Close() })
+	return nc
+}
+
+func TestDistributed_DispatchAndEvents(t *testing.T) {
+	srv := startFakeNATS(t)
+	apiConn := connectFake(t, srv, "api")
+	workerConn := connectFake(t, srv, "worker")
+
+	apiJobs := NewJobManager()
+	finished := make(chan models.JobStatus, 1)
+	apiJobs.OnFinished(func(job models.JobStatus) { finished <- job })
+	if err := StartWorkerEventConsumer(apiConn, apiJobs); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := NewRemoteJobManager(workerConn, "w1")
+	worker := NewRenderWorker(workerConn, remote, &stubPipeline{jm: remote}, "w1", t.TempDir(), 2)
+	if err := worker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := workerConn.Flush(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	apiJobs.CreateJob("job-1", "tiktok", "demo")
+	events, unsubscribe := apiJobs.Subscribe("job-1")
+	defer unsubscribe()
+
+	queue := NewQueueWorkflow(apiConn, apiJobs, time.Second)
+	queue.StartGeneration("job-1", models.GenerateRequest{Platform: "tiktok", Script: "hello world"})
+
+	job := waitForStatus(t, apiJobs, "job-1", "completed", "failed")
+	if job.Status != "completed" {
+		t.Fatalf("Expected completed, got %s (%v)", job.Status, job.Error)
+	}
+	if job.VideoPath != "/shared/job-1.mp4" || job.SavedPath != "/out/job-1.mp4" {
+		t.Errorf("Unexpected result paths %q / %q", job.VideoPath, job.SavedPath)
+	}
+	if job.ScriptLength != len("hello world") || job.Assets == nil || len(job.Assets.AudioPaths) != 1 {
+		t.Errorf("Synced fields not applied: length=%d assets=%+v", job.ScriptLength, job.Assets)
+	}
+	if job.StoredURLs["job-1.mp4"] != "https://cdn.example.com/job-1.mp4" {
+		t.Errorf("Stored URLs not synced: %v", job.StoredURLs)
+	}
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Error("OnFinished listener (webhooks) did not fire on the API side")
+	}
+
+	sawWorkerProgress := false
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == "progress" && ev.Step == "Generating audio 1/2" {
+			sawWorkerProgress = true
+		}
+	}
+	if !sawWorkerProgress {
+		t.Error("Worker progress was not streamed to API subscribers")
+	}
+
+	// Re-render carries the source assets to the worker
+	apiJobs.CreateJob("job-2", "tiktok", "demo-rerender")
+	queue.StartRerender("job-2", job, models.GenerateRequest{Platform: "tiktok"})
+	job2 := waitForStatus(t, apiJobs, "job-2", "completed", "failed")
+	if job2.Status != "completed" || job2.VideoPath != "/shared/a.mp3" {
+		t.Errorf("Rerender: status %s path %q err %v", job2.Status, job2.VideoPath, job2.Error)
+	}
+
+	// A subtitle_review job pauses with its cues on the API side and continues on a worker
+	apiJobs.CreateJob("job-3", "tiktok", "demo-review")
+	queue.StartGeneration("job-3", models.GenerateRequest{Platform: "tiktok", Script: "helo", SubtitleReview: true})
+	job3 := waitForStatus(t, apiJobs, "job-3", "awaiting_review", "completed", "failed")
+	if job3.Status != "awaiting_review" || len(job3.Subtitles) != 1 || job3.Subtitles[0].Text != "helo" || job3.Assets == nil {
+		t.Fatalf("Review: status %s subtitles %+v assets %+v", job3.Status, job3.Subtitles, job3.Assets)
+	}
+	job3.Subtitles = []models.SubtitleCue{{Start: 0, End: 2, Text: "hello"}}
+	queue.ContinueAfterReview("job-3", job3)
+	job3 = waitForStatus(t, apiJobs, "job-3", "completed", "failed")
+	if job3.Status != "completed" || job3.VideoPath != "/shared/hello.mp4" {
+		t.Errorf("Continue: status %s path %q err %v", job3.Status, job3.VideoPath, job3.Error)
+	}
+
+	if n := remote.Running(); n != 0 {
+		t.Errorf("Worker should forget finished jobs, still tracking %d", n)
+	}
+}
+
+func TestDistributed_BusyWorkerDeclines(t *testing.T) {
+	srv := startFakeNATS(t)
+	apiConn := connectFake(t, srv, "api")
+	workerConn := connectFake(t, srv, "worker")
+
+	apiJobs := NewJobManager()
+	if err := StartWorkerEventConsumer(apiConn, apiJobs); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := NewRemoteJobManager(workerConn, "w1")
+	pipeline := &stubPipeline{jm: remote, release: make(chan struct{})}
+	worker := NewRenderWorker(workerConn, remote, pipeline, "w1", t.TempDir(), 1)
+	if err := worker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	workerConn.Flush(time.Second)
+
+	// No retry budget: a declined dispatch fails the job straight away
+	queue := NewQueueWorkflow(apiConn, apiJobs, 0)
+
+	apiJobs.CreateJob("busy-1", "tiktok", "a")
+	queue.StartGeneration("busy-1", models.GenerateRequest{Platform: "tiktok"})
+
+	apiJobs.CreateJob("busy-2", "tiktok", "b")
+	queue.StartGeneration("busy-2", models.GenerateRequest{Platform: "tiktok"})
+
+	job := waitForStatus(t, apiJobs, "busy-2", "failed")
+	if job.Error == nil || !strings.Contains(job.Error.Error(), "no render workers available") {
+		t.Errorf("Unexpected error: %v", job.Error)
+	}
+
+	close(pipeline.release)
+	if job := waitForStatus(t, apiJobs, "busy-1", "completed", "failed"); job.Status != "completed" {
+		t.Errorf("First job should still complete, got %s", job.Status)
+	}
+}
+
+// encodingPipeline "encodes" into its job's temp folder once released, failing the job on error
+type encodingPipeline struct {
+	stubPipeline
+	tempDir string
+	result  chan error
+}
+
+func (p *encodingPipeline) StartGeneration(jobID string, req models.GenerateRequest) {
+	<-p.release
+	err := utils.RunFFmpegCommand([]string{"-i", "in.mp4", "-y", filepath.Join(p.tempDir, jobID, "final.mp4")})
+	p.jm.MarkFailed(jobID, err)
+	p.result <- err
+}
+
+// jobDirCancelled reports whether ffmpeg runs in jobDir fail as cancelled
+func jobDirCancelled(jobDir string) bool {
+	return errors.Is(utils.RunFFmpegCommand([]string{"-i", "in.mp4", "-y", filepath.Join(jobDir, "probe.mp4")}), utils.ErrFFmpegCancelled)
+}
+
+func TestDistributed_CancelReachesWorker(t *testing.T) {
+	srv := startFakeNATS(t)
+	apiConn := connectFake(t, srv, "api")
+	workerConn := connectFake(t, srv, "worker")
+
+	apiJobs := NewJobManager()
+	if err := StartWorkerEventConsumer(apiConn, apiJobs); err != nil {
+		t.Fatal(err)
+	}
+
+	tempDir := t.TempDir()
+	remote := NewRemoteJobManager(workerConn, "w1")
+	pipeline := &encodingPipeline{stubPipeline: stubPipeline{jm: remote, release: make(chan struct{})}, tempDir: tempDir, result: make(chan error, 1)}
+	worker := NewRenderWorker(workerConn, remote, pipeline, "w1", tempDir, 1)
+	if err := worker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	workerConn.Flush(time.Second)
+
+	apiJobs.CreateJob("job-1", "tiktok", "demo")
+	NewQueueWorkflow(apiConn, apiJobs, time.Second).StartGeneration("job-1", models.GenerateRequest{Platform: "tiktok"})
+	if _, err := apiJobs.MarkCancelled("job-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PublishCancel(apiConn, "job-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	jobDir := filepath.Join(tempDir, "job-1")
+	deadline := time.Now().Add(5 * time.Second)
+	for !jobDirCancelled(jobDir) {
+		if time.Now().After(deadline) {
+			t.Fatal("The worker never cancelled the job's encodes")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(pipeline.release)
+	if err := <-pipeline.result; !errors.Is(err, utils.ErrFFmpegCancelled) {
+		t.Errorf("Expected the job's encode to be cancelled, got %v", err)
+	}
+	if job := waitForStatus(t, apiJobs, "job-1", "cancelled", "failed"); job.Status != "cancelled" {
+		t.Errorf("Expected the job to stay cancelled, got %s", job.Status)
+	}
+	for deadline = time.Now().Add(5 * time.Second); jobDirCancelled(jobDir); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the worker to forget the cancelled folder once the pipeline returned")
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+)
+
+const elevenLabsAPIBase = "https://api.elevenlabs.io"
+
+// elevenLabsPresetVoices maps friendly names of ElevenLabs premade voices to their IDs
+var elevenLabsPresetVoices = map[string]string{
+	"rachel": "21m00Tcm4TlvDq8nQR9k",
+	"domi":   "AZnzlk1XvdvUeBnXmlld",
+	"antoni": "ErXwobaYiN019PkySvjV",
+	"josh":   "TxGEqnHWrfWFTfGW9XjX",
+	"arnold": "VR6AewLTigWG4xSOukaG",
+	"adam":   "pNInz6obpgDQGcFmaJgB",
+}
+
+// elevenLabsTTS streams MP3 from ElevenLabs, rotating over the key pool
+type elevenLabsTTS struct {
+	pool       *utils.APIKeyPool
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewElevenLabsTTS creates the ElevenLabs provider for model (e.g. "eleven_multilingual_v2")
+func NewElevenLabsTTS(pool *utils.APIKeyPool, model string) TTSProvider {
+	return &elevenLabsTTS{pool: pool, model: model, baseURL: elevenLabsAPIBase, httpClient: newTTSHTTPClient()}
+}
+
+// Synthesize calls the streaming endpoint with the voice mapped from presets or FPT names
+func (e *elevenLabsTTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	voiceID := mapToElevenLabsVoice(voice)
+	return withAPIKey(ctx, e.pool, "ElevenLabs", func(apiKey string) ([]byte, int, error) {
+		return e.streamElevenLabsTTS(ctx, text, voiceID, speed, apiKey)
+	})
+}
+
+// streamElevenLabsTTS calls the streaming endpoint and returns the MP3.
+// It returns the HTTP status (0 on transport errors) so callers can decide whether to rotate keys.
+func (e *elevenLabsTTS) streamElevenLabsTTS(ctx context.Context, text, voiceID string, speed float64, apiKey string) ([]byte, int, error) {
+	endpoint := fmt.Sprintf("%s/v1/text-to-speech/%s/stream?output_format=mp3_44100_128", e.baseURL, voiceID)
+
+	payload := map[string]interface{}{
+		"text":     text,
+		"model_id": e.model,
+		"voice_settings": map[string]interface{}{
+			"stability":         0.5,
+			"similarity_boost":  0.75,
+			"style":             0.0,
+			"use_speaker_boost": true,
+			"speed":             elevenLabsSpeed(speed),
+		},
+	}
+
+	jsonPayload, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "audio/mpeg")
+	req.Header.Set("xi-api-key", apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("ElevenLabs API returned %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		// A broken stream is transient, so it is reported like a transport error
+		return nil, 0, fmt.Errorf("failed to download audio stream: %w", err)
+	}
+	return data, resp.StatusCode, nil
+}
+
+// elevenLabsSpeed maps our 0.5-2.0 speaking speed onto the 0.7-1.2 range ElevenLabs accepts
+func elevenLabsSpeed(speed float64) float64 {
+	if speed <= 0 {
+		return 1.0
+	}
+	return math.Max(0.7, math.Min(1.2, speed))
+}
+
+// mapToElevenLabsVoice maps preset names and FPT voices (by gender), or takes long ID
+func mapToElevenLabsVoice(voiceID string) string {
+	const (
+		elevenMaleID   = "ipTvfDXAg1zowfF1rv9w"
+		elevenFemaleID = "Si3s1VCb7dLbeqH57kiC"
+	)
+	if len(voiceID) >= 10 {
+		return voiceID
+	}
+	if id, ok := elevenLabsPresetVoices[strings.ToLower(voiceID)]; ok {
+		return id
+	}
+	if isFPTMaleVoice(voiceID) {
+		return elevenMaleID
+	}
+	return elevenFemaleID
+}
+
+// CloneVoice creates an instant voice clone from sample and returns its voice ID
+func (e *elevenLabsTTS) CloneVoice(ctx context.Context, name string, sample []byte) (string, error) {
+	data, err := withAPIKey(ctx, e.pool, "ElevenLabs", func(apiKey string) ([]byte, int, error) {
+		body, contentType, err := voiceSampleForm("files", name+audioFileExt(sample), sample, map[string]string{"name": name})
+		if err != nil {
+			return nil, 0, err
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", e.baseURL+"/v1/voices/add", body)
+		if err != nil {
+			return nil, 0, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("xi-api-key", apiKey)
+
+		resp, err := e.httpClient.Do(req)
+		if err != nil {
+			return nil, 0, fmt.Errorf("request failed: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, resp.StatusCode, fmt.Errorf("ElevenLabs API returned %d: %s", resp.StatusCode, readErrorBody(resp))
+		}
+		data, err := io.ReadAll(resp.Body)
+		return data, resp.StatusCode, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		VoiceID string `json:"voice_id"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil || result.VoiceID == "" {
+		return "", fmt.Errorf("ElevenLabs returned no voice_id: %s", data)
+	}
+	return result.VoiceID, nil
+}
+
+// ElevenLabsTTSWithTimestampsResponse represents ElevenLabs TTS API response with timestamps
+type ElevenLabsTTSWithTimestampsResponse struct {
+	Audio     []byte `json:"audio"`
+	Alignment struct {
+		Chars            []string `json:"chars"`
+		CharStartTimesMs []int    `json:"char_start_times_ms"`
+		CharEndTimesMs   []int    `json:"char_end_times_ms"`
+	} `json:"alignment"`
+}
+
+// callElevenLabsTTSWithTimestamps calls ElevenLabs API and returns audio + alignment
+func (e *elevenLabsTTS) callElevenLabsTTSWithTimestamps(text, voiceID string) ([]byte, ElevenLabsTTSWithTimestampsResponse_Alignment, error) {
+	// The endpoint for timestamps is slightly different and requires a streaming output format
+	apiKey, err := e.pool.GetRandomKey()
+	if err != nil {
+		return nil, ElevenLabsTTSWithTimestampsResponse_Alignment{}, fmt.Errorf("no available ElevenLabs API keys: %w", err)
+	}
+	url := fmt.Sprintf("%s/v1/text-to-speech/%s/stream/with-timestamps", e.baseURL, voiceID)
+
+	payload := map[string]interface{}{
+		"text":     text,
+		"model_id": e.model,
+		"voice_settings": map[string]interface{}{
+			"stability":        0.5,
+			"similarity_boost": 0.75,
+		},
+	}
+
+	jsonPayload, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, ElevenLabsTTSWithTimestampsResponse_Alignment{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("xi-api-key", apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, ElevenLabsTTSWithTimestampsResponse_Alignment{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, ElevenLabsTTSWithTimestampsResponse_Alignment{}, fmt.Errorf("ElevenLabs API returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	// The "with-timestamps" response is a JSON stream where each line/chunk contains audio and alignment.
+	// Since we are calling the non-streaming REST wrapper as a block, we need to assemble it.
+	// Actually, for REST it returns a combined JSON object.
+	var fullAudio []byte
+	var finalAlignment ElevenLabsTTSWithTimestampsResponse_Alignment
+
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var chunk struct {
+			AudioBase64 string                                        `json:"audio_base64"`
+			Alignment   ElevenLabsTTSWithTimestampsResponse_Alignment `json:"alignment"`
+		}
+		if err := decoder.Decode(&chunk); err != nil {
+			break
+		}
+
+		if chunk.AudioBase64 != "" {
+			audio, _ := base64.StdEncoding.DecodeString(chunk.AudioBase64)
+			fullAudio = append(fullAudio, audio...)
+		}
+
+		if len(chunk.Alignment.Chars) > 0 {
+			finalAlignment.Chars = append(finalAlignment.Chars, chunk.Alignment.Chars...)
+			finalAlignment.CharStartTimesMs = append(finalAlignment.CharStartTimesMs, chunk.Alignment.CharStartTimesMs...)
+			finalAlignment.CharEndTimesMs = append(finalAlignment.CharEndTimesMs, chunk.Alignment.CharEndTimesMs...)
+		}
+	}
+
+	return fullAudio, finalAlignment, nil
+}
+
+// ElevenLabsTTSWithTimestampsResponse_Alignment helper struct
+type ElevenLabsTTSWithTimestampsResponse_Alignment struct {
+	Chars            []string `json:"chars"`
+	CharStartTimesMs []int    `json:"char_start_times_ms"`
+	CharEndTimesMs   []int    `json:"char_end_times_ms"`
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Embedding models that rank footage by meaning, selected with EMBEDDINGS_PROVIDER
+const (
+	EmbedderOpenAI = "openai"
+	EmbedderOllama = "ollama"
+)
+
+// defaultEmbeddingModels are used when EMBEDDINGS_MODEL is empty
+var defaultEmbeddingModels = map[string]string{
+	EmbedderOpenAI: "text-embedding-3-small",
+	EmbedderOllama: "nomic-embed-text",
+}
+
+// maxCachedEmbeddings bounds the in-memory embedding cache; stock results repeat across
+// segments and re-renders, so most clips are embedded once
+const maxCachedEmbeddings = 8192
+
+// Embedder turns texts into vectors whose cosine similarity reflects how close they are in meaning
+type Embedder interface {
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// embeddingAPI is an Embedder over a vendor's HTTP API, rotating through its key pool
+type embeddingAPI struct {
+	chatLLM
+	name string
+	pool *utils.APIKeyPool
+	call func(ctx context.Context, texts []string, apiKey string) ([][]float64, int, error)
+}
+
+// NewEmbedder creates the named embedding model's client. model may be empty for the vendor
+// default and baseURL empty for OpenAI's public API; Ollama needs a URL but no pool.
+func NewEmbedder(name string, pool *utils.APIKeyPool, model, baseURL string) (Embedder, error) {
+	if model == "" {
+		model = defaultEmbeddingModels[name]
+	}
+	e := &embeddingAPI{
+		chatLLM: chatLLM{model: model, baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{Timeout: time.Minute}},
+		name:    name,
+		pool:    pool,
+	}
+	switch name {
+	case EmbedderOpenAI:
+		if e.baseURL == "" {
+			e.baseURL = openAIAPIBase
+		}
+		e.call = e.openAI
+	case EmbedderOllama:
+		if e.baseURL == "" {
+			return nil, fmt.Errorf("ollama requires a server URL")
+		}
+		e.pool = nil
+		e.call = e.ollama
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q", name)
+	}
+	return e, nil
+}
+
+// Embed implements Embedder
+func (e *embeddingAPI) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var vectors [][]float64
+	_, err := callWithKeyRotation(ctx, e.pool, e.name, 2, func(apiKey string) ([]byte, int, error) {
+		var status int
+		var err error
+		vectors, status, err = e.call(ctx, texts, apiKey)
+		return nil, status, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d texts", e.name, len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// openAI calls /v1/embeddings with every text in one request
+func (e *embeddingAPI) openAI(ctx context.Context, texts []string, apiKey string) ([][]float64, int, error) {
+	body, _ := json.Marshal(map[string]interface{}{"model": e.model, "input": texts})
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + apiKey}
+	if status, err := e.post(ctx, e.baseURL+"/v1/embeddings", headers, body, &out); err != nil {
+		return nil, status, fmt.Errorf("openai embeddings failed: %w", err)
+	}
+	vectors := make([][]float64, len(texts))
+	for _, d := range out.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	for _, v := range vectors {
+		if len(v) == 0 {
+			return nil, 0, fmt.Errorf("openai returned incomplete embeddings")
+		}
+	}
+	return vectors, 0, nil
+}
+
+// ollama calls a local server's /api/embed
+func (e *embeddingAPI) ollama(ctx context.Context, texts []string, _ string) ([][]float64, int, error) {
+	body, _ := json.Marshal(map[string]interface{}{"model": e.model, "input": texts})
+	var out struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if status, err := e.post(ctx, e.baseURL+"/api/embed", nil, body, &out); err != nil {
+		return nil, status, fmt.Errorf("ollama embeddings failed: %w", err)
+	}
+	return out.Embeddings, 0, nil
+}
+
+// cosineSimilarity is in [-1, 1]; 0 when either vector is empty or the lengths differ
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// SetEmbedder makes stock and local footage be ranked by how close its description is in
+// meaning to the segment, using e (registered under name, which keys the cache); nil restores
+// the keyword ranking
+func (sv *StockVideoService) SetEmbedder(name string, e Embedder) {
+	sv.embedMux.Lock()
+	defer sv.embedMux.Unlock()
+	sv.embedder = e
+	sv.embedderName = name
+	sv.embedCache = make(map[string][]float64)
+}
+
+// similarities scores each description against query. Empty descriptions score -1 so they
+// rank last. It returns nil, and the caller keeps its keyword order, when no embedder is set or
+// the embedding call fails.
+func (sv *StockVideoService) similarities(ctx context.Context, jobID, query string, descriptions []string) []float64 {
+	sv.embedMux.Lock()
+	e, name := sv.embedder, sv.embedderName
+	sv.embedMux.Unlock()
+	query = strings.TrimSpace(query)
+	if e == nil || query == "" || len(descriptions) == 0 {
+		return nil
+	}
+
+	texts := append([]string{query}, descriptions...)
+	vectors := make([][]float64, len(texts))
+	keys := make([]string, len(texts))
+	var missing []string
+	var missingAt []int
+	sv.embedMux.Lock()
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		hash := sha256.Sum256([]byte(name + "\x00" + text))
+		keys[i] = hex.EncodeToString(hash[:])
+		if v, ok := sv.embedCache[keys[i]]; ok {
+			vectors[i] = v
+			continue
+		}
+		missing = append(missing, text)
+		missingAt = append(missingAt, i)
+	}
+	sv.embedMux.Unlock()
+
+	if len(missing) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		embedded, err := e.Embed(ctx, missing)
+		if err != nil {
+			log.Printf("[Stock] %s embeddings failed, keeping keyword ranking: %v", name, err)
+			sv.events.Logf(jobID, "Footage embeddings failed (%v), ranking clips by keywords", err)
+			return nil
+		}
+		sv.embedMux.Lock()
+		for j, i := range missingAt {
+			vectors[i] = embedded[j]
+			if len(sv.embedCache) >= maxCachedEmbeddings {
+				for k := range sv.embedCache {
+					delete(sv.embedCache, k)
+					break
+				}
+			}
+			sv.embedCache[keys[i]] = embedded[j]
+		}
+		sv.embedMux.Unlock()
+	}
+
+	scores := make([]float64, len(descriptions))
+	for i, desc := range descriptions {
+		if desc == "" {
+			scores[i] = -1
+			continue
+		}
+		scores[i] = cosineSimilarity(vectors[0], vectors[i+1])
+	}
+	return scores
+}
+
+// rankBySimilarity reorders stock clips by how well their description matches query, keeping
+// the provider's order among equal scores and entirely when embeddings are unavailable
+func (sv *StockVideoService) rankBySimilarity(ctx context.Context, jobID, query string, clips []StockClip) []StockClip {
+	descriptions := make([]string, len(clips))
+	for i, clip := range clips {
+		descriptions[i] = stockClipDescription(clip)
+	}
+	scores := sv.similarities(ctx, jobID, query, descriptions)
+	if scores == nil {
+		return clips
+	}
+	order := make([]int, len(clips))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	ranked := make([]StockClip, len(clips))
+	for i, j := range order {
+		ranked[i] = clips[j]
+	}
+	return ranked
+}
+
+// segmentMatchText is what footage is matched against: the visual description and narration
+// when the segment has them, otherwise the search keywords
+func segmentMatchText(keywords, text, visualDesc string) string {
+	var parts []string
+	for _, s := range []string{visualDesc, text} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	if len(parts) == 0 {
+		return strings.TrimSpace(keywords)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// stockClipDescription joins a clip's title and tags into text to embed. Page URLs such as
+// Pexels' "https://www.pexels.com/video/aerial-view-of-a-beach-1234567/" contribute their slug.
+func stockClipDescription(clip StockClip) string {
+	var parts []string
+	for _, tag := range clip.Tags {
+		tag = strings.TrimSpace(tag)
+		if u, err := url.Parse(tag); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			words := strings.FieldsFunc(path.Base(strings.TrimRight(u.Path, "/")), func(r rune) bool {
+				return r == '-' || r == '_'
+			})
+			if n := len(words); n > 0 && strings.IndexFunc(words[n-1], func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+				words = words[:n-1]
+			}
+			tag = strings.Join(words, " ")
+		}
+		if tag != "" {
+			parts = append(parts, tag)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEmbedder_Vendors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			if r.Header.Get("Authorization") != "Bearer oa-key" || body.Model != "text-embedding-3-small" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			// Out of order on purpose: results are matched by index
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": []map[string]interface{}{
+				{"index": 1, "embedding": []float64{0, 1}},
+				{"index": 0, "embedding": []float64{1, 0}},
+			}})
+		case "/api/embed":
+			if body.Model != "nomic-embed-text" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float64{{1, 0}, {0, 1}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for name, key := range map[string]string{EmbedderOpenAI: "oa-key", EmbedderOllama: ""} {
+		e, err := NewEmbedder(name, utils.NewAPIKeyPool([]string{key}), "", srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		vectors, err := e.Embed(context.Background(), []string{"beach", "city"})
+		if err != nil || len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+			t.Errorf("%s: got %v, %v", name, vectors, err)
+		}
+	}
+
+	if _, err := NewEmbedder("word2vec", nil, "", ""); err == nil {
+		t.Error("expected an unknown provider to be rejected")
+	}
+	if _, err := NewEmbedder(EmbedderOllama, nil, "", ""); err == nil {
+		t.Error("expected ollama without a URL to be rejected")
+	}
+}
+
+// wordEmbedder embeds a text as counts of a few words, enough to tell beaches from cities
+type wordEmbedder struct {
+	calls atomic.Int32
+	texts atomic.Int32
+	err   error
+}
+
+func (w *wordEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	w.calls.Add(1)
+	w.texts.Add(int32(len(texts)))
+	if w.err != nil {
+		return nil, w.err
+	}
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		text = strings.ToLower(text)
+		vectors[i] = []float64{
+			float64(strings.Count(text, "ocean") + strings.Count(text, "beach") + strings.Count(text, "waves")),
+			float64(strings.Count(text, "city") + strings.Count(text, "traffic")),
+			0.1,
+		}
+	}
+	return vectors, nil
+}
+
+func TestStockVideoService_RankBySimilarity(t *testing.T) {
+	sv := NewStockVideoService("", t.TempDir(), "", nil, nil, "")
+	clips := []StockClip{
+		{ID: "pexels:1", Tags: []string{"https://www.pexels.com/video/busy-city-traffic-at-night-1234567/"}},
+		{ID: "pexels:2"},
+		{ID: "pixabay:3", Tags: []string{"waves, beach, sunset"}},
+	}
+
+	if got := sv.rankBySimilarity(context.Background(), "job", "ocean", clips); got[0].ID != "pexels:1" {
+		t.Errorf("expected provider order without an embedder, got %v", got)
+	}
+
+	e := &wordEmbedder{}
+	sv.SetEmbedder(EmbedderOllama, e)
+	got := sv.rankBySimilarity(context.Background(), "job", segmentMatchText("sea", "Sóng vỗ vào bờ", "Slow pan over ocean waves"), clips)
+	if got[0].ID != "pixabay:3" || got[1].ID != "pexels:1" || got[2].ID != "pexels:2" {
+		t.Errorf("expected the beach clip first and the undescribed clip last, got %v", got)
+	}
+
+	// A second segment reuses the cached clip vectors and embeds only its own query
+	sv.rankBySimilarity(context.Background(), "job", "city at night", clips)
+	if n := e.texts.Load(); n != 4 {
+		t.Errorf("expected 4 texts embedded (2 queries, 2 clips), got %d", n)
+	}
+
+	sv.SetEmbedder(EmbedderOpenAI, &wordEmbedder{err: errors.New("quota exceeded")})
+	if got := sv.rankBySimilarity(context.Background(), "job", "ocean", clips); got[0].ID != "pexels:1" {
+		t.Errorf("expected provider order when embedding fails, got %v", got)
+	}
+}
+
+func TestPickBrollClips_Similarity(t *testing.T) {
+	clips := []BrollClip{
+		{Path: "a.mp4", Duration: 4, Keywords: []string{"city", "night"}},
+		{Path: "b.mp4", Duration: 4, Keywords: []string{"coast"}},
+	}
+	// "coast" shares no keyword with the query but is closest in meaning
+	picked := pickBrollClips(clips, "beach", []float64{0.2, 0.9}, 3, &sync.Map{})
+	if len(picked) != 1 || picked[0].Path != "b.mp4" {
+		t.Errorf("expected the most similar clip, got %+v", picked)
+	}
+}
+
+func TestStockClipDescription(t *testing.T) {
+	clip := StockClip{Tags: []string{"https://www.pexels.com/video/aerial-view-of-a-beach-1234567/", "", "drone"}}
+	if got := stockClipDescription(clip); got != "aerial view of a beach, drone" {
+		t.Errorf("got %q", got)
+	}
+	if got := cosineSimilarity([]float64{1, 2}, []float64{2, 4}); got < 0.999 {
+		t.Errorf("expected parallel vectors to score 1, got %f", got)
+	}
+	if got := cosineSimilarity([]float64{1}, []float64{1, 0}); got != 0 {
+		t.Errorf("expected mismatched lengths to score 0, got %f", got)
+	}
+}
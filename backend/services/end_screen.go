@@ -0,0 +1,63 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Generated end screen limits; YouTube end screens last 5 to 20 seconds
+const (
+	MinEndScreenSeconds = 5.0
+	MaxEndScreenSeconds = 20.0
+	MaxEndScreenVideos  = 2
+)
+
+const defaultEndScreenText = "Thanks for watching! Subscribe for more."
+
+// endScreenSeconds is how long req's end screen lasts: its duration, else END_SCREEN_DURATION,
+// kept to what YouTube allows
+func (s *VideoWorkflowService) endScreenSeconds(req models.GenerateRequest) float64 {
+	duration := req.EndScreen.Duration
+	if duration <= 0 {
+		duration = s.cfg.EndScreenDuration
+	}
+	return math.Max(MinEndScreenSeconds, math.Min(duration, MaxEndScreenSeconds))
+}
+
+// renderEndScreen renders req's end screen as output/end_screen.mp4, on its own background or
+// else the request's
+func (s *VideoWorkflowService) renderEndScreen(tempDir string, req models.GenerateRequest, orientation string) (string, error) {
+	es := req.EndScreen
+	text := strings.TrimSpace(es.Text)
+	if text == "" {
+		text = defaultEndScreenText
+	}
+	videos := es.Videos
+	if videos <= 0 || videos > MaxEndScreenVideos {
+		videos = MaxEndScreenVideos
+	}
+
+	image, color := es.BackgroundImage, es.BackgroundColor
+	if image == "" && color == "" {
+		image, color = req.BackgroundImage, req.BackgroundColor
+	}
+	card := utils.StaticCard{Color: color, TitleFile: filepath.Join(tempDir, "output", "end_screen.txt")}
+	if image != "" {
+		card.Background = ResolveStaticVideo(image, "")
+	}
+	// The prompt is drawn at a fourteenth of the frame height
+	if err := os.WriteFile(card.TitleFile, []byte(wrapSlideText(text, cardLineRunes(orientation, 14))), 0644); err != nil {
+		return "", fmt.Errorf("failed to write end screen text: %w", err)
+	}
+
+	clipPath := filepath.Join(tempDir, "output", "end_screen.mp4")
+	if err := utils.RenderEndScreen(clipPath, s.endScreenSeconds(req), card, videos, orientation, s.frameRate(req)); err != nil {
+		return "", fmt.Errorf("end screen render failed: %w", err)
+	}
+	return clipPath, nil
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"testing"
+)
+
+func TestEndScreenSeconds(t *testing.T) {
+	s := &VideoWorkflowService{cfg: &config.Config{EndScreenDuration: 12}}
+	for _, tt := range []struct {
+		duration, want float64
+	}{
+		{0, 12},
+		{8, 8},
+		{2, MinEndScreenSeconds},
+		{45, MaxEndScreenSeconds},
+	} {
+		req := models.GenerateRequest{EndScreen: models.EndScreen{Enabled: true, Duration: tt.duration}}
+		if got := s.endScreenSeconds(req); got != tt.want {
+			t.Errorf("endScreenSeconds(%g) = %g, want %g", tt.duration, got, tt.want)
+		}
+	}
+}
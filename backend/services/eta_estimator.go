@@ -0,0 +1,123 @@
+package services
+
+import (
+	"aituber/models"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stepCounterPattern strips counters like "7/32" or "12" so "Fetching stock video for segment 3/10"
+// and "... 4/10" are timed as the same step
+var stepCounterPattern = regexp.MustCompile(`\s*\d+(/\d+)?`)
+
+// stepStats accumulates timings of one step across completed jobs
+type stepStats struct {
+	count            int
+	secondsPerChar   float64 // sum over jobs
+	startProgressSum float64 // sum over jobs
+}
+
+func (st *stepStats) avgSecondsPerChar() float64 { return st.secondsPerChar / float64(st.count) }
+func (st *stepStats) avgStartProgress() float64  { return st.startProgressSum / float64(st.count) }
+
+// ETAEstimator learns how long each pipeline step takes per script character from finished jobs
+// and uses those averages to estimate the time remaining for running jobs.
+type ETAEstimator struct {
+	mu          sync.Mutex
+	steps       map[string]*stepStats
+	jobs        int
+	totalLength int
+}
+
+// NewETAEstimator creates an estimator with empty history
+func NewETAEstimator() *ETAEstimator {
+	return &ETAEstimator{steps: make(map[string]*stepStats)}
+}
+
+// StepKey normalizes a human-readable step label into a stable key for timing history
+func StepKey(step string) string {
+	return strings.TrimSpace(stepCounterPattern.ReplaceAllString(step, ""))
+}
+
+// Record folds the step timings of a completed job into the history
+func (e *ETAEstimator) Record(job models.JobStatus) {
+	if job.ScriptLength <= 0 || len(job.StepTimings) == 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.jobs++
+	e.totalLength += job.ScriptLength
+
+	// A step can appear more than once (e.g. retries); sum them per job first
+	perJob := make(map[string]models.StepTiming)
+	for _, timing := range job.StepTimings {
+		agg, seen := perJob[timing.Step]
+		if !seen {
+			agg = models.StepTiming{Step: timing.Step, StartProgress: timing.StartProgress}
+		}
+		agg.Duration += timing.Duration
+		perJob[timing.Step] = agg
+	}
+
+	for key, timing := range perJob {
+		st, exists := e.steps[key]
+		if !exists {
+			st = &stepStats{}
+			e.steps[key] = st
+		}
+		st.count++
+		st.secondsPerChar += timing.Duration.Seconds() / float64(job.ScriptLength)
+		st.startProgressSum += float64(timing.StartProgress)
+	}
+}
+
+// Estimate returns the expected time remaining for a running job.
+// The remainder of the current step plus every step that historically starts later in the
+// pipeline is summed and scaled by the job's script length. Without history it falls back to
+// extrapolating elapsed time from the progress percentage.
+func (e *ETAEstimator) Estimate(job models.JobStatus, now time.Time) (time.Duration, bool) {
+	if job.Status != "processing" {
+		return 0, false
+	}
+
+	e.mu.Lock()
+	length := float64(job.ScriptLength)
+	if length <= 0 && e.jobs > 0 {
+		// Script not generated yet; assume an average-sized one
+		length = float64(e.totalLength) / float64(e.jobs)
+	}
+
+	var remaining float64
+	known := false
+	if length > 0 {
+		for key, st := range e.steps {
+			expected := st.avgSecondsPerChar() * length
+			switch {
+			case key == job.StepKey:
+				elapsed := now.Sub(job.StepStartedAt).Seconds()
+				remaining += math.Max(expected-elapsed, 0)
+				known = true
+			case st.avgStartProgress() > float64(job.StepStartProgress):
+				remaining += expected
+				known = true
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	if !known {
+		if job.Progress <= 0 || job.Progress >= 100 {
+			return 0, false
+		}
+		elapsed := now.Sub(job.CreatedAt).Seconds()
+		remaining = elapsed * float64(100-job.Progress) / float64(job.Progress)
+	}
+
+	return time.Duration(remaining * float64(time.Second)), true
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"aituber/models"
+	"testing"
+	"time"
+)
+
+func TestStepKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Fetching stock video for segment 3/10", "Fetching stock video for segment"},
+		{"Generating 32 audio chunks", "Generating audio chunks"},
+		{"Merging audio", "Merging audio"},
+	}
+	for _, tt := range tests {
+		if got := StepKey(tt.input); got != tt.expected {
+			t.Errorf("StepKey(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestETAEstimator_Estimate(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Falls back to progress extrapolation without history", func(t *testing.T) {
+		e := NewETAEstimator()
+		job := models.JobStatus{Status: "processing", Progress: 25, CreatedAt: now.Add(-time.Minute)}
+
+		eta, ok := e.Estimate(job, now)
+		if !ok {
+			t.Fatal("Expected an estimate")
+		}
+		if eta < 179*time.Second || eta > 181*time.Second {
+			t.Errorf("Expected ~3m, got %v", eta)
+		}
+	})
+
+	t.Run("Scales history by script length", func(t *testing.T) {
+		e := NewETAEstimator()
+		// Finished job: 1000 chars, audio 100s, video 200s
+		e.Record(models.JobStatus{
+			ScriptLength: 1000,
+			StepTimings: []models.StepTiming{
+				{Step: "Generating audio chunks", StartProgress: 20, Duration: 100 * time.Second},
+				{Step: "Fetching stock video for segment", StartProgress: 50, Duration: 200 * time.Second},
+			},
+		})
+
+		// Twice the script, 50s into audio: 150s audio left + 400s video
+		job := models.JobStatus{
+			Status:            "processing",
+			Progress:          20,
+			ScriptLength:      2000,
+			StepKey:           "Generating audio chunks",
+			StepStartedAt:     now.Add(-50 * time.Second),
+			StepStartProgress: 20,
+		}
+		eta, ok := e.Estimate(job, now)
+		if !ok {
+			t.Fatal("Expected an estimate")
+		}
+		if eta < 549*time.Second || eta > 551*time.Second {
+			t.Errorf("Expected ~550s, got %v", eta)
+		}
+	})
+
+	t.Run("No estimate for finished jobs", func(t *testing.T) {
+		e := NewETAEstimator()
+		if _, ok := e.Estimate(models.JobStatus{Status: "completed", Progress: 100}, now); ok {
+			t.Error("Expected no estimate for completed job")
+		}
+	})
+}
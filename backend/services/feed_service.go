@@ -0,0 +1,382 @@
+package services
+
+import (
+	"aituber/models"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// JobEnqueuer registers and starts a generate job, returning its job ID
+type JobEnqueuer func(req models.GenerateRequest) (string, error)
+
+// Feed polling limits
+const (
+	defaultFeedItemsPerPoll = 3
+	maxFeedRecentJobs       = 20
+	maxFeedBodyBytes        = 5 << 20
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// FeedItem is one normalized RSS item or Atom entry
+type FeedItem struct {
+	ID    string
+	Title string
+	Link  string
+	Text  string
+}
+
+// rssItem / atomEntry mirror the fields we read from each format
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type atomEntry struct {
+	Title   string `xml:"title"`
+	ID      string `xml:"id"`
+	Summary string `xml:"summary"`
+	Content string `xml:"content"`
+	Links   []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+}
+
+// feedDocument accepts both <rss><channel><item> and <feed><entry> layouts
+type feedDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// FeedService polls registered RSS/Atom feeds and enqueues a generate job per new item
+type FeedService struct {
+	mu   sync.Mutex
+	subs map[string]*models.FeedSubscription
+	seen map[string]map[string]struct{} // subscription ID -> item IDs already handled
+
+	enqueue       JobEnqueuer
+	httpClient    *http.Client
+	maxTextLength int
+}
+
+// NewFeedService creates a feed service; maxTextLength caps the script taken from each article
+func NewFeedService(enqueue JobEnqueuer, maxTextLength int) *FeedService {
+	return &FeedService{
+		subs:    make(map[string]*models.FeedSubscription),
+		seen:    make(map[string]map[string]struct{}),
+		enqueue: enqueue,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		maxTextLength: maxTextLength,
+	}
+}
+
+// Add registers a subscription after fetching the feed once to validate it.
+// Unless includeExisting is set, items currently in the feed are marked as seen so only new ones are rendered.
+func (fs *FeedService) Add(sub models.FeedSubscription, includeExisting bool) (models.FeedSubscription, error) {
+	items, err := fs.FetchItems(sub.URL)
+	if err != nil {
+		return models.FeedSubscription{}, err
+	}
+	if sub.MaxItemsPerPoll <= 0 {
+		sub.MaxItemsPerPoll = defaultFeedItemsPerPoll
+	}
+
+	seen := make(map[string]struct{})
+	if !includeExisting {
+		for _, item := range items {
+			seen[item.ID] = struct{}{}
+		}
+	}
+
+	fs.mu.Lock()
+	fs.subs[sub.ID] = &sub
+	fs.seen[sub.ID] = seen
+	fs.mu.Unlock()
+
+	log.Printf("[Feeds] Subscribed %s to %s (%d existing items)", sub.ID, sub.URL, len(items))
+
+	if includeExisting {
+		fs.processItems(sub.ID, items)
+	}
+	return fs.snapshot(sub.ID), nil
+}
+
+// List returns all subscriptions, oldest first
+func (fs *FeedService) List() []models.FeedSubscription {
+	fs.mu.Lock()
+	subs := make([]models.FeedSubscription, 0, len(fs.subs))
+	for _, sub := range fs.subs {
+		subs = append(subs, copySubscription(sub))
+	}
+	fs.mu.Unlock()
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+	})
+	return subs
+}
+
+// Remove deletes a subscription; jobs already enqueued keep running
+func (fs *FeedService) Remove(id string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, exists := fs.subs[id]; !exists {
+		return false
+	}
+	delete(fs.subs, id)
+	delete(fs.seen, id)
+	return true
+}
+
+// StartPolling polls every subscription on a ticker until stop is closed
+func (fs *FeedService) StartPolling(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		log.Printf("[Feeds] Polling disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			fs.PollAll()
+		}
+	}
+}
+
+// PollAll fetches every subscribed feed once and enqueues jobs for unseen items
+func (fs *FeedService) PollAll() {
+	fs.mu.Lock()
+	targets := make(map[string]string, len(fs.subs))
+	for id, sub := range fs.subs {
+		targets[id] = sub.URL
+	}
+	fs.mu.Unlock()
+
+	for id, feedURL := range targets {
+		items, err := fs.FetchItems(feedURL)
+		if err != nil {
+			log.Printf("[Feeds] %s: poll failed: %v", id, err)
+			fs.recordPoll(id, err)
+			continue
+		}
+		fs.processItems(id, items)
+	}
+}
+
+// processItems enqueues up to MaxItemsPerPoll unseen items, oldest first
+func (fs *FeedService) processItems(id string, items []FeedItem) {
+	fs.mu.Lock()
+	sub, exists := fs.subs[id]
+	if !exists {
+		fs.mu.Unlock()
+		return
+	}
+	seen := fs.seen[id]
+	var fresh []FeedItem
+	for _, item := range items {
+		if _, done := seen[item.ID]; !done {
+			fresh = append(fresh, item)
+		}
+	}
+	settings := *sub
+	fs.mu.Unlock()
+
+	// Feeds list newest first; render in publication order
+	for i, j := 0, len(fresh)-1; i < j; i, j = i+1, j-1 {
+		fresh[i], fresh[j] = fresh[j], fresh[i]
+	}
+	if len(fresh) > settings.MaxItemsPerPoll {
+		fresh = fresh[:settings.MaxItemsPerPoll]
+	}
+
+	for _, item := range fresh {
+		jobID, err := fs.enqueue(fs.buildRequest(settings, item))
+
+		fs.mu.Lock()
+		if sub, ok := fs.subs[id]; ok {
+			// Mark failed items seen too, so one broken article doesn't spawn a job every poll
+			fs.seen[id][item.ID] = struct{}{}
+			if err == nil {
+				sub.ItemsEnqueued++
+				sub.RecentJobIDs = append(sub.RecentJobIDs, jobID)
+				if len(sub.RecentJobIDs) > maxFeedRecentJobs {
+					sub.RecentJobIDs = sub.RecentJobIDs[len(sub.RecentJobIDs)-maxFeedRecentJobs:]
+				}
+			}
+		}
+		fs.mu.Unlock()
+
+		if err != nil {
+			log.Printf("[Feeds] %s: failed to enqueue %q: %v", id, item.Title, err)
+			continue
+		}
+		log.Printf("[Feeds] %s: enqueued job %s for %q", id, jobID, item.Title)
+	}
+
+	fs.recordPoll(id, nil)
+}
+
+// buildRequest turns a feed item into a generate request using the subscription's settings.
+// The article text becomes the script; items without body text fall back to AI generation from the title.
+func (fs *FeedService) buildRequest(sub models.FeedSubscription, item FeedItem) models.GenerateRequest {
+	script := item.Text
+	if fs.maxTextLength > 0 && utf8.RuneCountInString(script) > fs.maxTextLength {
+		script = string([]rune(script)[:fs.maxTextLength])
+	}
+	if script != "" && item.Title != "" {
+		script = item.Title + ". " + script
+	}
+
+	return models.GenerateRequest{
+		Platform:      sub.Platform,
+		Topic:         item.Title,
+		Voice:         sub.Voice,
+		SpeakingSpeed: sub.SpeakingSpeed,
+		Script:        script,
+		VideoStyle:    sub.VideoStyle,
+		TTSProvider:   sub.TTSProvider,
+		Title:         item.Title,
+		Metadata: map[string]string{
+			"feed_id":   sub.ID,
+			"feed_item": item.Link,
+		},
+	}
+}
+
+// recordPoll stores the outcome of the latest poll
+func (fs *FeedService) recordPoll(id string, err error) {
+	now := time.Now()
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if sub, ok := fs.subs[id]; ok {
+		sub.LastPolledAt = &now
+		sub.LastError = ""
+		if err != nil {
+			sub.LastError = err.Error()
+		}
+	}
+}
+
+func (fs *FeedService) snapshot(id string) models.FeedSubscription {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if sub, ok := fs.subs[id]; ok {
+		return copySubscription(sub)
+	}
+	return models.FeedSubscription{}
+}
+
+func copySubscription(sub *models.FeedSubscription) models.FeedSubscription {
+	c := *sub
+	c.RecentJobIDs = append([]string(nil), sub.RecentJobIDs...)
+	return c
+}
+
+// FetchItems downloads and parses an RSS 2.0 or Atom feed
+func (fs *FeedService) FetchItems(feedURL string) ([]FeedItem, error) {
+	resp, err := fs.httpClient.Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	return ParseFeed(body)
+}
+
+// ParseFeed extracts items from an RSS 2.0 or Atom document
+func ParseFeed(data []byte) ([]FeedItem, error) {
+	var doc feedDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid feed XML: %w", err)
+	}
+
+	var items []FeedItem
+	switch doc.XMLName.Local {
+	case "rss":
+		for _, it := range doc.Channel.Items {
+			text := it.Content
+			if text == "" {
+				text = it.Description
+			}
+			items = append(items, newFeedItem(it.GUID, it.Title, strings.TrimSpace(it.Link), text))
+		}
+	case "feed":
+		for _, en := range doc.Entries {
+			link := ""
+			for _, l := range en.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			text := en.Content
+			if text == "" {
+				text = en.Summary
+			}
+			items = append(items, newFeedItem(en.ID, en.Title, link, text))
+		}
+	default:
+		return nil, errors.New("unsupported feed format: expected RSS or Atom")
+	}
+	return items, nil
+}
+
+// newFeedItem normalizes an item; the ID falls back to link, then title, when the feed has no GUID
+func newFeedItem(id, title, link, body string) FeedItem {
+	item := FeedItem{
+		Title: cleanFeedText(title),
+		Link:  link,
+		Text:  cleanFeedText(body),
+	}
+	switch {
+	case strings.TrimSpace(id) != "":
+		item.ID = strings.TrimSpace(id)
+	case link != "":
+		item.ID = link
+	default:
+		item.ID = item.Title
+	}
+	return item
+}
+
+// cleanFeedText strips HTML markup and entities and collapses whitespace
+func cleanFeedText(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"aituber/models"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+const testRSS = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title>News</title>
+  <item>
+    <title>Second &amp; newest</title>
+    <link>https://example.com/2</link>
+    <guid>item-2</guid>
+    <description>&lt;p&gt;Short summary&lt;/p&gt;</description>
+    <content:encoded><![CDATA[<p>Full <b>article</b> body.</p>]]></content:encoded>
+  </item>
+  <item>
+    <title>First</title>
+    <link>https://example.com/1</link>
+    <description>Only a description</description>
+  </item>
+</channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Blog</title>
+  <entry>
+    <title>Atom entry</title>
+    <id>urn:uuid:1</id>
+    <link rel="alternate" href="https://example.com/atom/1"/>
+    <summary>Entry summary</summary>
+  </entry>
+</feed>`
+
+func TestParseFeed(t *testing.T) {
+	t.Run("RSS prefers content:encoded and falls back to link as ID", func(t *testing.T) {
+		items, err := ParseFeed([]byte(testRSS))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("Expected 2 items, got %d", len(items))
+		}
+		if items[0].ID != "item-2" || items[0].Title != "Second & newest" || items[0].Text != "Full article body." {
+			t.Errorf("Unexpected first item: %+v", items[0])
+		}
+		if items[1].ID != "https://example.com/1" || items[1].Text != "Only a description" {
+			t.Errorf("Unexpected second item: %+v", items[1])
+		}
+	})
+
+	t.Run("Atom", func(t *testing.T) {
+		items, err := ParseFeed([]byte(testAtom))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(items) != 1 || items[0].ID != "urn:uuid:1" || items[0].Link != "https://example.com/atom/1" || items[0].Text != "Entry summary" {
+			t.Errorf("Unexpected items: %+v", items)
+		}
+	})
+
+	t.Run("Rejects other XML", func(t *testing.T) {
+		if _, err := ParseFeed([]byte(`<html><body/></html>`)); err == nil {
+			t.Error("Expected error for non-feed XML")
+		}
+	})
+}
+
+func TestFeedService_EnqueuesOnlyNewItems(t *testing.T) {
+	var mu sync.Mutex
+	feedXML := testAtom
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(feedXML))
+	}))
+	defer server.Close()
+
+	var enqueued []models.GenerateRequest
+	fs := NewFeedService(func(req models.GenerateRequest) (string, error) {
+		enqueued = append(enqueued, req)
+		return "job-" + req.Title, nil
+	}, 1000)
+
+	sub, err := fs.Add(models.FeedSubscription{ID: "feed1", URL: server.URL, Platform: "youtube", Voice: "banmai"}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sub.MaxItemsPerPoll != defaultFeedItemsPerPoll {
+		t.Errorf("Expected default max items %d, got %d", defaultFeedItemsPerPoll, sub.MaxItemsPerPoll)
+	}
+
+	// Existing entries are not rendered
+	fs.PollAll()
+	if len(enqueued) != 0 {
+		t.Fatalf("Expected no jobs for existing items, got %d", len(enqueued))
+	}
+
+	// A new entry appears
+	mu.Lock()
+	feedXML = `<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><title>Fresh post</title><id>urn:uuid:2</id><content>Breaking news text</content></entry>
+  <entry><title>Atom entry</title><id>urn:uuid:1</id></entry>
+</feed>`
+	mu.Unlock()
+
+	fs.PollAll()
+	fs.PollAll() // second poll must not duplicate
+	if len(enqueued) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(enqueued))
+	}
+	if enqueued[0].Topic != "Fresh post" || enqueued[0].Script != "Fresh post. Breaking news text" || enqueued[0].Metadata["feed_id"] != "feed1" {
+		t.Errorf("Unexpected request: %+v", enqueued[0])
+	}
+
+	subs := fs.List()
+	if len(subs) != 1 || subs[0].ItemsEnqueued != 1 || len(subs[0].RecentJobIDs) != 1 || subs[0].LastPolledAt == nil {
+		t.Errorf("Unexpected subscription state: %+v", subs)
+	}
+
+	if !fs.Remove("feed1") || fs.Remove("feed1") {
+		t.Error("Expected Remove to succeed once")
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// FPTCallbackSubject relays FPT.AI callbacks from the API front to the render workers,
+// since the worker that made the request is the one waiting for its audio
+const FPTCallbackSubject = "aituber.tts.fpt-callback"
+
+// FPTCallbackHub wakes synthesis calls waiting for FPT.AI to report their audio ready
+type FPTCallbackHub struct {
+	mu      sync.Mutex
+	waiters map[string]*fptWaiter // async URL or request ID -> waiter
+	// Callbacks that beat the waiter's registration, which happens only after FPT answered the request
+	early map[string]models.FPTCallback
+}
+
+type fptWaiter struct {
+	done   chan struct{}
+	once   sync.Once
+	result models.FPTCallback
+}
+
+// NewFPTCallbackHub creates an empty hub
+func NewFPTCallbackHub() *FPTCallbackHub {
+	return &FPTCallbackHub{
+		waiters: make(map[string]*fptWaiter),
+		early:   make(map[string]models.FPTCallback),
+	}
+}
+
+// wait registers interest in a callback for any of keys. w.done is closed
+// when one arrives; release must be called once the caller stops waiting.
+func (h *FPTCallbackHub) wait(keys ...string) (w *fptWaiter, release func()) {
+	w = &fptWaiter{done: make(chan struct{})}
+	h.mu.Lock()
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		h.waiters[key] = w
+		if cb, ok := h.early[key]; ok {
+			delete(h.early, key)
+			w.deliver(cb)
+		}
+	}
+	h.mu.Unlock()
+
+	return w, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for _, key := range keys {
+			if h.waiters[key] == w {
+				delete(h.waiters, key)
+			}
+		}
+	}
+}
+
+// deliver hands cb to the waiter; later callbacks for the same request are ignored
+func (w *fptWaiter) deliver(cb models.FPTCallback) {
+	w.once.Do(func() {
+		w.result = cb
+		close(w.done)
+	})
+}
+
+// Notify delivers cb to the call waiting for it and reports whether there was one.
+// Unclaimed callbacks are kept briefly in case their request is still registering.
+func (h *FPTCallbackHub) Notify(cb models.FPTCallback) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := []string{cb.RequestID, cb.RequestIDAlt, cb.Link, cb.Async}
+	for _, key := range keys {
+		if w := h.waiters[key]; key != "" && w != nil {
+			w.deliver(cb)
+			return true
+		}
+	}
+	if len(h.early) >= fptMaxPendingRequests {
+		h.early = make(map[string]models.FPTCallback)
+	}
+	for _, key := range keys {
+		if key != "" {
+			h.early[key] = cb
+		}
+	}
+	return false
+}
+
+// PublishFPTCallback forwards cb to the render workers
+func PublishFPTCallback(nc *utils.NATSConn, cb models.FPTCallback) error {
+	data, err := json.Marshal(cb)
+	if err != nil {
+		return err
+	}
+	return nc.Publish(FPTCallbackSubject, data)
+}
+
+// SubscribeFPTCallbacks delivers callbacks relayed by the API front to hub. Every worker
+// receives every callback; only the one holding the request acts on it.
+func SubscribeFPTCallbacks(nc *utils.NATSConn, hub *FPTCallbackHub) error {
+	_, err := nc.Subscribe(FPTCallbackSubject, func(msg *utils.NATSMsg) {
+		var cb models.FPTCallback
+		if err := json.Unmarshal(msg.Data, &cb); err != nil {
+			log.Printf("[FPT] Dropping malformed relayed callback: %v", err)
+			return
+		}
+		hub.Notify(cb)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", FPTCallbackSubject, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFPTCallbackHub(t *testing.T) {
+	hub := NewFPTCallbackHub()
+	if hub.Notify(models.FPTCallback{RequestID: "r1", Success: true}) {
+		t.Error("Expected a callback without a waiter to be unclaimed")
+	}
+
+	// A callback that arrived before its request registered is delivered on registration
+	w, release := hub.wait("r1", "https://fpt/a.mp3")
+	select {
+	case <-w.done:
+	default:
+		t.Fatal("Expected the early callback to be delivered")
+	}
+	release()
+
+	w, release = hub.wait("r2", "https://fpt/b.mp3")
+	defer release()
+	if !hub.Notify(models.FPTCallback{Link: "https://fpt/b.mp3", Success: true}) {
+		t.Fatal("Expected the callback to match the waiter by URL")
+	}
+	hub.Notify(models.FPTCallback{RequestIDAlt: "r2", Success: false})
+	<-w.done
+	if !w.result.Success {
+		t.Error("Only the first callback of a request should count")
+	}
+}
+
+func TestFPTTTS_CallbackDrivenDownload(t *testing.T) {
+	hub := NewFPTCallbackHub()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tts":
+			if r.Header.Get("callback_url") != "https://example.com/cb" {
+				t.Errorf("Missing callback_url header, got %q", r.Header.Get("callback_url"))
+			}
+			json.NewEncoder(w).Encode(FPTTTSResponse{Async: server.URL + "/audio.mp3", RequestID: "req-1"})
+			go hub.Notify(models.FPTCallback{RequestIDAlt: "req-1", Success: true})
+		case "/audio.mp3":
+			w.Write([]byte("ID3-fpt"))
+		}
+	}))
+	defer server.Close()
+
+	f := NewFPTTTS(utils.NewAPIKeyPool([]string{"k"}), nil, nil, hub, "https://example.com/cb").(*fptTTS)
+	f.endpoint = server.URL + "/tts"
+	start := time.Now()
+	data, err := f.Synthesize(context.Background(), "xin chào", "banmai", 1.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "ID3-fpt" {
+		t.Errorf("Unexpected audio %q", data)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the callback to skip the polling delay, took %v", elapsed)
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fptTTSEndpoint = "https://api.fpt.ai/hmi/tts/v5"
+
+// fptCallbackWait is how long a request waits for FPT's callback before falling back to polling
+const fptCallbackWait = 30 * time.Second
+
+// fptMaxPendingRequests bounds the async URLs remembered for chunks that never completed
+const fptMaxPendingRequests = 256
+
+// FPTTTSResponse represents FPT.AI TTS API response
+type FPTTTSResponse struct {
+	Async     string `json:"async,omitempty"`
+	Error     int    `json:"error,omitempty"`
+	Message   string `json:"message,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// fptTTS is the FPT.AI backend. Synthesis is asynchronous: the API returns a URL that becomes
+// downloadable once rendering finishes. URLs from earlier attempts of the same chunk are kept and
+// polled alongside the new one, since FPT sometimes finishes a request after we gave up on it.
+type fptTTS struct {
+	pool       *utils.APIKeyPool // rate limited per key, see APIKeyPool.SetRateLimit
+	endpoint   string
+	httpClient *http.Client
+
+	pendingMux sync.Mutex
+	pending    map[string][]string // request key -> async URLs of earlier attempts
+
+	// Shared by all chunks of all jobs, so an FPT outage fails fast instead of retrying every chunk
+	breaker *utils.CircuitBreaker // nil disables
+	budget  *utils.RetryBudget    // nil disables
+
+	// When set, FPT POSTs to callbackURL once the audio is ready instead of being polled
+	callbacks   *FPTCallbackHub
+	callbackURL string
+}
+
+// NewFPTTTS creates the FPT.AI provider. breaker and budget may be nil; a nil callbacks hub
+// (or empty callbackURL) polls for every request.
+func NewFPTTTS(pool *utils.APIKeyPool, breaker *utils.CircuitBreaker, budget *utils.RetryBudget, callbacks *FPTCallbackHub, callbackURL string) TTSProvider {
+	if callbackURL == "" {
+		callbacks = nil
+	}
+	return &fptTTS{
+		pool:        pool,
+		endpoint:    fptTTSEndpoint,
+		httpClient:  newTTSHTTPClient(),
+		pending:     make(map[string][]string),
+		breaker:     breaker,
+		budget:      budget,
+		callbacks:   callbacks,
+		callbackURL: callbackURL,
+	}
+}
+
+// RetryDelay gives FPT a larger budget (36 attempts) so a stalled request can roll over many keys,
+// as long as the shared retry budget lasts
+func (f *fptTTS) RetryDelay(attempt int) (time.Duration, bool) {
+	if attempt >= 36 {
+		return 0, false
+	}
+	if f.budget != nil && !f.budget.Withdraw() {
+		log.Printf("[FPT] Retry budget exhausted, giving up on chunk")
+		return 0, false
+	}
+	return 3 * time.Second, true
+}
+
+// Synthesize requests the audio once and polls every URL obtained so far for this chunk
+func (f *fptTTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	if f.breaker != nil && !f.breaker.Allow() {
+		return nil, &TTSError{Err: fmt.Errorf("FPT.AI: %w", ErrTTSCircuitOpen)}
+	}
+	if f.budget != nil {
+		f.budget.Deposit()
+	}
+
+	if err := f.pool.Wait(ctx); err != nil {
+		return nil, &TTSError{Err: fmt.Errorf("FPT.AI rate limit wait: %w", err)}
+	}
+	apiKey, err := f.pool.GetRandomKey()
+	if err != nil {
+		f.record(false)
+		return nil, &TTSError{Err: fmt.Errorf("no available FPT API keys: %w", err)}
+	}
+	noteTTSKey(ctx, apiKey)
+
+	asyncURL, requestID, err := f.callFPTTTSAsync(ctx, text, voice, speed, apiKey)
+	if err != nil {
+		f.pool.MarkFailed(apiKey, 15*time.Second)
+		f.record(false)
+		return nil, err
+	}
+	f.pool.MarkSuccess(apiKey)
+	noteTTSAsyncURL(ctx, asyncURL)
+
+	key := fmt.Sprintf("%s|%.1f|%s", voice, speed, text)
+	urls := f.addPending(key, asyncURL)
+
+	data, err := f.awaitAudio(ctx, urls, requestID)
+	f.record(err == nil)
+	if err != nil {
+		log.Printf("[FPT] Poll exhausted for %d URLs, will re-request TTS: %v", len(urls), err)
+		return nil, err
+	}
+	f.pendingMux.Lock()
+	delete(f.pending, key)
+	f.pendingMux.Unlock()
+	return data, nil
+}
+
+// record feeds a call outcome to the circuit breaker
+func (f *fptTTS) record(success bool) {
+	if f.breaker == nil {
+		return
+	}
+	wasOpen := f.breaker.Open()
+	f.breaker.Record(success)
+	if !wasOpen && f.breaker.Open() {
+		log.Printf("[FPT] Circuit open: too many failed calls, failing fast")
+	}
+}
+
+// addPending records asyncURL for key and returns all URLs known for it
+func (f *fptTTS) addPending(key, asyncURL string) []string {
+	f.pendingMux.Lock()
+	defer f.pendingMux.Unlock()
+	if _, exists := f.pending[key]; !exists && len(f.pending) >= fptMaxPendingRequests {
+		f.pending = make(map[string][]string)
+	}
+	f.pending[key] = append(f.pending[key], asyncURL)
+	return append([]string(nil), f.pending[key]...)
+}
+
+// awaitAudio downloads the audio of urls once FPT's callback arrives; without a callback hub,
+// or when the callback does not arrive within fptCallbackWait, it polls urls instead
+func (f *fptTTS) awaitAudio(ctx context.Context, urls []string, requestID string) ([]byte, error) {
+	if f.callbacks == nil {
+		// Give FPT a moment to render before the first download attempt
+		time.Sleep(3 * time.Second)
+		return f.pollForAudioDownloadList(urls)
+	}
+
+	w, release := f.callbacks.wait(append([]string{requestID}, urls...)...)
+	defer release()
+	timer := time.NewTimer(fptCallbackWait)
+	defer timer.Stop()
+	select {
+	case <-w.done:
+		if !w.result.Success {
+			return nil, fmt.Errorf("FPT.AI reported the synthesis failed: %s", w.result.Message)
+		}
+		for _, link := range []string{w.result.Link, w.result.Async} {
+			if link != "" && !slices.Contains(urls, link) {
+				urls = append(urls, link)
+			}
+		}
+	case <-timer.C:
+		log.Printf("[FPT] No callback for %s after %s, falling back to polling", requestID, fptCallbackWait)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return f.pollForAudioDownloadList(urls)
+}
+
+// callFPTTTSAsync calls FPT.AI TTS API and returns the async URL and request ID
+func (f *fptTTS) callFPTTTSAsync(ctx context.Context, text, voice string, speed float64, apiKey string) (string, string, error) {
+	// Create HTTP request with plain text body
+	req, err := http.NewRequestWithContext(ctx, "POST", f.endpoint, bytes.NewBufferString(text))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers (voice and speed must be in headers, not JSON body)
+	req.Header.Set("api-key", apiKey)
+	req.Header.Set("voice", voice)
+	req.Header.Set("speed", fmt.Sprintf("%.1f", speed))
+	if f.callbacks != nil {
+		req.Header.Set("callback_url", f.callbackURL)
+	}
+
+	// Send request
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	noteTTSStatus(ctx, resp.StatusCode)
+
+	// Read response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Check status code
+	if resp.StatusCode != http.StatusOK {
+		// Try to parse error response
+		var errResp FPTTTSResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
+			return "", "", fmt.Errorf("API error: %s (code: %d)", errResp.Message, errResp.Error)
+		}
+		return "", "", fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	// Parse response to get async URL
+	var apiResp FPTTTSResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return "", "", fmt.Errorf("failed to parse response: %w. Body: %s", err, string(body))
+	}
+
+	if apiResp.Error != 0 {
+		return "", "", fmt.Errorf("API error: %s (code: %d)", apiResp.Message, apiResp.Error)
+	}
+
+	if apiResp.Async == "" {
+		return "", "", fmt.Errorf("no async URL in response. Body: %s", string(body))
+	}
+
+	log.Printf("[TTS API] Received async URL: %s (request_id: %s)", apiResp.Async, apiResp.RequestID)
+	return apiResp.Async, apiResp.RequestID, nil
+}
+
+// pollForAudioDownloadList polls a list of FPT.AI generated audio URLs.
+// Quy định theo ý tưởng mới: Tổng thời gian chờ tối đa khoảng 60s.
+// Nó lặp qua tất cả URLs trong danh sách, nếu bất kỳ URL nào trả về data thành công thì thoát và lấy kết quả đó.
+func (f *fptTTS) pollForAudioDownloadList(urls []string) ([]byte, error) {
+	maxAttempts := 15
+	pollInterval := 4 * time.Second // 15 attempts * 4s = ~60s tổng thời gian chờ timeout
+	var lastErr error
+
+	for i := 1; i <= maxAttempts; i++ {
+		var any404 bool
+
+		for _, url := range urls {
+			data, err := f.downloadAudio(url)
+			if err == nil {
+				log.Printf("[FPT] Audio ready after %d poll attempt(s) from one of the URLs", i)
+				return data, nil
+			}
+
+			lastErr = err
+			if strings.Contains(err.Error(), "404") {
+				any404 = true
+			}
+		}
+
+		if any404 {
+			log.Printf("[FPT] Audio not ready (404) for %d URLs, waiting 4s (attempt %d/%d, max ~60s)", len(urls), i, maxAttempts)
+		} else {
+			log.Printf("[FPT] Download error: %v, waiting 4s (attempt %d/%d, max ~60s)", lastErr, i, maxAttempts)
+		}
+
+		// Giữ nguyên 4s cho mỗi lần thử để rải đều trong 60s
+		time.Sleep(pollInterval)
+	}
+
+	return nil, fmt.Errorf("all %d URLs still 404 or err after ~60s wait (poll exhausted): %w", len(urls), lastErr)
+}
+
+// downloadAudio downloads audio from URL
+func (f *fptTTS) downloadAudio(url string) ([]byte, error) {
+	resp, err := f.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download audio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio data: %w", err)
+	}
+
+	return data, nil
+}
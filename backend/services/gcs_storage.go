@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcsAPIBase       = "https://storage.googleapis.com"
+	gcsTokenURI      = "https://oauth2.googleapis.com/token"
+	gcsScope         = "https://www.googleapis.com/auth/devstorage.read_write"
+	gcsTokenLifetime = time.Hour
+)
+
+// gcsServiceAccount is the part of a service account's JSON key file GCSStorage signs with
+type gcsServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// GCSStorage uploads to a Google Cloud Storage bucket as a service account: a signed JWT is
+// exchanged for an OAuth access token, reused until shortly before it expires
+type GCSStorage struct {
+	account    gcsServiceAccount
+	key        *rsa.PrivateKey
+	bucket     string
+	apiBase    string
+	publicURL  string // objects are served from publicURL/key
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewGCSStorage creates the storage of bucket with the service account key file at
+// credentialsFile; publicURL is where the objects are served from (a CDN or public bucket),
+// empty for storage.googleapis.com
+func NewGCSStorage(credentialsFile, bucket, publicURL string) (*GCSStorage, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account key: %w", err)
+	}
+	var account gcsServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if account.ClientEmail == "" {
+		return nil, fmt.Errorf("service account key has no client_email")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = gcsTokenURI
+	}
+	key, err := parseServiceAccountKey(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if publicURL == "" {
+		publicURL = gcsAPIBase + "/" + bucket
+	}
+	return &GCSStorage{
+		account:    account,
+		key:        key,
+		bucket:     bucket,
+		apiBase:    gcsAPIBase,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		httpClient: &http.Client{Timeout: storageUploadTimeout},
+	}, nil
+}
+
+// parseServiceAccountKey reads the PEM-encoded PKCS #8 RSA key of a service account
+func parseServiceAccountKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("service account key has no PEM private_key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("service account private_key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Upload stores the file at localPath as key with a simple media upload
+func (g *GCSStorage) Upload(ctx context.Context, key, localPath string) (string, error) {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", g.apiBase, url.PathEscape(g.bucket), url.QueryEscape(key))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, file)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", objectContentType(localPath))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", parseGCSError(resp)
+	}
+	return g.publicURL + "/" + escapeObjectKey(key), nil
+}
+
+// SignedURL signs a V4 URL for a GET of key with the service account's key, bypassing
+// GCS_PUBLIC_URL
+func (g *GCSStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	return g.signURL("GET", key, time.Now(), ttl)
+}
+
+func (g *GCSStorage) signURL(method, key string, now time.Time, ttl time.Duration) (string, error) {
+	base, err := url.Parse(g.apiBase)
+	if err != nil {
+		return "", err
+	}
+	now = now.UTC()
+	timestamp := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/auto/storage/goog4_request"
+	resource := "/" + url.PathEscape(g.bucket) + "/" + escapeObjectKey(key)
+
+	// Parameter names sort as listed; the values need no escaping but the credential's / and @
+	query := strings.Join([]string{
+		"X-Goog-Algorithm=GOOG4-RSA-SHA256",
+		"X-Goog-Credential=" + url.QueryEscape(g.account.ClientEmail+"/"+scope),
+		"X-Goog-Date=" + timestamp,
+		"X-Goog-Expires=" + fmt.Sprint(int(ttl.Seconds())),
+		"X-Goog-SignedHeaders=host",
+	}, "&")
+	canonicalRequest := strings.Join([]string{method, resource, query, "host:" + base.Host + "\n", "host", "UNSIGNED-PAYLOAD"}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"GOOG4-RSA-SHA256", timestamp, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(nil, g.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign URL: %w", err)
+	}
+	return fmt.Sprintf("%s://%s%s?%s&X-Goog-Signature=%s", base.Scheme, base.Host, resource, query, hex.EncodeToString(signature)), nil
+}
+
+// accessToken returns the cached OAuth token, fetching a new one a minute before it expires
+func (g *GCSStorage) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	if g.token != "" && now.Before(g.tokenExpiry.Add(-time.Minute)) {
+		return g.token, nil
+	}
+
+	assertion, err := g.signJWT(now)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", g.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.AccessToken == "" {
+		return "", fmt.Errorf("invalid token response: %v", err)
+	}
+	g.token = body.AccessToken
+	g.tokenExpiry = now.Add(time.Duration(body.ExpiresIn) * time.Second)
+	return g.token, nil
+}
+
+// signJWT signs the RS256 assertion of the service account's token request
+func (g *GCSStorage) signJWT(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   g.account.ClientEmail,
+		"scope": gcsScope,
+		"aud":   g.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(gcsTokenLifetime).Unix(),
+	})
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(nil, g.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token request: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseGCSError reads the message of a JSON API error response
+func parseGCSError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(raw, &body) != nil || body.Error.Message == "" {
+		return fmt.Errorf("GCS returned %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return fmt.Errorf("GCS returned %d: %s", resp.StatusCode, body.Error.Message)
+}
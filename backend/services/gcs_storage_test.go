@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeServiceAccountKey writes a service account key file for key whose tokens come from tokenURI
+func writeServiceAccountKey(t *testing.T, key *rsa.PrivateKey, tokenURI string) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "renderer@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURI,
+	})
+	path := filepath.Join(t.TempDir(), "key.json")
+	os.WriteFile(path, data, 0600)
+	return path
+}
+
+func TestGCSStorage_Upload(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := 0
+	var gotName, gotType, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokens++
+			r.ParseForm()
+			parts := strings.Split(r.PostForm.Get("assertion"), ".")
+			if len(parts) != 3 || r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			if !strings.Contains(string(claims), `"iss":"renderer@project.iam.gserviceaccount.com"`) || !strings.Contains(string(claims), gcsScope) {
+				t.Errorf("Unexpected claims %s", claims)
+			}
+			io.WriteString(w, `{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`)
+		case "/upload/storage/v1/b/renders/o":
+			if r.Header.Get("Authorization") != "Bearer ya29.token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				io.WriteString(w, `{"error":{"code":401,"message":"Invalid Credentials"}}`)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			gotName, gotType, gotBody = r.URL.Query().Get("name"), r.Header.Get("Content-Type"), string(body)
+			io.WriteString(w, `{"kind":"storage#object"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	storage, err := NewGCSStorage(writeServiceAccountKey(t, key, server.URL+"/token"), "renders", "")
+	if err != nil {
+		t.Fatalf("NewGCSStorage() error: %v", err)
+	}
+	storage.apiBase = server.URL
+
+	file := filepath.Join(t.TempDir(), "subtitles.vtt")
+	os.WriteFile(file, []byte("WEBVTT"), 0644)
+	for i := 0; i < 2; i++ {
+		url, err := storage.Upload(context.Background(), "out/job1/subtitles.vtt", file)
+		if err != nil {
+			t.Fatalf("Upload() error: %v", err)
+		}
+		if url != "https://storage.googleapis.com/renders/out/job1/subtitles.vtt" {
+			t.Errorf("Unexpected object URL %q", url)
+		}
+	}
+	if gotName != "out/job1/subtitles.vtt" || gotType != "text/vtt" || gotBody != "WEBVTT" {
+		t.Errorf("Unexpected upload %q %q %q", gotName, gotType, gotBody)
+	}
+	if tokens != 1 {
+		t.Errorf("Expected the access token to be reused, fetched %d", tokens)
+	}
+
+	storage.token = "expired"
+	if _, err := storage.Upload(context.Background(), "job1/subtitles.vtt", file); err == nil || !strings.Contains(err.Error(), "401: Invalid Credentials") {
+		t.Errorf("Expected the GCS error message, got %v", err)
+	}
+}
+
+func TestNewGCSStorage_InvalidKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.json")
+	os.WriteFile(path, []byte(`{"client_email":"a@b.iam.gserviceaccount.com","private_key":"not a key"}`), 0600)
+	if _, err := NewGCSStorage(path, "renders", ""); err == nil {
+		t.Error("Expected a key file without a PEM key to be rejected")
+	}
+	if _, err := NewGCSStorage(filepath.Join(t.TempDir(), "missing.json"), "renders", ""); err == nil {
+		t.Error("Expected a missing key file to be rejected")
+	}
+}
+
+func TestGCSStorage_SignedURL(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := NewGCSStorage(writeServiceAccountKey(t, key, ""), "renders", "https://cdn.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+
+	signed, err := storage.signURL("GET", "out/job1/final.mp4", now, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("signURL() error: %v", err)
+	}
+	query := "X-Goog-Algorithm=GOOG4-RSA-SHA256" +
+		"&X-Goog-Credential=renderer%40project.iam.gserviceaccount.com%2F20261014%2Fauto%2Fstorage%2Fgoog4_request" +
+		"&X-Goog-Date=20261014T093000Z&X-Goog-Expires=900&X-Goog-SignedHeaders=host"
+	prefix := "https://storage.googleapis.com/renders/out/job1/final.mp4?" + query + "&X-Goog-Signature="
+	if !strings.HasPrefix(signed, prefix) {
+		t.Fatalf("Unexpected signed URL %s", signed)
+	}
+
+	canonicalRequest := "GET\n/renders/out/job1/final.mp4\n" + query + "\nhost:storage.googleapis.com\n\nhost\nUNSIGNED-PAYLOAD"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "GOOG4-RSA-SHA256\n20261014T093000Z\n20261014/auto/storage/goog4_request\n" + hex.EncodeToString(requestHash[:])
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, _ := hex.DecodeString(strings.TrimPrefix(signed, prefix))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Signature does not verify: %v", err)
+	}
+}
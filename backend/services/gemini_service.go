@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"aituber/models"
+	"aituber/utils"
 )
 
 // GeminiService generates video scripts using Google Gemini API
@@ -330,7 +331,7 @@ func (gs *GeminiService) callWithKey(apiKey, prompt string, temperature float64,
 
 // GenerateImageForKeyword generates a stock-style cinematic image using gemini-2.5-flash-image.
 // Returns raw PNG bytes. Used as fallback when Pexels is unavailable.
-// orientation: "portrait" (9:16 for TikTok) or "landscape" (16:9 for YouTube).
+// orientation: "portrait" (9:16 for TikTok), "square" (1:1) or "landscape" (16:9 for YouTube).
 // visualDesc: optional cinematic scene description from the video script (preferred over keyword when non-empty).
 func (gs *GeminiService) GenerateImageForKeyword(keyword, visualDesc, orientation string) ([]byte, error) {
 	if !gs.HasKeys() {
@@ -343,10 +344,7 @@ func (gs *GeminiService) GenerateImageForKeyword(keyword, visualDesc, orientatio
 	}
 
 	// Map orientation to supported aspect ratio
-	aspectRatio := "16:9"
-	if orientation == "portrait" {
-		aspectRatio = "9:16"
-	}
+	aspectRatio := utils.FrameAspect(orientation)
 
 	// Build image prompt: prefer rich visual_description from script; fall back to short keyword.
 	var imagePrompt string
@@ -611,6 +609,65 @@ QUY TẮC NHẤT QUÁN:
 	return segments, nil
 }
 
+// GenerateStoryboard plans one scene per script segment: visual description, on-screen text,
+// b-roll keywords and music mood. Scenes come back in segment order with the narration copied
+// from segments, so a short or reordered reply cannot change what is spoken.
+func (gs *GeminiService) GenerateStoryboard(topic string, segments []models.VideoSegment) ([]models.StoryboardScene, error) {
+	if !gs.HasKeys() {
+		return nil, fmt.Errorf("no Gemini API keys configured")
+	}
+
+	var script strings.Builder
+	for i, seg := range segments {
+		script.WriteString(fmt.Sprintf("%d. %s\n", i+1, seg.Text))
+	}
+
+	prompt := fmt.Sprintf(`Bạn là đạo diễn hình ảnh (storyboard artist) cho video YouTube/TikTok tiếng Việt.
+
+Chủ đề: "%s"
+Kịch bản (mỗi dòng là một cảnh, đánh số):
+%s
+Hãy lên storyboard cho TỪNG cảnh, giữ nguyên thứ tự và số cảnh:
+- visual_description: mô tả hình ảnh bằng tiếng Anh, cụ thể (chủ thể, bối cảnh, hành động, ánh sáng, góc máy), nhất quán giữa các cảnh
+- on_screen_text: chữ hiện trên màn hình bằng tiếng Việt, tối đa 6 từ, để trống nếu không cần
+- broll_keywords: 2-4 từ khóa tiếng Anh để tìm stock footage
+- music_mood: một từ tiếng Anh (calm, upbeat, epic, dramatic, sad, inspiring...)
+
+BẮT BUỘC trả về JSON ARRAY (không có text nào khác):
+[
+  {"scene": 1, "visual_description": "...", "on_screen_text": "...", "broll_keywords": "...", "music_mood": "..."}
+]`, topic, script.String())
+
+	rawText, err := gs.callGeminiRaw(prompt, 0.7, 8192)
+	if err != nil {
+		return nil, fmt.Errorf("storyboard generation failed: %w", err)
+	}
+
+	var planned []struct {
+		Scene int `json:"scene"`
+		models.StoryboardScene
+	}
+	if err := json.Unmarshal([]byte(rawText), &planned); err != nil {
+		return nil, fmt.Errorf("failed to parse storyboard JSON: %w. Raw: %s", err, rawText)
+	}
+	scenes := make([]models.StoryboardScene, len(segments))
+	for i, p := range planned {
+		idx := p.Scene - 1
+		if idx < 0 || idx >= len(segments) {
+			idx = i // unnumbered scenes are taken in order
+		}
+		if idx < len(segments) {
+			scenes[idx] = p.StoryboardScene
+		}
+	}
+	for i, seg := range segments {
+		scenes[i].Text = seg.Text
+	}
+
+	log.Printf("[Gemini] Generated storyboard: %d scenes for topic: %q", len(scenes), topic)
+	return scenes, nil
+}
+
 // callGeminiRaw calls Gemini and returns the raw text response (no JSON parsing).
 func (gs *GeminiService) callGeminiRaw(prompt string, temperature float64, maxTokens int) (string, error) {
 	maxRetries := 5
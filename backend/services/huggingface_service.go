@@ -179,8 +179,11 @@ func (hf *HuggingFaceService) GenerateImageForKeyword(keyword, visualDesc, orien
 
 	// Orientation hint to guide composition
 	orientHint := "wide cinematic landscape composition, 16:9"
-	if orientation == "portrait" {
+	switch orientation {
+	case "portrait":
 		orientHint = "vertical phone portrait composition, 9:16, tall"
+	case "square":
+		orientHint = "centered square composition, 1:1"
 	}
 
 	// Build image prompt: prefer rich visual_description from script; fall back to short keyword.
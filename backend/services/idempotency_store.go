@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is returned when a key is re-sent with a different request payload
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+// idempotencyEntry binds a client key to the job created for it
+type idempotencyEntry struct {
+	fingerprint string
+	jobID       string
+	createdAt   time.Time
+}
+
+// IdempotencyStore remembers Idempotency-Key headers so retried requests return the original job
+type IdempotencyStore struct {
+	entries map[string]idempotencyEntry
+	mu      sync.Mutex
+	ttl     time.Duration
+}
+
+// NewIdempotencyStore creates a store that forgets keys after ttl (0 keeps them forever)
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		entries: make(map[string]idempotencyEntry),
+		ttl:     ttl,
+	}
+}
+
+// Claim atomically binds key to jobID if the key is new, and returns the job ID the key belongs to.
+// A returned ID different from jobID means the request is a retry of an earlier one.
+// fingerprint identifies the request payload; reusing a key with another payload returns ErrIdempotencyKeyReused.
+func (s *IdempotencyStore) Claim(key, fingerprint, jobID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.pruneLocked(now)
+
+	if entry, exists := s.entries[key]; exists {
+		if entry.fingerprint != fingerprint {
+			return "", ErrIdempotencyKeyReused
+		}
+		return entry.jobID, nil
+	}
+
+	s.entries[key] = idempotencyEntry{
+		fingerprint: fingerprint,
+		jobID:       jobID,
+		createdAt:   now,
+	}
+	return jobID, nil
+}
+
+// pruneLocked drops keys older than the TTL; caller must hold s.mu
+func (s *IdempotencyStore) pruneLocked(now time.Time) {
+	if s.ttl <= 0 {
+		return
+	}
+	cutoff := now.Add(-s.ttl)
+	for key, entry := range s.entries {
+		if entry.createdAt.Before(cutoff) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStore_Claim(t *testing.T) {
+	store := NewIdempotencyStore(time.Hour)
+
+	if id, err := store.Claim("key-1", "fp-a", "job-1"); err != nil || id != "job-1" {
+		t.Fatalf("First claim: got (%q, %v), want (job-1, nil)", id, err)
+	}
+
+	// Retry with same payload returns the original job
+	if id, err := store.Claim("key-1", "fp-a", "job-2"); err != nil || id != "job-1" {
+		t.Errorf("Replay: got (%q, %v), want (job-1, nil)", id, err)
+	}
+
+	// Same key, different payload is rejected
+	if _, err := store.Claim("key-1", "fp-b", "job-3"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("Expected ErrIdempotencyKeyReused, got %v", err)
+	}
+
+	// Expired keys can be reused
+	store.entries["key-1"] = idempotencyEntry{fingerprint: "fp-a", jobID: "job-1", createdAt: time.Now().Add(-2 * time.Hour)}
+	if id, err := store.Claim("key-1", "fp-b", "job-4"); err != nil || id != "job-4" {
+		t.Errorf("After TTL: got (%q, %v), want (job-4, nil)", id, err)
+	}
+}
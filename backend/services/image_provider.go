@@ -0,0 +1,70 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"sort"
+)
+
+// ImageProvider renders still images, as used by the slideshow source and thumbnails.
+// Implementations own their vendor API, key pool and retries.
+type ImageProvider interface {
+	// GenerateImage renders prompt at the provider's native size closest to aspect ("16:9" or
+	// "9:16") and returns the encoded image (PNG)
+	GenerateImage(ctx context.Context, prompt, aspect string) ([]byte, error)
+}
+
+// imageCall makes one generation with apiKey and returns the image, or the HTTP status of the
+// failed call (0 for transport errors)
+type imageCall func(ctx context.Context, prompt, aspect, apiKey string) ([]byte, int, error)
+
+// pooledImageProvider adapts a vendor's imageCall into an ImageProvider, rotating through its
+// key pool (see callWithKeyRotation)
+type pooledImageProvider struct {
+	name       string
+	pool       *utils.APIKeyPool
+	call       imageCall
+	maxRetries int
+}
+
+// newPooledImageProvider retries call with keys from pool; a nil pool is for keyless local servers
+func newPooledImageProvider(name string, pool *utils.APIKeyPool, call imageCall) *pooledImageProvider {
+	return &pooledImageProvider{name: name, pool: pool, call: call, maxRetries: 3}
+}
+
+// GenerateImage implements ImageProvider
+func (p *pooledImageProvider) GenerateImage(ctx context.Context, prompt, aspect string) ([]byte, error) {
+	return callWithKeyRotation(ctx, p.pool, p.name, p.maxRetries, func(apiKey string) ([]byte, int, error) {
+		return p.call(ctx, prompt, aspect, apiKey)
+	})
+}
+
+// RegisterImageProvider makes p selectable as GenerateRequest.ImageProvider == name
+func (is *ImageService) RegisterImageProvider(name string, p ImageProvider) {
+	is.providersMux.Lock()
+	defer is.providersMux.Unlock()
+	is.providers[name] = p
+}
+
+// ImageProviders returns the names of the registered providers, sorted
+func (is *ImageService) ImageProviders() []string {
+	is.providersMux.RLock()
+	defer is.providersMux.RUnlock()
+	names := make([]string, 0, len(is.providers))
+	for name := range is.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// imageProvider returns the provider registered as name ("" for OpenAI)
+func (is *ImageService) imageProvider(name string) (ImageProvider, bool) {
+	if name == "" {
+		name = ImageProviderOpenAI
+	}
+	is.providersMux.RLock()
+	defer is.providersMux.RUnlock()
+	p, ok := is.providers[name]
+	return p, ok
+}
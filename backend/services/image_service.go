@@ -0,0 +1,242 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Text-to-image APIs selectable via GenerateRequest.ImageProvider (video_source "images")
+const (
+	ImageProviderOpenAI    = "openai" // default: DALL·E
+	ImageProviderStability = "stability"
+	ImageProviderLocal     = "local" // Stable Diffusion WebUI (SDXL) on our own GPU
+)
+
+const stabilityAPIBase = "https://api.stability.ai"
+
+// ImageService generates one still per segment for the slideshow source, which then pans and
+// zooms over it (utils.ImageToVideo). A still costs a fraction of a text-to-video clip.
+type ImageService struct {
+	providers    map[string]ImageProvider
+	providersMux sync.RWMutex
+}
+
+// NewImageService creates an image service with no providers; see RegisterImageProvider
+func NewImageService() *ImageService {
+	return &ImageService{providers: make(map[string]ImageProvider)}
+}
+
+// Configured reports whether provider ("" for OpenAI) is registered
+func (is *ImageService) Configured(provider string) bool {
+	_, ok := is.imageProvider(provider)
+	return ok
+}
+
+// GenerateImage renders prompt with provider ("" for OpenAI) and writes the image to outputPath.
+// orientation is "landscape", "portrait" or "square".
+func (is *ImageService) GenerateImage(ctx context.Context, provider, prompt, orientation, outputPath string) error {
+	p, ok := is.imageProvider(provider)
+	if !ok {
+		return fmt.Errorf("image provider %q is not configured", provider)
+	}
+	data, err := p.GenerateImage(ctx, prompt, utils.FrameAspect(orientation))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return os.WriteFile(outputPath, data, 0644)
+}
+
+func newImageHTTPClient() *http.Client {
+	return &http.Client{Timeout: 3 * time.Minute}
+}
+
+// sdxlSize returns the SDXL-native size closest to aspect
+func sdxlSize(aspect string) (width, height int) {
+	switch aspect {
+	case "9:16":
+		return 768, 1344
+	case "1:1":
+		return 1024, 1024
+	}
+	return 1344, 768
+}
+
+// openAIImages is the OpenAI Images (DALL·E) backend
+type openAIImages struct {
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOpenAIImages creates the OpenAI provider for model (e.g. "dall-e-3"); baseURL may be
+// empty for api.openai.com
+func NewOpenAIImages(pool *utils.APIKeyPool, model, baseURL string) ImageProvider {
+	if baseURL == "" {
+		baseURL = openAIAPIBase
+	}
+	o := &openAIImages{model: model, baseURL: strings.TrimRight(baseURL, "/"), httpClient: newImageHTTPClient()}
+	return newPooledImageProvider(ImageProviderOpenAI, pool, o.generate)
+}
+
+// generate calls /v1/images/generations; DALL·E 3 renders 1792x1024, 1024x1792 or 1024x1024
+func (o *openAIImages) generate(ctx context.Context, prompt, aspect, apiKey string) ([]byte, int, error) {
+	size := "1792x1024"
+	switch aspect {
+	case "9:16":
+		size = "1024x1792"
+	case "1:1":
+		size = "1024x1024"
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":           o.model,
+		"prompt":          prompt,
+		"n":               1,
+		"size":            size,
+		"response_format": "b64_json",
+	})
+	var out struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+	}
+	if status, err := postImageJSON(ctx, o.httpClient, o.baseURL+"/v1/images/generations", apiKey, body, &out); err != nil {
+		return nil, status, fmt.Errorf("openai image generation failed: %w", err)
+	}
+	if len(out.Data) == 0 {
+		return nil, 0, fmt.Errorf("openai returned no image")
+	}
+	return decodeImage(out.Data[0].B64JSON)
+}
+
+// stabilityImages is the Stability AI backend (v1 text-to-image)
+type stabilityImages struct {
+	engine     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewStabilityImages creates the Stability AI provider for engine (e.g.
+// "stable-diffusion-xl-1024-v1-0"); baseURL may be empty for api.stability.ai
+func NewStabilityImages(pool *utils.APIKeyPool, engine, baseURL string) ImageProvider {
+	if baseURL == "" {
+		baseURL = stabilityAPIBase
+	}
+	st := &stabilityImages{engine: engine, baseURL: strings.TrimRight(baseURL, "/"), httpClient: newImageHTTPClient()}
+	return newPooledImageProvider(ImageProviderStability, pool, st.generate)
+}
+
+// generate calls the text-to-image endpoint of the configured engine
+func (st *stabilityImages) generate(ctx context.Context, prompt, aspect, apiKey string) ([]byte, int, error) {
+	width, height := sdxlSize(aspect)
+	body, _ := json.Marshal(map[string]interface{}{
+		"text_prompts": []map[string]interface{}{{"text": prompt}},
+		"width":        width,
+		"height":       height,
+		"samples":      1,
+		"steps":        30,
+		"cfg_scale":    7,
+	})
+	var out struct {
+		Artifacts []struct {
+			Base64       string `json:"base64"`
+			FinishReason string `json:"finishReason"` // SUCCESS, CONTENT_FILTERED or ERROR
+		} `json:"artifacts"`
+	}
+	url := fmt.Sprintf("%s/v1/generation/%s/text-to-image", st.baseURL, st.engine)
+	if status, err := postImageJSON(ctx, st.httpClient, url, apiKey, body, &out); err != nil {
+		return nil, status, fmt.Errorf("stability image generation failed: %w", err)
+	}
+	if len(out.Artifacts) == 0 {
+		return nil, 0, fmt.Errorf("stability returned no image")
+	}
+	if reason := out.Artifacts[0].FinishReason; reason == "CONTENT_FILTERED" {
+		// Same prompt, same filter: report it like a rejected request so it isn't retried
+		return nil, http.StatusBadRequest, fmt.Errorf("stability filtered the image")
+	} else if reason != "" && reason != "SUCCESS" {
+		return nil, 0, fmt.Errorf("stability image generation finished with %s", reason)
+	}
+	return decodeImage(out.Artifacts[0].Base64)
+}
+
+// localSDImages is a Stable Diffusion WebUI started with --api
+type localSDImages struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewLocalSDImages creates the provider for the WebUI at url; it needs no keys
+func NewLocalSDImages(url string) ImageProvider {
+	l := &localSDImages{baseURL: strings.TrimRight(url, "/"), httpClient: newImageHTTPClient()}
+	return newPooledImageProvider(ImageProviderLocal, nil, l.generate)
+}
+
+// generate calls the WebUI's /sdapi/v1/txt2img
+func (l *localSDImages) generate(ctx context.Context, prompt, aspect, _ string) ([]byte, int, error) {
+	width, height := sdxlSize(aspect)
+	body, _ := json.Marshal(map[string]interface{}{
+		"prompt":          prompt,
+		"negative_prompt": "text, watermark, blurry, low quality",
+		"width":           width,
+		"height":          height,
+		"steps":           30,
+	})
+	var out struct {
+		Images []string `json:"images"`
+	}
+	if status, err := postImageJSON(ctx, l.httpClient, l.baseURL+"/sdapi/v1/txt2img", "", body, &out); err != nil {
+		return nil, status, fmt.Errorf("local image generation failed: %w", err)
+	}
+	if len(out.Images) == 0 {
+		return nil, 0, fmt.Errorf("local SD returned no image")
+	}
+	return decodeImage(out.Images[0])
+}
+
+// decodeImage decodes a base64 image from a successful response
+func decodeImage(b64 string) ([]byte, int, error) {
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid image data: %w", err)
+	}
+	return data, http.StatusOK, nil
+}
+
+// postImageJSON posts body, with a Bearer apiKey unless empty, and decodes the response into
+// out. It returns the HTTP status of a failed call (0 for transport errors).
+func postImageJSON(ctx context.Context, client *http.Client, url, apiKey string, body []byte, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestImageService_Providers(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString([]byte("png-data"))
+	var gotBody map[string]interface{}
+	var openAICalls, stabilityCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody = nil
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		switch r.URL.Path {
+		case "/v1/images/generations":
+			openAICalls.Add(1)
+			if r.Header.Get("Authorization") != "Bearer oa-key" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": []map[string]string{{"b64_json": png}}})
+		case "/v1/generation/sdxl/text-to-image":
+			stabilityCalls.Add(1)
+			reason := "SUCCESS"
+			if gotBody["text_prompts"].([]interface{})[0].(map[string]interface{})["text"] == "nsfw" {
+				reason = "CONTENT_FILTERED"
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"artifacts": []map[string]string{{"base64": png, "finishReason": reason}}})
+		case "/sdapi/v1/txt2img":
+			json.NewEncoder(w).Encode(map[string]interface{}{"images": []string{png}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	is := NewImageService()
+	if is.Configured("") || is.Configured(ImageProviderLocal) {
+		t.Error("Expected no provider to be configured")
+	}
+	is.RegisterImageProvider(ImageProviderOpenAI, NewOpenAIImages(utils.NewAPIKeyPool([]string{"oa-key"}), "dall-e-3", srv.URL))
+	is.RegisterImageProvider(ImageProviderStability, NewStabilityImages(utils.NewAPIKeyPool([]string{"st-key"}), "sdxl", srv.URL))
+	is.RegisterImageProvider(ImageProviderLocal, NewLocalSDImages(srv.URL+"/"))
+	if got := is.ImageProviders(); len(got) != 3 || got[0] != ImageProviderLocal {
+		t.Errorf("Unexpected providers %v", got)
+	}
+
+	dir := t.TempDir()
+	tests := []struct {
+		provider    string
+		orientation string
+		check       func() bool
+	}{
+		{"", "portrait", func() bool { return gotBody["size"] == "1024x1792" && gotBody["model"] == "dall-e-3" }},
+		{ImageProviderStability, "landscape", func() bool { return gotBody["width"] == 1344.0 && gotBody["height"] == 768.0 }},
+		{ImageProviderLocal, "portrait", func() bool { return gotBody["width"] == 768.0 && gotBody["height"] == 1344.0 }},
+	}
+	for _, tt := range tests {
+		out := filepath.Join(dir, tt.provider+"x", "img.png")
+		if err := is.GenerateImage(context.Background(), tt.provider, "a lighthouse", tt.orientation, out); err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.provider, err)
+		}
+		if data, _ := os.ReadFile(out); string(data) != "png-data" {
+			t.Errorf("%q: unexpected image %q", tt.provider, data)
+		}
+		if !tt.check() {
+			t.Errorf("%q: unexpected request %v", tt.provider, gotBody)
+		}
+	}
+
+	// A filtered prompt is not retried
+	stabilityCalls.Store(0)
+	if err := is.GenerateImage(context.Background(), ImageProviderStability, "nsfw", "landscape", filepath.Join(dir, "f.png")); err == nil || stabilityCalls.Load() != 1 {
+		t.Errorf("Expected a filtered image to fail after one call, got %d calls (%v)", stabilityCalls.Load(), err)
+	}
+
+	// A rejected key is parked and the next one serves the image
+	openAICalls.Store(0)
+	is.RegisterImageProvider(ImageProviderOpenAI, NewOpenAIImages(utils.NewAPIKeyPool([]string{"revoked", "oa-key"}), "dall-e-3", srv.URL))
+	if err := is.GenerateImage(context.Background(), "", "x", "landscape", filepath.Join(dir, "r.png")); err != nil || openAICalls.Load() > 2 {
+		t.Errorf("Expected the good key to serve the image, got %d calls (%v)", openAICalls.Load(), err)
+	}
+	if err := is.GenerateImage(context.Background(), "midjourney", "x", "landscape", filepath.Join(dir, "m.png")); err == nil {
+		t.Error("Expected an unknown provider to fail")
+	}
+}
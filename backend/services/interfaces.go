@@ -2,7 +2,9 @@ package services
 
 import (
 	"aituber/models"
+	"aituber/utils"
 	"context"
+	"time"
 )
 
 // IScriptGenerator defines the interface for generating scripts
@@ -11,35 +13,55 @@ type IScriptGenerator interface {
 	GenerateTikTokScript(topic string) ([]models.VideoSegment, error)
 	GenerateSeriesOutline(topic, platform string, numParts int) ([]models.SeriesPartOutline, error)
 	GenerateSeriesPartScript(topic, platform string, outline []models.SeriesPartOutline, partIdx int) ([]models.VideoSegment, error)
+	GenerateStoryboard(topic string, segments []models.VideoSegment) ([]models.StoryboardScene, error)
 	HasKeys() bool
 }
 
 // IAudioService defines the interface for audio generation and processing
 type IAudioService interface {
-	GenerateAudioChunks(chunks []string, voice string, speed float64, jobID string, maxConcurrent int) ([]string, error)
-	MergeAudioFiles(audioPaths []string, outputPath string) error
+	StreamAudioChunks(chunks []string, provider, voice string, speeds []float64, format AudioFormat, jobID string, maxConcurrent int) (<-chan AudioChunk, error)
+	MergeAudioFiles(audioPaths []string, outputPath string, format AudioFormat) error
+	JoinWithPauses(pieces []AudioPiece, format AudioFormat, jobID string, index int) (string, error)
 }
 
 // IStockVideoService defines the interface for fetching stock clips
 type IStockVideoService interface {
-	PrepareSegmentVideo(ctx context.Context, keywords string, visualDesc string, t2vModel, t2vProvider string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error)
+	PrepareSegmentVideo(ctx context.Context, keywords, text, visualDesc string, t2vModel, t2vProvider string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error)
+	PrepareLocalSegment(keywords, text string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error)
+	PrepareUploadedSegment(clip BrollClip, audioDuration float64, jobID string, segIndex int, orientation string) (string, error)
 }
 
 // IComposerService defines the interface for combining audio and video
 type IComposerService interface {
-	ComposeVideoWithAudio(videoPath, audioPath, outputPath string) error
+	ComposeVideoWithAudio(videoPath, audioPath, subtitlePath, outputPath string) error
+	ComposeWithPresenter(videoPath, audioPath, subtitlePath, outputPath string, presenter utils.Presenter) error
+	BurnSubtitles(videoPath, outputPath string, assPaths ...string) error
+	EncodeOutput(videoPath, outputPath string, format utils.OutputFormat) error
 }
 
 // IJobManager defines the interface for tracking job progress
 type IJobManager interface {
 	CreateJob(jobID, platform, contentName string) *models.JobStatus
 	GetJob(jobID string) (*models.JobStatus, bool)
+	ListJobs() []models.JobStatus
+	UpdateJob(jobID string, fn func(*models.JobStatus)) error
 	UpdateProgress(jobID string, step string, progress int) error
+	EstimateRemaining(jobID string) (time.Duration, bool)
+	LogEvent(jobID, message string)
+	History(jobID string) []models.JobEvent
+	Subscribe(jobID string) (<-chan models.JobEvent, func())
 	MarkFailed(jobID string, err error) error
 	MarkCompleted(jobID, videoPath, savedPath string) error
+	MarkAwaitingApproval(jobID string, storyboard *models.Storyboard) error
+	MarkAwaitingReview(jobID string, subtitles []models.SubtitleCue) error
+	ResumeJob(jobID, from string, fn func(*models.JobStatus) bool) (*models.JobStatus, error)
+	MarkCancelled(jobID string) (bool, error)
+	MarkExpired(jobID string) (bool, error)
 }
 
 // IVideoWorkflow defines the interface for orchestrating video generation
 type IVideoWorkflow interface {
 	StartGeneration(jobID string, req models.GenerateRequest)
+	StartRerender(jobID string, source models.JobStatus, req models.GenerateRequest)
+	ContinueAfterReview(jobID string, job models.JobStatus)
 }
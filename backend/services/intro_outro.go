@@ -0,0 +1,232 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of IntroOutroStore clips
+const (
+	ClipIntro = "intro"
+	ClipOutro = "outro"
+)
+
+// maxIntroOutroClips bounds the intros (and the outros) one tenant can keep
+const maxIntroOutroClips = 20
+
+// introOutroDefaults is the file of each tenant's default intro and outro in the store's folder
+const introOutroDefaults = "defaults.json"
+
+// IntroOutroStore keeps intros and outros uploaded by tenants, stored like BrollAssetStore's
+// clips as "<id><ext>" plus a "<id>.json" sidecar written last, and each tenant's default.
+type IntroOutroStore struct {
+	dir string
+	mu  sync.Mutex // guards listing, deletes and defaults.json
+}
+
+// NewIntroOutroStore creates a store in dir, or returns nil (uploads disabled) for an empty dir
+func NewIntroOutroStore(dir string) *IntroOutroStore {
+	if dir == "" {
+		return nil
+	}
+	return &IntroOutroStore{dir: dir}
+}
+
+// ValidClipKind reports whether kind is ClipIntro or ClipOutro
+func ValidClipKind(kind string) bool {
+	return kind == ClipIntro || kind == ClipOutro
+}
+
+// Save stores the clip read from src as one of tenant's intros or outros. fileName (as
+// uploaded) picks the extension; files ffprobe cannot read are rejected.
+func (s *IntroOutroStore) Save(tenant, kind, fileName string, src io.Reader) (models.IntroOutroClip, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if !brollExtensions[ext] {
+		return models.IntroOutroClip{}, fmt.Errorf("unsupported clip type %q (expected mp4, mov, m4v, webm or mkv)", ext)
+	}
+	s.mu.Lock()
+	existing, err := s.list(tenant, kind)
+	s.mu.Unlock()
+	if err != nil {
+		return models.IntroOutroClip{}, err
+	}
+	if len(existing) >= maxIntroOutroClips {
+		return models.IntroOutroClip{}, fmt.Errorf("a tenant may keep at most %d %ss", maxIntroOutroClips, kind)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return models.IntroOutroClip{}, fmt.Errorf("failed to create intro/outro upload dir: %w", err)
+	}
+
+	clip := models.IntroOutroClip{
+		ID:        uuid.New().String(),
+		Tenant:    tenant,
+		Kind:      kind,
+		FileName:  filepath.Base(fileName),
+		CreatedAt: time.Now(),
+	}
+	clipPath := s.Path(clip)
+	size, err := writeBrollFile(clipPath, src)
+	if err != nil {
+		os.Remove(clipPath)
+		return models.IntroOutroClip{}, fmt.Errorf("failed to store clip: %w", err)
+	}
+	clip.Size = size
+	if clip.Duration, err = utils.GetVideoDuration(clipPath); err != nil || clip.Duration <= 0 {
+		os.Remove(clipPath)
+		return models.IntroOutroClip{}, fmt.Errorf("file is not a readable video")
+	}
+
+	data, _ := json.MarshalIndent(clip, "", "  ")
+	if err := os.WriteFile(filepath.Join(s.dir, clip.ID+".json"), data, 0644); err != nil {
+		os.Remove(clipPath)
+		return models.IntroOutroClip{}, fmt.Errorf("failed to store clip metadata: %w", err)
+	}
+	return clip, nil
+}
+
+// Get returns clip id, whoever uploaded it
+func (s *IntroOutroStore) Get(id string) (models.IntroOutroClip, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return models.IntroOutroClip{}, fmt.Errorf("clip %q not found", id)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		return models.IntroOutroClip{}, fmt.Errorf("clip %q not found", id)
+	}
+	var clip models.IntroOutroClip
+	if err := json.Unmarshal(data, &clip); err != nil {
+		return models.IntroOutroClip{}, fmt.Errorf("clip %q is corrupt: %w", id, err)
+	}
+	return clip, nil
+}
+
+// Find returns tenant's clip id of kind
+func (s *IntroOutroStore) Find(tenant, kind, id string) (models.IntroOutroClip, error) {
+	clip, err := s.Get(id)
+	if err == nil && (clip.Tenant != tenant || clip.Kind != kind) {
+		err = fmt.Errorf("%s %q not found", kind, id)
+	}
+	return clip, err
+}
+
+// List returns tenant's clips of kind, newest first, marking the default
+func (s *IntroOutroStore) List(tenant, kind string) ([]models.IntroOutroClip, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clips, err := s.list(tenant, kind)
+	if err != nil {
+		return nil, err
+	}
+	def := s.defaults()[tenant][kind]
+	for i := range clips {
+		clips[i].Default = clips[i].ID == def
+	}
+	return clips, nil
+}
+
+// list is List without the defaults; the caller holds s.mu
+func (s *IntroOutroStore) list(tenant, kind string) ([]models.IntroOutroClip, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	clips := []models.IntroOutroClip{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.Name() == introOutroDefaults {
+			continue
+		}
+		if clip, err := s.Get(id); err == nil && clip.Tenant == tenant && clip.Kind == kind {
+			clips = append(clips, clip)
+		}
+	}
+	sort.Slice(clips, func(i, j int) bool { return clips[i].CreatedAt.After(clips[j].CreatedAt) })
+	return clips, nil
+}
+
+// Delete removes tenant's clip id of kind, and clears it as the default
+func (s *IntroOutroStore) Delete(tenant, kind, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clip, err := s.Find(tenant, kind, id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(s.dir, id+".json")); err != nil {
+		return fmt.Errorf("failed to delete clip: %w", err)
+	}
+	os.Remove(s.Path(clip))
+	if defaults := s.defaults(); defaults[tenant][kind] == id {
+		delete(defaults[tenant], kind)
+		return s.saveDefaults(defaults)
+	}
+	return nil
+}
+
+// Default returns the ID of tenant's default clip of kind, "none" when the tenant turned the
+// clip off, or "" to use the bundled one
+func (s *IntroOutroStore) Default(tenant, kind string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.defaults()[tenant][kind]
+}
+
+// SetDefault makes id, one of tenant's clips of kind or "none", the default for requests that
+// name none; "" restores the bundled clip
+func (s *IntroOutroStore) SetDefault(tenant, kind, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id != "" && id != "none" {
+		if _, err := s.Find(tenant, kind, id); err != nil {
+			return err
+		}
+	}
+	defaults := s.defaults()
+	if defaults[tenant] == nil {
+		defaults[tenant] = make(map[string]string)
+	}
+	if id == "" {
+		delete(defaults[tenant], kind)
+	} else {
+		defaults[tenant][kind] = id
+	}
+	return s.saveDefaults(defaults)
+}
+
+// defaults reads defaults.json: tenant -> kind -> clip ID or "none"; the caller holds s.mu
+func (s *IntroOutroStore) defaults() map[string]map[string]string {
+	defaults := make(map[string]map[string]string)
+	if data, err := os.ReadFile(filepath.Join(s.dir, introOutroDefaults)); err == nil {
+		json.Unmarshal(data, &defaults)
+	}
+	return defaults
+}
+
+// saveDefaults writes defaults.json atomically; the caller holds s.mu
+func (s *IntroOutroStore) saveDefaults(defaults map[string]map[string]string) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create intro/outro upload dir: %w", err)
+	}
+	data, _ := json.MarshalIndent(defaults, "", "  ")
+	tmp := filepath.Join(s.dir, introOutroDefaults+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save defaults: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, introOutroDefaults))
+}
+
+// Path is where clip's video is stored
+func (s *IntroOutroStore) Path(clip models.IntroOutroClip) string {
+	return filepath.Join(s.dir, clip.ID+strings.ToLower(filepath.Ext(clip.FileName)))
+}
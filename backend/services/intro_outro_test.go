@@ -0,0 +1,116 @@
+package services
+
+import (
+	"aituber/models"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeIntroOutroClip stores a clip as Save would, without probing a real video
+func writeIntroOutroClip(t *testing.T, dir string, clip models.IntroOutroClip) {
+	t.Helper()
+	data, _ := json.Marshal(clip)
+	if err := os.WriteFile(filepath.Join(dir, clip.ID+".json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write sidecar: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, clip.ID+filepath.Ext(clip.FileName)), []byte("video"), 0644); err != nil {
+		t.Fatalf("Failed to write clip: %v", err)
+	}
+}
+
+func TestIntroOutroStore(t *testing.T) {
+	if NewIntroOutroStore("") != nil {
+		t.Error("Expected uploads disabled without a dir")
+	}
+	dir := t.TempDir()
+	store := NewIntroOutroStore(dir)
+	old := models.IntroOutroClip{ID: "0b5a3c36-55d6-4b8e-9b52-8d1f0c1e3a01", Tenant: "acme", Kind: ClipIntro, FileName: "old.mp4", Duration: 12, CreatedAt: time.Now().Add(-time.Hour)}
+	recent := models.IntroOutroClip{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Tenant: "acme", Kind: ClipIntro, FileName: "Tet.MOV", Duration: 5, CreatedAt: time.Now()}
+	outro := models.IntroOutroClip{ID: "9f1c2a4e-3b5d-4c6e-8f70-1a2b3c4d5e6f", Tenant: "acme", Kind: ClipOutro, FileName: "bye.mp4", Duration: 4, CreatedAt: time.Now()}
+	other := models.IntroOutroClip{ID: "16fd2706-8baf-433b-82eb-8c7fada847da", Tenant: "globex", Kind: ClipIntro, FileName: "lab.mp4", Duration: 9, CreatedAt: time.Now()}
+	for _, c := range []models.IntroOutroClip{old, recent, outro, other} {
+		writeIntroOutroClip(t, dir, c)
+	}
+
+	clips, err := store.List("acme", ClipIntro)
+	if err != nil || len(clips) != 2 || clips[0].ID != recent.ID || clips[1].ID != old.ID {
+		t.Errorf("Expected acme's intros newest first, got %+v (%v)", clips, err)
+	}
+	if _, err := store.Find("globex", ClipIntro, old.ID); err == nil {
+		t.Error("Expected another tenant's clip to be rejected")
+	}
+	if _, err := store.Find("acme", ClipOutro, old.ID); err == nil {
+		t.Error("Expected an intro to be rejected as an outro")
+	}
+	if got := store.Path(recent); got != filepath.Join(dir, recent.ID+".mov") {
+		t.Errorf("Path() = %q", got)
+	}
+
+	// Defaults
+	if store.Default("acme", ClipIntro) != "" {
+		t.Error("Expected no default before one is set")
+	}
+	if err := store.SetDefault("acme", ClipIntro, other.ID); err == nil {
+		t.Error("Expected another tenant's clip to be refused as the default")
+	}
+	if err := store.SetDefault("acme", ClipIntro, old.ID); err != nil {
+		t.Fatalf("SetDefault() error = %v", err)
+	}
+	if err := store.SetDefault("acme", ClipOutro, "none"); err != nil {
+		t.Fatalf("SetDefault(none) error = %v", err)
+	}
+	if store.Default("acme", ClipIntro) != old.ID || store.Default("acme", ClipOutro) != "none" || store.Default("globex", ClipIntro) != "" {
+		t.Errorf("Unexpected defaults %+v", store.defaults())
+	}
+	if clips, _ := store.List("acme", ClipIntro); clips[0].Default || !clips[1].Default {
+		t.Errorf("Expected the old intro marked as default, got %+v", clips)
+	}
+
+	// Deleting the default clip restores the bundled one
+	if err := store.Delete("globex", ClipIntro, old.ID); err == nil {
+		t.Error("Expected another tenant's delete to be refused")
+	}
+	if err := store.Delete("acme", ClipIntro, old.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(store.Path(old)); !os.IsNotExist(err) {
+		t.Error("Expected the clip's video to be removed")
+	}
+	if store.Default("acme", ClipIntro) != "" || store.Default("acme", ClipOutro) != "none" {
+		t.Errorf("Expected only the intro default cleared, got %+v", store.defaults())
+	}
+
+	if _, err := store.Save("acme", ClipIntro, "notes.txt", strings.NewReader("text")); err == nil {
+		t.Error("Expected a non-video file type to be rejected")
+	}
+}
+
+func TestIntroOutroPath(t *testing.T) {
+	dir := t.TempDir()
+	clip := models.IntroOutroClip{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Tenant: "acme", Kind: ClipIntro, FileName: "tet.mp4", Duration: 5}
+	writeIntroOutroClip(t, dir, clip)
+	s := &VideoWorkflowService{introOutro: NewIntroOutroStore(dir)}
+
+	tests := []struct {
+		name, input, want string
+	}{
+		{"Uploaded", clip.ID, filepath.Join(dir, clip.ID+".mp4")},
+		{"Static", "tet_intro.mp4", filepath.Join("static", "tet_intro.mp4")},
+		{"Default", "", filepath.Join("static", "intro_video.mp4")},
+		{"Disabled", "none", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.introOutroPath(tt.input, defaultIntroVideo); got != tt.want {
+				t.Errorf("introOutroPath(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+	if got := s.introDuration(models.GenerateRequest{Platform: "tiktok", IntroVideo: clip.ID}); got != 0 {
+		t.Errorf("Expected no intro on TikTok, got %v", got)
+	}
+}
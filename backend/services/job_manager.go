@@ -2,35 +2,153 @@ package services
 
 import (
 	"aituber/models"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+// JobListener is invoked with a snapshot of a job once it reaches a terminal state
+type JobListener func(job models.JobStatus)
+
 // JobManager handles the state of background video generation jobs
 type JobManager struct {
 	jobs    map[string]*models.JobStatus
 	jobsMux sync.RWMutex
+
+	listeners []JobListener
+	eta       *ETAEstimator
+
+	subscribers map[string]map[chan models.JobEvent]struct{}
+	subsMux     sync.Mutex
 }
 
+// jobEventBuffer is the per-subscriber channel capacity; slow consumers drop events beyond it
+const jobEventBuffer = 64
+
+// maxJobHistory caps the timeline kept per job; the oldest entries are dropped first
+const maxJobHistory = 500
+
 // NewJobManager creates a new instance of job manager
 func NewJobManager() *JobManager {
 	return &JobManager{
-		jobs: make(map[string]*models.JobStatus),
+		jobs:        make(map[string]*models.JobStatus),
+		eta:         NewETAEstimator(),
+		subscribers: make(map[string]map[chan models.JobEvent]struct{}),
+	}
+}
+
+// Subscribe returns a channel of real-time events for a job and an unsubscribe func.
+// The caller must call the returned func once it stops reading.
+func (jm *JobManager) Subscribe(jobID string) (<-chan models.JobEvent, func()) {
+	ch := make(chan models.JobEvent, jobEventBuffer)
+
+	jm.subsMux.Lock()
+	if jm.subscribers[jobID] == nil {
+		jm.subscribers[jobID] = make(map[chan models.JobEvent]struct{})
+	}
+	jm.subscribers[jobID][ch] = struct{}{}
+	jm.subsMux.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			jm.subsMux.Lock()
+			delete(jm.subscribers[jobID], ch)
+			if len(jm.subscribers[jobID]) == 0 {
+				delete(jm.subscribers, jobID)
+			}
+			jm.subsMux.Unlock()
+		})
+	}
+}
+
+// LogEvent records an intermediate log line (e.g. "chunk 5/20 audio downloaded") in the job's
+// timeline and publishes it to subscribers
+func (jm *JobManager) LogEvent(jobID, message string) {
+	event := models.JobEvent{JobID: jobID, Type: "log", Message: message, Timestamp: time.Now()}
+
+	jm.jobsMux.Lock()
+	if job, exists := jm.jobs[jobID]; exists {
+		recordEvent(job, event)
+	}
+	jm.jobsMux.Unlock()
+
+	jm.publish(event)
+}
+
+// History returns a copy of the job's event timeline, oldest first
+func (jm *JobManager) History(jobID string) []models.JobEvent {
+	jm.jobsMux.RLock()
+	defer jm.jobsMux.RUnlock()
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		return nil
+	}
+	return append([]models.JobEvent(nil), job.Events...)
+}
+
+// recordEvent appends to the job's timeline; caller must hold jobsMux
+func recordEvent(job *models.JobStatus, event models.JobEvent) {
+	job.Events = append(job.Events, event)
+	if len(job.Events) > maxJobHistory {
+		job.Events = append([]models.JobEvent(nil), job.Events[len(job.Events)-maxJobHistory:]...)
+	}
+}
+
+// publish fans an event out to the job's subscribers without blocking the pipeline. A
+// subscriber that is not keeping up misses events, but never the status that ends the job: the
+// oldest event it has not read is dropped to make room.
+func (jm *JobManager) publish(event models.JobEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	terminal := event.Type == "status" && JobFinished(event.Status)
+
+	jm.subsMux.Lock()
+	defer jm.subsMux.Unlock()
+	for ch := range jm.subscribers[event.JobID] {
+		select {
+		case ch <- event:
+			continue
+		default:
+		}
+		if !terminal {
+			// Subscriber is not keeping up; drop rather than stall the render
+			continue
+		}
+		// Only publish sends, under subsMux, so the slot freed here stays free
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- event:
+		default:
+		}
 	}
 }
 
 // CreateJob creates a new job in memory
 func (jm *JobManager) CreateJob(jobID, platform, contentName string) *models.JobStatus {
+	now := time.Now()
 	job := &models.JobStatus{
-		JobID:       jobID,
-		Platform:    platform,
-		ContentName: contentName,
-		Status:      "processing",
-		Progress:    0,
-		CurrentStep: "Initializing",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		JobID:         jobID,
+		Platform:      platform,
+		ContentName:   contentName,
+		Status:        "processing",
+		Progress:      0,
+		CurrentStep:   "Initializing",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		StepKey:       StepKey("Initializing"),
+		StepStartedAt: now,
+		DownloadToken: newDownloadToken(),
+		Events: []models.JobEvent{
+			{JobID: jobID, Type: "status", Status: "processing", Message: "Job created", Timestamp: now},
+		},
 	}
 
 	jm.jobsMux.Lock()
@@ -40,6 +158,16 @@ func (jm *JobManager) CreateJob(jobID, platform, contentName string) *models.Job
 	return job
 }
 
+// newDownloadToken returns a random URL-safe token for authorizing downloads
+func newDownloadToken() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(fmt.Sprintf("failed to generate download token: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
 // GetJob retrieves a job status thread-safely
 func (jm *JobManager) GetJob(jobID string) (*models.JobStatus, bool) {
 	jm.jobsMux.RLock()
@@ -51,6 +179,44 @@ func (jm *JobManager) GetJob(jobID string) (*models.JobStatus, bool) {
 	return job, exists
 }
 
+// ListJobs returns snapshots of all jobs, newest first
+func (jm *JobManager) ListJobs() []models.JobStatus {
+	jm.jobsMux.RLock()
+	jobs := make([]models.JobStatus, 0, len(jm.jobs))
+	for _, job := range jm.jobs {
+		jobs = append(jobs, *job)
+	}
+	jm.jobsMux.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// OnFinished registers a listener called (asynchronously) when a job completes or fails
+func (jm *JobManager) OnFinished(fn JobListener) {
+	jm.jobsMux.Lock()
+	jm.listeners = append(jm.listeners, fn)
+	jm.jobsMux.Unlock()
+}
+
+// UpdateJob applies fn to the job under the manager's lock
+func (jm *JobManager) UpdateJob(jobID string, fn func(*models.JobStatus)) error {
+	jm.jobsMux.Lock()
+	defer jm.jobsMux.Unlock()
+
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job %s not found", jobID)
+	}
+
+	fn(job)
+	job.UpdatedAt = time.Now()
+
+	return nil
+}
+
 // UpdateProgress updates job's progress and current step
 func (jm *JobManager) UpdateProgress(jobID string, step string, progress int) error {
 	jm.jobsMux.Lock()
@@ -60,10 +226,29 @@ func (jm *JobManager) UpdateProgress(jobID string, step string, progress int) er
 	if !exists {
 		return fmt.Errorf("job %s not found", jobID)
 	}
+	if job.Status == "cancelled" {
+		return nil
+	}
+
+	now := time.Now()
+	if key := StepKey(step); key != job.StepKey {
+		closeStep(job, now)
+		job.StepKey = key
+		job.StepStartedAt = now
+		job.StepStartProgress = progress
+	}
+
+	event := models.JobEvent{JobID: jobID, Type: "progress", Step: step, Progress: progress, Status: job.Status, Timestamp: now}
+	// Repeated updates of the same step only move the percentage; keep them out of the timeline
+	if step != job.CurrentStep {
+		recordEvent(job, event)
+	}
 
 	job.CurrentStep = step
 	job.Progress = progress
-	job.UpdatedAt = time.Now()
+	job.UpdatedAt = now
+
+	jm.publish(event)
 
 	return nil
 }
@@ -71,36 +256,264 @@ func (jm *JobManager) UpdateProgress(jobID string, step string, progress int) er
 // MarkFailed marks a job as failed
 func (jm *JobManager) MarkFailed(jobID string, err error) error {
 	jm.jobsMux.Lock()
-	defer jm.jobsMux.Unlock()
 
 	job, exists := jm.jobs[jobID]
 	if !exists {
+		jm.jobsMux.Unlock()
 		return fmt.Errorf("job %s not found", jobID)
 	}
+	if job.Status == "cancelled" {
+		jm.jobsMux.Unlock()
+		return nil
+	}
 
 	job.Status = "failed"
 	job.Error = err
 	job.UpdatedAt = time.Now()
+	recordEvent(job, finishedEvent(job))
+
+	snapshot, listeners := *job, jm.listeners
+	jm.jobsMux.Unlock()
 
+	jm.notifyFinished(snapshot, listeners)
 	return nil
 }
 
 // MarkCompleted marks a job as successfully generated
 func (jm *JobManager) MarkCompleted(jobID, videoPath, savedPath string) error {
 	jm.jobsMux.Lock()
-	defer jm.jobsMux.Unlock()
 
 	job, exists := jm.jobs[jobID]
 	if !exists {
+		jm.jobsMux.Unlock()
 		return fmt.Errorf("job %s not found", jobID)
 	}
+	if job.Status == "cancelled" {
+		jm.jobsMux.Unlock()
+		return nil
+	}
 
+	closeStep(job, time.Now())
 	job.Status = "completed"
 	job.Progress = 100
 	job.CurrentStep = "Complete"
 	job.VideoPath = videoPath
 	job.SavedPath = savedPath
 	job.UpdatedAt = time.Now()
+	recordEvent(job, finishedEvent(job))
 
+	snapshot, listeners := *job, jm.listeners
+	jm.jobsMux.Unlock()
+
+	jm.eta.Record(snapshot)
+	jm.notifyFinished(snapshot, listeners)
 	return nil
 }
+
+// MarkCancelled stops a job that has not finished (POST /api/jobs/:job_id/cancel): it ends in
+// "cancelled", and what its pipeline reports afterwards is ignored. It returns false for a job
+// that had already finished.
+func (jm *JobManager) MarkCancelled(jobID string) (bool, error) {
+	jm.jobsMux.Lock()
+
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		jm.jobsMux.Unlock()
+		return false, fmt.Errorf("job %s not found", jobID)
+	}
+	if JobFinished(job.Status) {
+		jm.jobsMux.Unlock()
+		return false, nil
+	}
+
+	closeStep(job, time.Now())
+	job.Status = "cancelled"
+	job.UpdatedAt = time.Now()
+	recordEvent(job, finishedEvent(job))
+
+	snapshot, listeners := *job, jm.listeners
+	jm.jobsMux.Unlock()
+
+	jm.notifyFinished(snapshot, listeners)
+	return true, nil
+}
+
+// JobFinished reports whether status is final: "completed", "failed", "cancelled" or "expired"
+func JobFinished(status string) bool {
+	switch status {
+	case "completed", "failed", "cancelled", "expired":
+		return true
+	}
+	return false
+}
+
+// MarkAwaitingApproval parks a storyboard job after planning: it holds storyboard and waits in
+// "awaiting_approval" until the storyboard is approved and the job restarted
+func (jm *JobManager) MarkAwaitingApproval(jobID string, storyboard *models.Storyboard) error {
+	jm.jobsMux.Lock()
+	defer jm.jobsMux.Unlock()
+
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job %s not found", jobID)
+	}
+	if job.Status == "cancelled" {
+		return nil
+	}
+
+	closeStep(job, time.Now())
+	job.Status = "awaiting_approval"
+	job.CurrentStep = "Waiting for storyboard approval"
+	job.Storyboard = storyboard
+	job.UpdatedAt = time.Now()
+	event := finishedEvent(job)
+	recordEvent(job, event)
+	jm.publish(event)
+	return nil
+}
+
+// MarkAwaitingReview parks a subtitle_review job before composition: it holds subtitles and
+// waits in "awaiting_review" until they are reviewed and the job continued
+func (jm *JobManager) MarkAwaitingReview(jobID string, subtitles []models.SubtitleCue) error {
+	jm.jobsMux.Lock()
+	defer jm.jobsMux.Unlock()
+
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job %s not found", jobID)
+	}
+	if job.Status == "cancelled" {
+		return nil
+	}
+
+	closeStep(job, time.Now())
+	job.Status = "awaiting_review"
+	job.CurrentStep = "Waiting for subtitle review"
+	job.Subtitles = subtitles
+	job.UpdatedAt = time.Now()
+	event := finishedEvent(job)
+	recordEvent(job, event)
+	jm.publish(event)
+	return nil
+}
+
+// ResumeJob restarts a job parked in status from ("awaiting_approval" or "awaiting_review") as
+// "processing" when fn, applied to it under the manager's lock, returns true. The change is
+// recorded and published like the one that parked it. It returns a snapshot of the resumed
+// job, or nil when the job was not waiting in from.
+func (jm *JobManager) ResumeJob(jobID, from string, fn func(*models.JobStatus) bool) (*models.JobStatus, error) {
+	jm.jobsMux.Lock()
+	defer jm.jobsMux.Unlock()
+
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("job %s not found", jobID)
+	}
+	if job.Status != from || !fn(job) {
+		return nil, nil
+	}
+
+	now := time.Now()
+	job.Status = "processing"
+	job.CurrentStep = "Initializing"
+	job.StepStartedAt = now
+	job.UpdatedAt = now
+	event := finishedEvent(job)
+	recordEvent(job, event)
+	jm.publish(event)
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// EstimateRemaining returns the estimated time left for a running job
+func (jm *JobManager) EstimateRemaining(jobID string) (time.Duration, bool) {
+	jm.jobsMux.RLock()
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		jm.jobsMux.RUnlock()
+		return 0, false
+	}
+	snapshot := *job
+	jm.jobsMux.RUnlock()
+
+	return jm.eta.Estimate(snapshot, time.Now())
+}
+
+// closeStep appends the timing of the job's current step; caller must hold jobsMux
+func closeStep(job *models.JobStatus, now time.Time) {
+	if job.StepKey == "" {
+		return
+	}
+	job.StepTimings = append(job.StepTimings, models.StepTiming{
+		Step:          job.StepKey,
+		StartProgress: job.StepStartProgress,
+		Duration:      now.Sub(job.StepStartedAt),
+	})
+	job.StepKey = ""
+}
+
+// finishedEvent describes a job's terminal state change
+func finishedEvent(job *models.JobStatus) models.JobEvent {
+	event := models.JobEvent{JobID: job.JobID, Type: "status", Step: job.CurrentStep, Progress: job.Progress, Status: job.Status, Timestamp: job.UpdatedAt}
+	if job.Error != nil {
+		event.Message = job.Error.Error()
+	}
+	return event
+}
+
+// ExpireJobs marks finished jobs last updated before now-retention as "expired" and returns their IDs
+func (jm *JobManager) ExpireJobs(retention time.Duration) []string {
+	cutoff := time.Now().Add(-retention)
+
+	jm.jobsMux.Lock()
+	defer jm.jobsMux.Unlock()
+
+	var expired []string
+	for id, job := range jm.jobs {
+		if job.Status != "completed" && job.Status != "failed" && job.Status != "cancelled" {
+			continue
+		}
+		if job.UpdatedAt.After(cutoff) {
+			continue
+		}
+		expireJob(job)
+		expired = append(expired, id)
+	}
+	return expired
+}
+
+// MarkExpired expires a finished job right away (DELETE /api/jobs/:job_id), before
+// JOB_RETENTION would; the caller removes its files. It returns false for a job that has not
+// finished, or had already expired.
+func (jm *JobManager) MarkExpired(jobID string) (bool, error) {
+	jm.jobsMux.Lock()
+	defer jm.jobsMux.Unlock()
+
+	job, exists := jm.jobs[jobID]
+	if !exists {
+		return false, fmt.Errorf("job %s not found", jobID)
+	}
+	if !JobFinished(job.Status) || job.Status == "expired" {
+		return false, nil
+	}
+	expireJob(job)
+	return true, nil
+}
+
+// expireJob marks job "expired": its files are gone, so it can no longer be downloaded
+func expireJob(job *models.JobStatus) {
+	job.Status = "expired"
+	job.CurrentStep = "Expired"
+	job.VideoPath = ""
+	job.UpdatedAt = time.Now()
+}
+
+// notifyFinished fans a terminal job snapshot out to the registered listeners.
+// Must be called without the lock held.
+func (jm *JobManager) notifyFinished(job models.JobStatus, listeners []JobListener) {
+	jm.publish(job.Events[len(job.Events)-1])
+
+	for _, fn := range listeners {
+		go fn(job)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"aituber/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobManager_OnFinished(t *testing.T) {
+	jm := NewJobManager()
+	got := make(chan models.JobStatus, 1)
+	jm.OnFinished(func(job models.JobStatus) { got <- job })
+
+	jm.CreateJob("job1", "youtube", "content")
+	jm.MarkFailed("job1", errors.New("boom"))
+
+	select {
+	case job := <-got:
+		if job.Status != "failed" || job.Error == nil {
+			t.Errorf("Unexpected snapshot: %+v", job)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("listener was not called")
+	}
+}
+
+func TestJobManager_Subscribe(t *testing.T) {
+	jm := NewJobManager()
+	jm.CreateJob("job1", "tiktok", "content")
+
+	events, unsubscribe := jm.Subscribe("job1")
+	defer unsubscribe()
+
+	jm.UpdateProgress("job1", "Merging audio", 42)
+	jm.LogEvent("job1", "chunk 5/20 audio downloaded")
+	jm.MarkCompleted("job1", "/tmp/v.mp4", "")
+
+	want := []string{"progress", "log", "status"}
+	for i, typ := range want {
+		select {
+		case ev := <-events:
+			if ev.Type != typ {
+				t.Errorf("event %d: expected type %q, got %q", i, typ, ev.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d (%s) not received", i, typ)
+		}
+	}
+
+	// Events for other jobs must not leak into this subscription
+	jm.CreateJob("job2", "tiktok", "content")
+	jm.UpdateProgress("job2", "Other", 10)
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event from another job: %+v", ev)
+	default:
+	}
+}
+
+func TestJobManager_SubscribeFullBuffer(t *testing.T) {
+	jm := NewJobManager()
+	jm.CreateJob("job1", "tiktok", "content")
+
+	events, unsubscribe := jm.Subscribe("job1")
+	defer unsubscribe()
+
+	// A subscriber that reads nothing until the job is done
+	for i := 0; i < jobEventBuffer+10; i++ {
+		jm.LogEvent("job1", "chunk audio downloaded")
+	}
+	jm.MarkFailed("job1", errors.New("boom"))
+
+	var last models.JobEvent
+	for i := 0; i < jobEventBuffer; i++ {
+		select {
+		case last = <-events:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %d buffered events, got %d", jobEventBuffer, i)
+		}
+	}
+	if last.Type != "status" || last.Status != "failed" {
+		t.Errorf("Expected the failure to be delivered last, got %+v", last)
+	}
+}
+
+func TestJobManager_ResumeJob(t *testing.T) {
+	jm := NewJobManager()
+	jm.CreateJob("job1", "youtube", "content")
+
+	if job, err := jm.ResumeJob("job1", "awaiting_review", func(*models.JobStatus) bool { return true }); job != nil || err != nil {
+		t.Errorf("Expected a running job not to be resumed, got %v, %v", job, err)
+	}
+	if _, err := jm.ResumeJob("missing", "awaiting_review", func(*models.JobStatus) bool { return true }); err == nil {
+		t.Error("Expected an error for an unknown job")
+	}
+
+	jm.MarkAwaitingReview("job1", []models.SubtitleCue{{Start: 0, End: 1, Text: "hi"}})
+	if job, _ := jm.ResumeJob("job1", "awaiting_review", func(*models.JobStatus) bool { return false }); job != nil {
+		t.Error("Expected the job to stay parked when fn refuses")
+	}
+
+	events, unsubscribe := jm.Subscribe("job1")
+	defer unsubscribe()
+	job, err := jm.ResumeJob("job1", "awaiting_review", func(*models.JobStatus) bool { return true })
+	if err != nil || job == nil || job.Status != "processing" || job.CurrentStep != "Initializing" {
+		t.Fatalf("ResumeJob() = %+v, %v", job, err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Type != "status" || ev.Status != "processing" {
+			t.Errorf("Expected a processing status event, got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the resume to be published")
+	}
+	history := jm.History("job1")
+	if last := history[len(history)-1]; last.Type != "status" || last.Status != "processing" {
+		t.Errorf("Expected the resume in the job's history, got %+v", last)
+	}
+}
+
+func TestJobManager_MarkCancelled(t *testing.T) {
+	jm := NewJobManager()
+	jm.CreateJob("job1", "youtube", "content")
+	jm.CreateJob("done", "youtube", "content")
+	jm.MarkCompleted("done", "/tmp/v.mp4", "")
+
+	if ok, err := jm.MarkCancelled("job1"); !ok || err != nil {
+		t.Fatalf("MarkCancelled() = %v, %v", ok, err)
+	}
+	// The pipeline keeps running until it notices; what it reports no longer counts
+	jm.UpdateProgress("job1", "Burning subtitles", 93)
+	jm.MarkFailed("job1", errors.New("ffmpeg canceled"))
+	jm.MarkCompleted("job1", "/tmp/v.mp4", "")
+	if job, _ := jm.GetJob("job1"); job.Status != "cancelled" || job.Error != nil || job.VideoPath != "" || job.Progress == 93 {
+		t.Errorf("Expected the job to stay cancelled, got %+v", job)
+	}
+
+	if ok, _ := jm.MarkCancelled("done"); ok {
+		t.Error("Expected a completed job not to be cancelled")
+	}
+	if _, err := jm.MarkCancelled("missing"); err == nil {
+		t.Error("Expected an unknown job to be an error")
+	}
+}
+
+func TestJobManager_ExpireJobs(t *testing.T) {
+	jm := NewJobManager()
+	jm.CreateJob("old", "youtube", "a")
+	jm.CreateJob("fresh", "youtube", "b")
+	jm.CreateJob("running", "youtube", "c")
+
+	jm.MarkCompleted("old", "/tmp/old.mp4", "")
+	jm.MarkCompleted("fresh", "/tmp/fresh.mp4", "")
+	jm.jobs["old"].UpdatedAt = time.Now().Add(-2 * time.Hour)
+	jm.jobs["running"].UpdatedAt = time.Now().Add(-2 * time.Hour)
+
+	expired := jm.ExpireJobs(time.Hour)
+	if len(expired) != 1 || expired[0] != "old" {
+		t.Fatalf("Expected only 'old' to expire, got %v", expired)
+	}
+
+	job, _ := jm.GetJob("old")
+	if job.Status != "expired" || job.VideoPath != "" {
+		t.Errorf("Expected expired job with cleared video path, got %+v", job)
+	}
+	if job, _ := jm.GetJob("running"); job.Status != "processing" {
+		t.Errorf("Running job must not expire, got status %s", job.Status)
+	}
+}
+
+func TestJobManager_History(t *testing.T) {
+	jm := NewJobManager()
+	jm.CreateJob("job1", "tiktok", "content")
+
+	jm.UpdateProgress("job1", "Merging audio", 42)
+	jm.UpdateProgress("job1", "Merging audio", 45) // same step: not a new timeline entry
+	jm.LogEvent("job1", "Retry 2 of chunk 7: timeout")
+	jm.MarkFailed("job1", errors.New("boom"))
+
+	history := jm.History("job1")
+	want := []struct{ typ, detail string }{
+		{"status", "Job created"},
+		{"progress", "Merging audio"},
+		{"log", "Retry 2 of chunk 7: timeout"},
+		{"status", "boom"},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(want), len(history), history)
+	}
+	for i, w := range want {
+		ev := history[i]
+		detail := ev.Message
+		if ev.Type == "progress" {
+			detail = ev.Step
+		}
+		if ev.Type != w.typ || detail != w.detail {
+			t.Errorf("Event %d = %s %q, want %s %q", i, ev.Type, detail, w.typ, w.detail)
+		}
+		if i > 0 && ev.Timestamp.Before(history[i-1].Timestamp) {
+			t.Errorf("Event %d is out of order", i)
+		}
+	}
+
+	// Callers get a copy
+	history[0].Message = "changed"
+	if jm.History("job1")[0].Message != "Job created" {
+		t.Error("History must not expose the internal slice")
+	}
+
+	for i := 0; i < maxJobHistory+10; i++ {
+		jm.LogEvent("job1", "spam")
+	}
+	if n := len(jm.History("job1")); n != maxJobHistory {
+		t.Errorf("Expected history capped at %d, got %d", maxJobHistory, n)
+	}
+	if jm.History("missing") != nil {
+		t.Error("Expected nil history for unknown job")
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"aituber/models"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// scheduledJob is a registered job waiting for its run_at time
+type scheduledJob struct {
+	jobID string
+	req   models.GenerateRequest
+	runAt time.Time
+}
+
+// JobScheduler holds jobs submitted with a future run_at and launches them when due
+type JobScheduler struct {
+	jobManager IJobManager
+	workflow   IVideoWorkflow
+
+	mu      sync.Mutex
+	pending []scheduledJob // sorted by runAt
+	wake    chan struct{}
+}
+
+// schedulerIdleWait is how long the scheduler sleeps when nothing is pending
+const schedulerIdleWait = time.Hour
+
+// NewJobScheduler creates a scheduler that starts due jobs through workflow
+func NewJobScheduler(jobManager IJobManager, workflow IVideoWorkflow) *JobScheduler {
+	return &JobScheduler{
+		jobManager: jobManager,
+		workflow:   workflow,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Schedule marks an already-created job as "scheduled" and queues it to start at runAt
+func (s *JobScheduler) Schedule(jobID string, req models.GenerateRequest, runAt time.Time) {
+	s.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.Status = "scheduled"
+		j.CurrentStep = fmt.Sprintf("Scheduled for %s", runAt.Format(time.RFC3339))
+		j.RunAt = runAt
+	})
+
+	s.mu.Lock()
+	s.pending = append(s.pending, scheduledJob{jobID: jobID, req: req, runAt: runAt})
+	sort.SliceStable(s.pending, func(i, j int) bool {
+		return s.pending[i].runAt.Before(s.pending[j].runAt)
+	})
+	s.mu.Unlock()
+
+	log.Printf("[Scheduler] Job %s scheduled for %s", jobID, runAt.Format(time.RFC3339))
+
+	// Nudge Run so it re-arms its timer if this job is now the earliest
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns the number of jobs waiting to start
+func (s *JobScheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Run launches scheduled jobs as they come due, until stop is closed
+func (s *JobScheduler) Run(stop <-chan struct{}) {
+	timer := time.NewTimer(schedulerIdleWait)
+	defer timer.Stop()
+
+	for {
+		wait := s.launchDue(time.Now())
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// launchDue starts every job whose runAt has passed and returns how long until the next one
+func (s *JobScheduler) launchDue(now time.Time) time.Duration {
+	s.mu.Lock()
+	var due []scheduledJob
+	for len(s.pending) > 0 && !s.pending[0].runAt.After(now) {
+		due = append(due, s.pending[0])
+		s.pending = s.pending[1:]
+	}
+	wait := schedulerIdleWait
+	if len(s.pending) > 0 {
+		wait = s.pending[0].runAt.Sub(now)
+	}
+	s.mu.Unlock()
+
+	for _, sj := range due {
+		s.launch(sj)
+	}
+	return wait
+}
+
+// launch flips a scheduled job to "processing" and starts its pipeline, unless it was cancelled
+func (s *JobScheduler) launch(sj scheduledJob) {
+	cancelled := false
+	s.jobManager.UpdateJob(sj.jobID, func(j *models.JobStatus) {
+		if cancelled = j.Status == "cancelled"; cancelled {
+			return
+		}
+		j.Status = "processing"
+		j.CurrentStep = "Initializing"
+		j.StepStartedAt = time.Now()
+	})
+	if cancelled {
+		return
+	}
+	log.Printf("[Scheduler] Starting scheduled job %s", sj.jobID)
+	go s.workflow.StartGeneration(sj.jobID, sj.req)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"aituber/models"
+	"testing"
+	"time"
+)
+
+// recordingWorkflow reports each started job ID on a channel
+type recordingWorkflow struct {
+	started chan string
+}
+
+func (w *recordingWorkflow) StartGeneration(jobID string, req models.GenerateRequest) {
+	w.started <- jobID
+}
+
+func (w *recordingWorkflow) StartRerender(jobID string, source models.JobStatus, req models.GenerateRequest) {
+	w.started <- jobID
+}
+
+func (w *recordingWorkflow) ContinueAfterReview(jobID string, job models.JobStatus) {
+	w.started <- jobID
+}
+
+func TestJobScheduler_LaunchesDueJobsInOrder(t *testing.T) {
+	jm := NewJobManager()
+	wf := &recordingWorkflow{started: make(chan string, 2)}
+	scheduler := NewJobScheduler(jm, wf)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go scheduler.Run(stop)
+
+	jm.CreateJob("later", "youtube", "a")
+	jm.CreateJob("sooner", "youtube", "b")
+	scheduler.Schedule("later", models.GenerateRequest{}, time.Now().Add(120*time.Millisecond))
+	scheduler.Schedule("sooner", models.GenerateRequest{}, time.Now().Add(40*time.Millisecond))
+
+	if job, _ := jm.GetJob("later"); job.Status != "scheduled" || job.RunAt.IsZero() {
+		t.Errorf("Expected scheduled job with run_at, got status %q", job.Status)
+	}
+
+	for _, want := range []string{"sooner", "later"} {
+		select {
+		case got := <-wf.started:
+			if got != want {
+				t.Errorf("Expected %s to start next, got %s", want, got)
+			}
+			if job, _ := jm.GetJob(got); job.Status != "processing" {
+				t.Errorf("Expected %s to be processing, got %q", got, job.Status)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %s to start", want)
+		}
+	}
+
+	if n := scheduler.Pending(); n != 0 {
+		t.Errorf("Expected no pending jobs, got %d", n)
+	}
+}
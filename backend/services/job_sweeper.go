@@ -0,0 +1,101 @@
+package services
+
+import (
+	"aituber/utils"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StartJobSweeper periodically expires finished jobs older than retention and deletes their temp dirs.
+// It runs until stop is closed; a non-positive retention disables the sweeper.
+func StartJobSweeper(jm *JobManager, tempDir string, retention, interval time.Duration, stop <-chan struct{}) {
+	if retention <= 0 {
+		log.Printf("[Sweeper] Job retention disabled")
+		return
+	}
+	log.Printf("[Sweeper] Expiring finished jobs after %v (checking every %v)", retention, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			SweepExpiredJobs(jm, tempDir, retention)
+		}
+	}
+}
+
+// SweepExpiredJobs runs a single expiry pass and returns the expired job IDs
+func SweepExpiredJobs(jm *JobManager, tempDir string, retention time.Duration) []string {
+	expired := jm.ExpireJobs(retention)
+	for _, jobID := range expired {
+		if err := utils.CleanupJobFiles(tempDir, jobID); err != nil {
+			log.Printf("[Sweeper] Job %s: failed to remove temp files: %v", jobID, err)
+			continue
+		}
+		log.Printf("[Sweeper] Job %s expired, temp files removed", jobID)
+	}
+	return expired
+}
+
+// TempCleanupReport summarizes a temp directory purge
+type TempCleanupReport struct {
+	Removed    []string `json:"removed"`
+	FreedBytes int64    `json:"freed_bytes"`
+}
+
+// PurgeStaleTempDirs removes job directories under tempDir that have no job record,
+// or that were last modified more than maxAge ago (0 disables the age check).
+// Directories of jobs that have not finished are never touched.
+func PurgeStaleTempDirs(jm IJobManager, tempDir string, maxAge time.Duration) (TempCleanupReport, error) {
+	report := TempCleanupReport{Removed: []string{}}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return report, nil
+		}
+		return report, fmt.Errorf("failed to read temp dir: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		jobID := entry.Name()
+
+		// Jobs waiting for review, approval or their schedule still need their assets
+		job, exists := jm.GetJob(jobID)
+		if exists && !JobFinished(job.Status) {
+			continue
+		}
+		if exists {
+			info, err := entry.Info()
+			if err != nil || maxAge <= 0 || info.ModTime().After(cutoff) {
+				continue
+			}
+		}
+
+		dir := filepath.Join(tempDir, jobID)
+		size, err := utils.DirSize(dir)
+		if err != nil {
+			log.Printf("[Cleanup] %s: failed to measure size: %v", dir, err)
+		}
+		if err := utils.CleanupJobFiles(tempDir, jobID); err != nil {
+			log.Printf("[Cleanup] %s: failed to remove: %v", dir, err)
+			continue
+		}
+		report.Removed = append(report.Removed, jobID)
+		report.FreedBytes += size
+	}
+
+	log.Printf("[Cleanup] Removed %d temp dir(s), freed %d bytes", len(report.Removed), report.FreedBytes)
+	return report, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"aituber/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPurgeStaleTempDirs(t *testing.T) {
+	tempDir := t.TempDir()
+	jm := NewJobManager()
+
+	mkdir := func(name string, size int, age time.Duration) {
+		dir := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Join(dir, "audio"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "audio", "chunk.mp3"), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		os.Chtimes(dir, mtime, mtime)
+	}
+
+	mkdir("orphan", 100, 0)             // no job record -> removed
+	mkdir("running", 100, 48*time.Hour) // still processing -> kept
+	mkdir("old-done", 50, 48*time.Hour) // finished and stale -> removed
+	mkdir("new-done", 100, 0)           // finished but recent -> kept
+	mkdir("review", 100, 48*time.Hour)  // waiting for subtitle review -> kept
+
+	jm.CreateJob("running", "youtube", "a")
+	jm.CreateJob("old-done", "youtube", "b")
+	jm.MarkCompleted("old-done", "", "")
+	jm.CreateJob("new-done", "youtube", "c")
+	jm.MarkCompleted("new-done", "", "")
+	jm.CreateJob("review", "youtube", "d")
+	jm.MarkAwaitingReview("review", []models.SubtitleCue{{Start: 0, End: 1, Text: "a"}})
+
+	report, err := PurgeStaleTempDirs(jm, tempDir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(report.Removed) != 2 {
+		t.Errorf("Expected 2 removed dirs, got %v", report.Removed)
+	}
+	if report.FreedBytes != 150 {
+		t.Errorf("Expected 150 freed bytes, got %d", report.FreedBytes)
+	}
+	for name, wantExists := range map[string]bool{"orphan": false, "running": true, "old-done": false, "new-done": true, "review": true} {
+		_, err := os.Stat(filepath.Join(tempDir, name))
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("%s: exists=%v, want %v", name, exists, wantExists)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// callWithKeyRotation runs call up to maxRetries times with keys from pool, for the media
+// providers (video clips, images) whose renders are slow and paid per call: 401/403 park a key
+// for 10 minutes, 429 for 30 seconds, other 4xx (a rejected prompt) fail at once and anything
+// else parks the key for 2 minutes before retrying. A nil pool calls with an empty key.
+func callWithKeyRotation(ctx context.Context, pool *utils.APIKeyPool, name string, maxRetries int, call func(apiKey string) ([]byte, int, error)) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * 2 * time.Second):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		var apiKey string
+		if pool != nil {
+			if err := pool.Wait(ctx); err != nil {
+				return nil, err
+			}
+			var err error
+			if apiKey, err = pool.GetRandomKey(); err != nil {
+				return nil, fmt.Errorf("no available %s API keys: %w", name, err)
+			}
+		}
+
+		data, status, err := call(apiKey)
+		if err == nil {
+			if pool != nil {
+				pool.MarkSuccess(apiKey)
+			}
+			return data, nil
+		}
+		lastErr = err
+		if status >= 400 && status < 500 && status != http.StatusUnauthorized && status != http.StatusForbidden && status != http.StatusTooManyRequests {
+			// The prompt itself was rejected; another key won't help
+			return nil, fmt.Errorf("%w: %w", errPromptRejected, err)
+		}
+		if pool == nil {
+			continue
+		}
+		switch status {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			// Invalid or out-of-credit key: park it so other keys take over
+			pool.MarkFailed(apiKey, 10*time.Minute)
+		case http.StatusTooManyRequests:
+			pool.MarkFailed(apiKey, 30*time.Second)
+		default:
+			pool.MarkFailed(apiKey, 120*time.Second)
+		}
+	}
+	return nil, fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kling serves separate accounts per region; keys only work against their own region
+var klingRegionEndpoints = map[string]string{
+	"global": "https://api-singapore.klingai.com",
+	"cn":     "https://api-beijing.klingai.com",
+}
+
+// klingClient is the Kling AI backend. Keys are "accessKey:secretKey" pairs, exchanged for a
+// short-lived HS256 JWT on every call. Generation is a task polled until it succeeds; like
+// Runway, Kling renders 5 or 10 second clips only.
+type klingClient struct {
+	model        string
+	baseURL      string
+	httpClient   *http.Client
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+// klingResponse is the envelope of every Kling API response
+type klingResponse struct {
+	Code    int    `json:"code"` // 0 on success
+	Message string `json:"message"`
+	Data    struct {
+		TaskID        string `json:"task_id"`
+		TaskStatus    string `json:"task_status"` // submitted, processing, succeed or failed
+		TaskStatusMsg string `json:"task_status_msg"`
+		TaskResult    struct {
+			Videos []struct {
+				URL string `json:"url"`
+			} `json:"videos"`
+		} `json:"task_result"`
+	} `json:"data"`
+}
+
+// klingCapabilities: Kling renders at most 10 seconds at 720p
+var klingCapabilities = VideoGenCapabilities{
+	MaxDuration: 10,
+	Resolutions: map[string]string{"16:9": "1280x720", "9:16": "720x1280"},
+}
+
+// SetKling enables video provider "kling" with model (e.g. "kling-v1") in region ("global" or
+// "cn"); a non-empty baseURL overrides the regional endpoint. A nil pool disables it.
+func (vs *VideoService) SetKling(pool *utils.APIKeyPool, model, region, baseURL string) error {
+	if pool == nil {
+		vs.RegisterVideoProvider(VideoProviderKling, nil)
+		return nil
+	}
+	kling, err := newKlingClient(model, region, baseURL, vs.httpClient, vs.pollInterval)
+	if err != nil {
+		return err
+	}
+	vs.RegisterVideoProvider(VideoProviderKling, newPooledVideoProvider(VideoProviderKling, pool, kling.generate, klingCapabilities, vs.tempDir))
+	return nil
+}
+
+// newKlingClient creates a Kling client for model in region, or at baseURL if non-empty
+func newKlingClient(model, region, baseURL string, httpClient *http.Client, pollInterval time.Duration) (*klingClient, error) {
+	if baseURL == "" {
+		endpoint, ok := klingRegionEndpoints[region]
+		if !ok {
+			return nil, fmt.Errorf("unknown Kling region %q (expected global or cn)", region)
+		}
+		baseURL = endpoint
+	}
+	return &klingClient{
+		model:        model,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   httpClient,
+		pollInterval: pollInterval,
+		now:          time.Now,
+	}, nil
+}
+
+// klingToken signs the JWT Kling expects for an "accessKey:secretKey" pair
+func klingToken(apiKey string, now time.Time) (string, error) {
+	accessKey, secretKey, ok := strings.Cut(apiKey, ":")
+	if !ok || accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("kling keys must be accessKey:secretKey pairs")
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss": accessKey,
+		"exp": now.Add(30 * time.Minute).Unix(),
+		"nbf": now.Add(-5 * time.Second).Unix(),
+	})
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// klingAspect keeps the ratios Kling renders, defaulting to landscape
+func klingAspect(aspect string) string {
+	if aspect == "9:16" {
+		return aspect
+	}
+	return "16:9"
+}
+
+// generate runs one Kling task with apiKey and returns the MP4
+func (k *klingClient) generate(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle, apiKey string) ([]byte, int, error) {
+	payload := map[string]interface{}{
+		"model_name":   k.model,
+		"prompt":       prompt,
+		"duration":     strconv.Itoa(fixedClipDuration(duration)),
+		"aspect_ratio": klingAspect(aspect),
+		"mode":         "std",
+	}
+	if style.NegativePrompt != "" {
+		payload["negative_prompt"] = style.NegativePrompt
+	}
+	body, _ := json.Marshal(payload)
+	var task klingResponse
+	if status, err := k.call(ctx, "POST", "/v1/videos/text2video", apiKey, body, &task); err != nil {
+		return nil, status, fmt.Errorf("kling submit failed: %w", err)
+	}
+	taskID := task.Data.TaskID
+	if taskID == "" {
+		return nil, 0, fmt.Errorf("kling returned no task_id")
+	}
+
+	for task.Data.TaskStatus != "succeed" {
+		if task.Data.TaskStatus == "failed" {
+			return nil, 0, fmt.Errorf("kling task %s failed: %s", taskID, task.Data.TaskStatusMsg)
+		}
+		select {
+		case <-time.After(k.pollInterval):
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+		if status, err := k.call(ctx, "GET", "/v1/videos/text2video/"+taskID, apiKey, nil, &task); err != nil {
+			return nil, status, fmt.Errorf("kling poll failed: %w", err)
+		}
+	}
+	videos := task.Data.TaskResult.Videos
+	if len(videos) == 0 || videos[0].URL == "" {
+		return nil, 0, fmt.Errorf("kling task %s succeeded without a video", taskID)
+	}
+
+	data, err := downloadVideo(ctx, k.httpClient, videos[0].URL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("kling download failed: %w", err)
+	}
+	return data, http.StatusOK, nil
+}
+
+// call makes an authenticated Kling API request. Kling reports some failures in the envelope's
+// code rather than the HTTP status; auth (1000-1004), rate limit (1302-1303) and server (5xxx)
+// codes are mapped to 401, 429 and 500 so keys rotate as they would for other providers.
+func (k *klingClient) call(ctx context.Context, method, path, apiKey string, body []byte, out *klingResponse) (int, error) {
+	token, err := klingToken(apiKey, k.now())
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, k.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+	}
+	switch {
+	case out.Code == 0:
+		return resp.StatusCode, nil
+	case out.Code >= 1000 && out.Code <= 1004:
+		return http.StatusUnauthorized, fmt.Errorf("code %d: %s", out.Code, out.Message)
+	case out.Code == 1302 || out.Code == 1303:
+		return http.StatusTooManyRequests, fmt.Errorf("code %d: %s", out.Code, out.Message)
+	case out.Code >= 5000:
+		return http.StatusInternalServerError, fmt.Errorf("code %d: %s", out.Code, out.Message)
+	default:
+		return http.StatusBadRequest, fmt.Errorf("code %d: %s", out.Code, out.Message)
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKlingClient_Generate(t *testing.T) {
+	var polls atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/out.mp4" {
+			w.Write([]byte("kling-mp4"))
+			return
+		}
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if parts := strings.Split(token, "."); len(parts) != 3 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		resp := klingResponse{}
+		switch r.URL.Path {
+		case "/v1/videos/text2video":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["duration"] != "5" || body["aspect_ratio"] != "16:9" || body["model_name"] != "kling-v1" {
+				resp.Code = 1201
+				resp.Message = "unexpected request"
+				break
+			}
+			resp.Data.TaskID = "k1"
+			resp.Data.TaskStatus = "submitted"
+		case "/v1/videos/text2video/k1":
+			resp.Data.TaskID = "k1"
+			resp.Data.TaskStatus = "processing"
+			if polls.Add(1) >= 2 {
+				resp.Data.TaskStatus = "succeed"
+				resp.Data.TaskResult.Videos = append(resp.Data.TaskResult.Videos, struct {
+					URL string `json:"url"`
+				}{srv.URL + "/out.mp4"})
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	kling, err := newKlingClient("kling-v1", "global", srv.URL, srv.Client(), time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, status, err := kling.generate(context.Background(), "a cat", 4, "1:1", ClipStyle{}, "ak:sk")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "kling-mp4" || status != http.StatusOK {
+		t.Errorf("Unexpected result %q (status %d)", data, status)
+	}
+	if _, status, _ := kling.generate(context.Background(), "a cat", 4, "1:1", ClipStyle{}, "no-secret"); status != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 for a malformed key, got %d", status)
+	}
+	if _, status, _ := kling.generate(context.Background(), "a cat", 8, "1:1", ClipStyle{}, "ak:sk"); status != http.StatusBadRequest {
+		t.Errorf("Expected a 400 for a rejected request, got %d", status)
+	}
+}
+
+func TestKlingClient_EnvelopeCodes(t *testing.T) {
+	tests := []struct {
+		code int
+		want int
+	}{
+		{1002, http.StatusUnauthorized},
+		{1302, http.StatusTooManyRequests},
+		{5001, http.StatusInternalServerError},
+		{1201, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(klingResponse{Code: tt.code, Message: "nope"})
+		}))
+		k := &klingClient{baseURL: srv.URL, httpClient: srv.Client(), now: time.Now}
+		var out klingResponse
+		if status, err := k.call(context.Background(), "GET", "/v1/videos/text2video/x", "ak:sk", nil, &out); err == nil || status != tt.want {
+			t.Errorf("Code %d: expected status %d with an error, got %d (%v)", tt.code, tt.want, status, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestKlingToken(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	token, err := klingToken("ak:sk", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected a three-part JWT, got %q", token)
+	}
+	mac := hmac.New(sha256.New, []byte("sk"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if parts[2] != base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Error("Signature does not match the secret key")
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iss string `json:"iss"`
+		Exp int64  `json:"exp"`
+	}
+	json.Unmarshal(raw, &claims)
+	if claims.Iss != "ak" || claims.Exp != now.Add(30*time.Minute).Unix() {
+		t.Errorf("Unexpected claims %s", raw)
+	}
+	if _, err := klingToken("ak", now); err == nil {
+		t.Error("Expected an error for a key without a secret")
+	}
+}
+
+func TestSetKling_Region(t *testing.T) {
+	vs := NewVideoService(utils.NewAPIKeyPool([]string{"pika"}), t.TempDir(), "5M", "1920x1080", 30, 0.5)
+	if err := vs.SetKling(utils.NewAPIKeyPool([]string{"ak:sk"}), "kling-v1", "eu", ""); err == nil {
+		t.Error("Expected an error for an unknown region")
+	}
+	if kling, err := newKlingClient("kling-v1", "cn", "", nil, time.Second); err != nil || kling.baseURL != klingRegionEndpoints["cn"] {
+		t.Errorf("Expected the cn endpoint, got %v", err)
+	}
+	if err := vs.SetKling(utils.NewAPIKeyPool([]string{"ak:sk"}), "kling-v1", "cn", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := vs.videoProvider(VideoProviderKling); err != nil {
+		t.Errorf("Expected kling to be configured: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultTenant owns the lexicon used when a request names no tenant
+const DefaultTenant = "default"
+
+// Lexicon limits
+const (
+	maxLexiconEntries = 2000
+	maxLexiconWordLen = 100
+)
+
+// LexiconStore keeps a pronunciation dictionary (word -> phonetic replacement) per tenant.
+// With a file path the dictionaries survive restarts; otherwise they live in memory.
+type LexiconStore struct {
+	mu      sync.RWMutex
+	tenants map[string]map[string]string
+	path    string
+}
+
+// NewLexiconStore loads the lexicons saved at path; an empty path keeps them in memory only
+func NewLexiconStore(path string) *LexiconStore {
+	ls := &LexiconStore{tenants: make(map[string]map[string]string), path: path}
+	if path == "" {
+		return ls
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[Lexicon] Could not read %s: %v", path, err)
+		}
+		return ls
+	}
+	if err := json.Unmarshal(data, &ls.tenants); err != nil {
+		log.Printf("[Lexicon] Ignoring malformed %s: %v", path, err)
+		ls.tenants = make(map[string]map[string]string)
+	}
+	return ls
+}
+
+// Get returns a copy of the tenant's lexicon
+func (ls *LexiconStore) Get(tenant string) map[string]string {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	entries := make(map[string]string, len(ls.tenants[tenant]))
+	for word, replacement := range ls.tenants[tenant] {
+		entries[word] = replacement
+	}
+	return entries
+}
+
+// Set replaces the tenant's lexicon; an empty map deletes it
+func (ls *LexiconStore) Set(tenant string, entries map[string]string) error {
+	if err := ValidateLexicon(entries); err != nil {
+		return err
+	}
+	cleaned := make(map[string]string, len(entries))
+	for word, replacement := range entries {
+		cleaned[strings.TrimSpace(word)] = strings.TrimSpace(replacement)
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if len(cleaned) == 0 {
+		delete(ls.tenants, tenant)
+	} else {
+		ls.tenants[tenant] = cleaned
+	}
+	return ls.save()
+}
+
+// save writes all lexicons atomically; the caller holds ls.mu
+func (ls *LexiconStore) save() error {
+	if ls.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ls.tenants, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ls.path), 0755); err != nil {
+		return fmt.Errorf("failed to save lexicon: %w", err)
+	}
+	tmp := ls.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save lexicon: %w", err)
+	}
+	return os.Rename(tmp, ls.path)
+}
+
+// ValidateLexicon checks entry count and that words and replacements are non-empty plain text
+func ValidateLexicon(entries map[string]string) error {
+	if len(entries) > maxLexiconEntries {
+		return fmt.Errorf("lexicon may have at most %d entries", maxLexiconEntries)
+	}
+	for word, replacement := range entries {
+		word, replacement = strings.TrimSpace(word), strings.TrimSpace(replacement)
+		if word == "" || replacement == "" {
+			return fmt.Errorf("lexicon words and replacements must not be empty")
+		}
+		if utf8.RuneCountInString(word) > maxLexiconWordLen || utf8.RuneCountInString(replacement) > maxLexiconWordLen {
+			return fmt.Errorf("lexicon entry %q exceeds %d characters", word, maxLexiconWordLen)
+		}
+		if strings.ContainsAny(word+replacement, "<>") {
+			return fmt.Errorf("lexicon entry %q must not contain markup", word)
+		}
+	}
+	return nil
+}
+
+// ApplyLexicon replaces whole-word, case-insensitive occurrences of the lexicon's words with their
+// pronunciations. Longer words win (e.g. "AI Studio" before "AI"); SSML tags are left untouched.
+func ApplyLexicon(text string, lexicon map[string]string) string {
+	if len(lexicon) == 0 || text == "" {
+		return text
+	}
+	words := make([]string, 0, len(lexicon))
+	replacements := make(map[string]string, len(lexicon))
+	for word, replacement := range lexicon {
+		lower := strings.ToLower(strings.TrimSpace(word))
+		if lower == "" {
+			continue
+		}
+		words = append(words, lower)
+		replacements[lower] = replacement
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+
+	var out strings.Builder
+	last := 0
+	for _, loc := range ssmlTagPattern.FindAllStringIndex(text, -1) {
+		out.WriteString(replaceWords(text[last:loc[0]], words, replacements))
+		out.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(replaceWords(text[last:], words, replacements))
+	return out.String()
+}
+
+// replaceWords scans text once, trying the (length-sorted) words at each word start
+func replaceWords(text string, words []string, replacements map[string]string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Case folding changed byte offsets (rare non-ASCII letters); fall back to exact-case matching
+		lower = text
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		if i == 0 || !isWordRune(lastRune(text[:i])) {
+			if word, ok := matchWordAt(lower, i, words); ok {
+				out.WriteString(replacements[word])
+				i += len(word)
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		out.WriteString(text[i : i+size])
+		i += size
+	}
+	return out.String()
+}
+
+func matchWordAt(lower string, i int, words []string) (string, bool) {
+	for _, word := range words {
+		if !strings.HasPrefix(lower[i:], word) {
+			continue
+		}
+		end := i + len(word)
+		if end < len(lower) {
+			if r, _ := utf8.DecodeRuneInString(lower[end:]); isWordRune(r) {
+				continue
+			}
+		}
+		return word, true
+	}
+	return "", false
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// isWordRune covers Vietnamese letters with diacritics, which regexp's \b does not
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyLexicon(t *testing.T) {
+	lexicon := map[string]string{
+		"AI":        "ây ai",
+		"AI Studio": "ây ai xờ tiu đi ô",
+		"NASA":      "na sa",
+		"phở":       "phơ",
+	}
+	tests := map[string]string{
+		"AI is here":                              "ây ai is here",
+		"Try AI Studio, then ai.":                 "Try ây ai xờ tiu đi ô, then ây ai.",
+		"SAID and MAIN stay":                      "SAID and MAIN stay",
+		"Ăn phở ở NASA":                           "Ăn phơ ở na sa",
+		"phởbò is one word":                       "phởbò is one word",
+		`<emphasis level="AI">AI</emphasis> wins`: `<emphasis level="AI">ây ai</emphasis> wins`,
+	}
+	for in, want := range tests {
+		if got := ApplyLexicon(in, lexicon); got != want {
+			t.Errorf("ApplyLexicon(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := ApplyLexicon("unchanged", nil); got != "unchanged" {
+		t.Errorf("Empty lexicon changed text: %q", got)
+	}
+}
+
+func TestValidateLexicon(t *testing.T) {
+	if err := ValidateLexicon(map[string]string{"AITuber": "ây ai tu bơ"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, bad := range []map[string]string{
+		{"": "x"},
+		{"x": " "},
+		{"x": `<break time="1s"/>`},
+	} {
+		if err := ValidateLexicon(bad); err == nil {
+			t.Errorf("Expected error for %v", bad)
+		}
+	}
+}
+
+func TestLexiconStore_PersistsPerTenant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lexicon.json")
+	store := NewLexiconStore(path)
+	if err := store.Set("acme", map[string]string{" AITuber ": "ây ai tu bơ"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(store.Get(DefaultTenant)) != 0 {
+		t.Error("Tenants should not share lexicons")
+	}
+
+	reloaded := NewLexiconStore(path)
+	if got := reloaded.Get("acme")["AITuber"]; got != "ây ai tu bơ" {
+		t.Errorf("Lexicon not persisted, got %q", got)
+	}
+	if err := reloaded.Set("acme", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(NewLexiconStore(path).Get("acme")) != 0 {
+		t.Error("Empty lexicon should clear the tenant")
+	}
+}
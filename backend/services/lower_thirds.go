@@ -0,0 +1,156 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Lower third limits
+const (
+	defaultLowerThirdSeconds = 5.0
+	maxLowerThirdSeconds     = 60.0
+	maxLowerThirds           = 50
+	maxLowerThirdLen         = 80 // characters per line
+)
+
+// lowerThirdsFileName is the ASS file of a job's lower thirds, burned in with the subtitles
+const lowerThirdsFileName = "lower_thirds.ass"
+
+var lowerThirdAnimations = map[string]bool{"": true, utils.LowerThirdFade: true, utils.LowerThirdSlide: true, utils.LowerThirdNone: true}
+
+// ValidateLowerThirds checks lower_thirds and lower_third_style; empty and zero style fields are
+// valid and keep the default
+func ValidateLowerThirds(items []models.LowerThird, style models.LowerThirdStyle) error {
+	if len(items) > maxLowerThirds {
+		return fmt.Errorf("lower_thirds may list at most %d titles", maxLowerThirds)
+	}
+	for i, lt := range items {
+		if strings.TrimSpace(lt.Text) == "" {
+			return fmt.Errorf("lower_thirds %d: text must not be empty", i+1)
+		}
+		if utf8.RuneCountInString(lt.Text) > maxLowerThirdLen || utf8.RuneCountInString(lt.Subtitle) > maxLowerThirdLen {
+			return fmt.Errorf("lower_thirds %d: text and subtitle may have at most %d characters", i+1, maxLowerThirdLen)
+		}
+		if lt.Start < 0 || lt.Duration < 0 || lt.Duration > maxLowerThirdSeconds {
+			return fmt.Errorf("lower_thirds %d: start must not be negative and duration must be between 0 and %.0f seconds", i+1, maxLowerThirdSeconds)
+		}
+	}
+
+	if utf8.RuneCountInString(style.Font) > maxSubtitleFontLen || strings.ContainsAny(style.Font, ",\n\r") {
+		return fmt.Errorf("lower_third_style font must be a font name of at most %d characters without commas", maxSubtitleFontLen)
+	}
+	if style.Size != 0 && (style.Size < minSubtitleFontSize || style.Size > maxSubtitleFontSize) {
+		return fmt.Errorf("lower_third_style size must be between %d and %d (got %d)", minSubtitleFontSize, maxSubtitleFontSize, style.Size)
+	}
+	for field, color := range map[string]string{"primary_color": style.PrimaryColor, "box_color": style.BoxColor} {
+		if color == "" {
+			continue
+		}
+		if _, err := utils.ASSColor(color); err != nil {
+			return fmt.Errorf("lower_third_style %s %q must be #RRGGBB", field, color)
+		}
+	}
+	if !lowerThirdAnimations[style.Animation] {
+		return fmt.Errorf("lower_third_style animation must be fade, slide or none (got %q)", style.Animation)
+	}
+	return nil
+}
+
+// lowerThirdStyle is the built-in look for orientation in the subtitles' font, overridden field
+// by field by the request's lower_third_style
+func (s *VideoWorkflowService) lowerThirdStyle(req models.GenerateRequest, orientation string) utils.LowerThirdStyle {
+	style := utils.DefaultLowerThirdStyle(orientation)
+	subtitles, _ := s.subtitleStyle(req, orientation)
+	style.Font = subtitles.Font
+
+	o := req.LowerThirdStyle
+	if o.Font = strings.TrimSpace(o.Font); o.Font != "" {
+		style.Font = o.Font
+	}
+	if o.Size > 0 {
+		style.Size = o.Size
+	}
+	if o.PrimaryColor != "" {
+		style.PrimaryColor = o.PrimaryColor
+	}
+	if o.BoxColor != "" {
+		style.BoxColor = o.BoxColor
+	}
+	if o.Animation != "" {
+		style.Animation = o.Animation
+	}
+	return style
+}
+
+// writeLowerThirds saves the lower thirds of the segments and of req.LowerThirds as
+// output/lower_thirds.ass, in narration time, and returns its path; "" when there are none or
+// it could not be written
+func (s *VideoWorkflowService) writeLowerThirds(jobID, tempDir string, req models.GenerateRequest, assets *models.RenderAssets) string {
+	var cues []utils.LowerThirdCue
+	if hasSegmentLowerThirds(assets.Segments) {
+		if starts, segments, end, err := s.narrationTimeline(assets, 0); err != nil {
+			log.Printf("[Job %s] Segment lower thirds skipped: %v", jobID, err)
+		} else {
+			cues = segmentLowerThirds(segments, starts, end)
+		}
+	}
+	for _, lt := range req.LowerThirds {
+		duration := lt.Duration
+		if duration <= 0 {
+			duration = defaultLowerThirdSeconds
+		}
+		cues = append(cues, utils.LowerThirdCue{Start: lt.Start, End: lt.Start + duration, Text: strings.TrimSpace(lt.Text), Subtitle: lt.Subtitle})
+	}
+	if len(cues) == 0 {
+		return ""
+	}
+
+	path := filepath.Join(tempDir, "output", lowerThirdsFileName)
+	if err := utils.WriteLowerThirdsASS(path, s.lowerThirdStyle(req, assets.Orientation), assets.Orientation, cues); err != nil {
+		log.Printf("[Job %s] Failed to write lower thirds: %v", jobID, err)
+		return ""
+	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("%d lower thirds written", len(cues)))
+	return path
+}
+
+// hasSegmentLowerThirds reports whether a segment has a lower third
+func hasSegmentLowerThirds(segments []models.VideoSegment) bool {
+	for _, seg := range segments {
+		if strings.TrimSpace(seg.LowerThird) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentLowerThirds shows each segment's lower third from its start, for at most
+// defaultLowerThirdSeconds and never past the segment. starts and end are as narrationTimeline
+// returns them.
+func segmentLowerThirds(segments []models.VideoSegment, starts []float64, end float64) []utils.LowerThirdCue {
+	var cues []utils.LowerThirdCue
+	for i, seg := range segments {
+		text := strings.TrimSpace(seg.LowerThird)
+		if text == "" {
+			continue
+		}
+		segEnd := end
+		if i+1 < len(starts) {
+			segEnd = starts[i+1]
+		}
+		title, subtitle, _ := strings.Cut(text, "\n")
+		cues = append(cues, utils.LowerThirdCue{
+			Start:    starts[i],
+			End:      math.Min(starts[i]+defaultLowerThirdSeconds, segEnd),
+			Text:     strings.TrimSpace(title),
+			Subtitle: strings.TrimSpace(subtitle),
+		})
+	}
+	return cues
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateLowerThirds(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []models.LowerThird
+		style   models.LowerThirdStyle
+		wantErr bool
+	}{
+		{"None", nil, models.LowerThirdStyle{}, false},
+		{"Speaker", []models.LowerThird{{Text: "Jane Doe", Subtitle: "Host", Start: 3, Duration: 4}}, models.LowerThirdStyle{Font: "Inter", Size: 60, BoxColor: "#0A0A0A", Animation: "slide"}, false},
+		{"Empty text", []models.LowerThird{{Text: " "}}, models.LowerThirdStyle{}, true},
+		{"Text too long", []models.LowerThird{{Text: strings.Repeat("x", maxLowerThirdLen+1)}}, models.LowerThirdStyle{}, true},
+		{"Negative start", []models.LowerThird{{Text: "ok", Start: -1}}, models.LowerThirdStyle{}, true},
+		{"Too long on screen", []models.LowerThird{{Text: "ok", Duration: 120}}, models.LowerThirdStyle{}, true},
+		{"Too many", make([]models.LowerThird, maxLowerThirds+1), models.LowerThirdStyle{}, true},
+		{"Bad color", nil, models.LowerThirdStyle{PrimaryColor: "white"}, true},
+		{"Bad animation", nil, models.LowerThirdStyle{Animation: "spin"}, true},
+		{"Font with comma", nil, models.LowerThirdStyle{Font: "Inter,Bold"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLowerThirds(tt.items, tt.style); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLowerThirds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSegmentLowerThirds(t *testing.T) {
+	segments := []models.VideoSegment{
+		{Text: "One", LowerThird: "Jane Doe\nHost"},
+		{Text: "Two"},
+		{Text: "Three", LowerThird: " Guest "},
+	}
+	got := segmentLowerThirds(segments, []float64{0, 12, 15}, 18)
+	want := []utils.LowerThirdCue{
+		{Start: 0, End: 5, Text: "Jane Doe", Subtitle: "Host"},
+		{Start: 15, End: 18, Text: "Guest"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("segmentLowerThirds() = %+v, want %+v", got, want)
+	}
+	if !hasSegmentLowerThirds(segments) || hasSegmentLowerThirds(segments[1:2]) {
+		t.Error("hasSegmentLowerThirds() mismatch")
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"aituber/models"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeadingPattern = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdBulletPattern  = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	mdImagePattern   = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdLinkPattern    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdEmphasis       = strings.NewReplacer("**", "", "__", "", "`", "", "*", "", "~~", "")
+)
+
+// ParseMarkdownSlides turns a Markdown script into one segment per slide: every heading starts a
+// slide titled by it, and the paragraphs and list items below it are both shown on the slide and
+// narrated. A slide with nothing below its heading narrates the heading. Text before the first
+// heading becomes an untitled slide.
+func ParseMarkdownSlides(markdown string) []models.VideoSegment {
+	var segments []models.VideoSegment
+	var title string
+	var lines []string
+	started := false
+	flush := func() {
+		if !started {
+			return
+		}
+		var spoken []string
+		for _, line := range lines {
+			spoken = append(spoken, strings.TrimPrefix(line, "• "))
+		}
+		text := strings.Join(spoken, " ")
+		if text == "" {
+			text = title
+		}
+		if text != "" {
+			segments = append(segments, models.VideoSegment{
+				Text:       text,
+				SlideTitle: title,
+				SlideBody:  strings.Join(lines, "\n"),
+			})
+		}
+	}
+
+	for _, raw := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if m := mdHeadingPattern.FindStringSubmatch(raw); m != nil {
+			flush()
+			title, lines, started = stripInlineMarkdown(m[1]), nil, true
+			continue
+		}
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "```") {
+			continue
+		}
+		started = true
+		if mdBulletPattern.MatchString(raw) {
+			lines = append(lines, "• "+stripInlineMarkdown(mdBulletPattern.ReplaceAllString(raw, "")))
+		} else {
+			lines = append(lines, stripInlineMarkdown(strings.TrimLeft(line, "> ")))
+		}
+	}
+	flush()
+	return segments
+}
+
+// stripInlineMarkdown drops images, link targets and emphasis markers from one line
+func stripInlineMarkdown(s string) string {
+	s = mdImagePattern.ReplaceAllString(s, "")
+	s = mdLinkPattern.ReplaceAllString(s, "$1")
+	return strings.TrimSpace(mdEmphasis.Replace(s))
+}
+
+// wrapSlideText breaks each line of text at word boundaries so none is longer than width runes;
+// continuation lines of a bullet are indented under its text
+func wrapSlideText(text string, width int) string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		indent := ""
+		if strings.HasPrefix(line, "• ") {
+			indent = "  "
+		}
+		current := ""
+		for _, word := range strings.Fields(line) {
+			switch {
+			case current == "":
+				current = word
+			case len([]rune(current))+1+len([]rune(word)) > width:
+				out = append(out, current)
+				current = indent + word
+			default:
+				current += " " + word
+			}
+		}
+		out = append(out, current)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"aituber/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdownSlides(t *testing.T) {
+	markdown := "Welcome to the show.\r\n\r\n# Why **Go**?\n\nIt compiles [fast](https://go.dev).\n\n- Simple syntax\n* Great `tooling`\n\n## Summary ##\n\n---\n# Thanks"
+	want := []models.VideoSegment{
+		{Text: "Welcome to the show.", SlideBody: "Welcome to the show."},
+		{
+			Text:       "It compiles fast. Simple syntax Great tooling",
+			SlideTitle: "Why Go?",
+			SlideBody:  "It compiles fast.\n• Simple syntax\n• Great tooling",
+		},
+		{Text: "Summary", SlideTitle: "Summary"},
+		{Text: "Thanks", SlideTitle: "Thanks"},
+	}
+	if got := ParseMarkdownSlides(markdown); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMarkdownSlides() =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := ParseMarkdownSlides("\n\n"); len(got) != 0 {
+		t.Errorf("Expected no slides for empty script, got %+v", got)
+	}
+}
+
+func TestWrapSlideText(t *testing.T) {
+	got := wrapSlideText("Short line\n• a bullet that needs wrapping", 14)
+	want := "Short line\n• a bullet\n  that needs\n  wrapping"
+	if got != want {
+		t.Errorf("wrapSlideText() =\n%s\nwant\n%s", got, want)
+	}
+	if got := wrapSlideText("Xin chào các bạn", 9); got != "Xin chào\ncác bạn" {
+		t.Errorf("Expected wrapping by runes, got %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MusicLibraryDir holds the bundled background tracks. tracks.json describes them
+// (id, title, file, mood, bpm, duration, license); audio files it does not list are
+// still offered, with the file name as ID and the duration probed.
+var MusicLibraryDir = filepath.Join(staticVideoDir, "music")
+
+const musicManifest = "tracks.json"
+
+var musicExtensions = map[string]bool{".mp3": true, ".m4a": true, ".aac": true, ".wav": true, ".ogg": true}
+
+// ListMusicTracks returns the tracks of dir sorted by ID; a missing dir is an empty library
+func ListMusicTracks(dir string) ([]models.MusicTrack, error) {
+	var tracks []models.MusicTrack
+	listed := make(map[string]bool)
+
+	if data, err := os.ReadFile(filepath.Join(dir, musicManifest)); err == nil {
+		var manifest []models.MusicTrack
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", musicManifest, err)
+		}
+		for _, track := range manifest {
+			track.File = filepath.Base(track.File)
+			if track.ID == "" || !utils.FileExists(filepath.Join(dir, track.File)) {
+				log.Printf("[Music] Skipping manifest entry %q: missing id or file %q", track.ID, track.File)
+				continue
+			}
+			if track.Title == "" {
+				track.Title = track.ID
+			}
+			listed[track.File] = true
+			tracks = append(tracks, track)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || listed[name] || !musicExtensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		id := strings.TrimSuffix(name, filepath.Ext(name))
+		track := models.MusicTrack{ID: id, Title: id, File: name}
+		if d, err := utils.GetAudioDuration(filepath.Join(dir, name)); err == nil {
+			track.Duration = d
+		}
+		tracks = append(tracks, track)
+	}
+
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].ID < tracks[j].ID })
+	return tracks, nil
+}
+
+// ResolveMusicTrack returns the file of track id in the bundled library
+func ResolveMusicTrack(id string) (string, error) {
+	tracks, err := ListMusicTracks(MusicLibraryDir)
+	if err != nil {
+		return "", err
+	}
+	for _, track := range tracks {
+		if track.ID == id {
+			return filepath.Join(MusicLibraryDir, track.File), nil
+		}
+	}
+	return "", fmt.Errorf("music_track %q not found (see GET /api/music)", id)
+}
+
+// MusicTrackForMood returns the ID of the first track of dir tagged with mood (case-insensitive)
+func MusicTrackForMood(dir, mood string) (string, bool) {
+	tracks, err := ListMusicTracks(dir)
+	if err != nil {
+		log.Printf("[Music] Could not list %s: %v", dir, err)
+		return "", false
+	}
+	for _, track := range tracks {
+		if track.Mood != "" && strings.EqualFold(track.Mood, mood) {
+			return track.ID, true
+		}
+	}
+	return "", false
+}
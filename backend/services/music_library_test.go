@@ -0,0 +1,51 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListMusicTracks(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"calm_piano.mp3", "extra.wav", "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("audio"), 0644)
+	}
+	manifest := `[
+		{"id": "calm-piano", "title": "Calm Piano", "file": "calm_piano.mp3", "mood": "calm", "bpm": 72, "duration": 95.5},
+		{"id": "missing", "file": "missing.mp3"}
+	]`
+	os.WriteFile(filepath.Join(dir, musicManifest), []byte(manifest), 0644)
+
+	tracks, err := ListMusicTracks(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %+v", tracks)
+	}
+	if tracks[0].ID != "calm-piano" || tracks[0].Mood != "calm" || tracks[0].BPM != 72 || tracks[0].Duration != 95.5 {
+		t.Errorf("Unexpected manifest track %+v", tracks[0])
+	}
+	if tracks[1].ID != "extra" || tracks[1].File != "extra.wav" {
+		t.Errorf("Unlisted audio file should be offered by name, got %+v", tracks[1])
+	}
+
+	if tracks, err := ListMusicTracks(filepath.Join(dir, "nope")); err != nil || len(tracks) != 0 {
+		t.Errorf("Missing dir should be an empty library, got %v, %v", tracks, err)
+	}
+}
+
+func TestResolveMusicTrack(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lofi.mp3"), []byte("audio"), 0644)
+	defer func(old string) { MusicLibraryDir = old }(MusicLibraryDir)
+	MusicLibraryDir = dir
+
+	if path, err := ResolveMusicTrack("lofi"); err != nil || path != filepath.Join(dir, "lofi.mp3") {
+		t.Errorf("ResolveMusicTrack = %q, %v", path, err)
+	}
+	if _, err := ResolveMusicTrack("../lofi"); err == nil {
+		t.Error("Expected error for unknown track")
+	}
+}
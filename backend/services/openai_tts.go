@@ -0,0 +1,93 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+)
+
+const openAIAPIBase = "https://api.openai.com"
+
+// openAIVoices are the built-in voices of the speech endpoint
+var openAIVoices = map[string]bool{
+	"alloy": true, "ash": true, "coral": true, "echo": true, "fable": true,
+	"onyx": true, "nova": true, "sage": true, "shimmer": true,
+}
+
+// openAITTS is the OpenAI speech backend. It is synchronous: the MP3 comes back in the
+// response body, so there is nothing to poll or download.
+type openAITTS struct {
+	pool       *utils.APIKeyPool
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOpenAITTS creates the OpenAI provider for model (e.g. "tts-1"). baseURL may point at an
+// OpenAI-compatible server; empty uses api.openai.com.
+func NewOpenAITTS(pool *utils.APIKeyPool, model, baseURL string) TTSProvider {
+	if baseURL == "" {
+		baseURL = openAIAPIBase
+	}
+	return &openAITTS{pool: pool, model: model, baseURL: strings.TrimRight(baseURL, "/"), httpClient: newTTSHTTPClient()}
+}
+
+// Synthesize calls /v1/audio/speech with the voice mapped from OpenAI or FPT names
+func (o *openAITTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"model":           o.model,
+		"input":           text,
+		"voice":           mapToOpenAIVoice(voice),
+		"speed":           openAISpeed(speed),
+		"response_format": "mp3",
+	})
+	return withAPIKey(ctx, o.pool, "OpenAI", func(apiKey string) ([]byte, int, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/v1/audio/speech", bytes.NewReader(payload))
+		if err != nil {
+			return nil, 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+
+		resp, err := o.httpClient.Do(req)
+		if err != nil {
+			return nil, 0, fmt.Errorf("request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, resp.StatusCode, fmt.Errorf("OpenAI API returned %d: %s", resp.StatusCode, readErrorBody(resp))
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read audio: %w", err)
+		}
+		return data, resp.StatusCode, nil
+	})
+}
+
+// mapToOpenAIVoice keeps OpenAI voice names; FPT names map to a male or female default
+func mapToOpenAIVoice(voice string) string {
+	v := strings.ToLower(voice)
+	if openAIVoices[v] {
+		return v
+	}
+	if isFPTMaleVoice(v) {
+		return "onyx"
+	}
+	return "nova"
+}
+
+// openAISpeed clamps speed to the 0.25-4.0 range the endpoint accepts
+func openAISpeed(speed float64) float64 {
+	if speed <= 0 {
+		return 1.0
+	}
+	return math.Max(0.25, math.Min(4.0, speed))
+}
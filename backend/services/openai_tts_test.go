@@ -0,0 +1,57 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAITTS_Synthesize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" || r.Header.Get("Authorization") != "Bearer openai-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["voice"] != "onyx" || body["model"] != "tts-1" || body["speed"] != 1.2 || body["input"] != "xin chào" {
+			http.Error(w, "unexpected payload", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ID3-openai"))
+	}))
+	defer srv.Close()
+
+	provider := NewOpenAITTS(utils.NewAPIKeyPool([]string{"openai-key"}), "tts-1", srv.URL+"/")
+	data, err := provider.Synthesize(context.Background(), "xin chào", "minhquang", 1.2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "ID3-openai" {
+		t.Errorf("Unexpected audio %q", data)
+	}
+
+	// A rejected key is parked so the next attempt fails fast with a retryable error
+	bad := NewOpenAITTS(utils.NewAPIKeyPool([]string{"bad-key"}), "tts-1", srv.URL)
+	if _, err := bad.Synthesize(context.Background(), "x", "nova", 1.0); err == nil {
+		t.Fatal("Expected error for a rejected key")
+	}
+	if _, err := bad.Synthesize(context.Background(), "x", "nova", 1.0); err == nil {
+		t.Error("Expected the parked key to be unavailable")
+	}
+}
+
+func TestMapToOpenAIVoice(t *testing.T) {
+	tests := map[string]string{"Shimmer": "shimmer", "minhquang": "onyx", "banmai": "nova", "unknown": "nova"}
+	for in, want := range tests {
+		if got := mapToOpenAIVoice(in); got != want {
+			t.Errorf("mapToOpenAIVoice(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if openAISpeed(0) != 1.0 || openAISpeed(10) != 4.0 {
+		t.Error("Unexpected speed clamping")
+	}
+}
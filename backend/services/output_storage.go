@@ -0,0 +1,219 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Output storage backends (STORAGE_BACKEND)
+const (
+	StorageS3  = "s3"
+	StorageGCS = "gcs"
+)
+
+// OutputStorage keeps finished videos and their artifacts off the render host, so they can be
+// served from there once the job's temp files are gone
+type OutputStorage interface {
+	// Upload stores the file at localPath as key and returns the URL it is served from
+	Upload(ctx context.Context, key, localPath string) (string, error)
+	// SignedURL is a URL that serves key to anyone until ttl from now (at most 7 days)
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// MaxSignedURLTTL is the longest S3 and GCS accept a signed URL for
+const MaxSignedURLTTL = 7 * 24 * time.Hour
+
+// StorageKey is the object key of a job's output file name under prefix (STORAGE_PREFIX)
+func StorageKey(prefix, jobID, name string) string {
+	return path.Join(prefix, jobID, name)
+}
+
+// storageUploadTimeout bounds one object upload; a long 4K render is a few GB
+const storageUploadTimeout = 30 * time.Minute
+
+// S3Storage uploads to an S3 bucket, or one of an S3-compatible server such as MinIO, with
+// single SigV4-signed PUTs (objects up to 5 GB)
+type S3Storage struct {
+	creds      utils.AWSCredentials
+	region     string
+	bucketURL  string // objects are PUT to bucketURL/key
+	publicURL  string // and served from publicURL/key
+	httpClient *http.Client
+}
+
+// NewS3Storage creates the storage of bucket. endpoint is an S3-compatible server's base URL,
+// whose buckets are addressed path-style, or empty for AWS; publicURL is where the objects are
+// served from (a CDN or public bucket), empty for the bucket URL.
+func NewS3Storage(creds utils.AWSCredentials, region, bucket, endpoint, publicURL string) (*S3Storage, error) {
+	bucketURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	if endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+		}
+		bucketURL = strings.TrimRight(endpoint, "/") + "/" + bucket
+	}
+	if publicURL == "" {
+		publicURL = bucketURL
+	}
+	return &S3Storage{
+		creds:      creds,
+		region:     region,
+		bucketURL:  bucketURL,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		httpClient: &http.Client{Timeout: storageUploadTimeout},
+	}, nil
+}
+
+// Upload PUTs the file at localPath as key
+func (s *S3Storage) Upload(ctx context.Context, key, localPath string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	escaped := escapeObjectKey(key)
+	req, err := http.NewRequestWithContext(ctx, "PUT", s.bucketURL+"/"+escaped, file)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", objectContentType(localPath))
+	// The body is streamed from disk, so it is not hashed into the signature
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	utils.SignAWSRequest(req, nil, s.creds, s.region, "s3", time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", parseS3Error(resp)
+	}
+	return s.publicURL + "/" + escaped, nil
+}
+
+// SignedURL presigns a GET of key on the bucket, bypassing S3_PUBLIC_URL
+func (s *S3Storage) SignedURL(key string, ttl time.Duration) (string, error) {
+	u, err := url.Parse(s.bucketURL + "/" + escapeObjectKey(key))
+	if err != nil {
+		return "", err
+	}
+	return utils.PresignAWSURL("GET", u, s.creds, s.region, "s3", time.Now(), ttl).String(), nil
+}
+
+// parseS3Error reads the code and message of an S3 XML error response
+func parseS3Error(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(raw, &body) != nil || body.Code == "" {
+		return fmt.Errorf("S3 returned %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return fmt.Errorf("S3 returned %d %s: %s", resp.StatusCode, body.Code, body.Message)
+}
+
+// escapeObjectKey percent-encodes each segment of key. Keys are built from STORAGE_PREFIX, job
+// IDs and output file names, all plain ASCII, so this matches SigV4's canonical encoding.
+func escapeObjectKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// objectContentType is the Content-Type an output file is served with
+func objectContentType(name string) string {
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".mp4", ".mov", ".webm", ".mkv":
+		return utils.VideoContentType(name)
+	case ".srt":
+		return "application/x-subrip"
+	case ".vtt":
+		return "text/vtt"
+	case ".ass":
+		return "text/x-ssa"
+	case ".txt":
+		return "text/plain; charset=utf-8"
+	default:
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+		return "application/octet-stream"
+	}
+}
+
+// storedArtifacts are the output files uploaded with the video: what the job status lists as
+// artifacts
+var storedArtifacts = []string{
+	"subtitles.srt", "subtitles.vtt", "subtitles.ass", "subtitles.*.srt", "subtitles.*.vtt",
+	PreviewFileName, PreviewGIFFileName, "thumbnail.jpg", TranscriptFileName, ChaptersFileName,
+}
+
+// SetOutputStorage uploads each finished job's files to storage
+func (s *VideoWorkflowService) SetOutputStorage(storage OutputStorage) {
+	s.storage = storage
+}
+
+// uploadOutputs uploads videoPath and the artifacts in tempDir/output as
+// STORAGE_PREFIX/<job_id>/<file name> and records their URLs on the job. A file that fails is
+// logged and stays served from disk; with STORAGE_DELETE_LOCAL the job's temp files are removed
+// once everything is uploaded.
+func (s *VideoWorkflowService) uploadOutputs(jobID, tempDir, videoPath string) {
+	files := []string{videoPath}
+	for _, pattern := range storedArtifacts {
+		matches, _ := filepath.Glob(filepath.Join(tempDir, "output", pattern))
+		files = append(files, matches...)
+	}
+
+	urls := make(map[string]string, len(files))
+	failed := false
+	for _, file := range files {
+		name := filepath.Base(file)
+		if _, done := urls[name]; done {
+			continue
+		}
+		objectURL, err := s.storage.Upload(context.Background(), StorageKey(s.cfg.StoragePrefix, jobID, name), file)
+		if err != nil {
+			log.Printf("[Job %s] Failed to upload %s: %v", jobID, name, err)
+			s.jobManager.LogEvent(jobID, fmt.Sprintf("Upload of %s failed, serving it from disk: %v", name, err))
+			failed = true
+			continue
+		}
+		urls[name] = objectURL
+	}
+	if len(urls) > 0 {
+		s.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+			j.StoredURLs = urls
+		})
+		log.Printf("[Job %s] Uploaded %d files to output storage", jobID, len(urls))
+	}
+
+	if s.cfg.StorageDeleteLocal && !failed {
+		if err := utils.CleanupJobFiles(s.cfg.TempDir, jobID); err != nil {
+			log.Printf("[Job %s] Failed to remove temp files after upload: %v", jobID, err)
+		}
+	}
+}
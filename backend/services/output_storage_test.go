@@ -0,0 +1,144 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestS3Storage_Upload(t *testing.T) {
+	var gotPath, gotType, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if r.Method != "PUT" || !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+			return
+		}
+		if r.Header.Get("X-Amz-Content-Sha256") != "UNSIGNED-PAYLOAD" {
+			t.Errorf("Expected an unsigned payload, got %q", r.Header.Get("X-Amz-Content-Sha256"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotType, gotBody = r.URL.Path, r.Header.Get("Content-Type"), string(body)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "final.mp4")
+	os.WriteFile(file, []byte("video"), 0644)
+
+	storage, err := NewS3Storage(utils.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "eu-west-1", "renders", server.URL+"/", "")
+	if err != nil {
+		t.Fatalf("NewS3Storage() error: %v", err)
+	}
+	url, err := storage.Upload(context.Background(), "out/job1/final.mp4", file)
+	if err != nil {
+		t.Fatalf("Upload() error: %v", err)
+	}
+	if url != server.URL+"/renders/out/job1/final.mp4" {
+		t.Errorf("Unexpected object URL %q", url)
+	}
+	if gotPath != "/renders/out/job1/final.mp4" || gotType != "video/mp4" || gotBody != "video" {
+		t.Errorf("Unexpected upload %s %q %q", gotPath, gotType, gotBody)
+	}
+
+	denied, _ := NewS3Storage(utils.AWSCredentials{AccessKeyID: "OTHER", SecretAccessKey: "secret"}, "eu-west-1", "renders", server.URL, "")
+	if _, err := denied.Upload(context.Background(), "job1/final.mp4", file); err == nil || !strings.Contains(err.Error(), "403 AccessDenied: Access Denied") {
+		t.Errorf("Expected the S3 error code, got %v", err)
+	}
+}
+
+func TestNewS3Storage_URLs(t *testing.T) {
+	creds := utils.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	aws, _ := NewS3Storage(creds, "us-east-1", "renders", "", "")
+	if aws.bucketURL != "https://renders.s3.us-east-1.amazonaws.com" || aws.publicURL != aws.bucketURL {
+		t.Errorf("Unexpected AWS URLs %q %q", aws.bucketURL, aws.publicURL)
+	}
+	cdn, _ := NewS3Storage(creds, "us-east-1", "renders", "", "https://cdn.example.com/")
+	if cdn.publicURL != "https://cdn.example.com" {
+		t.Errorf("Unexpected public URL %q", cdn.publicURL)
+	}
+	if _, err := NewS3Storage(creds, "us-east-1", "renders", "minio:9000", ""); err == nil {
+		t.Error("Expected an endpoint without scheme to be rejected")
+	}
+}
+
+// fakeStorage records uploaded keys; keys in fail are rejected
+type fakeStorage struct {
+	keys []string
+	fail map[string]bool
+}
+
+func (f *fakeStorage) Upload(ctx context.Context, key, localPath string) (string, error) {
+	if f.fail[key] {
+		return "", errors.New("upload rejected")
+	}
+	f.keys = append(f.keys, key)
+	return "https://cdn.example.com/" + key, nil
+}
+
+func (f *fakeStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	return fmt.Sprintf("https://bucket.example.com/%s?expires=%d", key, int(ttl.Seconds())), nil
+}
+
+func TestUploadOutputs(t *testing.T) {
+	tempRoot := t.TempDir()
+	outputDir := filepath.Join(tempRoot, "job1", "output")
+	os.MkdirAll(outputDir, 0755)
+	for _, name := range []string{"final.mp4", "subtitles.srt", "subtitles.ja.vtt", "subtitles_burn.ass", "segments_concat.mp4", "thumbnail.jpg", ChaptersFileName} {
+		os.WriteFile(filepath.Join(outputDir, name), []byte(name), 0644)
+	}
+
+	jm := NewJobManager()
+	jm.CreateJob("job1", "youtube", "test")
+	storage := &fakeStorage{fail: map[string]bool{"renders/job1/chapters.txt": true}}
+	s := &VideoWorkflowService{cfg: &config.Config{TempDir: tempRoot, StoragePrefix: "renders/", StorageDeleteLocal: true}, jobManager: jm}
+	s.SetOutputStorage(storage)
+
+	s.uploadOutputs("job1", filepath.Join(tempRoot, "job1"), filepath.Join(outputDir, "final.mp4"))
+	want := []string{"renders/job1/final.mp4", "renders/job1/subtitles.srt", "renders/job1/subtitles.ja.vtt", "renders/job1/thumbnail.jpg"}
+	if !reflect.DeepEqual(storage.keys, want) {
+		t.Errorf("Uploaded %v, want %v", storage.keys, want)
+	}
+	job, _ := jm.GetJob("job1")
+	if len(job.StoredURLs) != 4 || job.StoredURLs["final.mp4"] != "https://cdn.example.com/renders/job1/final.mp4" {
+		t.Errorf("Unexpected stored URLs %v", job.StoredURLs)
+	}
+	if _, err := os.Stat(outputDir); err != nil {
+		t.Error("Expected the temp files to be kept when an upload failed")
+	}
+
+	delete(storage.fail, "renders/job1/chapters.txt")
+	s.uploadOutputs("job1", filepath.Join(tempRoot, "job1"), filepath.Join(outputDir, "final.mp4"))
+	if _, err := os.Stat(filepath.Join(tempRoot, "job1")); !os.IsNotExist(err) {
+		t.Error("Expected the temp files to be removed once everything was uploaded")
+	}
+}
+
+func TestS3Storage_SignedURL(t *testing.T) {
+	creds := utils.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	storage, _ := NewS3Storage(creds, "eu-west-1", "renders", "http://minio:9000", "https://cdn.example.com")
+
+	signed, err := storage.SignedURL("out/job1/final.mp4", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error: %v", err)
+	}
+	u, _ := url.Parse(signed)
+	if u.Host != "minio:9000" || u.Path != "/renders/out/job1/final.mp4" {
+		t.Errorf("Expected the bucket URL rather than the public one, got %s", signed)
+	}
+	if q := u.Query(); q.Get("X-Amz-Expires") != "3600" || !strings.HasPrefix(q.Get("X-Amz-Credential"), "AKID/") || len(q.Get("X-Amz-Signature")) != 64 {
+		t.Errorf("Unexpected presigned query %v", q)
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// pexelsStock is the Pexels video library, the default stock provider
+type pexelsStock struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// SearchVideos implements StockProvider, picking the best-quality file of each video.
+// orientation: "landscape", "portrait", or "square"
+func (p *pexelsStock) SearchVideos(ctx context.Context, keywords string, page, perPage int, orientation string) ([]StockClip, error) {
+	params := url.Values{}
+	params.Add("query", keywords)
+	params.Add("page", fmt.Sprintf("%d", page))
+	params.Add("per_page", fmt.Sprintf("%d", perPage))
+	params.Add("orientation", orientation)
+
+	var result PexelsVideoResponse
+	if err := p.get(ctx, "https://api.pexels.com/videos/search", params, &result); err != nil {
+		return nil, err
+	}
+
+	type scoredVideo struct {
+		info  StockClip
+		score int
+	}
+	var scoredInfos []scoredVideo
+
+	for _, video := range result.Videos {
+		if video.Duration < 3 || video.Duration > 60 {
+			continue
+		}
+		bestLink, bestScore := "", 0
+		for _, file := range video.VideoFiles {
+			score := renditionScore(file.Width, file.Height, file.Quality, orientation)
+			if score > bestScore {
+				bestScore = score
+				bestLink = file.Link
+			}
+		}
+		if bestLink != "" {
+			// Apply duration penalty: subtract points for longer videos
+			finalScore := bestScore - (video.Duration * 10)
+
+			// Massive bonus for ideal generative duration (5s - 15s)
+			if video.Duration >= 5 && video.Duration <= 15 {
+				finalScore += 5000
+			}
+
+			// Check and exclude heavily penalized / used URLs logic here, or just let 'used' check at download phase.
+			// The penalty phase runs globally. But we already filter at download phase! So it's fine.
+
+			scoredInfos = append(scoredInfos, scoredVideo{
+				info:  StockClip{ID: fmt.Sprintf("pexels:%d", video.ID), Link: bestLink, Duration: video.Duration, Source: StockProviderPexels, Tags: append([]string{video.URL}, video.Tags...)},
+				score: finalScore,
+			})
+		}
+	}
+
+	// Sort by highest score first
+	sort.Slice(scoredInfos, func(i, j int) bool {
+		return scoredInfos[i].score > scoredInfos[j].score
+	})
+
+	var infos []StockClip
+	for _, si := range scoredInfos {
+		infos = append(infos, si.info)
+	}
+
+	return infos, nil
+}
+
+// renditionScore rates one file of a Pexels video for orientation: an exact 1920x1080 (or
+// 1080x1920 for portrait) frame first, then other 16:9 files by size, with a bonus for 4K
+// sources, which downscale to 1080p ultra-sharp
+func renditionScore(width, height int, quality, orientation string) int {
+	long, short := width, height
+	if orientation == "portrait" {
+		long, short = height, width
+	}
+	ar := 0.0
+	if short > 0 {
+		ar = float64(long) / float64(short)
+	}
+	is169 := ar > 1.77 && ar < 1.79
+	score := 1
+	switch {
+	case long == 1920 && short == 1080:
+		score = 10000
+	case is169 && long >= 1280:
+		score = 5000
+	case is169:
+		score = 1000
+	case quality == "hd":
+		score = 500
+	}
+	if quality == "uhd" || width >= 3840 || height >= 3840 {
+		score += 3000
+	}
+	return score + long // bigger along the frame's long edge is better
+}
+
+// get calls a Pexels search endpoint, retrying transport errors and rate limits, and
+// decodes the JSON response into out
+func (p *pexelsStock) get(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", p.apiKey)
+
+	var resp *http.Response
+	var lastErr error
+	maxRetries := 3
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt*2) * time.Second)
+		}
+
+		resp, err = p.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("pexels API rate limited (429)")
+			time.Sleep(3 * time.Second) // Extra backoff
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("pexels API returned status %d", resp.StatusCode)
+			continue
+		}
+
+		// Success
+		break
+	}
+
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pexels search failed after %d retries: %v", maxRetries, lastErr)
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
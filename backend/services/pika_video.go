@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const pikaAPIBase = "https://api.pika.art"
+
+// pikaClient is the Pika Labs backend, the default video provider. It renders the requested
+// duration directly, up to its 10 second limit.
+type pikaClient struct {
+	baseURL      string
+	resolution   string
+	httpClient   *http.Client
+	pollInterval time.Duration
+}
+
+// PikaVideoRequest represents video generation request
+type PikaVideoRequest struct {
+	Prompt      string  `json:"prompt"`
+	Duration    float64 `json:"duration,omitempty"`
+	Resolution  string  `json:"resolution,omitempty"`
+	AspectRatio string  `json:"aspect_ratio,omitempty"` // "16:9" or "9:16"
+
+	Seed           int64  `json:"seed,omitempty"`
+	NegativePrompt string `json:"negative_prompt,omitempty"`
+	StyleImage     string `json:"style_image,omitempty"` // data URI
+}
+
+// PikaVideoResponse represents video generation response
+type PikaVideoResponse struct {
+	JobID    string `json:"job_id,omitempty"`
+	Status   string `json:"status,omitempty"`
+	VideoURL string `json:"video_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// pikaCapabilities describes what Pika renders at the configured landscape resolution
+func pikaCapabilities(resolution string) VideoGenCapabilities {
+	return VideoGenCapabilities{
+		MaxDuration: 10,
+		Resolutions: map[string]string{"16:9": resolution, "9:16": swapResolution(resolution)},
+	}
+}
+
+// swapResolution turns "WxH" into "HxW"
+func swapResolution(resolution string) string {
+	w, h, ok := strings.Cut(resolution, "x")
+	if !ok {
+		return resolution
+	}
+	return h + "x" + w
+}
+
+// SetPikaURL points the client at another Pika-compatible endpoint; empty keeps the default
+func (vs *VideoService) SetPikaURL(url string) {
+	if url == "" {
+		url = pikaAPIBase
+	}
+	pika := &pikaClient{
+		baseURL:      strings.TrimRight(url, "/"),
+		resolution:   vs.resolution,
+		httpClient:   vs.httpClient,
+		pollInterval: vs.pollInterval,
+	}
+	vs.RegisterVideoProvider(VideoProviderPika, newPooledVideoProvider(VideoProviderPika, vs.apiPool, pika.generate, pikaCapabilities(vs.resolution), vs.tempDir))
+}
+
+// generate runs one Pika generation: submit the prompt, poll the job until it finishes, then
+// download the MP4
+func (p *pikaClient) generate(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle, apiKey string) ([]byte, int, error) {
+	reqBody, _ := json.Marshal(PikaVideoRequest{
+		Prompt:         prompt,
+		Duration:       duration,
+		Resolution:     p.resolution,
+		AspectRatio:    aspect,
+		Seed:           style.Seed,
+		NegativePrompt: style.NegativePrompt,
+		StyleImage:     style.StyleImage,
+	})
+	var job PikaVideoResponse
+	if status, err := p.call(ctx, "POST", "/v1/generate", apiKey, reqBody, &job); err != nil {
+		return nil, status, fmt.Errorf("pika submit failed: %w", err)
+	}
+	if job.JobID == "" && job.VideoURL == "" {
+		return nil, 0, fmt.Errorf("pika returned no job_id")
+	}
+
+	for job.VideoURL == "" {
+		switch strings.ToLower(job.Status) {
+		case "failed", "error", "cancelled":
+			return nil, 0, fmt.Errorf("pika job %s failed: %s", job.JobID, job.Error)
+		}
+		select {
+		case <-time.After(p.pollInterval):
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+		if status, err := p.call(ctx, "GET", "/v1/jobs/"+job.JobID, apiKey, nil, &job); err != nil {
+			return nil, status, fmt.Errorf("pika poll failed: %w", err)
+		}
+	}
+
+	data, err := downloadVideo(ctx, p.httpClient, job.VideoURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("pika download failed: %w", err)
+	}
+	return data, http.StatusOK, nil
+}
+
+// call makes an authenticated Pika API call and decodes the JSON response into out
+func (p *pikaClient) call(ctx context.Context, method, path, apiKey string, body []byte, out *PikaVideoResponse) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// piperVoicePattern matches Piper voice names such as "vi_VN-vais1000-medium" or "en_US-lessac-high"
+var piperVoicePattern = regexp.MustCompile(`^[a-z]{2,3}_[A-Z]{2}-[A-Za-z0-9_]+-(x_low|low|medium|high)$`)
+
+// piperTTS is the local Piper backend; it produces WAV, which postProcessAudio re-encodes to MP3
+type piperTTS struct {
+	url        string // piper HTTP server; takes precedence over the binary
+	binary     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewPiperTTS creates the local Piper provider. With url set, chunks are posted to a
+// piper HTTP server; otherwise binary is run with the .onnx voice at model.
+func NewPiperTTS(url, binary, model string) TTSProvider {
+	if binary == "" {
+		binary = "piper"
+	}
+	return &piperTTS{url: strings.TrimRight(url, "/"), binary: binary, model: model, httpClient: newTTSHTTPClient()}
+}
+
+// RetryDelay disables retries: Piper runs on this machine, so a failure will not go away
+func (p *piperTTS) RetryDelay(attempt int) (time.Duration, bool) {
+	return 0, false
+}
+
+// Synthesize renders text with the Piper voice named by voice, or the default voice
+func (p *piperTTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	if p.url != "" {
+		return p.callPiperHTTP(ctx, text, voice, speed)
+	}
+	return p.runPiper(ctx, text, voice, speed)
+}
+
+// callPiperHTTP posts to a piper HTTP server (python -m piper.http_server), which answers with WAV
+func (p *piperTTS) callPiperHTTP(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	payload := map[string]interface{}{
+		"text":         text,
+		"length_scale": piperLengthScale(speed),
+	}
+	if piperVoicePattern.MatchString(voice) {
+		payload["voice"] = voice
+	}
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	noteTTSStatus(ctx, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Piper server returned %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// runPiper pipes text into the piper binary, which writes the WAV to a temporary file
+func (p *piperTTS) runPiper(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	out, err := os.CreateTemp("", "piper-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	cmd := exec.CommandContext(ctx, p.binary,
+		"--model", p.piperModel(voice),
+		"--output_file", out.Name(),
+		"--length_scale", strconv.FormatFloat(piperLengthScale(speed), 'f', 3, 64),
+	)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("piper error: %w, stderr: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out.Name())
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("piper produced no audio")
+	}
+	return data, nil
+}
+
+// piperModel picks "<voice>.onnx" next to the configured model when the request names a Piper voice
+// that is installed there, and the configured model otherwise (FPT voice names, unknown voices)
+func (p *piperTTS) piperModel(voice string) string {
+	if piperVoicePattern.MatchString(voice) {
+		candidate := filepath.Join(filepath.Dir(p.model), voice+".onnx")
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return p.model
+}
+
+// piperLengthScale converts speaking speed to Piper's length scale (phoneme duration; < 1 is faster)
+func piperLengthScale(speed float64) float64 {
+	if speed <= 0 {
+		return 1.0
+	}
+	return 1.0 / speed
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPiperLengthScale(t *testing.T) {
+	tests := map[float64]float64{0: 1.0, 1.0: 1.0, 2.0: 0.5, 0.8: 1.25}
+	for speed, want := range tests {
+		if got := piperLengthScale(speed); got != want {
+			t.Errorf("piperLengthScale(%v) = %v, want %v", speed, got, want)
+		}
+	}
+}
+
+func TestPiperTTS_HTTP(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Write([]byte("RIFF-piper"))
+	}))
+	defer srv.Close()
+
+	provider := NewPiperTTS(srv.URL+"/", "", "").(*piperTTS)
+	provider.httpClient = srv.Client()
+
+	data, err := provider.Synthesize(context.Background(), "Xin chào", "vi_VN-vais1000-medium", 2.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "RIFF-piper" {
+		t.Errorf("Unexpected audio content %q", data)
+	}
+	if got["text"] != "Xin chào" || got["voice"] != "vi_VN-vais1000-medium" || got["length_scale"] != 0.5 {
+		t.Errorf("Unexpected payload %v", got)
+	}
+
+	// FPT voice names are not forwarded; the server uses its default voice
+	provider.Synthesize(context.Background(), "x", "banmai", 1.0)
+	if _, ok := got["voice"]; ok {
+		t.Errorf("FPT voice should not be sent to Piper: %v", got)
+	}
+}
+
+func TestPiperTTS_Binary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the piper binary")
+	}
+	dir := t.TempDir()
+	// Fake piper: writes "<model>|<length_scale>|<stdin>" to --output_file
+	script := filepath.Join(dir, "piper")
+	os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s|%s|%s' \"$2\" \"$6\" \"$(cat)\" > \"$4\"\n"), 0755)
+	defaultModel := filepath.Join(dir, "vi_VN-vais1000-medium.onnx")
+	extraModel := filepath.Join(dir, "en_US-lessac-high.onnx")
+	os.WriteFile(defaultModel, nil, 0644)
+	os.WriteFile(extraModel, nil, 0644)
+
+	provider := NewPiperTTS("", script, defaultModel)
+
+	data, err := provider.Synthesize(context.Background(), "hello", "en_US-lessac-high", 1.25)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != extraModel+"|0.800|hello" {
+		t.Errorf("Unexpected piper invocation %q", data)
+	}
+
+	if data, _ := provider.Synthesize(context.Background(), "xin chào", "minhquang", 1.0); string(data) != defaultModel+"|1.000|xin chào" {
+		t.Errorf("Unexpected piper invocation %q", data)
+	}
+}
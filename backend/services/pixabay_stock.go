@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// pixabayStock is the Pixabay video library. Its search has no orientation filter, so
+// renditions are filtered by their own aspect ratio.
+type pixabayStock struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// pixabayVideo is one rendition of a Pixabay video
+type pixabayVideo struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// PixabayVideoResponse represents Pixabay video search response
+type PixabayVideoResponse struct {
+	Hits []struct {
+		ID       int    `json:"id"`
+		Tags     string `json:"tags"` // comma separated
+		Duration int    `json:"duration"`
+		Videos   struct {
+			Large  pixabayVideo `json:"large"` // 1920x1080 when available, otherwise empty
+			Medium pixabayVideo `json:"medium"`
+		} `json:"videos"`
+	} `json:"hits"`
+}
+
+// SearchVideos implements StockProvider, keeping Pixabay's relevance order
+func (p *pixabayStock) SearchVideos(ctx context.Context, keywords string, page, perPage int, orientation string) ([]StockClip, error) {
+	params := url.Values{}
+	params.Add("key", p.apiKey)
+	params.Add("q", keywords)
+	params.Add("page", fmt.Sprintf("%d", page))
+	params.Add("per_page", fmt.Sprintf("%d", max(perPage, 3))) // Pixabay's minimum
+	params.Add("safesearch", "true")
+
+	var result PixabayVideoResponse
+	if err := getStockJSON(ctx, p.httpClient, "https://pixabay.com/api/videos/?"+params.Encode(), "", &result); err != nil {
+		return nil, fmt.Errorf("pixabay search failed: %w", err)
+	}
+
+	var clips []StockClip
+	for _, hit := range result.Hits {
+		if hit.Duration < 3 || hit.Duration > 60 {
+			continue
+		}
+		video := hit.Videos.Large
+		if video.URL == "" {
+			video = hit.Videos.Medium
+		}
+		if video.URL == "" || !matchesOrientation(video.Width, video.Height, orientation) {
+			continue
+		}
+		clips = append(clips, StockClip{ID: fmt.Sprintf("pixabay:%d", hit.ID), Link: video.URL, Duration: hit.Duration, Source: StockProviderPixabay, Tags: []string{hit.Tags}})
+	}
+	return clips, nil
+}
+
+// matchesOrientation reports whether a width x height rendition suits orientation
+func matchesOrientation(width, height int, orientation string) bool {
+	switch orientation {
+	case "portrait":
+		return height > width
+	case "square":
+		return width == height
+	}
+	return width > height
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Amazon Polly engines and defaults. Polly has no Vietnamese voices, so FPT.AI voice
+// names fall back to US English neural voices of the same gender.
+const (
+	pollyEngineNeural       = "neural"
+	pollyEngineStandard     = "standard"
+	pollyDefaultMaleVoice   = "Matthew"
+	pollyDefaultFemaleVoice = "Joanna"
+	pollySampleRate         = "24000"
+
+	// SynthesizeSpeech accepts at most 3000 billed characters (6000 including SSML tags) per call;
+	// longer chunks are split at sentence boundaries and the MP3 parts appended.
+	pollyMaxTextChars = 3000
+)
+
+// pollyTTS is the Amazon Polly backend, signed with SigV4 from static or STS credentials
+type pollyTTS struct {
+	creds      utils.AWSCredentials
+	region     string
+	engine     string
+	endpoint   string
+	httpClient *http.Client
+}
+
+// pollyError carries the HTTP status and AWS error code of a failed SynthesizeSpeech call
+type pollyError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *pollyError) Error() string {
+	return fmt.Sprintf("Polly returned %d %s: %s", e.status, e.code, e.message)
+}
+
+// NewPollyTTS creates the Amazon Polly provider; engine is "neural" or "standard"
+func NewPollyTTS(creds utils.AWSCredentials, region, engine string) TTSProvider {
+	if engine == "" {
+		engine = pollyEngineNeural
+	}
+	return &pollyTTS{
+		creds:      creds,
+		region:     region,
+		engine:     engine,
+		endpoint:   fmt.Sprintf("https://polly.%s.amazonaws.com/v1/speech", region),
+		httpClient: newTTSHTTPClient(),
+	}
+}
+
+// SupportsSSML is true; tags the neural engine rejects trigger the standard-engine fallback
+func (p *pollyTTS) SupportsSSML() bool { return true }
+
+// Synthesize renders text, splitting it into several Polly calls when it exceeds the text limit.
+// MP3 is a frame stream, so the parts are joined byte-wise.
+func (p *pollyTTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	voiceID := mapToPollyVoice(voice)
+	parts := NewTextProcessor(pollyMaxTextChars, 0).SplitForAudio(text)
+	if len(parts) > 1 {
+		log.Printf("[Polly] Text exceeds Polly limit, synthesizing in %d parts", len(parts))
+	}
+
+	var audio []byte
+	for i, part := range parts {
+		data, err := p.synthesizePart(ctx, part, voiceID, speed)
+		if err != nil {
+			if len(parts) > 1 {
+				return nil, fmt.Errorf("part %d/%d: %w", i+1, len(parts), err)
+			}
+			return nil, err
+		}
+		audio = append(audio, data...)
+	}
+	return audio, nil
+}
+
+// synthesizePart calls Polly once; a voice that has no neural variant is retried with the standard engine
+func (p *pollyTTS) synthesizePart(ctx context.Context, text, voiceID string, speed float64) ([]byte, error) {
+	data, err := p.callPollyTTS(ctx, text, voiceID, speed, p.engine)
+	var pe *pollyError
+	if errors.As(err, &pe) && p.engine == pollyEngineNeural && pe.isEngineMismatch() {
+		log.Printf("[Polly] Voice %s has no neural engine, falling back to standard", voiceID)
+		data, err = p.callPollyTTS(ctx, text, voiceID, speed, pollyEngineStandard)
+	}
+	return data, err
+}
+
+func (p *pollyTTS) callPollyTTS(ctx context.Context, text, voiceID string, speed float64, engine string) ([]byte, error) {
+	payload := map[string]string{
+		"Engine":       engine,
+		"OutputFormat": "mp3",
+		"SampleRate":   pollySampleRate,
+		"Text":         text,
+		"TextType":     "text",
+		"VoiceId":      voiceID,
+	}
+	if ssml, ok := buildPollySSML(text, speed); ok {
+		payload["Text"] = ssml
+		payload["TextType"] = "ssml"
+	}
+
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	utils.SignAWSRequest(req, body, p.creds, p.region, "polly", time.Now())
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	noteTTSStatus(ctx, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		pe := parsePollyError(resp)
+		return nil, &TTSError{StatusCode: pe.status, Retryable: pe.retryable(), Err: pe}
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download audio stream: %w", err)
+	}
+	return data, nil
+}
+
+// parsePollyError reads the AWS error code from x-amzn-ErrorType or the JSON "__type" field
+func parsePollyError(resp *http.Response) *pollyError {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(raw, &body)
+
+	code := resp.Header.Get("X-Amzn-ErrorType")
+	if code == "" {
+		code = body.Type
+	}
+	// Codes may be qualified, e.g. "ValidationException:http://..." or "com.amazonaws.polly#ValidationException"
+	if i := strings.Index(code, ":"); i >= 0 {
+		code = code[:i]
+	}
+	if i := strings.LastIndex(code, "#"); i >= 0 {
+		code = code[i+1:]
+	}
+
+	message := body.Message
+	if message == "" {
+		message = strings.TrimSpace(string(raw))
+	}
+	return &pollyError{status: resp.StatusCode, code: code, message: message}
+}
+
+func (e *pollyError) isEngineMismatch() bool {
+	// e.g. "This voice does not support the selected engine" or "Unsupported Neural feature" (SSML tags)
+	message := strings.ToLower(e.message)
+	return e.status == http.StatusBadRequest && e.code == "ValidationException" &&
+		(strings.Contains(message, "engine") || strings.Contains(message, "neural"))
+}
+
+// retryable is true for throttling (which Polly reports as a 400) and server errors
+func (e *pollyError) retryable() bool {
+	if e.code == "ThrottlingException" || e.status == http.StatusTooManyRequests {
+		return true
+	}
+	return e.status >= 500
+}
+
+// buildPollySSML wraps inline SSML in <speak>, adding a prosody tag when speed differs from normal;
+// ok is false for plain text at normal speed
+func buildPollySSML(text string, speed float64) (string, bool) {
+	ssml := IsSSML(text)
+	normalSpeed := speed <= 0 || speed == 1.0
+	if !ssml && normalSpeed {
+		return "", false
+	}
+
+	body := unwrapSpeak(text)
+	if !ssml {
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(text))
+		body = escaped.String()
+	}
+	if normalSpeed {
+		return "<speak>" + body + "</speak>", true
+	}
+	// Polly accepts relative rates between 20% and 200%
+	rate := int(math.Round(math.Max(0.2, math.Min(2.0, speed)) * 100))
+	return fmt.Sprintf(`<speak><prosody rate="%d%%">%s</prosody></speak>`, rate, body), true
+}
+
+// mapToPollyVoice passes Polly voice IDs (e.g. "Joanna", "Matthew") through and maps FPT voices by gender
+func mapToPollyVoice(voice string) string {
+	if voice != "" && unicode.IsUpper(rune(voice[0])) {
+		return voice
+	}
+	if isFPTMaleVoice(voice) {
+		return pollyDefaultMaleVoice
+	}
+	return pollyDefaultFemaleVoice
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMapToPollyVoice(t *testing.T) {
+	tests := map[string]string{
+		"Joanna":    "Joanna",
+		"Matthew":   "Matthew",
+		"minhquang": pollyDefaultMaleVoice,
+		"banmai":    pollyDefaultFemaleVoice,
+		"":          pollyDefaultFemaleVoice,
+	}
+	for in, want := range tests {
+		if got := mapToPollyVoice(in); got != want {
+			t.Errorf("mapToPollyVoice(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildPollySSML(t *testing.T) {
+	if _, ok := buildPollySSML("plain", 1.0); ok {
+		t.Error("Normal speed should be sent as plain text")
+	}
+	ssml, ok := buildPollySSML("Tom & Jerry", 1.2)
+	if !ok || ssml != `<speak><prosody rate="120%">Tom &amp; Jerry</prosody></speak>` {
+		t.Errorf("Unexpected SSML %q", ssml)
+	}
+	ssml, ok = buildPollySSML(`<speak>Wait <break time="1s"/> now</speak>`, 1.0)
+	if !ok || ssml != `<speak>Wait <break time="1s"/> now</speak>` {
+		t.Errorf("Inline SSML should pass through at normal speed, got %q", ssml)
+	}
+	ssml, _ = buildPollySSML(`Wait <break time="1s"/> &amp; go`, 0.5)
+	if ssml != `<speak><prosody rate="50%">Wait <break time="1s"/> &amp; go</prosody></speak>` {
+		t.Errorf("Unexpected SSML %q", ssml)
+	}
+}
+
+// fakePolly records SynthesizeSpeech payloads; voices in noNeural reject the neural engine
+type fakePolly struct {
+	mu       sync.Mutex
+	requests []map[string]string
+	noNeural map[string]bool
+}
+
+func (f *fakePolly) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.Header().Set("X-Amzn-ErrorType", "UnrecognizedClientException:")
+		http.Error(w, `{"message":"missing signature"}`, http.StatusForbidden)
+		return
+	}
+	var payload map[string]string
+	json.NewDecoder(r.Body).Decode(&payload)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, payload)
+	f.mu.Unlock()
+
+	if payload["Engine"] == pollyEngineNeural && f.noNeural[payload["VoiceId"]] {
+		w.Header().Set("X-Amzn-ErrorType", "ValidationException:http://internal.amazon.com/coral/com.amazon.coral.validate/")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"This voice does not support the selected engine: neural"}`))
+		return
+	}
+	if len([]rune(payload["Text"])) > pollyMaxTextChars {
+		w.Header().Set("X-Amzn-ErrorType", "TextLengthExceededException:")
+		http.Error(w, `{"message":"too long"}`, http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte("[" + payload["Engine"] + "]"))
+}
+
+func newPollyTestProvider(t *testing.T, fake *fakePolly) *pollyTTS {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	provider := NewPollyTTS(utils.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "eu-west-1", "").(*pollyTTS)
+	if provider.endpoint != "https://polly.eu-west-1.amazonaws.com/v1/speech" {
+		t.Errorf("Unexpected endpoint %s", provider.endpoint)
+	}
+	provider.endpoint = srv.URL
+	provider.httpClient = srv.Client()
+	return provider
+}
+
+func TestPollyTTS_SplitsLongText(t *testing.T) {
+	fake := &fakePolly{}
+	provider := newPollyTestProvider(t, fake)
+
+	sentence := strings.Repeat("word ", 99) + "end. "
+	text := strings.Repeat(sentence, 14) // ~7000 chars, over the 3000 limit
+
+	data, err := provider.Synthesize(context.Background(), text, "minhquang", 1.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.requests) != 3 {
+		t.Fatalf("Expected 3 Polly calls, got %d", len(fake.requests))
+	}
+	for _, req := range fake.requests {
+		if req["VoiceId"] != pollyDefaultMaleVoice || req["OutputFormat"] != "mp3" || req["TextType"] != "text" {
+			t.Errorf("Unexpected payload %v", req)
+		}
+	}
+	if string(data) != "[neural][neural][neural]" {
+		t.Errorf("Parts were not appended in order: %q", data)
+	}
+}
+
+func TestPollyTTS_FallsBackToStandardEngine(t *testing.T) {
+	fake := &fakePolly{noNeural: map[string]bool{"Maxim": true}}
+	provider := newPollyTestProvider(t, fake)
+
+	data, err := provider.Synthesize(context.Background(), "Privet", "Maxim", 1.3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "[standard]" {
+		t.Errorf("Expected standard engine output, got %q", data)
+	}
+	if len(fake.requests) != 2 || fake.requests[1]["TextType"] != "ssml" {
+		t.Errorf("Unexpected requests %v", fake.requests)
+	}
+}
+
+func TestPollyTTS_ClientErrorNotRetried(t *testing.T) {
+	fake := &fakePolly{}
+	provider := newPollyTestProvider(t, fake)
+	provider.creds.AccessKeyID = "WRONG"
+
+	_, err := provider.Synthesize(context.Background(), "hello", "Joanna", 1.0)
+	var ttsErr *TTSError
+	if !errors.As(err, &ttsErr) || ttsErr.Retryable || !strings.Contains(err.Error(), "UnrecognizedClientException") {
+		t.Errorf("Expected non-retryable auth error, got %v", err)
+	}
+}
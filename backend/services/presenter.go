@@ -0,0 +1,79 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Presenter overlay ranges and defaults, as fractions of the frame width
+const (
+	minPresenterSize       = 0.1
+	maxPresenterSize       = 0.5
+	defaultPresenterSize   = 0.25
+	maxPresenterMargin     = 0.2
+	defaultPresenterMargin = 0.03
+)
+
+// presenterExtensions are the clips and animations a presenter overlay can loop
+var presenterExtensions = map[string]bool{".mp4": true, ".mov": true, ".m4v": true, ".webm": true, ".mkv": true, ".gif": true}
+
+var presenterCorners = map[string]bool{"": true, utils.CornerBottomRight: true, utils.CornerBottomLeft: true, utils.CornerTopRight: true, utils.CornerTopLeft: true}
+
+// ValidatePresenter checks the clip, corner and ranges of presenter; without a video it must
+// set nothing else
+func ValidatePresenter(p models.Presenter) error {
+	if p.Video == "" {
+		if p != (models.Presenter{}) {
+			return fmt.Errorf("presenter needs a video")
+		}
+		return nil
+	}
+	if !presenterExtensions[strings.ToLower(filepath.Ext(p.Video))] {
+		return fmt.Errorf("presenter video %q must be an mp4, mov, m4v, webm, mkv or gif file", p.Video)
+	}
+	if !utils.FileExists(ResolveStaticVideo(p.Video, "")) {
+		return fmt.Errorf("presenter video %q not found in static/", p.Video)
+	}
+	if !presenterCorners[p.Corner] {
+		return fmt.Errorf("presenter corner must be bottom_right, bottom_left, top_right or top_left (got %q)", p.Corner)
+	}
+	if p.Size != 0 && (p.Size < minPresenterSize || p.Size > maxPresenterSize) {
+		return fmt.Errorf("presenter size must be between %.1f and %.1f of the frame width (got %g)", minPresenterSize, maxPresenterSize, p.Size)
+	}
+	if m := p.Margin; m != nil && (*m < 0 || *m > maxPresenterMargin) {
+		return fmt.Errorf("presenter margin must be between 0 and %.1f of the frame width (got %g)", maxPresenterMargin, *m)
+	}
+	if p.CornerRadius < 0 || p.CornerRadius > 0.5 {
+		return fmt.Errorf("presenter corner_radius must be between 0 and 0.5 (got %g)", p.CornerRadius)
+	}
+	return nil
+}
+
+// presenter is req's presenter overlay in pixels of the job's frame
+func (s *VideoWorkflowService) presenter(req models.GenerateRequest, orientation string) utils.Presenter {
+	var width, height int
+	fmt.Sscanf(s.frameResolution(req, orientation), "%dx%d", &width, &height)
+
+	p := req.Presenter
+	size, margin := defaultPresenterSize, defaultPresenterMargin
+	if p.Size > 0 {
+		size = p.Size
+	}
+	if p.Margin != nil {
+		margin = *p.Margin
+	}
+	corner := p.Corner
+	if corner == "" {
+		corner = utils.CornerBottomRight
+	}
+	return utils.Presenter{
+		Path:   ResolveStaticVideo(p.Video, ""),
+		Corner: corner,
+		Width:  int(float64(width)*size) &^ 1,
+		Margin: int(float64(width) * margin),
+		Radius: p.CornerRadius,
+	}
+}
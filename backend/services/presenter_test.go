@@ -0,0 +1,60 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"aituber/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatePresenter(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, staticVideoDir), 0755)
+	os.WriteFile(filepath.Join(dir, staticVideoDir, "host.webm"), []byte("clip"), 0644)
+	os.WriteFile(filepath.Join(dir, staticVideoDir, "notes.txt"), []byte("text"), 0644)
+	t.Chdir(dir)
+
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name      string
+		presenter models.Presenter
+		wantErr   bool
+	}{
+		{"None", models.Presenter{}, false},
+		{"Defaults", models.Presenter{Video: "host.webm"}, false},
+		{"Circle top left", models.Presenter{Video: "host.webm", Corner: "top_left", Size: 0.2, Margin: f(0), CornerRadius: 0.5}, false},
+		{"Options without video", models.Presenter{Corner: "top_left"}, true},
+		{"Missing video", models.Presenter{Video: "guest.mp4"}, true},
+		{"Not a video", models.Presenter{Video: "notes.txt"}, true},
+		{"Unknown corner", models.Presenter{Video: "host.webm", Corner: "middle"}, true},
+		{"Too large", models.Presenter{Video: "host.webm", Size: 0.8}, true},
+		{"Margin too wide", models.Presenter{Video: "host.webm", Margin: f(0.3)}, true},
+		{"Radius too round", models.Presenter{Video: "host.webm", CornerRadius: 0.7}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePresenter(tt.presenter); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePresenter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPresenterPixels(t *testing.T) {
+	s := &VideoWorkflowService{cfg: &config.Config{}}
+
+	got := s.presenter(models.GenerateRequest{Presenter: models.Presenter{Video: "host.mp4"}}, "landscape")
+	want := utils.Presenter{Path: filepath.Join(staticVideoDir, "host.mp4"), Corner: utils.CornerBottomRight, Width: 480, Margin: 57}
+	if got != want {
+		t.Errorf("default presenter = %+v, want %+v", got, want)
+	}
+
+	margin := 0.05
+	req := models.GenerateRequest{Resolution: "720p", Presenter: models.Presenter{Video: "host.mp4", Corner: "top_left", Size: 0.3, Margin: &margin, CornerRadius: 0.5}}
+	got = s.presenter(req, "portrait")
+	if got.Width != 216 || got.Margin != 36 || got.Corner != utils.CornerTopLeft || got.Radius != 0.5 {
+		t.Errorf("720p portrait presenter = %+v", got)
+	}
+}
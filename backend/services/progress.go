@@ -0,0 +1,43 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProgressFunc reports sub-step progress, e.g. 7 of 32 audio chunks generated
+type ProgressFunc func(done, total int)
+
+// progressTracker counts finished items from concurrent workers and reports them in order
+type progressTracker struct {
+	mu         sync.Mutex
+	done       int
+	total      int
+	onProgress ProgressFunc
+}
+
+func newProgressTracker(total int, onProgress ProgressFunc) *progressTracker {
+	return &progressTracker{total: total, onProgress: onProgress}
+}
+
+// step marks one item finished. The callback runs under the lock so counts never go backwards.
+func (pt *progressTracker) step() {
+	if pt.onProgress == nil {
+		return
+	}
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.done++
+	pt.onProgress(pt.done, pt.total)
+}
+
+// EventLogger appends a line to a job's event timeline (normally JobManager.LogEvent)
+type EventLogger func(jobID, message string)
+
+// Logf formats and records a timeline line; a nil logger discards it
+func (l EventLogger) Logf(jobID, format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	l(jobID, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestProgressTracker_Step(t *testing.T) {
+	var reported []int
+	tracker := newProgressTracker(20, func(done, total int) {
+		if total != 20 {
+			t.Errorf("Expected total 20, got %d", total)
+		}
+		reported = append(reported, done)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.step()
+		}()
+	}
+	wg.Wait()
+
+	if len(reported) != 20 {
+		t.Fatalf("Expected 20 reports, got %d", len(reported))
+	}
+	for i, done := range reported {
+		if done != i+1 {
+			t.Errorf("Report %d: expected %d, got %d", i, i+1, done)
+		}
+	}
+
+	// A nil callback is a no-op
+	newProgressTracker(1, nil).step()
+}
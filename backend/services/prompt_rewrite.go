@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// errPromptRejected marks a media provider refusing the prompt itself (a 4xx other than auth or
+// rate limits), as opposed to an outage that another key or a later retry could get past
+var errPromptRejected = errors.New("prompt rejected")
+
+// flaggedPromptTerms are words text-to-video moderation commonly refuses; a rewritten prompt
+// drops them along with any STOCK_BLOCKLIST term
+var flaggedPromptTerms = []string{
+	"blood", "bloody", "gore", "corpse", "dead", "death", "kill", "killing", "murder", "suicide",
+	"gun", "guns", "rifle", "weapon", "weapons", "knife", "bomb", "explosion", "war", "terrorist",
+	"shooting", "fight", "violence", "violent", "nude", "naked", "sexy", "drug", "drugs", "cocaine",
+}
+
+// promptBoilerplate is styling the prompt templates add, which renders fine without it
+var promptBoilerplate = []string{
+	"cinematic lighting", "professional composition", "4k resolution", "highly detailed",
+	"dramatic lighting", "photorealistic", "ultra realistic", "high quality",
+}
+
+var promptParenthetical = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+
+// maxRewrittenPromptWords bounds a rewritten prompt; short prompts are rejected less often
+const maxRewrittenPromptWords = 25
+
+// rewriteVideoPrompt returns a simpler, safer prompt to retry with after a provider refused or
+// timed out on prompt: flagged and blocked terms, templated styling and asides are removed, only
+// the first sentence is kept and the result is capped at 25 words. ok is false when no words are left.
+func rewriteVideoPrompt(prompt string, blocklist []string) (rewritten string, ok bool) {
+	text := strings.ToLower(promptParenthetical.ReplaceAllString(prompt, " "))
+	for _, phrase := range promptBoilerplate {
+		text = strings.ReplaceAll(text, phrase, " ")
+	}
+	if i := strings.IndexAny(text, ".!?"); i > 0 {
+		text = text[:i]
+	}
+
+	flagged := make(map[string]bool, len(flaggedPromptTerms)+len(blocklist))
+	for _, term := range flaggedPromptTerms {
+		flagged[term] = true
+	}
+	var phrases []string
+	for _, term := range blocklist {
+		if term = normalizeSafetyText(term); strings.Contains(term, " ") {
+			phrases = append(phrases, term)
+		} else if term != "" {
+			flagged[term] = true
+		}
+	}
+	text = " " + normalizeSafetyText(text) + " "
+	for _, phrase := range phrases {
+		text = strings.ReplaceAll(text, " "+phrase+" ", " ")
+	}
+
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if !flagged[word] && len(words) < maxRewrittenPromptWords {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return "", false
+	}
+	return strings.Join(words, " ") + ", simple and family friendly", true
+}
+
+// retryablePromptError reports whether a rewritten prompt may succeed where err failed: the
+// provider refused the prompt or the render timed out
+func retryablePromptError(err error) bool {
+	return errors.Is(err, errPromptRejected) || errors.Is(err, context.DeadlineExceeded)
+}
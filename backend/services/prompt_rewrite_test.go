@@ -0,0 +1,86 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRewriteVideoPrompt(t *testing.T) {
+	tests := []struct {
+		name      string
+		prompt    string
+		blocklist []string
+		want      string
+		ok        bool
+	}{
+		{
+			"Template styling dropped",
+			"High quality cinematic video, technology themed, cinematic lighting, professional composition, 4K resolution",
+			nil,
+			"cinematic video technology themed, simple and family friendly",
+			true,
+		},
+		{
+			"Flagged and blocked terms removed",
+			"Soldiers fight in a bloody war near the Casino Royale (night shot). Smoke everywhere.",
+			[]string{"Casino Royale"},
+			"soldiers in a near the, simple and family friendly",
+			true,
+		},
+		{"Nothing left", "Gore!", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rewriteVideoPrompt(tt.prompt, tt.blocklist)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("rewriteVideoPrompt() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryablePromptError(t *testing.T) {
+	if !retryablePromptError(fmt.Errorf("%w: status 400", errPromptRejected)) {
+		t.Error("Expected a rejected prompt to be retryable")
+	}
+	if !retryablePromptError(fmt.Errorf("pika poll failed: %w", context.DeadlineExceeded)) {
+		t.Error("Expected a timeout to be retryable")
+	}
+	if retryablePromptError(errors.New("no available pika API keys")) {
+		t.Error("Expected other failures not to be retried")
+	}
+}
+
+// recordingVideoProvider refuses every prompt, recording them
+type recordingVideoProvider struct {
+	prompts []string
+}
+
+func (p *recordingVideoProvider) GenerateClip(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	return "", fmt.Errorf("%w: status 400: unsafe content", errPromptRejected)
+}
+
+func (p *recordingVideoProvider) Capabilities() VideoGenCapabilities {
+	return VideoGenCapabilities{}
+}
+
+func TestGenerateAIClip_RewritesRejectedPrompt(t *testing.T) {
+	provider := &recordingVideoProvider{}
+	vs := NewVideoService(nil, t.TempDir(), "5M", "1920x1080", 30, 0.5)
+	vs.RegisterVideoProvider("fake", provider)
+	s := &VideoWorkflowService{cfg: &config.Config{}, jobManager: &MockJobManager{}, videoService: vs}
+
+	seg := models.VideoSegment{Text: "x", VisualDescription: "A sniper with a gun on a rooftop at dawn, dramatic lighting"}
+	if _, err := s.generateAIClip("job1", seg, models.GenerateRequest{VideoProvider: "fake"}, 5, 0, "landscape"); err == nil {
+		t.Fatal("Expected the rewritten prompt to fail too")
+	}
+	want := []string{seg.VisualDescription, "a sniper with a on a rooftop at dawn, simple and family friendly"}
+	if fmt.Sprint(provider.prompts) != fmt.Sprint(want) {
+		t.Errorf("Prompts tried = %q, want %q", provider.prompts, want)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"aituber/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultPromptTemplate is the visual prompt used when neither the request, the tenant nor
+// PROMPT_TEMPLATE sets one
+const DefaultPromptTemplate = "High quality {style} video, {themes}, {branding}, cinematic lighting, professional composition, 4K resolution"
+
+// Prompt template limits
+const (
+	maxPromptTemplateLen = 1000
+	maxPromptBrandingLen = 300
+)
+
+// promptPlaceholders are the fields a template may reference
+var promptPlaceholders = map[string]bool{"text": true, "style": true, "themes": true, "branding": true}
+
+var (
+	promptPlaceholderPattern = regexp.MustCompile(`\{([A-Za-z_]+)\}`)
+	// promptEmptyFields collapses the separators left around placeholders that rendered empty
+	promptEmptyFields = regexp.MustCompile(`\s*,(\s*,)+\s*`)
+)
+
+// ValidatePromptTemplate checks a template's length and that it only uses known placeholders,
+// including {text} or {themes} so segments get different prompts
+func ValidatePromptTemplate(tmpl string) error {
+	if utf8.RuneCountInString(tmpl) > maxPromptTemplateLen {
+		return fmt.Errorf("prompt template exceeds %d characters", maxPromptTemplateLen)
+	}
+	perSegment := false
+	for _, m := range promptPlaceholderPattern.FindAllStringSubmatch(tmpl, -1) {
+		if !promptPlaceholders[m[1]] {
+			return fmt.Errorf("prompt template: unknown placeholder {%s} (use {text}, {style}, {themes} or {branding})", m[1])
+		}
+		perSegment = perSegment || m[1] == "text" || m[1] == "themes"
+	}
+	if !perSegment {
+		return fmt.Errorf("prompt template must contain {text} or {themes}")
+	}
+	return nil
+}
+
+// ValidatePromptBranding checks the channel branding is short plain text
+func ValidatePromptBranding(branding string) error {
+	if utf8.RuneCountInString(branding) > maxPromptBrandingLen {
+		return fmt.Errorf("branding exceeds %d characters", maxPromptBrandingLen)
+	}
+	if strings.ContainsAny(branding, "{}") {
+		return fmt.Errorf("branding must not contain placeholders")
+	}
+	return nil
+}
+
+// renderPromptTemplate fills tmpl's placeholders; an empty tmpl is DefaultPromptTemplate
+func renderPromptTemplate(tmpl string, fields map[string]string) string {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = DefaultPromptTemplate
+	}
+	out := promptPlaceholderPattern.ReplaceAllStringFunc(tmpl, func(m string) string {
+		return strings.TrimSpace(fields[m[1:len(m)-1]])
+	})
+	out = promptEmptyFields.ReplaceAllString(out, ", ")
+	return strings.Trim(strings.Join(strings.Fields(out), " "), " ,")
+}
+
+// PromptTemplateStore keeps a visual prompt template and branding per tenant.
+// With a file path they survive restarts; otherwise they live in memory.
+type PromptTemplateStore struct {
+	mu      sync.RWMutex
+	tenants map[string]models.PromptTemplate
+	path    string
+}
+
+// NewPromptTemplateStore loads the templates saved at path; an empty path keeps them in memory only
+func NewPromptTemplateStore(path string) *PromptTemplateStore {
+	ps := &PromptTemplateStore{tenants: make(map[string]models.PromptTemplate), path: path}
+	if path == "" {
+		return ps
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[PromptTemplate] Could not read %s: %v", path, err)
+		}
+		return ps
+	}
+	if err := json.Unmarshal(data, &ps.tenants); err != nil {
+		log.Printf("[PromptTemplate] Ignoring malformed %s: %v", path, err)
+		ps.tenants = make(map[string]models.PromptTemplate)
+	}
+	return ps
+}
+
+// Get returns the tenant's template, if it set one
+func (ps *PromptTemplateStore) Get(tenant string) (models.PromptTemplate, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	t, ok := ps.tenants[tenant]
+	return t, ok
+}
+
+// Set replaces the tenant's template; an empty Template deletes it
+func (ps *PromptTemplateStore) Set(tenant string, t models.PromptTemplate) error {
+	t.Template, t.Branding = strings.TrimSpace(t.Template), strings.TrimSpace(t.Branding)
+	if t.Template != "" {
+		if err := ValidatePromptTemplate(t.Template); err != nil {
+			return err
+		}
+	}
+	if err := ValidatePromptBranding(t.Branding); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if t.Template == "" {
+		delete(ps.tenants, tenant)
+	} else {
+		ps.tenants[tenant] = t
+	}
+	return ps.save()
+}
+
+// save writes all templates atomically; the caller holds ps.mu
+func (ps *PromptTemplateStore) save() error {
+	if ps.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ps.tenants, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ps.path), 0755); err != nil {
+		return fmt.Errorf("failed to save prompt templates: %w", err)
+	}
+	tmp := ps.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save prompt templates: %w", err)
+	}
+	return os.Rename(tmp, ps.path)
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"aituber/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderPromptTemplate(t *testing.T) {
+	fields := map[string]string{"text": "a cat naps", "style": "anime", "themes": "abstract", "branding": ""}
+	tests := map[string]string{
+		"":                                   "High quality anime video, abstract, cinematic lighting, professional composition, 4K resolution",
+		"{style} shot of {text}, {branding}": "anime shot of a cat naps",
+		"{branding}, {themes}, {style} look": "abstract, anime look",
+		"Flat illustration: {text}. Palette {style}": "Flat illustration: a cat naps. Palette anime",
+	}
+	for tmpl, want := range tests {
+		if got := renderPromptTemplate(tmpl, fields); got != want {
+			t.Errorf("renderPromptTemplate(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+	fields["branding"] = "teal palette"
+	if got := renderPromptTemplate("{text}, {branding}", fields); got != "a cat naps, teal palette" {
+		t.Errorf("branding not filled: %q", got)
+	}
+}
+
+func TestValidatePromptTemplate(t *testing.T) {
+	if err := ValidatePromptTemplate(DefaultPromptTemplate); err != nil {
+		t.Errorf("default template rejected: %v", err)
+	}
+	for _, bad := range []string{"{style} video", "{text} by {author}", string(make([]byte, maxPromptTemplateLen+1)) + "{text}"} {
+		if err := ValidatePromptTemplate(bad); err == nil {
+			t.Errorf("expected error for %.40q", bad)
+		}
+	}
+	if err := ValidatePromptBranding("mascot {text}"); err == nil {
+		t.Error("expected branding with placeholders to be rejected")
+	}
+}
+
+func TestPromptTemplateStore_PersistsPerTenant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt_templates.json")
+	store := NewPromptTemplateStore(path)
+	if err := store.Set("acme", models.PromptTemplate{Template: " {text}, {branding} ", Branding: "orange robot"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := store.Get(DefaultTenant); ok {
+		t.Error("Tenants should not share templates")
+	}
+	if err := store.Set("acme", models.PromptTemplate{Template: "{style}"}); err == nil {
+		t.Error("Expected an invalid template to be rejected")
+	}
+
+	reloaded := NewPromptTemplateStore(path)
+	if got, ok := reloaded.Get("acme"); !ok || got.Template != "{text}, {branding}" || got.Branding != "orange robot" {
+		t.Errorf("Template not persisted, got %+v", got)
+	}
+	if err := reloaded.Set("acme", models.PromptTemplate{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := NewPromptTemplateStore(path).Get("acme"); ok {
+		t.Error("Empty template should clear the tenant's")
+	}
+}
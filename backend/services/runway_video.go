@@ -0,0 +1,154 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	runwayAPIBase    = "https://api.dev.runwayml.com"
+	runwayAPIVersion = "2024-11-06"
+)
+
+// runwayClient is the Runway ML backend. Generation is a task: submit, poll /v1/tasks/{id} until
+// it succeeds, then download the first output. Runway only renders 5 or 10 second clips, which
+// VideoService then trims or loops to the narration.
+type runwayClient struct {
+	model        string
+	baseURL      string
+	httpClient   *http.Client
+	pollInterval time.Duration
+}
+
+// runwayTask is the subset of Runway's task object we use
+type runwayTask struct {
+	ID      string   `json:"id"`
+	Status  string   `json:"status"` // PENDING, THROTTLED, RUNNING, SUCCEEDED, FAILED or CANCELLED
+	Output  []string `json:"output"`
+	Failure string   `json:"failure"`
+}
+
+// runwayCapabilities: Gen-3 renders at most 10 seconds, in two fixed sizes
+var runwayCapabilities = VideoGenCapabilities{
+	MaxDuration: 10,
+	Resolutions: map[string]string{"16:9": "1280x768", "9:16": "768x1280"},
+}
+
+// SetRunway enables video provider "runway" with model (e.g. "gen3a_turbo"); baseURL may be
+// empty for Runway's public API. A nil pool disables it.
+func (vs *VideoService) SetRunway(pool *utils.APIKeyPool, model, baseURL string) {
+	if pool == nil {
+		vs.RegisterVideoProvider(VideoProviderRunway, nil)
+		return
+	}
+	runway := newRunwayClient(model, baseURL, vs.httpClient, vs.pollInterval)
+	vs.RegisterVideoProvider(VideoProviderRunway, newPooledVideoProvider(VideoProviderRunway, pool, runway.generate, runwayCapabilities, vs.tempDir))
+}
+
+// newRunwayClient creates a Runway client for model; an empty baseURL uses the public API
+func newRunwayClient(model, baseURL string, httpClient *http.Client, pollInterval time.Duration) *runwayClient {
+	if baseURL == "" {
+		baseURL = runwayAPIBase
+	}
+	return &runwayClient{
+		model:        model,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   httpClient,
+		pollInterval: pollInterval,
+	}
+}
+
+// fixedClipDuration picks the 5 or 10 second clip length (the only ones Runway and Kling
+// render) closest above duration
+func fixedClipDuration(duration float64) int {
+	if duration <= 5 {
+		return 5
+	}
+	return 10
+}
+
+// runwayRatio maps an aspect ratio onto the output sizes Gen-3 renders
+func runwayRatio(aspect string) string {
+	if aspect == "9:16" {
+		return "768:1280"
+	}
+	return "1280:768"
+}
+
+// generate runs one Runway task with apiKey and returns the MP4
+func (r *runwayClient) generate(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle, apiKey string) ([]byte, int, error) {
+	payload := map[string]interface{}{
+		"promptText": prompt,
+		"model":      r.model,
+		"duration":   fixedClipDuration(duration),
+		"ratio":      runwayRatio(aspect),
+		"watermark":  false,
+	}
+	if style.Seed > 0 {
+		payload["seed"] = style.Seed
+	}
+	body, _ := json.Marshal(payload)
+	var task runwayTask
+	if status, err := r.call(ctx, "POST", "/v1/text_to_video", apiKey, body, &task); err != nil {
+		return nil, status, fmt.Errorf("runway submit failed: %w", err)
+	}
+	if task.ID == "" {
+		return nil, 0, fmt.Errorf("runway returned no task id")
+	}
+
+	for task.Status != "SUCCEEDED" {
+		switch task.Status {
+		case "FAILED", "CANCELLED":
+			return nil, 0, fmt.Errorf("runway task %s %s: %s", task.ID, strings.ToLower(task.Status), task.Failure)
+		}
+		select {
+		case <-time.After(r.pollInterval):
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+		if status, err := r.call(ctx, "GET", "/v1/tasks/"+task.ID, apiKey, nil, &task); err != nil {
+			return nil, status, fmt.Errorf("runway poll failed: %w", err)
+		}
+	}
+	if len(task.Output) == 0 {
+		return nil, 0, fmt.Errorf("runway task %s succeeded without output", task.ID)
+	}
+
+	data, err := downloadVideo(ctx, r.httpClient, task.Output[0])
+	if err != nil {
+		return nil, 0, fmt.Errorf("runway download failed: %w", err)
+	}
+	return data, http.StatusOK, nil
+}
+
+// call makes an authenticated Runway API request and decodes the task in the response
+func (r *runwayClient) call(ctx context.Context, method, path, apiKey string, body []byte, out *runwayTask) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("X-Runway-Version", runwayAPIVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
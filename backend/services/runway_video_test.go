@@ -0,0 +1,73 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunwayClient_Generate(t *testing.T) {
+	var polls atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/out.mp4" && (r.Header.Get("Authorization") != "Bearer rw-key" || r.Header.Get("X-Runway-Version") == "") {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/text_to_video":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["duration"] != 10.0 || body["ratio"] != "768:1280" || body["watermark"] != false || body["model"] != "gen3a_turbo" || body["seed"] != 7.0 {
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(runwayTask{ID: "t1"})
+		case "/v1/tasks/t1":
+			if polls.Add(1) < 2 {
+				json.NewEncoder(w).Encode(runwayTask{ID: "t1", Status: "RUNNING"})
+				return
+			}
+			json.NewEncoder(w).Encode(runwayTask{ID: "t1", Status: "SUCCEEDED", Output: []string{srv.URL + "/out.mp4"}})
+		case "/out.mp4":
+			w.Write([]byte("runway-mp4"))
+		}
+	}))
+	defer srv.Close()
+
+	vs := NewVideoService(utils.NewAPIKeyPool([]string{"pika"}), t.TempDir(), "5M", "1920x1080", 30, 0.5)
+	vs.pollInterval = time.Millisecond
+	if _, err := vs.videoProvider(VideoProviderRunway); err == nil {
+		t.Error("Expected runway to be unconfigured before SetRunway")
+	}
+	vs.SetRunway(utils.NewAPIKeyPool([]string{"rw-key"}), "gen3a_turbo", srv.URL)
+	if _, err := vs.videoProvider(VideoProviderRunway); err != nil {
+		t.Errorf("Expected runway to be configured: %v", err)
+	}
+	runway := newRunwayClient("gen3a_turbo", srv.URL, srv.Client(), time.Millisecond)
+
+	data, status, err := runway.generate(context.Background(), "a cat", 7.5, "9:16", ClipStyle{Seed: 7}, "rw-key")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "runway-mp4" || status != http.StatusOK {
+		t.Errorf("Unexpected result %q (status %d)", data, status)
+	}
+	if _, status, _ := runway.generate(context.Background(), "a cat", 7.5, "9:16", ClipStyle{}, "bad"); status != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 for a bad key, got %d", status)
+	}
+}
+
+func TestFixedClipDurationAndRunwayRatio(t *testing.T) {
+	if fixedClipDuration(3) != 5 || fixedClipDuration(5) != 5 || fixedClipDuration(5.1) != 10 || fixedClipDuration(30) != 10 {
+		t.Error("Unexpected duration mapping")
+	}
+	if runwayRatio("16:9") != "1280:768" || runwayRatio("") != "1280:768" || runwayRatio("9:16") != "768:1280" {
+		t.Error("Unexpected ratio mapping")
+	}
+}
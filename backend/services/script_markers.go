@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Inline marker limits: a single pause (SSML <break> has the same cap) and the speaking speed range
+const (
+	maxPauseSeconds = 10.0
+	minMarkerSpeed  = 0.5
+	maxMarkerSpeed  = 2.0
+)
+
+// scriptMarkerPattern matches inline pacing markers: pauses such as "[pause 1.5s]", "[pause 800ms]"
+// or "[pause 2]", and speed changes such as "[speed:1.3]" or "[speed:normal]" (back to the request speed)
+var scriptMarkerPattern = regexp.MustCompile(`(?i)\[\s*(?:pause\s+(\d+(?:\.\d+)?)\s*(ms|s)?|speed\s*[:=]?\s*(\d+(?:\.\d+)?|normal|reset)\s*x?)\s*\]`)
+
+// scriptPart is a run of spoken text at speed or, when text is empty, a pause of the given seconds
+type scriptPart struct {
+	text  string
+	pause float64
+	speed float64
+}
+
+// AudioPiece is one piece of a segment's audio: a synthesized chunk, or Pause seconds of silence
+type AudioPiece struct {
+	Path  string
+	Pause float64
+}
+
+// HasScriptMarkers reports whether text contains [pause ...] or [speed:...] markers
+func HasScriptMarkers(text string) bool {
+	return scriptMarkerPattern.MatchString(text)
+}
+
+// StripScriptMarkers removes pause and speed markers, for subtitles and keyword extraction
+func StripScriptMarkers(text string) string {
+	if !HasScriptMarkers(text) {
+		return text
+	}
+	return strings.TrimSpace(multiSpace.ReplaceAllString(scriptMarkerPattern.ReplaceAllString(text, " "), " "))
+}
+
+// pauseSeconds parses the duration of a pause marker; seconds are the default unit
+func pauseSeconds(value, unit string) float64 {
+	seconds, _ := strconv.ParseFloat(value, 64)
+	if strings.EqualFold(unit, "ms") {
+		seconds /= 1000
+	}
+	return math.Min(seconds, maxPauseSeconds)
+}
+
+// markerSpeed parses a speed marker value; "normal" and "reset" return baseSpeed
+func markerSpeed(value string, baseSpeed float64) float64 {
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return baseSpeed
+	}
+	return math.Max(minMarkerSpeed, math.Min(maxMarkerSpeed, speed))
+}
+
+// splitScriptMarkers splits text at its markers. Text runs carry the speed in effect, starting at speed;
+// adjacent pauses are summed, empty text is dropped, and the speed after the last marker is returned
+// so it carries over into the next segment.
+func splitScriptMarkers(text string, speed, baseSpeed float64) ([]scriptPart, float64) {
+	var parts []scriptPart
+	addText := func(s string) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return
+		}
+		if n := len(parts); n > 0 && parts[n-1].text != "" && parts[n-1].speed == speed {
+			parts[n-1].text += " " + s
+			return
+		}
+		parts = append(parts, scriptPart{text: s, speed: speed})
+	}
+	addPause := func(seconds float64) {
+		if seconds <= 0 {
+			return
+		}
+		if n := len(parts); n > 0 && parts[n-1].text == "" {
+			parts[n-1].pause += seconds
+			return
+		}
+		parts = append(parts, scriptPart{pause: seconds})
+	}
+
+	last := 0
+	for _, m := range scriptMarkerPattern.FindAllStringSubmatchIndex(text, -1) {
+		addText(text[last:m[0]])
+		switch {
+		case m[2] >= 0:
+			unit := ""
+			if m[4] >= 0 {
+				unit = text[m[4]:m[5]]
+			}
+			addPause(pauseSeconds(text[m[2]:m[3]], unit))
+		case m[6] >= 0:
+			speed = markerSpeed(text[m[6]:m[7]], baseSpeed)
+		}
+		last = m[1]
+	}
+	addText(text[last:])
+	return parts, speed
+}
+
+// ssmlScriptMarkers turns pause markers into <break> tags and drops speed markers, so SSML scripts
+// stay one well-formed chunk; speed changes there take effect from the next segment
+func ssmlScriptMarkers(text string, speed, baseSpeed float64) (string, float64) {
+	_, next := splitScriptMarkers(text, speed, baseSpeed)
+	text = scriptMarkerPattern.ReplaceAllStringFunc(text, func(marker string) string {
+		m := scriptMarkerPattern.FindStringSubmatch(marker)
+		if m[1] == "" {
+			return ""
+		}
+		return fmt.Sprintf(`<break time="%dms"/>`, int(pauseSeconds(m[1], m[2])*1000))
+	})
+	return text, next
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSplitScriptMarkers_Pauses(t *testing.T) {
+	tests := map[string]string{
+		"Hello world":                            "[{Hello world 0 1}]",
+		"Hello [pause 1.5s] world":               "[{Hello 0 1} { 1.5 0} {world 0 1}]",
+		"[PAUSE 800ms]Intro[pause 2] [pause 1s]": "[{ 0.8 0} {Intro 0 1} { 3 0}]",
+		"Long [pause 30s] wait":                  "[{Long 0 1} { 10 0} {wait 0 1}]",
+		"Zero [pause 0s] pause":                  "[{Zero pause 0 1}]",
+	}
+	for in, want := range tests {
+		parts, _ := splitScriptMarkers(in, 1, 1)
+		if got := fmt.Sprint(parts); got != want {
+			t.Errorf("splitScriptMarkers(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestSplitScriptMarkers_Speed(t *testing.T) {
+	parts, next := splitScriptMarkers("Slow intro. [speed:1.3] Fast part. [speed 1.3] Still fast. [speed:normal] Back.", 0.8, 1.0)
+	if got := fmt.Sprint(parts); got != "[{Slow intro. 0 0.8} {Fast part. Still fast. 0 1.3} {Back. 0 1}]" {
+		t.Errorf("Unexpected parts %s", got)
+	}
+	if next != 1.0 {
+		t.Errorf("Expected speed reset to 1.0, got %v", next)
+	}
+
+	// The speed carries over into the next segment and is clamped
+	_, next = splitScriptMarkers("Recap [speed:5x]", 1.0, 1.0)
+	if next != maxMarkerSpeed {
+		t.Errorf("Expected clamped speed %v, got %v", maxMarkerSpeed, next)
+	}
+	parts, _ = splitScriptMarkers("Next segment", next, 1.0)
+	if parts[0].speed != maxMarkerSpeed {
+		t.Errorf("Speed did not carry over: %+v", parts)
+	}
+}
+
+func TestStripScriptMarkers(t *testing.T) {
+	if got := StripScriptMarkers("Wait [pause 1s] for it [ pause 250 ms ] [speed:1.2]"); got != "Wait for it" {
+		t.Errorf("StripScriptMarkers = %q", got)
+	}
+	if got := StripScriptMarkers("[pending] review"); got != "[pending] review" {
+		t.Errorf("Non-marker brackets changed: %q", got)
+	}
+}
+
+func TestSSMLScriptMarkers(t *testing.T) {
+	got, next := ssmlScriptMarkers(`<emphasis>Now</emphasis> [pause 1.25s] go [speed:1.5]`, 1.0, 1.0)
+	if got != `<emphasis>Now</emphasis> <break time="1250ms"/> go ` || next != 1.5 {
+		t.Errorf("ssmlScriptMarkers = %q, %v", got, next)
+	}
+}
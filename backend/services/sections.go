@@ -0,0 +1,112 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sectionMarkerPattern matches a script line such as "[section: Getting started]"
+var sectionMarkerPattern = regexp.MustCompile(`(?i)^\s*\[\s*section\s*[:=]?\s*([^\]]*?)\s*\]\s*$`)
+
+// scriptSection is a run of script text under one heading; Title is "" before the first
+type scriptSection struct {
+	Title string
+	Text  string
+}
+
+// splitScriptSections cuts a script at its Markdown headings and [section: Name] lines, which
+// are not narrated. A heading with no text under it is narrated itself.
+func splitScriptSections(script string) []scriptSection {
+	var sections []scriptSection
+	var title string
+	var lines []string
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(lines, "\n"))
+		if text == "" {
+			text = title
+		}
+		if text != "" {
+			sections = append(sections, scriptSection{Title: title, Text: text})
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(script, "\r\n", "\n"), "\n") {
+		m := mdHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			m = sectionMarkerPattern.FindStringSubmatch(line)
+		}
+		if m == nil {
+			lines = append(lines, line)
+			continue
+		}
+		flush()
+		title, lines = stripInlineMarkdown(m[1]), nil
+	}
+	flush()
+	return sections
+}
+
+// insertTitleCards puts a card segment of duration seconds before each segment that starts a
+// section. Segments that already have their cards are returned as they are.
+func insertTitleCards(segments []models.VideoSegment, duration float64) []models.VideoSegment {
+	for _, seg := range segments {
+		if seg.TitleCard != "" {
+			return segments
+		}
+	}
+	var out []models.VideoSegment
+	for _, seg := range segments {
+		if title := strings.TrimSpace(seg.SectionTitle); title != "" {
+			out = append(out, models.VideoSegment{
+				Text:      fmt.Sprintf("[pause %.2fs]", duration),
+				TitleCard: title,
+			})
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+// titleCardSeconds is TITLE_CARD_DURATION, kept to a pause marker's range
+func (s *VideoWorkflowService) titleCardSeconds() float64 {
+	if d := s.cfg.TitleCardDuration; d > 0 {
+		return min(d, maxPauseSeconds)
+	}
+	return 3
+}
+
+// renderTitleCard renders the card of segment idx over duration seconds, on the request's
+// background image or color
+func (s *VideoWorkflowService) renderTitleCard(jobID string, seg models.VideoSegment, req models.GenerateRequest, duration float64, idx int, orientation string) (string, error) {
+	cardDir := filepath.Join(s.cfg.TempDir, jobID, "video", fmt.Sprintf("title_%03d", idx))
+	if err := os.MkdirAll(cardDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create title card dir: %w", err)
+	}
+	card := utils.StaticCard{Color: req.BackgroundColor, TitleFile: filepath.Join(cardDir, "title.txt")}
+	if req.BackgroundImage != "" {
+		card.Background = ResolveStaticVideo(req.BackgroundImage, "")
+	}
+	// drawtext does not wrap; titles are drawn at a tenth of the frame height
+	title := wrapSlideText(seg.TitleCard, cardLineRunes(orientation, 10))
+	if err := os.WriteFile(card.TitleFile, []byte(title), 0644); err != nil {
+		return "", fmt.Errorf("failed to write title card text: %w", err)
+	}
+
+	clipPath := filepath.Join(cardDir, "title_card.mp4")
+	if err := utils.RenderTitleCard(clipPath, duration, card, orientation, s.frameRate(req)); err != nil {
+		return "", fmt.Errorf("title card render failed: %w", err)
+	}
+	return clipPath, nil
+}
+
+// cardLineRunes is about how many runes of text drawn at a divisor-th of the frame height fit
+// on a line of orientation's frame, leaving a tenth of its width free on each side
+func cardLineRunes(orientation string, divisor int) int {
+	width, height := utils.FrameSize(orientation)
+	// A rune is about half the font size wide
+	return max(8, width*8/10*divisor*2/height)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"aituber/models"
+	"reflect"
+	"testing"
+)
+
+func TestSplitScriptSections(t *testing.T) {
+	script := "Chào mừng các bạn.\n\n## Phần **một**\nLịch sử của Go.\nCòn nữa.\n[section: Phần hai]\nCú pháp.\n# Tổng kết\n"
+	got := splitScriptSections(script)
+	want := []scriptSection{
+		{Title: "", Text: "Chào mừng các bạn."},
+		{Title: "Phần một", Text: "Lịch sử của Go.\nCòn nữa."},
+		{Title: "Phần hai", Text: "Cú pháp."},
+		// A heading with nothing under it is still narrated
+		{Title: "Tổng kết", Text: "Tổng kết"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitScriptSections:\n got %+v\nwant %+v", got, want)
+	}
+
+	if got := splitScriptSections("Không có tiêu đề.\n#hashtag"); len(got) != 1 || got[0].Title != "" || got[0].Text != "Không có tiêu đề.\n#hashtag" {
+		t.Errorf("Expected one untitled section, got %+v", got)
+	}
+}
+
+func TestInsertTitleCards(t *testing.T) {
+	segments := []models.VideoSegment{
+		{Text: "Mở đầu."},
+		{Text: "Một.", SectionTitle: "Phần một"},
+		{Text: "Tiếp."},
+		{Text: "Hai.", SectionTitle: "Phần hai"},
+	}
+	got := insertTitleCards(segments, 2.5)
+	want := []models.VideoSegment{
+		segments[0],
+		{Text: "[pause 2.50s]", TitleCard: "Phần một"},
+		segments[1],
+		segments[2],
+		{Text: "[pause 2.50s]", TitleCard: "Phần hai"},
+		segments[3],
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("insertTitleCards:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Re-rendered or approved segments that already have their cards get no more
+	if again := insertTitleCards(got, 2.5); len(again) != len(got) {
+		t.Errorf("Expected no new cards, got %d segments", len(again))
+	}
+	if parts, _ := splitScriptMarkers(got[1].Text, 1, 1); len(parts) != 1 || parts[0].pause != 2.5 {
+		t.Errorf("Expected the card's text %q to be a 2.5s pause, got %+v", got[1].Text, parts)
+	}
+}
+
+func TestCardLineRunes(t *testing.T) {
+	for _, tt := range []struct {
+		orientation string
+		divisor     int
+		want        int
+	}{
+		{"landscape", 10, 28},
+		{"square", 10, 16},
+		{"portrait", 10, 9},
+		{"portrait", 4, 8},
+	} {
+		if got := cardLineRunes(tt.orientation, tt.divisor); got != tt.want {
+			t.Errorf("cardLineRunes(%q, %d) = %d, want %d", tt.orientation, tt.divisor, got, tt.want)
+		}
+	}
+}
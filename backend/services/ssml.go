@@ -0,0 +1,170 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ssmlTagPattern matches the SSML elements scripts may use inline
+var ssmlTagPattern = regexp.MustCompile(`</?(speak|break|emphasis|prosody|say-as|sub|phoneme|lang)\b[^>]*>`)
+
+// speakTagPattern matches the optional <speak> wrapper; providers add their own
+var speakTagPattern = regexp.MustCompile(`</?speak\b[^>]*>`)
+
+var multiSpace = regexp.MustCompile(`\s+`)
+
+// IsSSML reports whether text contains SSML markup (e.g. <break time="500ms"/>)
+func IsSSML(text string) bool {
+	return ssmlTagPattern.MatchString(text)
+}
+
+// ValidateSSML checks that SSML text is well-formed XML so providers do not reject it mid-render
+func ValidateSSML(text string) error {
+	decoder := xml.NewDecoder(strings.NewReader("<speak>" + unwrapSpeak(text) + "</speak>"))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("invalid SSML: %w", err)
+		}
+	}
+}
+
+// StripSSML removes SSML tags and entities, for providers without SSML support and for subtitles.
+// <sub alias="..."> keeps its written text.
+func StripSSML(text string) string {
+	text = ssmlTagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	text = multiSpace.ReplaceAllString(text, " ")
+	// Tags next to punctuation leave a stray space: "Hello <break/>." -> "Hello ."
+	for _, p := range []string{".", ",", "!", "?", ";", ":"} {
+		text = strings.ReplaceAll(text, " "+p, p)
+	}
+	return strings.TrimSpace(text)
+}
+
+// unwrapSpeak drops the <speak> wrapper so chunks can be re-wrapped individually
+func unwrapSpeak(text string) string {
+	return strings.TrimSpace(speakTagPattern.ReplaceAllString(text, ""))
+}
+
+// ssmlPiece is a tag or a run of text (at most one sentence) of an SSML script
+type ssmlPiece struct {
+	text string
+	tag  bool
+}
+
+// tokenizeSSML splits SSML into tags and sentence-sized text runs
+func (tp *TextProcessor) tokenizeSSML(text string) []ssmlPiece {
+	var pieces []ssmlPiece
+	last := 0
+	addText := func(s string) {
+		if strings.TrimSpace(s) == "" {
+			if s != "" {
+				pieces = append(pieces, ssmlPiece{text: s})
+			}
+			return
+		}
+		for _, sentence := range tp.splitIntoSentences(s) {
+			pieces = append(pieces, ssmlPiece{text: sentence + " "})
+		}
+	}
+	for _, loc := range ssmlTagPattern.FindAllStringIndex(text, -1) {
+		addText(text[last:loc[0]])
+		pieces = append(pieces, ssmlPiece{text: text[loc[0]:loc[1]], tag: true})
+		last = loc[1]
+	}
+	addText(text[last:])
+	return pieces
+}
+
+// splitSSML splits SSML into chunks of at most limit spoken text, measured with width, without breaking tags apart.
+// Cuts fall between text runs (sentences, or the text between two tags); elements still open at a cut
+// are closed and re-opened in the next chunk.
+func (tp *TextProcessor) splitSSML(text string, limit int, width func(rune) int, splitLong func(string, int) []string) []string {
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	var open []string // open tags, outermost first
+
+	closing := func() string {
+		var b strings.Builder
+		for i := len(open) - 1; i >= 0; i-- {
+			b.WriteString("</" + ssmlTagName(open[i]) + ">")
+		}
+		return b.String()
+	}
+	flush := func() {
+		if currentLen > 0 {
+			chunks = append(chunks, strings.TrimSpace(current.String())+closing())
+		}
+		current.Reset()
+		current.WriteString(strings.Join(open, ""))
+		currentLen = 0
+	}
+
+	// Tags are held back until the next text so a cut never leaves an empty element behind
+	var pending []string
+	applyPending := func() {
+		for _, tag := range pending {
+			current.WriteString(tag)
+			switch {
+			case strings.HasPrefix(tag, "</"):
+				if len(open) > 0 {
+					open = open[:len(open)-1]
+				}
+			case !strings.HasSuffix(tag, "/>"):
+				open = append(open, tag)
+			}
+		}
+		pending = nil
+	}
+
+	for _, piece := range tp.tokenizeSSML(unwrapSpeak(text)) {
+		if piece.tag {
+			pending = append(pending, piece.text)
+			continue
+		}
+
+		plain := textWidth(strings.TrimSpace(html.UnescapeString(piece.text)), width)
+		if plain == 0 {
+			if len(pending) == 0 {
+				current.WriteString(piece.text)
+			}
+			continue
+		}
+		if currentLen > 0 && currentLen+plain > limit {
+			flush()
+		}
+		applyPending()
+		if plain > limit {
+			// One sentence longer than the limit: split its text (tags are not inside it)
+			for _, part := range splitLong(strings.TrimSpace(piece.text), limit) {
+				if currentLen > 0 {
+					flush()
+				}
+				current.WriteString(part + " ")
+				currentLen = textWidth(part, width)
+			}
+			continue
+		}
+		current.WriteString(piece.text)
+		currentLen += plain
+	}
+	applyPending()
+	flush()
+	return chunks
+}
+
+// ssmlTagName returns the element name of an opening tag such as `<prosody rate="slow">`
+func ssmlTagName(tag string) string {
+	name := strings.TrimPrefix(tag, "<")
+	if i := strings.IndexAny(name, " \t\n>/"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
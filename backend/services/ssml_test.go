@@ -0,0 +1,73 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsSSML(t *testing.T) {
+	tests := map[string]bool{
+		"Plain text, no markup.":                  false,
+		"1 < 2 and <b>bold</b>":                   false,
+		`Hello <break time="500ms"/> world`:       true,
+		"<speak>Hi</speak>":                       true,
+		`A <prosody rate="slow">slow</prosody> b`: true,
+	}
+	for in, want := range tests {
+		if got := IsSSML(in); got != want {
+			t.Errorf("IsSSML(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestStripSSML(t *testing.T) {
+	in := `<speak>Hello <break time="500ms"/>. <emphasis level="strong">Tom &amp; Jerry</emphasis> are <prosody rate="slow">here</prosody>!</speak>`
+	if got := StripSSML(in); got != "Hello. Tom & Jerry are here!" {
+		t.Errorf("StripSSML = %q", got)
+	}
+}
+
+func TestValidateSSML(t *testing.T) {
+	if err := ValidateSSML(`Hi <break time="1s"/> <emphasis>there</emphasis>`); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := ValidateSSML(`Hi <emphasis>there`); err == nil {
+		t.Error("Expected error for unclosed element")
+	}
+	if err := ValidateSSML(`Tom & Jerry <break/>`); err == nil {
+		t.Error("Expected error for unescaped ampersand")
+	}
+}
+
+func TestSplitForAudio_SSML(t *testing.T) {
+	tp := NewTextProcessor(40, 0)
+	script := `<speak>First sentence is here. <break time="500ms"/> <prosody rate="slow">Second one is slow. Third one is slow too.</prosody> Done.</speak>`
+	chunks := tp.SplitForAudio(script)
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %v", chunks)
+	}
+	for i, chunk := range chunks {
+		if err := ValidateSSML(chunk); err != nil {
+			t.Errorf("Chunk %d is not well-formed: %v (%q)", i, err, chunk)
+		}
+		if strings.Contains(chunk, "<speak") {
+			t.Errorf("Chunk %d kept the speak wrapper: %q", i, chunk)
+		}
+		if n := len(StripSSML(chunk)); n > 40 {
+			t.Errorf("Chunk %d has %d spoken characters", i, n)
+		}
+	}
+	// The prosody element spans a cut and is re-opened in the following chunk
+	reopened := 0
+	for _, chunk := range chunks {
+		if strings.Contains(chunk, `<prosody rate="slow">`) {
+			reopened++
+		}
+	}
+	if reopened < 2 {
+		t.Errorf("Expected prosody re-opened across chunks: %q", chunks)
+	}
+	if got := StripSSML(strings.Join(chunks, " ")); got != "First sentence is here. Second one is slow. Third one is slow too. Done." {
+		t.Errorf("Text changed by splitting: %q", got)
+	}
+}
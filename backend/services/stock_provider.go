@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Stock footage libraries selectable via STOCK_PROVIDER
+const (
+	StockProviderPexels    = "pexels" // default
+	StockProviderPixabay   = "pixabay"
+	StockProviderCoverr    = "coverr"
+	StockProviderAggregate = "aggregate" // every library with a key, results merged
+)
+
+// StockClip is one downloadable rendition found by a StockProvider
+type StockClip struct {
+	ID       string // "<provider>:<id>", shared by every rendition of the same footage
+	Link     string
+	Duration int      // seconds
+	Source   string   // provider name
+	Tags     []string // title, tags and page slug, matched against the safety blocklist
+}
+
+// mediaKey identifies the clip in a job's used-media set
+func (c StockClip) mediaKey() string {
+	if c.ID != "" {
+		return "vid_" + c.ID
+	}
+	return "vid_" + c.Link
+}
+
+// StockProvider searches one stock footage library. page starts at 1. Clips come back best
+// first, each already narrowed to the rendition that suits orientation; skipping clips the job
+// already used is left to the caller.
+type StockProvider interface {
+	SearchVideos(ctx context.Context, keywords string, page, perPage int, orientation string) ([]StockClip, error)
+}
+
+// aggregateStock queries several libraries in parallel and interleaves their rankings, so the
+// best clip of each library comes before the second best of any
+type aggregateStock struct {
+	providers []StockProvider
+}
+
+// SearchVideos implements StockProvider; it fails only when every library failed
+func (a *aggregateStock) SearchVideos(ctx context.Context, keywords string, page, perPage int, orientation string) ([]StockClip, error) {
+	results := make([][]StockClip, len(a.providers))
+	errs := make([]error, len(a.providers))
+	var wg sync.WaitGroup
+	for i, p := range a.providers {
+		wg.Add(1)
+		go func(i int, p StockProvider) {
+			defer wg.Done()
+			results[i], errs[i] = p.SearchVideos(ctx, keywords, page, perPage, orientation)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var failures []string
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) == len(a.providers) {
+		return nil, fmt.Errorf("all stock providers failed: %s", strings.Join(failures, "; "))
+	}
+	return mergeStockResults(results), nil
+}
+
+// mergeStockResults interleaves ranked result lists round-robin, dropping duplicate links
+func mergeStockResults(results [][]StockClip) []StockClip {
+	var merged []StockClip
+	seen := make(map[string]bool)
+	for rank := 0; ; rank++ {
+		more := false
+		for _, clips := range results {
+			if rank >= len(clips) {
+				continue
+			}
+			more = true
+			if clip := clips[rank]; !seen[clip.mediaKey()] {
+				seen[clip.mediaKey()] = true
+				merged = append(merged, clip)
+			}
+		}
+		if !more {
+			return merged
+		}
+	}
+}
+
+// SetStockSource selects where stock clips come from: "pexels" (default), "pixabay", "coverr",
+// or "aggregate" to query every library that has a key. Photos always come from Pexels.
+func (sv *StockVideoService) SetStockSource(mode, pixabayKey, coverrKey string) error {
+	available := map[string]StockProvider{}
+	if sv.pexels.apiKey != "" {
+		available[StockProviderPexels] = sv.pexels
+	}
+	if pixabayKey != "" {
+		available[StockProviderPixabay] = &pixabayStock{apiKey: pixabayKey, httpClient: sv.httpClient}
+	}
+	if coverrKey != "" {
+		available[StockProviderCoverr] = &coverrStock{apiKey: coverrKey, httpClient: sv.httpClient}
+	}
+
+	switch mode {
+	case "", StockProviderPexels:
+		sv.stock = sv.pexels
+	case StockProviderPixabay, StockProviderCoverr:
+		p, ok := available[mode]
+		if !ok {
+			return fmt.Errorf("stock provider %q has no API key", mode)
+		}
+		sv.stock = p
+	case StockProviderAggregate:
+		agg := &aggregateStock{}
+		for _, name := range []string{StockProviderPexels, StockProviderPixabay, StockProviderCoverr} {
+			if p, ok := available[name]; ok {
+				agg.providers = append(agg.providers, p)
+			}
+		}
+		if len(agg.providers) == 0 {
+			return fmt.Errorf("stock provider %q needs at least one library key", mode)
+		}
+		sv.stock = agg
+	default:
+		return fmt.Errorf("unknown stock provider %q (expected pexels, pixabay, coverr or aggregate)", mode)
+	}
+	return nil
+}
+
+// getStockJSON makes one search call, sending authorization unless empty, and decodes the
+// JSON response into out
+func getStockJSON(ctx context.Context, client *http.Client, rawURL, authorization string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
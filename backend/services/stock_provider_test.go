@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+type fakeStock struct {
+	clips []StockClip   // every page, unless pages is set
+	pages [][]StockClip // page 1 first
+	err   error
+	calls int
+}
+
+func (f *fakeStock) SearchVideos(ctx context.Context, keywords string, page, perPage int, orientation string) ([]StockClip, error) {
+	f.calls++
+	if f.pages != nil {
+		if page > len(f.pages) {
+			return nil, f.err
+		}
+		return f.pages[page-1], f.err
+	}
+	return f.clips, f.err
+}
+
+func TestAggregateStock_InterleavesRankings(t *testing.T) {
+	agg := &aggregateStock{providers: []StockProvider{
+		&fakeStock{clips: []StockClip{{Link: "a1"}, {Link: "a2"}, {Link: "a3"}}},
+		&fakeStock{err: errors.New("down")},
+		&fakeStock{clips: []StockClip{{Link: "b1"}, {Link: "a2"}}},
+	}}
+	clips, err := agg.SearchVideos(context.Background(), "sea", 1, 15, "landscape")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var links []string
+	for _, c := range clips {
+		links = append(links, c.Link)
+	}
+	want := []string{"a1", "b1", "a2", "a3"}
+	if len(links) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, links)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, links)
+		}
+	}
+
+	allDown := &aggregateStock{providers: []StockProvider{&fakeStock{err: errors.New("down")}}}
+	if _, err := allDown.SearchVideos(context.Background(), "sea", 1, 15, "landscape"); err == nil {
+		t.Error("Expected an error when every provider fails")
+	}
+}
+
+func TestPixabayAndCoverrStock_SearchVideos(t *testing.T) {
+	client := &http.Client{Transport: &MockRoundTripper{
+		RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+			var body string
+			switch {
+			case req.URL.Host == "pixabay.com" && req.URL.Query().Get("key") == "px":
+				body = `{"hits": [
+					{"id": 1, "duration": 12, "videos": {"large": {"url": "http://px/1-large.mp4", "width": 1920, "height": 1080}}},
+					{"id": 2, "duration": 8, "videos": {"large": {}, "medium": {"url": "http://px/2-medium.mp4", "width": 1280, "height": 720}}},
+					{"id": 3, "duration": 9, "videos": {"large": {"url": "http://px/3-tall.mp4", "width": 1080, "height": 1920}}},
+					{"id": 4, "duration": 120, "videos": {"large": {"url": "http://px/4-long.mp4", "width": 1920, "height": 1080}}}
+				]}`
+			case req.URL.Host == "api.coverr.co" && req.Header.Get("Authorization") == "Bearer cv":
+				body = `{"hits": [
+					{"id": "a", "duration": 7.6, "is_vertical": false, "urls": {"mp4_download": "http://cv/a.mp4"}},
+					{"id": "b", "duration": 9, "is_vertical": true, "urls": {"mp4_download": "http://cv/b.mp4"}}
+				]}`
+			default:
+				return &http.Response{StatusCode: 401, Body: io.NopCloser(bytes.NewBufferString("no"))}, nil
+			}
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+		},
+	}}
+
+	px, err := (&pixabayStock{apiKey: "px", httpClient: client}).SearchVideos(context.Background(), "sea", 1, 15, "landscape")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(px) != 2 || px[0].Link != "http://px/1-large.mp4" || px[1].Link != "http://px/2-medium.mp4" || px[0].Source != StockProviderPixabay {
+		t.Errorf("Unexpected Pixabay clips %+v", px)
+	}
+
+	cv, err := (&coverrStock{apiKey: "cv", httpClient: client}).SearchVideos(context.Background(), "sea", 1, 15, "portrait")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cv) != 1 || cv[0].Link != "http://cv/b.mp4" || cv[0].Duration != 9 {
+		t.Errorf("Unexpected Coverr clips %+v", cv)
+	}
+
+	if _, err := (&coverrStock{apiKey: "bad", httpClient: client}).SearchVideos(context.Background(), "sea", 1, 15, "portrait"); err == nil {
+		t.Error("Expected a rejected key to fail the search")
+	}
+}
+
+func TestStockVideoService_SetStockSource(t *testing.T) {
+	sv := NewStockVideoService("pexels-key", t.TempDir(), "", nil, nil, "")
+	if err := sv.SetStockSource(StockProviderCoverr, "", ""); err == nil {
+		t.Error("Expected coverr without a key to fail")
+	}
+	if err := sv.SetStockSource("shutterstock", "", ""); err == nil {
+		t.Error("Expected an unknown provider to fail")
+	}
+	if err := sv.SetStockSource(StockProviderAggregate, "px", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if agg, ok := sv.stock.(*aggregateStock); !ok || len(agg.providers) != 2 {
+		t.Errorf("Expected Pexels and Pixabay to be aggregated, got %#v", sv.stock)
+	}
+}
+
+func TestSearchUnusedClips_ReadsMorePagesBeforeReuse(t *testing.T) {
+	sv := NewStockVideoService("", t.TempDir(), "", nil, nil, "")
+	stock := &fakeStock{pages: [][]StockClip{
+		{{ID: "pexels:1", Link: "l1", Duration: 10}, {ID: "pexels:2", Link: "l2", Duration: 10}},
+		{{ID: "pexels:2", Link: "l2-hd", Duration: 10}, {ID: "pexels:3", Link: "l3", Duration: 10}},
+		{{ID: "pexels:4", Link: "l4", Duration: 10}},
+		{{ID: "pexels:5", Link: "l5", Duration: 10}},
+	}}
+	sv.stock = stock
+	used := &sync.Map{}
+	used.Store(StockClip{ID: "pexels:1"}.mediaKey(), true)
+
+	clips, err := sv.searchUnusedClips(context.Background(), "sea", 25, "landscape", used)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(clips) != 3 || clips[0].ID != "pexels:2" || clips[1].ID != "pexels:3" || clips[2].ID != "pexels:4" {
+		t.Errorf("Unexpected clips %+v", clips)
+	}
+
+	// Never more than maxStockPages pages, even when short of the duration
+	stock.calls = 0
+	if _, err := sv.searchUnusedClips(context.Background(), "sea", 1000, "landscape", used); err != nil || stock.calls != maxStockPages {
+		t.Errorf("Expected %d pages, got %d (%v)", maxStockPages, stock.calls, err)
+	}
+}
+
+func TestRenditionScore_FollowsOrientation(t *testing.T) {
+	files := []struct {
+		width, height int
+		quality       string
+	}{{1920, 1080, "hd"}, {1080, 1920, "hd"}, {720, 1280, "hd"}, {960, 540, "sd"}}
+	best := func(orientation string) (int, int) {
+		bw, bh, bestScore := 0, 0, 0
+		for _, f := range files {
+			if score := renditionScore(f.width, f.height, f.quality, orientation); score > bestScore {
+				bw, bh, bestScore = f.width, f.height, score
+			}
+		}
+		return bw, bh
+	}
+
+	if w, h := best("portrait"); w != 1080 || h != 1920 {
+		t.Errorf("Expected 1080x1920 for portrait, got %dx%d", w, h)
+	}
+	if w, h := best("landscape"); w != 1920 || h != 1080 {
+		t.Errorf("Expected 1920x1080 for landscape, got %dx%d", w, h)
+	}
+	if renditionScore(2160, 3840, "uhd", "portrait") <= renditionScore(720, 1280, "hd", "portrait") {
+		t.Error("Expected a 4K portrait source to outrank 720p")
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// safetyFramePositions are where, as fractions of its duration, a downloaded clip is sampled
+// for the classifier
+var safetyFramePositions = []float64{0.2, 0.5, 0.8}
+
+// StockSafetyFilter keeps unsuitable footage out of kids and brand content. Clips whose title,
+// tags or page slug contain a blocklisted term are dropped before download; with a classifier
+// URL, frames of every downloaded clip (and every downloaded photo) are scored as well.
+type StockSafetyFilter struct {
+	blocklist     []string // normalized, see normalizeSafetyText
+	classifierURL string
+	threshold     float64
+	httpClient    *http.Client
+}
+
+// NewStockSafetyFilter creates a filter from blocklist terms (single words or phrases) and an
+// optional local NSFW classifier; images scoring threshold or more are rejected. It returns nil
+// when neither is configured.
+func NewStockSafetyFilter(blocklist []string, classifierURL string, threshold float64) *StockSafetyFilter {
+	f := &StockSafetyFilter{
+		classifierURL: classifierURL,
+		threshold:     threshold,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, term := range blocklist {
+		if term = normalizeSafetyText(term); term != "" {
+			f.blocklist = append(f.blocklist, term)
+		}
+	}
+	if len(f.blocklist) == 0 && f.classifierURL == "" {
+		return nil
+	}
+	return f
+}
+
+// SetSafetyFilter screens every stock clip and photo with f; nil disables screening
+func (sv *StockVideoService) SetSafetyFilter(f *StockSafetyFilter) {
+	sv.safety = f
+}
+
+// normalizeSafetyText lowercases s and reduces it to words separated by single spaces, so
+// "Beer-Party" in a URL slug matches the blocklist term "beer party"
+func normalizeSafetyText(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// blockedTerm returns the first blocklisted term found as whole words in texts, or ""
+func (f *StockSafetyFilter) blockedTerm(texts ...string) string {
+	if f == nil || len(f.blocklist) == 0 {
+		return ""
+	}
+	text := " " + normalizeSafetyText(strings.Join(texts, " ")) + " "
+	for _, term := range f.blocklist {
+		if strings.Contains(text, " "+term+" ") {
+			return term
+		}
+	}
+	return ""
+}
+
+// classifies reports whether downloaded media must be checked by the classifier
+func (f *StockSafetyFilter) classifies() bool {
+	return f != nil && f.classifierURL != ""
+}
+
+// checkClip samples frames of the downloaded clip at path and returns an error if any of them
+// is unsafe. A classifier that cannot be reached counts as unsafe: it fails closed.
+func (f *StockSafetyFilter) checkClip(ctx context.Context, path string, duration float64) error {
+	for i, at := range safetyFramePositions {
+		framePath := strings.TrimSuffix(path, filepath.Ext(path)) + fmt.Sprintf("_safety_%d.jpg", i)
+		if err := utils.ExtractFrame(path, duration*at, framePath); err != nil {
+			return fmt.Errorf("frame extraction failed: %w", err)
+		}
+		err := f.checkImage(ctx, framePath)
+		os.Remove(framePath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkImage scores one image and returns an error if it is unsafe. The JPEG is POSTed as the
+// request body; the classifier answers {"score": <0..1>}, the probability that it is unsafe.
+func (f *StockSafetyFilter) checkImage(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", f.classifierURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "image/jpeg")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("safety classifier unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("safety classifier status %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	var result struct {
+		Score float64 `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid safety classifier response: %w", err)
+	}
+	if result.Score >= f.threshold {
+		return fmt.Errorf("classified unsafe (score %.2f, threshold %.2f)", result.Score, f.threshold)
+	}
+	return nil
+}
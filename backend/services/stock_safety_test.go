@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestNewStockSafetyFilter_Disabled(t *testing.T) {
+	if f := NewStockSafetyFilter([]string{" ", "--"}, "", 0.7); f != nil {
+		t.Errorf("Expected nil filter without terms or classifier, got %+v", f)
+	}
+	var f *StockSafetyFilter
+	if f.blockedTerm("beer") != "" || f.classifies() {
+		t.Error("Expected a nil filter to allow everything")
+	}
+}
+
+func TestStockSafetyFilter_BlockedTerm(t *testing.T) {
+	f := NewStockSafetyFilter([]string{"Beer", "bikini", "night club"}, "", 0.7)
+	tests := []struct {
+		name  string
+		texts []string
+		want  string
+	}{
+		{"Clean", []string{"https://www.pexels.com/video/kids-playing-football-854128/"}, ""},
+		{"URL slug", []string{"https://www.pexels.com/video/friends-drinking-beer-854129/"}, "beer"},
+		{"Pixabay tags", []string{"beach, Bikini, summer"}, "bikini"},
+		{"Phrase across separators", []string{"Night-Club dancing"}, "night club"},
+		{"Whole words only", []string{"beerbohm portrait", "club night"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.blockedTerm(tt.texts...); got != tt.want {
+				t.Errorf("blockedTerm(%q) = %q, want %q", tt.texts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchUnusedClips_SkipsBlocklistedClips(t *testing.T) {
+	sv := NewStockVideoService("", t.TempDir(), "", nil, nil, "")
+	sv.stock = &fakeStock{clips: []StockClip{
+		{ID: "pixabay:1", Link: "l1", Duration: 10, Tags: []string{"party, beer, friends"}},
+		{ID: "pixabay:2", Link: "l2", Duration: 10, Tags: []string{"football, kids"}},
+	}}
+	sv.SetSafetyFilter(NewStockSafetyFilter([]string{"beer"}, "", 0.7))
+
+	clips, err := sv.searchUnusedClips(context.Background(), "party", 10, "landscape", &sync.Map{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(clips) != 1 || clips[0].ID != "pixabay:2" {
+		t.Errorf("Expected only the clean clip, got %+v", clips)
+	}
+}
+
+func TestStockSafetyFilter_CheckImage(t *testing.T) {
+	score := `{"score": 0.2}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "image/jpeg" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		w.Write([]byte(score))
+	}))
+	defer server.Close()
+
+	imgPath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(imgPath, []byte("jpeg"), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+	f := NewStockSafetyFilter(nil, server.URL, 0.7)
+
+	if err := f.checkImage(context.Background(), imgPath); err != nil {
+		t.Errorf("Expected low score to pass, got %v", err)
+	}
+	score = `{"score": 0.7}`
+	if err := f.checkImage(context.Background(), imgPath); err == nil {
+		t.Error("Expected a score at the threshold to be rejected")
+	}
+
+	// An unreachable classifier fails closed
+	server.Close()
+	if err := f.checkImage(context.Background(), imgPath); err == nil {
+		t.Error("Expected an unreachable classifier to reject the image")
+	}
+}
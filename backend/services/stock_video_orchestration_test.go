@@ -64,7 +64,7 @@ func TestStockVideoService_PrepareSegmentVideo_FullFallback(t *testing.T) {
 		// For now, let's see if it works with small dummy files.
 
 		ctx := context.Background()
-		path, err := sv.PrepareSegmentVideo(ctx, "test", "desc", "", "", 2.0, "job1", 0, "landscape")
+		path, err := sv.PrepareSegmentVideo(ctx, "test", "", "desc", "", "", 2.0, "job1", 0, "landscape")
 
 		// In a real environment, RunFFmpegCommand would fail on "dummy video content".
 		// But here we are testing if the logic REACHES the right tier.
@@ -93,7 +93,7 @@ func TestStockVideoService_PrepareSegmentVideo_FullFallback(t *testing.T) {
 		sv.hfService = nil
 		sv.geminiService = nil
 
-		path, _ := sv.PrepareSegmentVideo(context.Background(), "test", "desc", "", "", 2.0, "job2", 1, "landscape")
+		path, _ := sv.PrepareSegmentVideo(context.Background(), "test", "", "desc", "", "", 2.0, "job2", 1, "landscape")
 		if path != "" {
 			t.Log("Reached Ultra Fallback tier")
 		}
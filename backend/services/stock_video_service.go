@@ -7,33 +7,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
-
 	"sync"
 	"time"
 )
 
+// Stock searches read up to maxStockPages pages of stockPageSize results before a job reuses clips
+const (
+	maxStockPages = 3
+	stockPageSize = 15
+)
+
 // StockVideoService handles stock video searching and downloading
 type StockVideoService struct {
-	apiKey        string
 	httpClient    *http.Client
+	pexels        *pexelsStock  // photo fallback, and the default clip library
+	stock         StockProvider // where segment clips are searched; see SetStockSource
 	tempDir       string
 	cacheDir      string
 	geminiService *GeminiService      // AI image fallback tier 4
 	hfService     *HuggingFaceService // AI image fallback tier 3 (preferred, cheaper)
 	localHubURL   string              // Local Hub Tier (sequential CPU generation)
 	jobMediaTrack sync.Map            // Tracks used links/keywords per jobID to guarantee uniqueness
+	events        EventLogger
+	safety        *StockSafetyFilter // optional blocklist/classifier screening; see SetSafetyFilter
+	broll         *BrollLibrary      // the user's footage for video_source "local"; see SetBrollLibrary
+	embedder      Embedder           // optional semantic footage ranking; see SetEmbedder
+	embedderName  string
+	embedCache    map[string][]float64
+	embedMux      sync.Mutex
+	cleanup       utils.FootageCleanup // denoise/sharpen of downloaded clips; see SetFootageCleanup
 }
 
 // NewStockVideoService creates a new stock video service
 func NewStockVideoService(apiKey, tempDir, cacheDir string, geminiSvc *GeminiService, hfSvc *HuggingFaceService, localHubURL string) *StockVideoService {
-	return &StockVideoService{
-		apiKey: apiKey,
+	sv := &StockVideoService{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Minute,
 		},
@@ -43,15 +55,30 @@ func NewStockVideoService(apiKey, tempDir, cacheDir string, geminiSvc *GeminiSer
 		hfService:     hfSvc,
 		localHubURL:   localHubURL,
 	}
+	sv.pexels = &pexelsStock{apiKey: apiKey, httpClient: sv.httpClient}
+	sv.stock = sv.pexels
+	return sv
+}
+
+// SetEventLogger routes search results and tier fallbacks into the job's event timeline
+func (sv *StockVideoService) SetEventLogger(fn EventLogger) {
+	sv.events = fn
+}
+
+// SetFootageCleanup denoises and sharpens downloaded stock clips as they are fitted to the frame
+func (sv *StockVideoService) SetFootageCleanup(c utils.FootageCleanup) {
+	sv.cleanup = c
 }
 
 // PexelsVideoResponse represents Pexels API response
 type PexelsVideoResponse struct {
 	Videos []struct {
-		ID         int `json:"id"`
-		Width      int `json:"width"`
-		Height     int `json:"height"`
-		Duration   int `json:"duration"`
+		ID         int      `json:"id"`
+		URL        string   `json:"url"` // page URL; its slug describes the footage
+		Tags       []string `json:"tags"`
+		Width      int      `json:"width"`
+		Height     int      `json:"height"`
+		Duration   int      `json:"duration"`
 		VideoFiles []struct {
 			ID       int    `json:"id"`
 			Quality  string `json:"quality"` // hd, sd, uhd
@@ -63,6 +90,19 @@ type PexelsVideoResponse struct {
 	} `json:"videos"`
 }
 
+// PexelsPhotoResponse represents the Pexels photo search response
+type PexelsPhotoResponse struct {
+	Photos []struct {
+		ID  int    `json:"id"`
+		URL string `json:"url"`
+		Alt string `json:"alt"`
+		Src struct {
+			Original string `json:"original"`
+			Large2x  string `json:"large2x"` // ~1880px wide, plenty for a 1080p pan
+		} `json:"src"`
+	} `json:"photos"`
+}
+
 // CleanupJob media tracking after success/failure
 func (sv *StockVideoService) CleanupJob(jobID string) {
 	sv.jobMediaTrack.Delete(jobID)
@@ -74,13 +114,22 @@ func (sv *StockVideoService) PrepareStockVideo(keywords string, targetDuration f
 	trackIface, _ := sv.jobMediaTrack.LoadOrStore(jobID, &sync.Map{})
 	usedMedia := trackIface.(*sync.Map)
 
-	// 1. Search for multiple short videos (5-10s)
-	videoURLs, err := sv.searchMultipleVideos(keywords, targetDuration, "landscape", usedMedia)
+	// 1. Search for enough unused clips to cover the duration plus the transitions' overlap
+	clips, err := sv.searchUnusedClips(context.Background(), keywords, targetDuration+5, "landscape", usedMedia)
 	if err != nil {
 		return "", fmt.Errorf("failed to search videos: %w", err)
 	}
+	var unused []StockClip
+	for _, clip := range clips {
+		if _, loaded := usedMedia.LoadOrStore(clip.mediaKey(), true); !loaded {
+			unused = append(unused, clip)
+		}
+	}
+	if len(unused) == 0 {
+		return "", fmt.Errorf("no unused videos found for keywords: %s", keywords)
+	}
 
-	fmt.Printf("[Stock Video] Found %d short videos for keywords: %s\n", len(videoURLs), keywords)
+	fmt.Printf("[Stock Video] Found %d short videos for keywords: %s\n", len(unused), keywords)
 
 	// 2. Download all videos in parallel
 	var videoPaths []string
@@ -88,27 +137,34 @@ func (sv *StockVideoService) PrepareStockVideo(keywords string, targetDuration f
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 5) // Limit concurrency to 5
 
-	fmt.Printf("[Stock Video] Downloading %d videos in parallel...\n", len(videoURLs))
+	fmt.Printf("[Stock Video] Downloading %d videos in parallel...\n", len(unused))
 
-	for i, videoURL := range videoURLs {
+	for i, clip := range unused {
 		wg.Add(1)
-		go func(index int, url string) {
+		go func(index int, clip StockClip) {
 			defer wg.Done()
 			semaphore <- struct{}{}        // Acquire
 			defer func() { <-semaphore }() // Release
 
 			videoPath := filepath.Join(sv.tempDir, jobID, "stock", fmt.Sprintf("segment_%d.mp4", index))
-			fmt.Printf("[Stock Video] Downloading video %d/%d...\n", index+1, len(videoURLs))
+			fmt.Printf("[Stock Video] Downloading video %d/%d...\n", index+1, len(unused))
 
-			if err := sv.downloadVideo(url, videoPath); err != nil {
+			if err := sv.downloadVideo(clip.Link, videoPath); err != nil {
 				fmt.Printf("[Stock Video] Failed to download video %d: %v (Skipping)\n", index, err)
 				return
 			}
+			if sv.safety.classifies() {
+				if err := sv.safety.checkClip(context.Background(), videoPath, float64(clip.Duration)); err != nil {
+					fmt.Printf("[Stock Safety] Dropping %s: %v\n", clip.mediaKey(), err)
+					os.Remove(videoPath)
+					return
+				}
+			}
 
 			mutex.Lock()
 			videoPaths = append(videoPaths, videoPath)
 			mutex.Unlock()
-		}(i, videoURL)
+		}(i, clip)
 	}
 
 	wg.Wait()
@@ -128,8 +184,9 @@ func (sv *StockVideoService) PrepareStockVideo(keywords string, targetDuration f
 }
 
 // PrepareSegmentVideo fetches stock video for a SINGLE audio segment (by index).
-// orientation: "landscape" (YouTube, 1920x1080) or "portrait" (TikTok, 1080x1920)
-func (sv *StockVideoService) PrepareSegmentVideo(ctx context.Context, keywords string, visualDesc string, t2vModel, t2vProvider string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
+// orientation: "landscape" (YouTube, 1920x1080), "portrait" (TikTok, 1080x1920) or "square" (1080x1080).
+// text is the segment's narration, which stock results are ranked against when an embedder is set.
+func (sv *StockVideoService) PrepareSegmentVideo(ctx context.Context, keywords, text, visualDesc string, t2vModel, t2vProvider string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
 	if orientation == "" {
 		orientation = "landscape"
 	}
@@ -197,23 +254,14 @@ func (sv *StockVideoService) PrepareSegmentVideo(ctx context.Context, keywords s
 				// Normalize and trim the generated video
 				processedT2VPath := filepath.Join(segDir, "t2v_processed.mp4")
 
-				var vfFilter string
-				if orientation == "portrait" {
-					vfFilter = "scale=1080:1920:force_original_aspect_ratio=increase,crop=1080:1920:(iw-ow)/2:(ih-oh)/2,setsar=1,fps=30,eq=contrast=1.05:saturation=1.15:brightness=-0.02,format=yuv420p"
-				} else {
-					vfFilter = "scale=1920:1080:force_original_aspect_ratio=increase,crop=1920:1080:(iw-ow)/2:(ih-oh)/2,setsar=1,fps=30,eq=contrast=1.05:saturation=1.15:brightness=-0.02,format=yuv420p"
-				}
-
-				if trimErr := utils.RunFFmpegCommand([]string{
-					"-i", t2vVideoPath,
-					"-t", fmt.Sprintf("%.3f", audioDuration+0.4),
-					"-vf", vfFilter,
-					"-c:v", "libx264",
-					"-preset", "medium",
-					"-crf", "20",
-					"-an",
-					"-y", processedT2VPath,
-				}); trimErr == nil {
+				trimErr := utils.NewFFmpegCommand("trim T2V clip").
+					Input(t2vVideoPath).
+					Args("-t", fmt.Sprintf("%.3f", audioDuration+0.4)).
+					VideoFilter(stockFrameFilter(orientation)).
+					Args("-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+					Output(processedT2VPath).
+					Run()
+				if trimErr == nil {
 					fmt.Printf("[SegVideo %d] HF T2V generation SUCCEEDED!\n", segIndex)
 					saveToCache(processedT2VPath)
 					return processedT2VPath, nil
@@ -263,27 +311,44 @@ func (sv *StockVideoService) PrepareSegmentVideo(ctx context.Context, keywords s
 		}
 	}
 
-	// 3. TIER 3: Pexels Stock Video Search (Last Resort)
-	fmt.Printf("[SegVideo %d] Pexels search (Priority 3 - Last Resort) for: %q\n", segIndex, keywords)
+	// 3. TIER 3: Stock Video Search (Last Resort)
+	fmt.Printf("[SegVideo %d] Stock search (Priority 3 - Last Resort) for: %q\n", segIndex, keywords)
 
 	// Setup per-job tracking map
 	trackIface, _ := sv.jobMediaTrack.LoadOrStore(jobID, &sync.Map{})
 	usedMedia := trackIface.(*sync.Map)
 
-	// Search Pexels – fetch up to 15 candidates per query
-	videoInfos, _ := sv.searchVideoInfos(ctx, keywords, 15, orientation, usedMedia)
+	// Search the stock library, reading further pages if earlier segments used the first results
+	videoInfos, searchErr := sv.searchUnusedClips(ctx, keywords, audioDuration+0.5, orientation, usedMedia)
+	if searchErr != nil {
+		sv.events.Logf(jobID, "Segment %d: stock search for %q failed: %v", segIndex+1, keywords, searchErr)
+	} else {
+		sv.events.Logf(jobID, "Segment %d: stock search for %q found %d unused clips", segIndex+1, keywords, len(videoInfos))
+		videoInfos = sv.rankBySimilarity(ctx, jobID, segmentMatchText(keywords, text, visualDesc), videoInfos)
+	}
 
 	// Step 2: Greedily download videos until we have enough duration
-	downloadedPaths, err := sv.downloadUntilDuration(videoInfos, audioDuration, segDir, segIndex, usedMedia)
+	downloadedPaths, err := sv.downloadUntilDuration(ctx, videoInfos, audioDuration, segDir, segIndex, usedMedia)
 	if err == nil && len(downloadedPaths) > 0 {
 		return sv.processAndTrimStockVideo(downloadedPaths, audioDuration, orientation, segDir, segIndex, keywords)
 	}
 
+	// 3b. Pexels photos for the same keywords, animated with pan/zoom, before generic footage
+	if photoLinks, photoErr := sv.searchPhotoLinks(ctx, keywords, 15, orientation, usedMedia); photoErr == nil && len(photoLinks) > 0 {
+		sv.events.Logf(jobID, "Segment %d: no stock clips for %q, using %d photos", segIndex+1, keywords, len(photoLinks))
+		photoPath, err := sv.buildPhotoSegment(ctx, photoLinks, audioDuration, orientation, segDir, segIndex, keywords, usedMedia)
+		if err == nil {
+			return photoPath, nil
+		}
+		fmt.Printf("[SegVideo %d] Pexels photo fallback failed: %v\n", segIndex, err)
+	}
+
 	// 4. TIER 4: ULTRA FALLBACK - "natural 4k" search
 	fmt.Printf("[SegVideo %d] Tier 1, 2, 3 FAILED. Attempting Tier 4 (Ultra Fallback: natural 4k)...\n", segIndex)
-	fallbackInfos, _ := sv.searchVideoInfos(ctx, "natural 4k", 15, orientation, usedMedia)
+	sv.events.Logf(jobID, "Segment %d: no usable clips, falling back to generic footage", segIndex+1)
+	fallbackInfos, _ := sv.searchUnusedClips(ctx, "natural 4k", audioDuration+0.5, orientation, usedMedia)
 	if len(fallbackInfos) > 0 {
-		dlPaths, dlErr := sv.downloadUntilDuration(fallbackInfos, audioDuration, segDir, segIndex, usedMedia)
+		dlPaths, dlErr := sv.downloadUntilDuration(ctx, fallbackInfos, audioDuration, segDir, segIndex, usedMedia)
 		if dlErr == nil && len(dlPaths) > 0 {
 			finalPath, pErr := sv.processAndTrimStockVideo(dlPaths, audioDuration, orientation, segDir, segIndex, "natural 4k")
 			if pErr == nil {
@@ -294,24 +359,23 @@ func (sv *StockVideoService) PrepareSegmentVideo(ctx context.Context, keywords s
 
 	// 5. TIER 5: FINAL PLACEHOLDER (Guarantee A/V Sync)
 	fmt.Printf("[SegVideo %d] ALL SEARCH TIERS FAILED. Generating final placeholder...\n", segIndex)
+	sv.events.Logf(jobID, "Segment %d: all sources failed, using a black placeholder", segIndex+1)
 	placeholderPath := filepath.Join(segDir, "placeholder.mp4")
 	placeholderDur := audioDuration + 0.4
 
-	placeholderArgs := []string{
-		"-f", "lavfi",
-		"-i", "testsrc=duration=" + fmt.Sprintf("%.3f", placeholderDur) + ":size=1280x720:rate=30",
-		"-vf", "drawbox=y=0:color=black:t=fill", // Make it black
-	}
-
-	if orientation == "portrait" {
-		placeholderArgs = append(placeholderArgs, "-vf", "scale=1080:1920:force_original_aspect_ratio=increase,crop=1080:1920,format=yuv420p")
-	} else {
-		placeholderArgs = append(placeholderArgs, "-vf", "scale=1920:1080:force_original_aspect_ratio=increase,crop=1920:1080,format=yuv420p")
-	}
-
-	placeholderArgs = append(placeholderArgs, "-c:v", "libx264", "-preset", "ultrafast", "-an", "-y", placeholderPath)
-
-	if err := utils.RunFFmpegCommand(placeholderArgs); err != nil {
+	width, height := utils.FrameSize(orientation)
+	w, h := fmt.Sprint(width), fmt.Sprint(height)
+	err = utils.NewFFmpegCommand("render placeholder").
+		Input(fmt.Sprintf("testsrc=duration=%.3f:size=1280x720:rate=30", placeholderDur), "-f", "lavfi").
+		VideoFilter(utils.Chain().Then(
+			utils.F("drawbox", "y=0", "color=black", "t=fill"), // Make it black
+			utils.F("scale", w, h, "force_original_aspect_ratio=increase"),
+			utils.F("crop", w, h),
+			utils.F("format", "yuv420p"))).
+		Args("-c:v", "libx264", "-preset", "ultrafast", "-an").
+		Output(placeholderPath).
+		Run()
+	if err != nil {
 		return "", fmt.Errorf("all tiers failed AND placeholder generation failed: %w", err)
 	}
 
@@ -319,7 +383,7 @@ func (sv *StockVideoService) PrepareSegmentVideo(ctx context.Context, keywords s
 }
 
 // downloadUntilDuration is a helper to download videos from infos until a target duration is met
-func (sv *StockVideoService) downloadUntilDuration(videoInfos []videoInfo, audioDuration float64, segDir string, segIndex int, usedMedia *sync.Map) ([]string, error) {
+func (sv *StockVideoService) downloadUntilDuration(ctx context.Context, videoInfos []StockClip, audioDuration float64, segDir string, segIndex int, usedMedia *sync.Map) ([]string, error) {
 	var downloadedPaths []string
 	var totalDuration float64
 	downloadIdx := 0
@@ -328,7 +392,7 @@ func (sv *StockVideoService) downloadUntilDuration(videoInfos []videoInfo, audio
 		info := videoInfos[downloadIdx]
 		downloadIdx++
 
-		if _, loaded := usedMedia.LoadOrStore("vid_"+info.Link, true); loaded {
+		if _, loaded := usedMedia.LoadOrStore(info.mediaKey(), true); loaded {
 			continue
 		}
 
@@ -336,6 +400,13 @@ func (sv *StockVideoService) downloadUntilDuration(videoInfos []videoInfo, audio
 		if err := sv.downloadVideo(info.Link, dlPath); err != nil {
 			continue
 		}
+		if sv.safety.classifies() {
+			if err := sv.safety.checkClip(ctx, dlPath, float64(info.Duration)); err != nil {
+				fmt.Printf("[Stock Safety] Segment %d: dropping %s: %v\n", segIndex, info.mediaKey(), err)
+				os.Remove(dlPath)
+				continue
+			}
+		}
 		downloadedPaths = append(downloadedPaths, dlPath)
 		totalDuration += float64(info.Duration)
 	}
@@ -361,29 +432,20 @@ func (sv *StockVideoService) processAndTrimStockVideo(downloadedPaths []string,
 		f.Close()
 
 		concatPath = filepath.Join(segDir, "concat.mp4")
-		if err := utils.RunFFmpegCommand([]string{"-f", "concat", "-safe", "0", "-i", listPath, "-c", "copy", "-y", concatPath}); err != nil {
+		if err := utils.NewFFmpegCommand("concat stock clips").Input(listPath, "-f", "concat", "-safe", "0").Args("-c", "copy").Output(concatPath).Run(); err != nil {
 			return "", err
 		}
 	}
 
 	trimmedPath := filepath.Join(segDir, "segment.mp4")
-	var vfFilter string
-	if orientation == "portrait" {
-		vfFilter = "scale=1080:1920:force_original_aspect_ratio=increase,crop=1080:1920:(iw-ow)/2:(ih-oh)/2,setsar=1,fps=30,eq=contrast=1.05:saturation=1.15:brightness=-0.02,format=yuv420p"
-	} else {
-		vfFilter = "scale=1920:1080:force_original_aspect_ratio=increase,crop=1920:1080:(iw-ow)/2:(ih-oh)/2,setsar=1,fps=30,eq=contrast=1.05:saturation=1.15:brightness=-0.02,format=yuv420p"
-	}
-
-	if err := utils.RunFFmpegCommand([]string{
-		"-i", concatPath,
-		"-t", fmt.Sprintf("%.3f", audioDuration),
-		"-vf", vfFilter,
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "20",
-		"-an",
-		"-y", trimmedPath,
-	}); err != nil {
+	err := utils.NewFFmpegCommand("trim stock clip").
+		Input(concatPath).
+		Args("-t", fmt.Sprintf("%.3f", audioDuration)).
+		VideoFilter(cleanStockFrameFilter(orientation, sv.cleanup)).
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+		Output(trimmedPath).
+		Run()
+	if err != nil {
 		return "", err
 	}
 
@@ -391,13 +453,45 @@ func (sv *StockVideoService) processAndTrimStockVideo(downloadedPaths []string,
 	return trimmedPath, nil
 }
 
+// stockFrameFilter fills the output frame with a clip, cropping around the centre, and grades it
+func stockFrameFilter(orientation string) *utils.FilterChain {
+	width, height := utils.FrameSize(orientation)
+	w, h := fmt.Sprint(width), fmt.Sprint(height)
+	return utils.Chain().Then(
+		utils.F("scale", w, h, "force_original_aspect_ratio=increase"),
+		utils.F("crop", w, h, "(iw-ow)/2", "(ih-oh)/2"),
+		utils.F("setsar", "1"),
+		utils.F("fps", "30"),
+		utils.F("eq", "contrast=1.05", "saturation=1.15", "brightness=-0.02"),
+		utils.F("format", "yuv420p"))
+}
+
+// cleanStockFrameFilter is stockFrameFilter with cleanup's denoise before the scale, at the
+// clip's own size, and its sharpen after
+func cleanStockFrameFilter(orientation string, cleanup utils.FootageCleanup) *utils.FilterChain {
+	chain := stockFrameFilter(orientation)
+	if denoise, ok := cleanup.DenoiseFilter(); ok {
+		chain.Before("scale", denoise)
+	}
+	if sharpen, ok := cleanup.SharpenFilter(); ok {
+		chain.Before("format", sharpen)
+	}
+	return chain
+}
+
+// stockSearchOrientation is the footage orientation to search for: square frames are cropped
+// from landscape clips, since few stock clips are shot 1:1
+func stockSearchOrientation(orientation string) string {
+	if orientation == "square" {
+		return "landscape"
+	}
+	return orientation
+}
+
 // generateImageLocalHub calls the local Python hub service to generate an image
 func (sv *StockVideoService) generateImageLocalHub(ctx context.Context, prompt string, orientation string) ([]byte, error) {
 	// 1. Request generation with correct resolution
-	width, height := 1920, 1080 // Default Landscape
-	if orientation == "portrait" {
-		width, height = 1080, 1920
-	}
+	width, height := utils.FrameSize(orientation)
 
 	genURL := fmt.Sprintf("%s/generate", sv.localHubURL)
 	reqBody, _ := json.Marshal(map[string]interface{}{
@@ -475,322 +569,114 @@ func (sv *StockVideoService) getCacheHash(text string) string {
 	return utils.GetMD5Hash(text)
 }
 
-// videoInfo holds just the URL + duration of a Pexels video file match
-type videoInfo struct {
-	Link     string
-	Duration int
-}
-
-// searchVideoInfos searches Pexels and returns ordered list of (link, duration) for the best-quality files.
-// orientation: "landscape", "portrait", or "square"
-func (sv *StockVideoService) searchVideoInfos(ctx context.Context, keywords string, perPage int, orientation string, usedMedia *sync.Map) ([]videoInfo, error) {
-	baseURL := "https://api.pexels.com/videos/search"
+// searchPhotoLinks searches Pexels photos and returns the links of those not yet used by the job
+func (sv *StockVideoService) searchPhotoLinks(ctx context.Context, keywords string, perPage int, orientation string, usedMedia *sync.Map) ([]string, error) {
 	params := url.Values{}
 	params.Add("query", keywords)
 	params.Add("per_page", fmt.Sprintf("%d", perPage))
 	params.Add("orientation", orientation)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"?"+params.Encode(), nil)
-	if err != nil {
+	var result PexelsPhotoResponse
+	if err := sv.pexels.get(ctx, "https://api.pexels.com/v1/search", params, &result); err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", sv.apiKey)
 
-	var resp *http.Response
-	var lastErr error
-	maxRetries := 3
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(time.Duration(attempt*2) * time.Second)
+	var links []string
+	for _, photo := range result.Photos {
+		link := photo.Src.Large2x
+		if link == "" {
+			link = photo.Src.Original
 		}
-
-		resp, err = sv.httpClient.Do(req)
-		if err != nil {
-			lastErr = err
+		if link == "" {
 			continue
 		}
-
-		if resp.StatusCode == http.StatusTooManyRequests {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("pexels API rate limited (429)")
-			time.Sleep(3 * time.Second) // Extra backoff
+		if _, used := usedMedia.Load("img_" + link); used {
 			continue
 		}
-
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("pexels API returned status %d", resp.StatusCode)
+		if term := sv.safety.blockedTerm(photo.Alt, photo.URL); term != "" {
+			fmt.Printf("[Stock Safety] Skipping photo %d: matches %q\n", photo.ID, term)
 			continue
 		}
-
-		// Success
-		break
+		links = append(links, link)
 	}
+	return links, nil
+}
 
-	if resp == nil || resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("pexels search failed after %d retries: %v", maxRetries, lastErr)
+// buildPhotoSegment animates up to three stills (one per ~5s of narration) with a Ken Burns
+// zoom and joins them into the segment clip
+func (sv *StockVideoService) buildPhotoSegment(ctx context.Context, links []string, audioDuration float64, orientation, segDir string, segIndex int, keywords string, usedMedia *sync.Map) (string, error) {
+	count := int(math.Ceil(audioDuration / 5))
+	if count < 1 {
+		count = 1
 	}
-	defer resp.Body.Close()
-
-	var result PexelsVideoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	if count > 3 {
+		count = 3
 	}
-
-	type scoredVideo struct {
-		info  videoInfo
-		score int
+	if count > len(links) {
+		count = len(links)
 	}
-	var scoredInfos []scoredVideo
+	perPhoto := audioDuration/float64(count) + 0.4
 
-	for _, video := range result.Videos {
-		if video.Duration < 3 || video.Duration > 60 {
+	var clipPaths []string
+	for i := 0; i < len(links) && len(clipPaths) < count; i++ {
+		if _, loaded := usedMedia.LoadOrStore("img_"+links[i], true); loaded {
 			continue
 		}
-		bestLink, bestScore := "", 0
-		for _, file := range video.VideoFiles {
-			score := 0
-			if orientation == "portrait" {
-				// For portrait: prefer 1080x1920 or tall videos
-				ar := 0.0
-				if file.Width > 0 {
-					ar = float64(file.Height) / float64(file.Width)
-				}
-				isPortrait916 := ar > 1.77 && ar < 1.79
-				isUHD := file.Quality == "uhd" || file.Height >= 3840 || file.Width >= 3840
-				if file.Width == 1080 && file.Height == 1920 {
-					score = 10000
-				} else if isPortrait916 && file.Height >= 1280 {
-					score = 5000
-				} else if isPortrait916 {
-					score = 1000
-				} else if file.Quality == "hd" {
-					score = 500
-				} else {
-					score = 1
-				}
-				if isUHD {
-					score += 3000 // 4K downscale to 1080p = ultra-sharp
-				}
-				score += file.Height // taller = better for portrait
-			} else {
-				// For landscape: prefer 1920x1080
-				ar := 0.0
-				if file.Height > 0 {
-					ar = float64(file.Width) / float64(file.Height)
-				}
-				is169 := ar > 1.77 && ar < 1.79
-				isUHD := file.Quality == "uhd" || file.Width >= 3840 || file.Height >= 3840
-				if file.Width == 1920 && file.Height == 1080 {
-					score = 10000
-				} else if is169 && file.Width >= 1280 {
-					score = 5000
-				} else if is169 {
-					score = 1000
-				} else if file.Quality == "hd" {
-					score = 500
-				} else {
-					score = 1
-				}
-				if isUHD {
-					score += 3000 // 4K downscale to 1080p = ultra-sharp
-				}
-				score += file.Width
-			}
-			if score > bestScore {
-				bestScore = score
-				bestLink = file.Link
-			}
+		imgPath := filepath.Join(segDir, fmt.Sprintf("photo_%02d.jpg", i))
+		if err := sv.downloadVideo(links[i], imgPath); err != nil {
+			continue
 		}
-		if bestLink != "" {
-			// Apply duration penalty: subtract points for longer videos
-			finalScore := bestScore - (video.Duration * 10)
-
-			// Massive bonus for ideal generative duration (5s - 15s)
-			if video.Duration >= 5 && video.Duration <= 15 {
-				finalScore += 5000
+		if sv.safety.classifies() {
+			if err := sv.safety.checkImage(ctx, imgPath); err != nil {
+				fmt.Printf("[Stock Safety] Segment %d: dropping photo %s: %v\n", segIndex, links[i], err)
+				continue
 			}
-
-			// Check and exclude heavily penalized / used URLs logic here, or just let 'used' check at download phase.
-			// The penalty phase runs globally. But we already filter at download phase! So it's fine.
-
-			scoredInfos = append(scoredInfos, scoredVideo{
-				info:  videoInfo{Link: bestLink, Duration: video.Duration},
-				score: finalScore,
-			})
 		}
-	}
-
-	// Sort by highest score first
-	sort.Slice(scoredInfos, func(i, j int) bool {
-		return scoredInfos[i].score > scoredInfos[j].score
-	})
-
-	var infos []videoInfo
-	for _, si := range scoredInfos {
-		infos = append(infos, si.info)
-	}
-
-	return infos, nil
-}
-
-// searchMultipleVideos searches Pexels for multiple short videos (5-10s) matching keywords
-func (sv *StockVideoService) searchMultipleVideos(keywords string, targetDuration float64, orientation string, usedMedia *sync.Map) ([]string, error) {
-	baseURL := "https://api.pexels.com/videos/search"
-	params := url.Values{}
-	params.Add("query", keywords)
-	params.Add("per_page", "100") // Get more results to filter
-	params.Add("orientation", orientation)
-
-	req, err := http.NewRequest("GET", baseURL+"?"+params.Encode(), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", sv.apiKey)
-
-	var resp *http.Response
-	var lastErr error
-	maxRetries := 3
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(time.Duration(attempt*2) * time.Second)
-		}
-
-		resp, err = sv.httpClient.Do(req)
-		if err != nil {
-			lastErr = err
+		clipPath := filepath.Join(segDir, fmt.Sprintf("photo_%02d.mp4", i))
+		if err := utils.ImageToVideo(imgPath, clipPath, perPhoto, orientation); err != nil {
 			continue
 		}
-
-		if resp.StatusCode == http.StatusTooManyRequests {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("pexels API rate limited (429)")
-			time.Sleep(3 * time.Second) // Extra backoff
-			continue
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("pexels API returned status %d", resp.StatusCode)
-			continue
-		}
-
-		// Success
-		break
+		clipPaths = append(clipPaths, clipPath)
 	}
-
-	if resp == nil || resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("pexels search failed after %d retries: %v", maxRetries, lastErr)
-	}
-	defer resp.Body.Close()
-
-	var result PexelsVideoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	if len(clipPaths) == 0 || len(clipPaths) < count {
+		return "", fmt.Errorf("only %d of %d photos usable", len(clipPaths), count)
 	}
+	return sv.processAndTrimStockVideo(clipPaths, audioDuration, orientation, segDir, segIndex, "photos: "+keywords)
+}
 
-	if len(result.Videos) == 0 {
-		return nil, fmt.Errorf("no videos found for keywords: %s", keywords)
-	}
-
-	// Filter videos by duration (5-10 seconds preferred)
-	var shortVideos []struct {
-		Duration int
-		Link     string
-	}
-
-	for _, video := range result.Videos {
-		// Only accept videos between 5-15 seconds (flexible range)
-		if video.Duration >= 5 && video.Duration <= 35 {
-			var bestLink string
-			var bestScore int
-
-			for _, file := range video.VideoFiles {
-				currentScore := 0
-				var aspectRatio float64
-
-				if orientation == "portrait" {
-					if file.Width > 0 {
-						aspectRatio = float64(file.Height) / float64(file.Width)
-					}
-					isPortrait916 := aspectRatio > 1.77 && aspectRatio < 1.78
-					if file.Width == 1080 && file.Height == 1920 {
-						currentScore = 10000
-					} else if isPortrait916 && file.Height >= 1280 {
-						currentScore = 5000
-					} else if isPortrait916 {
-						currentScore = 1000
-					} else if file.Quality == "hd" {
-						currentScore = 500
-					} else {
-						currentScore = 1
-					}
-					currentScore += file.Height
-				} else {
-					if file.Height > 0 {
-						aspectRatio = float64(file.Width) / float64(file.Height)
-					}
-					is16_9 := aspectRatio > 1.77 && aspectRatio < 1.78
-					if file.Width == 1920 && file.Height == 1080 {
-						currentScore = 10000
-					} else if is16_9 && file.Width >= 1280 {
-						currentScore = 5000
-					} else if is16_9 {
-						currentScore = 1000
-					} else if file.Quality == "hd" {
-						currentScore = 500
-					} else {
-						currentScore = 1
-					}
-					currentScore += file.Width
-				}
-
-				if currentScore > bestScore {
-					bestScore = currentScore
-					bestLink = file.Link
-				}
-			}
-
-			if bestLink != "" {
-				// Prevent duplicate videos in the same job
-				if _, loaded := usedMedia.LoadOrStore("vid_"+bestLink, true); loaded {
-					continue
-				}
-
-				shortVideos = append(shortVideos, struct {
-					Duration int
-					Link     string
-				}{video.Duration, bestLink})
+// searchUnusedClips collects clips the job has not used yet, reading further result pages (up
+// to maxStockPages) until they add up to minDuration seconds. Blocklisted clips are skipped;
+// the rest are not marked used here.
+func (sv *StockVideoService) searchUnusedClips(ctx context.Context, keywords string, minDuration float64, orientation string, usedMedia *sync.Map) ([]StockClip, error) {
+	var clips []StockClip
+	var total float64
+	seen := make(map[string]bool)
+	for page := 1; page <= maxStockPages && total < minDuration; page++ {
+		results, err := sv.stock.SearchVideos(ctx, keywords, page, stockPageSize, stockSearchOrientation(orientation))
+		if err != nil {
+			if page == 1 {
+				return nil, err
 			}
-		}
-	}
-
-	if len(shortVideos) == 0 {
-		return nil, fmt.Errorf("no short videos (5-35s) found for keywords: %s", keywords)
-	}
-
-	var selectedURLs []string
-	var totalDuration float64
-
-	for _, video := range shortVideos {
-		selectedURLs = append(selectedURLs, video.Link)
-		totalDuration += float64(video.Duration)
-
-		if totalDuration >= targetDuration {
 			break
 		}
-		if len(selectedURLs) >= 100 {
+		if len(results) == 0 {
 			break
 		}
+		for _, clip := range results {
+			key := clip.mediaKey()
+			if _, used := usedMedia.Load(key); used || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if term := sv.safety.blockedTerm(clip.Tags...); term != "" {
+				fmt.Printf("[Stock Safety] Skipping %s: matches %q\n", key, term)
+				continue
+			}
+			clips = append(clips, clip)
+			total += float64(clip.Duration)
+		}
 	}
-
-	if len(selectedURLs) == 0 {
-		return nil, fmt.Errorf("failed to select videos")
-	}
-
-	return selectedURLs, nil
+	return clips, nil
 }
 
 // downloadVideo downloads file from URL with retry
@@ -875,13 +761,11 @@ func (sv *StockVideoService) loopVideoToDuration(inputPath, outputPath string, t
 
 	// Concatenate (loop)
 	loopedPath := filepath.Join(filepath.Dir(outputPath), "looped_temp.mp4")
-	err = utils.RunFFmpegCommand([]string{
-		"-f", "concat",
-		"-safe", "0",
-		"-i", listPath,
-		"-c", "copy",
-		"-y", loopedPath,
-	})
+	err = utils.NewFFmpegCommand("loop stock clip").
+		Input(listPath, "-f", "concat", "-safe", "0").
+		Args("-c", "copy").
+		Output(loopedPath).
+		Run()
 	if err != nil {
 		return fmt.Errorf("concat failed: %w", err)
 	}
@@ -926,15 +810,13 @@ func (sv *StockVideoService) mergeVideosWithTransition(inputPaths []string, outp
 	if currentEffective < safeTargetDuration {
 		fmt.Printf("[Stock Video] Effective duration (%.1fs) < target (%.1fs), looping videos...\n", currentEffective, safeTargetDuration)
 
-		// Seed random for variety
-		rand.Seed(time.Now().UnixNano())
-
-		// Keep adding random videos until we have enough duration
-		for currentEffective < safeTargetDuration {
-			randomIdx := rand.Intn(len(inputPaths))
-			finalInputPaths = append(finalInputPaths, inputPaths[randomIdx])
+		// Cycle through the clips in order, so every clip plays before any repeats and a
+		// repeat is as far from its previous showing as possible
+		for i := 0; currentEffective < safeTargetDuration; i++ {
+			next := inputPaths[i%len(inputPaths)]
+			finalInputPaths = append(finalInputPaths, next)
 
-			duration, _ := utils.GetVideoDuration(inputPaths[randomIdx])
+			duration, _ := utils.GetVideoDuration(next)
 			currentRawDuration += duration
 			currentCount++
 
@@ -953,6 +835,7 @@ func (sv *StockVideoService) mergeVideosWithTransition(inputPaths []string, outp
 	err := utils.MergeVideosWithTransition(
 		finalInputPaths,
 		mergedPath,
+		"fade",
 		1.0,         // 1 second transition
 		30,          // 30 fps
 		"1920x1080", // Resolution
@@ -1,6 +1,14 @@
 package services
 
 import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -35,3 +43,47 @@ func TestGetCacheHashConsistency(t *testing.T) {
 		t.Errorf("Hash is not consistent: %s vs %s", h1, h2)
 	}
 }
+
+func TestSearchPhotoLinks(t *testing.T) {
+	sv := NewStockVideoService("mock_pexels", t.TempDir(), "", nil, nil, "")
+	var query url.Values
+	sv.httpClient.Transport = &MockRoundTripper{
+		RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/v1/search" || req.Header.Get("Authorization") != "mock_pexels" {
+				return &http.Response{StatusCode: 404, Body: io.NopCloser(bytes.NewBufferString("{}"))}, nil
+			}
+			query = req.URL.Query()
+			jsonResp := `{"photos": [
+				{"id": 1, "src": {"large2x": "http://mock.com/1.jpg", "original": "http://mock.com/1-orig.jpg"}},
+				{"id": 2, "src": {"original": "http://mock.com/2-orig.jpg"}},
+				{"id": 3, "src": {"large2x": "http://mock.com/3.jpg"}}
+			]}`
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(jsonResp))}, nil
+		},
+	}
+
+	used := &sync.Map{}
+	used.Store("img_http://mock.com/3.jpg", true)
+	links, err := sv.searchPhotoLinks(context.Background(), "mountain lake", 15, "portrait", used)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(links) != 2 || links[0] != "http://mock.com/1.jpg" || links[1] != "http://mock.com/2-orig.jpg" {
+		t.Errorf("Unexpected links %v", links)
+	}
+	if query.Get("query") != "mountain lake" || query.Get("orientation") != "portrait" {
+		t.Errorf("Unexpected query %v", query)
+	}
+}
+
+func TestCleanStockFrameFilter(t *testing.T) {
+	if got, want := cleanStockFrameFilter("landscape", utils.FootageCleanup{}).String(), stockFrameFilter("landscape").String(); got != want {
+		t.Errorf("Expected no cleanup to leave the filter as it is, got %q", got)
+	}
+
+	// Denoise the source frames, sharpen the scaled ones
+	got := cleanStockFrameFilter("portrait", utils.FootageCleanup{Denoise: 4, Sharpen: 0.8}).String()
+	if !strings.HasPrefix(got, "hqdn3d=4.00:3.00:6.00:4.50,scale=1080:1920:") || !strings.HasSuffix(got, ",unsharp=5:5:0.80:5:5:0,format=yuv420p") {
+		t.Errorf("cleanStockFrameFilter = %q", got)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"aituber/models"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// Storyboard limits
+const (
+	maxStoryboardScenes   = 200
+	maxOnScreenTextLen    = 80
+	maxStoryboardFieldLen = 1000
+)
+
+// StoryboardSource reports whether video_source renders one clip per scene, which a storyboard
+// needs; static, waveform and slides videos have nothing for it to plan
+func StoryboardSource(source string) bool {
+	switch source {
+	case "", VideoSourceAI, VideoSourceImages, VideoSourceLocal:
+		return true
+	}
+	return false
+}
+
+// planStoryboard turns the script into a storyboard and parks the job until it is approved.
+// Scenes are planned by Gemini; when that fails they are taken from the script as is, so the
+// user can still fill them in.
+func (s *VideoWorkflowService) planStoryboard(jobID string, req models.GenerateRequest, segments []models.VideoSegment) {
+	s.jobManager.UpdateProgress(jobID, "Planning storyboard", 10)
+
+	var planned []models.StoryboardScene
+	if s.geminiService != nil && s.geminiService.HasKeys() {
+		var err error
+		if planned, err = s.geminiService.GenerateStoryboard(req.Topic, segments); err != nil {
+			log.Printf("[Job %s] Storyboard planning failed, using the script: %v", jobID, err)
+			s.jobManager.LogEvent(jobID, fmt.Sprintf("Storyboard planning failed (%v), scenes taken from the script", err))
+		}
+	}
+
+	storyboard := &models.Storyboard{Scenes: make([]models.StoryboardScene, len(segments))}
+	for i, seg := range segments {
+		var scene models.StoryboardScene
+		if i < len(planned) {
+			scene = planned[i]
+		}
+		scene.Text = seg.Text
+		if strings.TrimSpace(scene.VisualDescription) == "" {
+			scene.VisualDescription = seg.VisualDescription
+		}
+		if strings.TrimSpace(scene.BrollKeywords) == "" {
+			scene.BrollKeywords = seg.VisualPrompt
+		}
+		if strings.TrimSpace(scene.OnScreenText) == "" {
+			scene.OnScreenText = seg.OnScreenText
+		}
+		if strings.TrimSpace(scene.LowerThird) == "" {
+			scene.LowerThird = seg.LowerThird
+		}
+		if strings.TrimSpace(scene.SectionTitle) == "" {
+			scene.SectionTitle = seg.SectionTitle
+		}
+		storyboard.Scenes[i] = trimScene(scene)
+	}
+
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("Storyboard ready: %d scenes, waiting for approval", len(storyboard.Scenes)))
+	log.Printf("[Job %s] Storyboard of %d scenes waiting for approval", jobID, len(storyboard.Scenes))
+	s.jobManager.MarkAwaitingApproval(jobID, storyboard)
+}
+
+// ValidateStoryboard checks an edited storyboard: 1-200 scenes, each with narration, and
+// on-screen text short enough to read at a glance
+func ValidateStoryboard(storyboard models.Storyboard) error {
+	if len(storyboard.Scenes) == 0 {
+		return fmt.Errorf("storyboard must have at least one scene")
+	}
+	if len(storyboard.Scenes) > maxStoryboardScenes {
+		return fmt.Errorf("storyboard may have at most %d scenes", maxStoryboardScenes)
+	}
+	for i, scene := range storyboard.Scenes {
+		if strings.TrimSpace(scene.Text) == "" {
+			return fmt.Errorf("scene %d: text must not be empty", i+1)
+		}
+		if utf8.RuneCountInString(scene.OnScreenText) > maxOnScreenTextLen {
+			return fmt.Errorf("scene %d: on_screen_text exceeds %d characters", i+1, maxOnScreenTextLen)
+		}
+		if title, subtitle, _ := strings.Cut(scene.LowerThird, "\n"); utf8.RuneCountInString(title) > maxLowerThirdLen || utf8.RuneCountInString(subtitle) > maxLowerThirdLen {
+			return fmt.Errorf("scene %d: lower_third lines may have at most %d characters", i+1, maxLowerThirdLen)
+		}
+		for _, field := range []string{scene.Text, scene.VisualDescription, scene.BrollKeywords, scene.MusicMood, scene.SectionTitle} {
+			if utf8.RuneCountInString(field) > maxStoryboardFieldLen {
+				return fmt.Errorf("scene %d: fields may have at most %d characters", i+1, maxStoryboardFieldLen)
+			}
+		}
+	}
+	return nil
+}
+
+// StoryboardSegments converts approved scenes into the segments the job renders
+func StoryboardSegments(storyboard models.Storyboard) []models.VideoSegment {
+	segments := make([]models.VideoSegment, len(storyboard.Scenes))
+	for i, scene := range storyboard.Scenes {
+		scene = trimScene(scene)
+		segments[i] = models.VideoSegment{
+			Text:              scene.Text,
+			VisualPrompt:      scene.BrollKeywords,
+			VisualDescription: scene.VisualDescription,
+			OnScreenText:      scene.OnScreenText,
+			LowerThird:        scene.LowerThird,
+			SectionTitle:      scene.SectionTitle,
+		}
+	}
+	return segments
+}
+
+// StoryboardMusicMood is the mood most scenes ask for (the earliest on a tie), or ""
+func StoryboardMusicMood(storyboard models.Storyboard) string {
+	counts := make(map[string]int)
+	var best string
+	for _, scene := range storyboard.Scenes {
+		mood := strings.ToLower(strings.TrimSpace(scene.MusicMood))
+		if mood == "" {
+			continue
+		}
+		counts[mood]++
+		if counts[mood] > counts[best] {
+			best = mood
+		}
+	}
+	return best
+}
+
+func trimScene(scene models.StoryboardScene) models.StoryboardScene {
+	scene.Text = strings.TrimSpace(scene.Text)
+	scene.VisualDescription = strings.TrimSpace(scene.VisualDescription)
+	scene.OnScreenText = strings.TrimSpace(scene.OnScreenText)
+	scene.LowerThird = strings.TrimSpace(scene.LowerThird)
+	scene.SectionTitle = strings.TrimSpace(scene.SectionTitle)
+	scene.BrollKeywords = strings.TrimSpace(scene.BrollKeywords)
+	scene.MusicMood = strings.TrimSpace(scene.MusicMood)
+	return scene
+}
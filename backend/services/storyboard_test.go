@@ -0,0 +1,94 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVideoWorkflowService_PlansStoryboard(t *testing.T) {
+	cfg := &config.Config{TempDir: t.TempDir(), MaxTextLength: 1000, VideoSegmentDuration: 5.0}
+	segments := []models.VideoSegment{
+		{Text: "Mèo ngủ trưa", VisualPrompt: "cat nap"},
+		{Text: "Chó chạy", VisualDescription: "A dog sprints across a beach"},
+	}
+
+	t.Run("Gemini plans the scenes", func(t *testing.T) {
+		jm := NewJobManager()
+		jm.CreateJob("job1", "youtube", "test")
+		gemini := &MockGeminiService{
+			Segments: segments,
+			Scenes: []models.StoryboardScene{
+				{Text: "rewritten", VisualDescription: " A tabby cat asleep in the sun ", OnScreenText: "Giờ ngủ", MusicMood: "calm"},
+			},
+		}
+		audio := &MockAudioService{Err: errors.New("audio must not run before approval")}
+		workflow := NewVideoWorkflowService(cfg, jm, NewTextProcessor(1000, 5.0), audio, nil, &MockStockVideoService{}, &MockComposerService{}, gemini)
+
+		workflow.StartGeneration("job1", models.GenerateRequest{Topic: "pets", Storyboard: true})
+
+		job, _ := jm.GetJob("job1")
+		if job.Status != "awaiting_approval" || job.Storyboard == nil {
+			t.Fatalf("Expected the job to wait for approval, got %q (%v)", job.Status, job.Error)
+		}
+		scenes := job.Storyboard.Scenes
+		if len(scenes) != 2 {
+			t.Fatalf("Expected a scene per segment, got %d", len(scenes))
+		}
+		want := models.StoryboardScene{Text: "Mèo ngủ trưa", VisualDescription: "A tabby cat asleep in the sun", OnScreenText: "Giờ ngủ", BrollKeywords: "cat nap", MusicMood: "calm"}
+		if scenes[0] != want {
+			t.Errorf("Scene 1 = %+v, want %+v", scenes[0], want)
+		}
+		if scenes[1].VisualDescription != "A dog sprints across a beach" || scenes[1].Text != "Chó chạy" {
+			t.Errorf("Unplanned scene should come from its segment, got %+v", scenes[1])
+		}
+	})
+
+	t.Run("failed planning keeps the script", func(t *testing.T) {
+		jm := NewJobManager()
+		jm.CreateJob("job2", "youtube", "test")
+		gemini := &MockGeminiService{Segments: segments, StoryboardErr: errors.New("quota")}
+		workflow := NewVideoWorkflowService(cfg, jm, NewTextProcessor(1000, 5.0), &MockAudioService{}, nil, &MockStockVideoService{}, &MockComposerService{}, gemini)
+
+		workflow.StartGeneration("job2", models.GenerateRequest{Topic: "pets", Storyboard: true})
+
+		job, _ := jm.GetJob("job2")
+		if job.Status != "awaiting_approval" || len(job.Storyboard.Scenes) != 2 || job.Storyboard.Scenes[0].BrollKeywords != "cat nap" {
+			t.Fatalf("Expected the script as storyboard, got %q %+v", job.Status, job.Storyboard)
+		}
+	})
+}
+
+func TestStoryboardHelpers(t *testing.T) {
+	storyboard := models.Storyboard{Scenes: []models.StoryboardScene{
+		{Text: " One ", BrollKeywords: "city night", OnScreenText: "Intro", MusicMood: "Upbeat"},
+		{Text: "Two", VisualDescription: "Rain on a window", MusicMood: "calm"},
+		{Text: "Three", MusicMood: "calm"},
+	}}
+	if err := ValidateStoryboard(storyboard); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	segments := StoryboardSegments(storyboard)
+	if len(segments) != 3 || segments[0].Text != "One" || segments[0].VisualPrompt != "city night" || segments[0].OnScreenText != "Intro" || segments[1].VisualDescription != "Rain on a window" {
+		t.Errorf("Unexpected segments %+v", segments)
+	}
+	if mood := StoryboardMusicMood(storyboard); mood != "calm" {
+		t.Errorf("Expected the most common mood, got %q", mood)
+	}
+
+	for _, bad := range []models.Storyboard{
+		{},
+		{Scenes: []models.StoryboardScene{{Text: " "}}},
+		{Scenes: []models.StoryboardScene{{Text: "ok", OnScreenText: strings.Repeat("x", maxOnScreenTextLen+1)}}},
+		{Scenes: []models.StoryboardScene{{Text: "ok", LowerThird: "Jane\n" + strings.Repeat("x", maxLowerThirdLen+1)}}},
+	} {
+		if err := ValidateStoryboard(bad); err == nil {
+			t.Errorf("Expected error for %+v", bad)
+		}
+	}
+	if StoryboardSource(VideoSourceStatic) || !StoryboardSource(VideoSourceAI) {
+		t.Error("Only per-scene sources take a storyboard")
+	}
+}
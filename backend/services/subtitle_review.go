@@ -0,0 +1,95 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Subtitle review limits
+const (
+	maxReviewCues    = 2000
+	maxReviewCueText = 500
+)
+
+// awaitSubtitleReview parks the job with its narration cues for review
+func (s *VideoWorkflowService) awaitSubtitleReview(jobID string, req models.GenerateRequest, assets *models.RenderAssets) {
+	style, _ := s.subtitleStyle(req, assets.Orientation)
+	cues, err := s.subtitleCues(assets.AudioPaths, assets.AudioTexts, "", style.Karaoke)
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, fmt.Errorf("failed to time subtitles for review: %w", err))
+		return
+	}
+	review := make([]models.SubtitleCue, len(cues))
+	for i, cue := range cues {
+		review[i] = models.SubtitleCue{Start: cue.Start, End: cue.End, Text: cue.Text}
+		for _, w := range cue.Words {
+			review[i].Words = append(review[i].Words, models.SubtitleWord{Start: w.Start, End: w.End, Text: w.Text})
+		}
+	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("%d subtitles ready for review", len(review)))
+	s.jobManager.MarkAwaitingReview(jobID, review)
+}
+
+// ContinueAfterReview composes the video of a subtitle_review job from its stored assets,
+// captioned with the reviewed job.Subtitles
+func (s *VideoWorkflowService) ContinueAfterReview(jobID string, job models.JobStatus) {
+	s.jobManager.UpdateProgress(jobID, "Applying reviewed subtitles", 60)
+
+	if job.Assets == nil {
+		s.jobManager.MarkFailed(jobID, fmt.Errorf("job %s has no assets to continue from", jobID))
+		return
+	}
+	tempDir, err := utils.CreateTempDir(s.cfg.TempDir, jobID)
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, fmt.Errorf("failed to create temp dir: %w", err))
+		return
+	}
+	defer utils.SetEncodeQuality(tempDir, job.Request.Quality)()
+	defer utils.SetFFmpegJob(tempDir, jobID)()
+
+	cues := make([]utils.SubtitleCue, len(job.Subtitles))
+	for i, cue := range job.Subtitles {
+		cues[i] = utils.SubtitleCue{Start: cue.Start, End: cue.End, Text: cue.Text}
+		for _, w := range cue.Words {
+			cues[i].Words = append(cues[i].Words, utils.SubtitleWord{Start: w.Start, End: w.End, Text: w.Text})
+		}
+	}
+	s.writeSubtitleFiles(jobID, tempDir, job.Request, cues)
+
+	mergedAudioPath, err := s.mergeAudio(jobID, tempDir, job.Assets.AudioPaths, job.Request)
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, err)
+		return
+	}
+
+	s.assemble(jobID, tempDir, job.Request, job.Assets, mergedAudioPath)
+}
+
+// ValidateSubtitleCues checks reviewed subtitles: 1-2000 cues with text, in order, each ending
+// after it starts
+func ValidateSubtitleCues(cues []models.SubtitleCue) error {
+	if len(cues) == 0 {
+		return fmt.Errorf("subtitles must have at least one cue")
+	}
+	if len(cues) > maxReviewCues {
+		return fmt.Errorf("subtitles may have at most %d cues", maxReviewCues)
+	}
+	for i, cue := range cues {
+		if strings.TrimSpace(cue.Text) == "" {
+			return fmt.Errorf("cue %d: text must not be empty", i+1)
+		}
+		if utf8.RuneCountInString(cue.Text) > maxReviewCueText {
+			return fmt.Errorf("cue %d: text exceeds %d characters", i+1, maxReviewCueText)
+		}
+		if cue.Start < 0 || cue.End <= cue.Start {
+			return fmt.Errorf("cue %d: end must be after a non-negative start", i+1)
+		}
+		if i > 0 && cue.Start < cues[i-1].Start {
+			return fmt.Errorf("cue %d: starts before the previous cue", i+1)
+		}
+	}
+	return nil
+}
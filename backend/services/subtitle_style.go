@@ -0,0 +1,135 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"aituber/utils"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Subtitle style limits; sizes are pixels of a 1080p frame
+const (
+	minSubtitleFontSize = 8
+	maxSubtitleFontSize = 300
+	maxSubtitleMarginV  = 1000
+	maxSubtitleFontLen  = 64
+	maxSubtitleLines    = 4
+	minSubtitleLength   = 20
+	maxSubtitleLength   = 500
+)
+
+// subtitleAlignments maps subtitle_style.position to the ASS numpad alignment
+var subtitleAlignments = map[string]int{"": 2, "bottom": 2, "middle": 5, "top": 8}
+
+// ValidateSubtitleStyle checks a subtitle_style (or the SUBTITLE_* settings); empty and zero
+// fields are valid and keep the default
+func ValidateSubtitleStyle(style models.SubtitleStyle) error {
+	if utf8.RuneCountInString(style.Font) > maxSubtitleFontLen || strings.ContainsAny(style.Font, ",\n\r") {
+		return fmt.Errorf("subtitle font must be a font name of at most %d characters without commas", maxSubtitleFontLen)
+	}
+	if style.Size != 0 && (style.Size < minSubtitleFontSize || style.Size > maxSubtitleFontSize) {
+		return fmt.Errorf("subtitle size must be between %d and %d (got %d)", minSubtitleFontSize, maxSubtitleFontSize, style.Size)
+	}
+	if style.MarginV < 0 || style.MarginV > maxSubtitleMarginV {
+		return fmt.Errorf("subtitle margin_v must be between 0 and %d (got %d)", maxSubtitleMarginV, style.MarginV)
+	}
+	if _, ok := subtitleAlignments[style.Position]; !ok {
+		return fmt.Errorf("subtitle position must be bottom, middle or top (got %q)", style.Position)
+	}
+	if style.MaxLines < 0 || style.MaxLines > maxSubtitleLines {
+		return fmt.Errorf("subtitle max_lines must be between 0 and %d (got %d)", maxSubtitleLines, style.MaxLines)
+	}
+	if style.MaxLength != 0 && (style.MaxLength < minSubtitleLength || style.MaxLength > maxSubtitleLength) {
+		return fmt.Errorf("subtitle max_length must be between %d and %d (got %d)", minSubtitleLength, maxSubtitleLength, style.MaxLength)
+	}
+	for field, color := range map[string]string{"primary_color": style.PrimaryColor, "outline_color": style.OutlineColor, "box_color": style.BoxColor, "highlight_color": style.HighlightColor} {
+		if color == "" {
+			continue
+		}
+		if _, err := utils.ASSColor(color); err != nil {
+			return fmt.Errorf("subtitle %s %q must be #RRGGBB", field, color)
+		}
+	}
+	return nil
+}
+
+// subtitleStyle is the built-in look for orientation, overridden field by field by the
+// SUBTITLE_* settings and then the request's subtitle_style, and the cues' line limit (0 = none)
+func (s *VideoWorkflowService) subtitleStyle(req models.GenerateRequest, orientation string) (utils.ASSStyle, int) {
+	style := utils.DefaultASSStyle(orientation)
+	maxLines := 0
+	for _, o := range []models.SubtitleStyle{ConfigSubtitleStyle(s.cfg), req.SubtitleStyle} {
+		if o.Font = strings.TrimSpace(o.Font); o.Font != "" {
+			style.Font = o.Font
+		}
+		if o.Size > 0 {
+			style.Size = o.Size
+		}
+		if o.PrimaryColor != "" {
+			style.PrimaryColor = o.PrimaryColor
+		}
+		if o.OutlineColor != "" {
+			style.OutlineColor = o.OutlineColor
+		}
+		if o.MarginV > 0 {
+			style.MarginV = o.MarginV
+		}
+		if o.Position != "" {
+			style.Alignment = subtitleAlignments[o.Position]
+		}
+		if o.Box != nil {
+			style.Box = *o.Box
+		}
+		if o.BoxColor != "" {
+			style.BoxColor = o.BoxColor
+		}
+		if o.MaxLines > 0 {
+			maxLines = o.MaxLines
+		}
+		if o.Karaoke != nil {
+			style.Karaoke = *o.Karaoke
+		}
+		if o.HighlightColor != "" {
+			style.HighlightColor = o.HighlightColor
+		}
+	}
+	return style, maxLines
+}
+
+// subtitleLimits is how SplitForSubtitles cuts a script into cues for req: max_length from the
+// request or SUBTITLE_MAX_LENGTH, and the style's line limit at the line length of its font
+func (s *VideoWorkflowService) subtitleLimits(req models.GenerateRequest, orientation string) SubtitleLimits {
+	style, maxLines := s.subtitleStyle(req, orientation)
+	limits := SubtitleLimits{MaxLines: maxLines, LineChars: utils.SubtitleLineChars(style, orientation)}
+	for _, o := range []models.SubtitleStyle{ConfigSubtitleStyle(s.cfg), req.SubtitleStyle} {
+		if o.MaxLength > 0 {
+			limits.MaxLength = o.MaxLength
+		}
+	}
+	return limits
+}
+
+// ConfigSubtitleStyle is the SUBTITLE_* settings as a subtitle_style
+func ConfigSubtitleStyle(cfg *config.Config) models.SubtitleStyle {
+	style := models.SubtitleStyle{
+		Font:           cfg.SubtitleFont,
+		Size:           cfg.SubtitleFontSize,
+		PrimaryColor:   cfg.SubtitlePrimaryColor,
+		OutlineColor:   cfg.SubtitleOutlineColor,
+		MarginV:        cfg.SubtitleMarginV,
+		Position:       cfg.SubtitlePosition,
+		BoxColor:       cfg.SubtitleBoxColor,
+		MaxLines:       cfg.SubtitleMaxLines,
+		MaxLength:      cfg.SubtitleMaxLength,
+		HighlightColor: cfg.SubtitleHighlightColor,
+	}
+	if cfg.SubtitleBox {
+		style.Box = &cfg.SubtitleBox
+	}
+	if cfg.SubtitleKaraoke {
+		style.Karaoke = &cfg.SubtitleKaraoke
+	}
+	return style
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"strings"
+	"testing"
+)
+
+func TestValidateSubtitleStyle(t *testing.T) {
+	valid := []models.SubtitleStyle{{}, {Font: "Be Vietnam Pro", Size: 64, PrimaryColor: "#FFD400", OutlineColor: "#101010", MarginV: 200, Position: "middle", BoxColor: "#202020", MaxLines: 2}}
+	for _, style := range valid {
+		if err := ValidateSubtitleStyle(style); err != nil {
+			t.Errorf("%+v rejected: %v", style, err)
+		}
+	}
+	invalid := []models.SubtitleStyle{
+		{Font: "Arial,Bold"},
+		{Font: strings.Repeat("x", maxSubtitleFontLen+1)},
+		{Size: 4},
+		{Size: maxSubtitleFontSize + 1},
+		{MarginV: -1},
+		{PrimaryColor: "yellow"},
+		{OutlineColor: "#12345"},
+		{BoxColor: "black"},
+		{Position: "left"},
+		{MaxLines: maxSubtitleLines + 1},
+		{HighlightColor: "#FFFF0"},
+		{MaxLength: minSubtitleLength - 1},
+	}
+	for _, style := range invalid {
+		if err := ValidateSubtitleStyle(style); err == nil {
+			t.Errorf("expected %+v to be rejected", style)
+		}
+	}
+}
+
+func TestSubtitleStyle_Precedence(t *testing.T) {
+	s := &VideoWorkflowService{cfg: &config.Config{SubtitleFont: "Roboto", SubtitlePrimaryColor: "#00FF00", SubtitleMarginV: 90}}
+	s.cfg.SubtitleBox = true
+	s.cfg.SubtitleMaxLines = 2
+	noBox := false
+	req := models.GenerateRequest{SubtitleStyle: models.SubtitleStyle{PrimaryColor: "#FF0000", Size: 70, Position: "top", Box: &noBox}}
+
+	style, maxLines := s.subtitleStyle(req, "landscape")
+	if style.Font != "Roboto" || style.PrimaryColor != "#FF0000" || style.Size != 70 || style.MarginV != 90 || style.OutlineColor != "#000000" {
+		t.Errorf("expected request over settings over defaults, got %+v", style)
+	}
+	if style.Alignment != 8 || style.Box || maxLines != 2 {
+		t.Errorf("expected top alignment, the box turned off by the request and 2 lines, got %+v, %d", style, maxLines)
+	}
+	if style, _ := s.subtitleStyle(models.GenerateRequest{}, "portrait"); style.Size != 120 || style.PrimaryColor != "#00FF00" || !style.Box || style.Alignment != 2 {
+		t.Errorf("expected the portrait default size with the configured color and box, got %+v", style)
+	}
+
+	s.cfg.SubtitleKaraoke = true
+	s.cfg.SubtitleHighlightColor = "#FF00FF"
+	if style, _ := s.subtitleStyle(models.GenerateRequest{}, "landscape"); !style.Karaoke || style.HighlightColor != "#FF00FF" {
+		t.Errorf("expected karaoke from the settings, got %+v", style)
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Machine translation services for subtitle_languages, selected with SUBTITLE_TRANSLATOR
+const (
+	TranslatorDeepL  = "deepl"
+	TranslatorGoogle = "google"
+	TranslatorLLM    = "llm" // the VISUAL_PROMPT_LLM model
+)
+
+const (
+	deeplAPIBase     = "https://api.deepl.com"
+	deeplFreeAPIBase = "https://api-free.deepl.com" // for keys ending in ":fx"
+	googleAPIBase    = "https://translation.googleapis.com"
+)
+
+// maxTranslationBatch is how many cues go into one request; DeepL takes at most 50 texts
+const maxTranslationBatch = 50
+
+// MaxSubtitleLanguages bounds subtitle_languages
+const MaxSubtitleLanguages = 10
+
+// subtitleLanguagePattern accepts language codes such as "en", "ja" or "pt-BR"
+var subtitleLanguagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// ValidSubtitleLanguage reports whether lang looks like a language code; it also keeps the
+// code safe to use in file names
+func ValidSubtitleLanguage(lang string) bool {
+	return subtitleLanguagePattern.MatchString(lang)
+}
+
+// SubtitleTranslator machine-translates subtitle lines
+type SubtitleTranslator interface {
+	// Translate returns texts in the target language, one per input and in order
+	Translate(ctx context.Context, texts []string, target string) ([]string, error)
+}
+
+// translateCall makes one translation request with apiKey, returning the HTTP status of a
+// failed call (0 for transport errors)
+type translateCall func(ctx context.Context, texts []string, target, apiKey string) ([]string, int, error)
+
+// pooledTranslator batches texts and rotates through a key pool
+type pooledTranslator struct {
+	name string
+	pool *utils.APIKeyPool
+	call translateCall
+}
+
+// Translate implements SubtitleTranslator
+func (p *pooledTranslator) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	out := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); start += maxTranslationBatch {
+		batch := texts[start:min(start+maxTranslationBatch, len(texts))]
+		var translated []string
+		_, err := callWithKeyRotation(ctx, p.pool, p.name, 2, func(apiKey string) ([]byte, int, error) {
+			var status int
+			var err error
+			translated, status, err = p.call(ctx, batch, target, apiKey)
+			return nil, status, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(translated) != len(batch) {
+			return nil, fmt.Errorf("%s returned %d translations for %d lines", p.name, len(translated), len(batch))
+		}
+		out = append(out, translated...)
+	}
+	return out, nil
+}
+
+// translationAPI holds the endpoint shared by the vendor calls
+type translationAPI struct {
+	chatLLM
+	llm PromptLLM
+}
+
+// NewSubtitleTranslator creates the named translator. DeepL and Google take a key pool and an
+// optional baseURL (empty for the public API, DeepL's free API for ":fx" keys); "llm" asks llm.
+func NewSubtitleTranslator(name string, pool *utils.APIKeyPool, llm PromptLLM, baseURL string) (SubtitleTranslator, error) {
+	t := &translationAPI{
+		chatLLM: chatLLM{baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{Timeout: time.Minute}},
+		llm:     llm,
+	}
+	var call translateCall
+	switch name {
+	case TranslatorDeepL:
+		call = t.deepl
+	case TranslatorGoogle:
+		if t.baseURL == "" {
+			t.baseURL = googleAPIBase
+		}
+		call = t.google
+	case TranslatorLLM:
+		if llm == nil {
+			return nil, fmt.Errorf("llm translation requires VISUAL_PROMPT_LLM")
+		}
+		pool = nil
+		call = t.chat
+	default:
+		return nil, fmt.Errorf("unknown subtitle translator %q", name)
+	}
+	return &pooledTranslator{name: name, pool: pool, call: call}, nil
+}
+
+// deepl calls /v2/translate; DeepL wants upper-case target codes ("EN", "PT-BR")
+func (t *translationAPI) deepl(ctx context.Context, texts []string, target, apiKey string) ([]string, int, error) {
+	base := t.baseURL
+	if base == "" {
+		base = deeplAPIBase
+		if strings.HasSuffix(apiKey, ":fx") {
+			base = deeplFreeAPIBase
+		}
+	}
+	body, _ := json.Marshal(map[string]interface{}{"text": texts, "target_lang": strings.ToUpper(target)})
+	var out struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	headers := map[string]string{"Authorization": "DeepL-Auth-Key " + apiKey}
+	if status, err := t.post(ctx, base+"/v2/translate", headers, body, &out); err != nil {
+		return nil, status, fmt.Errorf("deepl translation failed: %w", err)
+	}
+	translated := make([]string, len(out.Translations))
+	for i, tr := range out.Translations {
+		translated[i] = tr.Text
+	}
+	return translated, 0, nil
+}
+
+// google calls Cloud Translation v2; the key goes in the query string
+func (t *translationAPI) google(ctx context.Context, texts []string, target, apiKey string) ([]string, int, error) {
+	body, _ := json.Marshal(map[string]interface{}{"q": texts, "target": target, "format": "text"})
+	var out struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	endpoint := t.baseURL + "/language/translate/v2?key=" + url.QueryEscape(apiKey)
+	if status, err := t.post(ctx, endpoint, nil, body, &out); err != nil {
+		return nil, status, fmt.Errorf("google translation failed: %w", err)
+	}
+	translated := make([]string, len(out.Data.Translations))
+	for i, tr := range out.Data.Translations {
+		translated[i] = tr.TranslatedText
+	}
+	return translated, 0, nil
+}
+
+// chat asks the language model for a JSON array with one translation per line
+func (t *translationAPI) chat(ctx context.Context, texts []string, target, _ string) ([]string, int, error) {
+	lines, _ := json.Marshal(texts)
+	reply, err := t.llm.Complete(ctx, fmt.Sprintf(`Translate these video subtitle lines into the language with code %q. Keep each line short and natural for captions and keep the order.
+
+%s
+
+Reply with a JSON array of exactly %d strings and nothing else.`, target, lines, len(texts)))
+	if err != nil {
+		return nil, 0, err
+	}
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, 0, fmt.Errorf("llm reply has no JSON array")
+	}
+	var translated []string
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &translated); err != nil {
+		return nil, 0, fmt.Errorf("invalid llm translation: %w", err)
+	}
+	return translated, 0, nil
+}
+
+// SetSubtitleTranslator enables GenerateRequest.SubtitleLanguages
+func (s *VideoWorkflowService) SetSubtitleTranslator(t SubtitleTranslator) {
+	s.translator = t
+}
+
+// translateSubtitles writes subtitles.<lang>.srt and .vtt for each of req's subtitle_languages,
+// cue times kept from cues. A language that fails is logged and skipped.
+func (s *VideoWorkflowService) translateSubtitles(jobID, outputDir string, req models.GenerateRequest, cues []utils.SubtitleCue, lineChars, maxLines int) {
+	if len(req.SubtitleLanguages) == 0 || len(cues) == 0 {
+		return
+	}
+	if s.translator == nil {
+		s.jobManager.LogEvent(jobID, "Subtitle translation skipped: SUBTITLE_TRANSLATOR is not configured")
+		return
+	}
+	texts := make([]string, len(cues))
+	for i, cue := range cues {
+		texts[i] = cue.Text
+	}
+	for _, lang := range req.SubtitleLanguages {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+		translated, err := s.translator.Translate(ctx, texts, lang)
+		cancel()
+		if err == nil {
+			langCues := make([]utils.SubtitleCue, len(cues))
+			for i, cue := range cues {
+				langCues[i] = utils.SubtitleCue{Start: cue.Start, End: cue.End, Text: strings.TrimSpace(translated[i])}
+			}
+			langCues = utils.WrapCues(langCues, lineChars, maxLines)
+			base := filepath.Join(outputDir, "subtitles."+lang)
+			if err = writeSRTCues(base+".srt", langCues); err == nil {
+				err = utils.WriteVTT(base+".vtt", langCues)
+			}
+		}
+		if err != nil {
+			log.Printf("[Job %s] Subtitle translation to %s failed: %v", jobID, lang, err)
+			s.jobManager.LogEvent(jobID, fmt.Sprintf("Subtitle translation to %s failed: %v", lang, err))
+			continue
+		}
+		s.jobManager.LogEvent(jobID, fmt.Sprintf("Subtitles translated to %s", lang))
+	}
+}
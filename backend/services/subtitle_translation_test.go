@@ -0,0 +1,123 @@
+package services
+
+import (
+	"aituber/config"
+	"aituber/models"
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubtitleTranslator_Vendors(t *testing.T) {
+	var batches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text         []string `json:"text"`
+			Target       string   `json:"target_lang"`
+			Q            []string `json:"q"`
+			GoogleTarget string   `json:"target"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v2/translate":
+			if r.Header.Get("Authorization") != "DeepL-Auth-Key dl-key" || body.Target != "PT-BR" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			batches++
+			var out []map[string]string
+			for _, text := range body.Text {
+				out = append(out, map[string]string{"text": "pt:" + text})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"translations": out})
+		case "/language/translate/v2":
+			if r.URL.Query().Get("key") != "g-key" || body.GoogleTarget != "ja" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			var out []map[string]string
+			for _, text := range body.Q {
+				out = append(out, map[string]string{"translatedText": "ja:" + text})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"translations": out}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	texts := make([]string, maxTranslationBatch+5)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("dòng %d", i)
+	}
+	deepl, _ := NewSubtitleTranslator(TranslatorDeepL, utils.NewAPIKeyPool([]string{"dl-key"}), nil, srv.URL)
+	got, err := deepl.Translate(context.Background(), texts, "pt-BR")
+	if err != nil || len(got) != len(texts) || got[maxTranslationBatch] != fmt.Sprintf("pt:dòng %d", maxTranslationBatch) || batches != 2 {
+		t.Errorf("deepl: got %d lines in %d batches, %v", len(got), batches, err)
+	}
+
+	google, _ := NewSubtitleTranslator(TranslatorGoogle, utils.NewAPIKeyPool([]string{"g-key"}), nil, srv.URL)
+	if got, err := google.Translate(context.Background(), []string{"xin chào"}, "ja"); err != nil || got[0] != "ja:xin chào" {
+		t.Errorf("google: got %v, %v", got, err)
+	}
+
+	llm, _ := NewSubtitleTranslator(TranslatorLLM, nil, &countingPromptLLM{reply: "Sure:\n[\"hello\", \"world\"]"}, "")
+	if got, err := llm.Translate(context.Background(), []string{"xin chào", "thế giới"}, "en"); err != nil || strings.Join(got, " ") != "hello world" {
+		t.Errorf("llm: got %v, %v", got, err)
+	}
+	short, _ := NewSubtitleTranslator(TranslatorLLM, nil, &countingPromptLLM{reply: "[\"hello\"]"}, "")
+	if _, err := short.Translate(context.Background(), []string{"a", "b"}, "en"); err == nil {
+		t.Error("expected a reply with missing lines to be rejected")
+	}
+
+	if _, err := NewSubtitleTranslator(TranslatorLLM, nil, nil, ""); err == nil {
+		t.Error("expected llm without a model to be rejected")
+	}
+	if _, err := NewSubtitleTranslator("babelfish", nil, nil, ""); err == nil {
+		t.Error("expected an unknown translator to be rejected")
+	}
+}
+
+func TestVideoWorkflowService_TranslateSubtitles(t *testing.T) {
+	outputDir := t.TempDir()
+	jm := &MockJobManager{}
+	s := NewVideoWorkflowService(&config.Config{}, jm, nil, nil, nil, nil, nil, nil)
+	s.SetSubtitleTranslator(&prefixTranslator{failFor: "ja"})
+
+	cues := []utils.SubtitleCue{{Start: 0, End: 2, Text: "xin chào"}}
+	s.translateSubtitles("job1", outputDir, models.GenerateRequest{SubtitleLanguages: []string{"en", "ja"}}, cues, 40, 0)
+
+	vtt, err := os.ReadFile(filepath.Join(outputDir, "subtitles.en.vtt"))
+	if err != nil || string(vtt) != "WEBVTT\n\n00:00:00.000 --> 00:00:02.000\nen: xin chào\n\n" {
+		t.Errorf("unexpected VTT %q, %v", vtt, err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "subtitles.en.srt")); err != nil {
+		t.Errorf("expected the SRT translation: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "subtitles.ja.srt")); err == nil {
+		t.Error("expected no file for the failed language")
+	}
+}
+
+// prefixTranslator "translates" by prefixing the language code, failing for failFor
+type prefixTranslator struct {
+	failFor string
+}
+
+func (p *prefixTranslator) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	if target == p.failFor {
+		return nil, fmt.Errorf("unsupported language")
+	}
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = target + ": " + text
+	}
+	return out, nil
+}
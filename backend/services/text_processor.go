@@ -2,8 +2,10 @@ package services
 
 import (
 	"aituber/models"
+	"aituber/utils"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 var vietnameseStopWords = map[string]bool{
@@ -66,7 +68,21 @@ type TextProcessor struct {
 	AudioChunkSize       int
 	VideoSegmentDuration float64
 	AvgWordsPerMinute    float64 // Default: 150 words per minute
-	MaxSubtitleLength    int     // Default: 100 chars
+	MaxSubtitleLength    int     // Default: 100 columns (utils.DisplayWidth)
+}
+
+// byteWidth measures audio chunks: TTS request limits count bytes of UTF-8
+func byteWidth(r rune) int {
+	return utf8.RuneLen(r)
+}
+
+// textWidth is the total width of s, rune by rune
+func textWidth(s string, width func(rune) int) int {
+	n := 0
+	for _, r := range s {
+		n += width(r)
+	}
+	return n
 }
 
 // NewTextProcessor creates a new text processor
@@ -83,11 +99,15 @@ func NewTextProcessor(audioChunkSize int, videoSegmentDuration float64) *TextPro
 // - Maximum characters per chunk defined by AudioChunkSize
 // - Splits strictly at sentence boundaries where possible
 // - Uses smart splitting for long sentences (punctuation > phrases)
+// - Never splits inside SSML tags (see splitSSML)
 func (tp *TextProcessor) SplitForAudio(text string) []string {
 	text = strings.TrimSpace(text)
 	if text == "" {
 		return []string{}
 	}
+	if IsSSML(text) {
+		return tp.splitSSML(text, tp.AudioChunkSize, byteWidth, tp.splitAudio)
+	}
 
 	if len(text) <= tp.AudioChunkSize {
 		return []string{text}
@@ -128,7 +148,7 @@ func (tp *TextProcessor) SplitForAudio(text string) []string {
 			// Start new chunk with current sentence
 			// If single sentence is too long, we must split it intelligently
 			if len(sentence) > tp.AudioChunkSize {
-				smartChunks := tp.smartSplit(sentence, tp.AudioChunkSize)
+				smartChunks := tp.splitAudio(sentence, tp.AudioChunkSize)
 				chunks = append(chunks, smartChunks...)
 				currentChunk = ""
 			} else {
@@ -145,121 +165,157 @@ func (tp *TextProcessor) SplitForAudio(text string) []string {
 	return chunks
 }
 
+// splitAudio splits a sentence longer than limit bytes for TTS
+func (tp *TextProcessor) splitAudio(text string, limit int) []string {
+	return tp.smartSplit(text, limit, byteWidth)
+}
+
+// SubtitleLimits bound the chunks of SplitForSubtitles for one job
+type SubtitleLimits struct {
+	MaxLength int // columns per chunk (utils.DisplayWidth); 0 = MaxSubtitleLength
+	MaxLines  int // lines per chunk once wrapped at LineChars; 0 = no limit
+	LineChars int
+}
+
 // SplitForSubtitles splits text into chunks where each chunk is one subtitle line and one audio file.
-// Prioritizes readability and sentence boundaries.
-func (tp *TextProcessor) SplitForSubtitles(text string) []string {
+// Prioritizes readability and sentence boundaries. Lengths are display widths, so a Vietnamese
+// line holds as many letters as an English one and a Chinese line half as many characters.
+func (tp *TextProcessor) SplitForSubtitles(text string, limits SubtitleLimits) []string {
 	text = strings.TrimSpace(text)
 	if text == "" {
 		return []string{}
 	}
+	maxLength := limits.MaxLength
+	if maxLength <= 0 {
+		maxLength = tp.MaxSubtitleLength
+	}
+	if IsSSML(text) {
+		return tp.splitSSML(text, maxLength, utils.RuneWidth, tp.splitByClauses)
+	}
 
 	chunks := []string{}
 	sentences := tp.splitIntoSentences(text)
 
 	for _, sentence := range sentences {
 		sentence = strings.TrimSpace(sentence)
-		if len(sentence) <= tp.MaxSubtitleLength {
+		if utils.DisplayWidth(sentence) <= maxLength {
 			chunks = append(chunks, sentence)
 			continue
 		}
 
 		// Sentence too long, split by clauses (comma, semicolon)
-		subChunks := tp.splitByClauses(sentence, tp.MaxSubtitleLength)
+		subChunks := tp.splitByClauses(sentence, maxLength)
 		chunks = append(chunks, subChunks...)
 	}
 
-	return chunks
+	return tp.limitLines(chunks, limits)
 }
 
-// splitByClauses splits a long sentence by punctuation (comma, semicolon) or words if needed
-func (tp *TextProcessor) splitByClauses(text string, limit int) []string {
-	chunks := []string{}
-
-	// Split by comma first
-	parts := strings.FieldsFunc(text, func(r rune) bool {
-		return r == ',' || r == ';'
-	})
-
-	currentMsg := ""
-	for i, part := range parts {
-		part = strings.TrimSpace(part)
-
-		// Add comma back if it's not the last part (approximation)
-		suffix := ""
-		if i < len(parts)-1 {
-			suffix = ","
+// limitLines splits chunks that wrap to more than limits.MaxLines lines: at clauses when that is
+// enough, otherwise every MaxLines wrapped lines
+func (tp *TextProcessor) limitLines(chunks []string, limits SubtitleLimits) []string {
+	if limits.MaxLines <= 0 || limits.LineChars <= 0 {
+		return chunks
+	}
+	fits := func(chunk string) bool { return len(utils.WrapLines(chunk, limits.LineChars)) <= limits.MaxLines }
+	var out []string
+	for _, chunk := range chunks {
+		if fits(chunk) {
+			out = append(out, chunk)
+			continue
 		}
-
-		if len(currentMsg)+len(part)+len(suffix)+1 <= limit {
-			if currentMsg != "" {
-				currentMsg += " " + part + suffix
-			} else {
-				currentMsg = part + suffix
+		for _, part := range tp.splitByClauses(chunk, limits.LineChars*limits.MaxLines) {
+			if fits(part) {
+				out = append(out, part)
+				continue
 			}
-		} else {
-			if currentMsg != "" {
-				chunks = append(chunks, currentMsg)
+			lines := utils.WrapLines(part, limits.LineChars)
+			for i := 0; i < len(lines); i += limits.MaxLines {
+				out = append(out, strings.Join(lines[i:min(i+limits.MaxLines, len(lines))], " "))
 			}
+		}
+	}
+	return out
+}
 
-			// Check if the part itself is too long
-			if len(part+suffix) > limit {
-				// Split using smartSplit (handling words and other punctuation)
-				// We use smartSplit instead of splitLongText for better results
-				smartChunks := tp.smartSplit(part+suffix, limit)
-				chunks = append(chunks, smartChunks...)
-				currentMsg = ""
-			} else {
-				currentMsg = part + suffix
-			}
+// clausePunctuation ends a clause, in Latin and full-width (Chinese, Japanese) forms
+const clausePunctuation = ",;，；、"
+
+// splitByClauses splits a long sentence after commas and semicolons, then with smartSplit where
+// one clause alone is longer than limit. Limits are display widths; clauses keep their own
+// punctuation and spacing.
+func (tp *TextProcessor) splitByClauses(text string, limit int) []string {
+	chunks := []string{}
+	currentMsg := ""
+	flush := func() {
+		if msg := strings.TrimSpace(currentMsg); msg != "" {
+			chunks = append(chunks, msg)
 		}
+		currentMsg = ""
 	}
 
-	if currentMsg != "" {
-		chunks = append(chunks, currentMsg)
+	for _, clause := range splitAfterAny(text, clausePunctuation) {
+		if utils.DisplayWidth(strings.TrimSpace(currentMsg+clause)) <= limit {
+			currentMsg += clause
+			continue
+		}
+		flush()
+		if part := strings.TrimSpace(clause); utils.DisplayWidth(part) > limit {
+			chunks = append(chunks, tp.smartSplit(part, limit, utils.RuneWidth)...)
+		} else {
+			currentMsg = clause
+		}
 	}
+	flush()
 
 	return chunks
 }
 
-// smartSplit splits a long text intelligently based on punctuation priorities
-func (tp *TextProcessor) smartSplit(text string, limit int) []string {
+// splitAfterAny cuts text after every rune in chars, keeping the runes and the spacing
+func splitAfterAny(text, chars string) []string {
+	var parts []string
+	start := 0
+	for i, r := range text {
+		if strings.ContainsRune(chars, r) {
+			end := i + utf8.RuneLen(r)
+			parts = append(parts, text[start:end])
+			start = end
+		}
+	}
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+	return parts
+}
+
+// splitPunctuation are the split points smartSplit tries first, in Latin and full-width forms
+var splitPunctuation = []string{";", ":", ",", " - ", " — ", ".", "；", "：", "，", "、", "。"}
+
+// smartSplit splits a long text intelligently based on punctuation priorities. Lengths are
+// measured rune by rune with width, and cuts fall on character boundaries only.
+func (tp *TextProcessor) smartSplit(text string, limit int, width func(rune) int) []string {
 	var chunks []string
 	remaining := text
 
-	for len(remaining) > limit {
-		// Find the best split point within the limit
-		// We look backwards from the limit to find the first suitable split point
-		splitIdx := -1
+	for textWidth(remaining, width) > limit {
+		// Find the best split point within the limit: the longest prefix that fits ends at limitIdx
+		limitIdx := utils.FitPrefix(remaining, limit, width)
 
 		// Search range: we want to find a split point roughly between limit/3 and limit
 		// to avoid creating too many tiny chunks, but priority is validity (< limit)
-		searchStart := limit / 3
-		if searchStart < 0 {
-			searchStart = 0
-		}
+		searchStart := utils.FitPrefix(remaining, limit/3, width)
 
 		// 1. Try splitting at major punctuation (comma, semicolon, colon, etc.)
-		// Priority: ; : , - — .
-		punctuations := []string{";", ":", ",", " - ", " — ", "."}
-		bestPuncIdx := -1
-
-		// Helper to find punctuation in a range
+		// Helper to find the last punctuation within a byte range, splitting after it
 		findPunc := func(start, end int) int {
 			localBestIdx := -1
-			for _, punc := range punctuations {
-				// Find LAST occurrence of this punctuation within range
-				// Extract substring to search in
-				if start >= end {
-					continue
-				}
-				searchArea := remaining[start:end]
-
+			if start >= end {
+				return localBestIdx
+			}
+			searchArea := remaining[start:end]
+			for _, punc := range splitPunctuation {
 				if idx := strings.LastIndex(searchArea, punc); idx != -1 {
-					// absolute index = start + idx + length of punctuation
-					actualIdx := start + idx + len(punc)
-
-					// Keep punctuation with the preceding chunk usually, or split after it
-					if actualIdx > localBestIdx {
+					if actualIdx := start + idx + len(punc); actualIdx > localBestIdx {
 						localBestIdx = actualIdx
 					}
 				}
@@ -268,34 +324,24 @@ func (tp *TextProcessor) smartSplit(text string, limit int) []string {
 		}
 
 		// First pass: Try preferred range [limit/3, limit]
-		limitIdx := limit
-		if limitIdx > len(remaining) {
-			limitIdx = len(remaining)
-		}
-		bestPuncIdx = findPunc(searchStart, limitIdx)
+		splitIdx := findPunc(searchStart, limitIdx)
 
 		// Second pass: If no punctuation found in preferred range, try [0, limit/3]
 		// This prevents "hard splits" when punctuation is only at the start
-		if bestPuncIdx == -1 {
-			bestPuncIdx = findPunc(0, searchStart)
+		if splitIdx == -1 {
+			splitIdx = findPunc(0, searchStart)
 		}
 
-		if bestPuncIdx != -1 {
-			splitIdx = bestPuncIdx
-		} else {
-			// 2. Fallback: Split at logical phrase boundaries (spaces)
-			// Find last space before limit
-			limitIdx := limit
-			if limitIdx > len(remaining) {
-				limitIdx = len(remaining)
-			}
-
-			lastSpace := strings.LastIndex(remaining[:limitIdx], " ")
-			if lastSpace != -1 {
-				splitIdx = lastSpace
-			} else {
-				// 3. Last Resort: Hard split at limit
-				splitIdx = limit
+		if splitIdx == -1 {
+			// 2. Fallback: Split at a line break opportunity: a space, or between Chinese or
+			// Japanese characters
+			splitIdx = utils.LastBreak(remaining, limitIdx)
+		}
+		if splitIdx <= 0 {
+			// 3. Last Resort: Hard split at limit, or after one character wider than the limit
+			splitIdx = limitIdx
+			if splitIdx == 0 {
+				splitIdx = utils.FitPrefix(remaining, width(firstRune(remaining)), width)
 			}
 		}
 
@@ -316,6 +362,12 @@ func (tp *TextProcessor) smartSplit(text string, limit int) []string {
 	return chunks
 }
 
+// firstRune is the first character of s
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
 // ExtractKeywordsFromText extracts meaningful keywords from a text segment for use as a Pexels search query.
 // It strips common Vietnamese and English stop words and returns up to 5 significant words.
 // An optional styleHint (e.g. "cinematic nature") is appended to the result.
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"aituber/utils"
 	"strings"
 	"testing"
 )
@@ -96,7 +97,7 @@ func TestSmartSplit(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			chunks := tp.smartSplit(tt.input, chunkSize)
+			chunks := tp.smartSplit(tt.input, chunkSize, utils.RuneWidth)
 
 			// Basic verification: Combined text should match input (minus spacing variations if any)
 			// But smartSplit might trim spaces.
@@ -394,3 +395,57 @@ func TestGetStats(t *testing.T) {
 		t.Error("total_words should not be 0")
 	}
 }
+
+func TestSplitForSubtitles_Limits(t *testing.T) {
+	tp := NewTextProcessor(4500, 5.5)
+	text := "Hôm nay trời đẹp, chúng ta đi dạo quanh hồ và ngắm hoa nở rộ khắp nơi trong công viên."
+
+	if chunks := tp.SplitForSubtitles("Hôm nay trời đẹp, chúng ta đi dạo quanh hồ.", SubtitleLimits{}); len(chunks) != 1 {
+		t.Errorf("expected the sentence to fit the default length, got %q", chunks)
+	}
+	if chunks := tp.SplitForSubtitles(text, SubtitleLimits{MaxLength: 40}); len(chunks) < 3 {
+		t.Errorf("expected a 40-character limit to split the sentence, got %q", chunks)
+	}
+
+	chunks := tp.SplitForSubtitles(text, SubtitleLimits{MaxLines: 1, LineChars: 30})
+	if len(chunks) < 3 || strings.Join(strings.Fields(strings.Join(chunks, " ")), " ") != text {
+		t.Errorf("expected the words kept in several chunks, got %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if lines := utils.WrapLines(chunk, 30); len(lines) != 1 {
+			t.Errorf("chunk %q wraps to %d lines, want 1", chunk, len(lines))
+		}
+	}
+}
+
+func TestSplitForSubtitles_Width(t *testing.T) {
+	tp := NewTextProcessor(4500, 5.5)
+
+	// 86 letters but 110 bytes: fits the default 100 columns
+	vi := "Hôm nay trời đẹp, chúng ta đi dạo quanh hồ và ngắm hoa nở rộ khắp nơi trong công viên."
+	if chunks := tp.SplitForSubtitles(vi, SubtitleLimits{}); len(chunks) != 1 {
+		t.Errorf("expected the Vietnamese sentence in one chunk, got %q", chunks)
+	}
+
+	ja := "今日はとても天気がいいので、私たちは公園に行って桜の花を見ながらお弁当を食べることにしました。"
+	chunks := tp.SplitForSubtitles(ja, SubtitleLimits{MaxLength: 30})
+	if len(chunks) < 3 || strings.Join(chunks, "") != ja {
+		t.Fatalf("expected the characters kept in several chunks without spaces, got %q", chunks)
+	}
+	if !strings.HasSuffix(chunks[0], "、") {
+		t.Errorf("expected the first cut after the comma, got %q", chunks[0])
+	}
+	for _, chunk := range chunks {
+		if w := utils.DisplayWidth(chunk); w > 30 {
+			t.Errorf("chunk %q is %d columns wide", chunk, w)
+		}
+		if r := []rune(chunk); r[0] == '。' || r[0] == 'ょ' {
+			t.Errorf("chunk %q starts with a character that cannot start a line", chunk)
+		}
+	}
+
+	// Clauses keep their own punctuation
+	if chunks := tp.SplitForSubtitles("Một; hai; ba bốn năm sáu bảy tám chín mười", SubtitleLimits{MaxLength: 20}); chunks[0] != "Một; hai;" {
+		t.Errorf("expected the semicolons kept, got %q", chunks)
+	}
+}
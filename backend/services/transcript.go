@@ -0,0 +1,41 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// TranscriptFileName is the JSON transcript saved next to a job's subtitles
+const TranscriptFileName = "transcript.json"
+
+// writeTranscript writes cues, one per narrated segment and timed like the subtitle files, as a
+// models.Transcript spoken by req's voice
+func writeTranscript(path string, req models.GenerateRequest, cues []utils.SubtitleCue) error {
+	transcript := models.Transcript{Segments: make([]models.TranscriptSegment, len(cues))}
+	for i, cue := range cues {
+		seg := models.TranscriptSegment{
+			Start:      cue.Start,
+			End:        cue.End,
+			Text:       cue.Text,
+			Speaker:    req.Voice,
+			Confidence: 1 - cue.Unheard,
+		}
+		for _, w := range cue.Words {
+			seg.Words = append(seg.Words, models.SubtitleWord{Start: w.Start, End: w.End, Text: w.Text})
+		}
+		transcript.Segments[i] = seg
+		transcript.Duration = math.Max(transcript.Duration, cue.End)
+	}
+	data, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
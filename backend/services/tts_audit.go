@@ -0,0 +1,150 @@
+package services
+
+import (
+	"aituber/models"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TTSAuditLog persists one models.TTSAuditRecord per provider call, as a JSON-lines file per
+// job, so sporadic provider failures and quota disputes can be investigated after the fact.
+// It lives outside TEMP_DIR so records outlive the job's working files.
+type TTSAuditLog struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewTTSAuditLog stores records under dir; an empty dir returns nil, which disables auditing
+func NewTTSAuditLog(dir string) *TTSAuditLog {
+	if dir == "" {
+		return nil
+	}
+	return &TTSAuditLog{dir: dir}
+}
+
+// path returns the audit file of jobID, rejecting IDs that would escape dir
+func (l *TTSAuditLog) path(jobID string) (string, error) {
+	if jobID == "" || jobID != filepath.Base(jobID) || strings.HasPrefix(jobID, ".") {
+		return "", fmt.Errorf("invalid job ID %q", jobID)
+	}
+	return filepath.Join(l.dir, jobID+".jsonl"), nil
+}
+
+// Append records one call; failures are logged since auditing must never fail a job
+func (l *TTSAuditLog) Append(rec models.TTSAuditRecord) {
+	if l == nil {
+		return
+	}
+	path, err := l.path(rec.JobID)
+	if err != nil {
+		log.Printf("[TTSAudit] %v", err)
+		return
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		log.Printf("[TTSAudit] Could not encode record: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		log.Printf("[TTSAudit] Could not create %s: %v", l.dir, err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("[TTSAudit] Could not open %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("[TTSAudit] Could not write %s: %v", path, err)
+	}
+}
+
+// Records returns the calls made for jobID in the order they finished; a job without
+// TTS calls has none. Lines that fail to decode (e.g. a torn final write) are skipped.
+func (l *TTSAuditLog) Records(jobID string) ([]models.TTSAuditRecord, error) {
+	path, err := l.path(jobID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.TTSAuditRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []models.TTSAuditRecord{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec models.TTSAuditRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) == nil {
+			records = append(records, rec)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// ttsCallInfo collects what a provider learns during one Synthesize call for the audit record
+type ttsCallInfo struct {
+	mu             sync.Mutex
+	keyFingerprint string
+	statusCode     int
+	asyncURL       string
+}
+
+type ttsCallInfoKey struct{}
+
+// withTTSCallInfo attaches a fresh ttsCallInfo to ctx
+func withTTSCallInfo(ctx context.Context) (context.Context, *ttsCallInfo) {
+	info := &ttsCallInfo{}
+	return context.WithValue(ctx, ttsCallInfoKey{}, info), info
+}
+
+// noteTTSCall updates the call info in ctx, if any
+func noteTTSCall(ctx context.Context, update func(info *ttsCallInfo)) {
+	info, ok := ctx.Value(ttsCallInfoKey{}).(*ttsCallInfo)
+	if !ok {
+		return
+	}
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	update(info)
+}
+
+// noteTTSKey records which API key served the call, as a fingerprint
+func noteTTSKey(ctx context.Context, apiKey string) {
+	noteTTSCall(ctx, func(info *ttsCallInfo) { info.keyFingerprint = keyFingerprint(apiKey) })
+}
+
+// noteTTSStatus records the provider's HTTP response code
+func noteTTSStatus(ctx context.Context, status int) {
+	noteTTSCall(ctx, func(info *ttsCallInfo) { info.statusCode = status })
+}
+
+// noteTTSAsyncURL records the download URL of an asynchronous provider
+func noteTTSAsyncURL(ctx context.Context, url string) {
+	noteTTSCall(ctx, func(info *ttsCallInfo) { info.asyncURL = url })
+}
+
+// keyFingerprint identifies an API key in logs without revealing it
+func keyFingerprint(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:4])
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTTSAuditLog_RecordsEveryAttempt(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	audit := NewTTSAuditLog(t.TempDir())
+	as.SetTTSAudit(audit)
+	as.RegisterTTSProvider("flaky", &scriptedTTS{failures: 1, err: httpTTSError(503, errors.New("busy")), audio: []byte("ID3")})
+
+	if _, err := as.GenerateAudioChunks([]string{"hello"}, "flaky", "banmai", []float64{1.0}, AudioFormat{}, "job1", 1, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	records, err := audit.Records("job1")
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected a record per attempt, got %+v", records)
+	}
+	if records[0].Attempt != 1 || records[0].StatusCode != 503 || records[0].Error == "" {
+		t.Errorf("Unexpected failed attempt record %+v", records[0])
+	}
+	if records[1].Attempt != 2 || records[1].Error != "" || records[1].Bytes != 3 || records[1].Provider != "flaky" {
+		t.Errorf("Unexpected successful attempt record %+v", records[1])
+	}
+
+	if records, err := audit.Records("other-job"); err != nil || len(records) != 0 {
+		t.Errorf("Expected no records for an unknown job, got %v, %v", records, err)
+	}
+	if _, err := audit.Records("../etc"); err == nil {
+		t.Error("Expected path traversal in the job ID to be rejected")
+	}
+}
+
+func TestWithAPIKey_NotesKeyAndStatus(t *testing.T) {
+	ctx, info := withTTSCallInfo(context.Background())
+	pool := utils.NewAPIKeyPool([]string{"secret-key"})
+	if _, err := withAPIKey(ctx, pool, "Test", func(apiKey string) ([]byte, int, error) {
+		return nil, http.StatusTooManyRequests, errors.New("slow down")
+	}); err == nil {
+		t.Fatal("Expected error")
+	}
+	if info.statusCode != http.StatusTooManyRequests || info.keyFingerprint != keyFingerprint("secret-key") || len(info.keyFingerprint) != 8 {
+		t.Errorf("Unexpected call info %+v", info)
+	}
+}
+
+func TestFPTTTS_NotesAsyncURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": 1, "message": "bad voice"}`))
+	}))
+	defer server.Close()
+
+	f := NewFPTTTS(utils.NewAPIKeyPool([]string{"k"}), nil, nil, nil, "").(*fptTTS)
+	f.endpoint = server.URL
+	ctx, info := withTTSCallInfo(context.Background())
+	if _, err := f.Synthesize(ctx, "xin chào", "banmai", 1.0); err == nil {
+		t.Fatal("Expected error")
+	}
+	if info.statusCode != http.StatusBadRequest || info.keyFingerprint == "" || info.asyncURL != "" {
+		t.Errorf("Unexpected call info %+v", info)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// ttsCache stores synthesized chunks under the hash of everything that affects the audio,
+// so re-rendering an edited script only pays for the sentences that changed
+type ttsCache struct {
+	dir string
+}
+
+// ttsCacheKey hashes provider, voice, speed and the exact text sent to the provider
+func ttsCacheKey(provider, voice string, speed float64, text string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%.3f\x00%s", provider, voice, speed, text)))
+	return hex.EncodeToString(hash[:])
+}
+
+// path returns the cached file for key; chunks are MP3 or WAV, so both extensions are probed
+func (c *ttsCache) path(key string) (string, bool) {
+	for _, ext := range []string{".mp3", ".wav"} {
+		path := filepath.Join(c.dir, key[:2], key+ext)
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// get returns the cached audio for key
+func (c *ttsCache) get(key string) ([]byte, bool) {
+	path, ok := c.path(key)
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	return data, true
+}
+
+// put stores audio for key; failures are logged since the cache is only an optimization
+func (c *ttsCache) put(key string, data []byte) {
+	dir := filepath.Join(c.dir, key[:2])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("[TTSCache] Could not create %s: %v", dir, err)
+		return
+	}
+	// Write then rename so concurrent jobs never read a partial file
+	path := filepath.Join(dir, key+audioFileExt(data))
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		log.Printf("[TTSCache] Could not store %s: %v", key, err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("[TTSCache] Could not store %s: %v", key, err)
+	}
+}
+
+// remove drops the cached audio for key, e.g. when QA rejected it
+func (c *ttsCache) remove(key string) {
+	if path, ok := c.path(key); ok {
+		os.Remove(path)
+	}
+}
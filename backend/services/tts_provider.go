@@ -0,0 +1,249 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// TTSProvider synthesizes speech for one chunk of text and returns the encoded audio (MP3 or WAV).
+// Implementations make a single attempt; AudioService owns chunking, retries, post-processing and merging.
+// Providers with an asynchronous API (FPT.AI) wait for and download the audio inside Synthesize,
+// so synchronous ones simply return the response body.
+type TTSProvider interface {
+	Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error)
+}
+
+// TTSRetryPolicy is implemented by providers that need a retry budget other than the default.
+// RetryDelay returns the wait before retry number attempt (1-based), or false to give up.
+type TTSRetryPolicy interface {
+	RetryDelay(attempt int) (time.Duration, bool)
+}
+
+// SSMLProvider is implemented by providers that accept inline SSML (<break>, <emphasis>, <prosody>, ...).
+// Other providers receive SSML scripts with the tags stripped.
+type SSMLProvider interface {
+	SupportsSSML() bool
+}
+
+// VoiceCloner is implemented by providers that can create a voice from reference audio.
+// CloneVoice returns the ID to pass as voice to Synthesize.
+type VoiceCloner interface {
+	CloneVoice(ctx context.Context, name string, sample []byte) (string, error)
+}
+
+// ErrTTSCircuitOpen is returned, without calling the provider, while its circuit breaker is open
+var ErrTTSCircuitOpen = errors.New("circuit breaker open after repeated failures")
+
+// TTSError is a failed synthesis; Retryable false stops the retry loop (bad voice, bad text)
+type TTSError struct {
+	StatusCode int // HTTP status, 0 for transport errors
+	Retryable  bool
+	Err        error
+}
+
+func (e *TTSError) Error() string { return e.Err.Error() }
+func (e *TTSError) Unwrap() error { return e.Err }
+
+// httpTTSError classifies an HTTP failure: transport errors, auth errors (another key may work),
+// rate limits and server errors are retried; other 4xx responses are not
+func httpTTSError(status int, err error) *TTSError {
+	retryable := status == 0 || status == http.StatusUnauthorized || status == http.StatusForbidden ||
+		status == http.StatusTooManyRequests || status >= 500
+	return &TTSError{StatusCode: status, Retryable: retryable, Err: err}
+}
+
+// defaultRetryDelay allows 5 attempts with linear backoff
+func defaultRetryDelay(attempt int) (time.Duration, bool) {
+	const maxAttempts = 5
+	return time.Duration(attempt) * 2 * time.Second, attempt < maxAttempts
+}
+
+// RegisterTTSProvider makes p selectable as GenerateRequest.TTSProvider == name
+func (as *AudioService) RegisterTTSProvider(name string, p TTSProvider) {
+	as.providersMux.Lock()
+	defer as.providersMux.Unlock()
+	as.providers[name] = p
+}
+
+// TTSProviders returns the names of the registered providers, sorted
+func (as *AudioService) TTSProviders() []string {
+	as.providersMux.RLock()
+	defer as.providersMux.RUnlock()
+	names := make([]string, 0, len(as.providers))
+	for name := range as.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (as *AudioService) ttsProvider(name string) (TTSProvider, bool) {
+	as.providersMux.RLock()
+	defer as.providersMux.RUnlock()
+	p, ok := as.providers[name]
+	return p, ok
+}
+
+// synthesizeChunk runs provider under its retry policy and saves the audio of chunk index
+func (as *AudioService) synthesizeChunk(ctx context.Context, name string, provider TTSProvider, text, voice string, speed float64, jobID string, index int) (string, error) {
+	retryDelay := defaultRetryDelay
+	if p, ok := provider.(TTSRetryPolicy); ok {
+		retryDelay = p.RetryDelay
+	}
+	cacheKey := ttsCacheKey(name, voice, speed, text)
+	if IsSSML(text) {
+		if p, ok := provider.(SSMLProvider); !ok || !p.SupportsSSML() {
+			text = StripSSML(text)
+		}
+	}
+
+	var lastErr error
+	attempt := 0
+	for {
+		if attempt > 0 {
+			delay, ok := retryDelay(attempt)
+			if !ok {
+				break
+			}
+			log.Printf("[Chunk %d] Re-requesting %s TTS (Attempt %d)", index, name, attempt+1)
+			as.events.Logf(jobID, "Retry %d of chunk %d: %v", attempt, index, lastErr)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		attempt++
+
+		data, err := as.auditedSynthesize(ctx, name, provider, text, voice, speed, jobID, index, attempt)
+		if err == nil {
+			if as.cache != nil {
+				as.cache.put(cacheKey, data)
+			}
+			return as.saveChunk(data, jobID, index)
+		}
+		lastErr = err
+		log.Printf("[Chunk %d] %s call failed: %v", index, name, err)
+
+		var ttsErr *TTSError
+		if errors.As(err, &ttsErr) && !ttsErr.Retryable {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%s failed after %d attempts, last error: %v", name, attempt, lastErr)
+}
+
+// auditedSynthesize makes one provider call, recording it in the audit log when enabled
+func (as *AudioService) auditedSynthesize(ctx context.Context, name string, provider TTSProvider, text, voice string, speed float64, jobID string, index, attempt int) ([]byte, error) {
+	ctx, info := withTTSCallInfo(ctx)
+	start := time.Now()
+	data, err := provider.Synthesize(ctx, text, voice, speed)
+	if err == nil && len(data) == 0 {
+		err = fmt.Errorf("%s returned empty audio", name)
+	}
+	if as.audit == nil {
+		return data, err
+	}
+
+	info.mu.Lock()
+	rec := models.TTSAuditRecord{
+		Time:           start,
+		JobID:          jobID,
+		Provider:       name,
+		Chunk:          index,
+		Attempt:        attempt,
+		KeyFingerprint: info.keyFingerprint,
+		StatusCode:     info.statusCode,
+		AsyncURL:       info.asyncURL,
+		LatencyMs:      time.Since(start).Milliseconds(),
+		Bytes:          len(data),
+	}
+	info.mu.Unlock()
+	var ttsErr *TTSError
+	if errors.As(err, &ttsErr) && ttsErr.StatusCode != 0 {
+		rec.StatusCode = ttsErr.StatusCode
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	as.audit.Append(rec)
+	return data, err
+}
+
+// cachedChunk saves the cached audio of a chunk into the job, returning "" on a cache miss
+func (as *AudioService) cachedChunk(name, text, voice string, speed float64, jobID string, index int) (string, error) {
+	if as.cache == nil {
+		return "", nil
+	}
+	data, ok := as.cache.get(ttsCacheKey(name, voice, speed, text))
+	if !ok {
+		return "", nil
+	}
+	return as.saveChunk(data, jobID, index)
+}
+
+// saveChunk writes the raw provider audio of chunk index into the job's audio dir
+func (as *AudioService) saveChunk(data []byte, jobID string, index int) (string, error) {
+	audioPath := filepath.Join(as.tempDir, jobID, "audio", fmt.Sprintf("chunk_%03d%s", index, audioFileExt(data)))
+	if err := as.saveAudioFile(data, audioPath); err != nil {
+		return "", err
+	}
+	return audioPath, nil
+}
+
+// withAPIKey makes one keyed call, parking keys that are rejected or rate limited so the
+// next attempt rotates to another key. call returns the HTTP status (0 for transport errors).
+// It first waits for the pool's rate limit, if one is set.
+func withAPIKey(ctx context.Context, pool *utils.APIKeyPool, provider string, call func(apiKey string) ([]byte, int, error)) ([]byte, error) {
+	if err := pool.Wait(ctx); err != nil {
+		return nil, &TTSError{Err: fmt.Errorf("%s rate limit wait: %w", provider, err)}
+	}
+	apiKey, err := pool.GetRandomKey()
+	if err != nil {
+		return nil, &TTSError{Err: fmt.Errorf("no available %s API keys: %w", provider, err)}
+	}
+
+	noteTTSKey(ctx, apiKey)
+
+	data, status, err := call(apiKey)
+	noteTTSStatus(ctx, status)
+	if err != nil {
+		switch status {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			// Invalid or out-of-quota key: park it so other keys take over
+			pool.MarkFailed(apiKey, 10*time.Minute)
+		case http.StatusTooManyRequests:
+			pool.MarkFailed(apiKey, 30*time.Second)
+		}
+		return nil, httpTTSError(status, err)
+	}
+	pool.MarkSuccess(apiKey)
+	return data, nil
+}
+
+// readErrorBody returns the start of a failed response's body for error messages
+func readErrorBody(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return string(body)
+}
+
+// audioFileExt picks the chunk file extension from the audio's magic bytes
+func audioFileExt(data []byte) string {
+	if len(data) >= 4 && string(data[:4]) == "RIFF" {
+		return ".wav"
+	}
+	return ".mp3"
+}
+
+func newTTSHTTPClient() *http.Client {
+	return &http.Client{Timeout: 2 * time.Minute}
+}
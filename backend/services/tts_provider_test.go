@@ -0,0 +1,179 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedTTS fails the first failures calls with err, then returns audio
+type scriptedTTS struct {
+	mu       sync.Mutex
+	calls    int
+	failures int
+	err      error
+	audio    []byte
+	lastText string
+}
+
+func (s *scriptedTTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	s.lastText = text
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+	return s.audio, nil
+}
+
+// RetryDelay keeps the default budget without the backoff
+func (s *scriptedTTS) RetryDelay(attempt int) (time.Duration, bool) {
+	_, ok := defaultRetryDelay(attempt)
+	return 0, ok
+}
+
+func TestAudioService_RegistryAndRetries(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	if _, err := as.GenerateAudioChunks([]string{"x"}, "", "banmai", []float64{1.0}, AudioFormat{}, "job1", 1, nil); err == nil || !strings.Contains(err.Error(), `"fpt" is not configured`) {
+		t.Errorf("Expected unconfigured default provider error, got %v", err)
+	}
+
+	flaky := &scriptedTTS{failures: 2, err: errors.New("connection reset"), audio: []byte("RIFF-wav")}
+	as.RegisterTTSProvider("flaky", flaky)
+	as.RegisterTTSProvider("aaa", &scriptedTTS{audio: []byte("ID3")})
+	if names := as.TTSProviders(); len(names) != 2 || names[0] != "aaa" || names[1] != "flaky" {
+		t.Errorf("Unexpected provider names %v", names)
+	}
+
+	paths, err := as.GenerateAudioChunks([]string{"hello"}, "flaky", "banmai", []float64{1.0}, AudioFormat{}, "job1", 1, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("Expected 3 calls (2 retried failures), got %d", flaky.calls)
+	}
+	// WAV output keeps its extension, or is re-encoded to MP3 when ffmpeg is available
+	if !strings.HasSuffix(paths[0], "chunk_000.wav") && !strings.HasSuffix(paths[0], "chunk_paced_000.mp3") {
+		t.Errorf("Unexpected chunk path %s", paths[0])
+	}
+	if _, err := os.Stat(paths[0]); err != nil {
+		t.Errorf("Chunk file missing: %v", err)
+	}
+}
+
+func TestAudioService_NonRetryableErrorStops(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	bad := &scriptedTTS{failures: 10, err: httpTTSError(400, errors.New("bad voice"))}
+	as.RegisterTTSProvider("bad", bad)
+
+	if _, err := as.GenerateAudioChunks([]string{"x"}, "bad", "v", []float64{1.0}, AudioFormat{}, "job1", 1, nil); err == nil {
+		t.Fatal("Expected error")
+	}
+	if bad.calls != 1 {
+		t.Errorf("A 400 should not be retried, got %d calls", bad.calls)
+	}
+
+	limited := &scriptedTTS{failures: 10, err: httpTTSError(429, errors.New("slow down"))}
+	as.RegisterTTSProvider("limited", limited)
+	_, err := as.GenerateAudioChunks([]string{"x"}, "limited", "v", []float64{1.0}, AudioFormat{}, "job1", 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed after 5 attempts") {
+		t.Errorf("Expected retry budget to be exhausted, got %v", err)
+	}
+}
+
+// ssmlTTS is a scriptedTTS that accepts SSML
+type ssmlTTS struct{ scriptedTTS }
+
+func (s *ssmlTTS) SupportsSSML() bool { return true }
+
+func TestSynthesizeChunk_SSMLPassThrough(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	script := `Hello <break time="500ms"/> <emphasis>world</emphasis>.`
+
+	plain := &scriptedTTS{audio: []byte("ID3")}
+	if _, err := as.synthesizeChunk(context.Background(), "plain", plain, script, "banmai", 1.0, "job1", 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if plain.lastText != "Hello world." {
+		t.Errorf("Expected SSML stripped for plain provider, got %q", plain.lastText)
+	}
+
+	ssml := &ssmlTTS{scriptedTTS{audio: []byte("ID3")}}
+	if _, err := as.synthesizeChunk(context.Background(), "ssml", ssml, script, "banmai", 1.0, "job1", 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ssml.lastText != script {
+		t.Errorf("Expected SSML passed through, got %q", ssml.lastText)
+	}
+}
+
+func TestGenerateAudioChunks_TTSCache(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	as.SetTTSCacheDir(t.TempDir())
+	tts := &scriptedTTS{audio: []byte("ID3-cached")}
+	as.RegisterTTSProvider("fake", tts)
+
+	if _, err := as.GenerateAudioChunks([]string{"First.", "Second."}, "fake", "banmai", []float64{1.0, 1.0}, AudioFormat{}, "job1", 1, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// An edited script only synthesizes the changed sentence
+	paths, err := as.GenerateAudioChunks([]string{"First.", "Second, edited."}, "fake", "banmai", []float64{1.0, 1.0}, AudioFormat{}, "job2", 1, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tts.calls != 3 {
+		t.Errorf("Expected 3 provider calls, got %d", tts.calls)
+	}
+	if data, _ := os.ReadFile(paths[0]); string(data) != "ID3-cached" {
+		t.Errorf("Unexpected cached audio %q", data)
+	}
+
+	// Voice and speed are part of the key
+	if _, err := as.GenerateAudioChunks([]string{"First."}, "fake", "banmai", []float64{1.2}, AudioFormat{}, "job3", 1, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tts.calls != 4 {
+		t.Errorf("Expected a new call for a different speed, got %d calls", tts.calls)
+	}
+}
+
+func TestGenerateAudioChunks_CircuitOpenFallback(t *testing.T) {
+	as := NewAudioService(t.TempDir(), "192k", 44100, 0)
+	open := &scriptedTTS{failures: 10, err: &TTSError{Err: fmt.Errorf("FPT.AI: %w", ErrTTSCircuitOpen)}}
+	backup := &scriptedTTS{audio: []byte("ID3-backup")}
+	as.RegisterTTSProvider("open", open)
+	as.RegisterTTSProvider("backup", backup)
+
+	if _, err := as.GenerateAudioChunks([]string{"x"}, "open", "v", []float64{1.0}, AudioFormat{}, "job1", 1, nil); !errors.Is(err, ErrTTSCircuitOpen) {
+		t.Fatalf("Without a fallback the open circuit should fail the chunk, got %v", err)
+	}
+	if open.calls != 1 {
+		t.Errorf("An open circuit should not be retried, got %d calls", open.calls)
+	}
+
+	as.SetTTSFallback("backup")
+	paths, err := as.GenerateAudioChunks([]string{"x"}, "open", "v", []float64{1.0}, AudioFormat{}, "job2", 1, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(paths[0]); string(data) != "ID3-backup" || backup.calls != 1 {
+		t.Errorf("Expected the fallback provider's audio, got %q after %d calls", data, backup.calls)
+	}
+}
+
+func TestFPTTTS_RetryBudget(t *testing.T) {
+	f := NewFPTTTS(nil, nil, utils.NewRetryBudget(0, 1), nil, "").(*fptTTS)
+	if _, ok := f.RetryDelay(1); !ok {
+		t.Fatal("Expected the first retry to fit the budget")
+	}
+	if _, ok := f.RetryDelay(1); ok {
+		t.Error("Expected retries to stop once the shared budget is spent")
+	}
+}
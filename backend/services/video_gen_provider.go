@@ -0,0 +1,135 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// VideoGenProvider renders text-to-video clips. Implementations own their vendor API, key pool
+// and retries; VideoService only fits the clip to the narration and files it under the job.
+type VideoGenProvider interface {
+	// GenerateClip renders prompt at about duration seconds (clamped to MaxDuration) in aspect
+	// ("16:9" or "9:16") with style, and returns the path of the MP4, which the caller takes over
+	GenerateClip(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle) (string, error)
+	Capabilities() VideoGenCapabilities
+}
+
+// ClipStyle is shared by every clip of one video so consecutive segments keep one look.
+// Providers drop what their API lacks: Runway takes only the seed, Kling only the negative prompt.
+type ClipStyle struct {
+	Seed           int64  // 0 lets the provider pick a random one
+	NegativePrompt string // what the clips must not show
+	StyleImage     string // data URI of a reference image whose look the clips follow
+}
+
+// VideoGenCapabilities describes what a provider renders
+type VideoGenCapabilities struct {
+	MaxDuration float64           // longest clip in seconds; longer segments are extended by looping
+	Resolutions map[string]string // supported aspect ratio -> output WxH
+}
+
+// aspect returns want if the provider renders it, landscape otherwise
+func (c VideoGenCapabilities) aspect(want string) string {
+	if _, ok := c.Resolutions[want]; ok {
+		return want
+	}
+	return "16:9"
+}
+
+// videoCall makes one generation with apiKey and returns the MP4, or the HTTP status of the
+// failed call (0 for transport errors and failed renders)
+type videoCall func(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle, apiKey string) ([]byte, int, error)
+
+// pooledVideoProvider adapts a vendor's videoCall into a VideoGenProvider, rotating through
+// its key pool (see callWithKeyRotation)
+type pooledVideoProvider struct {
+	name       string
+	pool       *utils.APIKeyPool
+	call       videoCall
+	caps       VideoGenCapabilities
+	dir        string
+	maxRetries int
+}
+
+// newPooledVideoProvider writes clips generated by call under dir
+func newPooledVideoProvider(name string, pool *utils.APIKeyPool, call videoCall, caps VideoGenCapabilities, dir string) *pooledVideoProvider {
+	return &pooledVideoProvider{name: name, pool: pool, call: call, caps: caps, dir: dir, maxRetries: 3}
+}
+
+// Capabilities implements VideoGenProvider
+func (p *pooledVideoProvider) Capabilities() VideoGenCapabilities {
+	return p.caps
+}
+
+// GenerateClip implements VideoGenProvider
+func (p *pooledVideoProvider) GenerateClip(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle) (string, error) {
+	if p.caps.MaxDuration > 0 && duration > p.caps.MaxDuration {
+		duration = p.caps.MaxDuration
+	}
+	aspect = p.caps.aspect(aspect)
+	videoData, err := callWithKeyRotation(ctx, p.pool, p.name, p.maxRetries, func(apiKey string) ([]byte, int, error) {
+		return p.call(ctx, prompt, duration, aspect, style, apiKey)
+	})
+	if err != nil {
+		return "", err
+	}
+	return p.save(videoData)
+}
+
+// save writes a generated clip to a new file under p.dir
+func (p *pooledVideoProvider) save(data []byte) (string, error) {
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.CreateTemp(p.dir, p.name+"_*.mp4")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to save video: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// RegisterVideoProvider makes p selectable as GenerateRequest.VideoProvider == name; a nil p
+// removes the provider
+func (vs *VideoService) RegisterVideoProvider(name string, p VideoGenProvider) {
+	vs.providersMux.Lock()
+	defer vs.providersMux.Unlock()
+	if p == nil {
+		delete(vs.providers, name)
+		return
+	}
+	vs.providers[name] = p
+}
+
+// VideoProviders returns the names of the registered providers, sorted
+func (vs *VideoService) VideoProviders() []string {
+	vs.providersMux.RLock()
+	defer vs.providersMux.RUnlock()
+	names := make([]string, 0, len(vs.providers))
+	for name := range vs.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// videoProvider returns the provider registered as name ("" for Pika)
+func (vs *VideoService) videoProvider(name string) (VideoGenProvider, error) {
+	if name == "" {
+		name = VideoProviderPika
+	}
+	vs.providersMux.RLock()
+	defer vs.providersMux.RUnlock()
+	p, ok := vs.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("video provider %q is not configured", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPooledVideoProvider_GenerateClip(t *testing.T) {
+	dir := t.TempDir()
+	var keys []string
+	var gotDuration float64
+	var gotAspect string
+	call := func(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle, apiKey string) ([]byte, int, error) {
+		keys = append(keys, apiKey)
+		gotDuration, gotAspect = duration, aspect
+		if apiKey == "revoked" {
+			return nil, http.StatusUnauthorized, errors.New("unauthorized")
+		}
+		return []byte("clip"), http.StatusOK, nil
+	}
+	p := newPooledVideoProvider("fake", utils.NewAPIKeyPool([]string{"revoked", "good"}), call, runwayCapabilities, dir)
+	p.maxRetries = 5
+
+	path, err := p.GenerateClip(context.Background(), "a cat", 25, "1:1", ClipStyle{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "clip" || filepath.Dir(path) != dir {
+		t.Errorf("Unexpected clip %s: %q", path, data)
+	}
+	if gotDuration != 10 || gotAspect != "16:9" {
+		t.Errorf("Expected the request clamped to 10s 16:9, got %vs %s", gotDuration, gotAspect)
+	}
+	if keys[len(keys)-1] != "good" {
+		t.Errorf("Expected the good key to serve the clip, got %v", keys)
+	}
+}
+
+func TestPooledVideoProvider_RejectedPrompt(t *testing.T) {
+	calls := 0
+	call := func(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle, apiKey string) ([]byte, int, error) {
+		calls++
+		return nil, http.StatusBadRequest, errors.New("prompt violates policy")
+	}
+	p := newPooledVideoProvider("fake", utils.NewAPIKeyPool([]string{"a", "b"}), call, klingCapabilities, t.TempDir())
+	if _, err := p.GenerateClip(context.Background(), "x", 5, "9:16", ClipStyle{}); err == nil {
+		t.Fatal("Expected error")
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single call for a 400, got %d", calls)
+	}
+}
+
+func TestVideoService_Providers(t *testing.T) {
+	vs := NewVideoService(utils.NewAPIKeyPool([]string{"pika"}), t.TempDir(), "5M", "1920x1080", 30, 0.5)
+	vs.SetRunway(utils.NewAPIKeyPool([]string{"rw"}), "gen3a_turbo", "")
+	if got := vs.VideoProviders(); len(got) != 2 || got[0] != VideoProviderPika || got[1] != VideoProviderRunway {
+		t.Errorf("Unexpected providers %v", got)
+	}
+	p, err := vs.videoProvider("")
+	if err != nil {
+		t.Fatalf("Expected Pika as the default: %v", err)
+	}
+	if res := p.Capabilities().Resolutions["9:16"]; res != "1080x1920" {
+		t.Errorf("Expected Pika portrait at 1080x1920, got %q", res)
+	}
+	vs.SetRunway(nil, "", "")
+	if _, err := vs.videoProvider(VideoProviderRunway); err == nil {
+		t.Error("Expected runway to be removed")
+	}
+}
@@ -3,82 +3,99 @@ package services
 import (
 	"aituber/models"
 	"aituber/utils"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Text-to-video APIs selectable via GenerateRequest.VideoProvider
+const (
+	VideoProviderPika   = "pika" // default
+	VideoProviderRunway = "runway"
+	VideoProviderKling  = "kling"
+)
+
 // VideoService handles video generation and processing
 type VideoService struct {
-	apiPool            *utils.APIKeyPool
+	apiPool            *utils.APIKeyPool // Pika keys, rate limited per key (APIKeyPool.SetRateLimit)
 	httpClient         *http.Client
+	providers          map[string]VideoGenProvider
+	providersMux       sync.RWMutex
+	pollInterval       time.Duration
 	tempDir            string
 	videoBitrate       string
 	resolution         string
 	fps                int
 	transitionDuration float64
+
+	// Visual prompts for segments without a visual_description (see SetPromptLLM)
+	promptLLM     PromptLLM
+	promptLLMName string
+	promptCache   map[string]string
+	promptMux     sync.Mutex
 }
 
-// NewVideoService creates a new video service
+// NewVideoService creates a new video service with Pika, using apiPool, as its only provider;
+// see RegisterVideoProvider
 func NewVideoService(apiPool *utils.APIKeyPool, tempDir string, videoBitrate string, resolution string, fps int, transitionDuration float64) *VideoService {
-	return &VideoService{
+	vs := &VideoService{
 		apiPool: apiPool,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Minute, // Videos take longer
 		},
+		providers:          make(map[string]VideoGenProvider),
+		pollInterval:       5 * time.Second,
 		tempDir:            tempDir,
 		videoBitrate:       videoBitrate,
 		resolution:         resolution,
 		fps:                fps,
 		transitionDuration: transitionDuration,
 	}
+	vs.SetPikaURL("")
+	return vs
 }
 
-// GenerateVideoPrompts generates visual prompts for each text segment
-// Uses simple template-based approach for consistency
-func (vs *VideoService) GenerateVideoPrompts(segments []models.VideoSegment, style string) ([]string, error) {
+// GenerateVideoPrompts generates visual prompts for each text segment, with the configured
+// LLM when there is one (see SetPromptLLM) and tmpl otherwise
+func (vs *VideoService) GenerateVideoPrompts(segments []models.VideoSegment, style string, tmpl models.PromptTemplate) ([]string, error) {
 	prompts := make([]string, len(segments))
 
 	for i, segment := range segments {
-		// Create a simple visual prompt
-		// In production, this could use GPT/Claude for better prompts
-		prompt := vs.createPromptFromText(segment.Text, style, i)
-		prompts[i] = prompt
+		prompts[i] = vs.visualPrompt(context.Background(), segment.Text, style, tmpl, i)
 	}
 
 	return prompts, nil
 }
 
-// createPromptFromText creates a visual prompt from text
-func (vs *VideoService) createPromptFromText(text, style string, index int) string {
-	// Simple template-based prompt generation
-	// This ensures visual consistency across segments
-
-	// Extract key themes (simplified - in production use NLP)
-	themes := vs.extractThemes(text)
-
-	basePrompt := fmt.Sprintf("High quality %s video, ", style)
-	if len(themes) > 0 {
-		basePrompt += themes + ", "
-	}
-	basePrompt += "cinematic lighting, professional composition, 4K resolution"
-
-	return basePrompt
+// createPromptFromText fills tmpl (DefaultPromptTemplate when empty) for text, the fallback when
+// no LLM is configured or it fails. The shared template keeps the look consistent across segments.
+func (vs *VideoService) createPromptFromText(text, style string, tmpl models.PromptTemplate) string {
+	return renderPromptTemplate(tmpl.Template, map[string]string{
+		"text":     text,
+		"style":    style,
+		"themes":   vs.extractThemes(text),
+		"branding": tmpl.Branding,
+	})
 }
 
-// extractThemes extracts key themes from text (simplified version)
+// extractThemes picks the first theme keyword (English or Vietnamese) found in text
 func (vs *VideoService) extractThemes(text string) string {
-	// In production, use proper NLP or LLM
-	// For now, use simple keyword matching
+	text = strings.ToLower(text)
 	keywords := []string{
 		"technology", "nature", "business", "education",
 		"science", "art", "music", "sports",
 	}
 
 	for _, keyword := range keywords {
-		if contains(text, keyword) || contains(text, translateToVietnamese(keyword)) {
+		if strings.Contains(text, keyword) || strings.Contains(text, translateToVietnamese(keyword)) {
 			return keyword + " themed"
 		}
 	}
@@ -86,10 +103,6 @@ func (vs *VideoService) extractThemes(text string) string {
 	return "abstract"
 }
 
-func contains(text, substr string) bool {
-	return len(text) > 0 && len(substr) > 0 // Simplified
-}
-
 func translateToVietnamese(word string) string {
 	// Simplified translation map
 	translations := map[string]string{
@@ -104,155 +117,122 @@ func translateToVietnamese(word string) string {
 	return word
 }
 
-// PikaVideoRequest represents video generation request
-type PikaVideoRequest struct {
-	Prompt     string  `json:"prompt"`
-	Duration   float64 `json:"duration,omitempty"`
-	Resolution string  `json:"resolution,omitempty"`
-}
-
-// PikaVideoResponse represents video generation response
-type PikaVideoResponse struct {
-	JobID    string `json:"job_id,omitempty"`
-	Status   string `json:"status,omitempty"`
-	VideoURL string `json:"video_url,omitempty"`
-	Error    string `json:"error,omitempty"`
-}
+// ClipFallback makes a replacement clip (e.g. stock footage) for segment index when its AI
+// render failed
+type ClipFallback func(ctx context.Context, index int, prompt string, duration float64) (string, error)
 
-// GenerateVideos generates video clips for each prompt
-func (vs *VideoService) GenerateVideos(prompts []string, durations []float64, jobID string, maxConcurrent int) ([]string, error) {
+// GenerateVideos generates video clips for each prompt, at most maxConcurrent at a time.
+// onProgress, if non-nil, is called after each clip finishes with the number done so far.
+// Without fallback the first failure cancels the remaining renders and is returned; with one,
+// failed segments are filled by it and only a failed fallback fails the batch.
+func (vs *VideoService) GenerateVideos(ctx context.Context, prompts []string, durations []float64, jobID string, maxConcurrent int, onProgress ProgressFunc, fallback ClipFallback) ([]string, error) {
 	if len(prompts) != len(durations) {
 		return nil, fmt.Errorf("prompts and durations length mismatch")
 	}
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	videoPaths := make([]string, len(prompts))
-	errors := make([]error, len(prompts))
-
-	// Create semaphore for rate limiting
+	errs := make([]error, len(prompts))
+	tracker := newProgressTracker(len(prompts), onProgress)
 	sem := make(chan struct{}, maxConcurrent)
-	done := make(chan struct{})
+	var wg sync.WaitGroup
 
-	// Process videos in parallel
 	for i, prompt := range prompts {
+		wg.Add(1)
 		go func(index int, p string, dur float64) {
-			sem <- struct{}{}        // Acquire semaphore
-			defer func() { <-sem }() // Release semaphore
-
-			videoPath, err := vs.generateSingleVideo(p, dur, jobID, index)
-			if err != nil {
-				errors[index] = err
-			} else {
-				videoPaths[index] = videoPath
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				errs[index] = ctx.Err()
+				return
 			}
 
-			if index == len(prompts)-1 {
-				close(done)
+			videoPath, err := vs.generateSingleVideo(ctx, VideoProviderPika, p, dur, "", ClipStyle{}, jobID, index)
+			if err != nil && fallback != nil && ctx.Err() == nil {
+				log.Printf("[Job %s] Video segment %d failed, using fallback: %v", jobID, index, err)
+				videoPath, err = fallback(ctx, index, p, dur)
+			}
+			if err != nil {
+				errs[index] = err
+				cancel()
+				return
 			}
+			videoPaths[index] = videoPath
+			tracker.step()
 		}(i, prompt, durations[i])
 	}
+	wg.Wait()
 
-	// Wait for all to complete
-	<-done
-
-	// Check for errors
-	for i, err := range errors {
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate video segment %d: %w", i, err)
+	// Report the failure that cancelled the batch, not the cancellations it caused
+	for _, skipCancelled := range []bool{true, false} {
+		for i, err := range errs {
+			if err != nil && (!skipCancelled || !errors.Is(err, context.Canceled)) {
+				return nil, fmt.Errorf("failed to generate video segment %d: %w", i, err)
+			}
 		}
 	}
-
 	return videoPaths, nil
 }
 
-// generateSingleVideo generates a single video with retry
-func (vs *VideoService) generateSingleVideo(prompt string, duration float64, jobID string, index int) (string, error) {
-	maxRetries := 3
-	var lastErr error
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		// Get API key from pool
-		apiKey, err := vs.apiPool.GetRandomKey()
-		if err != nil {
-			return "", fmt.Errorf("no available API keys: %w", err)
-		}
-
-		// Call video generation API (using mock for now)
-		videoData, err := vs.callVideoGenerationAPI(prompt, duration, apiKey)
-		if err != nil {
-			// Mark key as failed
-			vs.apiPool.MarkFailed(apiKey, time.Duration(120)*time.Second)
-			lastErr = err
-			time.Sleep(time.Duration(attempt+1) * 2 * time.Second)
-			continue
-		}
-
-		// Mark key as successful
-		vs.apiPool.MarkSuccess(apiKey)
-
-		// Save video to file
-		videoPath := filepath.Join(vs.tempDir, jobID, "video", fmt.Sprintf("segment_%03d.mp4", index))
-		if err := vs.saveVideoFile(videoData, videoPath); err != nil {
-			return "", fmt.Errorf("failed to save video: %w", err)
-		}
-
-		// Adjust duration if needed
-		adjustedPath := filepath.Join(vs.tempDir, jobID, "video", fmt.Sprintf("segment_%03d_adjusted.mp4", index))
-		if err := vs.adjustVideoDuration(videoPath, adjustedPath, duration); err != nil {
-			return "", fmt.Errorf("failed to adjust duration: %w", err)
-		}
-
-		return adjustedPath, nil
-	}
-
-	return "", fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+// GenerateSegmentVideo generates the AI clip of one segment with provider ("" for Pika),
+// trimmed or extended to duration. orientation is "landscape", "portrait" or "square"; style is
+// the same for every segment of the video.
+func (vs *VideoService) GenerateSegmentVideo(ctx context.Context, provider, prompt string, duration float64, style ClipStyle, jobID string, index int, orientation string) (string, error) {
+	return vs.generateSingleVideo(ctx, provider, prompt, duration, utils.FrameAspect(orientation), style, jobID, index)
 }
 
-// callVideoGenerationAPI calls video generation API
-// NOTE: This is a mock implementation - replace with actual API
-func (vs *VideoService) callVideoGenerationAPI(prompt string, duration float64, apiKey string) ([]byte, error) {
-	// Mock implementation - returns placeholder
-	// In production, implement actual API calls to:
-	// - Pika Labs: https://pika.art/api
-	// - Leonardo.AI: https://api.leonardo.ai
-	// - Runway ML: https://api.runwayml.com
-
-	// For now, return error to indicate API implementation needed
-	return nil, fmt.Errorf("video generation API not implemented - please configure with real API endpoint")
+// generateSingleVideo renders one segment with provider and fits it to duration
+func (vs *VideoService) generateSingleVideo(ctx context.Context, provider, prompt string, duration float64, aspect string, style ClipStyle, jobID string, index int) (string, error) {
+	p, err := vs.videoProvider(provider)
+	if err != nil {
+		return "", err
+	}
+	clipPath, err := p.GenerateClip(ctx, prompt, duration, aspect, style)
+	if err != nil {
+		return "", err
+	}
 
-	// Example implementation would be:
-	/*
-		url := "https://api.pika.art/v1/generate"
-		reqBody := PikaVideoRequest{
-			Prompt:     prompt,
-			Duration:   duration,
-			Resolution: vs.resolution,
-		}
+	videoPath := filepath.Join(vs.tempDir, jobID, "video", fmt.Sprintf("segment_%03d.mp4", index))
+	if err := os.MkdirAll(filepath.Dir(videoPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(clipPath, videoPath); err != nil {
+		return "", fmt.Errorf("failed to save video: %w", err)
+	}
 
-		jsonData, _ := json.Marshal(reqBody)
-		req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-		req.Header.Set("Content-Type", "application/json")
+	// Adjust duration if needed
+	adjustedPath := filepath.Join(vs.tempDir, jobID, "video", fmt.Sprintf("segment_%03d_adjusted.mp4", index))
+	if err := vs.adjustVideoDuration(videoPath, adjustedPath, duration); err != nil {
+		return "", fmt.Errorf("failed to adjust duration: %w", err)
+	}
 
-		resp, err := vs.httpClient.Do(req)
-		// ... handle response, poll for completion, download video
-	*/
+	return adjustedPath, nil
 }
 
-// saveVideoFile saves video data to file
-func (vs *VideoService) saveVideoFile(data []byte, path string) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+// downloadVideo fetches a finished clip. The signed URL is not tied to an API key, so a
+// failure here is never reported as a key problem.
+func downloadVideo(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
 	}
-
-	file, err := os.Create(path)
+	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
-
-	_, err = file.Write(data)
-	return err
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
 }
 
 // adjustVideoDuration adjusts video to target duration
@@ -293,6 +273,7 @@ func (vs *VideoService) MergeVideos(videoPaths []string, outputPath string) erro
 	err := utils.MergeVideosWithTransition(
 		videoPaths,
 		outputPath,
+		"fade",
 		vs.transitionDuration,
 		vs.fps,
 		vs.resolution,
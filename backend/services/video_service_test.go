@@ -0,0 +1,139 @@
+package services
+
+import (
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestPikaService(t *testing.T, handler http.HandlerFunc, keys ...string) (*VideoService, *pikaClient) {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	vs := NewVideoService(utils.NewAPIKeyPool(keys), t.TempDir(), "5M", "1920x1080", 30, 0.5)
+	vs.pollInterval = time.Millisecond
+	vs.SetPikaURL(srv.URL)
+	return vs, &pikaClient{baseURL: srv.URL, resolution: "1920x1080", httpClient: srv.Client(), pollInterval: time.Millisecond}
+}
+
+func TestVideoService_PikaFlow(t *testing.T) {
+	var polls atomic.Int32
+	var videoURL string
+	_, pika := newTestPikaService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/video.mp4" && r.Header.Get("Authorization") != "Bearer pika-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/generate":
+			var req PikaVideoRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Prompt != "a cat" || req.AspectRatio != "9:16" || req.Duration != 4 || req.Seed != 7 || req.NegativePrompt != "blur" {
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(PikaVideoResponse{JobID: "j1", Status: "queued"})
+		case "/v1/jobs/j1":
+			if polls.Add(1) < 3 {
+				json.NewEncoder(w).Encode(PikaVideoResponse{JobID: "j1", Status: "processing"})
+				return
+			}
+			json.NewEncoder(w).Encode(PikaVideoResponse{JobID: "j1", Status: "completed", VideoURL: videoURL})
+		case "/video.mp4":
+			w.Write([]byte("mp4-data"))
+		}
+	}, "pika-key")
+	videoURL = pika.baseURL + "/video.mp4"
+
+	data, status, err := pika.generate(context.Background(), "a cat", 4, "9:16", ClipStyle{Seed: 7, NegativePrompt: "blur"}, "pika-key")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "mp4-data" || status != http.StatusOK || polls.Load() != 3 {
+		t.Errorf("Unexpected result %q (status %d) after %d polls", data, status, polls.Load())
+	}
+
+	if _, status, err := pika.generate(context.Background(), "a cat", 4, "9:16", ClipStyle{Seed: 7, NegativePrompt: "blur"}, "wrong-key"); err == nil || status != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 for a bad key, got status %d, %v", status, err)
+	}
+}
+
+func TestVideoService_PikaFailures(t *testing.T) {
+	var submits atomic.Int32
+	vs, pika := newTestPikaService(t, func(w http.ResponseWriter, r *http.Request) {
+		submits.Add(1)
+		switch r.Header.Get("Authorization") {
+		case "Bearer rejected":
+			http.Error(w, "prompt violates policy", http.StatusBadRequest)
+		default:
+			json.NewEncoder(w).Encode(PikaVideoResponse{JobID: "j2", Status: "failed", Error: "render crashed"})
+		}
+	}, "rejected")
+
+	// A rejected prompt is not retried with other keys
+	if _, err := vs.GenerateSegmentVideo(context.Background(), "", "x", 3, ClipStyle{}, "job1", 0, "landscape"); err == nil {
+		t.Fatal("Expected error")
+	}
+	if submits.Load() != 1 {
+		t.Errorf("Expected a single submit for a 400, got %d", submits.Load())
+	}
+
+	if _, _, err := pika.generate(context.Background(), "x", 3, "", ClipStyle{}, "other"); err == nil {
+		t.Error("Expected a failed render to be reported")
+	}
+}
+
+// flakyVideoProvider refuses prompts starting with "bad"
+type flakyVideoProvider struct {
+	calls atomic.Int32
+}
+
+func (p *flakyVideoProvider) GenerateClip(ctx context.Context, prompt string, duration float64, aspect string, style ClipStyle) (string, error) {
+	p.calls.Add(1)
+	if strings.HasPrefix(prompt, "bad") {
+		return "", errors.New("prompt rejected")
+	}
+	return "", ctx.Err()
+}
+
+func (p *flakyVideoProvider) Capabilities() VideoGenCapabilities {
+	return VideoGenCapabilities{}
+}
+
+func TestVideoService_GenerateVideos(t *testing.T) {
+	vs := NewVideoService(nil, t.TempDir(), "5M", "1920x1080", 30, 0.5)
+	provider := &flakyVideoProvider{}
+	vs.RegisterVideoProvider(VideoProviderPika, provider)
+	prompts := []string{"bad first", "bad second", "bad third"}
+	durations := []float64{3, 3, 3}
+
+	if _, err := vs.GenerateVideos(context.Background(), prompts, durations, "job1", 1, nil, nil); err == nil {
+		t.Fatal("Expected the batch to fail without a fallback")
+	}
+	if n := provider.calls.Load(); n != 1 {
+		t.Errorf("Expected the first failure to cancel the rest, got %d renders", n)
+	}
+
+	var progress atomic.Int32
+	fallback := func(ctx context.Context, index int, prompt string, duration float64) (string, error) {
+		return fmt.Sprintf("stock_%d.mp4", index), nil
+	}
+	paths, err := vs.GenerateVideos(context.Background(), prompts, durations, "job1", 2, func(done, total int) { progress.Store(int32(done)) }, fallback)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(paths) != "[stock_0.mp4 stock_1.mp4 stock_2.mp4]" || progress.Load() != 3 {
+		t.Errorf("Unexpected paths %v after %d progress steps", paths, progress.Load())
+	}
+
+	if _, err := vs.GenerateVideos(context.Background(), prompts, durations[:2], "job1", 2, nil, nil); err == nil {
+		t.Error("Expected a length mismatch error")
+	}
+}
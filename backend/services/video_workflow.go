@@ -5,13 +5,17 @@ import (
 	"aituber/models"
 	"aituber/utils"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
+	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // VideoWorkflowService orchestrates the entire video creation pipeline
@@ -24,6 +28,12 @@ type VideoWorkflowService struct {
 	stockVideoService IStockVideoService
 	composerService   IComposerService
 	geminiService     IScriptGenerator
+	imageService      *ImageService      // nil until SetImageService
+	brollAssets       *BrollAssetStore   // nil until SetBrollAssets
+	introOutro        *IntroOutroStore   // nil until SetIntroOutroClips
+	translator        SubtitleTranslator // nil until SetSubtitleTranslator
+	aligner           WordAligner        // nil until SetWordAligner
+	storage           OutputStorage      // nil until SetOutputStorage
 }
 
 // NewVideoWorkflowService initializes workflow service with all bounded contexts
@@ -49,6 +59,41 @@ func NewVideoWorkflowService(
 	}
 }
 
+// SetImageService enables video_source "images"
+func (s *VideoWorkflowService) SetImageService(is *ImageService) {
+	s.imageService = is
+}
+
+// SetIntroOutroClips lets requests name uploaded intros and outros by ID
+func (s *VideoWorkflowService) SetIntroOutroClips(store *IntroOutroStore) {
+	s.introOutro = store
+}
+
+// SetBrollAssets lets requests interleave uploaded b-roll (GenerateRequest.BrollAssets)
+func (s *VideoWorkflowService) SetBrollAssets(store *BrollAssetStore) {
+	s.brollAssets = store
+}
+
+// outputOrientation is the requested aspect_ratio or orientation, else the platform's: portrait for
+// TikTok, landscape otherwise. Stock search and rendition choice follow it.
+func outputOrientation(req models.GenerateRequest) string {
+	switch req.AspectRatio {
+	case "16:9":
+		return "landscape"
+	case "9:16":
+		return "portrait"
+	case "1:1":
+		return "square"
+	}
+	if req.Orientation != "" {
+		return req.Orientation
+	}
+	if req.Platform == "tiktok" {
+		return "portrait"
+	}
+	return "landscape"
+}
+
 // StartGeneration kicks off background video generation pipeline
 func (s *VideoWorkflowService) StartGeneration(jobID string, req models.GenerateRequest) {
 	s.jobManager.UpdateProgress(jobID, "Creating temporary directories", 3)
@@ -58,11 +103,10 @@ func (s *VideoWorkflowService) StartGeneration(jobID string, req models.Generate
 		s.jobManager.MarkFailed(jobID, fmt.Errorf("failed to create temp dir: %w", err))
 		return
 	}
+	defer utils.SetEncodeQuality(tempDir, req.Quality)()
+	defer utils.SetFFmpegJob(tempDir, jobID)()
 
-	orientation := "landscape"
-	if req.Platform == "tiktok" {
-		orientation = "portrait"
-	}
+	orientation := outputOrientation(req)
 
 	// 1. Script Generation
 	segments, err := s.generateScript(jobID, req)
@@ -70,49 +114,213 @@ func (s *VideoWorkflowService) StartGeneration(jobID string, req models.Generate
 		s.jobManager.MarkFailed(jobID, err)
 		return
 	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("Script ready: %d segments", len(segments)))
+	s.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.ScriptLength = scriptLength(segments)
+	})
+	if req.Storyboard {
+		// Rendering starts again from the approved scenes (POST /api/jobs/:job_id/storyboard/approve)
+		s.planStoryboard(jobID, req, segments)
+		return
+	}
+	if req.TitleCards && !narrationWideSource(req.VideoSource) {
+		n := len(segments)
+		segments = insertTitleCards(segments, s.titleCardSeconds())
+		if added := len(segments) - n; added > 0 {
+			s.jobManager.LogEvent(jobID, fmt.Sprintf("%d title cards added", added))
+		}
+	}
 
-	// 2. Audio Generation
-	audioPaths, audioTexts, err := s.generateAudio(jobID, req, segments)
+	// 2. Audio Generation. Stock clips are fetched while later segments are still being synthesized:
+	// each segment's clip only needs that segment's narration length.
+	type videoResult struct {
+		paths []string
+		err   error
+	}
+	var videoDone chan videoResult
+	var ready chan segmentAudio
+	if !narrationWideSource(req.VideoSource) {
+		ready = make(chan segmentAudio, len(segments))
+		videoDone = make(chan videoResult, 1)
+		go func() {
+			paths, err := s.gatherStockVideos(jobID, segments, ready, req, orientation)
+			videoDone <- videoResult{paths, err}
+		}()
+	}
+	audioPaths, audioTexts, err := s.generateAudio(jobID, req, segments, ready)
 	if err != nil {
+		if videoDone != nil {
+			<-videoDone // let in-flight fetches finish before the job is torn down
+		}
 		s.jobManager.MarkFailed(jobID, err)
 		return
 	}
 
 	// 3. Subtitles Generation (Non-fatal)
 	s.jobManager.UpdateProgress(jobID, "Generating subtitles", 32)
-	if _, err := s.GenerateSRT(jobID, audioPaths, audioTexts, filepath.Join(tempDir, "output"), req.Platform); err != nil {
-		log.Printf("[Job %s] Failed to generate subtitles: %v", jobID, err)
-	}
+	s.writeSubtitles(jobID, tempDir, req, audioPaths, audioTexts)
 
 	// 4. Merge Audio
-	mergedAudioPath, err := s.mergeAudio(jobID, tempDir, audioPaths)
+	mergedAudioPath, err := s.mergeAudio(jobID, tempDir, audioPaths, req)
 	if err != nil {
+		if videoDone != nil {
+			<-videoDone
+		}
 		s.jobManager.MarkFailed(jobID, err)
 		return
 	}
 
-	// 5. Stock Video Gathering
-	mergedVideoPath, err := s.gatherAndConcatStockVideos(jobID, tempDir, segments, audioPaths, req, orientation)
+	// 5. Stock Video Gathering, or one visualization/still clip for the whole narration
+	var segVideoPaths []string
+	if req.VideoSource == VideoSourceWaveform {
+		segVideoPaths, err = s.renderWaveform(jobID, tempDir, mergedAudioPath, req, orientation)
+	} else if req.VideoSource == VideoSourceStatic {
+		segVideoPaths, err = s.renderStatic(jobID, tempDir, mergedAudioPath, req, orientation)
+	} else {
+		s.jobManager.UpdateProgress(jobID, "Waiting for per-segment stock videos", 50)
+		result := <-videoDone
+		segVideoPaths, err = result.paths, result.err
+	}
 	if err != nil {
 		s.jobManager.MarkFailed(jobID, err)
 		return
 	}
 
-	// 6. Composition
-	finalVideoPath, err := s.composeVideoWithAudio(jobID, tempDir, mergedVideoPath, mergedAudioPath)
+	// Keep the paid-for assets so the job can be re-rendered without new TTS/stock calls
+	assets := &models.RenderAssets{
+		Segments:          segments,
+		AudioPaths:        audioPaths,
+		AudioTexts:        audioTexts,
+		SegmentVideoPaths: segVideoPaths,
+		Orientation:       orientation,
+	}
+	s.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.Assets = assets
+	})
+
+	if req.SubtitleReview {
+		// Composition resumes with the corrected cues (POST /api/jobs/:job_id/continue)
+		s.awaitSubtitleReview(jobID, req, assets)
+		return
+	}
+
+	s.assemble(jobID, tempDir, req, assets, mergedAudioPath)
+}
+
+// StartRerender rebuilds the final video of source with the assembly options in req,
+// reusing its audio chunks and stock clips instead of calling TTS and stock providers again.
+func (s *VideoWorkflowService) StartRerender(jobID string, source models.JobStatus, req models.GenerateRequest) {
+	s.jobManager.UpdateProgress(jobID, "Copying assets from source job", 5)
+
+	if source.Assets == nil {
+		s.jobManager.MarkFailed(jobID, fmt.Errorf("source job %s has no reusable assets", source.JobID))
+		return
+	}
+
+	tempDir, err := utils.CreateTempDir(s.cfg.TempDir, jobID)
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, fmt.Errorf("failed to create temp dir: %w", err))
+		return
+	}
+	defer utils.SetEncodeQuality(tempDir, req.Quality)()
+	defer utils.SetFFmpegJob(tempDir, jobID)()
+
+	// Link the source files into this job's dir so expiring the source doesn't break the re-render
+	audioPaths, err := linkAssets(source.Assets.AudioPaths, filepath.Join(tempDir, "audio"))
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, fmt.Errorf("failed to reuse audio chunks: %w", err))
+		return
+	}
+	s.jobManager.UpdateProgress(jobID, "Copying assets from source job", 20)
+
+	segVideoPaths, err := linkAssets(source.Assets.SegmentVideoPaths, filepath.Join(tempDir, "video"))
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, fmt.Errorf("failed to reuse stock clips: %w", err))
+		return
+	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("Reusing %d audio chunks and %d clips from job %s", len(audioPaths), len(segVideoPaths), source.JobID))
+
+	assets := &models.RenderAssets{
+		Segments:          source.Assets.Segments,
+		AudioPaths:        audioPaths,
+		AudioTexts:        source.Assets.AudioTexts,
+		SegmentVideoPaths: segVideoPaths,
+		Orientation:       source.Assets.Orientation,
+	}
+	s.jobManager.UpdateJob(jobID, func(j *models.JobStatus) {
+		j.ScriptLength = scriptLength(assets.Segments)
+		j.Assets = assets
+	})
+
+	s.jobManager.UpdateProgress(jobID, "Generating subtitles", 32)
+	s.writeSubtitles(jobID, tempDir, req, audioPaths, assets.AudioTexts)
+
+	mergedAudioPath, err := s.mergeAudio(jobID, tempDir, audioPaths, req)
 	if err != nil {
 		s.jobManager.MarkFailed(jobID, err)
 		return
 	}
 
-	// 7. Add Intro/Outro for YouTube
-	finalVideoPath, err = s.addIntroOutro(jobID, tempDir, finalVideoPath, req.Platform)
+	s.assemble(jobID, tempDir, req, assets, mergedAudioPath)
+}
+
+// assemble runs the final, cheap stages: concat clips, mux audio, burn subtitles, intro/outro, save
+func (s *VideoWorkflowService) assemble(jobID, tempDir string, req models.GenerateRequest, assets *models.RenderAssets, mergedAudioPath string) {
+	// 6. Concatenate segment clips
+	narration, _ := utils.GetAudioDuration(mergedAudioPath)
+	mergedVideoPath, err := s.concatSegmentVideos(jobID, tempDir, assets.SegmentVideoPaths, req, assets.Orientation, narration)
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, err)
+		return
+	}
+
+	// 7. Composition, with optional background music under the narration, of video conformed to
+	// the audio's length
+	if req.MusicTrack != "" {
+		mergedAudioPath = s.mixMusic(jobID, tempDir, mergedAudioPath, req)
+	}
+	mergedVideoPath = s.conformVideo(jobID, tempDir, mergedVideoPath, mergedAudioPath, req)
+	finalVideoPath, err := s.composeVideoWithAudio(jobID, tempDir, mergedVideoPath, mergedAudioPath, req, assets.Orientation)
 	if err != nil {
 		s.jobManager.MarkFailed(jobID, err)
 		return
 	}
 
-	// 8. Save
+	// 8. Optional burned-in subtitles and lower thirds (before intro so cue times line up with the narration)
+	lowerThirdsPath := s.writeLowerThirds(jobID, tempDir, req, assets)
+	if req.BurnSubtitles || lowerThirdsPath != "" {
+		finalVideoPath, err = s.burnSubtitles(jobID, tempDir, finalVideoPath, req.BurnSubtitles, lowerThirdsPath)
+		if err != nil {
+			s.jobManager.MarkFailed(jobID, err)
+			return
+		}
+	}
+
+	// The thumbnail and previews come from the narrated part, not the intro
+	s.saveThumbnail(jobID, tempDir, finalVideoPath)
+	s.renderPreview(jobID, tempDir, finalVideoPath, req)
+	s.writeChapters(jobID, tempDir, req, assets)
+
+	// 9. Add Intro/Outro for YouTube
+	finalVideoPath, err = s.addIntroOutro(jobID, tempDir, finalVideoPath, req, assets.Orientation)
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, err)
+		return
+	}
+
+	// 10. Loudness of the whole soundtrack, music and intro/outro included
+	if s.cfg.AudioNormalizeFinalMix {
+		finalVideoPath = s.normalizeFinalMix(jobID, tempDir, finalVideoPath, req)
+	}
+
+	// 11. Requested container/codec
+	finalVideoPath, err = s.encodeOutput(jobID, tempDir, finalVideoPath, req)
+	if err != nil {
+		s.jobManager.MarkFailed(jobID, err)
+		return
+	}
+
+	// 12. Save
 	s.jobManager.UpdateProgress(jobID, "Saving video to output folder", 98)
 	savedPath, err := s.saveToOutputFolder(finalVideoPath, req.Platform, req.ContentName)
 	if err != nil {
@@ -122,6 +330,12 @@ func (s *VideoWorkflowService) StartGeneration(jobID string, req models.Generate
 		log.Printf("[Job %s] Video saved to: %s", jobID, savedPath)
 	}
 
+	// 13. Upload to output storage
+	if s.storage != nil {
+		s.jobManager.UpdateProgress(jobID, "Uploading to storage", 99)
+		s.uploadOutputs(jobID, tempDir, finalVideoPath)
+	}
+
 	s.jobManager.UpdateProgress(jobID, "Complete", 100)
 	s.jobManager.MarkCompleted(jobID, finalVideoPath, savedPath)
 	log.Printf("[Job %s] Video generation completed successfully", jobID)
@@ -138,7 +352,14 @@ func (s *VideoWorkflowService) generateScript(jobID string, req models.GenerateR
 	var segments []models.VideoSegment
 	script := req.Script
 
-	if script == "" {
+	if script != "" && req.VideoSource == VideoSourceSlides {
+		if len(script) > s.cfg.MaxTextLength {
+			script = script[:s.cfg.MaxTextLength]
+			log.Printf("[Job %s] Script truncated to %d chars", jobID, s.cfg.MaxTextLength)
+		}
+		segments = ParseMarkdownSlides(script)
+		log.Printf("[Job %s] Created %d slides from Markdown script", jobID, len(segments))
+	} else if script == "" {
 		s.jobManager.UpdateProgress(jobID, "Generating script with Gemini AI", 8)
 		var genErr error
 		if req.Platform == "tiktok" {
@@ -155,12 +376,18 @@ func (s *VideoWorkflowService) generateScript(jobID string, req models.GenerateR
 			script = script[:s.cfg.MaxTextLength]
 			log.Printf("[Job %s] Script truncated to %d chars", jobID, s.cfg.MaxTextLength)
 		}
-		chunks := s.textProcessor.SplitForSubtitles(script)
-		for _, chunk := range chunks {
-			segments = append(segments, models.VideoSegment{
-				Text:         chunk,
-				VisualPrompt: s.textProcessor.ExtractKeywordsFromText(chunk, req.StockKeywords),
-			})
+		limits := s.subtitleLimits(req, outputOrientation(req))
+		for _, section := range splitScriptSections(script) {
+			for i, chunk := range s.textProcessor.SplitForSubtitles(section.Text, limits) {
+				seg := models.VideoSegment{
+					Text:         chunk,
+					VisualPrompt: s.textProcessor.ExtractKeywordsFromText(StripScriptMarkers(StripSSML(chunk)), req.StockKeywords),
+				}
+				if i == 0 {
+					seg.SectionTitle = section.Title
+				}
+				segments = append(segments, seg)
+			}
 		}
 		log.Printf("[Job %s] Created %d segments from direct script text", jobID, len(segments))
 	}
@@ -168,104 +395,266 @@ func (s *VideoWorkflowService) generateScript(jobID string, req models.GenerateR
 }
 
 // Sub-pipeline: Audio
-func (s *VideoWorkflowService) generateAudio(jobID string, req models.GenerateRequest, segments []models.VideoSegment) ([]string, []string, error) {
+func (s *VideoWorkflowService) generateAudio(jobID string, req models.GenerateRequest, segments []models.VideoSegment, ready chan<- segmentAudio) ([]string, []string, error) {
+	if ready != nil {
+		defer close(ready)
+	}
 	s.jobManager.UpdateProgress(jobID, "Preparing text for audio generation", 12)
-	var audioTexts []string
-	for _, seg := range segments {
-		if strings.TrimSpace(seg.Text) != "" {
-			audioTexts = append(audioTexts, seg.Text)
+	// Each segment is synthesized per text run between [pause ...] and [speed:...] markers (chunk
+	// post-processing strips silence, so pauses are inserted afterwards). A speed marker holds for the
+	// following sentences, across segments. SSML scripts get <break> tags instead and cannot be split.
+	var audioTexts, spokenTexts []string
+	var speeds []float64
+	var segParts [][]scriptPart
+	var segIndexes, chunkSegs []int // source segment of each kept segment, kept segment of each chunk
+	speed := req.SpeakingSpeed
+	for i, seg := range segments {
+		if strings.TrimSpace(seg.Text) == "" {
+			continue
+		}
+		var parts []scriptPart
+		if IsSSML(seg.Text) {
+			var text string
+			segSpeed := speed
+			text, speed = ssmlScriptMarkers(seg.Text, speed, req.SpeakingSpeed)
+			parts, _ = splitScriptMarkers(text, segSpeed, req.SpeakingSpeed)
+		} else {
+			parts, speed = splitScriptMarkers(seg.Text, speed, req.SpeakingSpeed)
 		}
+		if len(parts) == 0 {
+			continue
+		}
+		for _, part := range parts {
+			if part.text != "" {
+				// Pronunciation fixes change what is spoken, not the subtitle text
+				spokenTexts = append(spokenTexts, ApplyLexicon(part.text, req.Pronunciations))
+				speeds = append(speeds, part.speed)
+				chunkSegs = append(chunkSegs, len(segParts))
+			}
+		}
+		audioTexts = append(audioTexts, seg.Text)
+		segParts = append(segParts, parts)
+		segIndexes = append(segIndexes, i)
 	}
 
-	if len(audioTexts) == 0 {
+	if len(spokenTexts) == 0 {
 		return nil, nil, fmt.Errorf("no valid script segments extracted to process")
 	}
 
-	s.jobManager.UpdateProgress(jobID, fmt.Sprintf("Generating %d audio chunks", len(audioTexts)), 20)
-	audioPaths, err := s.audioService.GenerateAudioChunks(
-		audioTexts,
+	s.jobManager.UpdateProgress(jobID, fmt.Sprintf("Generating audio 0/%d", len(spokenTexts)), 20)
+	results, err := s.audioService.StreamAudioChunks(
+		spokenTexts,
+		req.TTSProvider,
 		req.Voice,
-		req.SpeakingSpeed,
+		speeds,
+		audioFormat(req),
 		jobID,
 		s.cfg.MaxConcurrentTTSRequests,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("audio generation failed: %w", err)
 	}
+
+	// One audio file per segment, finished (and handed to ready) as soon as its last chunk arrives;
+	// segments with markers are joined from their chunks and silences
+	chunkPaths := make([]string, len(spokenTexts))
+	pending := make([]int, len(segParts))
+	for _, seg := range chunkSegs {
+		pending[seg]++
+	}
+	audioPaths := make([]string, len(segParts))
+	finish := func(seg int) error {
+		path, err := s.segmentAudio(segParts[seg], chunkPaths, chunkSegs, seg, req, jobID)
+		if err != nil {
+			return err
+		}
+		audioPaths[seg] = path
+		if ready != nil {
+			ready <- segmentAudio{Index: segIndexes[seg], Path: path}
+		}
+		return nil
+	}
+
+	var firstErr error
+	for seg, n := range pending {
+		if n == 0 && firstErr == nil {
+			firstErr = finish(seg) // pauses only
+		}
+	}
+	done := 0
+	for chunk := range results {
+		if firstErr != nil {
+			continue // drain so the workers can finish
+		}
+		if chunk.Err != nil {
+			firstErr = fmt.Errorf("audio generation failed: failed to generate audio chunk %d: %w", chunk.Index, chunk.Err)
+			continue
+		}
+		chunkPaths[chunk.Index] = chunk.Path
+		done++
+		// Audio spans 20% -> 40% of the pipeline
+		s.jobManager.UpdateProgress(jobID, fmt.Sprintf("Generating audio %d/%d", done, len(spokenTexts)), 20+done*20/len(spokenTexts))
+
+		seg := chunkSegs[chunk.Index]
+		if pending[seg]--; pending[seg] > 0 {
+			continue
+		}
+		firstErr = finish(seg)
+	}
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("%d audio chunks generated", len(chunkPaths)))
 	return audioPaths, audioTexts, nil
 }
 
+// segmentAudio returns the audio of kept segment seg: its only chunk, or its chunks joined with
+// the silences of its pause markers
+func (s *VideoWorkflowService) segmentAudio(parts []scriptPart, chunkPaths []string, chunkSegs []int, seg int, req models.GenerateRequest, jobID string) (string, error) {
+	next := 0
+	for next < len(chunkSegs) && chunkSegs[next] != seg {
+		next++
+	}
+	if len(parts) == 1 && parts[0].text != "" {
+		return chunkPaths[next], nil
+	}
+	pieces := make([]AudioPiece, 0, len(parts))
+	for _, part := range parts {
+		if part.text == "" {
+			pieces = append(pieces, AudioPiece{Pause: part.pause})
+			continue
+		}
+		pieces = append(pieces, AudioPiece{Path: chunkPaths[next]})
+		next++
+	}
+	segmentPath, err := s.audioService.JoinWithPauses(pieces, audioFormat(req), jobID, seg)
+	if err != nil {
+		return "", fmt.Errorf("segment %d: %w", seg+1, err)
+	}
+	return segmentPath, nil
+}
+
+// audioFormat is the job's override of the configured audio bitrate, sample rate and crossfade
+func audioFormat(req models.GenerateRequest) AudioFormat {
+	return AudioFormat{Bitrate: req.AudioBitrate, SampleRate: req.AudioSampleRate, Crossfade: req.AudioCrossfade}
+}
+
 // Sub-pipeline: Merge Audio
-func (s *VideoWorkflowService) mergeAudio(jobID, tempDir string, audioPaths []string) (string, error) {
+func (s *VideoWorkflowService) mergeAudio(jobID, tempDir string, audioPaths []string, req models.GenerateRequest) (string, error) {
 	s.jobManager.UpdateProgress(jobID, "Merging audio", 42)
 	mergedAudioPath := filepath.Join(tempDir, "output", "merged_audio.mp3")
-	if err := s.audioService.MergeAudioFiles(audioPaths, mergedAudioPath); err != nil {
+	if err := s.audioService.MergeAudioFiles(audioPaths, mergedAudioPath, audioFormat(req)); err != nil {
 		return "", fmt.Errorf("audio merge failed: %w", err)
 	}
 	return mergedAudioPath, nil
 }
 
-// Sub-pipeline: Stock Video
-func (s *VideoWorkflowService) gatherAndConcatStockVideos(
-	jobID, tempDir string, segments []models.VideoSegment, audioPaths []string,
-	req models.GenerateRequest, orientation string,
-) (string, error) {
-	s.jobManager.UpdateProgress(jobID, "Preparing per-segment stock videos", 50)
-
-	realDurations := make([]float64, len(audioPaths))
-	for i, ap := range audioPaths {
-		d, err := utils.GetAudioDuration(ap)
-		if err != nil {
-			log.Printf("[Job %s] Could not get duration of chunk %d: %v (using estimate 5s)", jobID, i, err)
-			d = 5.0
+// Sub-pipeline: Background music (non-fatal: on failure the video keeps the bare narration)
+func (s *VideoWorkflowService) mixMusic(jobID, tempDir, narrationPath string, req models.GenerateRequest) string {
+	s.jobManager.UpdateProgress(jobID, "Mixing background music", 86)
+	bitrate := s.cfg.AudioBitrate
+	if req.AudioBitrate != "" {
+		bitrate = req.AudioBitrate
+	}
+	musicPath, err := ResolveMusicTrack(req.MusicTrack)
+	if err == nil {
+		var duration float64
+		if duration, err = utils.GetAudioDuration(narrationPath); err == nil {
+			mixedPath := filepath.Join(tempDir, "output", "narration_music.mp3")
+			if err = utils.MixBackgroundMusic(narrationPath, musicPath, mixedPath, s.cfg.MusicVolume, duration, bitrate); err == nil {
+				s.jobManager.LogEvent(jobID, fmt.Sprintf("Background music %q mixed in", req.MusicTrack))
+				return mixedPath
+			}
 		}
-		realDurations[i] = d
 	}
+	log.Printf("[Job %s] Background music skipped: %v", jobID, err)
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("Background music skipped: %v", err))
+	return narrationPath
+}
+
+// segmentAudio is the finished narration of segment Index, ready for its stock clip
+type segmentAudio struct {
+	Index int
+	Path  string
+}
 
+// Sub-pipeline: Stock Video
+// Fetches the clip of each segment as soon as its audio arrives on ready (the clip length follows
+// the narration), until ready is closed. Returns the clip of every segment that succeeded, in timeline order.
+func (s *VideoWorkflowService) gatherStockVideos(
+	jobID string, segments []models.VideoSegment, ready <-chan segmentAudio,
+	req models.GenerateRequest, orientation string,
+) ([]string, error) {
 	segKeywords := make([]string, len(segments))
 	for i, seg := range segments {
 		segKeywords[i] = seg.VisualPrompt
 		if strings.TrimSpace(segKeywords[i]) == "" {
-			segKeywords[i] = s.textProcessor.ExtractKeywordsFromText(seg.Text, req.StockKeywords)
+			segKeywords[i] = s.textProcessor.ExtractKeywordsFromText(StripScriptMarkers(StripSSML(seg.Text)), req.StockKeywords)
 		}
 	}
 
+	uploaded := s.assignBrollAssets(jobID, req.BrollAssets, len(segments))
+
 	segVideoPaths := make([]string, len(segments))
 	segErrors := make([]error, len(segments))
 	sem := make(chan struct{}, 3)
 	var wg sync.WaitGroup
 
-	for i := range segments {
+	for audio := range ready {
 		wg.Add(1)
-		go func(idx int) {
+		go func(idx int, audioPath string) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			s.jobManager.UpdateProgress(jobID, fmt.Sprintf("Fetching stock video for segment %d/%d", idx+1, len(segments)), 50+idx*30/len(segments))
-
-			// Create a per-segment context with timeout (3 mins per segment should be plenty)
-			segCtx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
-			defer cancel()
-
-			vp, err := s.stockVideoService.PrepareSegmentVideo(
-				segCtx,
-				segKeywords[idx],
-				segments[idx].VisualDescription,
-				req.T2VModel,
-				req.T2VProvider,
-				realDurations[idx],
-				jobID,
-				idx,
-				orientation,
-			)
+			duration, err := utils.GetAudioDuration(audioPath)
+			if err != nil {
+				log.Printf("[Job %s] Could not get duration of segment %d audio: %v (using estimate 5s)", jobID, idx, err)
+				duration = 5.0
+			}
+
+			var vp string
+			footage := false
+			if segments[idx].TitleCard != "" {
+				vp, err = s.renderTitleCard(jobID, segments[idx], req, duration, idx, orientation)
+			} else if req.VideoSource == VideoSourceAI {
+				vp, err = s.generateAIClip(jobID, segments[idx], req, duration, idx, orientation)
+				if err != nil {
+					log.Printf("[Job %s] Segment %d AI video failed, falling back to stock: %v", jobID, idx, err)
+					s.jobManager.LogEvent(jobID, fmt.Sprintf("Segment %d/%d AI video failed (%v), using stock footage for %q", idx+1, len(segments), err, segKeywords[idx]))
+					vp, err = s.prepareStockClip(jobID, segments[idx], segKeywords[idx], req, duration, idx, orientation)
+				}
+			} else if req.VideoSource == VideoSourceImages {
+				segCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+				defer cancel()
+				vp, err = s.generateImageClip(segCtx, jobID, segments[idx], segKeywords[idx], req, duration, idx, orientation)
+			} else if req.VideoSource == VideoSourceSlides {
+				vp, err = s.renderSlide(jobID, segments[idx], req, duration, idx, orientation)
+			} else if req.VideoSource == VideoSourceLocal {
+				vp, err = s.stockVideoService.PrepareLocalSegment(segKeywords[idx], segments[idx].Text, duration, jobID, idx, orientation)
+				footage = true
+			} else if clip, ok := uploaded[idx]; ok {
+				vp, err = s.stockVideoService.PrepareUploadedSegment(clip, duration, jobID, idx, orientation)
+				footage = true
+			} else {
+				vp, err = s.prepareStockClip(jobID, segments[idx], segKeywords[idx], req, duration, idx, orientation)
+				footage = true
+			}
+			if err == nil && footage && req.KenBurns > 0 {
+				vp = s.addKenBurns(jobID, vp, req.KenBurns, duration, idx, orientation)
+			}
+			if err == nil && req.VideoSource != VideoSourceSlides && strings.TrimSpace(segments[idx].OnScreenText) != "" {
+				vp = s.addOnScreenText(jobID, vp, segments[idx].OnScreenText, idx, orientation)
+			}
 			if err != nil {
 				segErrors[idx] = err
 				log.Printf("[Job %s] Segment %d video error: %v", jobID, idx, err)
+				s.jobManager.LogEvent(jobID, fmt.Sprintf("Segment %d/%d video failed: %v", idx+1, len(segments), err))
 			} else {
 				segVideoPaths[idx] = vp
+				s.jobManager.LogEvent(jobID, fmt.Sprintf("Segment %d/%d video ready", idx+1, len(segments)))
 			}
-		}(i)
+		}(audio.Index, audio.Path)
 	}
 	wg.Wait()
 
@@ -281,83 +670,744 @@ func (s *VideoWorkflowService) gatherAndConcatStockVideos(
 	}
 
 	if len(goodSegPaths) == 0 {
-		return "", fmt.Errorf("all segment video fetches failed")
+		return nil, fmt.Errorf("all segment video fetches failed")
 	}
 
-	s.jobManager.UpdateProgress(jobID, "Concatenating segment videos", 82)
+	return goodSegPaths, nil
+}
+
+// addKenBurns zooms the footage clip of segment idx at intensity, alternating in and out between
+// segments. The clip is kept unzoomed if the zoom fails.
+func (s *VideoWorkflowService) addKenBurns(jobID, clipPath string, intensity, duration float64, idx int, orientation string) string {
+	zoomedPath := strings.TrimSuffix(clipPath, filepath.Ext(clipPath)) + "_kenburns.mp4"
+	if err := utils.KenBurnsVideo(clipPath, zoomedPath, duration, intensity, idx%2 == 1, orientation); err != nil {
+		log.Printf("[Job %s] Segment %d Ken Burns skipped: %v", jobID, idx, err)
+		return clipPath
+	}
+	return zoomedPath
+}
+
+// addOnScreenText captions the clip of segment idx with text. The clip is kept uncaptioned if
+// drawing fails.
+func (s *VideoWorkflowService) addOnScreenText(jobID, clipPath, text string, idx int, orientation string) string {
+	base := strings.TrimSuffix(clipPath, filepath.Ext(clipPath))
+	textFile := base + "_caption.txt"
+	captionedPath := base + "_caption.mp4"
+	err := os.WriteFile(textFile, []byte(strings.TrimSpace(text)), 0644)
+	if err == nil {
+		err = utils.DrawOnScreenText(clipPath, captionedPath, textFile, orientation)
+	}
+	if err != nil {
+		log.Printf("[Job %s] Segment %d on-screen text skipped: %v", jobID, idx, err)
+		s.jobManager.LogEvent(jobID, fmt.Sprintf("Segment %d on-screen text skipped: %v", idx+1, err))
+		return clipPath
+	}
+	return captionedPath
+}
+
+// prepareStockClip fetches the stock footage of segment idx for keywords
+func (s *VideoWorkflowService) prepareStockClip(jobID string, seg models.VideoSegment, keywords string, req models.GenerateRequest, duration float64, idx int, orientation string) (string, error) {
+	// Create a per-segment context with timeout (3 mins per segment should be plenty)
+	segCtx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	return s.stockVideoService.PrepareSegmentVideo(
+		segCtx,
+		keywords,
+		seg.Text,
+		seg.VisualDescription,
+		req.T2VModel,
+		req.T2VProvider,
+		duration,
+		jobID,
+		idx,
+		orientation,
+	)
+}
+
+// assignBrollAssets spreads uploaded clips evenly over the timeline, one per segment: with 2
+// clips and 6 segments they fill segments 1 and 4, stock footage the rest. Extra clips beyond one
+// per segment, and assets that no longer exist, are skipped.
+func (s *VideoWorkflowService) assignBrollAssets(jobID string, ids []string, numSegments int) map[int]BrollClip {
+	if len(ids) == 0 || s.brollAssets == nil {
+		return nil
+	}
+	var clips []BrollClip
+	for _, id := range ids {
+		asset, err := s.brollAssets.Get(id)
+		if err != nil {
+			s.jobManager.LogEvent(jobID, fmt.Sprintf("Uploaded b-roll skipped: %v", err))
+			continue
+		}
+		clips = append(clips, s.brollAssets.Clip(asset))
+	}
+	if len(clips) > numSegments {
+		clips = clips[:numSegments]
+	}
+	assigned := make(map[int]BrollClip, len(clips))
+	for k, clip := range clips {
+		assigned[k*numSegments/len(clips)] = clip
+	}
+	return assigned
+}
+
+// generateAIClip renders segment idx with the requested video provider from its visual
+// description, or a prompt built from the narration when the script has none. A prompt the
+// provider refuses or times out on is rewritten (see rewriteVideoPrompt) and tried once more.
+func (s *VideoWorkflowService) generateAIClip(jobID string, seg models.VideoSegment, req models.GenerateRequest, duration float64, idx int, orientation string) (string, error) {
+	if s.videoService == nil {
+		return "", fmt.Errorf("AI video generation is not configured")
+	}
+	prompt := strings.TrimSpace(seg.VisualDescription)
+	if prompt == "" {
+		style := req.VideoStyle
+		if style == "" {
+			style = "cinematic"
+		}
+		prompt = s.videoService.visualPrompt(context.Background(), StripScriptMarkers(StripSSML(seg.Text)), style, s.promptTemplate(req), idx)
+	}
+	style, err := clipStyle(req)
+	if err != nil {
+		return "", err
+	}
+
+	clipPath, err := s.renderAIClip(req.VideoProvider, prompt, duration, style, jobID, idx, orientation)
+	if err == nil || !retryablePromptError(err) {
+		return clipPath, err
+	}
+	rewritten, ok := rewriteVideoPrompt(prompt, s.cfg.StockBlocklist)
+	if !ok {
+		return "", err
+	}
+	log.Printf("[Job %s] Segment %d prompt failed (%v), retrying as %q", jobID, idx, err, rewritten)
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("Segment %d AI prompt failed (%v), retrying with rewritten prompt %q", idx+1, err, rewritten))
+	return s.renderAIClip(req.VideoProvider, rewritten, duration, style, jobID, idx, orientation)
+}
+
+// promptTemplate is req's visual prompt template and branding, defaulting to PROMPT_TEMPLATE
+// and PROMPT_BRANDING (the handler has already merged in the tenant's)
+func (s *VideoWorkflowService) promptTemplate(req models.GenerateRequest) models.PromptTemplate {
+	tmpl := models.PromptTemplate{Template: req.PromptTemplate, Branding: req.Branding}
+	if tmpl.Template == "" {
+		tmpl.Template = s.cfg.PromptTemplate
+	}
+	if tmpl.Branding == "" {
+		tmpl.Branding = s.cfg.PromptBranding
+	}
+	return tmpl
+}
+
+// renderAIClip makes one text-to-video render of prompt
+func (s *VideoWorkflowService) renderAIClip(provider, prompt string, duration float64, style ClipStyle, jobID string, idx int, orientation string) (string, error) {
+	// Text-to-video renders take minutes, queued behind the per-key rate limit
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	return s.videoService.GenerateSegmentVideo(ctx, provider, prompt, duration, style, jobID, idx, orientation)
+}
+
+// clipStyle is the look shared by every AI clip of req; style_image is read from static/
+func clipStyle(req models.GenerateRequest) (ClipStyle, error) {
+	style := ClipStyle{Seed: req.Seed, NegativePrompt: strings.TrimSpace(req.NegativePrompt)}
+	if req.StyleImage != "" {
+		data, err := os.ReadFile(ResolveStaticVideo(req.StyleImage, ""))
+		if err != nil {
+			return style, fmt.Errorf("failed to read style_image: %w", err)
+		}
+		style.StyleImage = "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+	return style, nil
+}
+
+// generateImageClip renders a still for segment idx from its visual description (or keywords)
+// and animates it with a slow Ken Burns zoom over the segment's narration
+func (s *VideoWorkflowService) generateImageClip(ctx context.Context, jobID string, seg models.VideoSegment, keywords string, req models.GenerateRequest, duration float64, idx int, orientation string) (string, error) {
+	if s.imageService == nil {
+		return "", fmt.Errorf("image generation is not configured")
+	}
+	subject := strings.TrimSpace(seg.VisualDescription)
+	if subject == "" {
+		subject = keywords
+	}
+	style := req.VideoStyle
+	if style == "" {
+		style = "cinematic"
+	}
+	prompt := fmt.Sprintf("%s, %s style, dramatic lighting, highly detailed, no text", subject, style)
+
+	dir := filepath.Join(s.cfg.TempDir, jobID, "images")
+	imagePath := filepath.Join(dir, fmt.Sprintf("segment_%03d.png", idx))
+	if err := s.imageService.GenerateImage(ctx, req.ImageProvider, prompt, orientation, imagePath); err != nil {
+		return "", err
+	}
+	clipPath := filepath.Join(dir, fmt.Sprintf("segment_%03d.mp4", idx))
+	if err := utils.ImageToVideo(imagePath, clipPath, duration, orientation); err != nil {
+		return "", fmt.Errorf("failed to animate image: %w", err)
+	}
+	return clipPath, nil
+}
+
+// Sub-pipeline: Waveform visualization
+// Renders the narration's visualization as the only clip, so re-renders reuse it like stock clips.
+func (s *VideoWorkflowService) renderWaveform(jobID, tempDir, mergedAudioPath string, req models.GenerateRequest, orientation string) ([]string, error) {
+	s.jobManager.UpdateProgress(jobID, "Rendering waveform video", 50)
+
+	background := ResolveStaticVideo(req.BackgroundImage, defaultWaveformBackground)
+	if !utils.FileExists(background) {
+		background = ""
+	}
+	videoPath := filepath.Join(tempDir, "video", "waveform.mp4")
+	if err := os.MkdirAll(filepath.Dir(videoPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create video dir: %w", err)
+	}
+	if err := utils.RenderWaveformVideo(mergedAudioPath, background, videoPath, req.WaveformStyle, orientation, s.frameRate(req)); err != nil {
+		return nil, fmt.Errorf("waveform render failed: %w", err)
+	}
+	s.jobManager.LogEvent(jobID, "Waveform video ready")
+	return []string{videoPath}, nil
+}
+
+// Sub-pipeline: Static card
+// Holds one frame (background image or color, title, avatar) for the length of the narration
+func (s *VideoWorkflowService) renderStatic(jobID, tempDir, mergedAudioPath string, req models.GenerateRequest, orientation string) ([]string, error) {
+	s.jobManager.UpdateProgress(jobID, "Rendering static video", 50)
+
+	duration, err := utils.GetAudioDuration(mergedAudioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read narration duration: %w", err)
+	}
+	videoPath := filepath.Join(tempDir, "video", "static.mp4")
+	if err := os.MkdirAll(filepath.Dir(videoPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create video dir: %w", err)
+	}
+
+	card := utils.StaticCard{Color: req.BackgroundColor}
+	if req.BackgroundImage != "" {
+		card.Background = ResolveStaticVideo(req.BackgroundImage, "")
+	}
+	if req.AvatarImage != "" {
+		card.Avatar = ResolveStaticVideo(req.AvatarImage, "")
+	}
+	if title := strings.TrimSpace(req.Title); title != "" {
+		// drawtext reads the title from a file, sparing it filtergraph escaping
+		card.TitleFile = filepath.Join(tempDir, "video", "title.txt")
+		if err := os.WriteFile(card.TitleFile, []byte(title), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write title: %w", err)
+		}
+	}
+	if err := utils.RenderStaticVideo(videoPath, duration, card, orientation, s.frameRate(req)); err != nil {
+		return nil, fmt.Errorf("static render failed: %w", err)
+	}
+	s.jobManager.LogEvent(jobID, "Static video ready")
+	return []string{videoPath}, nil
+}
+
+// renderSlide renders segment idx as a still slide: its title, and its body wrapped to the frame.
+// Segments without slide fields (generated scripts) show their narration as the body.
+func (s *VideoWorkflowService) renderSlide(jobID string, seg models.VideoSegment, req models.GenerateRequest, duration float64, idx int, orientation string) (string, error) {
+	slideDir := filepath.Join(s.cfg.TempDir, jobID, "video", fmt.Sprintf("slide_%03d", idx))
+	if err := os.MkdirAll(slideDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create slide dir: %w", err)
+	}
+	body := seg.SlideBody
+	if seg.SlideTitle == "" && body == "" {
+		body = StripScriptMarkers(StripSSML(seg.Text))
+	}
+	width := 48
+	switch orientation {
+	case "portrait":
+		width = 26
+	case "square":
+		width = 30
+	}
+
+	card := utils.StaticCard{Color: req.BackgroundColor}
+	if req.BackgroundImage != "" {
+		card.Background = ResolveStaticVideo(req.BackgroundImage, "")
+	}
+	for _, text := range []struct {
+		path  *string
+		name  string
+		value string
+	}{
+		{&card.TitleFile, "title.txt", seg.SlideTitle},
+		{&card.BodyFile, "body.txt", wrapSlideText(body, width)},
+	} {
+		if strings.TrimSpace(text.value) == "" {
+			continue
+		}
+		*text.path = filepath.Join(slideDir, text.name)
+		if err := os.WriteFile(*text.path, []byte(text.value), 0644); err != nil {
+			return "", fmt.Errorf("failed to write slide text: %w", err)
+		}
+	}
+
+	clipPath := filepath.Join(slideDir, "slide.mp4")
+	if err := utils.RenderStaticVideo(clipPath, duration, card, orientation, s.frameRate(req)); err != nil {
+		return "", fmt.Errorf("slide render failed: %w", err)
+	}
+	return clipPath, nil
+}
+
+// narrationWideSource reports whether source renders one clip over the merged narration
+// instead of a clip per segment
+func narrationWideSource(source string) bool {
+	return source == VideoSourceWaveform || source == VideoSourceStatic
+}
+
+// resolutionShortEdges maps GenerateRequest.Resolution to the short edge of the frame
+var resolutionShortEdges = map[string]int{"720p": 720, "1080p": 1080, "4k": 2160}
+
+// IsValidResolution reports whether name is a supported resolution override
+func IsValidResolution(name string) bool {
+	_, ok := resolutionShortEdges[name]
+	return ok
+}
+
+// resolution is req.Resolution, else the one of req.Quality ("720p" for drafts), else ""
+func resolution(req models.GenerateRequest) string {
+	if req.Resolution != "" {
+		return req.Resolution
+	}
+	return utils.QualityResolution(req.Quality)
+}
+
+// frameResolution is the "WxH" output frame of orientation at the request's resolution; without
+// one VIDEO_RESOLUTION sets the landscape one
+func (s *VideoWorkflowService) frameResolution(req models.GenerateRequest, orientation string) string {
+	width, height := utils.FrameSize(orientation)
+	if short, ok := resolutionShortEdges[resolution(req)]; ok {
+		return fmt.Sprintf("%dx%d", width*short/1080, height*short/1080)
+	}
+	if (orientation == "" || orientation == "landscape") && s.cfg.VideoResolution != "" {
+		return s.cfg.VideoResolution
+	}
+	return fmt.Sprintf("%dx%d", width, height)
+}
+
+// frameRate is req.FPS, else VIDEO_FPS
+func (s *VideoWorkflowService) frameRate(req models.GenerateRequest) int {
+	if req.FPS > 0 {
+		return req.FPS
+	}
+	if s.cfg.VideoFPS > 0 {
+		return s.cfg.VideoFPS
+	}
+	return 30
+}
+
+// ffmpegProgress moves jobID's progress from `from` to `to` while ffmpeg writes outputPath,
+// duration seconds of video; call the returned func when it is done
+func (s *VideoWorkflowService) ffmpegProgress(jobID, step, outputPath string, duration float64, from, to int) func() {
+	s.jobManager.UpdateProgress(jobID, step, from)
+	last := from
+	return utils.WatchProgress(outputPath, duration, func(done float64) {
+		if p := from + int(done*float64(to-from)); p > last {
+			last = p
+			s.jobManager.UpdateProgress(jobID, step, p)
+		}
+	})
+}
+
+// mediaDuration is the total length of the videos at paths, 0 if one cannot be probed
+func mediaDuration(paths ...string) float64 {
+	total := 0.0
+	for _, path := range paths {
+		duration, err := utils.GetVideoDuration(path)
+		if err != nil {
+			return 0
+		}
+		total += duration
+	}
+	return total
+}
+
+// Sub-pipeline: Segment concat (hard cuts, or xfade when req.VideoTransition is set), then the
+// optional color grade of all the footage. duration is the narration's, which the clips add up to.
+func (s *VideoWorkflowService) concatSegmentVideos(jobID, tempDir string, segPaths []string, req models.GenerateRequest, orientation string, duration float64) (string, error) {
+	grade, grading, err := colorGrade(req.ColorGrade)
+	if err != nil {
+		return "", err
+	}
+	end := 90
+	if grading {
+		end = 87
+	}
+
+	mergedPath, err := s.mergeSegmentVideos(jobID, tempDir, segPaths, req, orientation, duration, end)
+	if err != nil || !grading {
+		return mergedPath, err
+	}
+
+	gradedPath := filepath.Join(tempDir, "output", "segments_graded.mp4")
+	defer s.ffmpegProgress(jobID, "Color grading footage", gradedPath, duration, end, 90)()
+	if err := utils.GradeVideo(mergedPath, gradedPath, grade); err != nil {
+		return "", fmt.Errorf("color grade failed: %w", err)
+	}
+	return gradedPath, nil
+}
+
+// mergeSegmentVideos joins the segment clips at the requested frame size and rate, moving the
+// progress from 82 to end
+func (s *VideoWorkflowService) mergeSegmentVideos(jobID, tempDir string, segPaths []string, req models.GenerateRequest, orientation string, duration float64, end int) (string, error) {
 	concatVideoPath := filepath.Join(tempDir, "output", "segments_concat.mp4")
-	if err := utils.ConcatVideosNoAudio(goodSegPaths, concatVideoPath); err != nil {
+
+	if req.VideoTransition != "" && len(segPaths) > 1 {
+		defer s.ffmpegProgress(jobID, "Concatenating segment videos", concatVideoPath, duration, 82, end)()
+		err := utils.MergeVideosWithTransition(segPaths, concatVideoPath, req.VideoTransition, s.cfg.VideoTransitionDuration, s.frameRate(req), s.frameResolution(req, orientation))
+		if err != nil {
+			return "", fmt.Errorf("segment video transition merge failed: %w", err)
+		}
+		return concatVideoPath, nil
+	}
+
+	s.jobManager.UpdateProgress(jobID, "Concatenating segment videos", 82)
+	if err := utils.ConcatVideosNoAudio(segPaths, concatVideoPath); err != nil {
 		return "", fmt.Errorf("segment video concat failed: %w", err)
 	}
+	if resolution(req) == "" && req.FPS == 0 {
+		return concatVideoPath, nil
+	}
 
-	return concatVideoPath, nil
+	// Segment clips are rendered at the 1080p frame; re-encode once to the requested one
+	resizedPath := filepath.Join(tempDir, "output", "segments_resized.mp4")
+	defer s.ffmpegProgress(jobID, "Resizing segment videos", resizedPath, duration, 84, end)()
+	if err := utils.ResizeVideo(concatVideoPath, resizedPath, s.frameResolution(req, orientation), s.frameRate(req)); err != nil {
+		return "", fmt.Errorf("segment video resize failed: %w", err)
+	}
+	return resizedPath, nil
+}
+
+// Sub-pipeline: Burned-in subtitles, when subtitles is set, and the lower thirds at
+// lowerThirdsPath, if any, in one pass
+func (s *VideoWorkflowService) burnSubtitles(jobID, tempDir, videoPath string, subtitles bool, lowerThirdsPath string) (string, error) {
+	var assPaths []string
+	step := "Drawing lower thirds"
+	if subtitles {
+		assPaths = append(assPaths, filepath.Join(tempDir, "output", "subtitles_burn.ass"))
+		step = "Burning subtitles"
+	}
+	if lowerThirdsPath != "" {
+		assPaths = append(assPaths, lowerThirdsPath)
+	}
+	burnedPath := filepath.Join(tempDir, "output", "final_video_subtitled.mp4")
+	defer s.ffmpegProgress(jobID, step, burnedPath, mediaDuration(videoPath), 93, 95)()
+	if err := s.composerService.BurnSubtitles(videoPath, burnedPath, assPaths...); err != nil {
+		return "", err
+	}
+	return burnedPath, nil
+}
+
+// Sub-pipeline: A/V conform. Transition offsets and per-segment rounding add up over long
+// videos, and composing with -shortest would then cut the narration or leave the video running
+// on; the video is padded or trimmed to the audio stream's length instead. Returns videoPath
+// when the streams already match to within a frame or either cannot be measured.
+func (s *VideoWorkflowService) conformVideo(jobID, tempDir, videoPath, audioPath string, req models.GenerateRequest) string {
+	videoDuration, err := utils.GetStreamDuration(videoPath, "v")
+	if err != nil {
+		log.Printf("[Job %s] A/V conform skipped: %v", jobID, err)
+		return videoPath
+	}
+	audioDuration, err := utils.GetStreamDuration(audioPath, "a")
+	if err != nil {
+		log.Printf("[Job %s] A/V conform skipped: %v", jobID, err)
+		return videoPath
+	}
+	drift := videoDuration - audioDuration
+	if math.Abs(drift) <= 1/float64(s.frameRate(req)) {
+		return videoPath
+	}
+
+	conformedPath := filepath.Join(tempDir, "output", "segments_conformed.mp4")
+	defer s.ffmpegProgress(jobID, "Matching video to narration length", conformedPath, audioDuration, 90, 90)()
+	if err := utils.ConformVideo(videoPath, conformedPath, videoDuration, audioDuration); err != nil {
+		log.Printf("[Job %s] A/V conform failed: %v", jobID, err)
+		s.jobManager.LogEvent(jobID, fmt.Sprintf("Video is %+.2fs off the narration; conforming it failed: %v", drift, err))
+		return videoPath
+	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("Video conformed to the narration (was %+.2fs off)", drift))
+	return conformedPath
 }
 
 // Sub-pipeline: Compositing
-func (s *VideoWorkflowService) composeVideoWithAudio(jobID, tempDir, mergedVideoPath, mergedAudioPath string) (string, error) {
-	s.jobManager.UpdateProgress(jobID, "Composing final video with audio", 90)
+func (s *VideoWorkflowService) composeVideoWithAudio(jobID, tempDir, mergedVideoPath, mergedAudioPath string, req models.GenerateRequest, orientation string) (string, error) {
 	composedPath := filepath.Join(tempDir, "output", "final_video_composed.mp4")
-	if err := s.composerService.ComposeVideoWithAudio(mergedVideoPath, mergedAudioPath, composedPath); err != nil {
+	defer s.ffmpegProgress(jobID, "Composing final video with audio", composedPath, mediaDuration(mergedVideoPath), 90, 93)()
+	subtitlePath := softSubtitlePath(tempDir, req, "subtitles_soft.srt")
+	if req.Presenter.Video != "" {
+		// Composited here, under the burned subtitles and lower thirds
+		presenter := s.presenter(req, orientation)
+		if err := s.composerService.ComposeWithPresenter(mergedVideoPath, mergedAudioPath, subtitlePath, composedPath, presenter); err != nil {
+			return "", fmt.Errorf("composition failed: %w", err)
+		}
+		s.jobManager.LogEvent(jobID, fmt.Sprintf("Presenter %s overlaid %s", req.Presenter.Video, strings.ReplaceAll(presenter.Corner, "_", " ")))
+		return composedPath, nil
+	}
+	if err := s.composerService.ComposeVideoWithAudio(mergedVideoPath, mergedAudioPath, subtitlePath, composedPath); err != nil {
 		return "", fmt.Errorf("composition failed: %w", err)
 	}
 	return composedPath, nil
 }
 
 // Sub-pipeline: Intro Outro
-func (s *VideoWorkflowService) addIntroOutro(jobID, tempDir, finalVideoPath, platform string) (string, error) {
+func (s *VideoWorkflowService) addIntroOutro(jobID, tempDir, finalVideoPath string, req models.GenerateRequest, orientation string) (string, error) {
 	s.jobManager.UpdateProgress(jobID, "Adding intro/outro", 95)
 
-	introPath := "static/intro_video.mp4"
-	outroPath := "static/outro_video.mp4"
+	introPath := s.introOutroPath(req.IntroVideo, defaultIntroVideo)
+	outroPath := s.introOutroPath(req.OutroVideo, defaultOutroVideo)
+	if req.EndScreen.Enabled && req.Platform == "youtube" {
+		if endScreen, err := s.renderEndScreen(tempDir, req, orientation); err != nil {
+			log.Printf("[Job %s] End screen failed: %v", jobID, err)
+			s.jobManager.LogEvent(jobID, fmt.Sprintf("End screen failed (%v), using the outro video", err))
+		} else {
+			outroPath = endScreen
+		}
+	}
 
-	concatList := utils.BuildFinalConcatList(platform, introPath, outroPath, finalVideoPath)
+	concatList := utils.BuildFinalConcatList(req.Platform, introPath, outroPath, finalVideoPath)
 
 	if len(concatList) > 1 {
 		finalWithIntroOutro := filepath.Join(tempDir, "output", "final_complete.mp4")
-		if err := utils.ConcatVideos(concatList, finalWithIntroOutro); err != nil {
+		defer s.ffmpegProgress(jobID, "Adding intro/outro", finalWithIntroOutro, mediaDuration(concatList...), 95, 97)()
+		if err := utils.ConcatVideos(concatList, finalWithIntroOutro, s.frameResolution(req, orientation), s.frameRate(req)); err != nil {
 			return "", fmt.Errorf("failed to add intro/outro: %w", err)
 		}
+		// Concatenation drops the soft subtitles; put back the copy offset by the intro
+		if srtPath := softSubtitlePath(tempDir, req, "subtitles.srt"); srtPath != "" {
+			captioned := filepath.Join(tempDir, "output", "final_complete_subs.mp4")
+			if err := utils.AddSubtitleTrack(finalWithIntroOutro, srtPath, captioned); err != nil {
+				log.Printf("[Job %s] Failed to embed subtitles after intro/outro: %v", jobID, err)
+				s.jobManager.LogEvent(jobID, "Soft subtitles dropped: embedding them after the intro/outro failed")
+				return finalWithIntroOutro, nil
+			}
+			return captioned, nil
+		}
 		return finalWithIntroOutro, nil
 	}
 
 	return finalVideoPath, nil
 }
 
+// Sub-pipeline: Final mix loudness. The narration is normalized when it is merged, but music
+// and intro/outro clips bring their own levels; the finished soundtrack is normalized again to
+// the same target. Non-fatal: on failure the video keeps its mix.
+func (s *VideoWorkflowService) normalizeFinalMix(jobID, tempDir, videoPath string, req models.GenerateRequest) string {
+	sampleRate := s.cfg.AudioSampleRate
+	if req.AudioSampleRate > 0 {
+		sampleRate = req.AudioSampleRate
+	}
+	target := utils.LoudnessTarget{Integrated: s.cfg.AudioLoudnessTarget, TruePeak: s.cfg.AudioTruePeak, LRA: s.cfg.AudioLoudnessRange}
+	normalizedPath := filepath.Join(tempDir, "output", "final_loudnorm.mp4")
+	defer s.ffmpegProgress(jobID, "Normalizing final mix loudness", normalizedPath, mediaDuration(videoPath), 97, 97)()
+	if err := utils.NormalizeVideoLoudness(videoPath, normalizedPath, target, "192k", sampleRate); err != nil {
+		log.Printf("[Job %s] Final mix loudness normalization failed: %v", jobID, err)
+		s.jobManager.LogEvent(jobID, fmt.Sprintf("Final mix loudness normalization skipped: %v", err))
+		return videoPath
+	}
+	s.jobManager.LogEvent(jobID, fmt.Sprintf("Final mix normalized to %.1f LUFS", target.Integrated))
+	return normalizedPath
+}
+
+// Sub-pipeline: Output format (H.264 MP4 needs no pass of its own)
+func (s *VideoWorkflowService) encodeOutput(jobID, tempDir, finalVideoPath string, req models.GenerateRequest) (string, error) {
+	format, err := utils.ResolveOutputFormat(req.OutputFormat, req.VideoCodec)
+	if err != nil {
+		return "", err
+	}
+	if format.IsDefault() {
+		return finalVideoPath, nil
+	}
+	encodedPath := filepath.Join(tempDir, "output", "final_encoded"+format.Ext())
+	defer s.ffmpegProgress(jobID, fmt.Sprintf("Encoding %s (%s)", format.Container, format.Codec), encodedPath, mediaDuration(finalVideoPath), 97, 98)()
+	if err := s.composerService.EncodeOutput(finalVideoPath, encodedPath, format); err != nil {
+		return "", err
+	}
+	return encodedPath, nil
+}
+
+// saveThumbnail saves a frame a third into videoPath as output/thumbnail.jpg; failures are only logged
+func (s *VideoWorkflowService) saveThumbnail(jobID, tempDir, videoPath string) {
+	at := 1.0
+	if duration, err := utils.GetVideoDuration(videoPath); err == nil && duration > 0 {
+		at = duration / 3
+	}
+	if err := utils.ExtractFrame(videoPath, at, filepath.Join(tempDir, "output", "thumbnail.jpg")); err != nil {
+		log.Printf("[Job %s] Failed to save thumbnail: %v", jobID, err)
+	}
+}
+
+// Preview files saved next to the subtitles, and the length of the GIF
+const (
+	PreviewFileName    = "preview.mp4"
+	PreviewGIFFileName = "preview.gif"
+	previewGIFSeconds  = 10.0
+)
+
+// Preview choices of GenerateRequest.Preview
+const (
+	PreviewMP4  = "mp4"
+	PreviewGIF  = "gif"
+	PreviewBoth = "both"
+)
+
+// IsValidPreview reports whether preview is a GenerateRequest.Preview choice
+func IsValidPreview(preview string) bool {
+	switch preview {
+	case "", PreviewMP4, PreviewGIF, PreviewBoth:
+		return true
+	}
+	return false
+}
+
+// renderPreview saves the previews req asks for of videoPath, the composed narration, as
+// output/preview.mp4 and output/preview.gif; failures are only logged
+func (s *VideoWorkflowService) renderPreview(jobID, tempDir, videoPath string, req models.GenerateRequest) {
+	if req.Preview == "" {
+		return
+	}
+	s.jobManager.UpdateProgress(jobID, "Rendering preview", 95)
+	outputDir := filepath.Join(tempDir, "output")
+	if req.Preview == PreviewMP4 || req.Preview == PreviewBoth {
+		if err := utils.RenderPreview(videoPath, filepath.Join(outputDir, PreviewFileName)); err != nil {
+			log.Printf("[Job %s] Failed to render preview: %v", jobID, err)
+		}
+	}
+	if req.Preview == PreviewGIF || req.Preview == PreviewBoth {
+		if err := utils.RenderPreviewGIF(videoPath, filepath.Join(outputDir, PreviewGIFFileName), previewGIFSeconds); err != nil {
+			log.Printf("[Job %s] Failed to render preview GIF: %v", jobID, err)
+		}
+	}
+}
+
+// softSubtitlePath is the SRT name under tempDir/output to embed when req asks for soft
+// subtitles and it was written, or ""
+func softSubtitlePath(tempDir string, req models.GenerateRequest, name string) string {
+	if !req.SoftSubtitles {
+		return ""
+	}
+	path := filepath.Join(tempDir, "output", name)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
 func (s *VideoWorkflowService) saveToOutputFolder(srcPath, platform, contentName string) (string, error) {
 	destDir := filepath.Join(s.cfg.OutputDir, platform, contentName)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create output dir: %w", err)
 	}
-	destPath := filepath.Join(destDir, "final_video.mp4")
+	name := "final_video" + filepath.Ext(srcPath)
+	destPath := filepath.Join(destDir, name)
 	if err := utils.CopyFile(srcPath, destPath); err != nil {
 		return "", fmt.Errorf("failed to copy file: %w", err)
 	}
-	return filepath.Join("ai-videos", platform, contentName, "final_video.mp4"), nil
+	return filepath.Join("ai-videos", platform, contentName, name), nil
+}
+
+// writeSubtitles times a cue per audio chunk and writes the subtitle files from them. Subtitle
+// failures are logged but never fail the job.
+func (s *VideoWorkflowService) writeSubtitles(jobID, tempDir string, req models.GenerateRequest, audioPaths, audioTexts []string) {
+	style, _ := s.subtitleStyle(req, outputOrientation(req))
+	cues, err := s.subtitleCues(audioPaths, audioTexts, "", style.Karaoke)
+	if err != nil {
+		log.Printf("[Job %s] Failed to generate subtitles: %v", jobID, err)
+		return
+	}
+	s.writeSubtitleFiles(jobID, tempDir, req, cues)
+}
+
+// writeSubtitleFiles writes narration-timed cues as the downloadable SRT, VTT, styled ASS and JSON
+// transcript (offset by the intro), their translations and the un-offset copies to burn in or embed
+func (s *VideoWorkflowService) writeSubtitleFiles(jobID, tempDir string, req models.GenerateRequest, cues []utils.SubtitleCue) {
+	outputDir := filepath.Join(tempDir, "output")
+
+	shifted := utils.ShiftCues(cues, s.introDuration(req))
+
+	orientation := outputOrientation(req)
+	style, maxLines := s.subtitleStyle(req, orientation)
+	lineChars := utils.SubtitleLineChars(style, orientation)
+	wrapped := utils.WrapCues(shifted, lineChars, maxLines)
+	err := writeSRTCues(filepath.Join(outputDir, "subtitles.srt"), wrapped)
+	if err == nil {
+		err = utils.WriteVTT(filepath.Join(outputDir, "subtitles.vtt"), wrapped)
+	}
+	if err == nil {
+		err = utils.WriteASS(filepath.Join(outputDir, "subtitles.ass"), style, orientation, wrapped)
+	}
+	if err == nil {
+		err = writeTranscript(filepath.Join(outputDir, TranscriptFileName), req, shifted)
+	}
+	if err != nil {
+		log.Printf("[Job %s] Failed to generate subtitles: %v", jobID, err)
+	} else {
+		s.translateSubtitles(jobID, outputDir, req, shifted, lineChars, maxLines)
+	}
+
+	if req.BurnSubtitles {
+		if err := utils.WriteASS(filepath.Join(outputDir, "subtitles_burn.ass"), style, orientation, utils.WrapCues(cues, lineChars, maxLines)); err != nil {
+			log.Printf("[Job %s] Failed to generate burn-in subtitles: %v", jobID, err)
+		}
+	}
+	// The soft track is muxed in before the intro is added, so it keeps narration time too
+	if req.SoftSubtitles {
+		if err := writeSRTCues(filepath.Join(outputDir, "subtitles_soft.srt"), utils.WrapCues(cues, lineChars, maxLines)); err != nil {
+			log.Printf("[Job %s] Failed to generate soft subtitles: %v", jobID, err)
+		}
+	}
 }
 
 // GenerateSRT creates an SRT subtitle file based on audio durations and texts
 func (s *VideoWorkflowService) GenerateSRT(jobID string, audioPaths []string, texts []string, outputDir string, platform string) (string, error) {
-	srtPath := filepath.Join(outputDir, "subtitles.srt")
+	introPath := ""
+	if platform == "youtube" {
+		introPath = s.introOutroPath("", defaultIntroVideo)
+	}
+	return s.writeSRT(filepath.Join(outputDir, "subtitles.srt"), audioPaths, texts, introPath)
+}
+
+// writeSRT writes cues for each audio chunk to srtPath, shifted by the duration of introPath if it exists
+func (s *VideoWorkflowService) writeSRT(srtPath string, audioPaths []string, texts []string, introPath string) (string, error) {
+	cues, err := s.subtitleCues(audioPaths, texts, introPath, false)
+	if err != nil {
+		return "", err
+	}
+	if err := writeSRTCues(srtPath, cues); err != nil {
+		return "", err
+	}
+	return srtPath, nil
+}
+
+// writeSRTCues writes numbered SRT cues to srtPath
+func writeSRTCues(srtPath string, cues []utils.SubtitleCue) error {
 	file, err := os.Create(srtPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to create SRT file: %w", err)
+		return fmt.Errorf("failed to create SRT file: %w", err)
 	}
 	defer file.Close()
+	for i, cue := range cues {
+		fmt.Fprintf(file, "%d\n%s --> %s\n%s\n\n", i+1, utils.FormatSRTTimestamp(cue.Start), utils.FormatSRTTimestamp(cue.End), cue.Text)
+	}
+	return nil
+}
 
+// subtitleCues times one cue per audio chunk, shifted by the duration of introPath if it exists,
+// and with words also each word of it
+func (s *VideoWorkflowService) subtitleCues(audioPaths []string, texts []string, introPath string, words bool) ([]utils.SubtitleCue, error) {
 	currentOffset := 0.0
-	if platform == "youtube" {
-		if introDur, err := utils.GetVideoDuration("static/intro_video.mp4"); err == nil {
+	if introPath != "" {
+		if introDur, err := utils.GetVideoDuration(introPath); err == nil {
 			currentOffset = introDur
 		}
 	}
 
+	var cues []utils.SubtitleCue
 	for i, audioPath := range audioPaths {
 		if i >= len(texts) {
 			break
 		}
 		duration, err := utils.GetAudioDuration(audioPath)
 		if err != nil {
-			return "", fmt.Errorf("failed to get audio duration for %s: %w", audioPath, err)
+			return nil, fmt.Errorf("failed to get audio duration for %s: %w", audioPath, err)
 		}
 		if i > 0 {
 			currentOffset -= s.cfg.AudioCrossfadeDuration
@@ -366,10 +1416,109 @@ func (s *VideoWorkflowService) GenerateSRT(jobID string, audioPaths []string, te
 		end := currentOffset + duration
 		currentOffset += duration
 
-		startStr := utils.FormatSRTTimestamp(start)
-		endStr := utils.FormatSRTTimestamp(end)
-		fmt.Fprintf(file, "%d\n%s --> %s\n%s\n\n", i+1, startStr, endStr, texts[i])
+		// SSML markup and pause markers are spoken, not shown; a pause-only segment has no cue
+		text := StripScriptMarkers(StripSSML(texts[i]))
+		if text == "" {
+			continue
+		}
+		cue := utils.SubtitleCue{Start: start, End: end, Text: text}
+		if words {
+			var aligned []utils.SubtitleWord
+			aligned, cue.Unheard = s.alignedWords(audioPath, text, duration)
+			for _, w := range aligned {
+				cue.Words = append(cue.Words, utils.SubtitleWord{Start: start + w.Start, End: start + w.End, Text: w.Text})
+			}
+		}
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
+
+// scriptLength counts the characters of all segments; ETA history is scaled by it
+func scriptLength(segments []models.VideoSegment) int {
+	total := 0
+	for _, seg := range segments {
+		total += utf8.RuneCountInString(seg.Text)
 	}
+	return total
+}
 
-	return srtPath, nil
+// Default intro/outro clips, used for YouTube unless the request picks others
+const (
+	staticVideoDir    = "static"
+	defaultIntroVideo = "intro_video.mp4"
+	defaultOutroVideo = "outro_video.mp4"
+)
+
+// GenerateRequest.VideoSource values; empty uses stock footage (or AI video with a T2V model)
+const (
+	VideoSourceWaveform = "waveform" // audio visualization over a background image, no footage APIs
+	VideoSourceAI       = "ai"       // one text-to-video clip per segment (see VideoProvider*), stock footage where it fails
+	VideoSourceImages   = "images"   // one AI still per segment with Ken Burns motion (see ImageProvider*)
+	VideoSourceLocal    = "local"    // the user's own footage from BROLL_DIR (see BrollLibrary)
+	VideoSourceStatic   = "static"   // one still background (image or color) with title and avatar, no footage APIs
+	VideoSourceSlides   = "slides"   // one slide per Markdown heading of the script, held for its narration
+
+	WaveformStyleWaves    = "waves"
+	WaveformStyleSpectrum = "spectrum"
+
+	defaultWaveformBackground = "waveform_background.jpg"
+)
+
+// introOutroPath maps an intro/outro choice to its file: an uploaded clip's ID, else as
+// ResolveStaticVideo does
+func (s *VideoWorkflowService) introOutroPath(name, fallback string) string {
+	if s.introOutro != nil {
+		if clip, err := s.introOutro.Get(name); err == nil {
+			return s.introOutro.Path(clip)
+		}
+	}
+	return ResolveStaticVideo(name, fallback)
+}
+
+// introDuration is how long req's intro plays before the narration: subtitle and chapter times
+// are offset by it. Only YouTube videos get one.
+func (s *VideoWorkflowService) introDuration(req models.GenerateRequest) float64 {
+	if req.Platform != "youtube" {
+		return 0
+	}
+	introPath := s.introOutroPath(req.IntroVideo, defaultIntroVideo)
+	if introPath == "" {
+		return 0
+	}
+	duration, err := utils.GetVideoDuration(introPath)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// ResolveStaticVideo maps an intro/outro choice to a path under static/.
+// Empty selects fallback, "none" disables the clip; only the base name is used so requests can't escape static/.
+func ResolveStaticVideo(name, fallback string) string {
+	switch name {
+	case "":
+		name = fallback
+	case "none":
+		return ""
+	}
+	return filepath.Join(staticVideoDir, filepath.Base(name))
+}
+
+// linkAssets hard-links (or copies, across filesystems) files into destDir, keeping their order
+func linkAssets(paths []string, destDir string) ([]string, error) {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, err
+	}
+	linked := make([]string, len(paths))
+	for i, src := range paths {
+		dst := filepath.Join(destDir, fmt.Sprintf("reused_%03d%s", i, filepath.Ext(src)))
+		if err := os.Link(src, dst); err != nil {
+			if err := utils.CopyFile(src, dst); err != nil {
+				return nil, fmt.Errorf("failed to reuse %s: %w", src, err)
+			}
+		}
+		linked[i] = dst
+	}
+	return linked, nil
 }
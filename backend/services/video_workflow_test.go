@@ -3,10 +3,15 @@ package services
 import (
 	"aituber/config"
 	"aituber/models"
+	"aituber/utils"
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 // --- MOCK DEFINITIONS ---
@@ -19,13 +24,38 @@ func (m *MockJobManager) CreateJob(jobID, platform, contentName string) *models.
 func (m *MockJobManager) GetJob(jobID string) (*models.JobStatus, bool) {
 	return &models.JobStatus{JobID: jobID}, true
 }
+func (m *MockJobManager) ListJobs() []models.JobStatus                                 { return nil }
+func (m *MockJobManager) UpdateJob(jobID string, fn func(*models.JobStatus)) error     { return nil }
 func (m *MockJobManager) UpdateProgress(jobID string, step string, progress int) error { return nil }
-func (m *MockJobManager) MarkFailed(jobID string, err error) error                     { return nil }
-func (m *MockJobManager) MarkCompleted(jobID, videoPath, savedPath string) error       { return nil }
+func (m *MockJobManager) EstimateRemaining(jobID string) (time.Duration, bool)         { return 0, false }
+func (m *MockJobManager) LogEvent(jobID, message string)                               {}
+func (m *MockJobManager) History(jobID string) []models.JobEvent                       { return nil }
+func (m *MockJobManager) Subscribe(jobID string) (<-chan models.JobEvent, func()) {
+	return make(chan models.JobEvent), func() {}
+}
+func (m *MockJobManager) MarkFailed(jobID string, err error) error               { return nil }
+func (m *MockJobManager) MarkCompleted(jobID, videoPath, savedPath string) error { return nil }
+func (m *MockJobManager) MarkAwaitingApproval(jobID string, storyboard *models.Storyboard) error {
+	return nil
+}
+
+func (m *MockJobManager) MarkAwaitingReview(jobID string, subtitles []models.SubtitleCue) error {
+	return nil
+}
+
+func (m *MockJobManager) ResumeJob(jobID, from string, fn func(*models.JobStatus) bool) (*models.JobStatus, error) {
+	return nil, nil
+}
+
+func (m *MockJobManager) MarkCancelled(jobID string) (bool, error) { return true, nil }
+func (m *MockJobManager) MarkExpired(jobID string) (bool, error)   { return true, nil }
 
 type MockGeminiService struct {
 	Segments []models.VideoSegment
 	Err      error
+
+	Scenes        []models.StoryboardScene
+	StoryboardErr error
 }
 
 func (m *MockGeminiService) GenerateYouTubeScript(topic string) ([]models.VideoSegment, error) {
@@ -41,25 +71,79 @@ func (m *MockGeminiService) GenerateSeriesOutline(topic, platform string, numPar
 func (m *MockGeminiService) GenerateSeriesPartScript(topic, platform string, outline []models.SeriesPartOutline, partIdx int) ([]models.VideoSegment, error) {
 	return nil, nil
 }
+func (m *MockGeminiService) GenerateStoryboard(topic string, segments []models.VideoSegment) ([]models.StoryboardScene, error) {
+	return m.Scenes, m.StoryboardErr
+}
 
 type MockAudioService struct {
 	AudioPaths []string
 	Err        error
 }
 
-func (m *MockAudioService) GenerateAudioChunks(chunks []string, voice string, speed float64, jobID string, maxConcurrent int) ([]string, error) {
-	return m.AudioPaths, m.Err
+func (m *MockAudioService) StreamAudioChunks(chunks []string, provider, voice string, speeds []float64, format AudioFormat, jobID string, maxConcurrent int) (<-chan AudioChunk, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return streamPaths(m.AudioPaths), nil
 }
-func (m *MockAudioService) MergeAudioFiles(audioPaths []string, outputPath string) error {
+func (m *MockAudioService) MergeAudioFiles(audioPaths []string, outputPath string, format AudioFormat) error {
 	return m.Err
 }
+func (m *MockAudioService) JoinWithPauses(pieces []AudioPiece, format AudioFormat, jobID string, index int) (string, error) {
+	return fmt.Sprintf("/tmp/segment_%03d.mp3", index), m.Err
+}
+
+// streamPaths delivers paths as finished chunks, last first to exercise out-of-order arrival
+func streamPaths(paths []string) <-chan AudioChunk {
+	results := make(chan AudioChunk, len(paths))
+	for i := len(paths) - 1; i >= 0; i-- {
+		results <- AudioChunk{Index: i, Path: paths[i]}
+	}
+	close(results)
+	return results
+}
+
+// recordingAudioService returns one path per chunk and records the chunks and joined pieces
+type recordingAudioService struct {
+	chunks []string
+	speeds []float64
+	joined map[int][]AudioPiece
+}
+
+func (m *recordingAudioService) StreamAudioChunks(chunks []string, provider, voice string, speeds []float64, format AudioFormat, jobID string, maxConcurrent int) (<-chan AudioChunk, error) {
+	m.chunks = append(m.chunks, chunks...)
+	m.speeds = append(m.speeds, speeds...)
+	paths := make([]string, len(chunks))
+	for i := range chunks {
+		paths[i] = fmt.Sprintf("/tmp/chunk_%03d.mp3", i)
+	}
+	return streamPaths(paths), nil
+}
+func (m *recordingAudioService) MergeAudioFiles(audioPaths []string, outputPath string, format AudioFormat) error {
+	return nil
+}
+func (m *recordingAudioService) JoinWithPauses(pieces []AudioPiece, format AudioFormat, jobID string, index int) (string, error) {
+	if m.joined == nil {
+		m.joined = make(map[int][]AudioPiece)
+	}
+	m.joined[index] = pieces
+	return fmt.Sprintf("/tmp/segment_%03d.mp3", index), nil
+}
 
 type MockStockVideoService struct {
 	VideoPath string
 	Err       error
 }
 
-func (m *MockStockVideoService) PrepareSegmentVideo(ctx context.Context, keywords string, visualDesc string, t2vModel, t2vProvider string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
+func (m *MockStockVideoService) PrepareSegmentVideo(ctx context.Context, keywords, text, visualDesc string, t2vModel, t2vProvider string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
+	return m.VideoPath, m.Err
+}
+
+func (m *MockStockVideoService) PrepareLocalSegment(keywords, text string, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
+	return m.VideoPath, m.Err
+}
+
+func (m *MockStockVideoService) PrepareUploadedSegment(clip BrollClip, audioDuration float64, jobID string, segIndex int, orientation string) (string, error) {
 	return m.VideoPath, m.Err
 }
 
@@ -67,7 +151,19 @@ type MockComposerService struct {
 	Err error
 }
 
-func (m *MockComposerService) ComposeVideoWithAudio(videoPath, audioPath, outputPath string) error {
+func (m *MockComposerService) ComposeVideoWithAudio(videoPath, audioPath, subtitlePath, outputPath string) error {
+	return m.Err
+}
+
+func (m *MockComposerService) ComposeWithPresenter(videoPath, audioPath, subtitlePath, outputPath string, presenter utils.Presenter) error {
+	return m.Err
+}
+
+func (m *MockComposerService) BurnSubtitles(videoPath, outputPath string, assPaths ...string) error {
+	return m.Err
+}
+
+func (m *MockComposerService) EncodeOutput(videoPath, outputPath string, format utils.OutputFormat) error {
 	return m.Err
 }
 
@@ -125,7 +221,7 @@ func TestVideoWorkflowService_StartGeneration(t *testing.T) {
 
 	t.Run("GenerateAudio", func(t *testing.T) {
 		segments := []models.VideoSegment{{Text: "Hello"}}
-		paths, texts, err := workflow.generateAudio("job1", req, segments)
+		paths, texts, err := workflow.generateAudio("job1", req, segments, nil)
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -134,6 +230,95 @@ func TestVideoWorkflowService_StartGeneration(t *testing.T) {
 		}
 	})
 
+	t.Run("GenerateAudio with pause markers", func(t *testing.T) {
+		audio := &recordingAudioService{}
+		workflow := NewVideoWorkflowService(cfg, jm, tp, audio, nil, stock, composer, gemini)
+		segments := []models.VideoSegment{
+			{Text: "No pauses here."},
+			{Text: "Wait for it [pause 1.5s] now [pause 500ms][pause 0.5]"},
+		}
+		paths, texts, err := workflow.generateAudio("job1", req, segments, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(audio.chunks) != 3 || audio.chunks[1] != "Wait for it" || audio.chunks[2] != "now" {
+			t.Errorf("Unexpected TTS chunks %q", audio.chunks)
+		}
+		if len(paths) != 2 || paths[0] != "/tmp/chunk_000.mp3" || paths[1] != "/tmp/segment_001.mp3" {
+			t.Errorf("Unexpected segment audio %v", paths)
+		}
+		want := []AudioPiece{{Path: "/tmp/chunk_001.mp3"}, {Pause: 1.5}, {Path: "/tmp/chunk_002.mp3"}, {Pause: 1.0}}
+		if fmt.Sprint(audio.joined[1]) != fmt.Sprint(want) {
+			t.Errorf("Joined pieces = %v, want %v", audio.joined[1], want)
+		}
+		if len(texts) != 2 || texts[1] != segments[1].Text {
+			t.Errorf("Subtitle texts should keep the segment text, got %q", texts)
+		}
+	})
+
+	t.Run("GenerateAudio with speed markers", func(t *testing.T) {
+		audio := &recordingAudioService{}
+		workflow := NewVideoWorkflowService(cfg, jm, tp, audio, nil, stock, composer, gemini)
+		segments := []models.VideoSegment{
+			{Text: "[speed:0.9] Welcome to the show."},
+			{Text: "Still slow. [speed:1.3] Quick recap."},
+			{Text: "Recap continues. [speed:normal] Bye."},
+		}
+		req := models.GenerateRequest{SpeakingSpeed: 1.1}
+		paths, texts, err := workflow.generateAudio("job1", req, segments, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if fmt.Sprint(audio.speeds) != "[0.9 0.9 1.3 1.3 1.1]" {
+			t.Errorf("Unexpected chunk speeds %v for %q", audio.speeds, audio.chunks)
+		}
+		if len(paths) != 3 || len(texts) != 3 {
+			t.Errorf("Expected one audio file per segment, got %d/%d", len(paths), len(texts))
+		}
+	})
+
+	t.Run("GenerateAudio streams finished segments", func(t *testing.T) {
+		audio := &recordingAudioService{}
+		workflow := NewVideoWorkflowService(cfg, jm, tp, audio, nil, stock, composer, gemini)
+		segments := []models.VideoSegment{
+			{Text: "First."},
+			{Text: "  "},
+			{Text: "[pause 1s]"},
+			{Text: "Third. [pause 0.5s] Still third."},
+		}
+		ready := make(chan segmentAudio, len(segments))
+		paths, _, err := workflow.generateAudio("job1", req, segments, ready)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got := make(map[int]string)
+		for audio := range ready {
+			got[audio.Index] = audio.Path
+		}
+		// Segment indexes refer to the script, skipping the empty segment
+		if len(got) != 3 || got[0] != paths[0] || got[2] != paths[1] || got[3] != paths[2] {
+			t.Errorf("Unexpected ready segments %v for paths %v", got, paths)
+		}
+		if pieces := audio.joined[2]; len(pieces) != 3 || pieces[0].Path != "/tmp/chunk_001.mp3" || pieces[2].Path != "/tmp/chunk_002.mp3" {
+			t.Errorf("Unexpected pieces of the third segment: %+v", pieces)
+		}
+	})
+
+	t.Run("AI video falls back to stock", func(t *testing.T) {
+		// No video service is configured, so every AI clip fails
+		segments := []models.VideoSegment{{Text: "Mountains at dawn", VisualPrompt: "mountains"}}
+		ready := make(chan segmentAudio, 1)
+		ready <- segmentAudio{Index: 0, Path: "/tmp/missing_audio.mp3"}
+		close(ready)
+		paths, err := workflow.gatherStockVideos("job1", segments, ready, models.GenerateRequest{VideoSource: VideoSourceAI}, "landscape")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(paths) != 1 || paths[0] != stock.VideoPath {
+			t.Errorf("Expected the stock clip, got %v", paths)
+		}
+	})
+
 	t.Run("GenerateSRT precision and timing", func(t *testing.T) {
 		tempDir, _ := os.MkdirTemp("", "srt_precision_test")
 		defer os.RemoveAll(tempDir)
@@ -164,3 +349,211 @@ func TestVideoWorkflowService_StartGeneration(t *testing.T) {
 		}
 	})
 }
+
+func TestResolveStaticVideo(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Default", "", filepath.Join("static", "intro_video.mp4")},
+		{"Disabled", "none", ""},
+		{"Custom", "tet_intro.mp4", filepath.Join("static", "tet_intro.mp4")},
+		{"Path traversal stripped", "../../etc/passwd", filepath.Join("static", "passwd")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveStaticVideo(tt.input, defaultIntroVideo); got != tt.expected {
+				t.Errorf("ResolveStaticVideo(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOutputOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.GenerateRequest
+		want string
+	}{
+		{"YouTube", models.GenerateRequest{Platform: "youtube"}, "landscape"},
+		{"TikTok", models.GenerateRequest{Platform: "tiktok"}, "portrait"},
+		{"Vertical YouTube", models.GenerateRequest{Platform: "youtube", Orientation: "portrait"}, "portrait"},
+		{"Square", models.GenerateRequest{Platform: "tiktok", AspectRatio: "1:1"}, "square"},
+		{"Aspect ratio wins", models.GenerateRequest{Orientation: "portrait", AspectRatio: "16:9"}, "landscape"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputOrientation(tt.req); got != tt.want {
+				t.Errorf("outputOrientation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrameResolutionAndRate(t *testing.T) {
+	s := &VideoWorkflowService{cfg: &config.Config{VideoResolution: "1920x1080", VideoFPS: 30}}
+	tests := []struct {
+		name        string
+		req         models.GenerateRequest
+		orientation string
+		resolution  string
+		fps         int
+	}{
+		{"Server defaults", models.GenerateRequest{}, "landscape", "1920x1080", 30},
+		{"Portrait default", models.GenerateRequest{}, "portrait", "1080x1920", 30},
+		{"4K at 60fps", models.GenerateRequest{Resolution: "4k", FPS: 60}, "landscape", "3840x2160", 60},
+		{"720p portrait", models.GenerateRequest{Resolution: "720p", FPS: 24}, "portrait", "720x1280", 24},
+		{"720p square", models.GenerateRequest{Resolution: "720p"}, "square", "720x720", 30},
+		{"Draft", models.GenerateRequest{Quality: "draft"}, "landscape", "1280x720", 30},
+		{"Draft at 4K", models.GenerateRequest{Quality: "draft", Resolution: "4k"}, "landscape", "3840x2160", 30},
+		{"High", models.GenerateRequest{Quality: "high"}, "landscape", "1920x1080", 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.frameResolution(tt.req, tt.orientation); got != tt.resolution {
+				t.Errorf("frameResolution() = %q, want %q", got, tt.resolution)
+			}
+			if got := s.frameRate(tt.req); got != tt.fps {
+				t.Errorf("frameRate() = %d, want %d", got, tt.fps)
+			}
+		})
+	}
+}
+
+func TestClipStyle(t *testing.T) {
+	style, err := clipStyle(models.GenerateRequest{Seed: 42, NegativePrompt: "  text, watermark "})
+	if err != nil || style != (ClipStyle{Seed: 42, NegativePrompt: "text, watermark"}) {
+		t.Errorf("clipStyle() = %+v, %v", style, err)
+	}
+	if _, err := clipStyle(models.GenerateRequest{StyleImage: "missing_style.png"}); err == nil {
+		t.Error("Expected an error for a missing style_image")
+	}
+}
+
+func TestAssignBrollAssets(t *testing.T) {
+	dir := t.TempDir()
+	ids := []string{"0b5a3c36-55d6-4b8e-9b52-8d1f0c1e3a01", "7c9e6679-7425-40de-944b-e07fc1f90ae7"}
+	for _, id := range ids {
+		writeBrollAsset(t, dir, models.BrollAsset{ID: id, Tenant: "acme", FileName: "clip.mp4", Duration: 5})
+	}
+	s := &VideoWorkflowService{jobManager: NewJobManager(), brollAssets: NewBrollAssetStore(dir)}
+
+	assigned := s.assignBrollAssets("job1", append(ids, "16fd2706-8baf-433b-82eb-8c7fada847da"), 6)
+	if len(assigned) != 2 || assigned[0].Path != filepath.Join(dir, ids[0]+".mp4") || assigned[3].Path != filepath.Join(dir, ids[1]+".mp4") {
+		t.Errorf("Expected clips spread over segments 0 and 3, got %+v", assigned)
+	}
+	if assigned := s.assignBrollAssets("job1", ids, 1); len(assigned) != 1 {
+		t.Errorf("Expected one clip per segment at most, got %+v", assigned)
+	}
+}
+
+func TestLinkAssets(t *testing.T) {
+	srcDir := t.TempDir()
+	var srcs []string
+	for i, data := range []string{"chunk-a", "chunk-b"} {
+		p := filepath.Join(srcDir, fmt.Sprintf("chunk_%d.mp3", i))
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, p)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "audio")
+	linked, err := linkAssets(srcs, destDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(linked) != 2 {
+		t.Fatalf("Expected 2 linked files, got %d", len(linked))
+	}
+	for i, p := range linked {
+		if filepath.Dir(p) != destDir || filepath.Ext(p) != ".mp3" {
+			t.Errorf("Unexpected linked path %s", p)
+		}
+		data, _ := os.ReadFile(p)
+		want, _ := os.ReadFile(srcs[i])
+		if string(data) != string(want) {
+			t.Errorf("File %d content mismatch", i)
+		}
+	}
+
+	// Removing the source must not affect the reused copy
+	os.RemoveAll(srcDir)
+	if !utils.FileExists(linked[0]) {
+		t.Error("Linked asset disappeared with its source")
+	}
+
+	if _, err := linkAssets([]string{filepath.Join(srcDir, "missing.mp3")}, destDir); err == nil {
+		t.Error("Expected error for missing source file")
+	}
+}
+
+func TestWriteSubtitleFiles_SoftTrack(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "output"), 0755)
+	s := &VideoWorkflowService{cfg: &config.Config{}, jobManager: &MockJobManager{}}
+	req := models.GenerateRequest{Platform: "tiktok", SoftSubtitles: true}
+	s.writeSubtitleFiles("job1", tempDir, req, []utils.SubtitleCue{{Start: 0.5, End: 2, Text: "Xin chào"}})
+
+	path := softSubtitlePath(tempDir, req, "subtitles_soft.srt")
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "1\n00:00:00,500 --> 00:00:02,000\nXin chào\n\n" {
+		t.Errorf("expected the soft subtitle track in narration time, got %q (%v)", data, err)
+	}
+	if got := softSubtitlePath(tempDir, models.GenerateRequest{}, "subtitles_soft.srt"); got != "" {
+		t.Errorf("expected no soft track unless requested, got %q", got)
+	}
+	if got := softSubtitlePath(tempDir, req, "missing.srt"); got != "" {
+		t.Errorf("expected no soft track when the file was not written, got %q", got)
+	}
+}
+
+func TestWriteSubtitleFiles_Transcript(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "output"), 0755)
+	s := &VideoWorkflowService{cfg: &config.Config{}, jobManager: &MockJobManager{}}
+	req := models.GenerateRequest{Platform: "tiktok", Voice: "banmai"}
+	s.writeSubtitleFiles("job1", tempDir, req, []utils.SubtitleCue{
+		{Start: 0, End: 1.5, Text: "Xin chào"},
+		{Start: 1.5, End: 3, Text: "các bạn", Words: []utils.SubtitleWord{{Start: 1.5, End: 2, Text: "các"}, {Start: 2, End: 3, Text: "bạn"}}, Unheard: 0.5},
+	})
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "output", TranscriptFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var transcript models.Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		t.Fatalf("invalid transcript %s: %v", data, err)
+	}
+	want := models.Transcript{Duration: 3, Segments: []models.TranscriptSegment{
+		{Start: 0, End: 1.5, Text: "Xin chào", Speaker: "banmai", Confidence: 1},
+		{Start: 1.5, End: 3, Text: "các bạn", Speaker: "banmai", Confidence: 0.5, Words: []models.SubtitleWord{{Start: 1.5, End: 2, Text: "các"}, {Start: 2, End: 3, Text: "bạn"}}},
+	}}
+	if !reflect.DeepEqual(transcript, want) {
+		t.Errorf("transcript:\n got %+v\nwant %+v", transcript, want)
+	}
+}
+
+func TestConformVideo_Unmeasurable(t *testing.T) {
+	s := &VideoWorkflowService{cfg: &config.Config{}, jobManager: &MockJobManager{}}
+	tempDir := t.TempDir()
+	videoPath := filepath.Join(tempDir, "segments_concat.mp4")
+
+	// Streams that cannot be probed leave the video as it is rather than failing the job
+	if got := s.conformVideo("job", tempDir, videoPath, filepath.Join(tempDir, "missing.mp3"), models.GenerateRequest{}); got != videoPath {
+		t.Errorf("conformVideo() = %q, want %q", got, videoPath)
+	}
+}
+
+func TestNormalizeFinalMix_Fails(t *testing.T) {
+	s := &VideoWorkflowService{cfg: &config.Config{AudioLoudnessTarget: -14, AudioTruePeak: -1.5, AudioLoudnessRange: 11}, jobManager: &MockJobManager{}}
+	tempDir := t.TempDir()
+	videoPath := filepath.Join(tempDir, "missing.mp4")
+
+	// The video keeps its mix rather than failing the job
+	if got := s.normalizeFinalMix("job", tempDir, videoPath, models.GenerateRequest{}); got != videoPath {
+		t.Errorf("normalizeFinalMix() = %q, want %q", got, videoPath)
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Language models that can write visual prompts, selected with VISUAL_PROMPT_LLM
+const (
+	PromptLLMOpenAI = "openai"
+	PromptLLMClaude = "claude"
+	PromptLLMGemini = "gemini"
+	PromptLLMOllama = "ollama"
+)
+
+const (
+	anthropicAPIBase = "https://api.anthropic.com"
+	geminiAPIBase    = "https://generativelanguage.googleapis.com"
+)
+
+// defaultPromptLLMModels are used when VISUAL_PROMPT_MODEL is empty
+var defaultPromptLLMModels = map[string]string{
+	PromptLLMOpenAI: "gpt-4o-mini",
+	PromptLLMClaude: "claude-3-5-haiku-latest",
+	PromptLLMGemini: "gemini-2.0-flash",
+	PromptLLMOllama: "llama3.1",
+}
+
+// maxVisualPromptWords caps a model's reply; text-to-video prompts past this get truncated
+// by the providers anyway
+const maxVisualPromptWords = 80
+
+// maxCachedVisualPrompts bounds the in-memory prompt cache
+const maxCachedVisualPrompts = 2048
+
+// PromptLLM is a chat model that writes a segment's visual prompt from its narration
+type PromptLLM interface {
+	// Complete returns the model's reply to prompt
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// llmCall makes one completion with apiKey and returns the reply, or the HTTP status of the
+// failed call (0 for transport errors)
+type llmCall func(ctx context.Context, prompt, apiKey string) ([]byte, int, error)
+
+// pooledPromptLLM adapts a vendor's llmCall into a PromptLLM, rotating through its key pool
+type pooledPromptLLM struct {
+	name       string
+	pool       *utils.APIKeyPool
+	call       llmCall
+	maxRetries int
+}
+
+// Complete implements PromptLLM
+func (p *pooledPromptLLM) Complete(ctx context.Context, prompt string) (string, error) {
+	reply, err := callWithKeyRotation(ctx, p.pool, p.name, p.maxRetries, func(apiKey string) ([]byte, int, error) {
+		return p.call(ctx, prompt, apiKey)
+	})
+	return string(reply), err
+}
+
+// chatLLM holds the endpoint and model shared by the vendor calls
+type chatLLM struct {
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPromptLLM creates the named model's client. model may be empty for the vendor default and
+// baseURL empty for the vendor's public API (OPENAI_BASE_URL, OLLAMA_URL); Ollama needs no pool.
+func NewPromptLLM(name string, pool *utils.APIKeyPool, model, baseURL string) (PromptLLM, error) {
+	if model == "" {
+		model = defaultPromptLLMModels[name]
+	}
+	c := &chatLLM{model: model, baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{Timeout: time.Minute}}
+	var call llmCall
+	switch name {
+	case PromptLLMOpenAI:
+		if c.baseURL == "" {
+			c.baseURL = openAIAPIBase
+		}
+		call = c.openAI
+	case PromptLLMClaude:
+		if c.baseURL == "" {
+			c.baseURL = anthropicAPIBase
+		}
+		call = c.claude
+	case PromptLLMGemini:
+		if c.baseURL == "" {
+			c.baseURL = geminiAPIBase
+		}
+		call = c.gemini
+	case PromptLLMOllama:
+		if c.baseURL == "" {
+			return nil, fmt.Errorf("ollama requires a server URL")
+		}
+		pool = nil
+		call = c.ollama
+	default:
+		return nil, fmt.Errorf("unknown prompt LLM %q", name)
+	}
+	return &pooledPromptLLM{name: name, pool: pool, call: call, maxRetries: 2}, nil
+}
+
+// openAI calls /v1/chat/completions
+func (c *chatLLM) openAI(ctx context.Context, prompt, apiKey string) ([]byte, int, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":       c.model,
+		"messages":    []map[string]string{{"role": "user", "content": prompt}},
+		"temperature": 0.7,
+		"max_tokens":  200,
+	})
+	var out struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + apiKey}
+	if status, err := c.post(ctx, c.baseURL+"/v1/chat/completions", headers, body, &out); err != nil {
+		return nil, status, fmt.Errorf("openai completion failed: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return nil, 0, fmt.Errorf("openai returned no completion")
+	}
+	return []byte(out.Choices[0].Message.Content), 0, nil
+}
+
+// claude calls the Anthropic messages API
+func (c *chatLLM) claude(ctx context.Context, prompt, apiKey string) ([]byte, int, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":      c.model,
+		"max_tokens": 200,
+		"messages":   []map[string]string{{"role": "user", "content": prompt}},
+	})
+	var out struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	headers := map[string]string{"x-api-key": apiKey, "anthropic-version": "2023-06-01"}
+	if status, err := c.post(ctx, c.baseURL+"/v1/messages", headers, body, &out); err != nil {
+		return nil, status, fmt.Errorf("claude completion failed: %w", err)
+	}
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			return []byte(block.Text), 0, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("claude returned no text")
+}
+
+// gemini calls generateContent; the key goes in the query string
+func (c *chatLLM) gemini(ctx context.Context, prompt, apiKey string) ([]byte, int, error) {
+	body, _ := json.Marshal(geminiRequest{
+		Contents:         []geminiContent{{Parts: []geminiPart{{Text: prompt}}}},
+		GenerationConfig: geminiGenConfig{Temperature: 0.7, MaxOutputTokens: 200},
+	})
+	var out geminiResponse
+	endpoint := fmt.Sprintf("%s/v1beta/models/%s:generateContent?key=%s", c.baseURL, url.PathEscape(c.model), url.QueryEscape(apiKey))
+	if status, err := c.post(ctx, endpoint, nil, body, &out); err != nil {
+		return nil, status, fmt.Errorf("gemini completion failed: %w", err)
+	}
+	if len(out.Candidates) == 0 || len(out.Candidates[0].Content.Parts) == 0 {
+		return nil, 0, fmt.Errorf("gemini returned no completion")
+	}
+	return []byte(out.Candidates[0].Content.Parts[0].Text), 0, nil
+}
+
+// ollama calls a local server's /api/generate without streaming
+func (c *chatLLM) ollama(ctx context.Context, prompt, _ string) ([]byte, int, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":  c.model,
+		"prompt": prompt,
+		"stream": false,
+	})
+	var out struct {
+		Response string `json:"response"`
+	}
+	if status, err := c.post(ctx, c.baseURL+"/api/generate", nil, body, &out); err != nil {
+		return nil, status, fmt.Errorf("ollama completion failed: %w", err)
+	}
+	return []byte(out.Response), 0, nil
+}
+
+// post sends a JSON body and decodes a 200 response into out, returning the HTTP status
+func (c *chatLLM) post(ctx context.Context, endpoint string, headers map[string]string, body []byte, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// SetPromptLLM makes the video service ask llm (registered under name, which keys the cache)
+// for the visual prompt of segments without a visual_description; nil restores the template
+func (vs *VideoService) SetPromptLLM(name string, llm PromptLLM) {
+	vs.promptMux.Lock()
+	defer vs.promptMux.Unlock()
+	vs.promptLLM = llm
+	vs.promptLLMName = name
+	vs.promptCache = make(map[string]string)
+}
+
+// visualPrompt returns the text-to-video prompt for narration text: the LLM's answer when one is
+// set (cached per model, style, branding and text, since re-renders ask again), otherwise or
+// when it fails tmpl as filled by createPromptFromText
+func (vs *VideoService) visualPrompt(ctx context.Context, text, style string, tmpl models.PromptTemplate, index int) string {
+	vs.promptMux.Lock()
+	llm, name := vs.promptLLM, vs.promptLLMName
+	vs.promptMux.Unlock()
+	if llm == nil || strings.TrimSpace(text) == "" {
+		return vs.createPromptFromText(text, style, tmpl)
+	}
+
+	hash := sha256.Sum256([]byte(name + "\x00" + style + "\x00" + tmpl.Branding + "\x00" + text))
+	key := hex.EncodeToString(hash[:])
+	vs.promptMux.Lock()
+	cached, ok := vs.promptCache[key]
+	vs.promptMux.Unlock()
+	if ok {
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 45*time.Second)
+	defer cancel()
+	reply, err := llm.Complete(ctx, visualPromptInstruction(text, style, tmpl.Branding))
+	prompt := cleanVisualPrompt(reply)
+	if err == nil && prompt == "" {
+		err = fmt.Errorf("empty reply")
+	}
+	if err != nil {
+		log.Printf("[Video] %s visual prompt for segment %d failed, using template: %v", name, index, err)
+		return vs.createPromptFromText(text, style, tmpl)
+	}
+
+	vs.promptMux.Lock()
+	if len(vs.promptCache) >= maxCachedVisualPrompts {
+		for k := range vs.promptCache {
+			delete(vs.promptCache, k)
+			break
+		}
+	}
+	vs.promptCache[key] = prompt
+	vs.promptMux.Unlock()
+	return prompt
+}
+
+// visualPromptInstruction asks for one concrete English shot description of the narration,
+// which may be in any language, in the channel's branding when it has one
+func visualPromptInstruction(text, style, branding string) string {
+	var brand string
+	if branding != "" {
+		brand = fmt.Sprintf(" Keep to the channel's visual branding: %s.", branding)
+	}
+	return fmt.Sprintf(`You write prompts for a text-to-video model. Describe one concrete %s shot, in English and under 60 words, that illustrates this narration:
+
+%q
+
+Name the subject, setting, action, lighting and camera movement.%s No on-screen text, logos or real people's names. Reply with the prompt only.`, style, text, brand)
+}
+
+// cleanVisualPrompt takes the first non-empty line of a model reply, drops labels and quotes
+// and caps it at maxVisualPromptWords
+func cleanVisualPrompt(reply string) string {
+	var line string
+	for _, l := range strings.Split(reply, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			line = l
+			break
+		}
+	}
+	if i := strings.Index(line, ":"); i >= 0 && i < 16 && strings.Contains(strings.ToLower(line[:i]), "prompt") {
+		line = line[i+1:]
+	}
+	line = strings.Trim(line, " \t\"'`*")
+	words := strings.Fields(line)
+	if len(words) > maxVisualPromptWords {
+		words = words[:maxVisualPromptWords]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"aituber/models"
+	"aituber/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPromptLLM_Vendors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			if r.Header.Get("Authorization") != "Bearer oa-key" || body["model"] != "gpt-4o-mini" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"choices": []map[string]interface{}{{"message": map[string]string{"content": "openai shot"}}}})
+		case "/v1/messages":
+			if r.Header.Get("x-api-key") != "cl-key" || r.Header.Get("anthropic-version") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"content": []map[string]string{{"type": "text", "text": "claude shot"}}})
+		case "/v1beta/models/gemini-2.0-flash:generateContent":
+			if r.URL.Query().Get("key") != "gm-key" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"candidates": []map[string]interface{}{{"content": map[string]interface{}{"parts": []map[string]string{{"text": "gemini shot"}}}}}})
+		case "/api/generate":
+			if body["stream"] != false || body["model"] != "llama3.1" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"response": "ollama shot"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for name, key := range map[string]string{PromptLLMOpenAI: "oa-key", PromptLLMClaude: "cl-key", PromptLLMGemini: "gm-key", PromptLLMOllama: ""} {
+		llm, err := NewPromptLLM(name, utils.NewAPIKeyPool([]string{key}), "", srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		reply, err := llm.Complete(context.Background(), "narration")
+		if err != nil || reply != name+" shot" {
+			t.Errorf("%s: got %q, %v", name, reply, err)
+		}
+	}
+
+	if _, err := NewPromptLLM("bard", nil, "", ""); err == nil {
+		t.Error("expected an unknown LLM to be rejected")
+	}
+	if _, err := NewPromptLLM(PromptLLMOllama, nil, "", ""); err == nil {
+		t.Error("expected ollama without a URL to be rejected")
+	}
+}
+
+type countingPromptLLM struct {
+	calls atomic.Int32
+	reply string
+	err   error
+}
+
+func (c *countingPromptLLM) Complete(ctx context.Context, prompt string) (string, error) {
+	c.calls.Add(1)
+	return c.reply, c.err
+}
+
+func TestVideoService_VisualPrompt(t *testing.T) {
+	vs := NewVideoService(nil, t.TempDir(), "5M", "1920x1080", 30, 0.5)
+
+	t.Run("template matches themes", func(t *testing.T) {
+		if got := vs.visualPrompt(context.Background(), "Hôm nay ta nói về Thiên nhiên", "cinematic", models.PromptTemplate{}, 0); !strings.Contains(got, "nature themed") {
+			t.Errorf("expected the Vietnamese keyword to pick the nature theme, got %q", got)
+		}
+		if got := vs.visualPrompt(context.Background(), "A story about a cat", "cinematic", models.PromptTemplate{}, 0); !strings.Contains(got, "abstract") {
+			t.Errorf("expected no theme to match, got %q", got)
+		}
+	})
+
+	t.Run("LLM replies are cleaned and cached", func(t *testing.T) {
+		llm := &countingPromptLLM{reply: "\nPrompt: \"Slow dolly shot of a tabby cat on a sunlit windowsill\"\nExtra notes"}
+		vs.SetPromptLLM(PromptLLMOpenAI, llm)
+		for i := 0; i < 2; i++ {
+			if got := vs.visualPrompt(context.Background(), "A story about a cat", "cinematic", models.PromptTemplate{}, i); got != "Slow dolly shot of a tabby cat on a sunlit windowsill" {
+				t.Errorf("got %q", got)
+			}
+		}
+		if n := llm.calls.Load(); n != 1 {
+			t.Errorf("expected the second segment to hit the cache, got %d calls", n)
+		}
+		vs.visualPrompt(context.Background(), "A story about a cat", "anime", models.PromptTemplate{}, 2)
+		if n := llm.calls.Load(); n != 2 {
+			t.Errorf("expected a different style to miss the cache, got %d calls", n)
+		}
+	})
+
+	t.Run("LLM failure falls back to the template", func(t *testing.T) {
+		vs.SetPromptLLM(PromptLLMClaude, &countingPromptLLM{reply: "  "})
+		if got := vs.visualPrompt(context.Background(), "technology news", "cinematic", models.PromptTemplate{}, 0); !strings.Contains(got, "technology themed") {
+			t.Errorf("expected the template prompt, got %q", got)
+		}
+	})
+}
+
+func TestCleanVisualPrompt(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	if got := len(strings.Fields(cleanVisualPrompt(long))); got != maxVisualPromptWords {
+		t.Errorf("expected %d words, got %d", maxVisualPromptWords, got)
+	}
+	if got := cleanVisualPrompt("**Aerial drone shot: a city at night**"); got != "Aerial drone shot: a city at night" {
+		t.Errorf("got %q", got)
+	}
+}
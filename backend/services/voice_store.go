@@ -0,0 +1,133 @@
+package services
+
+import (
+	"aituber/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxClonedVoices bounds the voices one tenant can keep
+const maxClonedVoices = 50
+
+var voiceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// VoiceStore keeps the voices each tenant cloned, so GenerateRequest.Voice can name them.
+// With a file path the voices survive restarts; otherwise they live in memory.
+type VoiceStore struct {
+	mu      sync.RWMutex
+	tenants map[string][]models.ClonedVoice
+	path    string
+}
+
+// NewVoiceStore loads the voices saved at path; an empty path keeps them in memory only
+func NewVoiceStore(path string) *VoiceStore {
+	vs := &VoiceStore{tenants: make(map[string][]models.ClonedVoice), path: path}
+	if path == "" {
+		return vs
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[Voices] Could not read %s: %v", path, err)
+		}
+		return vs
+	}
+	if err := json.Unmarshal(data, &vs.tenants); err != nil {
+		log.Printf("[Voices] Ignoring malformed %s: %v", path, err)
+		vs.tenants = make(map[string][]models.ClonedVoice)
+	}
+	return vs
+}
+
+// List returns the tenant's cloned voices, oldest first
+func (vs *VoiceStore) List(tenant string) []models.ClonedVoice {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return append([]models.ClonedVoice{}, vs.tenants[tenant]...)
+}
+
+// Find returns the tenant's voice called name (case-insensitive)
+func (vs *VoiceStore) Find(tenant, name string) (models.ClonedVoice, bool) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	for _, voice := range vs.tenants[tenant] {
+		if strings.EqualFold(voice.Name, name) {
+			return voice, true
+		}
+	}
+	return models.ClonedVoice{}, false
+}
+
+// Add stores voice for tenant, replacing an older voice of the same name
+func (vs *VoiceStore) Add(tenant string, voice models.ClonedVoice) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	voices := vs.tenants[tenant][:0:0]
+	for _, existing := range vs.tenants[tenant] {
+		if !strings.EqualFold(existing.Name, voice.Name) {
+			voices = append(voices, existing)
+		}
+	}
+	if len(voices) >= maxClonedVoices {
+		return fmt.Errorf("a tenant may keep at most %d cloned voices", maxClonedVoices)
+	}
+	vs.tenants[tenant] = append(voices, voice)
+	return vs.save()
+}
+
+// save writes all voices atomically; the caller holds vs.mu
+func (vs *VoiceStore) save() error {
+	if vs.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(vs.tenants, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(vs.path), 0755); err != nil {
+		return fmt.Errorf("failed to save voices: %w", err)
+	}
+	tmp := vs.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save voices: %w", err)
+	}
+	return os.Rename(tmp, vs.path)
+}
+
+// ValidateVoiceName checks that name can be used as a voice (and XTTS speaker file) name
+func ValidateVoiceName(name string) error {
+	if !voiceNamePattern.MatchString(name) {
+		return fmt.Errorf("voice name must be 1-64 letters, digits, '_' or '-'")
+	}
+	return nil
+}
+
+// voiceSampleForm builds the multipart body carrying a reference recording for voice cloning
+func voiceSampleForm(field, filename string, sample []byte, fields map[string]string) (*bytes.Buffer, string, error) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for key, value := range fields {
+		if err := w.WriteField(key, value); err != nil {
+			return nil, "", err
+		}
+	}
+	part, err := w.CreateFormFile(field, filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(sample); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body, w.FormDataContentType(), nil
+}
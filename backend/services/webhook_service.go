@@ -0,0 +1,126 @@
+package services
+
+import (
+	"aituber/models"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of the request body
+const WebhookSignatureHeader = "X-Aituber-Signature"
+
+// WebhookService delivers job completion callbacks to client-provided URLs
+type WebhookService struct {
+	secret     string
+	baseURL    string // public base URL used to build absolute download links
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// NewWebhookService creates a new webhook service.
+// secret signs every payload; baseURL (e.g. "https://api.example.com") prefixes download links.
+func NewWebhookService(secret, baseURL string, maxRetries int) *WebhookService {
+	if maxRetries <= 0 {
+		maxRetries = 1
+	}
+	return &WebhookService{
+		secret:  secret,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		maxRetries: maxRetries,
+		retryDelay: 2 * time.Second,
+	}
+}
+
+// NotifyJob sends the terminal status of a job to its callback URL, if any.
+// Intended to be registered with JobManager.OnFinished.
+func (ws *WebhookService) NotifyJob(job models.JobStatus) {
+	if job.CallbackURL == "" {
+		return
+	}
+
+	payload := models.WebhookPayload{
+		JobID:     job.JobID,
+		Status:    job.Status,
+		Timestamp: time.Now().UTC(),
+	}
+	if job.Status == "completed" && job.VideoPath != "" {
+		payload.DownloadURL = fmt.Sprintf("%s/api/download/%s?token=%s", ws.baseURL, job.JobID, job.DownloadToken)
+	}
+	if job.Error != nil {
+		payload.Error = job.Error.Error()
+	}
+
+	if err := ws.Send(job.CallbackURL, payload); err != nil {
+		log.Printf("[Webhook] Job %s: callback to %s failed: %v", job.JobID, job.CallbackURL, err)
+		return
+	}
+	log.Printf("[Webhook] Job %s: delivered %q callback to %s", job.JobID, job.Status, job.CallbackURL)
+}
+
+// Send POSTs the payload to url, retrying with exponential backoff on network errors and 5xx/429 responses
+func (ws *WebhookService) Send(url string, payload models.WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	signature := SignWebhookPayload(ws.secret, body)
+
+	var lastErr error
+	attempts := 0
+	for attempt := 0; attempt < ws.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := ws.retryDelay * time.Duration(1<<uint(attempt-1))
+			log.Printf("[Webhook] Retrying %s in %v (attempt %d/%d)", url, delay, attempt+1, ws.maxRetries)
+			time.Sleep(delay)
+		}
+
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookSignatureHeader, signature)
+
+		attempts++
+		resp, err := ws.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request failed: %w", err)
+			continue
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+
+		lastErr = fmt.Errorf("callback returned status %d: %s", resp.StatusCode, string(respBody))
+		// Client errors (other than rate limiting) won't succeed on retry
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			break
+		}
+	}
+
+	return fmt.Errorf("webhook delivery failed after %d attempt(s): %w", attempts, lastErr)
+}
+
+// SignWebhookPayload returns the "sha256=<hex>" HMAC signature of body.
+// Receivers recompute it with the shared WEBHOOK_SECRET to verify authenticity.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
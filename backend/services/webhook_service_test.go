@@ -0,0 +1,74 @@
+package services
+
+import (
+	"aituber/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	sig := SignWebhookPayload("secret", []byte(`{"job_id":"1"}`))
+	if sig != SignWebhookPayload("secret", []byte(`{"job_id":"1"}`)) {
+		t.Error("signature is not deterministic")
+	}
+	if sig == SignWebhookPayload("other", []byte(`{"job_id":"1"}`)) {
+		t.Error("signature does not depend on secret")
+	}
+	if len(sig) != len("sha256=")+64 {
+		t.Errorf("unexpected signature format: %s", sig)
+	}
+}
+
+func TestWebhookService_Send(t *testing.T) {
+	t.Run("Retries on 5xx then succeeds with valid signature", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get(WebhookSignatureHeader) != SignWebhookPayload("s3cr3t", body) {
+				t.Errorf("signature mismatch")
+			}
+			if atomic.AddInt32(&calls, 1) < 2 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		ws := NewWebhookService("s3cr3t", "http://api", 3)
+		ws.retryDelay = time.Millisecond
+
+		err := ws.Send(server.URL, models.WebhookPayload{JobID: "job1", Status: "completed"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("Expected 2 calls, got %d", calls)
+		}
+	})
+
+	t.Run("Does not retry on 4xx", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		ws := NewWebhookService("s3cr3t", "http://api", 3)
+		ws.retryDelay = time.Millisecond
+
+		err := ws.Send(server.URL, models.WebhookPayload{JobID: "job1", Status: "failed"})
+		if err == nil || !strings.Contains(err.Error(), "after 1 attempt(s)") {
+			t.Errorf("Expected a 404 callback to fail after 1 attempt, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 call, got %d", calls)
+		}
+	})
+}
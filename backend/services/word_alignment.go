@@ -0,0 +1,218 @@
+package services
+
+import (
+	"aituber/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// WordAligner finds when words are spoken in an audio file, for karaoke captions
+type WordAligner interface {
+	// AlignWords returns the words heard in audioPath, timed in seconds from its start
+	AlignWords(ctx context.Context, audioPath string) ([]utils.SubtitleWord, error)
+}
+
+// SetWordAligner times karaoke captions by the speech itself; without one, words are timed in
+// proportion to their length
+func (s *VideoWorkflowService) SetWordAligner(a WordAligner) {
+	s.aligner = a
+}
+
+// AlignWords implements WordAligner with one-word segments (-ml 1), which whisper.cpp times
+// from its token timestamps
+func (w *whisperTranscriber) AlignWords(ctx context.Context, audioPath string) ([]utils.SubtitleWord, error) {
+	wavPath := audioPath + ".align.wav"
+	if err := utils.ConvertAudioForASR(audioPath, wavPath); err != nil {
+		return nil, fmt.Errorf("failed to prepare audio for Whisper: %w", err)
+	}
+	defer os.Remove(wavPath)
+
+	if w.url != "" {
+		data, err := w.postWhisper(ctx, wavPath, map[string]string{
+			"response_format": "verbose_json",
+			"max_len":         "1",
+			"split_on_word":   "true",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return parseWhisperServerWords(data)
+	}
+
+	if w.model == "" {
+		return nil, fmt.Errorf("no Whisper model configured (set WHISPER_MODEL or WHISPER_URL)")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, w.binary, "-m", w.model, "-f", wavPath, "-l", w.language, "-ml", "1", "-sow", "-oj", "-of", wavPath, "-np")
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", w.binary, err, strings.TrimSpace(stderr.String()))
+	}
+	defer os.Remove(wavPath + ".json")
+	data, err := os.ReadFile(wavPath + ".json")
+	if err != nil {
+		return nil, err
+	}
+	return parseWhisperCLIWords(data)
+}
+
+// parseWhisperServerWords reads a verbose_json reply, taking per-word timings when the server
+// includes them and otherwise the (one-word) segments
+func parseWhisperServerWords(data []byte) ([]utils.SubtitleWord, error) {
+	var out struct {
+		Segments []struct {
+			Start float64 `json:"start"`
+			End   float64 `json:"end"`
+			Text  string  `json:"text"`
+			Words []struct {
+				Word  string  `json:"word"`
+				Start float64 `json:"start"`
+				End   float64 `json:"end"`
+			} `json:"words"`
+		} `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid Whisper reply: %w", err)
+	}
+	var words []utils.SubtitleWord
+	for _, seg := range out.Segments {
+		if len(seg.Words) == 0 {
+			words = append(words, utils.EstimateWords(seg.Start, seg.End, seg.Text)...)
+			continue
+		}
+		for _, w := range seg.Words {
+			if text := strings.TrimSpace(w.Word); text != "" {
+				words = append(words, utils.SubtitleWord{Start: w.Start, End: w.End, Text: text})
+			}
+		}
+	}
+	return words, nil
+}
+
+// parseWhisperCLIWords reads the -oj file of whisper-cli, whose offsets are milliseconds
+func parseWhisperCLIWords(data []byte) ([]utils.SubtitleWord, error) {
+	var out struct {
+		Transcription []struct {
+			Offsets struct {
+				From int `json:"from"`
+				To   int `json:"to"`
+			} `json:"offsets"`
+			Text string `json:"text"`
+		} `json:"transcription"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid Whisper output: %w", err)
+	}
+	var words []utils.SubtitleWord
+	for _, seg := range out.Transcription {
+		words = append(words, utils.EstimateWords(float64(seg.Offsets.From)/1000, float64(seg.Offsets.To)/1000, seg.Text)...)
+	}
+	return words, nil
+}
+
+// alignedWords times the words of text, spoken over duration seconds of audioPath. Words the
+// aligner heard are matched to the script in order; the rest, and every word when alignment is
+// unavailable, share the time around them by length. unheard is the share of words the aligner
+// did not match (0 without alignment).
+func (s *VideoWorkflowService) alignedWords(audioPath, text string, duration float64) (words []utils.SubtitleWord, unheard float64) {
+	if s.aligner == nil {
+		return utils.EstimateWords(0, duration, text), 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	heard, err := s.aligner.AlignWords(ctx, audioPath)
+	if err != nil {
+		log.Printf("Word alignment of %s failed, estimating karaoke timing: %v", audioPath, err)
+		return utils.EstimateWords(0, duration, text), 0
+	}
+	script := strings.Fields(text)
+	words, matched := matchWordTimings(script, heard, duration)
+	if len(script) > 0 {
+		unheard = 1 - float64(matched)/float64(len(script))
+	}
+	return words, unheard
+}
+
+// matchWordTimings gives script words the timings of the heard words they match (longest common
+// subsequence, ignoring case and punctuation) and spreads unmatched runs over the gaps between.
+// matched counts the script words that were heard.
+func matchWordTimings(script []string, heard []utils.SubtitleWord, duration float64) (words []utils.SubtitleWord, matched int) {
+	norm := func(w string) string { return strings.Join(qaWords(w), "") }
+	a := make([]string, len(script))
+	for i, w := range script {
+		a[i] = norm(w)
+	}
+	b := make([]string, len(heard))
+	for j, w := range heard {
+		b[j] = norm(w.Text)
+	}
+
+	// lcs[i][j] is the match length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] != "" && a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] != "" && a[i] == b[j]:
+			match[i] = j
+			matched++
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	words = make([]utils.SubtitleWord, len(script))
+	prevEnd := 0.0
+	for i := 0; i < len(script); {
+		if match[i] >= 0 {
+			h := heard[match[i]]
+			start := math.Min(math.Max(h.Start, prevEnd), duration)
+			end := math.Min(math.Max(h.End, start), duration)
+			words[i] = utils.SubtitleWord{Start: start, End: end, Text: script[i]}
+			prevEnd = end
+			i++
+			continue
+		}
+		// An unmatched run lasts until the next matched word starts
+		j := i
+		for j < len(script) && match[j] < 0 {
+			j++
+		}
+		gapEnd := duration
+		if j < len(script) {
+			gapEnd = math.Min(math.Max(heard[match[j]].Start, prevEnd), duration)
+		}
+		for k, w := range utils.EstimateWords(prevEnd, gapEnd, strings.Join(script[i:j], " ")) {
+			words[i+k] = w
+		}
+		prevEnd = gapEnd
+		i = j
+	}
+	return words, matched
+}
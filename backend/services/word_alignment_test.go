@@ -0,0 +1,55 @@
+package services
+
+import (
+	"aituber/utils"
+	"math"
+	"testing"
+)
+
+func TestMatchWordTimings(t *testing.T) {
+	// Whisper misheard "Mèo" and added a filler word
+	heard := []utils.SubtitleWord{
+		{Start: 0.1, End: 0.4, Text: " Meo"},
+		{Start: 0.5, End: 0.8, Text: " ngủ,"},
+		{Start: 0.9, End: 1.0, Text: " ờ"},
+		{Start: 2.0, End: 2.6, Text: " trưa."},
+	}
+	words, matched := matchWordTimings([]string{"Mèo", "ngủ", "rất", "say", "trưa!"}, heard, 3)
+	if matched != 2 {
+		t.Errorf("expected 2 words heard, got %d", matched)
+	}
+	want := []utils.SubtitleWord{
+		{Start: 0, End: 0.5, Text: "Mèo"},
+		{Start: 0.5, End: 0.8, Text: "ngủ"},
+		{Start: 0.8, End: 1.4, Text: "rất"},
+		{Start: 1.4, End: 2.0, Text: "say"},
+		{Start: 2.0, End: 2.6, Text: "trưa!"},
+	}
+	for i, w := range want {
+		got := words[i]
+		if got.Text != w.Text || math.Abs(got.Start-w.Start) > 1e-9 || math.Abs(got.End-w.End) > 1e-9 {
+			t.Errorf("word %d: got %+v, want %+v", i, got, w)
+		}
+	}
+
+	if words, _ := matchWordTimings([]string{"a", "b"}, nil, 2); words[1].Start != 1 || words[1].End != 2 {
+		t.Errorf("expected words timed by length without speech, got %+v", words)
+	}
+}
+
+func TestParseWhisperWords(t *testing.T) {
+	server := `{"segments": [
+		{"start": 0, "end": 1, "text": " Xin chào", "words": [{"word": " Xin", "start": 0, "end": 0.4}, {"word": " chào", "start": 0.4, "end": 1}]},
+		{"start": 1.2, "end": 1.6, "text": " bạn"}
+	]}`
+	words, err := parseWhisperServerWords([]byte(server))
+	if err != nil || len(words) != 3 || words[1].Text != "chào" || words[2].Start != 1.2 {
+		t.Errorf("server: got %+v, %v", words, err)
+	}
+
+	cli := `{"transcription": [{"offsets": {"from": 0, "to": 380}, "text": " Hello"}, {"offsets": {"from": 380, "to": 900}, "text": " world"}]}`
+	words, err = parseWhisperCLIWords([]byte(cli))
+	if err != nil || len(words) != 2 || words[1].Start != 0.38 || words[1].Text != "world" {
+		t.Errorf("cli: got %+v, %v", words, err)
+	}
+}
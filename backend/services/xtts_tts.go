@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// xttsTTS is a self-hosted Coqui XTTS server (xtts-api-server). Speaker references are the
+// .wav files in the server's speakers folder, so cloned voices are selected by name.
+type xttsTTS struct {
+	url            string
+	language       string
+	defaultSpeaker string // used when the request names an FPT.AI voice
+	httpClient     *http.Client
+}
+
+// NewXTTSTTS creates the XTTS provider
+func NewXTTSTTS(url, language, defaultSpeaker string) TTSProvider {
+	return &xttsTTS{
+		url:            strings.TrimRight(url, "/"),
+		language:       language,
+		defaultSpeaker: defaultSpeaker,
+		httpClient:     newTTSHTTPClient(),
+	}
+}
+
+// Synthesize renders text with the speaker reference named by voice and returns WAV.
+// XTTS has no per-request speed; it is set server-side via /set_tts_settings.
+func (x *xttsTTS) Synthesize(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	body, _ := json.Marshal(map[string]string{
+		"text":        text,
+		"speaker_wav": x.mapToXTTSSpeaker(voice),
+		"language":    x.language,
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", x.url+"/tts_to_audio/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := x.httpClient.Do(req)
+	if err != nil {
+		return nil, httpTTSError(0, fmt.Errorf("XTTS request failed: %w", err))
+	}
+	defer resp.Body.Close()
+	noteTTSStatus(ctx, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpTTSError(resp.StatusCode, fmt.Errorf("XTTS server returned %d: %s", resp.StatusCode, readErrorBody(resp)))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// CloneVoice uploads sample into the server's speakers folder as <name>.wav; the name is the voice ID
+func (x *xttsTTS) CloneVoice(ctx context.Context, name string, sample []byte) (string, error) {
+	if audioFileExt(sample) != ".wav" {
+		return "", fmt.Errorf("XTTS speaker references must be WAV files")
+	}
+	body, contentType, err := voiceSampleForm("wavFile", name+".wav", sample, nil)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", x.url+"/upload_sample", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := x.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("XTTS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("XTTS server returned %d: %s", resp.StatusCode, readErrorBody(resp))
+	}
+	return name, nil
+}
+
+// mapToXTTSSpeaker passes custom speaker references (cloned voices) through; FPT voices use the default
+func (x *xttsTTS) mapToXTTSSpeaker(voice string) string {
+	if voice == "" || isFPTVoice(voice) {
+		return x.defaultSpeaker
+	}
+	return voice
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestXTTSTTS_Synthesize(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/tts_to_audio/" {
+			http.NotFound(w, r)
+			return
+		}
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		if got["speaker_wav"] == "missing" {
+			http.Error(w, `{"detail":"Speaker not found"}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("RIFF-xtts"))
+	}))
+	defer srv.Close()
+
+	provider := NewXTTSTTS(srv.URL+"/", "en", "female").(*xttsTTS)
+	provider.httpClient = srv.Client()
+	ctx := context.Background()
+
+	data, err := provider.Synthesize(ctx, "Hello there", "my_voice", 1.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "RIFF-xtts" {
+		t.Errorf("Unexpected audio content %q", data)
+	}
+	if got["speaker_wav"] != "my_voice" || got["language"] != "en" || got["text"] != "Hello there" {
+		t.Errorf("Unexpected payload %v", got)
+	}
+
+	// FPT voice names fall back to the default reference
+	if _, err := provider.Synthesize(ctx, "x", "banmai", 1.0); err != nil || got["speaker_wav"] != "female" {
+		t.Errorf("Expected default speaker, got %v (err %v)", got, err)
+	}
+
+	_, err = provider.Synthesize(ctx, "x", "missing", 1.0)
+	var ttsErr *TTSError
+	if !errors.As(err, &ttsErr) || ttsErr.Retryable {
+		t.Errorf("Unknown speaker should be a non-retryable error, got %v", err)
+	}
+}
+
+func TestXTTSTTS_CloneVoice(t *testing.T) {
+	var uploaded string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/upload_sample" {
+			http.NotFound(w, r)
+			return
+		}
+		_, header, err := r.FormFile("wavFile")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		uploaded = header.Filename
+	}))
+	defer srv.Close()
+
+	provider := NewXTTSTTS(srv.URL, "en", "female").(*xttsTTS)
+	provider.httpClient = srv.Client()
+
+	voiceID, err := provider.CloneVoice(context.Background(), "host", []byte("RIFF-sample"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if voiceID != "host" || uploaded != "host.wav" {
+		t.Errorf("Expected speaker host uploaded as host.wav, got %q / %q", voiceID, uploaded)
+	}
+	if _, err := provider.CloneVoice(context.Background(), "host", []byte("ID3-mp3")); err == nil {
+		t.Error("Expected non-WAV samples to be rejected")
+	}
+}
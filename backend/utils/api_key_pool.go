@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"sync"
@@ -14,6 +15,10 @@ type APIKeyPool struct {
 	lastUsedTime map[string]time.Time
 	blacklist    map[string]time.Time
 	mu           sync.RWMutex
+
+	// Optional request rate limit; each available key adds keyRate requests per second
+	limiter *TokenBucket
+	keyRate float64
 }
 
 // NewAPIKeyPool creates a new API key pool
@@ -30,6 +35,34 @@ func NewAPIKeyPool(keys []string) *APIKeyPool {
 	}
 }
 
+// SetRateLimit limits requests to perKey per second for every key that is not blacklisted.
+// A rate of 0 removes the limit.
+func (p *APIKeyPool) SetRateLimit(perKey float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keyRate = perKey
+	p.limiter = nil
+	if perKey > 0 {
+		n := max(1, len(p.getAvailableKeys()))
+		p.limiter = NewTokenBucket(perKey*float64(n), n)
+	}
+}
+
+// Wait blocks until the rate limit allows another request. The bucket is resized to the keys
+// currently available, so parked keys slow the pool down instead of drawing 429s.
+func (p *APIKeyPool) Wait(ctx context.Context) error {
+	p.mu.Lock()
+	limiter := p.limiter
+	n := max(1, len(p.getAvailableKeys()))
+	rate := p.keyRate * float64(n)
+	p.mu.Unlock()
+	if limiter == nil {
+		return nil
+	}
+	limiter.SetLimit(rate, n)
+	return limiter.Wait(ctx)
+}
+
 // GetRandomKey returns an available API key
 // Implements smart selection: prefers less-used keys, avoids blacklisted keys
 func (p *APIKeyPool) GetRandomKey() (string, error) {
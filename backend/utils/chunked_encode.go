@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// minChunkSeconds is the shortest part TranscodeVideoChunked encodes on its own; shorter videos
+// are split into fewer parts, and ones under twice as long are not split
+const minChunkSeconds = 60.0
+
+// TranscodeVideoChunked is TranscodeVideo with the video split into up to chunks parts that are
+// encoded in parallel, then joined by stream copy under the input's audio and subtitles. It
+// falls back to one encode when there is nothing to encode (H.264 is copied), the video is too
+// short or it cannot be probed.
+func TranscodeVideoChunked(inputPath, outputPath string, format OutputFormat, chunks int) error {
+	if chunks < 2 || format.Codec == CodecH264 {
+		return TranscodeVideo(inputPath, outputPath, format)
+	}
+	bounds := planChunks(inputPath, chunks)
+	if bounds == nil {
+		return TranscodeVideo(inputPath, outputPath, format)
+	}
+	return encodeChunks(inputPath, outputPath, bounds, format.Ext(), func(part string, i int) *FFmpegCommand {
+		return chunkCommand(inputPath, part, format, bounds, i)
+	}, format.outputArgs()...)
+}
+
+// BurnASSChunked is BurnASS with the H.264 encode split into up to chunks parts run in
+// parallel, as TranscodeVideoChunked. It is the final encode of the default MP4 output, and the
+// longest one at the "high" quality preset; short videos are burned in one run.
+func BurnASSChunked(inputPath, outputPath string, chunks int, assPaths ...string) error {
+	if chunks < 2 {
+		return BurnASS(inputPath, outputPath, assPaths...)
+	}
+	bounds := planChunks(inputPath, chunks)
+	if bounds == nil {
+		return BurnASS(inputPath, outputPath, assPaths...)
+	}
+	return encodeChunks(inputPath, outputPath, bounds, filepath.Ext(outputPath), func(part string, i int) *FFmpegCommand {
+		return burnChunkCommand(inputPath, part, bounds, i, assPaths)
+	}, "-c:a", "copy", "-c:s", "copy")
+}
+
+// planChunks probes inputPath for the bounds of its parts (see chunkBounds), or nil to encode
+// it in one run
+func planChunks(inputPath string, chunks int) []float64 {
+	duration, err := GetVideoDuration(inputPath)
+	if err != nil {
+		log.Printf("Chunked encode skipped: %v", err)
+		return nil
+	}
+	probed, err := probeConcatInputs([]string{inputPath})
+	if err != nil {
+		log.Printf("Chunked encode skipped: %v", err)
+		return nil
+	}
+	fps, ok := parseFrameRate(probed[0][0].FrameRate)
+	if !ok {
+		log.Printf("Chunked encode skipped: unknown frame rate %q", probed[0][0].FrameRate)
+		return nil
+	}
+	return chunkBounds(duration, fps, chunks)
+}
+
+// encodeChunks runs the encodePart command of each part of bounds in parallel, writing to
+// files named after outputPath with ext, then joins their video by stream copy under the audio
+// and subtitles of inputPath, encoded with joinArgs
+func encodeChunks(inputPath, outputPath string, bounds []float64, ext string, encodePart func(partPath string, i int) *FFmpegCommand, joinArgs ...string) error {
+	duration := bounds[len(bounds)-1]
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	parts := make([]string, len(bounds)-1)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%s_chunk%02d%s", base, i, ext)
+		defer os.Remove(parts[i])
+	}
+	// The parts' progress adds up to the progress of outputPath's watcher
+	if w := progressFor([]string{outputPath}); w != nil {
+		var mutex sync.Mutex
+		done := make([]float64, len(parts))
+		for i, part := range parts {
+			length := bounds[i+1] - bounds[i]
+			defer WatchProgress(part, length, func(fraction float64) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				done[i] = fraction * length
+				total := 0.0
+				for _, d := range done {
+					total += d
+				}
+				w.report(total / duration)
+			})()
+		}
+	}
+
+	errs := make([]error, len(parts))
+	var wg sync.WaitGroup
+	for i := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = encodePart(parts[i], i).Run()
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to encode part %d of %d: %w", i+1, len(parts), err)
+		}
+	}
+
+	listPath := base + "_chunks.txt"
+	var list strings.Builder
+	for _, part := range parts {
+		abs, err := filepath.Abs(part)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path %s: %w", part, err)
+		}
+		list.WriteString(fmt.Sprintf("file '%s'\n", filepath.ToSlash(abs)))
+	}
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write concat list: %w", err)
+	}
+	defer os.Remove(listPath)
+
+	return NewFFmpegCommand("join encoded parts").
+		Input(listPath, "-f", "concat", "-safe", "0").
+		Input(inputPath).
+		Map("0:v:0", "1:a?", "1:s?").
+		Args("-c:v", "copy").
+		Args(joinArgs...).
+		Output(outputPath).
+		Run()
+}
+
+// chunkBounds splits duration seconds of fps video into at most chunks parts of at least
+// minChunkSeconds, returning the times their first frames are shown and, last, duration; nil
+// when the video should not be split. Cuts fall halfway between two frames, so each frame goes
+// to exactly one part.
+func chunkBounds(duration, fps float64, chunks int) []float64 {
+	n := min(chunks, int(duration/minChunkSeconds))
+	if n < 2 || fps <= 0 {
+		return nil
+	}
+	bounds := []float64{0}
+	for i := 1; i < n; i++ {
+		frame := math.Round(duration * float64(i) / float64(n) * fps)
+		bounds = append(bounds, (frame-0.5)/fps)
+	}
+	return append(bounds, duration)
+}
+
+// chunkCommand encodes the video of part i of bounds into outputPath; the last part runs to
+// the end of the input
+func chunkCommand(inputPath, outputPath string, format OutputFormat, bounds []float64, i int) *FFmpegCommand {
+	cmd := NewFFmpegCommand(fmt.Sprintf("encode part %d", i+1)).Input(inputPath, "-ss", fmt.Sprintf("%.6f", bounds[i]))
+	if i+2 < len(bounds) {
+		cmd.Args("-t", fmt.Sprintf("%.6f", bounds[i+1]-bounds[i]))
+	}
+	return cmd.Map("0:v:0").Args(format.videoArgs()...).Args("-an").Output(outputPath)
+}
+
+// burnChunkCommand burns assPaths into the video of part i of bounds, written to outputPath.
+// The part's frames are moved back to their time in the whole video for the subtitles, then
+// to the start of the part for the join.
+func burnChunkCommand(inputPath, outputPath string, bounds []float64, i int, assPaths []string) *FFmpegCommand {
+	cmd := NewFFmpegCommand(fmt.Sprintf("burn subtitles part %d", i+1)).Input(inputPath, "-ss", fmt.Sprintf("%.6f", bounds[i]))
+	if i+2 < len(bounds) {
+		cmd.Args("-t", fmt.Sprintf("%.6f", bounds[i+1]-bounds[i]))
+	}
+	chain := Chain().Then(F("setpts", fmt.Sprintf("PTS+%.6f/TB", bounds[i]))).Then(assFilters(assPaths)...).Then(F("setpts", "PTS-STARTPTS"))
+	return cmd.Map("0:v:0").
+		VideoFilter(chain).
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+		Output(outputPath)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestChunkBounds(t *testing.T) {
+	bounds := chunkBounds(600, 30, 4)
+	if len(bounds) != 5 || bounds[0] != 0 || bounds[4] != 600 {
+		t.Fatalf("Expected 4 parts of 600s, got %v", bounds)
+	}
+	for i, b := range bounds[1:4] {
+		// Halfway between the last frame of a part and the first of the next
+		if want := (float64(i+1)*150*30 - 0.5) / 30; math.Abs(b-want) > 1e-9 {
+			t.Errorf("bounds[%d] = %v, want %v", i+1, b, want)
+		}
+	}
+
+	if got := chunkBounds(150, 30, 8); len(got) != 3 {
+		t.Errorf("Expected a 150s video to be split in 2 parts of at least a minute, got %v", got)
+	}
+	for _, tt := range []struct {
+		duration float64
+		chunks   int
+	}{{100, 8}, {600, 1}, {600, 0}} {
+		if got := chunkBounds(tt.duration, 30, tt.chunks); got != nil {
+			t.Errorf("chunkBounds(%v, 30, %d) = %v, want no split", tt.duration, tt.chunks, got)
+		}
+	}
+}
+
+func TestChunkCommand(t *testing.T) {
+	format := OutputFormat{Container: ContainerWebM, Codec: CodecVP9}
+	bounds := []float64{0, 299.983333, 600}
+
+	first := strings.Join(chunkCommand("in.mp4", "out_chunk00.webm", format, bounds, 0).Build(), " ")
+	if !strings.HasPrefix(first, "-ss 0.000000 -i in.mp4 -t 299.983333 -map 0:v:0 -c:v libvpx-vp9") || !strings.HasSuffix(first, "-an -y out_chunk00.webm") {
+		t.Errorf("Unexpected first part args %q", first)
+	}
+	last := chunkCommand("in.mp4", "out_chunk01.webm", format, bounds, 1).Build()
+	if want := []string{"-ss", "299.983333", "-i", "in.mp4", "-map", "0:v:0"}; !reflect.DeepEqual(last[:6], want) {
+		t.Errorf("Expected the last part to run to the end, got %v", last)
+	}
+}
+
+func TestBurnChunkCommand(t *testing.T) {
+	got := strings.Join(burnChunkCommand("in.mp4", "out_chunk01.mp4", []float64{0, 299.983333, 600}, 1, []string{"subs.ass"}).Build(), " ")
+	want := "-ss 299.983333 -i in.mp4 -map 0:v:0 -vf setpts=PTS+299.983333/TB,ass='subs.ass',setpts=PTS-STARTPTS -c:v libx264 -preset medium -crf 20 -an -y out_chunk01.mp4"
+	if got != want {
+		t.Errorf("burnChunkCommand() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBurnASSChunked(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffprobe is a shell script")
+	}
+	// A 10 minute, 30 fps video
+	bin := t.TempDir()
+	script := "#!/bin/sh\ncase \"$*\" in\n*json*) echo '{\"streams\":[{\"codec_type\":\"video\",\"r_frame_rate\":\"30/1\"}]}' ;;\n*) echo 600 ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(bin, "ffprobe"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	SetFFmpegDryRun(true)
+	defer SetFFmpegDryRun(false)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	output := filepath.Join(t.TempDir(), "final_video_subtitled.mp4")
+	if err := BurnASSChunked("composed.mp4", output, 4, "subs.ass"); err != nil {
+		t.Fatalf("BurnASSChunked() = %v", err)
+	}
+	for _, want := range []string{
+		"burn subtitles part 1: ffmpeg -ss 0.000000 -i composed.mp4 -t 149.983333",
+		"burn subtitles part 4: ffmpeg -ss 449.983333 -i composed.mp4 -map 0:v:0",
+		"join encoded parts: ffmpeg -f concat -safe 0 -i",
+		"-c:v copy -c:a copy -c:s copy -y " + output,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := BurnASSChunked("composed.mp4", output, 1, "subs.ass"); err != nil {
+		t.Fatalf("BurnASSChunked() = %v", err)
+	}
+	if !strings.Contains(buf.String(), "burn subtitles: ffmpeg -i composed.mp4") || strings.Contains(buf.String(), "part 1") {
+		t.Errorf("Expected one encode without chunks, got\n%s", buf.String())
+	}
+}
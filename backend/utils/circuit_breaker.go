@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker stops calls to a failing dependency. It opens when more than failureRatio of
+// the calls in the last window failed (once at least minCalls were made), rejects calls for
+// cooldown, then lets a single trial call through: success closes it, failure re-opens it.
+type CircuitBreaker struct {
+	window       time.Duration
+	failureRatio float64
+	minCalls     int
+	cooldown     time.Duration
+
+	mu       sync.Mutex
+	outcomes []callOutcome
+	openedAt time.Time // zero while closed
+	trial    bool      // a half-open trial call is in flight
+	now      func() time.Time
+}
+
+type callOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// NewCircuitBreaker creates a closed breaker
+func NewCircuitBreaker(window time.Duration, failureRatio float64, minCalls int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		window:       window,
+		failureRatio: failureRatio,
+		minCalls:     minCalls,
+		cooldown:     cooldown,
+		now:          time.Now,
+	}
+}
+
+// Allow reports whether a call may be made now
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.openedAt.IsZero() {
+		return true
+	}
+	if cb.trial || cb.now().Sub(cb.openedAt) < cb.cooldown {
+		return false
+	}
+	cb.trial = true
+	return true
+}
+
+// Record reports the outcome of an allowed call
+func (cb *CircuitBreaker) Record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	now := cb.now()
+
+	if !cb.openedAt.IsZero() {
+		if !cb.trial {
+			return // a call started before the breaker opened
+		}
+		cb.trial = false
+		if success {
+			cb.openedAt = time.Time{}
+			cb.outcomes = nil
+		} else {
+			cb.openedAt = now
+		}
+		return
+	}
+
+	cb.outcomes = append(cb.outcomes, callOutcome{at: now, failed: !success})
+	cutoff := now.Add(-cb.window)
+	kept := cb.outcomes[:0]
+	failures := 0
+	for _, o := range cb.outcomes {
+		if o.at.After(cutoff) {
+			kept = append(kept, o)
+			if o.failed {
+				failures++
+			}
+		}
+	}
+	cb.outcomes = kept
+	if len(kept) >= cb.minCalls && float64(failures) > cb.failureRatio*float64(len(kept)) {
+		cb.openedAt = now
+	}
+}
+
+// Open reports whether the breaker is currently rejecting calls
+func (cb *CircuitBreaker) Open() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return !cb.openedAt.IsZero()
+}
+
+// RetryBudget caps retries at a fraction of calls across all users of a client, so an outage
+// cannot multiply traffic by the per-call attempt limit. Every call deposits ratio tokens (up to
+// max) and every retry withdraws one.
+type RetryBudget struct {
+	ratio float64
+	max   float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// NewRetryBudget creates a budget that starts full
+func NewRetryBudget(ratio, max float64) *RetryBudget {
+	return &RetryBudget{ratio: ratio, max: max, tokens: max}
+}
+
+// Deposit records a call
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+// Withdraw takes one retry from the budget, or reports false when it is spent
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(time.Minute, 0.5, 4, 30*time.Second)
+	cb.now = func() time.Time { return now }
+
+	// Too few calls to judge, then a failure majority opens the breaker
+	cb.Record(false)
+	cb.Record(false)
+	cb.Record(false)
+	if cb.Open() {
+		t.Fatal("Breaker opened before minCalls")
+	}
+	cb.Record(true)
+	if !cb.Open() || cb.Allow() {
+		t.Fatal("Expected the breaker to open after 3 of 4 calls failed")
+	}
+
+	// After the cooldown only one trial call goes through
+	now = now.Add(31 * time.Second)
+	if !cb.Allow() {
+		t.Fatal("Expected a trial call after the cooldown")
+	}
+	if cb.Allow() {
+		t.Error("Only one trial call should be allowed")
+	}
+	cb.Record(false)
+	if cb.Allow() {
+		t.Error("A failed trial should re-open the breaker")
+	}
+
+	now = now.Add(31 * time.Second)
+	cb.Allow()
+	cb.Record(true)
+	if cb.Open() || !cb.Allow() {
+		t.Error("A successful trial should close the breaker")
+	}
+
+	// Old failures fall out of the window
+	cb.Record(false)
+	cb.Record(false)
+	cb.Record(false)
+	now = now.Add(2 * time.Minute)
+	cb.Record(true)
+	if cb.Open() {
+		t.Error("Failures outside the window should not count")
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := NewRetryBudget(0.5, 2)
+	if !b.Withdraw() || !b.Withdraw() || b.Withdraw() {
+		t.Fatal("Expected a full budget of 2 retries")
+	}
+	b.Deposit()
+	if b.Withdraw() {
+		t.Error("Half a token should not pay for a retry")
+	}
+	b.Deposit()
+	if !b.Withdraw() {
+		t.Error("Two calls should earn one retry")
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ColorGrade is one look applied to all of a video's footage: a 3D LUT, then contrast,
+// saturation and white balance adjustments
+type ColorGrade struct {
+	LUTPath     string  // .cube file; "" for none
+	Contrast    float64 // 1 is unchanged
+	Saturation  float64 // 1 is unchanged, 0 is black and white
+	Temperature float64 // -1 (cooler) to 1 (warmer), 0 is unchanged
+}
+
+// Filter is the ffmpeg video filter chain of g, empty when g changes nothing
+func (g ColorGrade) Filter() *FilterChain {
+	chain := Chain()
+	if g.LUTPath != "" {
+		chain.Then(F("lut3d", fmt.Sprintf("file='%s'", filepath.ToSlash(g.LUTPath))))
+	}
+	if g.Contrast != 1 || g.Saturation != 1 {
+		chain.Then(F("eq", fmt.Sprintf("contrast=%.3f", g.Contrast), fmt.Sprintf("saturation=%.3f", g.Saturation)))
+	}
+	if g.Temperature != 0 {
+		// Warmer pushes the midtones and highlights toward red and away from blue
+		mid, high := 0.15*g.Temperature, 0.08*g.Temperature
+		chain.Then(F("colorbalance", fmt.Sprintf("rm=%.3f", mid), fmt.Sprintf("bm=%.3f", -mid), fmt.Sprintf("rh=%.3f", high), fmt.Sprintf("bh=%.3f", -high)))
+	}
+	return chain
+}
+
+// GradeVideo re-encodes the video-only file at inputPath with grade applied
+func GradeVideo(inputPath, outputPath string, grade ColorGrade) error {
+	filter := grade.Filter()
+	if len(filter.Filters) == 0 {
+		return fmt.Errorf("color grade changes nothing")
+	}
+	return NewFFmpegCommand("color grade").
+		Input(inputPath).
+		VideoFilter(filter.Then(F("format", "yuv420p"))).
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "18", "-an").
+		Output(outputPath).
+		Run()
+}
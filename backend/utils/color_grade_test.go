@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestColorGradeFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		grade ColorGrade
+		want  string
+	}{
+		{"Unchanged", ColorGrade{Contrast: 1, Saturation: 1}, ""},
+		{"LUT only", ColorGrade{LUTPath: "static/luts/film.cube", Contrast: 1, Saturation: 1}, "lut3d=file='static/luts/film.cube'"},
+		{"Black and white", ColorGrade{Contrast: 1.2, Saturation: 0}, "eq=contrast=1.200:saturation=0.000"},
+		{"Warm", ColorGrade{Contrast: 1, Saturation: 1, Temperature: 0.5}, "colorbalance=rm=0.075:bm=-0.075:rh=0.040:bh=-0.040"},
+		{
+			"Everything",
+			ColorGrade{LUTPath: "film.cube", Contrast: 1.1, Saturation: 1.3, Temperature: -1},
+			"lut3d=file='film.cube',eq=contrast=1.100:saturation=1.300,colorbalance=rm=-0.150:bm=0.150:rh=-0.080:bh=0.080",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.grade.Filter().String(); got != tt.want {
+				t.Errorf("Filter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// probedStream is the part of a stream's ffprobe entry that decides whether files can be joined
+// without re-encoding
+type probedStream struct {
+	CodecType         string `json:"codec_type"`
+	CodecName         string `json:"codec_name"`
+	Profile           string `json:"profile"`
+	Width             int    `json:"width"`
+	Height            int    `json:"height"`
+	PixFmt            string `json:"pix_fmt"`
+	SampleAspectRatio string `json:"sample_aspect_ratio"`
+	FrameRate         string `json:"r_frame_rate"`
+	SampleRate        string `json:"sample_rate"`
+	Channels          int    `json:"channels"`
+}
+
+// probeConcatInputs returns the first video and audio stream of each of inputFiles, in that
+// order; a file without audio has an empty second stream
+func probeConcatInputs(inputFiles []string) ([][2]probedStream, error) {
+	probed := make([][2]probedStream, 0, len(inputFiles))
+	for _, path := range inputFiles {
+		output, err := exec.Command("ffprobe",
+			"-v", "error",
+			"-show_entries", "stream=codec_type,codec_name,profile,width,height,pix_fmt,sample_aspect_ratio,r_frame_rate,sample_rate,channels",
+			"-of", "json",
+			path,
+		).Output()
+		if err != nil {
+			return nil, fmt.Errorf("ffprobe error on %s: %w", path, err)
+		}
+		streams, err := parseProbedStreams(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ffprobe output of %s: %w", path, err)
+		}
+		probed = append(probed, streams)
+	}
+	return probed, nil
+}
+
+// parseProbedStreams picks the first video and audio stream out of ffprobe's JSON output
+func parseProbedStreams(output []byte) ([2]probedStream, error) {
+	var result struct {
+		Streams []probedStream `json:"streams"`
+	}
+	var streams [2]probedStream
+	if err := json.Unmarshal(output, &result); err != nil {
+		return streams, err
+	}
+	for _, st := range result.Streams {
+		switch {
+		case st.CodecType == "video" && streams[0].CodecType == "":
+			streams[0] = st
+		case st.CodecType == "audio" && streams[1].CodecType == "":
+			streams[1] = st
+		}
+	}
+	return streams, nil
+}
+
+// concatCopyable reports whether files whose streams are probed can be joined by stream copy
+// into what ConcatVideos' filter graph would make of them: all H.264 of one profile at width x
+// height, fps and yuv420p with square pixels, with AAC audio at 44.1 kHz stereo. Other
+// differences between the encodes (e.g. their levels) are left to the decoders.
+func concatCopyable(probed [][2]probedStream, width, height, fps int) bool {
+	if len(probed) == 0 {
+		return false
+	}
+	first := probed[0]
+	for _, streams := range probed {
+		v, a := streams[0], streams[1]
+		if v.CodecName != "h264" || v.Profile != first[0].Profile || v.Width != width || v.Height != height || v.PixFmt != "yuv420p" {
+			return false
+		}
+		if sar := v.SampleAspectRatio; sar != "" && sar != "N/A" && sar != "1:1" && sar != "0:1" {
+			return false
+		}
+		if rate, ok := parseFrameRate(v.FrameRate); !ok || math.Abs(rate-float64(fps)) > 0.01 {
+			return false
+		}
+		if a.CodecName != "aac" || a.Profile != first[1].Profile || a.SampleRate != "44100" || a.Channels != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFrameRate parses an ffprobe rate such as "30000/1001"
+func parseFrameRate(rate string) (float64, bool) {
+	num, den, found := strings.Cut(rate, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, false
+	}
+	if !found {
+		return n, true
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0, false
+	}
+	return n / d, true
+}
@@ -0,0 +1,73 @@
+package utils
+
+import "testing"
+
+func TestParseProbedStreams(t *testing.T) {
+	output := []byte(`{"streams": [
+		{"codec_type": "video", "codec_name": "h264", "profile": "High", "width": 1920, "height": 1080, "pix_fmt": "yuv420p", "sample_aspect_ratio": "1:1", "r_frame_rate": "30/1"},
+		{"codec_type": "audio", "codec_name": "aac", "profile": "LC", "sample_rate": "44100", "channels": 2},
+		{"codec_type": "subtitle", "codec_name": "mov_text"}
+	]}`)
+	streams, err := parseProbedStreams(output)
+	if err != nil {
+		t.Fatalf("parseProbedStreams() error: %v", err)
+	}
+	if streams[0].CodecName != "h264" || streams[0].Width != 1920 || streams[1].CodecName != "aac" || streams[1].Channels != 2 {
+		t.Errorf("Unexpected streams %+v", streams)
+	}
+
+	streams, err = parseProbedStreams([]byte(`{"streams": [{"codec_type": "video", "codec_name": "vp9"}]}`))
+	if err != nil || streams[1].CodecType != "" {
+		t.Errorf("Expected no audio stream, got %+v (%v)", streams, err)
+	}
+}
+
+func TestConcatCopyable(t *testing.T) {
+	normalized := [2]probedStream{
+		{CodecType: "video", CodecName: "h264", Profile: "High", Width: 1920, Height: 1080, PixFmt: "yuv420p", SampleAspectRatio: "1:1", FrameRate: "30/1"},
+		{CodecType: "audio", CodecName: "aac", Profile: "LC", SampleRate: "44100", Channels: 2},
+	}
+	with := func(change func(s *[2]probedStream)) [2]probedStream {
+		s := normalized
+		change(&s)
+		return s
+	}
+
+	if !concatCopyable([][2]probedStream{normalized, normalized, normalized}, 1920, 1080, 30) {
+		t.Error("Expected normalized inputs to be copyable")
+	}
+	tests := map[string][2]probedStream{
+		"other size":    with(func(s *[2]probedStream) { s[0].Width, s[0].Height = 1280, 720 }),
+		"other rate":    with(func(s *[2]probedStream) { s[0].FrameRate = "30000/1001" }),
+		"other profile": with(func(s *[2]probedStream) { s[0].Profile = "Main" }),
+		"other codec":   with(func(s *[2]probedStream) { s[0].CodecName = "hevc" }),
+		"anamorphic":    with(func(s *[2]probedStream) { s[0].SampleAspectRatio = "4:3" }),
+		"48 kHz":        with(func(s *[2]probedStream) { s[1].SampleRate = "48000" }),
+		"mono":          with(func(s *[2]probedStream) { s[1].Channels = 1 }),
+		"no audio":      with(func(s *[2]probedStream) { s[1] = probedStream{} }),
+		"10-bit":        with(func(s *[2]probedStream) { s[0].PixFmt = "yuv420p10le" }),
+		"unknown rate":  with(func(s *[2]probedStream) { s[0].FrameRate = "0/0" }),
+		"no frame size": with(func(s *[2]probedStream) { s[0].Width, s[0].Height = 0, 0 }),
+	}
+	for name, odd := range tests {
+		if concatCopyable([][2]probedStream{normalized, odd, normalized}, 1920, 1080, 30) {
+			t.Errorf("%s: expected the inputs to need re-encoding", name)
+		}
+	}
+	if concatCopyable([][2]probedStream{normalized}, 1080, 1920, 30) {
+		t.Error("Expected inputs of another frame size than the target to need re-encoding")
+	}
+}
+
+func TestParseFrameRate(t *testing.T) {
+	for rate, want := range map[string]float64{"30/1": 30, "30000/1001": 29.97002997002997, "25": 25} {
+		if got, ok := parseFrameRate(rate); !ok || got != want {
+			t.Errorf("parseFrameRate(%q) = %v, %v, want %v", rate, got, ok, want)
+		}
+	}
+	for _, rate := range []string{"", "0/0", "N/A"} {
+		if _, ok := parseFrameRate(rate); ok {
+			t.Errorf("Expected parseFrameRate(%q) to fail", rate)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import "fmt"
+
+// ScreenZone is a rectangle of a frame, in pixels
+type ScreenZone struct {
+	X, Y, W, H int
+}
+
+// EndScreenZones lays out the placeholders of YouTube end screen elements on a width x height
+// frame: videos 16:9 video elements, a third of a landscape frame wide as in YouTube's
+// templates, and a square for the subscribe button. Landscape and square frames get one row,
+// subscribe first; portrait ones a column.
+func EndScreenZones(width, height, videos int) (video []ScreenZone, subscribe ScreenZone) {
+	gap := even(width / 30)
+	if width >= height {
+		vw := even(width * 32 / 100)
+		vh := even(vw * 9 / 16)
+		side := even(width * 12 / 100)
+		x := (width - side - videos*(vw+gap)) / 2
+		y := height * 55 / 100
+		subscribe = ScreenZone{x, y - side/2, side, side}
+		x += side + gap
+		for i := 0; i < videos; i++ {
+			video = append(video, ScreenZone{x, y - vh/2, vw, vh})
+			x += vw + gap
+		}
+		return video, subscribe
+	}
+
+	vw := even(width * 80 / 100)
+	vh := even(vw * 9 / 16)
+	side := even(width * 25 / 100)
+	y := height / 4
+	subscribe = ScreenZone{(width - side) / 2, y, side, side}
+	y += side + gap
+	for i := 0; i < videos; i++ {
+		video = append(video, ScreenZone{(width - vw) / 2, y, vw, vh})
+		y += vh + gap
+	}
+	return video, subscribe
+}
+
+// even rounds n down to an even number
+func even(n int) int {
+	return n &^ 1
+}
+
+// RenderEndScreen renders a clip of duration seconds, with a silent audio track so it
+// concatenates like an outro: card.TitleFile (e.g. a subscribe prompt) at the top of card's
+// background and outlined placeholders where the end screen elements go (see EndScreenZones)
+func RenderEndScreen(outputPath string, duration float64, card StaticCard, videos int, orientation string, fps int) error {
+	width, height := FrameSize(orientation)
+	if fps <= 0 {
+		fps = 30
+	}
+	cmd := NewFFmpegCommand("render end screen")
+	cardBackground(cmd, card, width, height, fps)
+	return cmd.Input("anullsrc=r=44100:cl=stereo", "-f", "lavfi").
+		FilterComplex(endScreenFilter(card, videos, width, height, fps, duration)).
+		Map("[v]", "1:a").
+		Args("-t", fmt.Sprintf("%.3f", duration), "-c:v", "libx264", "-preset", "medium", "-tune", "stillimage", "-crf", "20", "-c:a", "aac", "-b:a", "128k").
+		Output(outputPath).
+		Run()
+}
+
+// endScreenFilter builds the -filter_complex of RenderEndScreen
+func endScreenFilter(card StaticCard, videos, width, height, fps int, duration float64) FilterGraph {
+	chain := Chain("0:v").Then(cardFrame(width, height, fps)...)
+	if card.TitleFile != "" {
+		chain.Then(drawTextFile(card.TitleFile,
+			"fontcolor=white", fmt.Sprintf("fontsize=%d", height/14), "x=(w-text_w)/2", "y=h/8", "shadowcolor=black@0.6", "shadowx=3", "shadowy=3"))
+	}
+	video, subscribe := EndScreenZones(width, height, videos)
+	border := max(2, height/270)
+	for _, z := range append([]ScreenZone{subscribe}, video...) {
+		box := []string{fmt.Sprintf("x=%d", z.X), fmt.Sprintf("y=%d", z.Y), fmt.Sprintf("w=%d", z.W), fmt.Sprintf("h=%d", z.H)}
+		chain.Then(
+			F("drawbox", append(box, "color=black@0.35", "t=fill")...),
+			F("drawbox", append(box, "color=white@0.8", fmt.Sprintf("t=%d", border))...))
+	}
+	chain.Then(F("fade", "t=in", "st=0", fmt.Sprintf("d=%.3f", min(0.5, duration/6))), F("format", "yuv420p"))
+	return FilterGraph{chain.To("v")}
+}
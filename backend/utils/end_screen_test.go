@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEndScreenZones(t *testing.T) {
+	for _, orientation := range []string{"landscape", "portrait", "square"} {
+		width, height := FrameSize(orientation)
+		for videos := 1; videos <= 2; videos++ {
+			video, subscribe := EndScreenZones(width, height, videos)
+			if len(video) != videos {
+				t.Fatalf("%s: got %d video zones, want %d", orientation, len(video), videos)
+			}
+			zones := append([]ScreenZone{subscribe}, video...)
+			for i, z := range zones {
+				if z.X < 0 || z.Y < 0 || z.X+z.W > width || z.Y+z.H > height {
+					t.Errorf("%s/%d: zone %d %+v leaves the %dx%d frame", orientation, videos, i, z, width, height)
+				}
+				for _, o := range zones[i+1:] {
+					if z.X < o.X+o.W && o.X < z.X+z.W && z.Y < o.Y+o.H && o.Y < z.Y+z.H {
+						t.Errorf("%s/%d: zones %+v and %+v overlap", orientation, videos, z, o)
+					}
+				}
+			}
+			if v := video[0]; v.H != even(v.W*9/16) {
+				t.Errorf("%s: video zone %+v is not 16:9", orientation, v)
+			}
+		}
+	}
+
+	// YouTube's landscape templates: a third of the frame per video, subscribe on the left
+	video, subscribe := EndScreenZones(1920, 1080, 2)
+	if video[0].W != 614 || video[0].H != 344 || subscribe.X >= video[0].X {
+		t.Errorf("landscape zones: video %+v, subscribe %+v", video, subscribe)
+	}
+}
+
+func TestEndScreenFilter(t *testing.T) {
+	got := endScreenFilter(StaticCard{TitleFile: "end.txt"}, 1, 1920, 1080, 30, 20).String()
+	for _, part := range []string{
+		"[0:v]scale=1920:1080:force_original_aspect_ratio=increase,crop=1920:1080,setsar=1,fps=30,",
+		",drawtext=textfile='end.txt':fontcolor=white:fontsize=77:x=(w-text_w)/2:y=h/8:",
+		",drawbox=x=", ":color=white@0.8:t=4",
+		",fade=t=in:st=0:d=0.500,format=yuv420p[v]",
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("Expected %q in\n%s", part, got)
+		}
+	}
+	// A fill and an outline per zone: the subscribe square and one video
+	if n := strings.Count(got, "drawbox="); n != 4 {
+		t.Errorf("Expected 4 drawbox filters, got %d in\n%s", n, got)
+	}
+}
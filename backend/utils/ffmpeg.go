@@ -2,26 +2,174 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // RunFFmpegCommand executes an FFmpeg command
 func RunFFmpegCommand(args []string) error {
-	cmd := exec.Command("ffmpeg", args...)
+	_, err := runFFmpeg(args)
+	return err
+}
+
+// runFFmpeg executes FFmpeg and returns its stderr, where filters such as loudnorm print their reports.
+// libx264 encodes take the SetEncodeQuality preset of their folder, then run on the FFMPEG_HWACCEL
+// encoder when there is one, and again in software if that fails.
+func runFFmpeg(args []string) (string, error) {
+	return runFFmpegStep("", args)
+}
+
+// runFFmpegStep is runFFmpeg for the FFmpegCommand of step
+func runFFmpegStep(step string, args []string) (string, error) {
+	args = withQuality(args)
+	if ffmpegDryRun.Load() {
+		if hwArgs, ok := withHWAccel(args); ok {
+			args = hwArgs
+		}
+		logDryRun(step, args)
+		return "", nil
+	}
+	if hwArgs, ok := withHWAccel(args); ok {
+		stderr, err := execFFmpeg(hwArgs)
+		if err == nil {
+			return stderr, nil
+		}
+		if errors.Is(err, ErrFFmpegCancelled) || errors.Is(err, context.DeadlineExceeded) {
+			return stderr, err
+		}
+		// e.g. the GPU's concurrent session limit, or a frame size the encoder rejects
+		log.Printf("Hardware encode failed, retrying with libx264: %v", err)
+	}
+	return execFFmpeg(args)
+}
+
+// ErrFFmpegCancelled is the error of ffmpeg runs stopped by CancelFFmpeg
+var ErrFFmpegCancelled = errors.New("ffmpeg canceled")
+
+// ffmpegTimeout bounds each ffmpeg run, in nanoseconds; 0 means no limit
+var ffmpegTimeout atomic.Int64
+
+// SetFFmpegTimeout kills ffmpeg runs that take longer than d (FFMPEG_TIMEOUT), so a hung encode
+// fails its job instead of holding the worker; 0 disables the limit
+func SetFFmpegTimeout(d time.Duration) {
+	ffmpegTimeout.Store(int64(d))
+}
+
+// ffmpegRuns are the running ffmpeg processes, by the cancel func of their context, and the
+// folders CancelFFmpeg closed
+var ffmpegRuns = struct {
+	sync.Mutex
+	running  map[*context.CancelFunc]string // -> output path
+	canceled map[string]bool
+}{running: make(map[*context.CancelFunc]string), canceled: make(map[string]bool)}
+
+// CancelFFmpeg kills the ffmpeg runs writing under dir, such as a job's temp folder, and makes
+// later runs there fail at once with ErrFFmpegCancelled. It returns how many it killed.
+func CancelFFmpeg(dir string) int {
+	dir = filepath.Clean(dir)
+	ffmpegRuns.Lock()
+	defer ffmpegRuns.Unlock()
+	ffmpegRuns.canceled[dir] = true
+	killed := 0
+	for cancel, output := range ffmpegRuns.running {
+		if isUnder(output, dir) {
+			(*cancel)()
+			killed++
+		}
+	}
+	return killed
+}
+
+// ReleaseFFmpegCancel forgets that CancelFFmpeg closed dir, once nothing encodes there any
+// more: the job's folder was removed or its pipeline returned
+func ReleaseFFmpegCancel(dir string) {
+	ffmpegRuns.Lock()
+	delete(ffmpegRuns.canceled, filepath.Clean(dir))
+	ffmpegRuns.Unlock()
+}
+
+// isUnder reports whether path is inside dir
+func isUnder(path, dir string) bool {
+	return strings.HasPrefix(filepath.Clean(path), dir+string(filepath.Separator))
+}
+
+// startFFmpegRun registers a run writing output, returning its context and the func that
+// unregisters it, or ErrFFmpegCancelled for a folder CancelFFmpeg closed
+func startFFmpegRun(output string) (context.Context, func(), error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := time.Duration(ffmpegTimeout.Load()); timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	ffmpegRuns.Lock()
+	defer ffmpegRuns.Unlock()
+	for dir := range ffmpegRuns.canceled {
+		if isUnder(output, dir) {
+			cancel()
+			return nil, nil, ErrFFmpegCancelled
+		}
+	}
+	ffmpegRuns.running[&cancel] = output
+	return ctx, func() {
+		ffmpegRuns.Lock()
+		delete(ffmpegRuns.running, &cancel)
+		ffmpegRuns.Unlock()
+		cancel()
+	}, nil
+}
+
+// execFFmpeg runs ffmpeg with args as they are, plus -progress when WatchProgress watches the
+// output. The process is killed on timeout or CancelFFmpeg.
+func execFFmpeg(args []string) (string, error) {
+	output := ""
+	if len(args) > 0 {
+		output = args[len(args)-1]
+	}
+	ctx, finish, err := startFFmpegRun(output)
+	if err != nil {
+		return "", err
+	}
+	defer finish()
+
+	watcher := progressFor(args)
+	if watcher != nil {
+		args = append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.WaitDelay = 10 * time.Second // don't wait for the pipes of a killed ffmpeg's children
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
+	if watcher != nil {
+		cmd.Stdout = &progressWriter{watcher: watcher}
+	}
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
-		return fmt.Errorf("ffmpeg error: %w, stderr: %s", err, stderr.String())
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return stderr.String(), fmt.Errorf("ffmpeg timed out after %s: %w", time.Duration(ffmpegTimeout.Load()), ctx.Err())
+		case context.Canceled:
+			return stderr.String(), ErrFFmpegCancelled
+		}
+		return stderr.String(), fmt.Errorf("ffmpeg error: %w, stderr: %s", err, stderr.String())
 	}
 
-	return nil
+	return stderr.String(), nil
 }
 
 // GetVideoDuration returns the duration of a video file in seconds
@@ -52,22 +200,71 @@ func GetAudioDuration(audioPath string) (float64, error) {
 	return GetVideoDuration(audioPath) // Same implementation
 }
 
-// MergeAudioWithCrossfade merges audio files with crossfade effect
-func MergeAudioWithCrossfade(inputFiles []string, outputFile string, crossfadeDuration float64, bitrate string) error {
+// GetStreamDuration returns the duration in seconds of the first stream of kind ("v" or "a") in
+// path, which can differ from the container's; containers that keep no stream durations, such
+// as WebM, give the container's
+func GetStreamDuration(path, kind string) (float64, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-select_streams", kind+":0",
+		"-show_entries", "stream=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe error: %w", err)
+	}
+	durationStr := strings.TrimSpace(string(output))
+	if durationStr == "" {
+		return 0, fmt.Errorf("no %s stream in %s", kind, path)
+	}
+	if durationStr == "N/A" {
+		return GetVideoDuration(path)
+	}
+	duration, err := strconv.ParseFloat(durationStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse duration: %w", err)
+	}
+	return duration, nil
+}
+
+// LoudnessTarget is the EBU R128 loudnorm target of the merged narration
+type LoudnessTarget struct {
+	Integrated float64 // LUFS
+	TruePeak   float64 // dBTP
+	LRA        float64 // loudness range, LU
+}
+
+// DefaultLoudnessTarget follows YouTube's -14 LUFS reference
+var DefaultLoudnessTarget = LoudnessTarget{Integrated: -14, TruePeak: -1.5, LRA: 11}
+
+// MergeAudioWithCrossfade merges audio files with crossfade effect, then normalizes the result to loudness
+func MergeAudioWithCrossfade(inputFiles []string, outputFile string, crossfadeDuration float64, bitrate string, sampleRate int, loudness LoudnessTarget) error {
 	if len(inputFiles) == 0 {
 		return fmt.Errorf("no input files provided")
 	}
+	if len(inputFiles) == 1 {
+		return NormalizeLoudness(inputFiles[0], outputFile, loudness, bitrate, sampleRate)
+	}
 
+	// Mix to lossless PCM first so both loudnorm passes see the same signal
+	premix := strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "_premix.wav"
+	defer os.Remove(premix)
+	if err := mixAudioFiles(inputFiles, premix, crossfadeDuration, sampleRate); err != nil {
+		return err
+	}
+	return NormalizeLoudness(premix, outputFile, loudness, bitrate, sampleRate)
+}
+
+// mixAudioFiles concatenates or crossfades inputs into a WAV file, without normalization
+func mixAudioFiles(inputFiles []string, outputFile string, crossfadeDuration float64, sampleRate int) error {
 	if len(inputFiles) == 1 {
-		// Single file - just copy with normalization
-		args := []string{
-			"-i", inputFiles[0],
-			"-af", "loudnorm",
-			"-ar", "44100",
-			"-ab", bitrate,
-			"-y", outputFile,
-		}
-		return RunFFmpegCommand(args)
+		return NewFFmpegCommand("mix narration").
+			Input(inputFiles[0]).
+			Args("-ar", fmt.Sprintf("%d", sampleRate), "-c:a", "pcm_s16le").
+			Output(outputFile).
+			Run()
 	}
 
 	// Handle large number of files by batching to avoid command line length limits
@@ -89,14 +286,14 @@ func MergeAudioWithCrossfade(inputFiles []string, outputFile string, crossfadeDu
 			tempOutput := filepath.Join(dir, fmt.Sprintf("temp_batch_%d_%s", i, filepath.Base(outputFile)))
 
 			// Recursively merge this batch
-			if err := MergeAudioWithCrossfade(batch, tempOutput, crossfadeDuration, bitrate); err != nil {
+			if err := mixAudioFiles(batch, tempOutput, crossfadeDuration, sampleRate); err != nil {
 				return fmt.Errorf("failed to merge batch %d: %w", i, err)
 			}
 			intermediateFiles = append(intermediateFiles, tempOutput)
 		}
 
 		// Final merge of intermediate files
-		err := MergeAudioWithCrossfade(intermediateFiles, outputFile, crossfadeDuration, bitrate)
+		err := mixAudioFiles(intermediateFiles, outputFile, crossfadeDuration, sampleRate)
 
 		// Cleanup intermediate files
 		for _, f := range intermediateFiles {
@@ -111,7 +308,7 @@ func MergeAudioWithCrossfade(inputFiles []string, outputFile string, crossfadeDu
 	}
 
 	// Multiple files - build complex filter
-	args := []string{}
+	cmd := NewFFmpegCommand("mix narration")
 	// Add input files (we already checked for empty files above, but let's be safe)
 	for i, file := range inputFiles {
 		if file == "" {
@@ -121,79 +318,269 @@ func MergeAudioWithCrossfade(inputFiles []string, outputFile string, crossfadeDu
 		if err != nil {
 			return fmt.Errorf("failed to get absolute path for %s: %w", file, err)
 		}
-		args = append(args, "-i", absPath)
+		cmd.Input(absPath)
 	}
 
-	// If crossfade duration is 0 or negative, use simple concat
+	var graph FilterGraph
 	if crossfadeDuration <= 0 {
-		filterParts := ""
-		for i := 0; i < len(inputFiles); i++ {
-			filterParts += fmt.Sprintf("[%d:a]", i)
+		// If crossfade duration is 0 or negative, use simple concat
+		graph = FilterGraph{Chain(streamLabels(len(inputFiles), "%d:a")...).Then(concatFilter(len(inputFiles), 0, 1)).To("aout")}
+	} else {
+		// Crossfade each input into the mix of the ones before it
+		last := "0:a"
+		for i := 1; i < len(inputFiles); i++ {
+			out := fmt.Sprintf("a%d", i)
+			if i == len(inputFiles)-1 {
+				out = "aout"
+			}
+			graph = append(graph, Chain(last, fmt.Sprintf("%d:a", i)).
+				Then(F("acrossfade", fmt.Sprintf("d=%.2f", crossfadeDuration), "c1=tri", "c2=tri")).
+				To(out))
+			last = out
 		}
-		filterParts += fmt.Sprintf("concat=n=%d:v=0:a=1[aout];[aout]loudnorm[final]", len(inputFiles))
+	}
 
-		args = append(args,
-			"-filter_complex", filterParts,
-			"-map", "[final]",
-			"-ar", "44100",
-			"-ab", bitrate,
-			"-y", outputFile,
-		)
+	return cmd.FilterComplex(graph).
+		Map("[aout]").
+		Args("-ar", fmt.Sprintf("%d", sampleRate), "-c:a", "pcm_s16le").
+		Output(outputFile).
+		Run()
+}
 
-		return RunFFmpegCommand(args)
+// streamLabels are the pad labels format ("%d:v", "v%d", ...) of the n streams 0 to n-1
+func streamLabels(n int, format string) []string {
+	labels := make([]string, n)
+	for i := range labels {
+		labels[i] = fmt.Sprintf(format, i)
 	}
+	return labels
+}
 
-	// Build filter complex for crossfade
-	filterParts := []string{}
-	lastLabel := "[0:a]"
+// concatFilter joins n segments of v video and a audio streams each
+func concatFilter(n, v, a int) Filter {
+	return F("concat", fmt.Sprintf("n=%d", n), fmt.Sprintf("v=%d", v), fmt.Sprintf("a=%d", a))
+}
 
-	for i := 1; i < len(inputFiles); i++ {
-		currentInput := fmt.Sprintf("[%d:a]", i)
-		outputLabel := fmt.Sprintf("[a%d]", i)
+// loudnormStats is the print_format=json report of a loudnorm measurement pass
+type loudnormStats struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
 
-		if i == len(inputFiles)-1 {
-			outputLabel = "[aout]"
+// parseLoudnormStats extracts the JSON report loudnorm prints at the end of stderr.
+// Silent input measures -inf, which the second pass cannot use.
+func parseLoudnormStats(stderr string) (loudnormStats, bool) {
+	var stats loudnormStats
+	start, end := strings.LastIndex(stderr, "{"), strings.LastIndex(stderr, "}")
+	if start < 0 || end < start {
+		return stats, false
+	}
+	if err := json.Unmarshal([]byte(stderr[start:end+1]), &stats); err != nil {
+		return stats, false
+	}
+	for _, v := range []string{stats.InputI, stats.InputTP, stats.InputLRA, stats.InputThresh, stats.TargetOffset} {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return stats, false
 		}
+	}
+	return stats, true
+}
+
+// NormalizeLoudness runs two-pass loudnorm: the first pass measures the input, the second applies
+// a linear gain to hit target exactly. It falls back to single-pass (dynamic) loudnorm if measuring fails.
+func NormalizeLoudness(inputFile, outputFile string, target LoudnessTarget, bitrate string, sampleRate int) error {
+	return NewFFmpegCommand("normalize loudness").
+		Input(inputFile).
+		AudioFilter(Chain().Then(measuredLoudnorm(inputFile, target))).
+		Args("-ar", fmt.Sprintf("%d", sampleRate), "-ab", bitrate).
+		Output(outputFile).
+		Run()
+}
 
-		filter := fmt.Sprintf("%s%sacrossfade=d=%.2f:c1=tri:c2=tri%s",
-			lastLabel, currentInput, crossfadeDuration, outputLabel)
-		filterParts = append(filterParts, filter)
+// NormalizeVideoLoudness is NormalizeLoudness of a video's whole soundtrack, e.g. narration,
+// music and intro/outro together; the video and subtitle streams are copied as they are
+func NormalizeVideoLoudness(inputFile, outputFile string, target LoudnessTarget, bitrate string, sampleRate int) error {
+	return NewFFmpegCommand("normalize loudness").
+		Input(inputFile).
+		Map("0:v", "0:a:0", "0:s?").
+		Args("-c:v", "copy", "-c:s", "copy").
+		AudioFilter(Chain().Then(measuredLoudnorm(inputFile, target))).
+		Args("-c:a", "aac", "-ar", fmt.Sprintf("%d", sampleRate), "-b:a", bitrate).
+		Output(outputFile).
+		Run()
+}
 
-		lastLabel = outputLabel
+// measuredLoudnorm measures the first audio stream of inputFile for target and returns the
+// linear loudnorm filter that meets it, or single-pass (dynamic) loudnorm if measuring fails
+func measuredLoudnorm(inputFile string, target LoudnessTarget) Filter {
+	filter := F("loudnorm", fmt.Sprintf("I=%.1f", target.Integrated), fmt.Sprintf("TP=%.1f", target.TruePeak), fmt.Sprintf("LRA=%.1f", target.LRA))
+
+	measure := F(filter.Name, append(append([]string(nil), filter.Args...), "print_format=json")...)
+	stderr, err := NewFFmpegCommand("measure loudness").
+		Input(inputFile, "-hide_banner", "-nostats").
+		Map("0:a:0").
+		AudioFilter(Chain().Then(measure)).
+		Args("-f", "null").
+		Output("-").
+		run()
+	if stats, ok := parseLoudnormStats(stderr); err == nil && ok {
+		filter.Args = append(filter.Args,
+			"measured_I="+stats.InputI, "measured_TP="+stats.InputTP, "measured_LRA="+stats.InputLRA,
+			"measured_thresh="+stats.InputThresh, "offset="+stats.TargetOffset, "linear=true")
+		return filter
 	}
+	fmt.Printf("[FFmpeg] Loudness measurement failed, using single-pass loudnorm\n")
+	return filter
+}
 
-	// Add loudnorm at the end
-	filterComplex := strings.Join(filterParts, ";") + ";[aout]loudnorm[final]"
+// GenerateSilence writes seconds of silent stereo audio, e.g. for pause markers in scripts
+func GenerateSilence(outputPath string, seconds float64, sampleRate int, bitrate string) error {
+	return NewFFmpegCommand("generate silence").
+		Input(F("anullsrc", fmt.Sprintf("r=%d", sampleRate), "cl=stereo").String(), "-f", "lavfi").
+		Args("-t", fmt.Sprintf("%.3f", seconds), "-c:a", "libmp3lame", "-ab", bitrate).
+		Output(outputPath).
+		Run()
+}
 
-	args = append(args,
-		"-filter_complex", filterComplex,
-		"-map", "[final]",
-		"-ar", "44100",
-		"-ab", bitrate,
-		"-y", outputFile,
-	)
+// ConcatAudioFiles joins audio files back to back, without crossfade or loudness normalization.
+// Inputs may differ in sample rate and channels (TTS chunks vs generated silence).
+func ConcatAudioFiles(inputFiles []string, outputFile string, sampleRate int, bitrate string) error {
+	if len(inputFiles) == 0 {
+		return fmt.Errorf("no input files provided")
+	}
+
+	cmd := NewFFmpegCommand("concat audio")
+	var graph FilterGraph
+	for i, file := range inputFiles {
+		cmd.Input(file)
+		graph = append(graph, Chain(fmt.Sprintf("%d:a", i)).
+			Then(F("aresample", fmt.Sprint(sampleRate)), F("aformat", "channel_layouts=stereo")).
+			To(fmt.Sprintf("a%d", i)))
+	}
+	graph = append(graph, Chain(streamLabels(len(inputFiles), "a%d")...).Then(concatFilter(len(inputFiles), 0, 1)).To("aout"))
+
+	return cmd.FilterComplex(graph).
+		Map("[aout]").
+		Args("-c:a", "libmp3lame", "-ab", bitrate).
+		Output(outputFile).
+		Run()
+}
+
+// MixBackgroundMusic lays musicPath under the narration at volume, looping the track when it is
+// shorter and cutting it at the narration's end (duration seconds) with short fades
+func MixBackgroundMusic(narrationPath, musicPath, outputPath string, volume, duration float64, bitrate string) error {
+	fadeOut := math.Min(2.0, duration/4)
+	graph := FilterGraph{
+		Chain("1:a").Then(
+			F("volume", fmt.Sprintf("%.3f", volume)),
+			F("afade", "t=in", "d=1"),
+			F("afade", "t=out", fmt.Sprintf("st=%.3f", math.Max(0, duration-fadeOut)), fmt.Sprintf("d=%.3f", fadeOut))).
+			To("music"),
+		// amix halves each input; volume=2 restores the narration level
+		Chain("0:a", "music").Then(F("amix", "inputs=2", "duration=first", "dropout_transition=0"), F("volume", "2")).To("aout"),
+	}
+	return NewFFmpegCommand("mix music").
+		Input(narrationPath).
+		Input(musicPath, "-stream_loop", "-1").
+		FilterComplex(graph).
+		Map("[aout]").
+		Args("-t", fmt.Sprintf("%.3f", duration), "-c:a", "libmp3lame", "-ab", bitrate).
+		Output(outputPath).
+		Run()
+}
+
+// xfadeTransitions are the FFmpeg xfade transition names accepted from API requests
+var xfadeTransitions = map[string]bool{
+	"fade": true, "fadeblack": true, "fadewhite": true, "dissolve": true, "distance": true,
+	"wipeleft": true, "wiperight": true, "wipeup": true, "wipedown": true,
+	"slideleft": true, "slideright": true, "slideup": true, "slidedown": true,
+	"smoothleft": true, "smoothright": true, "smoothup": true, "smoothdown": true,
+	"circleopen": true, "circleclose": true, "circlecrop": true, "rectcrop": true,
+	"radial": true, "pixelize": true,
+}
+
+// IsValidTransition reports whether name is a supported xfade transition
+func IsValidTransition(name string) bool {
+	return xfadeTransitions[name]
+}
+
+// FrameSize is the output frame of orientation: 1920x1080 ("landscape"), 1080x1920 ("portrait")
+// or 1080x1080 ("square")
+func FrameSize(orientation string) (width, height int) {
+	switch orientation {
+	case "portrait":
+		return 1080, 1920
+	case "square":
+		return 1080, 1080
+	}
+	return 1920, 1080
+}
+
+// FrameAspect is the aspect ratio of orientation as image and video APIs name it
+func FrameAspect(orientation string) string {
+	switch orientation {
+	case "portrait":
+		return "9:16"
+	case "square":
+		return "1:1"
+	}
+	return "16:9"
+}
 
-	return RunFFmpegCommand(args)
+// parseResolution splits "WxH", falling back to 1920x1080
+func parseResolution(resolution string) (width, height int) {
+	w, h, _ := strings.Cut(resolution, "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 1920, 1080
+	}
+	return width, height
+}
+
+// cropToFrame scales a clip to cover width x height and crops the overflow around the centre,
+// so 16:9 footage fills a square or vertical frame without bars
+func cropToFrame(width, height int) []Filter {
+	w, h := fmt.Sprint(width), fmt.Sprint(height)
+	return []Filter{F("scale", w, h, "force_original_aspect_ratio=increase"), F("crop", w, h, "(iw-ow)/2", "(ih-oh)/2")}
+}
+
+// padToFrame scales a clip to fit inside width x height and centres it on black bars, so
+// nothing of it is cut (intros and outros keep their logos)
+func padToFrame(width, height int) []Filter {
+	w, h := fmt.Sprint(width), fmt.Sprint(height)
+	return []Filter{F("scale", w, h, "force_original_aspect_ratio=decrease"), F("pad", w, h, "(ow-iw)/2", "(oh-ih)/2")}
+}
+
+// normalizeFrame ends a chain fitting clips to the frame so they can be joined: square pixels,
+// fps and yuv420p
+func normalizeFrame(fps int) []Filter {
+	return []Filter{F("setsar", "1"), F("fps", fmt.Sprint(fps)), F("format", "yuv420p")}
 }
 
-// MergeVideosWithTransition merges video files with transition effects
-func MergeVideosWithTransition(inputFiles []string, outputFile string, transitionDuration float64, fps int, resolution string) error {
+// MergeVideosWithTransition merges video files with transition effects. resolution ("WxH") is the
+// output frame; inputs of another shape are centre-cropped to it.
+func MergeVideosWithTransition(inputFiles []string, outputFile string, transitionType string, transitionDuration float64, fps int, resolution string) error {
 	if len(inputFiles) == 0 {
 		return fmt.Errorf("no input files provided")
 	}
+	width, height := parseResolution(resolution)
+	if transitionType == "" {
+		transitionType = "fade"
+	}
 
 	if len(inputFiles) == 1 {
 		// Single file - just re-encode
-		args := []string{
-			"-i", inputFiles[0],
-			"-c:v", "libx264",
-			"-preset", "medium",
-			"-crf", "18",
-			"-r", strconv.Itoa(fps),
-			"-s", resolution,
-			"-y", outputFile,
-		}
-		return RunFFmpegCommand(args)
+		return NewFFmpegCommand("merge clips").
+			Input(inputFiles[0]).
+			Args("-c:v", "libx264", "-preset", "medium", "-crf", "18", "-r", strconv.Itoa(fps)).
+			VideoFilter(Chain().Then(cropToFrame(width, height)...).Then(F("setsar", "1"))).
+			Output(outputFile).
+			Run()
 	}
 
 	// Get durations to calculate offsets
@@ -206,78 +593,76 @@ func MergeVideosWithTransition(inputFiles []string, outputFile string, transitio
 		durations[i] = dur
 	}
 
-	// Build filter complex
-	args := []string{}
-
-	// Add input files
-	for _, file := range inputFiles {
-		args = append(args, "-i", file)
-	}
-
-	// Build normalization and xfade transitions
-	filterParts := []string{}
-
-	// 1. Normalize all inputs first (resolution, fps, pixel format, sar)
-	// This prevents "timebase mismatch" and "main timebase" errors in xfade
-	for i := 0; i < len(inputFiles); i++ {
-		// Scale to target resolution, force generic PAR, set FPS, set pixel format
-		// [0:v]scale=1920:1080,setsar=1,fps=30,format=yuv420p[v0norm]
-		normFilter := fmt.Sprintf("[%d:v]scale=%s,setsar=1,fps=%d,format=yuv420p[v%dnorm]",
-			i, resolution, fps, i)
-		filterParts = append(filterParts, normFilter)
+	cmd := NewFFmpegCommand("merge clips")
+	var graph FilterGraph
+	for i, file := range inputFiles {
+		cmd.Input(file)
+		// 1. Normalize all inputs first (resolution, fps, pixel format, sar)
+		// This prevents "timebase mismatch" and "main timebase" errors in xfade
+		graph = append(graph, Chain(fmt.Sprintf("%d:v", i)).
+			Then(cropToFrame(width, height)...).
+			Then(normalizeFrame(fps)...).
+			To(fmt.Sprintf("v%dnorm", i)))
 	}
 
 	// 2. Apply xfade transitions
 	offset := 0.0
-	// Start with the first normalized text
-	lastLabel := "[v0norm]"
-
+	last := "v0norm"
 	for i := 1; i < len(inputFiles); i++ {
 		offset += durations[i-1] - transitionDuration
-		currentInput := fmt.Sprintf("[v%dnorm]", i)
-		outputLabel := fmt.Sprintf("[v%d]", i)
-
+		out := fmt.Sprintf("v%d", i)
 		if i == len(inputFiles)-1 {
-			outputLabel = "[vout]"
+			out = "vout"
 		}
-
-		filter := fmt.Sprintf("%s%sxfade=transition=fade:duration=%.2f:offset=%.2f%s",
-			lastLabel, currentInput, transitionDuration, offset, outputLabel)
-		filterParts = append(filterParts, filter)
-
-		lastLabel = outputLabel
+		graph = append(graph, Chain(last, fmt.Sprintf("v%dnorm", i)).
+			Then(F("xfade", "transition="+transitionType, fmt.Sprintf("duration=%.2f", transitionDuration), fmt.Sprintf("offset=%.2f", offset))).
+			To(out))
+		last = out
 	}
 
-	filterComplex := strings.Join(filterParts, ";")
-
-	args = append(args,
-		"-filter_complex", filterComplex,
-		"-map", "[vout]",
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "18",
-		"-r", strconv.Itoa(fps),
-		"-y", outputFile,
-	)
+	return cmd.FilterComplex(graph).
+		Map("[vout]").
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "18", "-r", strconv.Itoa(fps)).
+		Output(outputFile).
+		Run()
+}
 
-	return RunFFmpegCommand(args)
+// ResizeVideo re-encodes a video-only file to resolution ("WxH") and fps, centre-cropping
+// inputs of another shape
+func ResizeVideo(inputPath, outputPath, resolution string, fps int) error {
+	width, height := parseResolution(resolution)
+	return NewFFmpegCommand("resize").
+		Input(inputPath).
+		VideoFilter(Chain().Then(cropToFrame(width, height)...).Then(normalizeFrame(fps)...)).
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "18", "-an").
+		Output(outputPath).
+		Run()
 }
 
 // CombineAudioVideo combines audio and video into final output
-func CombineAudioVideo(videoPath, audioPath, outputPath string) error {
-	args := []string{
-		"-i", videoPath,
-		"-i", audioPath,
-		"-c:v", "copy",
-		"-c:a", "aac",
-		"-b:a", "192k",
-		"-map", "0:v:0",
-		"-map", "1:a:0",
-		"-shortest",
-		"-y", outputPath,
+// subtitlePath, if set, is an SRT muxed in as a mov_text track that players can toggle
+func CombineAudioVideo(videoPath, audioPath, subtitlePath, outputPath string) error {
+	cmd := NewFFmpegCommand("combine audio and video").Input(videoPath).Input(audioPath)
+	if subtitlePath != "" {
+		cmd.Input(subtitlePath)
 	}
+	cmd.Args("-c:v", "copy", "-c:a", "aac", "-b:a", "192k").Map("0:v:0", "1:a:0")
+	if subtitlePath != "" {
+		cmd.Map("2:s:0").Args("-c:s", "mov_text")
+	}
+	return cmd.Args("-shortest").Output(outputPath).Run()
+}
 
-	return RunFFmpegCommand(args)
+// AddSubtitleTrack copies inputPath with the SRT at subtitlePath as its mov_text track,
+// replacing any subtitle track it had
+func AddSubtitleTrack(inputPath, subtitlePath, outputPath string) error {
+	return NewFFmpegCommand("add subtitle track").
+		Input(inputPath).
+		Input(subtitlePath).
+		Map("0:v", "0:a?", "1:s:0").
+		Args("-c:v", "copy", "-c:a", "copy", "-c:s", "mov_text").
+		Output(outputPath).
+		Run()
 }
 
 // ExtendVideo extends video duration by freezing last frame
@@ -289,38 +674,55 @@ func ExtendVideo(inputPath, outputPath string, targetDuration float64) error {
 
 	if currentDuration >= targetDuration {
 		// Already long enough - just copy
-		args := []string{"-i", inputPath, "-c", "copy", "-y", outputPath}
-		return RunFFmpegCommand(args)
+		return NewFFmpegCommand("extend video").Input(inputPath).Args("-c", "copy").Output(outputPath).Run()
 	}
 
 	// Freeze last frame
 	freezeDuration := targetDuration - currentDuration
 
-	args := []string{
-		"-i", inputPath,
-		"-filter_complex",
-		fmt.Sprintf("[0:v]trim=duration=%.2f,setpts=PTS-STARTPTS[v1];[0:v]trim=start=%.2f,setpts=PTS-STARTPTS,tpad=stop_duration=%.2f:stop_mode=clone[v2];[v1][v2]concat=n=2:v=1:a=0[vout]",
-			currentDuration, currentDuration-0.1, freezeDuration),
-		"-map", "[vout]",
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "18",
-		"-y", outputPath,
+	graph := FilterGraph{
+		Chain("0:v").Then(F("trim", fmt.Sprintf("duration=%.2f", currentDuration)), F("setpts", "PTS-STARTPTS")).To("v1"),
+		Chain("0:v").Then(
+			F("trim", fmt.Sprintf("start=%.2f", currentDuration-0.1)),
+			F("setpts", "PTS-STARTPTS"),
+			F("tpad", fmt.Sprintf("stop_duration=%.2f", freezeDuration), "stop_mode=clone")).
+			To("v2"),
+		Chain("v1", "v2").Then(concatFilter(2, 1, 0)).To("vout"),
 	}
+	return NewFFmpegCommand("extend video").
+		Input(inputPath).
+		FilterComplex(graph).
+		Map("[vout]").
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "18").
+		Output(outputPath).
+		Run()
+}
 
-	return RunFFmpegCommand(args)
+// ConformVideo makes the video-only file at inputPath, current seconds long, exactly
+// targetDuration long: longer ones are cut without re-encoding, shorter ones hold their last
+// frame
+func ConformVideo(inputPath, outputPath string, current, targetDuration float64) error {
+	cmd := NewFFmpegCommand("conform video").Input(inputPath)
+	if current >= targetDuration {
+		return cmd.Args("-t", fmt.Sprintf("%.3f", targetDuration), "-c", "copy", "-an").Output(outputPath).Run()
+	}
+	return cmd.VideoFilter(Chain().Then(F("tpad", "stop_mode=clone", fmt.Sprintf("stop_duration=%.3f", targetDuration-current)))).
+		Args("-t", fmt.Sprintf("%.3f", targetDuration), "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-an").
+		Output(outputPath).
+		Run()
 }
 
-// TrimVideo trims video to target duration
+// TrimVideo trims video to target duration. Stream copy cuts at a keyframe, so the result can
+// run up to a GOP long; see SetAccurateTrim.
 func TrimVideo(inputPath, outputPath string, targetDuration float64) error {
-	args := []string{
-		"-i", inputPath,
-		"-t", fmt.Sprintf("%.2f", targetDuration),
-		"-c", "copy",
-		"-y", outputPath,
+	if accurateTrim.Load() {
+		return TrimVideoAccurate(inputPath, outputPath, targetDuration)
 	}
-
-	return RunFFmpegCommand(args)
+	return NewFFmpegCommand("trim video").
+		Input(inputPath).
+		Args("-t", fmt.Sprintf("%.2f", targetDuration), "-c", "copy").
+		Output(outputPath).
+		Run()
 }
 
 // ConcatVideosNoAudio concatenates video-only files (no audio stream) into one MP4.
@@ -333,10 +735,14 @@ func ConcatVideosNoAudio(inputFiles []string, outputPath string) error {
 
 	if len(inputFiles) == 1 {
 		// Single segment – just copy
-		args := []string{"-i", inputFiles[0], "-c", "copy", "-y", outputPath}
-		return RunFFmpegCommand(args)
+		return NewFFmpegCommand("concat clips").Input(inputFiles[0]).Args("-c", "copy").Output(outputPath).Run()
 	}
+	return concatCopy(inputFiles, outputPath)
+}
 
+// concatCopy joins inputFiles with the concat demuxer, copying the streams of the first, or the
+// ones of maps ("-map" values)
+func concatCopy(inputFiles []string, outputPath string, maps ...string) error {
 	// Build a concat list file
 	listPath := outputPath + "_list.txt"
 	f, err := os.Create(listPath)
@@ -355,158 +761,415 @@ func ConcatVideosNoAudio(inputFiles []string, outputPath string) error {
 	defer os.Remove(listPath)
 
 	// Use concat demuxer – fast, no re-encode when codecs match
-	args := []string{
-		"-f", "concat",
-		"-safe", "0",
-		"-i", listPath,
-		"-c", "copy",
-		"-y", outputPath,
-	}
-	return RunFFmpegCommand(args)
+	return NewFFmpegCommand("concat clips").
+		Input(listPath, "-f", "concat", "-safe", "0").
+		Map(maps...).
+		Args("-c", "copy").
+		Output(outputPath).
+		Run()
 }
 
-// ConcatVideos concatenates multiple video files with audio, normalizing them
-func ConcatVideos(inputFiles []string, outputPath string) error {
+// ConcatVideos concatenates multiple video files with audio, normalizing them to resolution ("WxH")
+// and fps. Inputs of another shape are letterboxed, centred, rather than cropped. Inputs that
+// are already alike and normalized are joined by stream copy instead (see concatCopyable).
+func ConcatVideos(inputFiles []string, outputPath, resolution string, fps int) error {
+	width, height := parseResolution(resolution)
+	if fps <= 0 {
+		fps = 30
+	}
 
 	if len(inputFiles) == 0 {
 		return fmt.Errorf("no input files provided")
 	}
 
-	// Build filter complex
-	args := []string{}
-
-	// Add input files
-	for _, file := range inputFiles {
-		args = append(args, "-i", file)
+	if probed, err := probeConcatInputs(inputFiles); err != nil {
+		log.Printf("Concat inputs not probed, re-encoding: %v", err)
+	} else if concatCopyable(probed, width, height, fps) {
+		// Soft subtitles are dropped as by the filter graph
+		return concatCopy(inputFiles, outputPath, "0:v:0", "0:a:0")
 	}
 
-	// Filter complex for normalization and concat
-	filterParts := []string{}
+	cmd := NewFFmpegCommand("concat intro/outro")
+	var graph FilterGraph
+	joined := Chain()
+	for i, file := range inputFiles {
+		cmd.Input(file)
+		// Normalize video: fit the frame, setsar 1, fps, format yuv420p; audio: 44.1 kHz stereo
+		graph = append(graph,
+			Chain(fmt.Sprintf("%d:v", i)).Then(padToFrame(width, height)...).Then(normalizeFrame(fps)...).To(fmt.Sprintf("v%d", i)),
+			Chain(fmt.Sprintf("%d:a", i)).Then(F("aformat", "sample_rates=44100", "channel_layouts=stereo")).To(fmt.Sprintf("a%d", i)))
+		joined.Inputs = append(joined.Inputs, fmt.Sprintf("v%d", i), fmt.Sprintf("a%d", i))
+	}
+	graph = append(graph, joined.Then(concatFilter(len(inputFiles), 1, 1)).To("vout", "aout"))
 
-	for i := 0; i < len(inputFiles); i++ {
-		// Normalize video: scale to 1920x1080, setsar 1, fps 30, format yuv420p
-		// Use force_original_aspect_ratio to keep aspect ratio and pad to fill
-		vNorm := fmt.Sprintf("[%d:v]scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=30,format=yuv420p[v%d]", i, i)
-		// Normalize audio: sample rate 44100, stereo
-		aNorm := fmt.Sprintf("[%d:a]aformat=sample_rates=44100:channel_layouts=stereo[a%d]", i, i)
+	return cmd.FilterComplex(graph).
+		Map("[vout]", "[aout]").
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "18", "-c:a", "aac", "-b:a", "192k").
+		Output(outputPath).
+		Run()
+}
 
-		filterParts = append(filterParts, vNorm, aNorm)
-	}
+// ExtractAudioSegment extracts a segment from an audio file
+func ExtractAudioSegment(inputPath string, startTime float64, duration float64, outputPath string) error {
+	return NewFFmpegCommand("extract audio segment").
+		Input(inputPath, "-ss", fmt.Sprintf("%.3f", startTime), "-t", fmt.Sprintf("%.3f", duration)).
+		Args("-c", "copy").
+		Output(outputPath).
+		Run()
+}
 
-	// Concat part
-	concatFilter := ""
-	for i := 0; i < len(inputFiles); i++ {
-		concatFilter += fmt.Sprintf("[v%d][a%d]", i, i)
-	}
-	concatFilter += fmt.Sprintf("concat=n=%d:v=1:a=1[vout][aout]", len(inputFiles))
+// ExtractFrame saves the video frame at atSeconds as a JPEG
+func ExtractFrame(videoPath string, atSeconds float64, outputPath string) error {
+	return NewFFmpegCommand("extract frame").
+		Input(videoPath, "-ss", fmt.Sprintf("%.3f", atSeconds)).
+		Args("-frames:v", "1", "-q:v", "3").
+		Output(outputPath).
+		Run()
+}
 
-	filterParts = append(filterParts, concatFilter)
-	filterComplex := strings.Join(filterParts, ";")
+// previewScale fits a frame's short edge to 480px, keeping the aspect ratio and even
+// dimensions, with opts such as "flags=lanczos"
+func previewScale(opts ...string) Filter {
+	return F("scale", append([]string{"'if(gt(iw,ih),-2,480)'", "'if(gt(iw,ih),480,-2)'"}, opts...)...)
+}
 
-	args = append(args,
-		"-filter_complex", filterComplex,
-		"-map", "[vout]",
-		"-map", "[aout]",
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "18",
-		"-c:a", "aac",
-		"-b:a", "192k",
-		"-y", outputPath,
-	)
+// RenderPreview encodes a small, fast-loading 480p H.264 copy of a video
+func RenderPreview(inputPath, outputPath string) error {
+	return NewFFmpegCommand("render preview").
+		Input(inputPath).
+		Map("0:v:0", "0:a?").
+		VideoFilter(Chain().Then(previewScale())).
+		Args("-c:v", "libx264", "-preset", "veryfast", "-crf", "28", "-pix_fmt", "yuv420p",
+			"-c:a", "aac", "-b:a", "96k", "-movflags", "+faststart").
+		Output(outputPath).
+		Run()
+}
 
-	return RunFFmpegCommand(args)
+// RenderPreviewGIF saves the first seconds of a video as a looping 480p GIF at 10 fps, with a
+// palette made from those frames
+func RenderPreviewGIF(inputPath, outputPath string, seconds float64) error {
+	return NewFFmpegCommand("render preview GIF").
+		Input(inputPath, "-t", fmt.Sprintf("%.3f", seconds)).
+		Args("-vf", previewGIFFilter().String(), "-loop", "0").
+		Output(outputPath).
+		Run()
 }
 
-// ExtractAudioSegment extracts a segment from an audio file
-func ExtractAudioSegment(inputPath string, startTime float64, duration float64, outputPath string) error {
-	args := []string{
-		"-ss", fmt.Sprintf("%.3f", startTime),
-		"-t", fmt.Sprintf("%.3f", duration),
-		"-i", inputPath,
-		"-c", "copy",
-		"-y", outputPath,
+// previewGIFFilter builds the -vf of RenderPreviewGIF: the frames are split to make the
+// palette from the same frames it colors
+func previewGIFFilter() FilterGraph {
+	return FilterGraph{
+		Chain().Then(F("fps", "10"), previewScale("flags=lanczos"), F("split")).To("a", "b"),
+		Chain("a").Then(F("palettegen")).To("p"),
+		Chain("b", "p").Then(F("paletteuse")),
 	}
-	return RunFFmpegCommand(args)
 }
 
+// removeSilence drops the pauses of 0.3s or more quieter than -35dB
+var removeSilence = F("silenceremove", "stop_periods=-1", "stop_duration=0.3", "stop_threshold=-35dB")
+
 // RemoveAudioSilence removes silence from an audio file to improve pacing
 func RemoveAudioSilence(inputPath, outputPath string) error {
-	args := []string{
-		"-i", inputPath,
-		"-af", "silenceremove=stop_periods=-1:stop_duration=0.3:stop_threshold=-35dB",
-		"-c:a", "libmp3lame",
-		"-q:a", "2",
-		"-y", outputPath,
+	return NewFFmpegCommand("remove silence").
+		Input(inputPath).
+		AudioFilter(Chain().Then(removeSilence)).
+		Args("-c:a", "libmp3lame", "-q:a", "2").
+		Output(outputPath).
+		Run()
+}
+
+// NormalizeAudioChunk re-encodes a TTS chunk to sampleRate stereo MP3, optionally trimming
+// silences, so chunks from different providers (or cached from older runs) can be crossfaded together
+func NormalizeAudioChunk(inputPath, outputPath string, sampleRate int, trimSilence bool) error {
+	return NewFFmpegCommand("normalize audio chunk").
+		Input(inputPath).
+		AudioFilter(chunkAudioFilter(sampleRate, trimSilence)).
+		Args("-c:a", "libmp3lame", "-q:a", "2").
+		Output(outputPath).
+		Run()
+}
+
+// chunkAudioFilter builds the -af chain of NormalizeAudioChunk
+func chunkAudioFilter(sampleRate int, trimSilence bool) *FilterChain {
+	if sampleRate <= 0 {
+		sampleRate = 44100
+	}
+	chain := Chain()
+	if trimSilence {
+		chain.Then(removeSilence)
 	}
-	return RunFFmpegCommand(args)
+	return chain.Then(
+		F("aresample", fmt.Sprint(sampleRate)),
+		F("aformat", fmt.Sprintf("sample_rates=%d", sampleRate), "channel_layouts=stereo"),
+	)
+}
+
+// ConvertAudioForASR writes 16 kHz mono PCM WAV, the input format speech recognizers expect
+func ConvertAudioForASR(inputPath, outputPath string) error {
+	return NewFFmpegCommand("convert audio for ASR").
+		Input(inputPath).
+		Args("-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le").
+		Output(outputPath).
+		Run()
 }
 
 // ImageToVideo converts a static image into a video clip with Ken Burns zoom animation.
-// duration: target video length in seconds. orientation: "portrait" or "landscape".
+// duration: target video length in seconds. orientation: "portrait", "square" or "landscape".
 func ImageToVideo(imagePath, outputPath string, duration float64, orientation string) error {
 	// Ken Burns: slow zoom from centre.
 	durationSec := int(duration) + 1
+	width, height := FrameSize(orientation)
+
+	// Fix jitter: Scale image up by 4x before zooming, then zoompan downcales it smoothly back to the frame.
+	big := []string{fmt.Sprintf("%d*4", width), fmt.Sprintf("%d*4", height)}
+	filter := Chain().Then(
+		F("scale", big[0], big[1], "force_original_aspect_ratio=increase"),
+		F("crop", big[0], big[1], "(iw-ow)/2", "(ih-oh)/2"),
+		F("zoompan", "z='min(zoom+0.0007,1.15)'", fmt.Sprintf("d=%d", durationSec*30),
+			"x='iw/2-(iw/zoom)/2'", "y='ih/2-(ih/zoom)/2'", fmt.Sprintf("s=%dx%d", width, height), "fps=30"),
+		F("eq", "contrast=1.05", "saturation=1.15", "brightness=-0.02"),
+		F("format", "yuv420p"),
+	)
 
-	var filter string
-	if orientation == "portrait" {
-		// Output 1080x1920.
-		// Fix jitter: Scale image up by 4x before zooming, then zoompan downcales it smoothly back to 1080x1920.
-		filter = fmt.Sprintf(
-			"scale=1080*4:1920*4:force_original_aspect_ratio=increase,crop=1080*4:1920*4:(iw-ow)/2:(ih-oh)/2,"+
-				"zoompan=z='min(zoom+0.0007,1.15)':d=%d:x='iw/2-(iw/zoom)/2':y='ih/2-(ih/zoom)/2':s=1080x1920:fps=30,"+
-				"eq=contrast=1.05:saturation=1.15:brightness=-0.02,format=yuv420p",
-			durationSec*30,
-		)
-	} else {
-		// Output 1920x1080.
-		filter = fmt.Sprintf(
-			"scale=1920*4:1080*4:force_original_aspect_ratio=increase,crop=1920*4:1080*4:(iw-ow)/2:(ih-oh)/2,"+
-				"zoompan=z='min(zoom+0.0007,1.15)':d=%d:x='iw/2-(iw/zoom)/2':y='ih/2-(ih/zoom)/2':s=1920x1080:fps=30,"+
-				"eq=contrast=1.05:saturation=1.15:brightness=-0.02,format=yuv420p",
-			durationSec*30,
-		)
-	}
-
-	args := []string{
-		"-loop", "1",
-		"-i", imagePath,
-		"-vf", filter,
-		"-t", fmt.Sprintf("%d", durationSec),
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "20",
-		"-an",
-		"-y", outputPath,
-	}
-	return RunFFmpegCommand(args)
-}
-
-// BurnSubtitles burns (hardcodes) subtitles from an SRT file into a video.
-// orientation: "portrait" (TikTok) or "landscape" (YouTube).
-func BurnSubtitles(inputPath, srtPath, outputPath, orientation string) error {
-	var style string
-	if orientation == "portrait" {
-		// TikTok style: Yellow text, bold, smaller, high margin to avoid UI overlap
-		style = "Fontname=Ubuntu Sans,Fontsize=18,PrimaryColour=&H0000FFFF,OutlineColour=&H00000000,BorderStyle=1,Outline=1.5,Shadow=1,Alignment=2,MarginV=80,Bold=1"
+	return NewFFmpegCommand("image to video").
+		Input(imagePath, "-loop", "1").
+		VideoFilter(filter).
+		Args("-t", fmt.Sprintf("%d", durationSec), "-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+		Output(outputPath).
+		Run()
+}
+
+// KenBurnsVideo adds a slow zoom to a video-only footage clip of duration seconds: in by
+// intensity*20% over the clip, or out from there when zoomOut is set, keeping the frame of orientation
+func KenBurnsVideo(inputPath, outputPath string, duration, intensity float64, zoomOut bool, orientation string) error {
+	width, height := FrameSize(orientation)
+	return NewFFmpegCommand("ken burns").
+		Input(inputPath).
+		VideoFilter(kenBurnsFilter(duration, intensity, zoomOut, width, height, 30)).
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+		Output(outputPath).
+		Run()
+}
+
+// kenBurnsFilter builds the -vf of KenBurnsVideo. The frame is doubled before zoompan so the
+// crop moves in sub-pixel steps instead of jittering.
+func kenBurnsFilter(duration, intensity float64, zoomOut bool, width, height, fps int) *FilterChain {
+	frames := int(math.Ceil(duration * float64(fps)))
+	if frames < 1 {
+		frames = 1
+	}
+	progress := fmt.Sprintf("on/%d", frames)
+	if zoomOut {
+		progress = fmt.Sprintf("(1-on/%d)", frames)
+	}
+	return Chain().Then(
+		F("scale", fmt.Sprint(width*2), fmt.Sprint(height*2)),
+		F("zoompan", fmt.Sprintf("z='1+%.3f*%s'", 0.2*intensity, progress), "d=1",
+			"x='iw/2-(iw/zoom)/2'", "y='ih/2-(ih/zoom)/2'", fmt.Sprintf("s=%dx%d", width, height), fmt.Sprintf("fps=%d", fps)),
+		F("setsar", "1"),
+		F("format", "yuv420p"),
+	)
+}
+
+// DrawOnScreenText captions a clip with the UTF-8 text of textFile, centred near the top of
+// the frame (subtitles sit at the bottom)
+func DrawOnScreenText(inputPath, outputPath, textFile, orientation string) error {
+	_, height := FrameSize(orientation)
+	return NewFFmpegCommand("draw on-screen text").
+		Input(inputPath).
+		VideoFilter(onScreenTextFilter(textFile, height)).
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+		Output(outputPath).
+		Run()
+}
+
+// onScreenTextFilter builds the -vf of DrawOnScreenText
+func onScreenTextFilter(textFile string, height int) *FilterChain {
+	return Chain().Then(
+		drawTextFile(textFile, "fontcolor=white", fmt.Sprintf("fontsize=%d", height/16), "x=(w-text_w)/2", "y=h/10",
+			"box=1", "boxcolor=black@0.5", fmt.Sprintf("boxborderw=%d", height/80)),
+		F("format", "yuv420p"),
+	)
+}
+
+// RenderWaveformVideo renders a video-only clip visualizing audioPath over backgroundImage (or a
+// dark background when empty). style is "waves" (showwaves) or "spectrum" (showspectrum);
+// orientation picks the frame (see FrameSize).
+func RenderWaveformVideo(audioPath, backgroundImage, outputPath, style, orientation string, fps int) error {
+	width, height := FrameSize(orientation)
+	if fps <= 0 {
+		fps = 30
+	}
+
+	cmd := NewFFmpegCommand("render waveform")
+	cardBackground(cmd, StaticCard{Background: backgroundImage}, width, height, fps)
+	return cmd.Input(audioPath).
+		FilterComplex(waveformFilter(style, width, height, fps)).
+		Map("[v]").
+		Args("-shortest", "-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+		Output(outputPath).
+		Run()
+}
+
+// waveformFilter builds the -filter_complex of RenderWaveformVideo: input 0 is the background,
+// input 1 the audio; the visualization takes the middle third of the frame
+func waveformFilter(style string, width, height, fps int) FilterGraph {
+	size := fmt.Sprintf("s=%dx%d", width, height/3)
+	viz := Chain("1:a")
+	if style == "spectrum" {
+		viz.Then(
+			F("showspectrum", size, "mode=combined", "slide=scroll", "color=intensity", "scale=cbrt"),
+			F("fps", fmt.Sprint(fps)),
+			F("format", "rgba"),
+			F("colorchannelmixer", "aa=0.85"))
 	} else {
-		// YouTube style: White text, semi-bold, smaller, standard margin
-		style = "Fontname=Ubuntu Sans,Fontsize=14,PrimaryColour=&H00FFFFFF,OutlineColour=&H00000000,BorderStyle=1,Outline=1.2,Shadow=1,Alignment=2,MarginV=40,Bold=1"
+		viz.Then(F("showwaves", size, "mode=cline", fmt.Sprintf("rate=%d", fps), "colors=white"))
 	}
+	return FilterGraph{
+		Chain("0:v").Then(cardFrame(width, height, fps)...).To("bg"),
+		viz.To("viz"),
+		Chain("bg", "viz").Then(F("overlay", "0", "(H-h)/2", "shortest=1"), F("format", "yuv420p")).To("v"),
+	}
+}
 
-	// FFmpeg subtitles filter needs specific escaping for windows/linux paths
-	// We use the simpler syntax first
-	filter := fmt.Sprintf("subtitles='%s':force_style='%s'", filepath.ToSlash(srtPath), style)
+// StaticCard is the frame RenderStaticVideo holds for the whole narration
+type StaticCard struct {
+	Background string // image path; empty fills the frame with Color
+	Color      string // ffmpeg color ("0x101018", "navy"); empty is a dark blue-grey
+	TitleFile  string // UTF-8 text file drawn in the upper third; empty draws no title
+	BodyFile   string // UTF-8 text file drawn left-aligned below the title, lines pre-wrapped; optional
+	Avatar     string // image path overlaid bottom-center at a third of the frame height; optional
+}
+
+// RenderStaticVideo renders a video-only clip of duration seconds showing card. orientation
+// picks the frame (see FrameSize).
+func RenderStaticVideo(outputPath string, duration float64, card StaticCard, orientation string, fps int) error {
+	width, height := FrameSize(orientation)
+	if fps <= 0 {
+		fps = 30
+	}
+	cmd := NewFFmpegCommand("render static card")
+	cardBackground(cmd, card, width, height, fps)
+	if card.Avatar != "" {
+		cmd.Input(card.Avatar, "-loop", "1", "-framerate", fmt.Sprintf("%d", fps))
+	}
+	return cmd.FilterComplex(staticCardFilter(card, width, height, fps)).
+		Map("[v]").
+		Args("-t", fmt.Sprintf("%.3f", duration), "-c:v", "libx264", "-preset", "medium", "-tune", "stillimage", "-crf", "20", "-an").
+		Output(outputPath).
+		Run()
+}
+
+// staticCardFilter builds the -filter_complex of RenderStaticVideo: input 0 is the background,
+// input 1 the avatar when there is one
+func staticCardFilter(card StaticCard, width, height, fps int) FilterGraph {
+	graph := FilterGraph{Chain("0:v").Then(cardFrame(width, height, fps)...).To("bg")}
+	last := "bg"
+	if card.Avatar != "" {
+		graph = append(graph,
+			Chain("1:v").Then(F("scale", "-2", fmt.Sprint(height/3))).To("avatar"),
+			Chain("bg", "avatar").Then(F("overlay", "(W-w)/2", "H-h-H/12")).To("card"))
+		last = "card"
+	}
+	if card.TitleFile != "" {
+		graph = append(graph, Chain(last).Then(drawTextFile(card.TitleFile,
+			"fontcolor=white", fmt.Sprintf("fontsize=%d", height/14), "x=(w-text_w)/2", "y=h/6", "box=1", "boxcolor=black@0.4", "boxborderw=24")).To("titled"))
+		last = "titled"
+	}
+	if card.BodyFile != "" {
+		graph = append(graph, Chain(last).Then(drawTextFile(card.BodyFile,
+			"fontcolor=white", fmt.Sprintf("fontsize=%d", height/24), fmt.Sprintf("line_spacing=%d", height/60), "x=w/10", "y=h/3")).To("body"))
+		last = "body"
+	}
+	return append(graph, Chain(last).Then(F("format", "yuv420p")).To("v"))
+}
+
+// cardFrame scales a card's background to cover the width x height frame at fps
+func cardFrame(width, height, fps int) []Filter {
+	return []Filter{
+		F("scale", fmt.Sprint(width), fmt.Sprint(height), "force_original_aspect_ratio=increase"),
+		F("crop", fmt.Sprint(width), fmt.Sprint(height)),
+		F("setsar", "1"),
+		F("fps", fmt.Sprint(fps)),
+	}
+}
+
+// drawTextFile is a drawtext filter of the text in path, with opts
+func drawTextFile(path string, opts ...string) Filter {
+	return F("drawtext", append([]string{fmt.Sprintf("textfile='%s'", filepath.ToSlash(path))}, opts...)...)
+}
+
+// cardBackground adds the ffmpeg input of card's background to cmd: its image, looped, or a
+// frame of its color
+func cardBackground(cmd *FFmpegCommand, card StaticCard, width, height, fps int) {
+	if card.Background != "" {
+		cmd.Input(card.Background, "-loop", "1", "-framerate", fmt.Sprintf("%d", fps))
+		return
+	}
+	color := card.Color
+	if color == "" {
+		color = "0x101018"
+	}
+	cmd.Input(fmt.Sprintf("color=c=%s:s=%dx%d:r=%d", color, width, height, fps), "-f", "lavfi")
+}
 
-	args := []string{
-		"-i", inputPath,
-		"-vf", filter,
-		"-c:a", "copy", // keep original audio
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "20",
-		"-y", outputPath,
+// RenderTitleCard renders a video-only clip of duration seconds showing card.TitleFile large in
+// the middle of its background, faded in from and out to black
+func RenderTitleCard(outputPath string, duration float64, card StaticCard, orientation string, fps int) error {
+	width, height := FrameSize(orientation)
+	if fps <= 0 {
+		fps = 30
 	}
+	cmd := NewFFmpegCommand("render title card")
+	cardBackground(cmd, card, width, height, fps)
+	return cmd.FilterComplex(titleCardFilter(card, width, height, fps, duration)).
+		Map("[v]").
+		Args("-t", fmt.Sprintf("%.3f", duration), "-c:v", "libx264", "-preset", "medium", "-crf", "20", "-an").
+		Output(outputPath).
+		Run()
+}
+
+// titleCardFilter builds the -filter_complex of RenderTitleCard. Fades take a sixth of the card
+// each, at most half a second.
+func titleCardFilter(card StaticCard, width, height, fps int, duration float64) FilterGraph {
+	chain := Chain("0:v").Then(cardFrame(width, height, fps)...)
+	if card.TitleFile != "" {
+		chain.Then(drawTextFile(card.TitleFile,
+			"fontcolor=white", fmt.Sprintf("fontsize=%d", height/10), "x=(w-text_w)/2", "y=(h-text_h)/2", "shadowcolor=black@0.6", "shadowx=4", "shadowy=4"))
+	}
+	fade := math.Min(0.5, duration/6)
+	chain.Then(
+		F("fade", "t=in", "st=0", fmt.Sprintf("d=%.3f", fade)),
+		F("fade", "t=out", fmt.Sprintf("st=%.3f", math.Max(0, duration-fade)), fmt.Sprintf("d=%.3f", fade)),
+		F("format", "yuv420p"))
+	return FilterGraph{chain.To("v")}
+}
 
-	return RunFFmpegCommand(args)
+// BurnSubtitles burns (hardcodes) subtitles from an ASS file (see WriteASS) into a video; the
+// file carries its own style
+func BurnSubtitles(inputPath, assPath, outputPath string) error {
+	return BurnASS(inputPath, outputPath, assPath)
+}
+
+// BurnASS burns ASS files, such as subtitles and lower thirds (see WriteLowerThirdsASS), into a
+// video in one pass, later files drawn over earlier ones
+func BurnASS(inputPath, outputPath string, assPaths ...string) error {
+	return NewFFmpegCommand("burn subtitles").
+		Input(inputPath).
+		VideoFilter(Chain().Then(assFilters(assPaths)...)).
+		Args("-c:a", "copy"). // keep original audio
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "20").
+		Output(outputPath).
+		Run()
+}
+
+// assFilters draw the ASS scripts at assPaths, in order
+func assFilters(assPaths []string) []Filter {
+	// FFmpeg filter arguments need specific escaping for windows/linux paths
+	// We use the simpler syntax first
+	filters := make([]Filter, len(assPaths))
+	for i, assPath := range assPaths {
+		filters[i] = F("ass", fmt.Sprintf("'%s'", filepath.ToSlash(assPath)))
+	}
+	return filters
 }
@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Filter is one ffmpeg filter and its arguments, e.g. F("scale", "1920", "-2") for
+// "scale=1920:-2". Arguments are written as they are; quote ones holding ':' or ','.
+type Filter struct {
+	Name string
+	Args []string
+}
+
+// F builds a Filter. An argument is a positional value or a "key=value" option.
+func F(name string, args ...string) Filter {
+	return Filter{Name: name, Args: args}
+}
+
+// String writes f as ffmpeg parses it
+func (f Filter) String() string {
+	if len(f.Args) == 0 {
+		return f.Name
+	}
+	return f.Name + "=" + strings.Join(f.Args, ":")
+}
+
+// FilterChain is filters applied one after the other, from pads Inputs to pads Outputs. A
+// chain without pads is a -vf or -af value.
+type FilterChain struct {
+	Inputs  []string // pad labels without brackets, e.g. "0:v"
+	Filters []Filter
+	Outputs []string
+}
+
+// Chain starts a FilterChain reading the pads inputs
+func Chain(inputs ...string) *FilterChain {
+	return &FilterChain{Inputs: inputs}
+}
+
+// Then appends filters to c
+func (c *FilterChain) Then(filters ...Filter) *FilterChain {
+	c.Filters = append(c.Filters, filters...)
+	return c
+}
+
+// Before inserts filter before the last filter named name, or appends it when there is none
+func (c *FilterChain) Before(name string, filter Filter) *FilterChain {
+	for i := len(c.Filters) - 1; i >= 0; i-- {
+		if c.Filters[i].Name == name {
+			c.Filters = append(c.Filters[:i], append([]Filter{filter}, c.Filters[i:]...)...)
+			return c
+		}
+	}
+	return c.Then(filter)
+}
+
+// To names the pads c writes
+func (c *FilterChain) To(outputs ...string) *FilterChain {
+	c.Outputs = outputs
+	return c
+}
+
+// String writes c as ffmpeg parses it, e.g. "[0:v]scale=1920:-2,fps=30[v]"
+func (c *FilterChain) String() string {
+	var b strings.Builder
+	for _, in := range c.Inputs {
+		b.WriteString("[" + in + "]")
+	}
+	for i, f := range c.Filters {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(f.String())
+	}
+	for _, out := range c.Outputs {
+		b.WriteString("[" + out + "]")
+	}
+	return b.String()
+}
+
+// FilterGraph is a -filter_complex: chains joined by their pad labels
+type FilterGraph []*FilterChain
+
+// String writes g as ffmpeg parses it
+func (g FilterGraph) String() string {
+	chains := make([]string, len(g))
+	for i, c := range g {
+		chains[i] = c.String()
+	}
+	return strings.Join(chains, ";")
+}
+
+// FFmpegCommand builds the arguments of one ffmpeg run, named by the pipeline step it is
+// for. Options are kept in the order they are added; the output comes last, as the run's
+// hooks (quality, hardware encoding, progress, cancelling) expect.
+type FFmpegCommand struct {
+	step    string
+	inputs  []string
+	options []string
+	output  string
+}
+
+// NewFFmpegCommand starts the command of step, e.g. "concat intro/outro"
+func NewFFmpegCommand(step string) *FFmpegCommand {
+	return &FFmpegCommand{step: step}
+}
+
+// Input adds an input, after its own options such as "-ss" or "-stream_loop"
+func (c *FFmpegCommand) Input(path string, opts ...string) *FFmpegCommand {
+	c.inputs = append(append(c.inputs, opts...), "-i", path)
+	return c
+}
+
+// VideoFilter sets the -vf of the output
+func (c *FFmpegCommand) VideoFilter(chain *FilterChain) *FFmpegCommand {
+	return c.Args("-vf", chain.String())
+}
+
+// AudioFilter sets the -af of the output
+func (c *FFmpegCommand) AudioFilter(chain *FilterChain) *FFmpegCommand {
+	return c.Args("-af", chain.String())
+}
+
+// FilterComplex sets the -filter_complex of the run
+func (c *FFmpegCommand) FilterComplex(graph FilterGraph) *FFmpegCommand {
+	return c.Args("-filter_complex", graph.String())
+}
+
+// Map adds a -map for each of specs, e.g. "[v]" or "1:a:0"
+func (c *FFmpegCommand) Map(specs ...string) *FFmpegCommand {
+	for _, spec := range specs {
+		c.options = append(c.options, "-map", spec)
+	}
+	return c
+}
+
+// Args adds output options as they are, e.g. "-c:v", "libx264"
+func (c *FFmpegCommand) Args(args ...string) *FFmpegCommand {
+	c.options = append(c.options, args...)
+	return c
+}
+
+// Output sets the file the run writes, overwriting it
+func (c *FFmpegCommand) Output(path string) *FFmpegCommand {
+	c.output = path
+	return c
+}
+
+// Build returns the ffmpeg arguments of c
+func (c *FFmpegCommand) Build() []string {
+	args := append(append([]string(nil), c.inputs...), c.options...)
+	return append(args, "-y", c.output)
+}
+
+// String is c's command line, quoted for a POSIX shell
+func (c *FFmpegCommand) String() string {
+	return commandLine(c.Build())
+}
+
+// Run runs c like RunFFmpegCommand, under its step's name in dry-run logs
+func (c *FFmpegCommand) Run() error {
+	_, err := c.run()
+	return err
+}
+
+// run is Run returning ffmpeg's stderr, where filters such as loudnorm print their reports
+func (c *FFmpegCommand) run() (string, error) {
+	return runFFmpegStep(c.step, c.Build())
+}
+
+// ffmpegDryRun makes ffmpeg runs log their command line instead of running
+var ffmpegDryRun atomic.Bool
+
+// SetFFmpegDryRun makes every ffmpeg run log the exact command line it would run, after the
+// quality and hardware encoding rewrites, and succeed without running (FFMPEG_DRY_RUN). Jobs
+// stop at the first step that reads a file ffmpeg did not write.
+func SetFFmpegDryRun(on bool) {
+	ffmpegDryRun.Store(on)
+}
+
+// ffmpegJobs maps a job's temp folder to the job's ID
+var ffmpegJobs sync.Map
+
+// SetFFmpegJob names jobID in the dry-run logs of ffmpeg runs writing under dir. Call stop
+// once the job is done.
+func SetFFmpegJob(dir, jobID string) (stop func()) {
+	dir = filepath.Clean(dir)
+	ffmpegJobs.Store(dir, jobID)
+	return func() { ffmpegJobs.Delete(dir) }
+}
+
+// ffmpegJob is the SetFFmpegJob job of the folder output is written in, or ""
+func ffmpegJob(output string) string {
+	job := ""
+	ffmpegJobs.Range(func(dir, id any) bool {
+		if isUnder(output, dir.(string)) {
+			job = id.(string)
+		}
+		return job == ""
+	})
+	return job
+}
+
+// logDryRun logs the run of args for step, "" for runs started without a name, with the job
+// of its output, the last argument, when it has one
+func logDryRun(step string, args []string) {
+	if step == "" {
+		step = "ffmpeg"
+	}
+	if len(args) > 0 {
+		if job := ffmpegJob(args[len(args)-1]); job != "" {
+			log.Printf("[FFmpeg dry run] [Job %s] %s: %s", job, step, commandLine(args))
+			return
+		}
+	}
+	log.Printf("[FFmpeg dry run] %s: %s", step, commandLine(args))
+}
+
+// commandLine is ffmpeg with args, quoted for a POSIX shell
+func commandLine(args []string) string {
+	quoted := []string{"ffmpeg"}
+	for _, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>()[]*?!#~{}") {
+			quoted = append(quoted, arg)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilterGraphString(t *testing.T) {
+	if got := F("setsar", "1").String(); got != "setsar=1" {
+		t.Errorf("Filter.String() = %q", got)
+	}
+	if got := F("hflip").String(); got != "hflip" {
+		t.Errorf("Expected a filter without arguments to have no '=', got %q", got)
+	}
+
+	graph := FilterGraph{
+		Chain("0:v").Then(F("scale", "1920", "-2"), F("fps", "30")).To("v"),
+		Chain("v", "1:v").Then(F("overlay", "x=10", "y=10")).To("out"),
+	}
+	if got, want := graph.String(), "[0:v]scale=1920:-2,fps=30[v];[v][1:v]overlay=x=10:y=10[out]"; got != want {
+		t.Errorf("FilterGraph.String() = %q, want %q", got, want)
+	}
+	if got := Chain().Then(F("hqdn3d"), F("format", "yuv420p")).String(); got != "hqdn3d,format=yuv420p" {
+		t.Errorf("Expected a chain without pads to be a plain filter list, got %q", got)
+	}
+}
+
+func TestFilterChainBefore(t *testing.T) {
+	chain := Chain().Then(F("scale", "1920", "1080"), F("fps", "30"), F("format", "yuv420p"))
+	chain.Before("scale", F("hqdn3d")).Before("format", F("unsharp"))
+	if got, want := chain.String(), "hqdn3d,scale=1920:1080,fps=30,unsharp,format=yuv420p"; got != want {
+		t.Errorf("Before() = %q, want %q", got, want)
+	}
+	if got := Chain().Then(F("fps", "30")).Before("scale", F("hqdn3d")).String(); got != "fps=30,hqdn3d" {
+		t.Errorf("Expected a filter to be appended when there is none of the name, got %q", got)
+	}
+}
+
+func TestFFmpegCommandBuild(t *testing.T) {
+	cmd := NewFFmpegCommand("test").
+		Input("a.mp4").
+		Input("logo.png", "-loop", "1").
+		FilterComplex(FilterGraph{Chain("0:v", "1:v").Then(F("overlay")).To("v")}).
+		Map("[v]", "0:a?").
+		Args("-c:a", "copy").
+		Output("out.mp4")
+	want := []string{"-i", "a.mp4", "-loop", "1", "-i", "logo.png", "-filter_complex", "[0:v][1:v]overlay[v]",
+		"-map", "[v]", "-map", "0:a?", "-c:a", "copy", "-y", "out.mp4"}
+	if got := cmd.Build(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %v, want %v", got, want)
+	}
+	if got, want := cmd.String(), "ffmpeg -i a.mp4 -loop 1 -i logo.png -filter_complex '[0:v][1:v]overlay[v]' -map '[v]' -map '0:a?' -c:a copy -y out.mp4"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestCommandLineQuoting(t *testing.T) {
+	got := commandLine([]string{"-vf", "drawtext=text='it's':x=1", "-metadata", "title=a b", "-f", "", "out dir/x.mp4"})
+	want := `ffmpeg -vf 'drawtext=text='\''it'\''s'\'':x=1' -metadata 'title=a b' -f '' 'out dir/x.mp4'`
+	if got != want {
+		t.Errorf("commandLine() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFFmpegDryRun(t *testing.T) {
+	SetFFmpegDryRun(true)
+	defer SetFFmpegDryRun(false)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	output := t.TempDir() + "/out.mp4"
+	err := NewFFmpegCommand("render test").Input("missing.mp4").Args("-c:v", "libx264").Output(output).Run()
+	if err != nil {
+		t.Fatalf("Expected a dry run to succeed, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "[FFmpeg dry run] render test: ffmpeg -i missing.mp4 -c:v libx264") {
+		t.Errorf("Expected the command line to be logged, got %q", buf.String())
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Error("Expected a dry run not to write the output")
+	}
+
+	jobDir := t.TempDir()
+	stop := SetFFmpegJob(jobDir, "job1")
+	buf.Reset()
+	if err := GenerateSilence(jobDir+"/pause.mp3", 0.5, 44100, "192k"); err != nil {
+		t.Fatalf("Expected a dry run to succeed, got %v", err)
+	}
+	if want := "[FFmpeg dry run] [Job job1] generate silence: ffmpeg -f lavfi -i anullsrc=r=44100:cl=stereo -t 0.500"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected %q logged, got %q", want, buf.String())
+	}
+	stop()
+	buf.Reset()
+	GenerateSilence(jobDir+"/pause.mp3", 0.5, 44100, "192k")
+	if strings.Contains(buf.String(), "[Job job1]") {
+		t.Errorf("Expected the job to be forgotten after stop, got %q", buf.String())
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// progressWatchers maps an output path to the watcher of the ffmpeg run that writes it
+var progressWatchers sync.Map
+
+// progressWatcher reports how far an ffmpeg run is through duration seconds of output
+type progressWatcher struct {
+	duration float64
+	report   func(done float64)
+}
+
+// WatchProgress makes ffmpeg runs writing outputPath, an output of duration seconds, report
+// progress (-progress pipe:1): report gets the fraction done, from 0 to 1, about twice a second.
+// Nothing is reported for an unknown duration. Call stop once the file is written.
+func WatchProgress(outputPath string, duration float64, report func(done float64)) (stop func()) {
+	if duration <= 0 {
+		return func() {}
+	}
+	progressWatchers.Store(outputPath, &progressWatcher{duration: duration, report: report})
+	return func() { progressWatchers.Delete(outputPath) }
+}
+
+// progressFor returns the watcher of the ffmpeg command args, whose last argument is the output
+func progressFor(args []string) *progressWatcher {
+	if len(args) == 0 {
+		return nil
+	}
+	if w, ok := progressWatchers.Load(args[len(args)-1]); ok {
+		return w.(*progressWatcher)
+	}
+	return nil
+}
+
+// progressWriter parses the key=value lines ffmpeg writes to -progress
+type progressWriter struct {
+	watcher *progressWatcher
+	line    []byte
+}
+
+// Write implements io.Writer for exec.Cmd.Stdout
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.line = append(pw.line, p...)
+	for {
+		i := bytes.IndexByte(pw.line, '\n')
+		if i < 0 {
+			break
+		}
+		pw.parse(strings.TrimSpace(string(pw.line[:i])))
+		pw.line = pw.line[i+1:]
+	}
+	return len(p), nil
+}
+
+// parse handles one progress line: out_time_us (out_time_ms in older ffmpeg, also in
+// microseconds) is how much output is written, and progress=end closes the run
+func (pw *progressWriter) parse(line string) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return
+	}
+	switch key {
+	case "out_time_us", "out_time_ms":
+		us, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || us < 0 {
+			return // "N/A" before the first frame
+		}
+		pw.watcher.report(min(1, float64(us)/1e6/pw.watcher.duration))
+	case "progress":
+		if value == "end" {
+			pw.watcher.report(1)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProgressWriter(t *testing.T) {
+	var got []float64
+	stop := WatchProgress("/tmp/job/out.mp4", 10, func(done float64) { got = append(got, done) })
+	defer stop()
+	watcher := progressFor([]string{"-i", "in.mp4", "-y", "/tmp/job/out.mp4"})
+	if watcher == nil {
+		t.Fatal("Expected the output to be watched")
+	}
+
+	pw := &progressWriter{watcher: watcher}
+	// Lines arrive in arbitrary chunks
+	for _, chunk := range []string{
+		"frame=1\nout_time_us=N/A\nprogress=continue\n",
+		"out_time_ms=2500",
+		"000\nout_time=00:00:02.500000\nprogress=continue\nout_time_us=12000000\n",
+		"progress=end\n",
+	} {
+		pw.Write([]byte(chunk))
+	}
+	if want := []float64{0.25, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Reported %v, want %v", got, want)
+	}
+
+	stop()
+	if progressFor([]string{"-y", "/tmp/job/out.mp4"}) != nil {
+		t.Error("Expected stop to end the watch")
+	}
+	WatchProgress("/tmp/job/unknown.mp4", 0, func(float64) {})
+	if progressFor([]string{"-y", "/tmp/job/unknown.mp4"}) != nil {
+		t.Error("Expected no watch of an output of unknown duration")
+	}
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -23,3 +26,172 @@ func TestFormatSRTTimestamp(t *testing.T) {
 		}
 	}
 }
+
+func TestParseLoudnormStats(t *testing.T) {
+	stderr := `[Parsed_loudnorm_0 @ 0x55d] 
+{
+	"input_i" : "-23.54",
+	"input_tp" : "-4.02",
+	"input_lra" : "6.10",
+	"input_thresh" : "-34.01",
+	"output_i" : "-14.02",
+	"output_tp" : "-1.50",
+	"output_lra" : "5.20",
+	"output_thresh" : "-24.40",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.02"
+}`
+	stats, ok := parseLoudnormStats(stderr)
+	if !ok || stats.InputI != "-23.54" || stats.InputThresh != "-34.01" || stats.TargetOffset != "0.02" {
+		t.Errorf("Unexpected stats %+v (ok=%v)", stats, ok)
+	}
+
+	silent := `{"input_i": "-inf", "input_tp": "-inf", "input_lra": "0.00", "input_thresh": "-70.00", "target_offset": "inf"}`
+	if _, ok := parseLoudnormStats(silent); ok {
+		t.Error("Silent input should not be usable for a second pass")
+	}
+	if _, ok := parseLoudnormStats("ffmpeg error"); ok {
+		t.Error("Expected no stats without a JSON report")
+	}
+}
+
+func TestChunkAudioFilter(t *testing.T) {
+	got := chunkAudioFilter(48000, true).String()
+	want := "silenceremove=stop_periods=-1:stop_duration=0.3:stop_threshold=-35dB,aresample=48000,aformat=sample_rates=48000:channel_layouts=stereo"
+	if got != want {
+		t.Errorf("chunkAudioFilter(48000, true) = %q, want %q", got, want)
+	}
+	if got := chunkAudioFilter(0, false).String(); got != "aresample=44100,aformat=sample_rates=44100:channel_layouts=stereo" {
+		t.Errorf("chunkAudioFilter(0, false) = %q", got)
+	}
+}
+
+func TestWaveformFilter(t *testing.T) {
+	got := waveformFilter("waves", 1080, 1920, 30).String()
+	want := "[0:v]scale=1080:1920:force_original_aspect_ratio=increase,crop=1080:1920,setsar=1,fps=30[bg];" +
+		"[1:a]showwaves=s=1080x640:mode=cline:rate=30:colors=white[viz];" +
+		"[bg][viz]overlay=0:(H-h)/2:shortest=1,format=yuv420p[v]"
+	if got != want {
+		t.Errorf("waveformFilter(waves) =\n%s\nwant\n%s", got, want)
+	}
+	if got := waveformFilter("spectrum", 1920, 1080, 25).String(); !strings.Contains(got, "[1:a]showspectrum=s=1920x360:") {
+		t.Errorf("Expected showspectrum visualization, got %s", got)
+	}
+}
+
+func TestStaticCardFilter(t *testing.T) {
+	got := staticCardFilter(StaticCard{}, 1920, 1080, 30).String()
+	want := "[0:v]scale=1920:1080:force_original_aspect_ratio=increase,crop=1920:1080,setsar=1,fps=30[bg];[bg]format=yuv420p[v]"
+	if got != want {
+		t.Errorf("staticCardFilter(plain) =\n%s\nwant\n%s", got, want)
+	}
+
+	got = staticCardFilter(StaticCard{Avatar: "host.png", TitleFile: "title.txt"}, 1080, 1920, 30).String()
+	for _, part := range []string{
+		"[1:v]scale=-2:640[avatar];[bg][avatar]overlay=(W-w)/2:H-h-H/12[card]",
+		"[card]drawtext=textfile='title.txt':fontcolor=white:fontsize=137:",
+		"[titled]format=yuv420p[v]",
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("Expected %q in\n%s", part, got)
+		}
+	}
+
+	got = staticCardFilter(StaticCard{TitleFile: "title.txt", BodyFile: "body.txt"}, 1920, 1080, 30).String()
+	if part := "[titled]drawtext=textfile='body.txt':fontcolor=white:fontsize=45:line_spacing=18:x=w/10:y=h/3[body];[body]format=yuv420p[v]"; !strings.HasSuffix(got, part) {
+		t.Errorf("Expected body drawtext %q in\n%s", part, got)
+	}
+}
+
+func TestTitleCardFilter(t *testing.T) {
+	got := titleCardFilter(StaticCard{TitleFile: "title.txt"}, 1920, 1080, 30, 3).String()
+	want := "[0:v]scale=1920:1080:force_original_aspect_ratio=increase,crop=1920:1080,setsar=1,fps=30" +
+		",drawtext=textfile='title.txt':fontcolor=white:fontsize=108:x=(w-text_w)/2:y=(h-text_h)/2:shadowcolor=black@0.6:shadowx=4:shadowy=4" +
+		",fade=t=in:st=0:d=0.500,fade=t=out:st=2.500:d=0.500,format=yuv420p[v]"
+	if got != want {
+		t.Errorf("titleCardFilter =\n%s\nwant\n%s", got, want)
+	}
+
+	// Short cards fade for a sixth of their length
+	got = titleCardFilter(StaticCard{}, 1080, 1920, 30, 1.2).String()
+	if part := ",fade=t=in:st=0:d=0.200,fade=t=out:st=1.000:d=0.200,format=yuv420p[v]"; !strings.HasSuffix(got, part) || strings.Contains(got, "drawtext") {
+		t.Errorf("Expected untitled fades %q in\n%s", part, got)
+	}
+}
+
+func TestOnScreenTextFilter(t *testing.T) {
+	got := onScreenTextFilter("seg_0_caption.txt", 1920).String()
+	want := "drawtext=textfile='seg_0_caption.txt':fontcolor=white:fontsize=120:x=(w-text_w)/2:y=h/10:box=1:boxcolor=black@0.5:boxborderw=24,format=yuv420p"
+	if got != want {
+		t.Errorf("onScreenTextFilter =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFrameSize(t *testing.T) {
+	tests := []struct {
+		orientation   string
+		width, height int
+		aspect        string
+	}{
+		{"", 1920, 1080, "16:9"},
+		{"landscape", 1920, 1080, "16:9"},
+		{"portrait", 1080, 1920, "9:16"},
+		{"square", 1080, 1080, "1:1"},
+	}
+	for _, tt := range tests {
+		if w, h := FrameSize(tt.orientation); w != tt.width || h != tt.height {
+			t.Errorf("FrameSize(%q) = %dx%d, want %dx%d", tt.orientation, w, h, tt.width, tt.height)
+		}
+		if got := FrameAspect(tt.orientation); got != tt.aspect {
+			t.Errorf("FrameAspect(%q) = %q, want %q", tt.orientation, got, tt.aspect)
+		}
+	}
+}
+
+func TestFrameFilters(t *testing.T) {
+	if w, h := parseResolution("1080x1080"); w != 1080 || h != 1080 {
+		t.Errorf("parseResolution(1080x1080) = %dx%d", w, h)
+	}
+	if w, h := parseResolution("junk"); w != 1920 || h != 1080 {
+		t.Errorf("Expected 1920x1080 fallback, got %dx%d", w, h)
+	}
+	if got, want := Chain().Then(cropToFrame(1080, 1080)...).String(), "scale=1080:1080:force_original_aspect_ratio=increase,crop=1080:1080:(iw-ow)/2:(ih-oh)/2"; got != want {
+		t.Errorf("cropToFrame() = %q, want %q", got, want)
+	}
+	if got, want := Chain().Then(padToFrame(1080, 1080)...).String(), "scale=1080:1080:force_original_aspect_ratio=decrease,pad=1080:1080:(ow-iw)/2:(oh-ih)/2"; got != want {
+		t.Errorf("padToFrame() = %q, want %q", got, want)
+	}
+}
+
+func TestKenBurnsFilter(t *testing.T) {
+	got := kenBurnsFilter(2, 0.5, false, 1920, 1080, 30).String()
+	want := "scale=3840:2160,zoompan=z='1+0.100*on/60':d=1:x='iw/2-(iw/zoom)/2':y='ih/2-(ih/zoom)/2':s=1920x1080:fps=30,setsar=1,format=yuv420p"
+	if got != want {
+		t.Errorf("kenBurnsFilter() =\n%s\nwant\n%s", got, want)
+	}
+	if got := kenBurnsFilter(1.01, 1, true, 1080, 1920, 30).String(); !strings.Contains(got, "z='1+0.200*(1-on/31)'") {
+		t.Errorf("Expected a zoom out over 31 frames, got %s", got)
+	}
+}
+
+func TestCancelFFmpeg(t *testing.T) {
+	jobDir := filepath.Join(t.TempDir(), "job1")
+	if n := CancelFFmpeg(jobDir); n != 0 {
+		t.Errorf("Expected nothing running, killed %d", n)
+	}
+	// Later runs of the job fail before starting ffmpeg; other jobs are unaffected
+	if err := RunFFmpegCommand([]string{"-i", "in.mp4", "-y", filepath.Join(jobDir, "output", "final.mp4")}); !errors.Is(err, ErrFFmpegCancelled) {
+		t.Errorf("Expected ErrFFmpegCancelled, got %v", err)
+	}
+	if err := RunFFmpegCommand([]string{"-i", "in.mp4", "-y", jobDir + "2/final.mp4"}); errors.Is(err, ErrFFmpegCancelled) {
+		t.Error("Expected a sibling folder not to be cancelled")
+	}
+
+	// Removing the job's folder forgets it
+	if err := CleanupJobFiles(filepath.Dir(jobDir), "job1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, cancelled := ffmpegRuns.canceled[jobDir]; cancelled {
+		t.Error("Expected the cancelled folder to be forgotten once removed")
+	}
+}
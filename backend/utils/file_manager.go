@@ -75,15 +75,27 @@ func DownloadFile(url, destPath string) error {
 // CleanupJobFiles removes all temporary files for a job
 func CleanupJobFiles(baseDir, jobID string) error {
 	jobDir := filepath.Join(baseDir, jobID)
+	ReleaseFFmpegCancel(jobDir)
 	return os.RemoveAll(jobDir)
 }
 
-// ScheduleCleanup schedules automatic cleanup after a delay
-func ScheduleCleanup(baseDir, jobID string, delay time.Duration) {
-	go func() {
-		time.Sleep(delay)
-		_ = CleanupJobFiles(baseDir, jobID)
-	}()
+// DirSize returns the total size in bytes of all regular files under path
+func DirSize(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
 }
 
 // FileExists checks if a file exists
@@ -0,0 +1,28 @@
+package utils
+
+import "fmt"
+
+// FootageCleanup denoises and sharpens source footage, e.g. low-bitrate SD stock clips that
+// look soft once upscaled to the output frame
+type FootageCleanup struct {
+	Denoise float64 // hqdn3d luma spatial strength, 0 is off; ffmpeg's default is 4
+	Sharpen float64 // unsharp luma amount, 0 is off; 0.5-1 suits upscaled SD
+}
+
+// DenoiseFilter is the hqdn3d filter of c, run on the source frames before scaling; false when
+// off. Chroma and temporal strengths keep hqdn3d's default ratios to the luma one.
+func (c FootageCleanup) DenoiseFilter() (Filter, bool) {
+	if c.Denoise <= 0 {
+		return Filter{}, false
+	}
+	d := c.Denoise
+	return F("hqdn3d", fmt.Sprintf("%.2f", d), fmt.Sprintf("%.2f", d*0.75), fmt.Sprintf("%.2f", d*1.5), fmt.Sprintf("%.2f", d*1.125)), true
+}
+
+// SharpenFilter is the unsharp filter of c, run on the scaled frames; false when off
+func (c FootageCleanup) SharpenFilter() (Filter, bool) {
+	if c.Sharpen <= 0 {
+		return Filter{}, false
+	}
+	return F("unsharp", "5", "5", fmt.Sprintf("%.2f", c.Sharpen), "5", "5", "0"), true
+}
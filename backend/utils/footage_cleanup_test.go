@@ -0,0 +1,18 @@
+package utils
+
+import "testing"
+
+func TestFootageCleanup(t *testing.T) {
+	_, denoise := (FootageCleanup{}).DenoiseFilter()
+	_, sharpen := (FootageCleanup{}).SharpenFilter()
+	if denoise || sharpen {
+		t.Error("Expected no filters when off")
+	}
+	c := FootageCleanup{Denoise: 4, Sharpen: 0.8}
+	if got, _ := c.DenoiseFilter(); got.String() != "hqdn3d=4.00:3.00:6.00:4.50" {
+		t.Errorf("DenoiseFilter() = %q, want %q", got, "hqdn3d=4.00:3.00:6.00:4.50")
+	}
+	if got, _ := c.SharpenFilter(); got.String() != "unsharp=5:5:0.80:5:5:0" {
+		t.Errorf("SharpenFilter() = %q, want %q", got, "unsharp=5:5:0.80:5:5:0")
+	}
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FFMPEG_HWACCEL choices: hardware H.264 encoders that replace libx264
+const (
+	HWAccelAuto         = "auto" // the first encoder that works on this host, else libx264
+	HWAccelNone         = "none"
+	HWAccelNVENC        = "nvenc"
+	HWAccelVAAPI        = "vaapi"
+	HWAccelVideoToolbox = "videotoolbox"
+)
+
+// DefaultVAAPIDevice is the render node VAAPI encodes on
+const DefaultVAAPIDevice = "/dev/dri/renderD128"
+
+// hwEncoders are the ffmpeg encoders of each hardware accelerator
+var hwEncoders = map[string]string{
+	HWAccelNVENC:        "h264_nvenc",
+	HWAccelVAAPI:        "h264_vaapi",
+	HWAccelVideoToolbox: "h264_videotoolbox",
+}
+
+// hwAccel is the accelerator every libx264 encode is switched to ("" for none), set by
+// ConfigureHWAccel
+var hwAccel struct {
+	sync.RWMutex
+	name   string
+	device string // VAAPI render node
+}
+
+// ConfigureHWAccel makes ffmpeg encode H.264 on mode's hardware instead of with libx264, after a
+// test encode shows it works; "auto" tries VideoToolbox (macOS), NVENC, then VAAPI. It returns the
+// accelerator in use, or "none".
+func ConfigureHWAccel(mode, vaapiDevice string) (string, error) {
+	if vaapiDevice == "" {
+		vaapiDevice = DefaultVAAPIDevice
+	}
+	var candidates []string
+	switch mode {
+	case "", HWAccelNone:
+	case HWAccelAuto:
+		if runtime.GOOS == "darwin" {
+			candidates = append(candidates, HWAccelVideoToolbox)
+		}
+		candidates = append(candidates, HWAccelNVENC, HWAccelVAAPI)
+	case HWAccelNVENC, HWAccelVAAPI, HWAccelVideoToolbox:
+		candidates = []string{mode}
+	default:
+		return HWAccelNone, fmt.Errorf("unknown FFMPEG_HWACCEL %q (expected auto, none, nvenc, vaapi or videotoolbox)", mode)
+	}
+
+	for _, name := range candidates {
+		probe := []string{
+			"-hide_banner",
+			"-f", "lavfi", "-i", "color=black:s=256x256:d=0.2",
+			"-vf", "format=yuv420p",
+			"-c:v", "libx264", "-preset", "medium", "-crf", "20",
+			"-f", "null", "-",
+		}
+		if _, err := execFFmpeg(hwEncodeArgs(probe, name, vaapiDevice)); err != nil {
+			if mode != HWAccelAuto {
+				return HWAccelNone, fmt.Errorf("%s is not usable on this host: %w", hwEncoders[name], err)
+			}
+			continue
+		}
+		hwAccel.Lock()
+		hwAccel.name, hwAccel.device = name, vaapiDevice
+		hwAccel.Unlock()
+		return name, nil
+	}
+	hwAccel.Lock()
+	hwAccel.name = ""
+	hwAccel.Unlock()
+	return HWAccelNone, nil
+}
+
+// withHWAccel rewrites a libx264 command for the configured accelerator; ok is false when
+// there is none or args encode no H.264
+func withHWAccel(args []string) ([]string, bool) {
+	hwAccel.RLock()
+	name, device := hwAccel.name, hwAccel.device
+	hwAccel.RUnlock()
+	if name == "" || !encodesSoftwareH264(args) {
+		return args, false
+	}
+	return hwEncodeArgs(args, name, device), true
+}
+
+// encodesSoftwareH264 reports whether args choose libx264
+func encodesSoftwareH264(args []string) bool {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-c:v" && args[i+1] == "libx264" {
+			return true
+		}
+	}
+	return false
+}
+
+// hwEncodeArgs replaces "-c:v libx264" and its -preset, -crf and -tune options in args with
+// accelerator name's encoder at about the same quality and speed. VAAPI also gets its device and
+// the upload of frames to it at the end of the video filter.
+func hwEncodeArgs(args []string, name, vaapiDevice string) []string {
+	preset, crf := "medium", 20
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-preset":
+			preset = args[i+1]
+		case "-crf":
+			if n, err := strconv.Atoi(args[i+1]); err == nil {
+				crf = n
+			}
+		}
+	}
+
+	var out []string
+	if name == HWAccelVAAPI {
+		out = append(out, "-vaapi_device", vaapiDevice)
+	}
+	filtered := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case i+1 < len(args) && (args[i] == "-preset" || args[i] == "-crf" || args[i] == "-tune"):
+			i++
+		case i+1 < len(args) && args[i] == "-c:v" && args[i+1] == "libx264":
+			out = append(out, hwEncoderArgs(name, preset, crf)...)
+			i++
+		case i+1 < len(args) && args[i] == "-vf" && name == HWAccelVAAPI:
+			out = append(out, "-vf", args[i+1]+",format=nv12,hwupload")
+			filtered = true
+			i++
+		case i+1 < len(args) && args[i] == "-filter_complex" && name == HWAccelVAAPI:
+			// The first mapped label is the video output; upload it under a new label
+			if label := firstMappedLabel(args); label != "" {
+				out = append(out, "-filter_complex", args[i+1]+";"+label+"format=nv12,hwupload[vhw]")
+				filtered = true
+			} else {
+				out = append(out, args[i], args[i+1])
+			}
+			i++
+		default:
+			out = append(out, args[i])
+		}
+	}
+	if name == HWAccelVAAPI {
+		if label := firstMappedLabel(args); filtered && label != "" {
+			for i := range out {
+				if out[i] == label && i > 0 && out[i-1] == "-map" {
+					out[i] = "[vhw]"
+				}
+			}
+		} else if !filtered {
+			// Before the output file, the last argument
+			output := out[len(out)-1]
+			out = append(out[:len(out)-1], "-vf", "format=nv12,hwupload", output)
+		}
+	}
+	return out
+}
+
+// hwEncoderArgs are the options of accelerator name's H.264 encoder for a libx264 preset and CRF
+func hwEncoderArgs(name, preset string, crf int) []string {
+	switch name {
+	case HWAccelNVENC:
+		// NVENC presets run from p1 (fastest) to p7 (best)
+		p := "p4"
+		switch preset {
+		case "ultrafast", "superfast", "veryfast":
+			p = "p1"
+		case "faster", "fast":
+			p = "p3"
+		case "slow":
+			p = "p6"
+		case "slower", "veryslow":
+			p = "p7"
+		}
+		return []string{"-c:v", "h264_nvenc", "-preset", p, "-rc", "vbr", "-cq", strconv.Itoa(crf), "-b:v", "0"}
+	case HWAccelVAAPI:
+		return []string{"-c:v", "h264_vaapi", "-qp", strconv.Itoa(crf)}
+	case HWAccelVideoToolbox:
+		// -q:v runs from 1 to 100, higher is better
+		return []string{"-c:v", "h264_videotoolbox", "-q:v", strconv.Itoa(min(100, max(1, 100-2*crf)))}
+	}
+	return []string{"-c:v", "libx264", "-preset", preset, "-crf", strconv.Itoa(crf)}
+}
+
+// firstMappedLabel is the first filter graph output ("[label]") args map, or ""
+func firstMappedLabel(args []string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-map" && strings.HasPrefix(args[i+1], "[") {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHWEncodeArgs(t *testing.T) {
+	simple := []string{"-i", "in.mp4", "-vf", "scale=1280:720", "-c:v", "libx264", "-preset", "ultrafast", "-crf", "18", "-an", "-y", "out.mp4"}
+	graph := []string{"-i", "a.mp4", "-i", "b.mp4", "-filter_complex", "[0:v][1:v]concat=n=2:v=1:a=0[vout]", "-map", "[vout]", "-c:v", "libx264", "-preset", "medium", "-tune", "stillimage", "-crf", "20", "-y", "out.mp4"}
+	bare := []string{"-i", "in.mp4", "-c:v", "libx264", "-crf", "20", "-y", "out.mp4"}
+
+	tests := []struct {
+		name  string
+		args  []string
+		accel string
+		want  string
+	}{
+		{"NVENC", simple, HWAccelNVENC, "-i in.mp4 -vf scale=1280:720 -c:v h264_nvenc -preset p1 -rc vbr -cq 18 -b:v 0 -an -y out.mp4"},
+		{"VideoToolbox", graph, HWAccelVideoToolbox, "-i a.mp4 -i b.mp4 -filter_complex [0:v][1:v]concat=n=2:v=1:a=0[vout] -map [vout] -c:v h264_videotoolbox -q:v 60 -y out.mp4"},
+		{"VAAPI filter", simple, HWAccelVAAPI, "-vaapi_device /dev/dri/renderD128 -i in.mp4 -vf scale=1280:720,format=nv12,hwupload -c:v h264_vaapi -qp 18 -an -y out.mp4"},
+		{"VAAPI graph", graph, HWAccelVAAPI, "-vaapi_device /dev/dri/renderD128 -i a.mp4 -i b.mp4 -filter_complex [0:v][1:v]concat=n=2:v=1:a=0[vout];[vout]format=nv12,hwupload[vhw] -map [vhw] -c:v h264_vaapi -qp 20 -y out.mp4"},
+		{"VAAPI no filter", bare, HWAccelVAAPI, "-vaapi_device /dev/dri/renderD128 -i in.mp4 -c:v h264_vaapi -qp 20 -y -vf format=nv12,hwupload out.mp4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(hwEncodeArgs(tt.args, tt.accel, DefaultVAAPIDevice), " "); got != tt.want {
+				t.Errorf("hwEncodeArgs() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithHWAccel(t *testing.T) {
+	defer func() { hwAccel.name = "" }()
+	hwAccel.name = HWAccelNVENC
+	if _, ok := withHWAccel([]string{"-i", "in.mp4", "-c", "copy", "-y", "out.mp4"}); ok {
+		t.Error("Expected a stream copy to stay as it is")
+	}
+	if _, ok := withHWAccel([]string{"-i", "in.mp4", "-c:v", "libx264", "-y", "out.mp4"}); !ok {
+		t.Error("Expected a libx264 encode to be moved to NVENC")
+	}
+	if _, err := ConfigureHWAccel("cuda", ""); err == nil {
+		t.Error("Expected an unknown accelerator to be rejected")
+	}
+	if got, err := ConfigureHWAccel(HWAccelNone, ""); err != nil || got != HWAccelNone || hwAccel.name != "" {
+		t.Errorf("ConfigureHWAccel(none) = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lower third animations
+const (
+	LowerThirdFade  = "fade"  // fades in and out (default)
+	LowerThirdSlide = "slide" // slides in from the left edge, fades out
+	LowerThirdNone  = "none"
+)
+
+// lowerThirdFadeMs is how long lower thirds take to appear and disappear
+const lowerThirdFadeMs = 300
+
+// LowerThirdStyle is how lower thirds look. Sizes are in pixels of the 1080p frame of the
+// orientation, as in ASSStyle.
+type LowerThirdStyle struct {
+	Font         string
+	Size         int    // of the title; the second line is 60% of it
+	PrimaryColor string // "#RRGGBB"
+	BoxColor     string // "#RRGGBB", drawn 40% transparent behind the text
+	MarginV      int    // distance from the bottom edge, above the subtitles
+	Animation    string // LowerThirdFade, LowerThirdSlide or LowerThirdNone
+}
+
+// DefaultLowerThirdStyle is a white title on a dark box at the bottom left of the frame, clear
+// of DefaultASSStyle's captions
+func DefaultLowerThirdStyle(orientation string) LowerThirdStyle {
+	if orientation == "portrait" {
+		return LowerThirdStyle{Font: "Ubuntu Sans", Size: 72, PrimaryColor: "#FFFFFF", BoxColor: "#000000", MarginV: 800, Animation: LowerThirdFade}
+	}
+	return LowerThirdStyle{Font: "Ubuntu Sans", Size: 48, PrimaryColor: "#FFFFFF", BoxColor: "#000000", MarginV: 280, Animation: LowerThirdFade}
+}
+
+// LowerThirdCue is one lower third and when it is shown, in seconds
+type LowerThirdCue struct {
+	Start    float64
+	End      float64
+	Text     string // e.g. a speaker's name or the segment's title
+	Subtitle string // optional smaller second line, e.g. the speaker's role
+}
+
+// WriteLowerThirdsASS writes cues as an ASS file of one "LowerThird" style, bottom left on a
+// canvas the size of orientation's frame, animated in and out as style.Animation says
+func WriteLowerThirdsASS(path string, style LowerThirdStyle, orientation string, cues []LowerThirdCue) error {
+	primary, err := ASSColor(style.PrimaryColor)
+	if err != nil {
+		return fmt.Errorf("invalid primary color: %w", err)
+	}
+	box, err := ASSColor(style.BoxColor)
+	if err != nil {
+		return fmt.Errorf("invalid box color: %w", err)
+	}
+	width, height := FrameSize(orientation)
+	marginH := assMarginH(width)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Script Info]\nScriptType: v4.00+\nPlayResX: %d\nPlayResY: %d\nWrapStyle: 2\nScaledBorderAndShadow: yes\n\n", width, height)
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	// BorderStyle 3 draws a box padded by Outline; alignment 1 is bottom left
+	fmt.Fprintf(&b, "Style: LowerThird,%s,%d,%s,%s,%s,&H66%s,-1,0,0,0,100,100,0,0,3,%g,0,1,%d,%d,%d,1\n\n",
+		style.Font, style.Size, primary, primary, "&H66"+box[4:], box[4:], float64(style.Size)/4, marginH, marginH, style.MarginV)
+	b.WriteString("[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, cue := range cues {
+		text := assText(cue.Text)
+		if sub := strings.TrimSpace(cue.Subtitle); sub != "" {
+			text += fmt.Sprintf(`\N{\b0\fs%d}%s`, style.Size*3/5, assText(sub))
+		}
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,LowerThird,,0,0,0,,%s%s\n", FormatASSTimestamp(cue.Start), FormatASSTimestamp(cue.End), lowerThirdAnimation(style, width, height, marginH), text)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// lowerThirdAnimation is the override block that animates a lower third in and out
+func lowerThirdAnimation(style LowerThirdStyle, width, height, marginH int) string {
+	switch style.Animation {
+	case LowerThirdNone:
+		return ""
+	case LowerThirdSlide:
+		// \move positions the bottom-left corner; start a frame's width off the left edge
+		y := height - style.MarginV
+		return fmt.Sprintf(`{\move(%d,%d,%d,%d,0,%d)\fad(0,%d)}`, marginH-width, y, marginH, y, lowerThirdFadeMs+100, lowerThirdFadeMs)
+	}
+	return fmt.Sprintf(`{\fad(%d,%d)}`, lowerThirdFadeMs, lowerThirdFadeMs)
+}
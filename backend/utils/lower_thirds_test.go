@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteLowerThirdsASS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lower_thirds.ass")
+	style := DefaultLowerThirdStyle("landscape")
+	cues := []LowerThirdCue{{Start: 2, End: 7, Text: "Jane {Doe}", Subtitle: "Host"}, {Start: 30, End: 35, Text: "Chapter two"}}
+	if err := WriteLowerThirdsASS(path, style, "landscape", cues); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+	for _, want := range []string{
+		"PlayResX: 1920\nPlayResY: 1080",
+		"Style: LowerThird,Ubuntu Sans,48,&H00FFFFFF,&H00FFFFFF,&H66000000,&H66000000,-1,0,0,0,100,100,0,0,3,12,0,1,",
+		`Dialogue: 0,0:00:02.00,0:00:07.00,LowerThird,,0,0,0,,{\fad(300,300)}Jane (Doe)\N{\b0\fs28}Host` + "\n",
+		`Dialogue: 0,0:00:30.00,0:00:35.00,LowerThird,,0,0,0,,{\fad(300,300)}Chapter two` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	style.Animation = LowerThirdSlide
+	if err := WriteLowerThirdsASS(path, style, "landscape", cues[1:]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	marginH := assMarginH(1920)
+	if want := `{\move(` + strconv.Itoa(marginH-1920) + ",800," + strconv.Itoa(marginH) + `,800,0,400)\fad(0,300)}Chapter two`; !strings.Contains(string(data), want) {
+		t.Errorf("expected %q in:\n%s", want, data)
+	}
+
+	style.Animation = LowerThirdNone
+	if err := WriteLowerThirdsASS(path, style, "landscape", cues[1:]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ = os.ReadFile(path); !strings.Contains(string(data), ",LowerThird,,0,0,0,,Chapter two\n") {
+		t.Errorf("expected no animation in:\n%s", data)
+	}
+
+	style.BoxColor = "navy"
+	if err := WriteLowerThirdsASS(path, style, "landscape", cues); err == nil {
+		t.Error("expected an invalid box color to be rejected")
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// MediaInfo is what ffprobe reports of a media file, for clients to check a finished video
+// against a platform's specs
+type MediaInfo struct {
+	Format     string        `json:"format"` // ffprobe's demuxer names, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration   float64       `json:"duration"`
+	Size       int64         `json:"size"`     // bytes
+	BitRate    int64         `json:"bit_rate"` // bits per second, all streams
+	Resolution string        `json:"resolution,omitempty"`
+	Streams    []MediaStream `json:"streams"`
+}
+
+// MediaStream is one stream of a MediaInfo; fields that do not apply to its type are omitted
+type MediaStream struct {
+	Index         int     `json:"index"`
+	Type          string  `json:"type"` // "video", "audio", "subtitle", ...
+	Codec         string  `json:"codec"`
+	Profile       string  `json:"profile,omitempty"`
+	Duration      float64 `json:"duration,omitempty"`
+	BitRate       int64   `json:"bit_rate,omitempty"`
+	Width         int     `json:"width,omitempty"`
+	Height        int     `json:"height,omitempty"`
+	FrameRate     float64 `json:"frame_rate,omitempty"`
+	PixelFormat   string  `json:"pixel_format,omitempty"`
+	SampleRate    int     `json:"sample_rate,omitempty"`
+	Channels      int     `json:"channels,omitempty"`
+	ChannelLayout string  `json:"channel_layout,omitempty"`
+	Language      string  `json:"language,omitempty"`
+}
+
+// ProbeMediaInfo runs ffprobe on path
+func ProbeMediaInfo(path string) (*MediaInfo, error) {
+	output, err := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_format",
+		"-show_streams",
+		"-of", "json",
+		path,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %w", err)
+	}
+	return parseMediaInfo(output)
+}
+
+// parseMediaInfo reads ffprobe's -show_format -show_streams JSON, which quotes most numbers
+func parseMediaInfo(output []byte) (*MediaInfo, error) {
+	var probe struct {
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+			Size       string `json:"size"`
+			BitRate    string `json:"bit_rate"`
+		} `json:"format"`
+		Streams []struct {
+			Index         int               `json:"index"`
+			CodecType     string            `json:"codec_type"`
+			CodecName     string            `json:"codec_name"`
+			Profile       string            `json:"profile"`
+			Duration      string            `json:"duration"`
+			BitRate       string            `json:"bit_rate"`
+			Width         int               `json:"width"`
+			Height        int               `json:"height"`
+			FrameRate     string            `json:"avg_frame_rate"`
+			PixFmt        string            `json:"pix_fmt"`
+			SampleRate    string            `json:"sample_rate"`
+			Channels      int               `json:"channels"`
+			ChannelLayout string            `json:"channel_layout"`
+			Tags          map[string]string `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &MediaInfo{
+		Format:   probe.Format.FormatName,
+		Duration: parseProbeFloat(probe.Format.Duration),
+		Size:     parseProbeInt(probe.Format.Size),
+		BitRate:  parseProbeInt(probe.Format.BitRate),
+		Streams:  []MediaStream{},
+	}
+	for _, st := range probe.Streams {
+		stream := MediaStream{
+			Index:         st.Index,
+			Type:          st.CodecType,
+			Codec:         st.CodecName,
+			Profile:       st.Profile,
+			Duration:      parseProbeFloat(st.Duration),
+			BitRate:       parseProbeInt(st.BitRate),
+			Width:         st.Width,
+			Height:        st.Height,
+			PixelFormat:   st.PixFmt,
+			SampleRate:    int(parseProbeInt(st.SampleRate)),
+			Channels:      st.Channels,
+			ChannelLayout: st.ChannelLayout,
+			Language:      st.Tags["language"],
+		}
+		if rate, ok := parseFrameRate(st.FrameRate); ok && st.CodecType == "video" {
+			stream.FrameRate = rate
+		}
+		if stream.Language == "und" {
+			stream.Language = ""
+		}
+		if info.Resolution == "" && st.CodecType == "video" && st.Width > 0 {
+			info.Resolution = fmt.Sprintf("%dx%d", st.Width, st.Height)
+		}
+		info.Streams = append(info.Streams, stream)
+	}
+	return info, nil
+}
+
+// parseProbeFloat parses a quoted ffprobe number, 0 for "N/A" and missing values
+func parseProbeFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// parseProbeInt is parseProbeFloat for integers
+func parseProbeInt(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
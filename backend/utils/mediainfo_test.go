@@ -0,0 +1,39 @@
+package utils
+
+import "testing"
+
+func TestParseMediaInfo(t *testing.T) {
+	output := []byte(`{
+		"streams": [
+			{"index": 0, "codec_name": "h264", "profile": "High", "codec_type": "video", "width": 1920, "height": 1080, "pix_fmt": "yuv420p", "avg_frame_rate": "30/1", "duration": "61.500000", "bit_rate": "4800000", "tags": {"language": "und"}},
+			{"index": 1, "codec_name": "aac", "profile": "LC", "codec_type": "audio", "sample_rate": "44100", "channels": 2, "channel_layout": "stereo", "avg_frame_rate": "0/0", "duration": "61.480000", "bit_rate": "192000", "tags": {"language": "eng"}},
+			{"index": 2, "codec_name": "mov_text", "codec_type": "subtitle", "avg_frame_rate": "0/0", "duration": "N/A"}
+		],
+		"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "61.500000", "size": "38000000", "bit_rate": "4943089"}
+	}`)
+	info, err := parseMediaInfo(output)
+	if err != nil {
+		t.Fatalf("parseMediaInfo() error: %v", err)
+	}
+	if info.Format != "mov,mp4,m4a,3gp,3g2,mj2" || info.Duration != 61.5 || info.Size != 38000000 || info.BitRate != 4943089 || info.Resolution != "1920x1080" {
+		t.Errorf("Unexpected format info %+v", info)
+	}
+	if len(info.Streams) != 3 {
+		t.Fatalf("Expected 3 streams, got %d", len(info.Streams))
+	}
+	video := MediaStream{Index: 0, Type: "video", Codec: "h264", Profile: "High", Duration: 61.5, BitRate: 4800000, Width: 1920, Height: 1080, FrameRate: 30, PixelFormat: "yuv420p"}
+	if info.Streams[0] != video {
+		t.Errorf("video stream:\n got %+v\nwant %+v", info.Streams[0], video)
+	}
+	audio := MediaStream{Index: 1, Type: "audio", Codec: "aac", Profile: "LC", Duration: 61.48, BitRate: 192000, SampleRate: 44100, Channels: 2, ChannelLayout: "stereo", Language: "eng"}
+	if info.Streams[1] != audio {
+		t.Errorf("audio stream:\n got %+v\nwant %+v", info.Streams[1], audio)
+	}
+	if s := info.Streams[2]; s.Type != "subtitle" || s.Duration != 0 {
+		t.Errorf("Unexpected subtitle stream %+v", s)
+	}
+
+	if _, err := parseMediaInfo([]byte("not json")); err == nil {
+		t.Error("Expected an error for output that is not JSON")
+	}
+}
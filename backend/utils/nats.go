@@ -0,0 +1,414 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSMsg is a message delivered to a subscription
+type NATSMsg struct {
+	Subject string
+	Reply   string
+	Data    []byte
+}
+
+// NATSConn is a minimal client for the NATS core text protocol (PUB/SUB/UNSUB, queue groups,
+// request-reply). There is no automatic reconnect: callers watch Done() and restart.
+type NATSConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+	wmu  sync.Mutex
+
+	subsMu  sync.Mutex
+	subs    map[int64]*NATSSubscription
+	nextSID int64
+
+	inbox      string // per-connection reply prefix, e.g. _INBOX.3f2a...
+	respMu     sync.Mutex
+	resp       map[string]chan []byte
+	respSubbed bool
+
+	pongs chan struct{}
+	done  chan struct{}
+	once  sync.Once
+	err   error
+}
+
+// NATSSubscription is an active subscription; handlers run in order on a dedicated goroutine
+type NATSSubscription struct {
+	sid     int64
+	subject string
+	queue   string
+	handler func(*NATSMsg)
+	msgs    chan *NATSMsg
+	nc      *NATSConn
+	once    sync.Once
+}
+
+// natsSubscriptionBuffer is the per-subscription delivery queue; the reader blocks when it is full
+const natsSubscriptionBuffer = 1024
+
+// ConnectNATS dials a nats://[user:pass@|token@]host:port URL and completes the handshake
+func ConnectNATS(rawURL, name string) (*NATSConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", host, err)
+	}
+
+	nc := &NATSConn{
+		conn:  conn,
+		r:     bufio.NewReader(conn),
+		w:     bufio.NewWriter(conn),
+		subs:  make(map[int64]*NATSSubscription),
+		inbox: "_INBOX." + randomHex(8),
+		resp:  make(map[string]chan []byte),
+		pongs: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+
+	// Server greets with INFO {...}
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	line, err := nc.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected NATS greeting %q: %v", strings.TrimSpace(line), err)
+	}
+
+	opts := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"lang":     "go",
+		"version":  "aituber",
+		"protocol": 1,
+		"name":     name,
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts["user"] = u.User.Username()
+			opts["pass"] = pass
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+	connectJSON, _ := json.Marshal(opts)
+	if err := nc.writeRaw("CONNECT " + string(connectJSON) + "\r\nPING\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// The server answers PING with PONG, or -ERR if CONNECT was rejected
+	line, err = nc.r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake failed: %w", err)
+	}
+	if strings.HasPrefix(line, "-ERR") {
+		conn.Close()
+		return nil, fmt.Errorf("NATS rejected connection: %s", strings.TrimSpace(line))
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	go nc.readLoop()
+	return nc, nil
+}
+
+// Publish sends data to subject
+func (nc *NATSConn) Publish(subject string, data []byte) error {
+	return nc.publish(subject, "", data)
+}
+
+// Subscribe delivers messages on subject (wildcards "*" and ">" allowed) to handler
+func (nc *NATSConn) Subscribe(subject string, handler func(*NATSMsg)) (*NATSSubscription, error) {
+	return nc.QueueSubscribe(subject, "", handler)
+}
+
+// QueueSubscribe joins a queue group: each message goes to only one member of the group
+func (nc *NATSConn) QueueSubscribe(subject, queue string, handler func(*NATSMsg)) (*NATSSubscription, error) {
+	nc.subsMu.Lock()
+	nc.nextSID++
+	sub := &NATSSubscription{
+		sid:     nc.nextSID,
+		subject: subject,
+		queue:   queue,
+		handler: handler,
+		msgs:    make(chan *NATSMsg, natsSubscriptionBuffer),
+		nc:      nc,
+	}
+	nc.subs[sub.sid] = sub
+	nc.subsMu.Unlock()
+
+	go sub.deliver()
+
+	cmd := fmt.Sprintf("SUB %s %d\r\n", subject, sub.sid)
+	if queue != "" {
+		cmd = fmt.Sprintf("SUB %s %s %d\r\n", subject, queue, sub.sid)
+	}
+	if err := nc.writeRaw(cmd); err != nil {
+		sub.stop()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Request publishes data with a reply inbox and waits for the first response
+func (nc *NATSConn) Request(subject string, data []byte, timeout time.Duration) ([]byte, error) {
+	if err := nc.ensureResponseSub(); err != nil {
+		return nil, err
+	}
+
+	token := randomHex(8)
+	ch := make(chan []byte, 1)
+	nc.respMu.Lock()
+	nc.resp[token] = ch
+	nc.respMu.Unlock()
+	defer func() {
+		nc.respMu.Lock()
+		delete(nc.resp, token)
+		nc.respMu.Unlock()
+	}()
+
+	if err := nc.publish(subject, nc.inbox+"."+token, data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply := <-ch:
+		return reply, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no reply on %s within %v", subject, timeout)
+	case <-nc.done:
+		return nil, nc.closedErr()
+	}
+}
+
+// Flush round-trips a PING so everything written so far has been processed by the server
+func (nc *NATSConn) Flush(timeout time.Duration) error {
+	if err := nc.writeRaw("PING\r\n"); err != nil {
+		return err
+	}
+	select {
+	case <-nc.pongs:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("NATS flush timed out")
+	case <-nc.done:
+		return nc.closedErr()
+	}
+}
+
+// Done is closed when the connection is lost or closed
+func (nc *NATSConn) Done() <-chan struct{} {
+	return nc.done
+}
+
+// Close terminates the connection
+func (nc *NATSConn) Close() error {
+	nc.shutdown(errors.New("connection closed"))
+	return nil
+}
+
+// Unsubscribe stops delivery for this subscription
+func (sub *NATSSubscription) Unsubscribe() error {
+	sub.stop()
+	return sub.nc.writeRaw(fmt.Sprintf("UNSUB %d\r\n", sub.sid))
+}
+
+func (sub *NATSSubscription) stop() {
+	sub.once.Do(func() {
+		sub.nc.subsMu.Lock()
+		delete(sub.nc.subs, sub.sid)
+		sub.nc.subsMu.Unlock()
+		close(sub.msgs)
+	})
+}
+
+func (sub *NATSSubscription) deliver() {
+	for msg := range sub.msgs {
+		sub.handler(msg)
+	}
+}
+
+func (nc *NATSConn) ensureResponseSub() error {
+	nc.respMu.Lock()
+	defer nc.respMu.Unlock()
+	if nc.respSubbed {
+		return nil
+	}
+	prefix := nc.inbox + "."
+	_, err := nc.Subscribe(prefix+"*", func(msg *NATSMsg) {
+		token := strings.TrimPrefix(msg.Subject, prefix)
+		nc.respMu.Lock()
+		ch, ok := nc.resp[token]
+		nc.respMu.Unlock()
+		if ok {
+			select {
+			case ch <- msg.Data:
+			default:
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	nc.respSubbed = true
+	return nil
+}
+
+func (nc *NATSConn) publish(subject, reply string, data []byte) error {
+	nc.wmu.Lock()
+	defer nc.wmu.Unlock()
+	select {
+	case <-nc.done:
+		return nc.closedErr()
+	default:
+	}
+
+	if reply != "" {
+		fmt.Fprintf(nc.w, "PUB %s %s %d\r\n", subject, reply, len(data))
+	} else {
+		fmt.Fprintf(nc.w, "PUB %s %d\r\n", subject, len(data))
+	}
+	nc.w.Write(data)
+	nc.w.WriteString("\r\n")
+	return nc.w.Flush()
+}
+
+func (nc *NATSConn) writeRaw(s string) error {
+	nc.wmu.Lock()
+	defer nc.wmu.Unlock()
+	select {
+	case <-nc.done:
+		return nc.closedErr()
+	default:
+	}
+	if _, err := nc.w.WriteString(s); err != nil {
+		return err
+	}
+	return nc.w.Flush()
+}
+
+// readLoop parses server operations until the connection drops
+func (nc *NATSConn) readLoop() {
+	for {
+		line, err := nc.r.ReadString('\n')
+		if err != nil {
+			nc.shutdown(fmt.Errorf("NATS connection lost: %w", err))
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			if err := nc.handleMsg(line); err != nil {
+				nc.shutdown(err)
+				return
+			}
+		case line == "PING":
+			nc.writeRaw("PONG\r\n")
+		case line == "PONG":
+			select {
+			case nc.pongs <- struct{}{}:
+			default:
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("[NATS] Server error: %s", line)
+		}
+		// +OK and INFO updates need no action
+	}
+}
+
+// handleMsg reads the payload of "MSG <subject> <sid> [reply-to] <#bytes>" and routes it
+func (nc *NATSConn) handleMsg(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) != 4 && len(fields) != 5 {
+		return fmt.Errorf("malformed NATS MSG: %q", line)
+	}
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return fmt.Errorf("malformed NATS MSG size: %q", line)
+	}
+	sid, _ := strconv.ParseInt(fields[2], 10, 64)
+
+	payload := make([]byte, size+2) // payload + CRLF
+	if _, err := readFull(nc.r, payload); err != nil {
+		return fmt.Errorf("NATS connection lost: %w", err)
+	}
+
+	msg := &NATSMsg{Subject: fields[1], Data: payload[:size]}
+	if len(fields) == 5 {
+		msg.Reply = fields[3]
+	}
+
+	nc.subsMu.Lock()
+	sub, ok := nc.subs[sid]
+	if ok {
+		// Send under the lock so stop() can't close the channel mid-send
+		sub.msgs <- msg
+	}
+	nc.subsMu.Unlock()
+	return nil
+}
+
+func (nc *NATSConn) shutdown(err error) {
+	nc.once.Do(func() {
+		nc.err = err
+		close(nc.done)
+		nc.conn.Close()
+
+		nc.subsMu.Lock()
+		subs := make([]*NATSSubscription, 0, len(nc.subs))
+		for _, sub := range nc.subs {
+			subs = append(subs, sub)
+		}
+		nc.subsMu.Unlock()
+		for _, sub := range subs {
+			sub.stop()
+		}
+	})
+}
+
+func (nc *NATSConn) closedErr() error {
+	if nc.err != nil {
+		return nc.err
+	}
+	return errors.New("NATS connection closed")
+}
+
+func readFull(r *bufio.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
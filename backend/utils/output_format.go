@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Output containers and video codecs of GenerateRequest.OutputFormat and VideoCodec
+const (
+	ContainerMP4  = "mp4"
+	ContainerWebM = "webm"
+	ContainerMOV  = "mov"
+
+	CodecH264 = "h264"
+	CodecH265 = "h265"
+	CodecVP9  = "vp9"
+	CodecAV1  = "av1"
+)
+
+// containerCodecs lists the video codecs each container may hold, its default first
+var containerCodecs = map[string][]string{
+	ContainerMP4:  {CodecH264, CodecH265, CodecAV1},
+	ContainerMOV:  {CodecH264, CodecH265},
+	ContainerWebM: {CodecVP9, CodecAV1},
+}
+
+// OutputFormat is the container and video codec of a finished video. The pipeline renders
+// H.264 MP4; other formats are encoded from it by TranscodeVideo.
+type OutputFormat struct {
+	Container string
+	Codec     string
+}
+
+// ResolveOutputFormat validates container and codec, filling in mp4 and the container's default
+// codec (h264, or vp9 for webm) when they are empty
+func ResolveOutputFormat(container, codec string) (OutputFormat, error) {
+	container, codec = strings.ToLower(container), strings.ToLower(codec)
+	if container == "" {
+		container = ContainerMP4
+	}
+	codecs, ok := containerCodecs[container]
+	if !ok {
+		return OutputFormat{}, fmt.Errorf("unsupported output_format %q (expected mp4, webm or mov)", container)
+	}
+	if codec == "" {
+		return OutputFormat{Container: container, Codec: codecs[0]}, nil
+	}
+	for _, c := range codecs {
+		if c == codec {
+			return OutputFormat{Container: container, Codec: codec}, nil
+		}
+	}
+	return OutputFormat{}, fmt.Errorf("video_codec %q is not supported in %s (expected %s)", codec, container, strings.Join(codecs, ", "))
+}
+
+// IsDefault reports whether f is the H.264 MP4 the pipeline renders anyway
+func (f OutputFormat) IsDefault() bool {
+	return f.Container == ContainerMP4 && f.Codec == CodecH264
+}
+
+// Ext is the file extension of f's container, e.g. ".webm"
+func (f OutputFormat) Ext() string {
+	return "." + f.Container
+}
+
+// VideoContentType is the MIME type of a finished video, by the extension of path
+func VideoContentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".webm":
+		return "video/webm"
+	case ".mov":
+		return "video/quicktime"
+	}
+	return "video/mp4"
+}
+
+// videoArgs are the ffmpeg encoder arguments of f's codec, at about the quality of the
+// pipeline's CRF 18 H.264
+func (f OutputFormat) videoArgs() []string {
+	switch f.Codec {
+	case CodecH265:
+		// hvc1 is the tag Apple players require
+		return []string{"-c:v", "libx265", "-preset", "medium", "-crf", "22", "-tag:v", "hvc1"}
+	case CodecVP9:
+		return []string{"-c:v", "libvpx-vp9", "-crf", "30", "-b:v", "0", "-row-mt", "1"}
+	case CodecAV1:
+		return []string{"-c:v", "libsvtav1", "-preset", "8", "-crf", "30"}
+	}
+	return []string{"-c:v", "libx264", "-preset", "medium", "-crf", "18"}
+}
+
+// subtitleCodec is the soft subtitle format f's container holds
+func (f OutputFormat) subtitleCodec() string {
+	if f.Container == ContainerWebM {
+		return "webvtt"
+	}
+	return "mov_text"
+}
+
+// TranscodeVideo encodes the H.264/AAC MP4 at inputPath into format at outputPath, keeping its
+// subtitle track if it has one
+func TranscodeVideo(inputPath, outputPath string, format OutputFormat) error {
+	return transcodeCommand(inputPath, outputPath, format).Run()
+}
+
+// transcodeCommand is TranscodeVideo's ffmpeg run. H.264 is copied, as is AAC outside WebM,
+// which takes Opus.
+func transcodeCommand(inputPath, outputPath string, format OutputFormat) *FFmpegCommand {
+	cmd := NewFFmpegCommand("transcode").Input(inputPath).Map("0:v:0", "0:a?", "0:s?")
+	if format.Codec == CodecH264 {
+		cmd.Args("-c:v", "copy")
+	} else {
+		cmd.Args(format.videoArgs()...)
+	}
+	return cmd.Args(format.outputArgs()...).Output(outputPath)
+}
+
+// outputArgs end a transcode into f: the audio and subtitle codecs
+func (f OutputFormat) outputArgs() []string {
+	var args []string
+	if f.Container == ContainerWebM {
+		args = append(args, "-c:a", "libopus", "-b:a", "192k")
+	} else {
+		args = append(args, "-c:a", "copy", "-movflags", "+faststart")
+	}
+	return append(args, "-c:s", f.subtitleCodec())
+}
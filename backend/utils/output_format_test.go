@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveOutputFormat(t *testing.T) {
+	tests := []struct {
+		container, codec string
+		want             OutputFormat
+		wantErr          bool
+	}{
+		{"", "", OutputFormat{ContainerMP4, CodecH264}, false},
+		{"mp4", "H265", OutputFormat{ContainerMP4, CodecH265}, false},
+		{"", "av1", OutputFormat{ContainerMP4, CodecAV1}, false},
+		{"webm", "", OutputFormat{ContainerWebM, CodecVP9}, false},
+		{"webm", "av1", OutputFormat{ContainerWebM, CodecAV1}, false},
+		{"mov", "", OutputFormat{ContainerMOV, CodecH264}, false},
+		{"webm", "h264", OutputFormat{}, true},
+		{"mov", "vp9", OutputFormat{}, true},
+		{"mp4", "mpeg2", OutputFormat{}, true},
+		{"avi", "", OutputFormat{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveOutputFormat(tt.container, tt.codec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveOutputFormat(%q, %q) = %+v, %v; want %+v", tt.container, tt.codec, got, err, tt.want)
+		}
+	}
+	if f, _ := ResolveOutputFormat("", ""); !f.IsDefault() || f.Ext() != ".mp4" {
+		t.Errorf("Expected H.264 MP4 as the default, got %+v", f)
+	}
+}
+
+func TestTranscodeCommand(t *testing.T) {
+	tests := []struct {
+		format OutputFormat
+		want   string
+	}{
+		{OutputFormat{ContainerMOV, CodecH264}, "-c:v copy -c:a copy -movflags +faststart -c:s mov_text"},
+		{OutputFormat{ContainerMP4, CodecH265}, "-c:v libx265 -preset medium -crf 22 -tag:v hvc1 -c:a copy -movflags +faststart -c:s mov_text"},
+		{OutputFormat{ContainerWebM, CodecVP9}, "-c:v libvpx-vp9 -crf 30 -b:v 0 -row-mt 1 -c:a libopus -b:a 192k -c:s webvtt"},
+		{OutputFormat{ContainerWebM, CodecAV1}, "-c:v libsvtav1 -preset 8 -crf 30 -c:a libopus -b:a 192k -c:s webvtt"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(transcodeCommand("in.mp4", "out"+tt.format.Ext(), tt.format).Build(), " ")
+		want := "-i in.mp4 -map 0:v:0 -map 0:a? -map 0:s? " + tt.want + " -y out" + tt.format.Ext()
+		if got != want {
+			t.Errorf("transcodeCommand(%+v) =\n%s\nwant\n%s", tt.format, got, want)
+		}
+	}
+	if got := VideoContentType("/tmp/job/output/final_encoded.webm"); got != "video/webm" {
+		t.Errorf("VideoContentType() = %q", got)
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Presenter overlay corners
+const (
+	CornerBottomRight = "bottom_right" // default
+	CornerBottomLeft  = "bottom_left"
+	CornerTopRight    = "top_right"
+	CornerTopLeft     = "top_left"
+)
+
+// Presenter is a picture-in-picture overlay: a presenter video or avatar animation, looped in a
+// corner of the frame for the whole video
+type Presenter struct {
+	Path   string
+	Corner string  // CornerBottomRight, CornerBottomLeft, CornerTopRight or CornerTopLeft
+	Width  int     // pixels; the height keeps the clip's aspect ratio
+	Margin int     // pixels from the two nearest frame edges
+	Radius float64 // corner radius as a fraction of the overlay's shorter side; 0.5 makes a square a circle
+}
+
+// CombineAudioVideoWithPresenter is CombineAudioVideo with presenter composited over the video,
+// which is therefore re-encoded. The presenter's own audio is dropped.
+func CombineAudioVideoWithPresenter(videoPath, audioPath, subtitlePath, outputPath string, presenter Presenter) error {
+	loop := []string{"-stream_loop", "-1"}
+	if strings.EqualFold(filepath.Ext(presenter.Path), ".webm") {
+		// The native VP8/VP9 decoders drop the alpha channel
+		loop = append([]string{"-c:v", "libvpx-vp9"}, loop...)
+	}
+	cmd := NewFFmpegCommand("compose with presenter").
+		Input(videoPath).
+		Input(presenter.Path, loop...).
+		Input(audioPath)
+	if subtitlePath != "" {
+		cmd.Input(subtitlePath)
+	}
+	cmd.FilterComplex(presenterFilter(presenter)).
+		Map("[v]", "2:a:0").
+		Args("-c:v", "libx264", "-preset", "medium", "-crf", "18", "-c:a", "aac", "-b:a", "192k")
+	if subtitlePath != "" {
+		cmd.Map("3:s:0").Args("-c:s", "mov_text")
+	}
+	return cmd.Args("-shortest").Output(outputPath).Run()
+}
+
+// presenterFilter builds the -filter_complex of CombineAudioVideoWithPresenter: input 1, scaled
+// and with rounded corners, over input 0 until it ends
+func presenterFilter(p Presenter) FilterGraph {
+	pip := Chain("1:v").Then(F("scale", fmt.Sprint(p.Width), "-2"), F("format", "rgba"))
+	if p.Radius > 0 {
+		// Clear the alpha of the pixels beyond radius r of the corner circles' centres
+		r := fmt.Sprintf("(%.3f*min(W,H))", min(p.Radius, 0.5))
+		dx, dy := "(W/2-abs(W/2-X))", "(H/2-abs(H/2-Y))"
+		pip.Then(F("geq", "r='r(X,Y)'", "g='g(X,Y)'", "b='b(X,Y)'",
+			fmt.Sprintf("a='if(lt(%s,%s)*lt(%s,%s)*gt(hypot(%s-%s,%s-%s),%s),0,alpha(X,Y))'", dx, r, dy, r, r, dx, r, dy, r)))
+	}
+
+	m := p.Margin
+	x, y := fmt.Sprintf("W-w-%d", m), fmt.Sprintf("H-h-%d", m)
+	switch p.Corner {
+	case CornerBottomLeft:
+		x = fmt.Sprintf("%d", m)
+	case CornerTopRight:
+		y = fmt.Sprintf("%d", m)
+	case CornerTopLeft:
+		x, y = fmt.Sprintf("%d", m), fmt.Sprintf("%d", m)
+	}
+	return FilterGraph{
+		pip.To("pip"),
+		Chain("0:v", "pip").Then(F("overlay", "x="+x, "y="+y, "shortest=1"), F("format", "yuv420p")).To("v"),
+	}
+}
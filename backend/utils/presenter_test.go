@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPresenterFilter(t *testing.T) {
+	got := presenterFilter(Presenter{Path: "host.mp4", Corner: CornerBottomRight, Width: 480, Margin: 57}).String()
+	want := "[1:v]scale=480:-2,format=rgba[pip];[0:v][pip]overlay=x=W-w-57:y=H-h-57:shortest=1,format=yuv420p[v]"
+	if got != want {
+		t.Errorf("presenterFilter =\n%s\nwant\n%s", got, want)
+	}
+
+	for corner, position := range map[string]string{
+		CornerBottomLeft: "overlay=x=20:y=H-h-20:",
+		CornerTopRight:   "overlay=x=W-w-20:y=20:",
+		CornerTopLeft:    "overlay=x=20:y=20:",
+	} {
+		if got := presenterFilter(Presenter{Corner: corner, Width: 320, Margin: 20}).String(); !strings.Contains(got, position) {
+			t.Errorf("%s: expected %q in\n%s", corner, position, got)
+		}
+	}
+
+	// Rounded corners clear the alpha outside the corner circles
+	got = presenterFilter(Presenter{Corner: CornerTopLeft, Width: 320, Radius: 0.5}).String()
+	if part := ",geq=r='r(X,Y)':g='g(X,Y)':b='b(X,Y)':a='if(lt((W/2-abs(W/2-X)),(0.500*min(W,H)))*"; !strings.Contains(got, part) {
+		t.Errorf("Expected rounded corners %q in\n%s", part, got)
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// GenerateRequest.Quality presets, trading encode time for picture quality
+const (
+	QualityDraft    = "draft"    // ultrafast 720p, to iterate on a script
+	QualityStandard = "standard" // the configured encodes
+	QualityHigh     = "high"     // slower, near-transparent final renders
+)
+
+// EncodeQuality is what a quality preset changes in the pipeline's libx264 encodes
+type EncodeQuality struct {
+	Preset     string // replaces the "medium" x264 preset; "" keeps it
+	CRFOffset  int    // added to each -crf
+	Resolution string // default GenerateRequest.Resolution, e.g. "720p"; "" keeps VIDEO_RESOLUTION
+}
+
+// encodeQualities are the presets by name
+var encodeQualities = map[string]EncodeQuality{
+	QualityDraft:    {Preset: "ultrafast", CRFOffset: 8, Resolution: "720p"},
+	QualityStandard: {},
+	QualityHigh:     {Preset: "slow", CRFOffset: -2},
+}
+
+// IsValidQuality reports whether name is a quality preset
+func IsValidQuality(name string) bool {
+	_, ok := encodeQualities[name]
+	return ok
+}
+
+// QualityResolution is the resolution quality renders at when the request sets none, or ""
+func QualityResolution(quality string) string {
+	return encodeQualities[quality].Resolution
+}
+
+// qualityDirs maps a folder, such as a job's temp folder, to the quality of encodes written in it
+var qualityDirs sync.Map
+
+// SetEncodeQuality applies preset quality to the libx264 encodes of ffmpeg runs writing under dir.
+// Call stop once the job is done.
+func SetEncodeQuality(dir, quality string) (stop func()) {
+	q := encodeQualities[quality]
+	if q.Preset == "" && q.CRFOffset == 0 {
+		return func() {}
+	}
+	dir = filepath.Clean(dir)
+	qualityDirs.Store(dir, q)
+	return func() { qualityDirs.Delete(dir) }
+}
+
+// withQuality rewrites the -preset and -crf of a libx264 command for the quality of the folder
+// its output, the last argument, is written in
+func withQuality(args []string) []string {
+	if len(args) == 0 || !encodesSoftwareH264(args) {
+		return args
+	}
+	output := args[len(args)-1]
+	var q EncodeQuality
+	found := false
+	qualityDirs.Range(func(dir, value any) bool {
+		if isUnder(output, dir.(string)) {
+			q, found = value.(EncodeQuality), true
+		}
+		return !found
+	})
+	if !found {
+		return args
+	}
+
+	out := append([]string(nil), args...)
+	for i := 0; i+1 < len(out); i++ {
+		switch out[i] {
+		case "-preset":
+			// Placeholders already encode ultrafast
+			if q.Preset != "" && out[i+1] == "medium" {
+				out[i+1] = q.Preset
+			}
+		case "-crf":
+			if n, err := strconv.Atoi(out[i+1]); err == nil {
+				out[i+1] = strconv.Itoa(min(51, max(0, n+q.CRFOffset)))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWithQuality(t *testing.T) {
+	jobDir := filepath.Join(t.TempDir(), "job1")
+	stop := SetEncodeQuality(jobDir, QualityDraft)
+	defer stop()
+
+	encode := []string{"-i", "in.mp4", "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-y", filepath.Join(jobDir, "output", "final.mp4")}
+	want := []string{"-i", "in.mp4", "-c:v", "libx264", "-preset", "ultrafast", "-crf", "26", "-y", filepath.Join(jobDir, "output", "final.mp4")}
+	if got := withQuality(encode); !reflect.DeepEqual(got, want) {
+		t.Errorf("withQuality() = %v, want %v", got, want)
+	}
+	if encode[5] != "medium" {
+		t.Error("Expected the arguments not to be modified")
+	}
+
+	// Other folders, other encoders and stream copies are left alone
+	other := []string{"-i", "in.mp4", "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-y", jobDir + "2/final.mp4"}
+	copyArgs := []string{"-i", "in.mp4", "-c:v", "copy", "-y", filepath.Join(jobDir, "final.mp4")}
+	vp9 := []string{"-i", "in.mp4", "-c:v", "libvpx-vp9", "-crf", "30", "-y", filepath.Join(jobDir, "final.webm")}
+	for _, args := range [][]string{other, copyArgs, vp9} {
+		if got := withQuality(args); !reflect.DeepEqual(got, args) {
+			t.Errorf("withQuality(%v) = %v, want it unchanged", args, got)
+		}
+	}
+
+	stop()
+	if got := withQuality(encode); !reflect.DeepEqual(got, encode) {
+		t.Errorf("Expected no rewrite once cleared, got %v", got)
+	}
+}
+
+func TestSetEncodeQuality_High(t *testing.T) {
+	jobDir := t.TempDir()
+	defer SetEncodeQuality(jobDir, QualityHigh)()
+
+	placeholder := []string{"-c:v", "libx264", "-preset", "ultrafast", "-crf", "1", filepath.Join(jobDir, "p.mp4")}
+	want := []string{"-c:v", "libx264", "-preset", "ultrafast", "-crf", "0", filepath.Join(jobDir, "p.mp4")}
+	if got := withQuality(placeholder); !reflect.DeepEqual(got, want) {
+		t.Errorf("withQuality() = %v, want %v", got, want)
+	}
+	if !IsValidQuality(QualityStandard) || IsValidQuality("ultra") {
+		t.Error("IsValidQuality() mismatch")
+	}
+	if QualityResolution(QualityDraft) != "720p" || QualityResolution(QualityHigh) != "" {
+		t.Error("QualityResolution() mismatch")
+	}
+}
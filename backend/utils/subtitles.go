@@ -0,0 +1,345 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// SubtitleCue is one subtitle line and when it is shown, in seconds
+type SubtitleCue struct {
+	Start float64
+	End   float64
+	Text  string
+	Words []SubtitleWord // when each word of Text is spoken, for karaoke; optional
+
+	// Unheard is the share of Words the speech aligner could not match, 0 when not aligned
+	Unheard float64
+}
+
+// SubtitleWord is one spoken word of a cue, in seconds
+type SubtitleWord struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+// ASSStyle is how burned-in captions look. Sizes are in pixels of the 1080p frame of the
+// orientation (see FrameSize); libass scales them to the actual resolution.
+type ASSStyle struct {
+	Font           string
+	Size           int
+	PrimaryColor   string // "#RRGGBB"
+	OutlineColor   string // "#RRGGBB"
+	MarginV        int    // distance from the bottom edge, or the top edge with Alignment 8
+	Outline        float64
+	Shadow         float64
+	Bold           bool
+	Alignment      int    // numpad layout: 2 bottom center (default), 5 middle, 8 top
+	Box            bool   // opaque box behind the text instead of an outline
+	BoxColor       string // "#RRGGBB", drawn 40% transparent
+	Karaoke        bool   // highlight each word as it is spoken (\k tags)
+	HighlightColor string // "#RRGGBB" of spoken words with Karaoke
+}
+
+// DefaultASSStyle is the caption look used before styles were configurable: yellow and higher
+// up on portrait (clear of TikTok's UI), white elsewhere
+func DefaultASSStyle(orientation string) ASSStyle {
+	if orientation == "portrait" {
+		return ASSStyle{Font: "Ubuntu Sans", Size: 120, PrimaryColor: "#FFFF00", OutlineColor: "#000000", MarginV: 533, Outline: 10, Shadow: 6.7, Bold: true, Alignment: 2, BoxColor: "#000000", HighlightColor: "#00FF00"}
+	}
+	return ASSStyle{Font: "Ubuntu Sans", Size: 52, PrimaryColor: "#FFFFFF", OutlineColor: "#000000", MarginV: 150, Outline: 4.5, Shadow: 3.75, Bold: true, Alignment: 2, BoxColor: "#000000", HighlightColor: "#FFFF00"}
+}
+
+// ASSColor converts "#RRGGBB" into ASS's opaque "&H00BBGGRR"
+func ASSColor(hex string) (string, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 || strings.Trim(strings.ToUpper(hex), "0123456789ABCDEF") != "" {
+		return "", fmt.Errorf("color %q must be #RRGGBB", "#"+hex)
+	}
+	hex = strings.ToUpper(hex)
+	return "&H00" + hex[4:6] + hex[2:4] + hex[0:2], nil
+}
+
+// FormatASSTimestamp formats seconds as H:MM:SS.cc
+func FormatASSTimestamp(seconds float64) string {
+	cs := int(math.Round(seconds * 100))
+	if cs < 0 {
+		cs = 0
+	}
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// FormatVTTTimestamp formats seconds as WebVTT's HH:MM:SS.mmm
+func FormatVTTTimestamp(seconds float64) string {
+	return strings.Replace(FormatSRTTimestamp(seconds), ",", ".", 1)
+}
+
+// WriteVTT writes cues as a WebVTT file
+func WriteVTT(path string, cues []SubtitleCue) error {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		// "-->" in the text would end the cue's timing line early
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", FormatVTTTimestamp(cue.Start), FormatVTTTimestamp(cue.End), strings.ReplaceAll(cue.Text, "-->", "->"))
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// WriteASS writes cues as an Advanced SubStation Alpha file with a single "Default" style, on a
+// canvas the size of orientation's frame. With style.Karaoke, words turn from PrimaryColor to
+// HighlightColor as they are spoken (cues without matching Words are timed by EstimateWords).
+func WriteASS(path string, style ASSStyle, orientation string, cues []SubtitleCue) error {
+	primary, err := ASSColor(style.PrimaryColor)
+	if err != nil {
+		return fmt.Errorf("invalid primary color: %w", err)
+	}
+	// \k shows SecondaryColour until a word's time comes, then PrimaryColour
+	secondary := primary
+	if style.Karaoke {
+		if primary, err = ASSColor(style.HighlightColor); err != nil {
+			return fmt.Errorf("invalid highlight color: %w", err)
+		}
+	}
+	outline, err := ASSColor(style.OutlineColor)
+	if err != nil {
+		return fmt.Errorf("invalid outline color: %w", err)
+	}
+	bold := 0
+	if style.Bold {
+		bold = -1
+	}
+	alignment := style.Alignment
+	if alignment == 0 {
+		alignment = 2
+	}
+	// BorderStyle 3 turns the outline into a box around the text, padded by Outline
+	borderStyle, outlineWidth, shadow := 1, style.Outline, style.Shadow
+	if style.Box {
+		box, err := ASSColor(style.BoxColor)
+		if err != nil {
+			return fmt.Errorf("invalid box color: %w", err)
+		}
+		borderStyle, outline, outlineWidth, shadow = 3, "&H66"+box[4:], float64(style.Size)/5, 0
+	}
+	width, height := FrameSize(orientation)
+	marginH := assMarginH(width)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Script Info]\nScriptType: v4.00+\nPlayResX: %d\nPlayResY: %d\nWrapStyle: 0\nScaledBorderAndShadow: yes\n\n", width, height)
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	fmt.Fprintf(&b, "Style: Default,%s,%d,%s,%s,%s,&H80000000,%d,0,0,0,100,100,0,0,%d,%g,%g,%d,%d,%d,%d,1\n\n",
+		style.Font, style.Size, primary, secondary, outline, bold, borderStyle, outlineWidth, shadow, alignment, marginH, marginH, style.MarginV)
+	b.WriteString("[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, cue := range cues {
+		text := assText(cue.Text)
+		if style.Karaoke {
+			text = karaokeText(cue)
+		}
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n", FormatASSTimestamp(cue.Start), FormatASSTimestamp(cue.End), text)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// karaokeText is cue's text with a \k tag before each word, in centiseconds counted from the
+// cue start so rounding never drifts; pauses between words get an empty \k of their own
+func karaokeText(cue SubtitleCue) string {
+	lines := strings.Split(cue.Text, "\n")
+	words := cue.Words
+	if !wordsFit(cue) {
+		words = EstimateWords(cue.Start, cue.End, cue.Text)
+	}
+	var b strings.Builder
+	elapsed := 0 // centiseconds since cue.Start already tagged
+	next := 0
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString(`\N`)
+		}
+		for j, w := range strings.Fields(line) {
+			if next >= len(words) {
+				break
+			}
+			word := words[next]
+			next++
+			start := int(math.Round((word.Start - cue.Start) * 100))
+			end := int(math.Round((min(word.End, cue.End) - cue.Start) * 100))
+			if start > elapsed {
+				fmt.Fprintf(&b, `{\k%d}`, start-elapsed)
+				elapsed = start
+			}
+			if j > 0 {
+				b.WriteString(" ")
+			}
+			fmt.Fprintf(&b, `{\k%d}%s`, max(end-elapsed, 0), assText(w))
+			elapsed = max(end, elapsed)
+		}
+	}
+	return b.String()
+}
+
+// wordsFit reports whether cue.Words still time cue: one per word of the text, in order and
+// within the cue (an edited cue may have outgrown them)
+func wordsFit(cue SubtitleCue) bool {
+	if len(cue.Words) == 0 || len(cue.Words) != len(strings.Fields(cue.Text)) {
+		return false
+	}
+	const slack = 0.05
+	prev := cue.Start - slack
+	for _, w := range cue.Words {
+		if w.Start < prev || w.End < w.Start {
+			return false
+		}
+		prev = w.Start
+	}
+	return cue.Words[len(cue.Words)-1].End <= cue.End+slack
+}
+
+// EstimateWords times the words of text across start-end in proportion to their length, for
+// cues whose speech was not aligned
+func EstimateWords(start, end float64, text string) []SubtitleWord {
+	fields := strings.Fields(text)
+	total := 0
+	for _, f := range fields {
+		total += utf8.RuneCountInString(f)
+	}
+	words := make([]SubtitleWord, len(fields))
+	at, done := start, 0
+	for i, f := range fields {
+		done += utf8.RuneCountInString(f)
+		next := start + (end-start)*float64(done)/float64(total)
+		words[i] = SubtitleWord{Start: at, End: next, Text: f}
+		at = next
+	}
+	return words
+}
+
+// ShiftCues moves cues, and their words, offset seconds later
+func ShiftCues(cues []SubtitleCue, offset float64) []SubtitleCue {
+	shifted := make([]SubtitleCue, len(cues))
+	for i, cue := range cues {
+		shifted[i] = SubtitleCue{Start: cue.Start + offset, End: cue.End + offset, Text: cue.Text, Unheard: cue.Unheard}
+		for _, w := range cue.Words {
+			shifted[i].Words = append(shifted[i].Words, SubtitleWord{Start: w.Start + offset, End: w.End + offset, Text: w.Text})
+		}
+	}
+	return shifted
+}
+
+// assMarginH is the left and right margin of captions on a frame width pixels wide
+func assMarginH(width int) int {
+	return width / 20
+}
+
+// SubtitleLineChars estimates how many characters of style fit on one caption line of
+// orientation's frame, taking an average glyph as 0.55 em
+func SubtitleLineChars(style ASSStyle, orientation string) int {
+	width, _ := FrameSize(orientation)
+	if style.Size <= 0 {
+		return 40
+	}
+	n := int(float64(width-2*assMarginH(width)) / (0.55 * float64(style.Size)))
+	if n < 8 {
+		n = 8
+	}
+	return n
+}
+
+// WrapCues breaks each cue into lines of at most lineChars columns (longer words stay whole,
+// and so do Chinese and Japanese runs when the cue has Words) and splits cues of more than maxLines lines into consecutive cues, dividing the cue's time by
+// text length, or at the first word of each part when the cue has Words. maxLines 0 leaves cues
+// unchanged, for the player to wrap.
+func WrapCues(cues []SubtitleCue, lineChars, maxLines int) []SubtitleCue {
+	if maxLines <= 0 {
+		return cues
+	}
+	var out []SubtitleCue
+	for _, cue := range cues {
+		words := cue.Words
+		if !wordsFit(cue) {
+			words = nil
+		}
+		// Breaking inside a word would leave Words out of step with the text
+		lines := wrapWords(strings.Fields(cue.Text), lineChars, words == nil)
+		if len(lines) == 0 {
+			continue
+		}
+		var chunks []SubtitleCue
+		total, word := 0, 0
+		for i := 0; i < len(lines); i += maxLines {
+			chunk := SubtitleCue{Text: strings.Join(lines[i:min(i+maxLines, len(lines))], "\n")}
+			if words != nil {
+				n := len(strings.Fields(chunk.Text))
+				chunk.Words = words[word : word+n]
+				word += n
+			}
+			chunks = append(chunks, chunk)
+			total += utf8.RuneCountInString(chunk.Text)
+		}
+		start := cue.Start
+		for i := range chunks {
+			end := cue.End
+			if i < len(chunks)-1 {
+				if words != nil {
+					end = chunks[i+1].Words[0].Start
+				} else {
+					end = start + (cue.End-cue.Start)*float64(utf8.RuneCountInString(chunks[i].Text))/float64(total)
+				}
+			}
+			chunks[i].Start, chunks[i].End = start, end
+			out = append(out, chunks[i])
+			start = end
+		}
+	}
+	return out
+}
+
+// WrapLines breaks text into lines of at most lineChars columns, as WrapCues does, also
+// breaking runs of Chinese or Japanese that are wider than a line
+func WrapLines(text string, lineChars int) []string {
+	return wrapWords(strings.Fields(text), lineChars, true)
+}
+
+// wrapWords fills lines of at most lineChars columns greedily. With breakRuns a word wider than
+// a line is broken where CanBreak allows (a Latin word stays whole).
+func wrapWords(words []string, lineChars int, breakRuns bool) []string {
+	var lines []string
+	var line string
+	for _, w := range words {
+		if breakRuns && DisplayWidth(w) > lineChars {
+			for {
+				cut := LastBreak(w, FitPrefix(w, lineChars, RuneWidth))
+				if cut <= 0 {
+					break
+				}
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, w[:cut])
+				w = w[cut:]
+			}
+		}
+		if line != "" && DisplayWidth(line)+1+DisplayWidth(w) > lineChars {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// assText keeps cue text literal: braces would start override tags and backslashes escapes,
+// and a Dialogue line cannot span lines
+func assText(text string) string {
+	return strings.NewReplacer("{", "(", "}", ")", "\\", "/", "\r\n", `\N`, "\n", `\N`).Replace(text)
+}
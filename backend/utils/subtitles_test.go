@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestASSColor(t *testing.T) {
+	if got, err := ASSColor("#ff8800"); err != nil || got != "&H000088FF" {
+		t.Errorf("ASSColor(#ff8800) = %q, %v", got, err)
+	}
+	for _, bad := range []string{"", "#fff", "#GG0000", "red"} {
+		if _, err := ASSColor(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestFormatASSTimestamp(t *testing.T) {
+	tests := map[float64]string{0: "0:00:00.00", 61.526: "0:01:01.53", 3661.999: "1:01:02.00"}
+	for seconds, want := range tests {
+		if got := FormatASSTimestamp(seconds); got != want {
+			t.Errorf("FormatASSTimestamp(%.3f) = %s; want %s", seconds, got, want)
+		}
+	}
+}
+
+func TestWriteASS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subtitles.ass")
+	style := DefaultASSStyle("portrait")
+	style.Font = "Be Vietnam Pro"
+	style.PrimaryColor = "#00C2A8"
+	cues := []SubtitleCue{{Start: 0, End: 1.5, Text: "Xin chào {mọi người}"}, {Start: 1.5, End: 3, Text: "dòng một\ndòng hai"}}
+	if err := WriteASS(path, style, "portrait", cues); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+	for _, want := range []string{
+		"PlayResX: 1080\nPlayResY: 1920",
+		"Style: Default,Be Vietnam Pro,120,&H00A8C200,&H00A8C200,&H00000000,",
+		",2,54,54,533,1\n",
+		"Dialogue: 0,0:00:00.00,0:00:01.50,Default,,0,0,0,,Xin chào (mọi người)\n",
+		`Dialogue: 0,0:00:01.50,0:00:03.00,Default,,0,0,0,,dòng một\Ndòng hai`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	style.Box = true
+	style.BoxColor = "#102030"
+	style.Alignment = 8
+	if err := WriteASS(path, style, "portrait", cues); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), ",&H66302010,&H80000000,-1,0,0,0,100,100,0,0,3,24,0,8,54,54,533,1\n") {
+		t.Errorf("expected a top-aligned box style, got:\n%s", data)
+	}
+
+	style.OutlineColor = "black"
+	if err := WriteASS(path, style, "portrait", cues); err == nil {
+		t.Error("expected an invalid outline color to be rejected")
+	}
+}
+
+func TestWrapCues(t *testing.T) {
+	cues := []SubtitleCue{{Start: 10, End: 16, Text: "một hai ba bốn năm sáu bảy tám"}}
+	if got := WrapCues(cues, 10, 0); len(got) != 1 || got[0].Text != cues[0].Text {
+		t.Errorf("expected cues unchanged without a line limit, got %+v", got)
+	}
+
+	got := WrapCues(cues, 10, 2)
+	if len(got) != 2 || got[0].Text != "một hai ba\nbốn năm" || got[1].Text != "sáu bảy\ntám" {
+		t.Fatalf("expected two cues of at most two lines, got %+v", got)
+	}
+	if got[0].Start != 10 || got[1].End != 16 || got[0].End != got[1].Start || got[0].End <= 12 {
+		t.Errorf("expected the cue time split by text length, got %+v", got)
+	}
+
+	if n := SubtitleLineChars(DefaultASSStyle("landscape"), "landscape"); n < 50 || n > 70 {
+		t.Errorf("expected about 60 characters per landscape line, got %d", n)
+	}
+}
+
+func TestWriteASS_Karaoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subtitles.ass")
+	style := DefaultASSStyle("landscape")
+	style.Karaoke = true
+	cues := []SubtitleCue{
+		{Start: 1, End: 3, Text: "Xin chào\nbạn", Words: []SubtitleWord{
+			{Start: 1, End: 1.4, Text: "Xin"}, {Start: 1.4, End: 2, Text: "chào"}, {Start: 2.5, End: 3, Text: "bạn"},
+		}},
+		{Start: 3, End: 4, Text: "ab abc"}, // no words: timed by length
+	}
+	if err := WriteASS(path, style, "landscape", cues); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+	for _, want := range []string{
+		// Spoken words turn yellow; the rest stay white
+		"Style: Default,Ubuntu Sans,52,&H0000FFFF,&H00FFFFFF,",
+		`,,{\k40}Xin {\k60}chào\N{\k50}{\k50}bạn` + "\n",
+		`,,{\k40}ab {\k60}abc` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWrapCues_Words(t *testing.T) {
+	words := EstimateWords(0, 4, "one two three four")
+	if words[1].Start != 0.8 || words[3].End != 4 {
+		t.Errorf("expected words timed by length, got %+v", words)
+	}
+	words[3].Start = 3.5 // the last word is spoken after a pause
+	cues := WrapCues([]SubtitleCue{{Start: 0, End: 4, Text: "one two three four", Words: words}}, 8, 2)
+	if len(cues) != 2 || cues[0].End != 3.5 || cues[1].Start != 3.5 || len(cues[1].Words) != 1 {
+		t.Errorf("expected the cue split where the last word starts, got %+v", cues)
+	}
+
+	// Words that no longer match an edited cue are dropped
+	cues = WrapCues([]SubtitleCue{{Start: 0, End: 4, Text: "one two three", Words: words}}, 8, 1)
+	if len(cues) != 2 || cues[0].Words != nil {
+		t.Errorf("expected mismatched words dropped, got %+v", cues)
+	}
+}
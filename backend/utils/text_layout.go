@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// noLineStart are characters a line must not begin with: closing brackets, trailing
+// punctuation and, in Japanese, small kana and the prolonged sound mark
+const noLineStart = ",.!?:;)]}%…、。，．・：；？！）］｝」』】〕〉》〗〙〛ー々ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶ"
+
+// noLineEnd are characters a line must not end with: opening brackets
+const noLineEnd = "([{（［｛「『【〔〈《〖〘〚"
+
+// RuneWidth is how many columns r takes on screen: 2 for East Asian wide and full-width
+// characters, 0 for combining marks and other zero-width characters, 1 otherwise
+func RuneWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115F, // Hangul Jamo initials
+		r >= 0x2E80 && r <= 0x303E, // CJK radicals, symbols and punctuation
+		r >= 0x3041 && r <= 0x33FF, // kana, Bopomofo, CJK compatibility
+		r >= 0x3400 && r <= 0x4DBF, // CJK extension A
+		r >= 0x4E00 && r <= 0x9FFF, // CJK unified ideographs
+		r >= 0xA000 && r <= 0xA4CF, // Yi
+		r >= 0xAC00 && r <= 0xD7A3, // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF, // CJK compatibility ideographs
+		r >= 0xFE30 && r <= 0xFE4F, // CJK compatibility forms
+		r >= 0xFF00 && r <= 0xFF60, // full-width forms
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // emoji
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions B and later
+		return 2
+	}
+	return 1
+}
+
+// DisplayWidth is the number of columns s takes on screen (see RuneWidth), which is what
+// subtitle lengths are measured in: Vietnamese "ệ" is one column however it is encoded and a
+// Chinese character two
+func DisplayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// breaksAnywhere reports whether a line may break before or after r without a space: Chinese
+// and Japanese are written without spaces between words. Korean separates words with spaces
+// and breaks only there.
+func breaksAnywhere(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFF60)
+}
+
+// CanBreak reports whether a line may break in s at byte offset i, between the character
+// ending there and the one starting there: at a space, or between Chinese or Japanese
+// characters unless that would start a line with closing punctuation or end one with an
+// opening bracket. It never breaks before a combining mark.
+func CanBreak(s string, i int) bool {
+	if i <= 0 || i >= len(s) {
+		return false
+	}
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	next, _ := utf8.DecodeRuneInString(s[i:])
+	switch {
+	case unicode.IsSpace(next):
+		return !unicode.IsSpace(prev)
+	case unicode.IsSpace(prev), unicode.In(next, unicode.Mn, unicode.Me):
+		return false
+	case strings.ContainsRune(noLineStart, next), strings.ContainsRune(noLineEnd, prev):
+		return false
+	}
+	return breaksAnywhere(prev) || breaksAnywhere(next)
+}
+
+// LastBreak returns the largest offset in (0, end] where CanBreak allows s to break, or -1
+func LastBreak(s string, end int) int {
+	end = min(end, len(s))
+	for end > 0 && end < len(s) && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	for i := end; i > 0; {
+		if CanBreak(s, i) {
+			return i
+		}
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return -1
+}
+
+// FitPrefix returns the byte length of the longest prefix of s whose total width, rune by rune,
+// is at most limit. The prefix ends on a character boundary and never splits a letter from its
+// combining marks.
+func FitPrefix(s string, limit int, width func(rune) int) int {
+	n, end := 0, 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if n += width(r); n > limit {
+			break
+		}
+		end += size
+	}
+	for end > 0 && end < len(s) {
+		next, _ := utf8.DecodeRuneInString(s[end:])
+		if !unicode.In(next, unicode.Mn, unicode.Me) {
+			break
+		}
+		_, size := utf8.DecodeLastRuneInString(s[:end])
+		end -= size
+	}
+	return end
+}
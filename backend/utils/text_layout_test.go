@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"Việt Nam", 8},
+		{"Việt Nam", 8}, // decomposed: combining marks take no column
+		{"日本語", 6},
+		{"한국어", 6},
+		{"ｈｉ", 4},
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.text); got != tt.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLastBreak(t *testing.T) {
+	tests := []struct {
+		text string
+		end  int
+		want string // the text before the break
+	}{
+		{"xin chào các bạn", len("xin chào cá"), "xin chào"},
+		{"xin chào các bạn", len("xin chào các"), "xin chào các"},
+		{"今日は天気がいい", len("今日は天"), "今日は天"},
+		{"今日はいい天気です。", len("今日はいい天気です"), "今日はいい天気で"}, // no line starts with 。
+		{"これは「本」です", len("これは「"), "これは"},             // nor ends with 「
+		{"오늘 날씨가 좋다", len("오늘 날씨가 좋"), "오늘 날씨가"},     // Korean breaks at spaces only
+	}
+	for _, tt := range tests {
+		i := LastBreak(tt.text, tt.end)
+		if i < 0 || tt.text[:i] != tt.want {
+			t.Errorf("LastBreak(%q, %d) = %d, want a break after %q", tt.text, tt.end, i, tt.want)
+		}
+	}
+	if i := LastBreak("supercalifragilistic", 10); i != -1 {
+		t.Errorf("expected no break inside a Latin word, got %d", i)
+	}
+}
+
+func TestFitPrefix(t *testing.T) {
+	if n := FitPrefix("日本語", 5, RuneWidth); n != len("日本") {
+		t.Errorf("expected two wide characters in 5 columns, got %d bytes", n)
+	}
+	// The letter and its combining marks stay together
+	s := "Việt"
+	if n := FitPrefix(s, 3, func(rune) int { return 1 }); !utf8.ValidString(s[:n]) || s[:n] != "Vi" {
+		t.Errorf("expected the cut before the accented letter, got %q", s[:n])
+	}
+}
+
+func TestWrapLines_CJK(t *testing.T) {
+	lines := WrapLines("今日はとても天気がいいので公園に行きましょう。", 16)
+	if len(lines) < 3 {
+		t.Fatalf("expected the run broken into lines, got %q", lines)
+	}
+	for _, line := range lines {
+		if DisplayWidth(line) > 16 {
+			t.Errorf("line %q is %d columns wide", line, DisplayWidth(line))
+		}
+	}
+	if got := WrapLines("Xin chào các bạn thân mến", 10); len(got) != 3 {
+		t.Errorf("expected Vietnamese wrapped by letters, got %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a rate limiter that allows rate requests per second with bursts of up to
+// burst requests. Callers that find the bucket empty wait for the next token.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewTokenBucket creates a full bucket
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// SetLimit changes the rate and burst, keeping the tokens already earned
+func (tb *TokenBucket) SetLimit(rate float64, burst int) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	if burst < 1 {
+		burst = 1
+	}
+	tb.rate = rate
+	tb.burst = float64(burst)
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		delay := tb.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise returns how long until one is
+func (tb *TokenBucket) reserve() time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0
+	}
+	if tb.rate <= 0 {
+		return time.Second
+	}
+	return time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+}
+
+// refill adds the tokens earned since the last call. Must be called with lock held.
+func (tb *TokenBucket) refill() {
+	now := tb.now()
+	tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+	tb.last = now
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	tb := NewTokenBucket(2, 2)
+	tb.now = func() time.Time { return now }
+	tb.last = now
+
+	// The burst is available immediately, then tokens accrue at rate
+	if tb.reserve() != 0 || tb.reserve() != 0 {
+		t.Fatal("Expected the initial burst to be free")
+	}
+	if d := tb.reserve(); d != 500*time.Millisecond {
+		t.Errorf("Expected to wait 500ms for the next token, got %v", d)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if tb.reserve() != 0 {
+		t.Error("Expected a token after 500ms at 2 rps")
+	}
+
+	// Idle time never saves more than the burst
+	now = now.Add(time.Hour)
+	tb.SetLimit(1, 1)
+	if tb.reserve() != 0 {
+		t.Fatal("Expected a token after idling")
+	}
+	if d := tb.reserve(); d != time.Second {
+		t.Errorf("Expected the burst to be capped at 1, got wait %v", d)
+	}
+}
+
+func TestAPIKeyPool_RateLimit(t *testing.T) {
+	pool := NewAPIKeyPool([]string{"a", "b"})
+	if err := pool.Wait(context.Background()); err != nil {
+		t.Fatalf("An unlimited pool should not wait: %v", err)
+	}
+
+	// One token per available key; the third request has to wait
+	pool.SetRateLimit(0.01)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if err := pool.Wait(ctx); err != nil {
+			t.Fatalf("Request %d should use the burst: %v", i, err)
+		}
+	}
+	if err := pool.Wait(ctx); err == nil {
+		t.Error("Expected the third request to be held back")
+	}
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// accurateTrim makes TrimVideo cut at the exact duration instead of at a keyframe
+var accurateTrim atomic.Bool
+
+// SetAccurateTrim switches TrimVideo to TrimVideoAccurate (FFMPEG_ACCURATE_TRIM)
+func SetAccurateTrim(on bool) {
+	accurateTrim.Store(on)
+}
+
+// TrimVideoAccurate trims video to exactly targetDuration. The H.264 stream is copied up to
+// the last keyframe before the cut and only the GOP that the cut falls in is re-encoded;
+// other codecs, and clips with no keyframe before the cut, are re-encoded whole. Audio is
+// re-encoded to the same length.
+func TrimVideoAccurate(inputPath, outputPath string, targetDuration float64) error {
+	codec, pixFmt, err := videoCodec(inputPath)
+	if err != nil {
+		return err
+	}
+	var cut float64
+	if codec == "h264" {
+		keyframes, err := keyframeTimes(inputPath)
+		if err != nil {
+			return err
+		}
+		cut = lastKeyframeBefore(keyframes, targetDuration)
+	}
+	if cut <= 0 {
+		return reencodeTrim(inputPath, outputPath, targetDuration)
+	}
+
+	// MPEG-TS carries the parameter sets in-band, so the tail may differ from the head in
+	// profile or level and still play after the join
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	head, tail, list := base+"_head.ts", base+"_tail.ts", base+"_trim.txt"
+	defer os.Remove(head)
+	defer os.Remove(tail)
+	defer os.Remove(list)
+
+	if err := NewFFmpegCommand("trim video head").
+		Input(inputPath).
+		Map("0:v:0").
+		Args("-t", fmt.Sprintf("%.3f", cut), "-c", "copy").
+		Output(head).
+		Run(); err != nil {
+		return fmt.Errorf("failed to copy up to the keyframe at %.3fs: %w", cut, err)
+	}
+	if err := NewFFmpegCommand("trim video tail").
+		Input(inputPath, "-ss", fmt.Sprintf("%.3f", cut)).
+		Map("0:v:0").
+		Args("-t", fmt.Sprintf("%.3f", targetDuration-cut), "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-pix_fmt", pixFmt).
+		Output(tail).
+		Run(); err != nil {
+		return fmt.Errorf("failed to re-encode from the keyframe at %.3fs: %w", cut, err)
+	}
+
+	var concat strings.Builder
+	for _, part := range []string{head, tail} {
+		// The concat demuxer resolves relative paths against the list's folder
+		abs, err := filepath.Abs(part)
+		if err != nil {
+			abs = part
+		}
+		concat.WriteString(fmt.Sprintf("file '%s'\n", filepath.ToSlash(abs)))
+	}
+	if err := os.WriteFile(list, []byte(concat.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write concat list: %w", err)
+	}
+	return NewFFmpegCommand("trim video").
+		Input(list, "-f", "concat", "-safe", "0").
+		Input(inputPath).
+		Map("0:v:0", "1:a:0?").
+		Args("-t", fmt.Sprintf("%.3f", targetDuration), "-c:v", "copy", "-c:a", "aac", "-b:a", "192k").
+		Output(outputPath).
+		Run()
+}
+
+// reencodeTrim trims video to targetDuration, re-encoding it whole
+func reencodeTrim(inputPath, outputPath string, targetDuration float64) error {
+	return NewFFmpegCommand("trim video").
+		Input(inputPath).
+		Map("0:v:0", "0:a:0?").
+		Args("-t", fmt.Sprintf("%.3f", targetDuration), "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-c:a", "aac", "-b:a", "192k").
+		Output(outputPath).
+		Run()
+}
+
+// videoCodec returns the codec and pixel format of path's first video stream
+func videoCodec(path string) (codec, pixFmt string, err error) {
+	output, err := exec.Command("ffprobe",
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=codec_name,pix_fmt",
+		"-of", "csv=p=0",
+		path,
+	).Output()
+	if err != nil {
+		return "", "", fmt.Errorf("ffprobe error: %w", err)
+	}
+	fields := strings.Split(strings.TrimSpace(string(output)), ",")
+	if len(fields) < 2 || fields[0] == "" {
+		return "", "", fmt.Errorf("no video stream in %s", path)
+	}
+	return fields[0], fields[1], nil
+}
+
+// keyframeTimes returns the presentation times of the keyframes of path's first video stream,
+// read from the packet flags so nothing is decoded
+func keyframeTimes(path string) ([]float64, error) {
+	output, err := exec.Command("ffprobe",
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "packet=pts_time,flags",
+		"-of", "csv=p=0",
+		path,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %w", err)
+	}
+	return parseKeyframeTimes(string(output)), nil
+}
+
+// parseKeyframeTimes reads the "pts_time,flags" lines of keyframeTimes' ffprobe run, in
+// presentation order
+func parseKeyframeTimes(output string) []float64 {
+	var times []float64
+	for _, line := range strings.Split(output, "\n") {
+		pts, flags, ok := strings.Cut(strings.TrimSpace(line), ",")
+		if !ok || !strings.HasPrefix(flags, "K") {
+			continue
+		}
+		t, err := strconv.ParseFloat(pts, 64)
+		if err != nil {
+			continue // N/A
+		}
+		times = append(times, t)
+	}
+	sort.Float64s(times)
+	return times
+}
+
+// lastKeyframeBefore is the latest of keyframes before t, or 0 when there is none
+func lastKeyframeBefore(keyframes []float64, t float64) float64 {
+	var cut float64
+	for _, k := range keyframes {
+		if k >= t {
+			break
+		}
+		cut = k
+	}
+	return cut
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyframeTimes(t *testing.T) {
+	output := "0.000000,K__\n0.033333,___\n2.002000,K__\nN/A,K__\n\n4.004000,K_D\n1.001000,__\n"
+	if got, want := parseKeyframeTimes(output), []float64{0, 2.002, 4.004}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeyframeTimes() = %v, want %v", got, want)
+	}
+}
+
+func TestLastKeyframeBefore(t *testing.T) {
+	keyframes := []float64{0, 2, 4}
+	tests := []struct {
+		t    float64
+		want float64
+	}{
+		{5.5, 4},
+		{4, 2}, // a cut on a keyframe still re-encodes the GOP before it
+		{3.2, 2},
+		{1.5, 0},
+	}
+	for _, tt := range tests {
+		if got := lastKeyframeBefore(keyframes, tt.t); got != tt.want {
+			t.Errorf("lastKeyframeBefore(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+	if got := lastKeyframeBefore(nil, 3); got != 0 {
+		t.Errorf("Expected 0 without keyframes, got %v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebsocketAcceptKey(t *testing.T) {
+	// Example handshake from RFC 6455 section 1.3
+	got := websocketAcceptKey("dGhlIHNhbXBsZSBub25jZQ==")
+	if got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("websocketAcceptKey = %s; want s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", got)
+	}
+}
+
+func TestUpgradeWebSocket_WriteText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := UpgradeWebSocket(w, r)
+		if err != nil {
+			t.Errorf("Upgrade failed: %v", err)
+			return
+		}
+		ws.WriteText([]byte("hello"))
+		ws.Close()
+	}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+
+	head := make([]byte, 2)
+	if _, err := reader.Read(head); err != nil {
+		t.Fatal(err)
+	}
+	if head[0] != 0x81 || int(head[1]) != len("hello") {
+		t.Fatalf("Unexpected frame header: %x", head)
+	}
+	payload := make([]byte, head[1])
+	reader.Read(payload)
+	if string(payload) != "hello" {
+		t.Errorf("Expected payload 'hello', got %q", payload)
+	}
+}
+
+func TestUpgradeWebSocket_RejectsPlainRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if _, err := UpgradeWebSocket(httptest.NewRecorder(), req); err == nil {
+		t.Error("Expected error for non-upgrade request")
+	}
+}
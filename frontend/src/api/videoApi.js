@@ -6,7 +6,7 @@ export const videoApi = {
     /**
      * Generate video from script
      * @param {Object} data - { script, voice, speaking_speed, video_style }
-     * @returns {Promise<Object>} { job_id, status }
+     * @returns {Promise<Object>} { job_id, status, download_token }
      */
     async generateVideo(data) {
         const response = await axios.post(`${API_BASE}/generate`, data)
@@ -16,20 +16,22 @@ export const videoApi = {
     /**
      * Get job status
      * @param {string} jobId - Job ID
-     * @returns {Promise<Object>} { status, progress, current_step, video_url?, error? }
+     * @param {string} token - Download token returned by generateVideo; without it the links are left out
+     * @returns {Promise<Object>} { status, progress, current_step, video_url?, subtitle_url?, error? }
      */
-    async getStatus(jobId) {
-        const response = await axios.get(`${API_BASE}/status/${jobId}`)
+    async getStatus(jobId, token) {
+        const response = await axios.get(`${API_BASE}/status/${jobId}`, { params: { token } })
         return response.data
     },
 
     /**
      * Get download URL for video
      * @param {string} jobId - Job ID
+     * @param {string} token - Download token returned by generateVideo
      * @returns {string} Download URL
      */
-    getDownloadUrl(jobId) {
-        return `${API_BASE}/download/${jobId}`
+    getDownloadUrl(jobId, token) {
+        return `${API_BASE}/download/${jobId}?token=${encodeURIComponent(token)}`
     },
 
     /**
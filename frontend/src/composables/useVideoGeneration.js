@@ -7,6 +7,7 @@ export function useVideoGeneration() {
   const currentStep = ref("");
   const jobStatus = ref("idle");
   const videoUrl = ref(null);
+  const subtitleUrl = ref(null);
   const savedPath = ref(null);
   const error = ref(null);
   const jobId = ref(null);
@@ -18,7 +19,7 @@ export function useVideoGeneration() {
 
   let pollInterval = null;
 
-  const pollStatus = async (id, checkingSeries) => {
+  const pollStatus = async (id, checkingSeries, token) => {
     // Clear existing if any
     if (pollInterval) clearInterval(pollInterval);
 
@@ -40,7 +41,7 @@ export function useVideoGeneration() {
           }
         } else {
           // Single video checking
-          const status = await videoApi.getStatus(id);
+          const status = await videoApi.getStatus(id, token);
           progress.value = status.progress;
           currentStep.value = status.current_step;
           jobStatus.value = status.status;
@@ -49,6 +50,7 @@ export function useVideoGeneration() {
             clearInterval(pollInterval);
             pollInterval = null;
             videoUrl.value = status.video_url;
+            subtitleUrl.value = status.subtitle_url || null;
             savedPath.value = status.saved_path || null;
             generating.value = false;
           } else if (status.status === "failed") {
@@ -74,6 +76,7 @@ export function useVideoGeneration() {
     currentStep.value = "Initializing...";
     jobStatus.value = "processing";
     videoUrl.value = null;
+    subtitleUrl.value = null;
     savedPath.value = null;
 
     isSeries.value = isSeriesMode;
@@ -106,7 +109,7 @@ export function useVideoGeneration() {
         });
 
         jobId.value = result.job_id;
-        pollStatus(result.job_id, false);
+        pollStatus(result.job_id, false, result.download_token);
       }
     } catch (err) {
       console.error("Failed to start generation:", err);
@@ -125,6 +128,7 @@ export function useVideoGeneration() {
     currentStep.value = "";
     jobStatus.value = "idle";
     videoUrl.value = null;
+    subtitleUrl.value = null;
     savedPath.value = null;
     error.value = null;
     jobId.value = null;
@@ -159,6 +163,7 @@ export function useVideoGeneration() {
     currentStep,
     jobStatus,
     videoUrl,
+    subtitleUrl,
     savedPath,
     error,
     jobId,